@@ -16,15 +16,49 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // EntityMatch specifies match conditions to cloud entities.
 // Cloud entities must satisfy all fields(ANDed) in EntityMatch to satisfy EntityMatch.
 type EntityMatch struct {
-	// MatchName matches cloud entities' name. If not specified, it matches any cloud entities.
+	// MatchName matches cloud entities' name. It may be a glob pattern, where '*' matches any sequence of
+	// characters and '?' matches any single character. If not specified, it matches any cloud entities.
 	MatchName string `json:"matchName,omitempty"`
 	// MatchID matches cloud entities' identifier. If not specified, it matches any cloud entities.
 	MatchID string `json:"matchID,omitempty"`
+	// MatchTags matches cloud entities' tags. A cloud entity matches if it carries every key in MatchTags
+	// with the corresponding value. If not specified, it matches any cloud entities.
+	MatchTags map[string]string `json:"matchTags,omitempty"`
+	// MatchExpressions is a list of tag match expressions, all of which must be satisfied(ANDed) by
+	// cloud entities' tags. If not specified, it matches any cloud entities.
+	MatchExpressions []TagMatchExpression `json:"matchExpressions,omitempty"`
+}
+
+// TagMatchOperator is the operator used in a TagMatchExpression.
+// +kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist
+type TagMatchOperator string
+
+const (
+	// TagMatchOperatorIn matches cloud entities having the tag key with any of the values.
+	TagMatchOperatorIn TagMatchOperator = "In"
+	// TagMatchOperatorNotIn matches cloud entities not having the tag key with any of the values.
+	TagMatchOperatorNotIn TagMatchOperator = "NotIn"
+	// TagMatchOperatorExists matches cloud entities having the tag key.
+	TagMatchOperatorExists TagMatchOperator = "Exists"
+	// TagMatchOperatorDoesNotExist matches cloud entities not having the tag key.
+	TagMatchOperatorDoesNotExist TagMatchOperator = "DoesNotExist"
+)
+
+// TagMatchExpression specifies a match condition on a cloud entity tag.
+type TagMatchExpression struct {
+	// Key is the tag key the expression applies to.
+	Key string `json:"key"`
+	// Operator represents the relationship of the tag key to the values.
+	Operator TagMatchOperator `json:"operator"`
+	// Values is the set of tag values. It must be non-empty for operators In and NotIn, and
+	// empty for operators Exists and DoesNotExist.
+	Values []string `json:"values,omitempty"`
 }
 
 // VirtualMachineSelector specifies VirtualMachine match criteria.
@@ -38,12 +72,18 @@ type VirtualMachineSelector struct {
 	// It is an array, match satisfying any item on VMMatch is selected(ORed).
 	// If it is not specified, all VirtualMachines matching VpcMatch are selected.
 	VMMatch []EntityMatch `json:"vmMatch,omitempty"`
+	// ExcludeMatch specifies VirtualMachines to exclude from those matched by VpcMatch and VMMatch.
+	// It is an array, VirtualMachines satisfying any item on ExcludeMatch are excluded(ORed).
+	ExcludeMatch []EntityMatch `json:"excludeMatch,omitempty"`
 }
 
 // CloudEntitySelectorSpec defines the desired state of CloudEntitySelector.
 type CloudEntitySelectorSpec struct {
 	// AccountName specifies cloud account in this CloudProvider.
 	AccountName string `json:"accountName,omitempty"`
+	// AccountNamespace specifies the namespace of the cloud account. If not specified, the account
+	// is expected in the same namespace as this CloudEntitySelector.
+	AccountNamespace string `json:"accountNamespace,omitempty"`
 	// VMSelector selects the VirtualMachines the user has modify privilege.
 	// VMSelector is mandatory, at least one selector under VMSelector is required.
 	// It is an array, VirtualMachines satisfying any item on VMSelector are selected(ORed).
@@ -60,6 +100,18 @@ type CloudEntitySelector struct {
 	Spec              CloudEntitySelectorSpec `json:"spec,omitempty"`
 }
 
+// GetAccountNamespacedName returns the namespaced name of the cloud account this CloudEntitySelector refers to.
+func (r *CloudEntitySelector) GetAccountNamespacedName() *types.NamespacedName {
+	namespace := r.Spec.AccountNamespace
+	if len(namespace) == 0 {
+		namespace = r.Namespace
+	}
+	return &types.NamespacedName{
+		Namespace: namespace,
+		Name:      r.Spec.AccountName,
+	}
+}
+
 // +kubebuilder:object:root=true
 
 // CloudEntitySelectorList contains a list of CloudEntitySelector.
@@ -21,7 +21,6 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -56,12 +55,10 @@ func (r *CloudEntitySelector) Default() {
 	cloudentityselectorlog.Info("default", "name", r.Name)
 
 	// make sure selector has owner reference.
-	// set owner account only if resource cloudprovideraccount with cloudentiryselector account name in this namespace exists.
+	// set owner account only if resource cloudprovideraccount with cloudentiryselector account name exists in this namespace.
+	// Owner references cannot cross namespaces, selectors referring to an account in other namespace have no owner.
 	ownerReference := metav1.GetControllerOf(r)
-	accountNameSpacedName := &types.NamespacedName{
-		Namespace: r.Namespace,
-		Name:      r.Spec.AccountName,
-	}
+	accountNameSpacedName := r.GetAccountNamespacedName()
 	ownerAccount := &CloudProviderAccount{}
 	err := client.Get(context.TODO(), *accountNameSpacedName, ownerAccount)
 	if err != nil {
@@ -81,13 +78,17 @@ func (r *CloudEntitySelector) Default() {
 				m.VpcMatch.MatchID = strings.ToLower(m.VpcMatch.MatchID)
 				m.VpcMatch.MatchName = strings.ToLower(m.VpcMatch.MatchName)
 			}
-			for _, vmMatch := range m.VMMatch {
-				vmMatch.MatchID = strings.ToLower(vmMatch.MatchID)
-				vmMatch.MatchName = strings.ToLower(vmMatch.MatchName)
+			for i := range m.VMMatch {
+				m.VMMatch[i].MatchID = strings.ToLower(m.VMMatch[i].MatchID)
+				m.VMMatch[i].MatchName = strings.ToLower(m.VMMatch[i].MatchName)
+			}
+			for i := range m.ExcludeMatch {
+				m.ExcludeMatch[i].MatchID = strings.ToLower(m.ExcludeMatch[i].MatchID)
+				m.ExcludeMatch[i].MatchName = strings.ToLower(m.ExcludeMatch[i].MatchName)
 			}
 		}
 	}
-	if ownerReference == nil {
+	if ownerReference == nil && accountNameSpacedName.Namespace == r.Namespace {
 		err = controllerutil.SetControllerReference(ownerAccount, r, sh)
 		if err != nil {
 			cloudentityselectorlog.Error(err, "failed to set owner account", "cloudentityselector", r, "account", *accountNameSpacedName)
@@ -106,14 +107,15 @@ var _ webhook.Validator = &CloudEntitySelector{}
 func (r *CloudEntitySelector) ValidateCreate() error {
 	cloudentityselectorlog.Info("validate create", "name", r.Name)
 
-	// make sure owner exists. Default will try to populate if owner with provided account name exists.
-	if r.GetObjectMeta().GetOwnerReferences() == nil {
-		return fmt.Errorf("owner account %v not found", r.Spec.AccountName)
+	// make sure account exists. Multiple cloudentityselectors may refer to the same account.
+	ownerAccount, err := r.GetOwnerAccount()
+	if err != nil {
+		return fmt.Errorf("owner account %v not found", *r.GetAccountNamespacedName())
 	}
-
-	// make sure no existing cloudentityselector has same account as this cloudentiryselector as its owner.
-	if err := r.validateNoOwnerConflict(); err != nil {
-		return err
+	// an account in another namespace must allow selectors of this namespace to import its VMs.
+	if !ownerAccount.IsSelectorNamespaceAllowed(r.Namespace) {
+		return fmt.Errorf("account %v does not allow selectors in namespace %v, see annotation %v",
+			*r.GetAccountNamespacedName(), r.Namespace, CloudProviderAccountSelectorNamespacesAnnotation)
 	}
 
 	// make sure unsupported match combinations are not configured
@@ -123,26 +125,6 @@ func (r *CloudEntitySelector) ValidateCreate() error {
 	return nil
 }
 
-// validateNoOwnerConflict makes sure no two cloudentityselectors have same account owner.
-func (r *CloudEntitySelector) validateNoOwnerConflict() error {
-	cloudEntitySelectorList := &CloudEntitySelectorList{}
-	err := client.List(context.TODO(), cloudEntitySelectorList, controllerclient.InNamespace(r.Namespace))
-	if err != nil {
-		return err
-	}
-
-	namespace := r.GetNamespace()
-	accName := r.Spec.AccountName
-	for _, item := range cloudEntitySelectorList.Items {
-		if strings.Compare(strings.TrimSpace(item.Spec.AccountName), strings.TrimSpace(accName)) == 0 {
-			return fmt.Errorf("cloudentityselector %v/%v with owner as account %v/%v already exists."+
-				"(account in a namespace can be owner of only one cloudentityselector)", namespace, item.GetName(), namespace, accName)
-		}
-	}
-
-	return nil
-}
-
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (r *CloudEntitySelector) ValidateUpdate(old runtime.Object) error {
 	cloudentityselectorlog.Info("validate update", "name", r.Name)
@@ -153,6 +135,11 @@ func (r *CloudEntitySelector) ValidateUpdate(old runtime.Object) error {
 	if strings.Compare(oldAccName, newAccountName) != 0 {
 		return fmt.Errorf("account name update not allowed (old:%v, new:%v)", oldAccName, newAccountName)
 	}
+	oldAccNamespace := old.(*CloudEntitySelector).GetAccountNamespacedName().Namespace
+	newAccNamespace := r.GetAccountNamespacedName().Namespace
+	if strings.Compare(oldAccNamespace, newAccNamespace) != 0 {
+		return fmt.Errorf("account namespace update not allowed (old:%v, new:%v)", oldAccNamespace, newAccNamespace)
+	}
 
 	// make sure unsupported match combinations are not configured
 	if err := r.validateMatchSections(); err != nil {
@@ -171,10 +158,7 @@ func (r *CloudEntitySelector) ValidateDelete() error {
 }
 
 func (r *CloudEntitySelector) GetOwnerAccount() (*CloudProviderAccount, error) {
-	accountNameSpacedName := &types.NamespacedName{
-		Namespace: r.Namespace,
-		Name:      r.Spec.AccountName,
-	}
+	accountNameSpacedName := r.GetAccountNamespacedName()
 	ownerAccount := &CloudProviderAccount{}
 	err := client.Get(context.TODO(), *accountNameSpacedName, ownerAccount)
 	if err != nil {
@@ -214,6 +198,31 @@ func (r *CloudEntitySelector) validateMatchSections() error {
 		return err
 	}
 
+	// tag matches must be well formed and supported by the cloud provider, and exclude matches must not be empty.
+	for _, m := range r.Spec.VMSelector {
+		if m.VpcMatch != nil {
+			if err := validateTagMatch(m.VpcMatch, cloudProviderType); err != nil {
+				return fmt.Errorf("invalid vpcMatch: %v", err)
+			}
+		}
+		for i := range m.VMMatch {
+			if err := validateTagMatch(&m.VMMatch[i], cloudProviderType); err != nil {
+				return fmt.Errorf("invalid vmMatch: %v", err)
+			}
+		}
+		for i := range m.ExcludeMatch {
+			excludeMatch := &m.ExcludeMatch[i]
+			if len(strings.TrimSpace(excludeMatch.MatchID)) == 0 && len(strings.TrimSpace(excludeMatch.MatchName)) == 0 &&
+				len(excludeMatch.MatchTags) == 0 && len(excludeMatch.MatchExpressions) == 0 {
+				return fmt.Errorf("invalid excludeMatch: at least one of matchID, matchName, matchTags " +
+					"and matchExpressions must be configured")
+			}
+			if err := validateTagMatch(excludeMatch, cloudProviderType); err != nil {
+				return fmt.Errorf("invalid excludeMatch: %v", err)
+			}
+		}
+	}
+
 	// In Azure, Vpc Name is not supported in vpcMatch
 	// In AWS, Vpc name(in vpcMatch section) with either vm id or vm name(in vmMatch section) is not supported
 	if cloudProviderType == AzureCloudProvider {
@@ -243,3 +252,43 @@ func (r *CloudEntitySelector) validateMatchSections() error {
 	}
 	return nil
 }
+
+// validateTagMatch checks matchTags and matchExpressions of an EntityMatch.
+func validateTagMatch(match *EntityMatch, cloudProviderType CloudProvider) error {
+	if len(match.MatchTags) == 0 && len(match.MatchExpressions) == 0 {
+		return nil
+	}
+	// GCP uses labels instead of tags, and labels are not supported yet.
+	if cloudProviderType == GCPCloudProvider {
+		return fmt.Errorf("matchTags and matchExpressions are not supported for cloud provider %v", cloudProviderType)
+	}
+	for key := range match.MatchTags {
+		if len(strings.TrimSpace(key)) == 0 {
+			return fmt.Errorf("empty key in matchTags")
+		}
+	}
+	for _, expr := range match.MatchExpressions {
+		if len(strings.TrimSpace(expr.Key)) == 0 {
+			return fmt.Errorf("empty key in matchExpressions")
+		}
+		switch expr.Operator {
+		case TagMatchOperatorIn, TagMatchOperatorNotIn:
+			if len(expr.Values) == 0 {
+				return fmt.Errorf("values must be specified for operator %v of key %v", expr.Operator, expr.Key)
+			}
+		case TagMatchOperatorExists, TagMatchOperatorDoesNotExist:
+			if len(expr.Values) != 0 {
+				return fmt.Errorf("values must not be specified for operator %v of key %v", expr.Operator, expr.Key)
+			}
+		default:
+			return fmt.Errorf("unknown operator %v of key %v", expr.Operator, expr.Key)
+		}
+		// AWS filters can only select on presence of a tag, so negative operators cannot be translated.
+		if cloudProviderType == AWSCloudProvider &&
+			(expr.Operator == TagMatchOperatorNotIn || expr.Operator == TagMatchOperatorDoesNotExist) {
+			return fmt.Errorf("operator %v of key %v is not supported for cloud provider %v",
+				expr.Operator, expr.Key, cloudProviderType)
+		}
+	}
+	return nil
+}
@@ -21,7 +21,7 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
-// +kubebuilder:validation:Enum=Azure;AWS
+// +kubebuilder:validation:Enum=Azure;AWS;GCP
 // CloudProvider specifies a cloud provider.
 type CloudProvider string
 
@@ -30,8 +30,26 @@ const (
 	AzureCloudProvider CloudProvider = "Azure"
 	// AWSCloudProvider specifies AWS.
 	AWSCloudProvider CloudProvider = "AWS"
+	// GCPCloudProvider specifies GCP.
+	GCPCloudProvider CloudProvider = "GCP"
 )
 
+// AllRegions in the regions of an account selects every region of the account.
+const AllRegions = "all"
+
+// CloudProviderAccountResyncAnnotation requests security groups of an account to be synchronized with cloud
+// immediately. A new value of the annotation triggers a new synchronization, whose completion is reported by the
+// ResyncRequest status of the account.
+const CloudProviderAccountResyncAnnotation = "cloud.antrea.io/resync"
+
+// CloudProviderAccountSelectorNamespacesAnnotation lists the namespaces, comma separated, whose CloudEntitySelectors
+// may select VMs of an account and import them, in addition to the account namespace. "*" allows all namespaces.
+const CloudProviderAccountSelectorNamespacesAnnotation = "cloud.antrea.io/selector-namespaces"
+
+// PreserveSecurityGroupsTagKey is the cloud tag of a VM overriding the PreserveSecurityGroups config of its account. Its
+// value is true or false.
+const PreserveSecurityGroupsTagKey = "nephe-preserve-security-groups"
+
 // CloudProviderAccountSpec defines the desired state of CloudProviderAccount.
 type CloudProviderAccountSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster.
@@ -39,22 +57,160 @@ type CloudProviderAccountSpec struct {
 
 	// PollIntervalInSeconds defines account poll interval (default value is 60, if not specified).
 	PollIntervalInSeconds *uint `json:"pollIntervalInSeconds,omitempty"`
+	// ResyncIntervalInSeconds defines the full inventory interval of an account with an event queue (default value is
+	// 900, if not specified). It should be >= PollIntervalInSeconds.
+	ResyncIntervalInSeconds *uint `json:"resyncIntervalInSeconds,omitempty"`
+	// CloudSyncIntervalInSeconds defines the interval security groups of the account are synchronized with cloud, if
+	// shorter than the cloud sync interval of nephe-controller. It should be >= 60.
+	CloudSyncIntervalInSeconds *uint `json:"cloudSyncIntervalInSeconds,omitempty"`
+	// DriftPolicy specifies how changes made outside of nephe-controller to cloud security groups managed by
+	// nephe-controller are handled (default value is Remediate, if not specified).
+	DriftPolicy CloudSecurityDriftPolicy `json:"driftPolicy,omitempty"`
 	// Cloud provider account config.
 	AWSConfig *CloudProviderAccountAWSConfig `json:"awsConfig,omitempty"`
 	// Cloud provider account config.
 	AzureConfig *CloudProviderAccountAzureConfig `json:"azureConfig,omitempty"`
+	// Cloud provider account config.
+	GCPConfig *CloudProviderAccountGCPConfig `json:"gcpConfig,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=Remediate;ReportOnly;Ignore
+// CloudSecurityDriftPolicy specifies how drift of cloud security groups in an account is handled.
+type CloudSecurityDriftPolicy string
+
+const (
+	// CloudSecurityDriftPolicyRemediate reports drift and reverts cloud security groups to their computed state.
+	CloudSecurityDriftPolicyRemediate CloudSecurityDriftPolicy = "Remediate"
+	// CloudSecurityDriftPolicyReportOnly reports drift and leaves cloud security groups unchanged.
+	CloudSecurityDriftPolicyReportOnly CloudSecurityDriftPolicy = "ReportOnly"
+	// CloudSecurityDriftPolicyIgnore neither reports drift nor changes cloud security groups.
+	CloudSecurityDriftPolicyIgnore CloudSecurityDriftPolicy = "Ignore"
+)
+
+// +kubebuilder:validation:Enum=Static;WebIdentity;InstanceProfile
+// AWSCredentialMode specifies how AWS credentials of an account are obtained.
+type AWSCredentialMode string
+
+const (
+	// AWSCredentialModeStatic uses access keys from the account secret, and assumes a role if one is configured.
+	AWSCredentialModeStatic AWSCredentialMode = "Static"
+	// AWSCredentialModeWebIdentity assumes a role with the projected service account token of nephe-controller.
+	AWSCredentialModeWebIdentity AWSCredentialMode = "WebIdentity"
+	// AWSCredentialModeInstanceProfile uses the instance profile of the node running nephe-controller, and assumes a
+	// role if one is configured.
+	AWSCredentialModeInstanceProfile AWSCredentialMode = "InstanceProfile"
+)
+
+// AWSDefaultMemberRoleName is the role assumed in member accounts, if MemberRoleName is not specified.
+const AWSDefaultMemberRoleName = "OrganizationAccountAccessRole"
+
 type CloudProviderAccountAWSConfig struct {
-	// Reference to k8s secret which has cloud provider credentials.
+	// CredentialMode specifies how AWS credentials are obtained (default value is Static, if not specified).
+	CredentialMode AWSCredentialMode `json:"credentialMode,omitempty"`
+	// Reference to k8s secret which has cloud provider credentials. Required in Static credential mode only.
 	SecretRef *SecretReference `json:"secretRef,omitempty"`
+	// RoleArn is the ARN of the role to assume. Required in WebIdentity credential mode. Overrides the role ARN of the
+	// secret, if any.
+	RoleArn string `json:"roleArn,omitempty"`
+	// ExternalID to assume the role with. Not supported in WebIdentity credential mode. Overrides the external ID of the
+	// secret, if any.
+	ExternalID string `json:"externalID,omitempty"`
+	// SessionDurationSeconds is the duration of assumed role sessions (default value is 900, if not specified).
+	// +kubebuilder:validation:Minimum=900
+	// +kubebuilder:validation:Maximum=43200
+	SessionDurationSeconds *int64 `json:"sessionDurationSeconds,omitempty"`
+	// WebIdentityTokenFile is the path of the projected service account token in WebIdentity credential mode (default
+	// value is the AWS_WEB_IDENTITY_TOKEN_FILE environment variable injected by EKS, if not specified).
+	WebIdentityTokenFile string `json:"webIdentityTokenFile,omitempty"`
 	// Cloud provider account region.
 	Region string `json:"region,omitempty"`
+	// Regions of the account, in addition to Region. all selects every region enabled for the account, resolved again
+	// every inventory resync interval.
+	Regions []string `json:"regions,omitempty"`
+	// MemberAccountIDs are IDs of AWS accounts, e.g. organization member accounts, whose VMs are imported with the
+	// account. MemberRoleName is assumed in each member account with the account credentials.
+	MemberAccountIDs []string `json:"memberAccountIDs,omitempty"`
+	// DiscoverMemberAccounts adds the active accounts of the AWS organization of the account, listed with Organizations
+	// ListAccounts, to the member accounts.
+	DiscoverMemberAccounts bool `json:"discoverMemberAccounts,omitempty"`
+	// MemberRoleName is the name of the role assumed in each member account (default value is
+	// OrganizationAccountAccessRole, if not specified).
+	MemberRoleName string `json:"memberRoleName,omitempty"`
+	// EventQueueURL is the URL of an SQS queue receiving EC2 instance state-change and tag-change events of the account
+	// from EventBridge. VMs are then updated from the events, and a full inventory is done every
+	// ResyncIntervalInSeconds.
+	EventQueueURL string `json:"eventQueueURL,omitempty"`
+	// PreserveSecurityGroups keeps security groups not created by nephe-controller attached to network interfaces of
+	// the account. Security groups of nephe-controller are attached and detached alongside them, and the VPC default
+	// security group is attached only to network interfaces left without any security group.
+	PreserveSecurityGroups bool `json:"preserveSecurityGroups,omitempty"`
 }
 
+// AzureCredentialMode specifies how nephe-controller authenticates with Azure.
+// +kubebuilder:validation:Enum=ClientSecret;ClientCertificate;ManagedIdentity;WorkloadIdentity
+type AzureCredentialMode string
+
+const (
+	// AzureCredentialModeClientSecret uses the client secret of a service principal from the account secret.
+	AzureCredentialModeClientSecret AzureCredentialMode = "ClientSecret"
+	// AzureCredentialModeClientCertificate uses the client certificate of a service principal from the account secret.
+	AzureCredentialModeClientCertificate AzureCredentialMode = "ClientCertificate"
+	// AzureCredentialModeManagedIdentity uses the system-assigned, or a user-assigned, managed identity of the node
+	// running nephe-controller.
+	AzureCredentialModeManagedIdentity AzureCredentialMode = "ManagedIdentity"
+	// AzureCredentialModeWorkloadIdentity exchanges the projected service account token of nephe-controller for an
+	// Azure AD token, with a federated identity credential.
+	AzureCredentialModeWorkloadIdentity AzureCredentialMode = "WorkloadIdentity"
+)
+
 type CloudProviderAccountAzureConfig struct {
+	// CredentialMode specifies how Azure credentials are obtained (default value is ClientSecret, if not specified).
+	CredentialMode AzureCredentialMode `json:"credentialMode,omitempty"`
+	// Reference to k8s secret which has cloud provider credentials. Required in ClientSecret and ClientCertificate
+	// credential modes only.
 	SecretRef *SecretReference `json:"secretRef,omitempty"`
-	Region    string           `json:"region,omitempty"`
+	// SubscriptionID of the account. Required in ManagedIdentity and WorkloadIdentity credential modes. Overrides the
+	// subscription ID of the secret, if any.
+	SubscriptionID string `json:"subscriptionID,omitempty"`
+	// SubscriptionIDs of additional subscriptions, whose VMs are imported with the account credentials.
+	SubscriptionIDs []string `json:"subscriptionIDs,omitempty"`
+	// TenantID of the account. Overrides the tenant ID of the secret, if any. In WorkloadIdentity credential mode,
+	// default value is the AZURE_TENANT_ID environment variable injected by Azure workload identity, if not specified.
+	TenantID string `json:"tenantID,omitempty"`
+	// ClientID of the application, or of the user-assigned managed identity. Overrides the client ID of the secret, if
+	// any. In WorkloadIdentity credential mode, default value is the AZURE_CLIENT_ID environment variable injected by
+	// Azure workload identity, if not specified. In ManagedIdentity credential mode, the system-assigned managed
+	// identity is used, if not specified.
+	ClientID string `json:"clientID,omitempty"`
+	// FederatedTokenFile is the path of the projected service account token in WorkloadIdentity credential mode
+	// (default value is the AZURE_FEDERATED_TOKEN_FILE environment variable injected by Azure workload identity, if not
+	// specified).
+	FederatedTokenFile string `json:"federatedTokenFile,omitempty"`
+	// Cloud provider account region.
+	Region string `json:"region,omitempty"`
+	// Regions of the account, in addition to Region. all selects every region with a virtual network in the
+	// subscription, resolved again every inventory resync interval.
+	Regions []string `json:"regions,omitempty"`
+	// EventQueueURL is the URL of a Storage queue receiving virtual machine events of the account subscriptions from
+	// Event Grid. VMs are then updated from the events, and a full inventory is done every ResyncIntervalInSeconds.
+	EventQueueURL string `json:"eventQueueURL,omitempty"`
+	// PreserveSecurityGroups keeps network security groups not created by nephe-controller attached to network
+	// interfaces of the account, only application security groups of nephe-controller are attached to them. It requires
+	// SecurityGroupName, security rules of nephe-controller are not enforced on network interfaces of other NSGs.
+	PreserveSecurityGroups bool `json:"preserveSecurityGroups,omitempty"`
+	// SecurityGroupName is the name of a network security group, in the resource group of each virtual network, that
+	// security rules of nephe-controller are merged into, instead of the network security group nephe-controller
+	// creates per virtual network. Security rules not created by nephe-controller are kept in it.
+	SecurityGroupName string `json:"securityGroupName,omitempty"`
+}
+
+type CloudProviderAccountGCPConfig struct {
+	// Reference to k8s secret which has GCP service account JSON key.
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+	// GCP project ID. Defaults to the project of the service account, if not specified.
+	ProjectID string `json:"projectID,omitempty"`
+	// Cloud provider account region.
+	Region string `json:"region,omitempty"`
 }
 
 // SecretReference is a reference to a k8s secret resource in an arbitrary namespace.
@@ -81,6 +237,58 @@ type AzureAccountCredential struct {
 	ClientID       string `json:"clientId,omitempty"`
 	TenantID       string `json:"tenantId,omitempty"`
 	ClientKey      string `json:"clientKey,omitempty"`
+	// ClientCertificate is the base64 encoded PKCS#12 bundle of the client certificate and its private key.
+	ClientCertificate         string `json:"clientCertificate,omitempty"`
+	ClientCertificatePassword string `json:"clientCertificatePassword,omitempty"`
+}
+
+// GCPAccountCredential is the format of k8s secret for gcp provider account. It is the JSON key of a GCP
+// service account, as generated by GCP.
+type GCPAccountCredential struct {
+	Type         string `json:"type,omitempty"`
+	ProjectID    string `json:"project_id,omitempty"`
+	PrivateKeyID string `json:"private_key_id,omitempty"`
+	PrivateKey   string `json:"private_key,omitempty"`
+	ClientEmail  string `json:"client_email,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	AuthURI      string `json:"auth_uri,omitempty"`
+	TokenURI     string `json:"token_uri,omitempty"`
+}
+
+const (
+	// CloudProviderAccountConditionCredentialsValid indicates whether the cloud API accepted the account credentials.
+	CloudProviderAccountConditionCredentialsValid = "CredentialsValid"
+	// CloudProviderAccountConditionInventorySynced indicates whether the last inventory poll of all cloud services
+	// succeeded.
+	CloudProviderAccountConditionInventorySynced = "InventorySynced"
+	// CloudProviderAccountConditionSecurityEnforcementReady indicates whether the account inventory is complete
+	// enough for network policies to be enforced on its VMs.
+	CloudProviderAccountConditionSecurityEnforcementReady = "SecurityEnforcementReady"
+
+	// CloudProviderAccountReasonCloudAPIAuthFailed is the reason of CredentialsValid set to False by an inventory poll
+	// whose cloud api calls are rejected for the account credentials.
+	CloudProviderAccountReasonCloudAPIAuthFailed = "CloudAPIAuthFailed"
+	// CloudProviderAccountReasonCloudAPIAuthSucceeded is the reason of CredentialsValid set back to True by an
+	// inventory poll succeeding after CloudProviderAccountReasonCloudAPIAuthFailed.
+	CloudProviderAccountReasonCloudAPIAuthSucceeded = "CloudAPIAuthSucceeded"
+)
+
+// CloudServiceStatus defines the inventory poll statistics of a cloud service of the account.
+type CloudServiceStatus struct {
+	// Name of the cloud service.
+	Name string `json:"name"`
+	// TotalPollCount is the number of inventory polls performed.
+	TotalPollCount int64 `json:"totalPollCount,omitempty"`
+	// SuccessfulPollCount is the number of inventory polls that succeeded.
+	SuccessfulPollCount int64 `json:"successfulPollCount,omitempty"`
+	// LastSuccessfulPollTime is the time of the last successful inventory poll.
+	LastSuccessfulPollTime *metav1.Time `json:"lastSuccessfulPollTime,omitempty"`
+	// LastPollError is the error of the last failed inventory poll.
+	LastPollError string `json:"lastPollError,omitempty"`
+	// LastPollErrorTime is the time of the last failed inventory poll.
+	LastPollErrorTime *metav1.Time `json:"lastPollErrorTime,omitempty"`
+	// VirtualMachineCount is the number of VMs found by the last successful inventory poll.
+	VirtualMachineCount int `json:"virtualMachineCount,omitempty"`
 }
 
 // CloudProviderAccountStatus defines the observed state of CloudProviderAccount.
@@ -89,6 +297,19 @@ type CloudProviderAccountStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	// Error is current error, if any, of the CloudProviderAccount.
 	Error string `json:"error,omitempty"`
+	// Conditions describe the current state of the CloudProviderAccount.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// LastSuccessfulPollTime is the time by which all cloud services of the account were last polled successfully.
+	LastSuccessfulPollTime *metav1.Time `json:"lastSuccessfulPollTime,omitempty"`
+	// Services is the inventory poll statistics of each cloud service of the account.
+	Services []CloudServiceStatus `json:"services,omitempty"`
+	// ResyncRequest is the value of the resync annotation of the last completed synchronization with cloud on demand.
+	ResyncRequest string `json:"resyncRequest,omitempty"`
+	// LastResyncTime is the time of the last completed synchronization with cloud on demand.
+	LastResyncTime *metav1.Time `json:"lastResyncTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
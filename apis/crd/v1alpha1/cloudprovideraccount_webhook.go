@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"k8s.io/apimachinery/pkg/types"
+	"net/url"
+	"regexp"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -40,6 +42,35 @@ var (
 
 const MinPollInterval = 30
 
+// MinCloudSyncInterval is the minimum interval security groups of an account are synchronized with cloud.
+const MinCloudSyncInterval = 60
+
+// DefaultResyncInterval is the full inventory interval of an account with an event queue, if not specified.
+const DefaultResyncInterval = 900
+
+// awsAccountIDRegex matches 12 digit AWS account IDs.
+var awsAccountIDRegex = regexp.MustCompile(`^[0-9]{12}$`)
+
+// AccountCredentialsProbeFunc checks cloud credentials of an account with the cloud.
+type AccountCredentialsProbeFunc func(account *CloudProviderAccount) error
+
+// accountCredentialsProbe, if set, rejects accounts whose credentials are not accepted by the cloud at admission.
+var accountCredentialsProbe AccountCredentialsProbeFunc
+
+// SetAccountCredentialsProbe enables strict validation of account credentials at admission with given probe.
+func SetAccountCredentialsProbe(probe AccountCredentialsProbeFunc) {
+	accountCredentialsProbe = probe
+}
+
+// accountSecretNamespace, if set, is the only namespace new account credential secrets may be referred in.
+var accountSecretNamespace string
+
+// SetAccountSecretNamespace restricts account credential secrets to given namespace, which nephe-controller
+// is allowed to read and watch secrets in. Existing accounts keep the secret namespace they refer to.
+func SetAccountSecretNamespace(namespace string) {
+	accountSecretNamespace = namespace
+}
+
 func (r *CloudProviderAccount) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	clientK8s = mgr.GetClient()
 	return ctrl.NewWebhookManagedBy(mgr).
@@ -62,6 +93,23 @@ func (r *CloudProviderAccount) Default() {
 		var defaultIntv uint = 60
 		r.Spec.PollIntervalInSeconds = &defaultIntv
 	}
+	if r.Spec.ResyncIntervalInSeconds == nil && r.hasEventQueue() {
+		var defaultResyncIntv uint = DefaultResyncInterval
+		r.Spec.ResyncIntervalInSeconds = &defaultResyncIntv
+	}
+	if len(r.Spec.DriftPolicy) == 0 {
+		r.Spec.DriftPolicy = CloudSecurityDriftPolicyRemediate
+	}
+	if r.Spec.AWSConfig != nil && len(r.Spec.AWSConfig.CredentialMode) == 0 {
+		r.Spec.AWSConfig.CredentialMode = AWSCredentialModeStatic
+	}
+	if r.Spec.AWSConfig != nil && len(r.Spec.AWSConfig.MemberRoleName) == 0 &&
+		(len(r.Spec.AWSConfig.MemberAccountIDs) != 0 || r.Spec.AWSConfig.DiscoverMemberAccounts) {
+		r.Spec.AWSConfig.MemberRoleName = AWSDefaultMemberRoleName
+	}
+	if r.Spec.AzureConfig != nil && len(r.Spec.AzureConfig.CredentialMode) == 0 {
+		r.Spec.AzureConfig.CredentialMode = AzureCredentialModeClientSecret
+	}
 }
 
 // TODO(user): change verbs to :"verbs=create;update;delete" if you want to enable deletion validation.
@@ -88,13 +136,27 @@ func (r *CloudProviderAccount) ValidateCreate() error {
 		if err := r.validateAzureAccount(); err != nil {
 			return err
 		}
+	case GCPCloudProvider:
+		if err := r.validateGCPAccount(); err != nil {
+			return err
+		}
+	}
+	if err := r.validateAccountSecretNamespace(); err != nil {
+		return err
 	}
 
 	if *r.Spec.PollIntervalInSeconds < MinPollInterval {
 		return fmt.Errorf("pollIntervalInSeconds should be >= 30. If not specified, defaults to 60")
 	}
+	if r.Spec.ResyncIntervalInSeconds != nil && *r.Spec.ResyncIntervalInSeconds < *r.Spec.PollIntervalInSeconds {
+		return fmt.Errorf("resyncIntervalInSeconds should be >= pollIntervalInSeconds. If not specified, defaults to %v",
+			DefaultResyncInterval)
+	}
+	if r.Spec.CloudSyncIntervalInSeconds != nil && *r.Spec.CloudSyncIntervalInSeconds < MinCloudSyncInterval {
+		return fmt.Errorf("cloudSyncIntervalInSeconds should be >= %v", MinCloudSyncInterval)
+	}
 
-	return nil
+	return r.probeAccountCredentials()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -115,13 +177,29 @@ func (r *CloudProviderAccount) ValidateUpdate(old runtime.Object) error {
 		if err := r.validateAzureAccount(); err != nil {
 			return err
 		}
+	case GCPCloudProvider:
+		if err := r.validateGCPAccount(); err != nil {
+			return err
+		}
+	}
+	if oldAccount, ok := old.(*CloudProviderAccount); !ok || !r.hasSameSecretNamespace(oldAccount) {
+		if err := r.validateAccountSecretNamespace(); err != nil {
+			return err
+		}
 	}
 
 	if *r.Spec.PollIntervalInSeconds < MinPollInterval {
 		return fmt.Errorf("pollIntervalInSeconds should be >= 30. If not specified, defaults to 60")
 	}
+	if r.Spec.ResyncIntervalInSeconds != nil && *r.Spec.ResyncIntervalInSeconds < *r.Spec.PollIntervalInSeconds {
+		return fmt.Errorf("resyncIntervalInSeconds should be >= pollIntervalInSeconds. If not specified, defaults to %v",
+			DefaultResyncInterval)
+	}
+	if r.Spec.CloudSyncIntervalInSeconds != nil && *r.Spec.CloudSyncIntervalInSeconds < MinCloudSyncInterval {
+		return fmt.Errorf("cloudSyncIntervalInSeconds should be >= %v", MinCloudSyncInterval)
+	}
 
-	return nil
+	return r.probeAccountCredentials()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
@@ -132,17 +210,136 @@ func (r *CloudProviderAccount) ValidateDelete() error {
 	return nil
 }
 
+// probeAccountCredentials checks account credentials with the cloud, if strict validation is enabled.
+func (r *CloudProviderAccount) probeAccountCredentials() error {
+	if accountCredentialsProbe == nil {
+		return nil
+	}
+	if err := accountCredentialsProbe(r); err != nil {
+		return fmt.Errorf("cloud credentials rejected by cloud provider: %v", err)
+	}
+	return nil
+}
+
+// validateAccountSecretNamespace rejects accounts referring to secrets outside of the namespace nephe-controller
+// reads secrets in.
+func (r *CloudProviderAccount) validateAccountSecretNamespace() error {
+	secretRef := r.GetAccountSecretRef()
+	if len(accountSecretNamespace) == 0 || secretRef == nil || secretRef.Namespace == accountSecretNamespace {
+		return nil
+	}
+	return fmt.Errorf("secretRef namespace %v is not supported, account credential secrets must be in namespace %v",
+		secretRef.Namespace, accountSecretNamespace)
+}
+
+// hasSameSecretNamespace returns true if the account refers to a secret in the same namespace as old account, so that
+// accounts created before the secret namespace is restricted can still be updated.
+func (r *CloudProviderAccount) hasSameSecretNamespace(old *CloudProviderAccount) bool {
+	secretRef, oldSecretRef := r.GetAccountSecretRef(), old.GetAccountSecretRef()
+	return secretRef != nil && oldSecretRef != nil && secretRef.Namespace == oldSecretRef.Namespace
+}
+
+// IsSelectorNamespaceAllowed returns true if CloudEntitySelectors in namespace may select VMs of the account, i.e.
+// namespace is the account namespace or is allowed by its CloudProviderAccountSelectorNamespacesAnnotation.
+func (r *CloudProviderAccount) IsSelectorNamespaceAllowed(namespace string) bool {
+	if namespace == r.Namespace {
+		return true
+	}
+	for _, allowed := range strings.Split(r.Annotations[CloudProviderAccountSelectorNamespacesAnnotation], ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAccountSecretRef returns the reference to the secret holding account credentials.
+func (r *CloudProviderAccount) GetAccountSecretRef() *SecretReference {
+	if r.Spec.AWSConfig != nil {
+		return r.Spec.AWSConfig.SecretRef
+	} else if r.Spec.AzureConfig != nil {
+		return r.Spec.AzureConfig.SecretRef
+	} else if r.Spec.GCPConfig != nil {
+		return r.Spec.GCPConfig.SecretRef
+	}
+	return nil
+}
+
 func (r *CloudProviderAccount) GetAccountProviderType() (CloudProvider, error) {
 	if r.Spec.AWSConfig != nil {
 		return AWSCloudProvider, nil
 	} else if r.Spec.AzureConfig != nil {
 		return AzureCloudProvider, nil
+	} else if r.Spec.GCPConfig != nil {
+		return GCPCloudProvider, nil
 	} else {
-		return "", fmt.Errorf("missing cloud provider config. Please add AWS or Azure Config")
+		return "", fmt.Errorf("missing cloud provider config. Please add AWS, Azure or GCP Config")
 	}
 }
 
 func (r *CloudProviderAccount) validateAWSAccount() error {
+	awsConfig := r.Spec.AWSConfig
+
+	switch awsConfig.CredentialMode {
+	case AWSCredentialModeStatic, "":
+		if err := validateAWSStaticCredentials(awsConfig); err != nil {
+			return err
+		}
+	case AWSCredentialModeWebIdentity:
+		if awsConfig.SecretRef != nil {
+			return fmt.Errorf("secretRef is supported in %v credential mode only", AWSCredentialModeStatic)
+		}
+		if len(strings.TrimSpace(awsConfig.RoleArn)) == 0 {
+			return fmt.Errorf("roleArn must be specified in %v credential mode", AWSCredentialModeWebIdentity)
+		}
+		if len(strings.TrimSpace(awsConfig.ExternalID)) != 0 {
+			return fmt.Errorf("externalID is not supported in %v credential mode", AWSCredentialModeWebIdentity)
+		}
+	case AWSCredentialModeInstanceProfile:
+		if awsConfig.SecretRef != nil {
+			return fmt.Errorf("secretRef is supported in %v credential mode only", AWSCredentialModeStatic)
+		}
+	default:
+		return fmt.Errorf("credential mode %v not supported", awsConfig.CredentialMode)
+	}
+
+	accountRegions := getAccountRegions(awsConfig.Region, awsConfig.Regions)
+	if len(accountRegions) == 0 {
+		return fmt.Errorf("region cannot be blank or empty")
+	}
+
+	// NOTE: currently only AWS standard partition regions supported (aws-cn, aws-us-gov etc are not
+	// supported). As we add support for other partitions, validation needs to be updated
+	regions := endpoints.AwsPartition().Regions()
+	for _, region := range accountRegions {
+		if region == AllRegions {
+			continue
+		}
+		if _, found := regions[region]; !found {
+			var supportedRegions []string
+			for key := range regions {
+				supportedRegions = append(supportedRegions, key)
+			}
+			return fmt.Errorf("%v not in supported regions [%v]", region, supportedRegions)
+		}
+	}
+
+	for _, accountID := range awsConfig.MemberAccountIDs {
+		if !awsAccountIDRegex.MatchString(strings.TrimSpace(accountID)) {
+			return fmt.Errorf("member account ID %v is not a 12 digit AWS account ID", accountID)
+		}
+	}
+
+	return validateEventQueueURL(awsConfig.EventQueueURL)
+}
+
+// validateAWSStaticCredentials validates access keys, or role ARN, of an AWS account in Static credential mode.
+func validateAWSStaticCredentials(awsConfig *CloudProviderAccountAWSConfig) error {
+	if awsConfig.SecretRef == nil {
+		return fmt.Errorf("secretRef must be specified in %v credential mode", AWSCredentialModeStatic)
+	}
+
 	u := &unstructured.Unstructured{}
 	u.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   "",
@@ -150,8 +347,6 @@ func (r *CloudProviderAccount) validateAWSAccount() error {
 		Version: "v1",
 	})
 
-	awsConfig := r.Spec.AWSConfig
-
 	err := clientK8s.Get(context.TODO(), types.NamespacedName{
 		Namespace: awsConfig.SecretRef.Namespace,
 		Name:      awsConfig.SecretRef.Name}, u)
@@ -169,32 +364,84 @@ func (r *CloudProviderAccount) validateAWSAccount() error {
 		return fmt.Errorf("unable to unmarshal the json: %s", err.Error())
 	}
 	// validate roleArn or A
-	if len(strings.TrimSpace(awsCredential.RoleArn)) != 0 {
+	if len(strings.TrimSpace(awsCredential.RoleArn)) != 0 || len(strings.TrimSpace(awsConfig.RoleArn)) != 0 {
 		cloudprovideraccountlog.Info("Role ARN configured will be used for cloud-account access")
 	} else if len(strings.TrimSpace(awsCredential.AccessKeyID)) == 0 || len(strings.TrimSpace(awsCredential.AccessKeySecret)) == 0 {
 		return fmt.Errorf("must specify either credentials or role arn, cannot both be empty")
 	}
+	return nil
+}
 
-	if len(strings.TrimSpace(awsConfig.Region)) == 0 {
+func (r *CloudProviderAccount) validateAzureAccount() error {
+	azureConfig := r.Spec.AzureConfig
+
+	switch azureConfig.CredentialMode {
+	case AzureCredentialModeClientSecret, AzureCredentialModeClientCertificate, "":
+		if err := validateAzureSecretCredentials(azureConfig); err != nil {
+			return err
+		}
+	case AzureCredentialModeManagedIdentity, AzureCredentialModeWorkloadIdentity:
+		if azureConfig.SecretRef != nil {
+			return fmt.Errorf("secretRef is not supported in %v credential mode", azureConfig.CredentialMode)
+		}
+		if len(strings.TrimSpace(azureConfig.SubscriptionID)) == 0 {
+			return fmt.Errorf("subscriptionID must be specified in %v credential mode", azureConfig.CredentialMode)
+		}
+	default:
+		return fmt.Errorf("credential mode %v not supported", azureConfig.CredentialMode)
+	}
+
+	// validate region
+	if len(getAccountRegions(azureConfig.Region, azureConfig.Regions)) == 0 {
 		return fmt.Errorf("region cannot be blank or empty")
 	}
 
-	// NOTE: currently only AWS standard partition regions supported (aws-cn, aws-us-gov etc are not
-	// supported). As we add support for other partitions, validation needs to be updated
-	regions := endpoints.AwsPartition().Regions()
-	_, found := regions[awsConfig.Region]
-	if !found {
-		var supportedRegions []string
-		for key := range regions {
-			supportedRegions = append(supportedRegions, key)
-		}
-		return fmt.Errorf("%v not in supported regions [%v]", awsConfig.Region, supportedRegions)
+	// security rules of nephe-controller are only enforced on network interfaces of the NSG holding them.
+	if azureConfig.PreserveSecurityGroups && len(strings.TrimSpace(azureConfig.SecurityGroupName)) == 0 {
+		return fmt.Errorf("securityGroupName must be specified with preserveSecurityGroups")
 	}
 
+	return validateEventQueueURL(azureConfig.EventQueueURL)
+}
+
+// hasEventQueue returns true if an event queue is configured for the account.
+func (r *CloudProviderAccount) hasEventQueue() bool {
+	return (r.Spec.AWSConfig != nil && len(strings.TrimSpace(r.Spec.AWSConfig.EventQueueURL)) != 0) ||
+		(r.Spec.AzureConfig != nil && len(strings.TrimSpace(r.Spec.AzureConfig.EventQueueURL)) != 0)
+}
+
+// validateEventQueueURL validates the event queue URL of an account, if any, is an https URL of a queue.
+func validateEventQueueURL(eventQueueURL string) error {
+	eventQueueURL = strings.TrimSpace(eventQueueURL)
+	if len(eventQueueURL) == 0 {
+		return nil
+	}
+	u, err := url.Parse(eventQueueURL)
+	if err != nil || u.Scheme != "https" || len(u.Host) == 0 || len(strings.Trim(u.Path, "/")) == 0 {
+		return fmt.Errorf("eventQueueURL %v is not an https URL of a queue", eventQueueURL)
+	}
 	return nil
 }
 
-func (r *CloudProviderAccount) validateAzureAccount() error {
+// getAccountRegions returns the non-empty regions of an account, from its region and its list of regions.
+func getAccountRegions(region string, regions []string) []string {
+	var accountRegions []string
+	for _, r := range append([]string{region}, regions...) {
+		if r = strings.TrimSpace(r); len(r) != 0 {
+			accountRegions = append(accountRegions, r)
+		}
+	}
+	return accountRegions
+}
+
+// validateAzureSecretCredentials validates service principal credentials of an Azure account in ClientSecret or
+// ClientCertificate credential mode.
+func validateAzureSecretCredentials(azureConfig *CloudProviderAccountAzureConfig) error {
+	if azureConfig.SecretRef == nil {
+		return fmt.Errorf("secretRef must be specified in %v and %v credential modes", AzureCredentialModeClientSecret,
+			AzureCredentialModeClientCertificate)
+	}
+
 	u := &unstructured.Unstructured{}
 	u.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   "",
@@ -202,8 +449,6 @@ func (r *CloudProviderAccount) validateAzureAccount() error {
 		Version: "v1",
 	})
 
-	azureConfig := r.Spec.AzureConfig
-
 	err := clientK8s.Get(context.TODO(), types.NamespacedName{
 		Namespace: azureConfig.SecretRef.Namespace,
 		Name:      azureConfig.SecretRef.Name}, u)
@@ -222,20 +467,71 @@ func (r *CloudProviderAccount) validateAzureAccount() error {
 	}
 
 	// validate subscription ID
-	if len(strings.TrimSpace(azureCredential.SubscriptionID)) == 0 {
+	if len(strings.TrimSpace(azureCredential.SubscriptionID)) == 0 && len(strings.TrimSpace(azureConfig.SubscriptionID)) == 0 {
 		return fmt.Errorf("subscription id cannot be blank or empty")
 	}
 	// validate tenant ID
-	if len(strings.TrimSpace(azureCredential.TenantID)) == 0 {
+	if len(strings.TrimSpace(azureCredential.TenantID)) == 0 && len(strings.TrimSpace(azureConfig.TenantID)) == 0 {
 		return fmt.Errorf("tenant id cannot be blank or empty")
 	}
+	// validate client ID
+	if len(strings.TrimSpace(azureCredential.ClientID)) == 0 && len(strings.TrimSpace(azureConfig.ClientID)) == 0 {
+		return fmt.Errorf("client id cannot be blank or empty")
+	}
+	// validate credentials
+	if azureConfig.CredentialMode == AzureCredentialModeClientCertificate {
+		if len(strings.TrimSpace(azureCredential.ClientCertificate)) == 0 {
+			return fmt.Errorf("client certificate cannot be blank or empty in %v credential mode",
+				AzureCredentialModeClientCertificate)
+		}
+	} else if len(strings.TrimSpace(azureCredential.ClientKey)) == 0 {
+		return fmt.Errorf("client key cannot be blank or empty in %v credential mode", AzureCredentialModeClientSecret)
+	}
+	return nil
+}
+
+func (r *CloudProviderAccount) validateGCPAccount() error {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "",
+		Kind:    "Secret",
+		Version: "v1",
+	})
+
+	gcpConfig := r.Spec.GCPConfig
+
+	err := clientK8s.Get(context.TODO(), types.NamespacedName{
+		Namespace: gcpConfig.SecretRef.Namespace,
+		Name:      gcpConfig.SecretRef.Name}, u)
+	if err != nil {
+		return fmt.Errorf("unable to get secret: %s", err.Error())
+	}
+	data := u.Object["data"].(map[string]interface{})
+	decode, err := base64.StdEncoding.DecodeString(data[gcpConfig.SecretRef.Key].(string))
+	if err != nil {
+		return fmt.Errorf("unable to decode the secret: %s", err.Error())
+	}
+
+	gcpCredential := &GCPAccountCredential{}
+	if err = json.Unmarshal(decode, gcpCredential); err != nil {
+		return fmt.Errorf("unable to unmarshal the json: %s", err.Error())
+	}
+
+	// validate key type, only service account keys are supported.
+	if gcpCredential.Type != "service_account" {
+		return fmt.Errorf("credential type %q not supported, must be service_account", gcpCredential.Type)
+	}
+	// validate project ID
+	if len(strings.TrimSpace(gcpConfig.ProjectID)) == 0 && len(strings.TrimSpace(gcpCredential.ProjectID)) == 0 {
+		return fmt.Errorf("project id cannot be blank or empty")
+	}
 	// validate credentials
-	if len(strings.TrimSpace(azureCredential.ClientID)) == 0 || len(strings.TrimSpace(azureCredential.ClientKey)) == 0 {
-		return fmt.Errorf("must specify either credentials or managed identity client id, cannot both be empty")
+	if len(strings.TrimSpace(gcpCredential.ClientEmail)) == 0 || len(strings.TrimSpace(gcpCredential.PrivateKey)) == 0 {
+		return fmt.Errorf("must specify service account client email and private key, cannot be empty")
 	}
 
 	// validate region
-	if len(strings.TrimSpace(azureConfig.Region)) == 0 {
+	if len(strings.TrimSpace(gcpConfig.Region)) == 0 {
 		return fmt.Errorf("region cannot be blank or empty")
 	}
 
@@ -59,6 +59,13 @@ func (this VMState) String() string {
 	}
 }
 
+type VMOSType string
+
+const (
+	Linux   VMOSType = "linux"
+	Windows VMOSType = "windows"
+)
+
 type IPAddress struct {
 	AddressType AddressType `json:"addressType"`
 	Address     string      `json:"address"`
@@ -69,6 +76,8 @@ type NetworkInterface struct {
 	Name string `json:"name,omitempty"`
 	// Hardware address of the interface.
 	MAC string `json:"mac,omitempty"`
+	// Subnet is the cloud subnet of the interface.
+	Subnet string `json:"subnet,omitempty"`
 	// IP addresses of this NetworkInterface.
 	IPs []IPAddress `json:"ips,omitempty"`
 }
@@ -80,12 +89,32 @@ type VirtualMachineStatus struct {
 	Provider CloudProvider `json:"provider,omitempty"`
 	// VirtualPrivateCloud is the virtual private cloud this VirtualMachine belongs to.
 	VirtualPrivateCloud string `json:"virtualPrivateCloud,omitempty"`
+	// Region is the cloud region this VirtualMachine belongs to.
+	Region string `json:"region,omitempty"`
+	// Zone is the cloud availability zone this VirtualMachine belongs to.
+	Zone string `json:"zone,omitempty"`
+	// Subnet is the cloud subnet of the primary network interface of this VirtualMachine.
+	Subnet string `json:"subnet,omitempty"`
+	// InstanceType is the cloud instance type, or size, of this VirtualMachine.
+	InstanceType string `json:"instanceType,omitempty"`
+	// Image is the cloud image this VirtualMachine is launched from.
+	Image string `json:"image,omitempty"`
+	// OSType is the operating system type of this VirtualMachine.
+	OSType VMOSType `json:"osType,omitempty"`
+	// SecurityGroups are names of the cloud security groups attached to this VirtualMachine.
+	SecurityGroups []string `json:"securityGroups,omitempty"`
 	// Tags of this VirtualMachine. A corresponding label is also generated for each tag.
 	Tags map[string]string `json:"tags,omitempty"`
 	// NetworkInterfaces is array of NetworkInterfaces attached to this VirtualMachine.
 	NetworkInterfaces []NetworkInterface `json:"networkInterfaces,omitempty"`
 	// State indicates current state of the VirtualMachine.
 	State VMState `json:"state,omitempty"`
+	// LaunchTime is the time this VirtualMachine was launched, as reported by cloud.
+	LaunchTime *metav1.Time `json:"launchTime,omitempty"`
+	// StateTransitionTime is the time State of this VirtualMachine last changed, as observed by Nephe.
+	StateTransitionTime *metav1.Time `json:"stateTransitionTime,omitempty"`
+	// Error is current error, if any, of the VirtualMachine.
+	Error string `json:"error,omitempty"`
 }
 
 // +genclient
@@ -96,6 +125,9 @@ type VirtualMachineStatus struct {
 // +kubebuilder:printcolumn:name="Cloud-Provider",type=string,JSONPath=`.status.provider`
 // +kubebuilder:printcolumn:name="Virtual-Private-Cloud",type=string,JSONPath=`.status.virtualPrivateCloud`
 // +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.status.zone`,priority=1
+// +kubebuilder:printcolumn:name="Instance-Type",type=string,JSONPath=`.status.instanceType`,priority=1
+// +kubebuilder:printcolumn:name="OS",type=string,JSONPath=`.status.osType`,priority=1
 // VirtualMachine is the Schema for the virtualmachines API
 // A virtualMachine object is created automatically based on
 // matching criteria specification of CloudEntitySelector.
@@ -20,7 +20,8 @@
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -139,7 +140,7 @@ func (in *CloudProviderAccount) DeepCopyInto(out *CloudProviderAccount) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccount.
@@ -168,6 +169,21 @@ func (in *CloudProviderAccountAWSConfig) DeepCopyInto(out *CloudProviderAccountA
 		*out = new(SecretReference)
 		**out = **in
 	}
+	if in.SessionDurationSeconds != nil {
+		in, out := &in.SessionDurationSeconds, &out.SessionDurationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MemberAccountIDs != nil {
+		in, out := &in.MemberAccountIDs, &out.MemberAccountIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccountAWSConfig.
@@ -188,6 +204,16 @@ func (in *CloudProviderAccountAzureConfig) DeepCopyInto(out *CloudProviderAccoun
 		*out = new(SecretReference)
 		**out = **in
 	}
+	if in.SubscriptionIDs != nil {
+		in, out := &in.SubscriptionIDs, &out.SubscriptionIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccountAzureConfig.
@@ -200,6 +226,26 @@ func (in *CloudProviderAccountAzureConfig) DeepCopy() *CloudProviderAccountAzure
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudProviderAccountGCPConfig) DeepCopyInto(out *CloudProviderAccountGCPConfig) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccountGCPConfig.
+func (in *CloudProviderAccountGCPConfig) DeepCopy() *CloudProviderAccountGCPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudProviderAccountGCPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudProviderAccountList) DeepCopyInto(out *CloudProviderAccountList) {
 	*out = *in
@@ -240,6 +286,16 @@ func (in *CloudProviderAccountSpec) DeepCopyInto(out *CloudProviderAccountSpec)
 		*out = new(uint)
 		**out = **in
 	}
+	if in.ResyncIntervalInSeconds != nil {
+		in, out := &in.ResyncIntervalInSeconds, &out.ResyncIntervalInSeconds
+		*out = new(uint)
+		**out = **in
+	}
+	if in.CloudSyncIntervalInSeconds != nil {
+		in, out := &in.CloudSyncIntervalInSeconds, &out.CloudSyncIntervalInSeconds
+		*out = new(uint)
+		**out = **in
+	}
 	if in.AWSConfig != nil {
 		in, out := &in.AWSConfig, &out.AWSConfig
 		*out = new(CloudProviderAccountAWSConfig)
@@ -250,6 +306,11 @@ func (in *CloudProviderAccountSpec) DeepCopyInto(out *CloudProviderAccountSpec)
 		*out = new(CloudProviderAccountAzureConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.GCPConfig != nil {
+		in, out := &in.GCPConfig, &out.GCPConfig
+		*out = new(CloudProviderAccountGCPConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccountSpec.
@@ -265,6 +326,28 @@ func (in *CloudProviderAccountSpec) DeepCopy() *CloudProviderAccountSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudProviderAccountStatus) DeepCopyInto(out *CloudProviderAccountStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSuccessfulPollTime != nil {
+		in, out := &in.LastSuccessfulPollTime, &out.LastSuccessfulPollTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]CloudServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastResyncTime != nil {
+		in, out := &in.LastResyncTime, &out.LastResyncTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderAccountStatus.
@@ -277,9 +360,46 @@ func (in *CloudProviderAccountStatus) DeepCopy() *CloudProviderAccountStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudServiceStatus) DeepCopyInto(out *CloudServiceStatus) {
+	*out = *in
+	if in.LastSuccessfulPollTime != nil {
+		in, out := &in.LastSuccessfulPollTime, &out.LastSuccessfulPollTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastPollErrorTime != nil {
+		in, out := &in.LastPollErrorTime, &out.LastPollErrorTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudServiceStatus.
+func (in *CloudServiceStatus) DeepCopy() *CloudServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EntityMatch) DeepCopyInto(out *EntityMatch) {
 	*out = *in
+	if in.MatchTags != nil {
+		in, out := &in.MatchTags, &out.MatchTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]TagMatchExpression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntityMatch.
@@ -292,6 +412,21 @@ func (in *EntityMatch) DeepCopy() *EntityMatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPAccountCredential) DeepCopyInto(out *GCPAccountCredential) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPAccountCredential.
+func (in *GCPAccountCredential) DeepCopy() *GCPAccountCredential {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPAccountCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAddress) DeepCopyInto(out *IPAddress) {
 	*out = *in
@@ -342,6 +477,26 @@ func (in *SecretReference) DeepCopy() *SecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TagMatchExpression) DeepCopyInto(out *TagMatchExpression) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TagMatchExpression.
+func (in *TagMatchExpression) DeepCopy() *TagMatchExpression {
+	if in == nil {
+		return nil
+	}
+	out := new(TagMatchExpression)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachine) DeepCopyInto(out *VirtualMachine) {
 	*out = *in
@@ -406,12 +561,21 @@ func (in *VirtualMachineSelector) DeepCopyInto(out *VirtualMachineSelector) {
 	if in.VpcMatch != nil {
 		in, out := &in.VpcMatch, &out.VpcMatch
 		*out = new(EntityMatch)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.VMMatch != nil {
 		in, out := &in.VMMatch, &out.VMMatch
 		*out = make([]EntityMatch, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExcludeMatch != nil {
+		in, out := &in.ExcludeMatch, &out.ExcludeMatch
+		*out = make([]EntityMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -428,6 +592,11 @@ func (in *VirtualMachineSelector) DeepCopy() *VirtualMachineSelector {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachineStatus) DeepCopyInto(out *VirtualMachineStatus) {
 	*out = *in
+	if in.SecurityGroups != nil {
+		in, out := &in.SecurityGroups, &out.SecurityGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Tags != nil {
 		in, out := &in.Tags, &out.Tags
 		*out = make(map[string]string, len(*in))
@@ -442,6 +611,14 @@ func (in *VirtualMachineStatus) DeepCopyInto(out *VirtualMachineStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LaunchTime != nil {
+		in, out := &in.LaunchTime, &out.LaunchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StateTransitionTime != nil {
+		in, out := &in.StateTransitionTime, &out.StateTransitionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualMachineStatus.
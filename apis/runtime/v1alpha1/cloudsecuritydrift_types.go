@@ -0,0 +1,69 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CloudSecurityDiscrepancy is a discrepancy between a cloud SecurityGroup realized by the controller and the
+// SecurityGroup in cloud.
+type CloudSecurityDiscrepancy struct {
+	// Type is one of ExtraRule, MissingRule, ForeignMember, MissingMember and DeletedSecurityGroup.
+	Type string `json:"type"`
+	// Item is the rule, member or SecurityGroup of the discrepancy.
+	Item string `json:"item"`
+}
+
+// CloudSecurityDriftStatus defines the observed state of CloudSecurityDrift.
+type CloudSecurityDriftStatus struct {
+	// SecurityGroup is the name of the SecurityGroup in cloud.
+	SecurityGroup string `json:"securityGroup,omitempty"`
+	// VPC is the VPC of the SecurityGroup.
+	VPC string `json:"vpc,omitempty"`
+	// MembershipOnly is true if the SecurityGroup tracks membership of an AddressGroup only.
+	MembershipOnly bool `json:"membershipOnly,omitempty"`
+	// Account is the namespaced name of the CloudProviderAccount managing the SecurityGroup.
+	Account string `json:"account,omitempty"`
+	// Policy is the drift policy of the account, one of Remediate and ReportOnly.
+	Policy string `json:"policy,omitempty"`
+	// Discrepancies are the discrepancies detected on the last synchronization with cloud.
+	Discrepancies []CloudSecurityDiscrepancy `json:"discrepancies,omitempty"`
+	// DetectionTime is the time the drift is first detected.
+	DetectionTime metav1.Time `json:"detectionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// CloudSecurityDrift is the Schema for the CloudSecurityDrift API.
+// A CloudSecurityDrift object is converted from cloud.CloudSecurityDrift for external access.
+type CloudSecurityDrift struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status CloudSecurityDriftStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CloudSecurityDriftList contains a list of CloudSecurityDrift.
+type CloudSecurityDriftList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CloudSecurityDrift `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CloudSecurityDrift{}, &CloudSecurityDriftList{})
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type SecurityGroupOperation string
+
+const (
+	SecurityGroupCreate SecurityGroupOperation = "Create"
+	SecurityGroupUpdate SecurityGroupOperation = "Update"
+	SecurityGroupDelete SecurityGroupOperation = "Delete"
+)
+
+// SecurityGroupPlanStatus defines the observed state of SecurityGroupPlan.
+type SecurityGroupPlanStatus struct {
+	// SecurityGroup is the name of the SecurityGroup in cloud.
+	SecurityGroup string `json:"securityGroup,omitempty"`
+	// VPC is the VPC of the SecurityGroup.
+	VPC string `json:"vpc,omitempty"`
+	// MembershipOnly is true if the SecurityGroup tracks membership of an AddressGroup only.
+	MembershipOnly bool `json:"membershipOnly,omitempty"`
+	// Operation is the operation to be performed on the SecurityGroup.
+	Operation SecurityGroupOperation `json:"operation,omitempty"`
+	// MembersToAdd are cloud resources to be attached to the SecurityGroup.
+	MembersToAdd []string `json:"membersToAdd,omitempty"`
+	// MembersToRemove are cloud resources to be detached from the SecurityGroup.
+	MembersToRemove []string `json:"membersToRemove,omitempty"`
+	// RulesToAdd are rules to be added to the SecurityGroup.
+	RulesToAdd []string `json:"rulesToAdd,omitempty"`
+	// RulesToRevoke are rules to be revoked from the SecurityGroup.
+	RulesToRevoke []string `json:"rulesToRevoke,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// SecurityGroupPlan is the Schema for the SecurityGroupPlan API.
+// A SecurityGroupPlan object is the change to a cloud SecurityGroup computed by the controller in dry-run mode,
+// against the SecurityGroup enforced in cloud.
+type SecurityGroupPlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status SecurityGroupPlanStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// SecurityGroupPlanList contains a list of SecurityGroupPlan.
+type SecurityGroupPlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecurityGroupPlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecurityGroupPlan{}, &SecurityGroupPlanList{})
+}
@@ -38,7 +38,8 @@ type NetworkPolicyStatus struct {
 type VirtualMachinePolicyStatus struct {
 	// Realization shows the summary of applied network policy status.
 	Realization Realization `json:"realization,omitempty"`
-	// NetworkPolicyDetails shows all the statuses of applied network policies.
+	// NetworkPolicyDetails shows all the statuses of applied network policies,
+	// keyed by <policy type>:<policy name>, e.g. AntreaClusterNetworkPolicy:acnp-baseline.
 	NetworkPolicyDetails map[string]*NetworkPolicyStatus `json:"networkPolicyStatus,omitempty"`
 }
 
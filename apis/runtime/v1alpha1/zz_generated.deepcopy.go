@@ -23,6 +23,100 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudSecurityDiscrepancy) DeepCopyInto(out *CloudSecurityDiscrepancy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudSecurityDiscrepancy.
+func (in *CloudSecurityDiscrepancy) DeepCopy() *CloudSecurityDiscrepancy {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudSecurityDiscrepancy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudSecurityDrift) DeepCopyInto(out *CloudSecurityDrift) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudSecurityDrift.
+func (in *CloudSecurityDrift) DeepCopy() *CloudSecurityDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudSecurityDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudSecurityDrift) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudSecurityDriftList) DeepCopyInto(out *CloudSecurityDriftList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CloudSecurityDrift, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudSecurityDriftList.
+func (in *CloudSecurityDriftList) DeepCopy() *CloudSecurityDriftList {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudSecurityDriftList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CloudSecurityDriftList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudSecurityDriftStatus) DeepCopyInto(out *CloudSecurityDriftStatus) {
+	*out = *in
+	if in.Discrepancies != nil {
+		in, out := &in.Discrepancies, &out.Discrepancies
+		*out = make([]CloudSecurityDiscrepancy, len(*in))
+		copy(*out, *in)
+	}
+	in.DetectionTime.DeepCopyInto(&out.DetectionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudSecurityDriftStatus.
+func (in *CloudSecurityDriftStatus) DeepCopy() *CloudSecurityDriftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudSecurityDriftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyStatus) DeepCopyInto(out *NetworkPolicyStatus) {
 	*out = *in
@@ -38,6 +132,99 @@ func (in *NetworkPolicyStatus) DeepCopy() *NetworkPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityGroupPlan) DeepCopyInto(out *SecurityGroupPlan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGroupPlan.
+func (in *SecurityGroupPlan) DeepCopy() *SecurityGroupPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityGroupPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecurityGroupPlan) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityGroupPlanList) DeepCopyInto(out *SecurityGroupPlanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecurityGroupPlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGroupPlanList.
+func (in *SecurityGroupPlanList) DeepCopy() *SecurityGroupPlanList {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityGroupPlanList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecurityGroupPlanList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityGroupPlanStatus) DeepCopyInto(out *SecurityGroupPlanStatus) {
+	*out = *in
+	if in.MembersToAdd != nil {
+		in, out := &in.MembersToAdd, &out.MembersToAdd
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MembersToRemove != nil {
+		in, out := &in.MembersToRemove, &out.MembersToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RulesToAdd != nil {
+		in, out := &in.RulesToAdd, &out.RulesToAdd
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RulesToRevoke != nil {
+		in, out := &in.RulesToRevoke, &out.RulesToRevoke
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGroupPlanStatus.
+func (in *SecurityGroupPlanStatus) DeepCopy() *SecurityGroupPlanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityGroupPlanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachinePolicy) DeepCopyInto(out *VirtualMachinePolicy) {
 	*out = *in
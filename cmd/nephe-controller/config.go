@@ -19,4 +19,12 @@ const (
 	defaultLeaderElectionFlag = false
 	defaultMetricsAddress     = ":8080"
 	defaultDebugLogFlag       = false
+	defaultStrictCredentials  = false
+	defaultDryRun             = false
+	defaultRestrictSecrets    = false
+	// defaultNepheNamespace is the namespace of nephe-controller, if not set by podNamespaceEnv.
+	defaultNepheNamespace = "nephe-system"
+	// podNamespaceEnv is the environment variable holding the namespace of nephe-controller, which holds cloud
+	// account credential secrets.
+	podNamespaceEnv = "POD_NAMESPACE"
 )
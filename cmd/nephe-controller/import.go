@@ -0,0 +1,160 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	crdv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	cloudprovider "antrea.io/nephe/pkg/cloud-provider"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/converter/target"
+	"antrea.io/nephe/pkg/logging"
+)
+
+const importSecurityGroupsCommand = "import-security-groups"
+
+// runImportSecurityGroups writes Antrea NetworkPolicies equivalent to cloud security groups of a virtual private cloud
+// of a CloudProviderAccount to out as YAML, and settings of the security groups they do not represent to report.
+func runImportSecurityGroups(args []string, out, report io.Writer) error {
+	var kubeconfig, account, vpcID, namespace string
+	var enableDebugLog bool
+
+	flags := flag.NewFlagSet(importSecurityGroupsCommand, flag.ContinueOnError)
+	flags.SetOutput(report)
+	flags.StringVar(&kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig of the cluster of the CloudProviderAccount. The in-cluster or default kubeconfig is used if not set.")
+	flags.StringVar(&account, "account", "", "The CloudProviderAccount in namespace/name form.")
+	flags.StringVar(&vpcID, "vpc", "", "The AWS VPC ID or Azure virtual network resource ID to import security groups of.")
+	flags.StringVar(&namespace, "namespace", "",
+		"The namespace of the NetworkPolicies, where ExternalEntities of the VMs are. "+
+			"Defaults to the namespace of the CloudEntitySelectors of the account.")
+	flags.BoolVar(&enableDebugLog, "enable-debug-log", defaultDebugLogFlag, "Enable debug logs of cloud plugins.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	accountTokens := strings.Split(account, "/")
+	if len(accountTokens) != 2 || len(accountTokens[0]) == 0 || len(accountTokens[1]) == 0 {
+		return fmt.Errorf("invalid account %q, expect namespace/name", account)
+	}
+	if len(vpcID) == 0 {
+		return fmt.Errorf("vpc is not set")
+	}
+	accountNamespacedName := types.NamespacedName{Namespace: accountTokens[0], Name: accountTokens[1]}
+
+	logging.SetDebugLog(enableDebugLog)
+	ctrl.SetLogger(logging.GetLogger("import"))
+
+	var config *rest.Config
+	var err error
+	if len(kubeconfig) != 0 {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		config, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get kubeconfig: %v", err)
+	}
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %v", err)
+	}
+
+	cloudAccount := &crdv1alpha1.CloudProviderAccount{}
+	if err = k8sClient.Get(context.TODO(), accountNamespacedName, cloudAccount); err != nil {
+		return fmt.Errorf("unable to get account %v: %v", accountNamespacedName, err)
+	}
+	if len(namespace) == 0 {
+		if namespace, err = getAccountSelectorNamespace(k8sClient, &accountNamespacedName); err != nil {
+			return err
+		}
+	}
+	accountCloudType, err := cloudAccount.GetAccountProviderType()
+	if err != nil {
+		return err
+	}
+	cloudInterface, err := cloudprovider.GetCloudInterface(cloudcommon.ProviderType(accountCloudType))
+	if err != nil {
+		return err
+	}
+	if err = cloudInterface.AddProviderAccount(k8sClient, cloudAccount); err != nil {
+		return fmt.Errorf("unable to add account %v: %v", accountNamespacedName, err)
+	}
+	defer cloudInterface.RemoveProviderAccount(&accountNamespacedName)
+
+	groups, err := cloudInterface.GetVpcSecurityGroups(&accountNamespacedName, vpcID)
+	if err != nil {
+		return fmt.Errorf("unable to get security groups of vpc %v: %v", vpcID, err)
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("no security groups found in vpc %v of account %v", vpcID, accountNamespacedName)
+	}
+
+	policies, unsupported := target.NetworkPoliciesFromSecurityGroups(namespace, groups)
+	for _, policy := range policies {
+		data, err := yaml.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(out, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	for _, msg := range unsupported {
+		fmt.Fprintf(report, "not imported: %v\n", msg)
+	}
+	return nil
+}
+
+// getAccountSelectorNamespace returns the namespace of CloudEntitySelectors of the account, in which ExternalEntities of
+// VirtualMachines of the account are. The namespace of the account is returned if the account has no selector.
+func getAccountSelectorNamespace(k8sClient client.Client, accountNamespacedName *types.NamespacedName) (string, error) {
+	selectorList := &crdv1alpha1.CloudEntitySelectorList{}
+	if err := k8sClient.List(context.TODO(), selectorList); err != nil {
+		return "", fmt.Errorf("unable to list selectors: %v", err)
+	}
+	namespaceSet := make(map[string]struct{})
+	for i := range selectorList.Items {
+		selector := &selectorList.Items[i]
+		if *selector.GetAccountNamespacedName() == *accountNamespacedName {
+			namespaceSet[selector.Namespace] = struct{}{}
+		}
+	}
+	if len(namespaceSet) == 0 {
+		return accountNamespacedName.Namespace, nil
+	}
+	namespaces := make([]string, 0, len(namespaceSet))
+	for namespace := range namespaceSet {
+		namespaces = append(namespaces, namespace)
+	}
+	if len(namespaces) > 1 {
+		sort.Strings(namespaces)
+		return "", fmt.Errorf("selectors of account %v are in namespaces %v, namespace is not set", *accountNamespacedName,
+			namespaces)
+	}
+	return namespaces[0], nil
+}
@@ -16,11 +16,15 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	antreanetworking "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
 	antreatypes "antrea.io/antrea/pkg/apis/crd/v1alpha2"
@@ -48,9 +52,21 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == importSecurityGroupsCommand {
+		if err := runImportSecurityGroups(os.Args[2:], os.Stdout, os.Stderr); err != nil && err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var enableDebugLog bool
+	var strictCredentialValidation bool
+	var restrictSecretNamespace bool
+	var dryRun bool
+	var cloudSyncInterval time.Duration
 
 	flag.StringVar(&metricsAddr, "metrics-addr", defaultMetricsAddress, "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", defaultLeaderElectionFlag,
@@ -58,17 +74,29 @@ func main() {
 			"Enabling this will ensure there is only one active nephe-controller manager.")
 	flag.BoolVar(&enableDebugLog, "enable-debug-log", defaultDebugLogFlag,
 		"Enable debug mode for nephe-controller manager. Enabling this will add debug logs")
+	flag.BoolVar(&strictCredentialValidation, "strict-credential-validation", defaultStrictCredentials,
+		"Reject CloudProviderAccounts whose credentials are not accepted by the cloud at admission.")
+	flag.BoolVar(&restrictSecretNamespace, "restrict-secret-namespace", defaultRestrictSecrets,
+		"Reject new CloudProviderAccounts referring to credential secrets outside of the nephe-controller namespace.")
+	flag.BoolVar(&dryRun, "dry-run", defaultDryRun,
+		"Compute cloud security group changes without applying them. Planned changes are exposed as SecurityGroupPlans.")
+	flag.DurationVar(&cloudSyncInterval, "cloud-sync-interval", controllers.DefaultCloudSyncInterval,
+		"The interval cloud security groups are synchronized with cloud.")
 	flag.Parse()
 
 	logging.SetDebugLog(enableDebugLog)
 	ctrl.SetLogger(logging.GetLogger("setup"))
 
+	nepheNamespace := getNepheNamespace()
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   electionID,
+		// snapshot ConfigMaps are read on start only, caching them would watch all ConfigMaps of the cluster.
+		ClientDisableCacheFor: []client.Object{&corev1.ConfigMap{}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -85,9 +113,10 @@ func main() {
 	}
 
 	if err = (&controllers.CloudProviderAccountReconciler{
-		Client: mgr.GetClient(),
-		Log:    logging.GetLogger("controllers").WithName("CloudProviderAccount"),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Log:             logging.GetLogger("controllers").WithName("CloudProviderAccount"),
+		Scheme:          mgr.GetScheme(),
+		SecretNamespace: nepheNamespace,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CloudProviderAccount")
 		os.Exit(1)
@@ -102,9 +131,13 @@ func main() {
 	}
 
 	npController := &controllers.NetworkPolicyReconciler{
-		Client: mgr.GetClient(),
-		Log:    logging.GetLogger("controllers").WithName("NetworkPolicy"),
-		Scheme: mgr.GetScheme(),
+		Client:            mgr.GetClient(),
+		Log:               logging.GetLogger("controllers").WithName("NetworkPolicy"),
+		Scheme:            mgr.GetScheme(),
+		SnapshotNamespace: nepheNamespace,
+		DryRun:            dryRun,
+		Recorder:          mgr.GetEventRecorderFor("nephe-controller"),
+		CloudSyncInterval: cloudSyncInterval,
 	}
 
 	if err = npController.SetupWithManager(mgr); err != nil {
@@ -134,9 +167,18 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "CloudProviderAccount")
 		os.Exit(1)
 	}
+	if restrictSecretNamespace {
+		crdv1alpha1.SetAccountSecretNamespace(nepheNamespace)
+	}
+	if strictCredentialValidation {
+		crdv1alpha1.SetAccountCredentialsProbe(func(account *crdv1alpha1.CloudProviderAccount) error {
+			return controllers.ProbeAccountCredentials(mgr.GetClient(), account)
+		})
+	}
 
 	if err = (&apiserver.NepheControllerAPIServer{}).SetupWithManager(mgr,
-		npController.GetVirtualMachinePolicyIndexer(), logging.GetLogger("apiServer")); err != nil {
+		npController.GetVirtualMachinePolicyIndexer(), npController, npController.GetCloudSecurityDriftIndexer(),
+		logging.GetLogger("apiServer")); err != nil {
 		setupLog.Error(err, "unable to create APIServer")
 		os.Exit(1)
 	}
@@ -148,3 +190,11 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// getNepheNamespace returns the namespace nephe-controller runs in.
+func getNepheNamespace() string {
+	if namespace := os.Getenv(podNamespaceEnv); len(namespace) != 0 {
+		return namespace
+	}
+	return defaultNepheNamespace
+}
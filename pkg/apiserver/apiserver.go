@@ -30,6 +30,8 @@ import (
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
 	runtimev1alpha1 "antrea.io/nephe/apis/runtime/v1alpha1"
+	"antrea.io/nephe/pkg/apiserver/registry/cloudsecuritydrift"
+	"antrea.io/nephe/pkg/apiserver/registry/securitygroupplan"
 	"antrea.io/nephe/pkg/apiserver/registry/virtualmachinepolicy"
 )
 
@@ -46,6 +48,10 @@ var (
 type ExtraConfig struct {
 	// virtual machine policy indexer.
 	vmpIndexer cache.Indexer
+	// security group planner in dry-run mode.
+	sgPlanner securitygroupplan.Planner
+	// cloud security drift indexer.
+	driftIndexer cache.Indexer
 }
 
 // Config defines the config for the apiserver.
@@ -54,7 +60,8 @@ type Config struct {
 	ExtraConfig   ExtraConfig
 }
 
-func NewConfig(codecs serializer.CodecFactory, indexer cache.Indexer) (*Config, error) {
+func NewConfig(codecs serializer.CodecFactory, indexer cache.Indexer, planner securitygroupplan.Planner,
+	driftIndexer cache.Indexer) (*Config, error) {
 	recommend := genericoptions.NewRecommendedOptions("", nil)
 	serverConfig := genericapiserver.NewRecommendedConfig(codecs)
 	recommend.SecureServing.BindPort = apiServerPort
@@ -80,7 +87,9 @@ func NewConfig(codecs serializer.CodecFactory, indexer cache.Indexer) (*Config,
 	config := &Config{
 		GenericConfig: serverConfig,
 		ExtraConfig: ExtraConfig{
-			vmpIndexer: indexer,
+			vmpIndexer:   indexer,
+			sgPlanner:    planner,
+			driftIndexer: driftIndexer,
 		},
 	}
 	return config, nil
@@ -104,10 +113,12 @@ func (s *NepheControllerAPIServer) Start(stop context.Context) error {
 func (s *NepheControllerAPIServer) SetupWithManager(
 	mgr controllerruntime.Manager,
 	indexer cache.Indexer,
+	planner securitygroupplan.Planner,
+	driftIndexer cache.Indexer,
 	logger logger.Logger) error {
 	s.logger = logger
 	codecs := serializer.NewCodecFactory(mgr.GetScheme())
-	apiConfig, err := NewConfig(codecs, indexer)
+	apiConfig, err := NewConfig(codecs, indexer, planner, driftIndexer)
 	if err != nil {
 		s.logger.Error(err, "unable to create APIServer config")
 		return err
@@ -156,10 +167,14 @@ func (c completedConfig) New(scheme *runtime.Scheme, codecs serializer.CodecFact
 	}
 
 	vmpStorage := virtualmachinepolicy.NewREST(c.ExtraConfig.vmpIndexer, logger.WithName("VirtualMachinePolicy"))
+	sgpStorage := securitygroupplan.NewREST(c.ExtraConfig.sgPlanner, logger.WithName("SecurityGroupPlan"))
+	csdStorage := cloudsecuritydrift.NewREST(c.ExtraConfig.driftIndexer, logger.WithName("CloudSecurityDrift"))
 
 	cpGroup := genericapiserver.NewDefaultAPIGroupInfo(runtimev1alpha1.GroupVersion.Group, scheme, metav1.ParameterCodec, codecs)
 	cpv1alpha1Storage := map[string]rest.Storage{}
 	cpv1alpha1Storage["virtualmachinepolicy"] = vmpStorage
+	cpv1alpha1Storage["securitygroupplan"] = sgpStorage
+	cpv1alpha1Storage["cloudsecuritydrift"] = csdStorage
 
 	cpGroup.VersionedResourcesStorageMap["v1alpha1"] = cpv1alpha1Storage
 
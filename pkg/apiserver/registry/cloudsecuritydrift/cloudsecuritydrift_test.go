@@ -0,0 +1,111 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsecuritydrift_test
+
+import (
+	"context"
+	"time"
+
+	"antrea.io/nephe/apis/runtime/v1alpha1"
+	. "antrea.io/nephe/pkg/apiserver/registry/cloudsecuritydrift"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/controllers/cloud"
+	logger "github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("Cloudsecuritydrift", func() {
+	var l logger.Logger
+	detectionTime := time.Unix(1650000000, 0)
+	drifts := []*cloud.CloudSecurityDrift{
+		{
+			ID:            securitygroup.CloudResourceID{Name: "applied-grp", Vpc: "/subscriptions/sub/virtualNetworks/VNet-1"},
+			Account:       &types.NamespacedName{Namespace: "default", Name: "account"},
+			Policy:        "ReportOnly",
+			Discrepancies: []cloud.CloudSecurityDiscrepancy{{Type: cloud.CloudSecurityDriftExtraRule, Item: "ingress action=Allow peer=any"}},
+			DetectionTime: detectionTime,
+		},
+		{
+			ID:             securitygroup.CloudResourceID{Name: "Addr-Grp", Vpc: "vpc-1"},
+			MembershipOnly: true,
+			Policy:         "Remediate",
+			Discrepancies:  []cloud.CloudSecurityDiscrepancy{{Type: cloud.CloudSecurityDriftForeignMember, Item: "VirtualMachine/vm-2/vpc-1"}},
+			DetectionTime:  detectionTime,
+		},
+	}
+	expectedDrifts := []v1alpha1.CloudSecurityDrift{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nephe-ag-addr-grp.vpc-1"},
+			Status: v1alpha1.CloudSecurityDriftStatus{
+				SecurityGroup:  "nephe-ag-addr-grp",
+				VPC:            "vpc-1",
+				MembershipOnly: true,
+				Policy:         "Remediate",
+				Discrepancies:  []v1alpha1.CloudSecurityDiscrepancy{{Type: "ForeignMember", Item: "VirtualMachine/vm-2/vpc-1"}},
+				DetectionTime:  metav1.NewTime(detectionTime),
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nephe-at-applied-grp.vnet-1"},
+			Status: v1alpha1.CloudSecurityDriftStatus{
+				SecurityGroup: "nephe-at-applied-grp",
+				VPC:           "/subscriptions/sub/virtualNetworks/VNet-1",
+				Account:       "default/account",
+				Policy:        "ReportOnly",
+				Discrepancies: []v1alpha1.CloudSecurityDiscrepancy{{Type: "ExtraRule", Item: "ingress action=Allow peer=any"}},
+				DetectionTime: metav1.NewTime(detectionTime),
+			},
+		},
+	}
+	var indexer cache.Indexer
+
+	BeforeEach(func() {
+		indexer = cache.NewIndexer(func(obj interface{}) (string, error) {
+			return obj.(*cloud.CloudSecurityDrift).String(), nil
+		}, cache.Indexers{})
+		for _, drift := range drifts {
+			Expect(indexer.Add(drift)).Should(Succeed())
+		}
+	})
+
+	It("Get drift by name", func() {
+		rest := NewREST(indexer, l)
+		for i := range expectedDrifts {
+			drift, err := rest.Get(context.TODO(), expectedDrifts[i].Name, &metav1.GetOptions{})
+			Expect(err).Should(BeNil())
+			Expect(drift).To(Equal(&expectedDrifts[i]))
+		}
+		_, err := rest.Get(context.TODO(), "nephe-at-unknown.vpc-1", &metav1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("List drifts", func() {
+		rest := NewREST(indexer, l)
+		list, err := rest.List(context.TODO(), &internalversion.ListOptions{})
+		Expect(err).Should(BeNil())
+		Expect(list).To(Equal(&v1alpha1.CloudSecurityDriftList{Items: expectedDrifts}))
+
+		Expect(indexer.Replace(nil, "")).Should(Succeed())
+		list, err = rest.List(context.TODO(), &internalversion.ListOptions{})
+		Expect(err).Should(BeNil())
+		Expect(list.(*v1alpha1.CloudSecurityDriftList).Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,134 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsecuritydrift
+
+import (
+	"context"
+	"sort"
+
+	logger "github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metatable "k8s.io/apimachinery/pkg/api/meta/table"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/cache"
+
+	runtimev1alpha1 "antrea.io/nephe/apis/runtime/v1alpha1"
+	"antrea.io/nephe/pkg/controllers/cloud"
+)
+
+// REST implements rest.Storage for CloudSecurityDrift.
+type REST struct {
+	driftIndexer cache.Indexer
+	logger       logger.Logger
+}
+
+var (
+	_ rest.Scoper = &REST{}
+	_ rest.Getter = &REST{}
+	_ rest.Lister = &REST{}
+)
+
+// NewREST returns a REST object that will work against API services.
+func NewREST(indexer cache.Indexer, l logger.Logger) *REST {
+	return &REST{
+		driftIndexer: indexer,
+		logger:       l,
+	}
+}
+
+func (r *REST) New() runtime.Object {
+	return &runtimev1alpha1.CloudSecurityDrift{}
+}
+
+func (r *REST) NewList() runtime.Object {
+	return &runtimev1alpha1.CloudSecurityDriftList{}
+}
+
+func (r *REST) ShortNames() []string {
+	return []string{"csd"}
+}
+
+func (r *REST) Get(_ context.Context, name string, _ *metav1.GetOptions) (runtime.Object, error) {
+	for _, obj := range r.driftIndexer.List() {
+		if csd := r.convertToCSD(obj.(*cloud.CloudSecurityDrift)); csd.Name == name {
+			return csd, nil
+		}
+	}
+	return nil, errors.NewNotFound(runtimev1alpha1.Resource("cloudsecuritydrift"), name)
+}
+
+func (r *REST) List(_ context.Context, _ *internalversion.ListOptions) (runtime.Object, error) {
+	csdList := &runtimev1alpha1.CloudSecurityDriftList{}
+	for _, obj := range r.driftIndexer.List() {
+		csdList.Items = append(csdList.Items, *r.convertToCSD(obj.(*cloud.CloudSecurityDrift)))
+	}
+	sort.Slice(csdList.Items, func(i, j int) bool {
+		return csdList.Items[i].Name < csdList.Items[j].Name
+	})
+	return csdList, nil
+}
+
+func (r *REST) NamespaceScoped() bool {
+	return false
+}
+
+func (r *REST) ConvertToTable(_ context.Context, obj runtime.Object, _ runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Description: "Cloud security drift name."},
+			{Name: "Account", Type: "string", Description: "Account managing the cloud security group."},
+			{Name: "Policy", Type: "string", Description: "Drift policy of the account."},
+			{Name: "Discrepancies", Type: "integer", Description: "Number of discrepancies with cloud."},
+		},
+	}
+	if m, err := meta.ListAccessor(obj); err == nil {
+		table.ResourceVersion = m.GetResourceVersion()
+		table.Continue = m.GetContinue()
+		table.RemainingItemCount = m.GetRemainingItemCount()
+	} else {
+		if m, err := meta.CommonAccessor(obj); err == nil {
+			table.ResourceVersion = m.GetResourceVersion()
+		}
+	}
+	var err error
+	table.Rows, err = metatable.MetaToTableRow(obj,
+		func(obj runtime.Object, m metav1.Object, name, age string) ([]interface{}, error) {
+			csd := obj.(*runtimev1alpha1.CloudSecurityDrift)
+			return []interface{}{name, csd.Status.Account, csd.Status.Policy, len(csd.Status.Discrepancies)}, nil
+		})
+	return table, err
+}
+
+func (r *REST) convertToCSD(drift *cloud.CloudSecurityDrift) *runtimev1alpha1.CloudSecurityDrift {
+	csd := &runtimev1alpha1.CloudSecurityDrift{}
+	csd.Name = cloud.GetSecurityGroupObjectName(&drift.ID, drift.MembershipOnly)
+	csd.Status.SecurityGroup = drift.ID.GetCloudName(drift.MembershipOnly)
+	csd.Status.VPC = drift.ID.Vpc
+	csd.Status.MembershipOnly = drift.MembershipOnly
+	if drift.Account != nil {
+		csd.Status.Account = drift.Account.String()
+	}
+	csd.Status.Policy = string(drift.Policy)
+	for _, d := range drift.Discrepancies {
+		csd.Status.Discrepancies = append(csd.Status.Discrepancies,
+			runtimev1alpha1.CloudSecurityDiscrepancy{Type: string(d.Type), Item: d.Item})
+	}
+	csd.Status.DetectionTime = metav1.NewTime(drift.DetectionTime)
+	return csd
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitygroupplan
+
+import (
+	"context"
+	"fmt"
+
+	logger "github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metatable "k8s.io/apimachinery/pkg/api/meta/table"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	runtimev1alpha1 "antrea.io/nephe/apis/runtime/v1alpha1"
+	"antrea.io/nephe/pkg/controllers/cloud"
+)
+
+// Planner returns cloud SecurityGroup changes computed in dry-run mode.
+type Planner interface {
+	GetSecurityGroupPlans() []*cloud.SecurityGroupPlan
+}
+
+// REST implements rest.Storage for SecurityGroupPlan.
+type REST struct {
+	planner Planner
+	logger  logger.Logger
+}
+
+var (
+	_ rest.Scoper = &REST{}
+	_ rest.Getter = &REST{}
+	_ rest.Lister = &REST{}
+)
+
+// NewREST returns a REST object that will work against API services.
+func NewREST(planner Planner, l logger.Logger) *REST {
+	return &REST{
+		planner: planner,
+		logger:  l,
+	}
+}
+
+func (r *REST) New() runtime.Object {
+	return &runtimev1alpha1.SecurityGroupPlan{}
+}
+
+func (r *REST) NewList() runtime.Object {
+	return &runtimev1alpha1.SecurityGroupPlanList{}
+}
+
+func (r *REST) ShortNames() []string {
+	return []string{"sgp"}
+}
+
+func (r *REST) Get(_ context.Context, name string, _ *metav1.GetOptions) (runtime.Object, error) {
+	for _, plan := range r.planner.GetSecurityGroupPlans() {
+		if sgp := r.convertToSGP(plan); sgp.Name == name {
+			return sgp, nil
+		}
+	}
+	return nil, errors.NewNotFound(runtimev1alpha1.Resource("securitygroupplan"), name)
+}
+
+func (r *REST) List(_ context.Context, _ *internalversion.ListOptions) (runtime.Object, error) {
+	sgpList := &runtimev1alpha1.SecurityGroupPlanList{}
+	for _, plan := range r.planner.GetSecurityGroupPlans() {
+		sgpList.Items = append(sgpList.Items, *r.convertToSGP(plan))
+	}
+	return sgpList, nil
+}
+
+func (r *REST) NamespaceScoped() bool {
+	return false
+}
+
+func (r *REST) ConvertToTable(_ context.Context, obj runtime.Object, _ runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Description: "Security group plan name."},
+			{Name: "Operation", Type: "string", Description: "Operation on the cloud security group."},
+			{Name: "Members", Type: "string", Description: "Number of members to add and remove."},
+			{Name: "Rules", Type: "string", Description: "Number of rules to add and revoke."},
+		},
+	}
+	if m, err := meta.ListAccessor(obj); err == nil {
+		table.ResourceVersion = m.GetResourceVersion()
+		table.Continue = m.GetContinue()
+		table.RemainingItemCount = m.GetRemainingItemCount()
+	} else {
+		if m, err := meta.CommonAccessor(obj); err == nil {
+			table.ResourceVersion = m.GetResourceVersion()
+		}
+	}
+	var err error
+	table.Rows, err = metatable.MetaToTableRow(obj,
+		func(obj runtime.Object, m metav1.Object, name, age string) ([]interface{}, error) {
+			sgp := obj.(*runtimev1alpha1.SecurityGroupPlan)
+			members := fmt.Sprintf("+%d/-%d", len(sgp.Status.MembersToAdd), len(sgp.Status.MembersToRemove))
+			rules := fmt.Sprintf("+%d/-%d", len(sgp.Status.RulesToAdd), len(sgp.Status.RulesToRevoke))
+			return []interface{}{name, sgp.Status.Operation, members, rules}, nil
+		})
+	return table, err
+}
+
+func (r *REST) convertToSGP(plan *cloud.SecurityGroupPlan) *runtimev1alpha1.SecurityGroupPlan {
+	sgp := &runtimev1alpha1.SecurityGroupPlan{}
+	sgp.Name = cloud.GetSecurityGroupObjectName(&plan.ID, plan.MembershipOnly)
+	sgp.Status.SecurityGroup = plan.ID.GetCloudName(plan.MembershipOnly)
+	sgp.Status.VPC = plan.ID.Vpc
+	sgp.Status.MembershipOnly = plan.MembershipOnly
+	sgp.Status.Operation = runtimev1alpha1.SecurityGroupOperation(plan.Operation)
+	sgp.Status.MembersToAdd = plan.MembersToAdd
+	sgp.Status.MembersToRemove = plan.MembersToRemove
+	sgp.Status.RulesToAdd = plan.RulesToAdd
+	sgp.Status.RulesToRevoke = plan.RulesToRevoke
+	return sgp
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitygroupplan_test
+
+import (
+	"context"
+
+	"antrea.io/nephe/apis/runtime/v1alpha1"
+	. "antrea.io/nephe/pkg/apiserver/registry/securitygroupplan"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/controllers/cloud"
+	logger "github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakePlanner []*cloud.SecurityGroupPlan
+
+func (p fakePlanner) GetSecurityGroupPlans() []*cloud.SecurityGroupPlan {
+	return p
+}
+
+var _ = Describe("Securitygroupplan", func() {
+	var l logger.Logger
+	planner := fakePlanner{
+		{
+			ID:             securitygroup.CloudResourceID{Name: "Addr-Grp", Vpc: "vpc-1"},
+			MembershipOnly: true,
+			Operation:      cloud.SecurityGroupPlanOperationCreate,
+			MembersToAdd:   []string{"VirtualMachine/vm-1/vpc-1"},
+		},
+		{
+			ID:            securitygroup.CloudResourceID{Name: "applied-grp", Vpc: "/subscriptions/sub/virtualNetworks/VNet-1"},
+			Operation:     cloud.SecurityGroupPlanOperationUpdate,
+			RulesToRevoke: []string{"ingress action=Allow protocol=6,port=22 peer=10.0.0.0/24"},
+		},
+	}
+	expectedPlans := []v1alpha1.SecurityGroupPlan{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nephe-ag-addr-grp.vpc-1"},
+			Status: v1alpha1.SecurityGroupPlanStatus{
+				SecurityGroup:  "nephe-ag-addr-grp",
+				VPC:            "vpc-1",
+				MembershipOnly: true,
+				Operation:      v1alpha1.SecurityGroupCreate,
+				MembersToAdd:   []string{"VirtualMachine/vm-1/vpc-1"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nephe-at-applied-grp.vnet-1"},
+			Status: v1alpha1.SecurityGroupPlanStatus{
+				SecurityGroup: "nephe-at-applied-grp",
+				VPC:           "/subscriptions/sub/virtualNetworks/VNet-1",
+				Operation:     v1alpha1.SecurityGroupUpdate,
+				RulesToRevoke: []string{"ingress action=Allow protocol=6,port=22 peer=10.0.0.0/24"},
+			},
+		},
+	}
+
+	It("Get plan by name", func() {
+		rest := NewREST(planner, l)
+		for i := range expectedPlans {
+			plan, err := rest.Get(context.TODO(), expectedPlans[i].Name, &metav1.GetOptions{})
+			Expect(err).Should(BeNil())
+			Expect(plan).To(Equal(&expectedPlans[i]))
+		}
+		_, err := rest.Get(context.TODO(), "nephe-at-unknown.vpc-1", &metav1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("List plans", func() {
+		rest := NewREST(planner, l)
+		plans, err := rest.List(context.TODO(), &internalversion.ListOptions{})
+		Expect(err).Should(BeNil())
+		Expect(plans).To(Equal(&v1alpha1.SecurityGroupPlanList{Items: expectedPlans}))
+
+		plans, err = NewREST(fakePlanner(nil), l).List(context.TODO(), &internalversion.ListOptions{})
+		Expect(err).Should(BeNil())
+		Expect(plans.(*v1alpha1.SecurityGroupPlanList).Items).To(BeEmpty())
+	})
+})
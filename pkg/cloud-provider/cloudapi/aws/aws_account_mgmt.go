@@ -22,28 +22,85 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 type awsAccountConfig struct {
 	v1alpha1.AwsAccountCredential
-	region string
+	// region is the region of account wide api calls. In the config of a regional service, it is the service region.
+	region               string
+	regions              []string
+	credentialMode       v1alpha1.AWSCredentialMode
+	sessionDuration      time.Duration
+	webIdentityTokenFile string
+	// memberAccountIDs are accounts whose instances are imported with the account, by assuming memberRoleName.
+	memberAccountIDs       []string
+	discoverMemberAccounts bool
+	memberRoleName         string
+	// memberAccountID is the member account in the config of a member account service, empty otherwise.
+	memberAccountID string
+	// eventQueueURL is the SQS queue receiving EC2 instance events of the account and of its member accounts.
+	eventQueueURL string
+	// preserveSecurityGroups keeps security groups not created by nephe-controller attached to network interfaces.
+	preserveSecurityGroups bool
+	// apiLimiter limits the rate of api calls of the account or member account in the region of the config, it is set
+	// when services are created.
+	apiLimiter *internal.CloudAPILimiter
 }
 
 // setAccountCredentials sets account credentials.
 func setAccountCredentials(client client.Client, credentials interface{}) (interface{}, error) {
 	awsProviderConfig := credentials.(*v1alpha1.CloudProviderAccountAWSConfig)
-	accCred, err := extractSecret(client, awsProviderConfig.SecretRef)
-	if err != nil {
-		return nil, err
-	}
-
 	awsConfig := &awsAccountConfig{
-		AwsAccountCredential: *accCred,
-		region:               strings.TrimSpace(awsProviderConfig.Region),
+		region:               awsDefaultRegion,
+		regions:              utils.GetAccountRegions(awsProviderConfig.Region, awsProviderConfig.Regions),
+		credentialMode:       awsProviderConfig.CredentialMode,
+		webIdentityTokenFile: strings.TrimSpace(awsProviderConfig.WebIdentityTokenFile),
+
+		memberAccountIDs:       utils.GetAccountIDs(awsProviderConfig.MemberAccountIDs),
+		discoverMemberAccounts: awsProviderConfig.DiscoverMemberAccounts,
+		memberRoleName:         strings.TrimSpace(awsProviderConfig.MemberRoleName),
+		eventQueueURL:          strings.TrimSpace(awsProviderConfig.EventQueueURL),
+		preserveSecurityGroups: awsProviderConfig.PreserveSecurityGroups,
+	}
+	if len(awsConfig.memberRoleName) == 0 {
+		awsConfig.memberRoleName = v1alpha1.AWSDefaultMemberRoleName
+	}
+	for _, region := range awsConfig.regions {
+		if region != v1alpha1.AllRegions {
+			awsConfig.region = region
+			break
+		}
+	}
+	if len(awsConfig.credentialMode) == 0 {
+		awsConfig.credentialMode = v1alpha1.AWSCredentialModeStatic
+	}
+	// only Static credential mode reads credentials from a secret.
+	if awsConfig.credentialMode == v1alpha1.AWSCredentialModeStatic {
+		accCred, err := extractSecret(client, awsProviderConfig.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		awsConfig.AwsAccountCredential = *accCred
+	}
+	if roleArn := strings.TrimSpace(awsProviderConfig.RoleArn); len(roleArn) != 0 {
+		awsConfig.RoleArn = roleArn
+	}
+	if externalID := strings.TrimSpace(awsProviderConfig.ExternalID); len(externalID) != 0 {
+		awsConfig.ExternalID = externalID
+	}
+	if awsProviderConfig.SessionDurationSeconds != nil {
+		awsConfig.sessionDuration = time.Duration(*awsProviderConfig.SessionDurationSeconds) * time.Second
 	}
 
 	return awsConfig, nil
@@ -62,13 +119,69 @@ func compareAccountCredentials(accountName string, existing interface{}, new int
 		credsChanged = true
 		awsPluginLogger().Info("account access key secret updated", "account", accountName)
 	}
-	if strings.Compare(existingConfig.region, newConfig.region) != 0 {
+	if strings.Compare(existingConfig.region, newConfig.region) != 0 ||
+		strings.Join(existingConfig.regions, ",") != strings.Join(newConfig.regions, ",") {
 		credsChanged = true
 		awsPluginLogger().Info("account region updated", "account", accountName)
 	}
+	if existingConfig.credentialMode != newConfig.credentialMode {
+		credsChanged = true
+		awsPluginLogger().Info("account credential mode updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.RoleArn, newConfig.RoleArn) != 0 ||
+		strings.Compare(existingConfig.ExternalID, newConfig.ExternalID) != 0 ||
+		existingConfig.sessionDuration != newConfig.sessionDuration {
+		credsChanged = true
+		awsPluginLogger().Info("account role updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.webIdentityTokenFile, newConfig.webIdentityTokenFile) != 0 {
+		credsChanged = true
+		awsPluginLogger().Info("account web identity token file updated", "account", accountName)
+	}
+	if strings.Join(existingConfig.memberAccountIDs, ",") != strings.Join(newConfig.memberAccountIDs, ",") ||
+		existingConfig.discoverMemberAccounts != newConfig.discoverMemberAccounts ||
+		strings.Compare(existingConfig.memberRoleName, newConfig.memberRoleName) != 0 {
+		credsChanged = true
+		awsPluginLogger().Info("account member accounts updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.eventQueueURL, newConfig.eventQueueURL) != 0 {
+		credsChanged = true
+		awsPluginLogger().Info("account event queue updated", "account", accountName)
+	}
+	if existingConfig.preserveSecurityGroups != newConfig.preserveSecurityGroups {
+		credsChanged = true
+		awsPluginLogger().Info("account preserve security groups updated", "account", accountName)
+	}
 	return credsChanged
 }
 
+// probeAccountCredentials checks account credentials by getting the caller identity and describing a few VPCs of
+// the account region.
+func probeAccountCredentials(credentials interface{}, awsSpecificHelper interface{}) error {
+	awsServicesHelper := awsSpecificHelper.(awsServicesHelper)
+	awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(credentials.(*awsAccountConfig))
+	if err != nil {
+		return err
+	}
+
+	stsWrapper, err := awsServiceClientCreator.identity()
+	if err != nil {
+		return err
+	}
+	if _, err = stsWrapper.getCallerIdentityWrapper(&sts.GetCallerIdentityInput{}); err != nil {
+		return fmt.Errorf("unable to get caller identity: %v", err)
+	}
+
+	ec2Wrapper, err := awsServiceClientCreator.compute()
+	if err != nil {
+		return err
+	}
+	if _, err = ec2Wrapper.describeVpcsWrapper(&ec2.DescribeVpcsInput{MaxResults: aws.Int64(5)}); err != nil {
+		return fmt.Errorf("unable to describe vpcs: %v", err)
+	}
+	return nil
+}
+
 // extractSecret extracts credentials from a Kubernetes secret.
 func extractSecret(c client.Client, s *v1alpha1.SecretReference) (*v1alpha1.AwsAccountCredential, error) {
 	if s == nil {
@@ -99,28 +212,48 @@ func extractSecret(c client.Client, s *v1alpha1.SecretReference) (*v1alpha1.AwsA
 	return cred, nil
 }
 
-// getVpcAccount returns first found account config to which this vpc id belongs.
-func (c *awsCloud) getVpcAccount(vpcID string) internal.CloudAccountInterface {
+// getVpcAccount returns first found account config to which this vpc id belongs, and the ec2 service config of the
+// vpc region.
+func (c *awsCloud) getVpcAccount(vpcID string) (internal.CloudAccountInterface, *ec2ServiceConfig) {
 	accCfgs := c.cloudCommon.GetCloudAccounts()
 	if len(accCfgs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	for _, accCfg := range accCfgs {
-		ec2ServiceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-		if err != nil {
-			awsPluginLogger().Error(err, "get ec2 service config failed", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+		ec2Services := getEC2ServiceConfigs(accCfg)
+		if len(ec2Services) == 0 {
+			awsPluginLogger().Info("no ec2 service config found for account", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
 			continue
 		}
-		accVpcIDs := ec2ServiceCfg.(*ec2ServiceConfig).getCachedVpcIDs()
-		if len(accVpcIDs) == 0 {
-			awsPluginLogger().Info("no vpc found for account", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+		for _, ec2Service := range ec2Services {
+			if _, found := ec2Service.getCachedVpcIDs()[strings.ToLower(vpcID)]; found {
+				return accCfg, ec2Service
+			}
+		}
+		awsPluginLogger().Info("vpcID not found in cache", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+	}
+	return nil, nil
+}
+
+// getEC2ServiceConfigs returns ec2 service configs of all regions of the account and of its member accounts, ordered by
+// member account and region.
+func getEC2ServiceConfigs(accCfg internal.CloudAccountInterface) []*ec2ServiceConfig {
+	var ec2Services []*ec2ServiceConfig
+	for name := range accCfg.GetServiceConfigs() {
+		serviceCfg, err := accCfg.GetServiceConfigByName(name)
+		if err != nil {
 			continue
 		}
-		if _, found := accVpcIDs[strings.ToLower(vpcID)]; found {
-			return accCfg
+		if ec2Service, ok := serviceCfg.(*ec2ServiceConfig); ok {
+			ec2Services = append(ec2Services, ec2Service)
 		}
-		awsPluginLogger().Info("vpcID not found in cache", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
 	}
-	return nil
+	sort.Slice(ec2Services, func(i, j int) bool {
+		if ec2Services[i].memberAccountID != ec2Services[j].memberAccountID {
+			return ec2Services[i].memberAccountID < ec2Services[j].memberAccountID
+		}
+		return ec2Services[i].region < ec2Services[j].region
+	})
+	return ec2Services
 }
@@ -23,6 +23,9 @@ import (
 	reflect "reflect"
 
 	ec2 "github.com/aws/aws-sdk-go/service/ec2"
+	organizations "github.com/aws/aws-sdk-go/service/organizations"
+	sqs "github.com/aws/aws-sdk-go/service/sqs"
+	sts "github.com/aws/aws-sdk-go/service/sts"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -109,6 +112,21 @@ func (mr *MockawsEC2WrapperMockRecorder) deleteSecurityGroup(input interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "deleteSecurityGroup", reflect.TypeOf((*MockawsEC2Wrapper)(nil).deleteSecurityGroup), input)
 }
 
+// describeRegionsWrapper mocks base method.
+func (m *MockawsEC2Wrapper) describeRegionsWrapper(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "describeRegionsWrapper", input)
+	ret0, _ := ret[0].(*ec2.DescribeRegionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// describeRegionsWrapper indicates an expected call of describeRegionsWrapper.
+func (mr *MockawsEC2WrapperMockRecorder) describeRegionsWrapper(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "describeRegionsWrapper", reflect.TypeOf((*MockawsEC2Wrapper)(nil).describeRegionsWrapper), input)
+}
+
 // describeSecurityGroups mocks base method.
 func (m *MockawsEC2Wrapper) describeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
 	m.ctrl.T.Helper()
@@ -228,3 +246,132 @@ func (mr *MockawsEC2WrapperMockRecorder) revokeSecurityGroupIngress(input interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "revokeSecurityGroupIngress", reflect.TypeOf((*MockawsEC2Wrapper)(nil).revokeSecurityGroupIngress), input)
 }
+
+// MockawsSTSWrapper is a mock of awsSTSWrapper interface.
+type MockawsSTSWrapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockawsSTSWrapperMockRecorder
+}
+
+// MockawsSTSWrapperMockRecorder is the mock recorder for MockawsSTSWrapper.
+type MockawsSTSWrapperMockRecorder struct {
+	mock *MockawsSTSWrapper
+}
+
+// NewMockawsSTSWrapper creates a new mock instance.
+func NewMockawsSTSWrapper(ctrl *gomock.Controller) *MockawsSTSWrapper {
+	mock := &MockawsSTSWrapper{ctrl: ctrl}
+	mock.recorder = &MockawsSTSWrapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockawsSTSWrapper) EXPECT() *MockawsSTSWrapperMockRecorder {
+	return m.recorder
+}
+
+// getCallerIdentityWrapper mocks base method.
+func (m *MockawsSTSWrapper) getCallerIdentityWrapper(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getCallerIdentityWrapper", input)
+	ret0, _ := ret[0].(*sts.GetCallerIdentityOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// getCallerIdentityWrapper indicates an expected call of getCallerIdentityWrapper.
+func (mr *MockawsSTSWrapperMockRecorder) getCallerIdentityWrapper(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getCallerIdentityWrapper", reflect.TypeOf((*MockawsSTSWrapper)(nil).getCallerIdentityWrapper), input)
+}
+
+// MockawsOrganizationsWrapper is a mock of awsOrganizationsWrapper interface.
+type MockawsOrganizationsWrapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockawsOrganizationsWrapperMockRecorder
+}
+
+// MockawsOrganizationsWrapperMockRecorder is the mock recorder for MockawsOrganizationsWrapper.
+type MockawsOrganizationsWrapperMockRecorder struct {
+	mock *MockawsOrganizationsWrapper
+}
+
+// NewMockawsOrganizationsWrapper creates a new mock instance.
+func NewMockawsOrganizationsWrapper(ctrl *gomock.Controller) *MockawsOrganizationsWrapper {
+	mock := &MockawsOrganizationsWrapper{ctrl: ctrl}
+	mock.recorder = &MockawsOrganizationsWrapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockawsOrganizationsWrapper) EXPECT() *MockawsOrganizationsWrapperMockRecorder {
+	return m.recorder
+}
+
+// pagedListAccountsWrapper mocks base method.
+func (m *MockawsOrganizationsWrapper) pagedListAccountsWrapper(input *organizations.ListAccountsInput) ([]*organizations.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "pagedListAccountsWrapper", input)
+	ret0, _ := ret[0].([]*organizations.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// pagedListAccountsWrapper indicates an expected call of pagedListAccountsWrapper.
+func (mr *MockawsOrganizationsWrapperMockRecorder) pagedListAccountsWrapper(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "pagedListAccountsWrapper", reflect.TypeOf((*MockawsOrganizationsWrapper)(nil).pagedListAccountsWrapper), input)
+}
+
+// MockawsSQSWrapper is a mock of awsSQSWrapper interface.
+type MockawsSQSWrapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockawsSQSWrapperMockRecorder
+}
+
+// MockawsSQSWrapperMockRecorder is the mock recorder for MockawsSQSWrapper.
+type MockawsSQSWrapperMockRecorder struct {
+	mock *MockawsSQSWrapper
+}
+
+// NewMockawsSQSWrapper creates a new mock instance.
+func NewMockawsSQSWrapper(ctrl *gomock.Controller) *MockawsSQSWrapper {
+	mock := &MockawsSQSWrapper{ctrl: ctrl}
+	mock.recorder = &MockawsSQSWrapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockawsSQSWrapper) EXPECT() *MockawsSQSWrapperMockRecorder {
+	return m.recorder
+}
+
+// deleteMessageBatchWrapper mocks base method.
+func (m *MockawsSQSWrapper) deleteMessageBatchWrapper(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "deleteMessageBatchWrapper", input)
+	ret0, _ := ret[0].(*sqs.DeleteMessageBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// deleteMessageBatchWrapper indicates an expected call of deleteMessageBatchWrapper.
+func (mr *MockawsSQSWrapperMockRecorder) deleteMessageBatchWrapper(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "deleteMessageBatchWrapper", reflect.TypeOf((*MockawsSQSWrapper)(nil).deleteMessageBatchWrapper), input)
+}
+
+// receiveMessageWrapper mocks base method.
+func (m *MockawsSQSWrapper) receiveMessageWrapper(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "receiveMessageWrapper", input)
+	ret0, _ := ret[0].(*sqs.ReceiveMessageOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// receiveMessageWrapper indicates an expected call of receiveMessageWrapper.
+func (mr *MockawsSQSWrapperMockRecorder) receiveMessageWrapper(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "receiveMessageWrapper", reflect.TypeOf((*MockawsSQSWrapper)(nil).receiveMessageWrapper), input)
+}
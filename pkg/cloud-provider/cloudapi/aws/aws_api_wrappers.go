@@ -15,12 +15,85 @@
 package aws
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 )
 
+// newAwsAPILimiter returns the api rate limiter of an account, shared by api clients of an account or member account in
+// a region, as AWS throttles api calls per account and region.
+func newAwsAPILimiter(accountNamespacedName *types.NamespacedName) *internal.CloudAPILimiter {
+	return internal.NewCloudAPILimiter(string(providerType), accountNamespacedName.String(), isAwsThrottledError, awsPluginLogger)
+}
+
+// isAwsThrottledError returns whether an api call failed as it was throttled, e.g. with RequestLimitExceeded. AWS does
+// not return a retry delay, exponential backoff is used.
+func isAwsThrottledError(err error) (bool, time.Duration) {
+	if request.IsErrorThrottle(err) {
+		return true, 0
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusTooManyRequests {
+		return true, 0
+	}
+	return false, 0
+}
+
+// awsAuthErrorCodes are the error codes of api calls rejected for the account credentials.
+var awsAuthErrorCodes = map[string]struct{}{
+	"AuthFailure":                 {},
+	"UnauthorizedOperation":       {},
+	"InvalidClientTokenId":        {},
+	"UnrecognizedClientException": {},
+	"SignatureDoesNotMatch":       {},
+	"IncompleteSignature":         {},
+	"MissingAuthenticationToken":  {},
+	"ExpiredToken":                {},
+	"ExpiredTokenException":       {},
+	"AccessDenied":                {},
+	"AccessDeniedException":       {},
+}
+
+// isAwsAuthError returns whether an api call failed as AWS rejected the account credentials, e.g. with AuthFailure.
+func isAwsAuthError(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	if _, found := awsAuthErrorCodes[awsErr.Code()]; found {
+		return true
+	}
+	var reqErr awserr.RequestFailure
+	return errors.As(err, &reqErr) &&
+		(reqErr.StatusCode() == http.StatusUnauthorized || reqErr.StatusCode() == http.StatusForbidden)
+}
+
+// awsRetryer retries failed api calls like the SDK default retryer, except calls throttled by cloud, which are retried
+// by the api limiter only, so that each throttled call is retried with one backoff.
+type awsRetryer struct {
+	client.DefaultRetryer
+}
+
+// ShouldRetry returns whether a failed api call is retried by the SDK.
+func (r awsRetryer) ShouldRetry(req *request.Request) bool {
+	if throttled, _ := isAwsThrottledError(req.Error); throttled {
+		return false
+	}
+	return r.DefaultRetryer.ShouldRetry(req)
+}
+
 // awsEC2Wrapper is layer above aws EC2 sdk apis to allow for unit-testing.
 type awsEC2Wrapper interface {
 	// instances
@@ -42,20 +115,28 @@ type awsEC2Wrapper interface {
 	// vpcs
 	describeVpcsWrapper(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error)
 
+	// regions
+	describeRegionsWrapper(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error)
+
 	// peer connections
 	describeVpcPeeringConnectionsWrapper(input *ec2.DescribeVpcPeeringConnectionsInput) (*ec2.DescribeVpcPeeringConnectionsOutput, error)
 }
 type awsEC2WrapperImpl struct {
-	ec2 *ec2.EC2
+	ec2        *ec2.EC2
+	apiLimiter *internal.CloudAPILimiter
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) pagedDescribeInstancesWrapper(input *ec2.DescribeInstancesInput) ([]*ec2.Instance, error) {
 	var instances []*ec2.Instance
 	var nextToken *string
 	for {
-		response, err := ec2Wrapper.ec2.DescribeInstances(input)
+		var response *ec2.DescribeInstancesOutput
+		err := ec2Wrapper.apiLimiter.Do("DescribeInstances", func() (err error) {
+			response, err = ec2Wrapper.ec2.DescribeInstances(input)
+			return err
+		})
 		if err != nil {
-			return nil, fmt.Errorf("error describing ec2 instances : %q", err)
+			return nil, fmt.Errorf("error describing ec2 instances : %w", err)
 		}
 
 		reservations := response.Reservations
@@ -77,9 +158,13 @@ func (ec2Wrapper *awsEC2WrapperImpl) pagedDescribeNetworkInterfaces(input *ec2.D
 	var networkInterfaces []*ec2.NetworkInterface
 	var nextToken *string
 	for {
-		response, err := ec2Wrapper.ec2.DescribeNetworkInterfaces(input)
+		var response *ec2.DescribeNetworkInterfacesOutput
+		err := ec2Wrapper.apiLimiter.Do("DescribeNetworkInterfaces", func() (err error) {
+			response, err = ec2Wrapper.ec2.DescribeNetworkInterfaces(input)
+			return err
+		})
 		if err != nil {
-			return nil, fmt.Errorf("error describing ec2 network interfaces: %q", err)
+			return nil, fmt.Errorf("error describing ec2 network interfaces: %w", err)
 		}
 
 		interfaces := response.NetworkInterfaces
@@ -95,48 +180,171 @@ func (ec2Wrapper *awsEC2WrapperImpl) pagedDescribeNetworkInterfaces(input *ec2.D
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) modifyNetworkInterfaceAttribute(input *ec2.ModifyNetworkInterfaceAttributeInput) (
-	req *ec2.ModifyNetworkInterfaceAttributeOutput, output error) {
-	return ec2Wrapper.ec2.ModifyNetworkInterfaceAttribute(input)
+	output *ec2.ModifyNetworkInterfaceAttributeOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("ModifyNetworkInterfaceAttribute", func() (err error) {
+		output, err = ec2Wrapper.ec2.ModifyNetworkInterfaceAttribute(input)
+		return err
+	})
+	return output, err
 }
 
-func (ec2Wrapper *awsEC2WrapperImpl) createSecurityGroup(input *ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
-	return ec2Wrapper.ec2.CreateSecurityGroup(input)
+func (ec2Wrapper *awsEC2WrapperImpl) createSecurityGroup(input *ec2.CreateSecurityGroupInput) (
+	output *ec2.CreateSecurityGroupOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("CreateSecurityGroup", func() (err error) {
+		output, err = ec2Wrapper.ec2.CreateSecurityGroup(input)
+		return err
+	})
+	return output, err
 }
 
-func (ec2Wrapper *awsEC2WrapperImpl) describeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput,
-	error) {
-	return ec2Wrapper.ec2.DescribeSecurityGroups(input)
+func (ec2Wrapper *awsEC2WrapperImpl) describeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (
+	output *ec2.DescribeSecurityGroupsOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("DescribeSecurityGroups", func() (err error) {
+		output, err = ec2Wrapper.ec2.DescribeSecurityGroups(input)
+		return err
+	})
+	return output, err
 }
 
-func (ec2Wrapper *awsEC2WrapperImpl) deleteSecurityGroup(input *ec2.DeleteSecurityGroupInput) (*ec2.DeleteSecurityGroupOutput, error) {
-	return ec2Wrapper.ec2.DeleteSecurityGroup(input)
+func (ec2Wrapper *awsEC2WrapperImpl) deleteSecurityGroup(input *ec2.DeleteSecurityGroupInput) (
+	output *ec2.DeleteSecurityGroupOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("DeleteSecurityGroup", func() (err error) {
+		output, err = ec2Wrapper.ec2.DeleteSecurityGroup(input)
+		return err
+	})
+	return output, err
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) authorizeSecurityGroupEgress(input *ec2.AuthorizeSecurityGroupEgressInput) (
-	*ec2.AuthorizeSecurityGroupEgressOutput, error) {
-	return ec2Wrapper.ec2.AuthorizeSecurityGroupEgress(input)
+	output *ec2.AuthorizeSecurityGroupEgressOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("AuthorizeSecurityGroupEgress", func() (err error) {
+		output, err = ec2Wrapper.ec2.AuthorizeSecurityGroupEgress(input)
+		return err
+	})
+	return output, err
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) authorizeSecurityGroupIngress(input *ec2.AuthorizeSecurityGroupIngressInput) (
-	*ec2.AuthorizeSecurityGroupIngressOutput, error) {
-	return ec2Wrapper.ec2.AuthorizeSecurityGroupIngress(input)
+	output *ec2.AuthorizeSecurityGroupIngressOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("AuthorizeSecurityGroupIngress", func() (err error) {
+		output, err = ec2Wrapper.ec2.AuthorizeSecurityGroupIngress(input)
+		return err
+	})
+	return output, err
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) revokeSecurityGroupEgress(input *ec2.RevokeSecurityGroupEgressInput) (
-	*ec2.RevokeSecurityGroupEgressOutput, error) {
-	return ec2Wrapper.ec2.RevokeSecurityGroupEgress(input)
+	output *ec2.RevokeSecurityGroupEgressOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("RevokeSecurityGroupEgress", func() (err error) {
+		output, err = ec2Wrapper.ec2.RevokeSecurityGroupEgress(input)
+		return err
+	})
+	return output, err
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) revokeSecurityGroupIngress(input *ec2.RevokeSecurityGroupIngressInput) (
-	*ec2.RevokeSecurityGroupIngressOutput, error) {
-	return ec2Wrapper.ec2.RevokeSecurityGroupIngress(input)
+	output *ec2.RevokeSecurityGroupIngressOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("RevokeSecurityGroupIngress", func() (err error) {
+		output, err = ec2Wrapper.ec2.RevokeSecurityGroupIngress(input)
+		return err
+	})
+	return output, err
 }
 
-func (ec2Wrapper *awsEC2WrapperImpl) describeVpcsWrapper(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
-	return ec2Wrapper.ec2.DescribeVpcs(input)
+func (ec2Wrapper *awsEC2WrapperImpl) describeVpcsWrapper(input *ec2.DescribeVpcsInput) (
+	output *ec2.DescribeVpcsOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("DescribeVpcs", func() (err error) {
+		output, err = ec2Wrapper.ec2.DescribeVpcs(input)
+		return err
+	})
+	return output, err
+}
+
+func (ec2Wrapper *awsEC2WrapperImpl) describeRegionsWrapper(input *ec2.DescribeRegionsInput) (
+	output *ec2.DescribeRegionsOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("DescribeRegions", func() (err error) {
+		output, err = ec2Wrapper.ec2.DescribeRegions(input)
+		return err
+	})
+	return output, err
 }
 
 func (ec2Wrapper *awsEC2WrapperImpl) describeVpcPeeringConnectionsWrapper(input *ec2.DescribeVpcPeeringConnectionsInput) (
-	*ec2.DescribeVpcPeeringConnectionsOutput, error) {
-	return ec2Wrapper.ec2.DescribeVpcPeeringConnections(input)
+	output *ec2.DescribeVpcPeeringConnectionsOutput, err error) {
+	err = ec2Wrapper.apiLimiter.Do("DescribeVpcPeeringConnections", func() (err error) {
+		output, err = ec2Wrapper.ec2.DescribeVpcPeeringConnections(input)
+		return err
+	})
+	return output, err
+}
+
+// awsSTSWrapper is layer above aws STS sdk apis to allow for unit-testing.
+type awsSTSWrapper interface {
+	getCallerIdentityWrapper(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+}
+type awsSTSWrapperImpl struct {
+	sts        *sts.STS
+	apiLimiter *internal.CloudAPILimiter
+}
+
+func (stsWrapper *awsSTSWrapperImpl) getCallerIdentityWrapper(input *sts.GetCallerIdentityInput) (
+	output *sts.GetCallerIdentityOutput, err error) {
+	err = stsWrapper.apiLimiter.Do("GetCallerIdentity", func() (err error) {
+		output, err = stsWrapper.sts.GetCallerIdentity(input)
+		return err
+	})
+	return output, err
+}
+
+// awsOrganizationsWrapper is layer above aws Organizations sdk apis to allow for unit-testing.
+type awsOrganizationsWrapper interface {
+	pagedListAccountsWrapper(input *organizations.ListAccountsInput) ([]*organizations.Account, error)
+}
+type awsOrganizationsWrapperImpl struct {
+	organizations *organizations.Organizations
+	apiLimiter    *internal.CloudAPILimiter
+}
+
+func (organizationsWrapper *awsOrganizationsWrapperImpl) pagedListAccountsWrapper(input *organizations.ListAccountsInput) (
+	[]*organizations.Account, error) {
+	var accounts []*organizations.Account
+	err := organizationsWrapper.apiLimiter.Do("ListAccounts", func() error {
+		accounts = nil
+		return organizationsWrapper.organizations.ListAccountsPages(input, func(output *organizations.ListAccountsOutput, _ bool) bool {
+			accounts = append(accounts, output.Accounts...)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing organization accounts : %w", err)
+	}
+	return accounts, nil
+}
+
+// awsSQSWrapper is layer above aws SQS sdk apis to allow for unit-testing.
+type awsSQSWrapper interface {
+	receiveMessageWrapper(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	deleteMessageBatchWrapper(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error)
+}
+type awsSQSWrapperImpl struct {
+	sqs        *sqs.SQS
+	apiLimiter *internal.CloudAPILimiter
+}
+
+func (sqsWrapper *awsSQSWrapperImpl) receiveMessageWrapper(input *sqs.ReceiveMessageInput) (output *sqs.ReceiveMessageOutput,
+	err error) {
+	err = sqsWrapper.apiLimiter.Do("ReceiveMessage", func() (err error) {
+		output, err = sqsWrapper.sqs.ReceiveMessage(input)
+		return err
+	})
+	return output, err
+}
+
+func (sqsWrapper *awsSQSWrapperImpl) deleteMessageBatchWrapper(input *sqs.DeleteMessageBatchInput) (
+	output *sqs.DeleteMessageBatchOutput, err error) {
+	err = sqsWrapper.apiLimiter.Do("DeleteMessageBatch", func() (err error) {
+		output, err = sqsWrapper.sqs.DeleteMessageBatch(input)
+		return err
+	})
+	return output, err
 }
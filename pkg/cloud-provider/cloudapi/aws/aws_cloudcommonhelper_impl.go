@@ -29,3 +29,19 @@ func (h *awsCloudCommonHelperImpl) SetAccountCredentialsFunc() internal.CloudCre
 func (h *awsCloudCommonHelperImpl) GetCloudCredentialsComparatorFunc() internal.CloudCredentialComparatorFunc {
 	return compareAccountCredentials
 }
+
+func (h *awsCloudCommonHelperImpl) GetCloudCredentialsProbeFunc() internal.CloudCredentialProbeFunc {
+	return probeAccountCredentials
+}
+
+func (h *awsCloudCommonHelperImpl) GetCloudEventQueueCreateFunc() internal.CloudEventQueueCreatorFunc {
+	return newAwsEventQueue
+}
+
+func (h *awsCloudCommonHelperImpl) GetCloudAuthErrorFunc() internal.CloudAuthErrorFunc {
+	return isAwsAuthError
+}
+
+func (h *awsCloudCommonHelperImpl) GetCloudServicesRefreshFunc() internal.CloudServicesRefreshFunc {
+	return hasAllRegions
+}
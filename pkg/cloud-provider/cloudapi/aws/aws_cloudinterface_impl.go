@@ -73,14 +73,30 @@ func (c *awsCloud) InstancesGivenProviderAccount(accountNamespacedName *types.Na
 	return vmCRDs, err
 }
 
+// InstancesGivenProviderAccountSelector returns VM CRD for instances of a given cloud provider account matched by the selector.
+func (c *awsCloud) InstancesGivenProviderAccountSelector(accountNamespacedName *types.NamespacedName,
+	selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error) {
+	vmCRDs, err := c.cloudCommon.GetCloudAccountSelectorComputeResourceCRDs(accountNamespacedName, selectorNamespacedName)
+	return vmCRDs, err
+}
+
 // IsVirtualPrivateCloudPresent returns true if given ID is managed by the cloud, else false.
 func (c *awsCloud) IsVirtualPrivateCloudPresent(vpcUniqueIdentifier string) bool {
-	if accCfg := c.getVpcAccount(vpcUniqueIdentifier); accCfg == nil {
+	if accCfg, _ := c.getVpcAccount(vpcUniqueIdentifier); accCfg == nil {
 		return false
 	}
 	return true
 }
 
+// GetVirtualPrivateCloudAccount returns the account managing given ID, nil if it is not managed by the cloud.
+func (c *awsCloud) GetVirtualPrivateCloudAccount(vpcUniqueIdentifier string) *types.NamespacedName {
+	accCfg, _ := c.getVpcAccount(vpcUniqueIdentifier)
+	if accCfg == nil {
+		return nil
+	}
+	return accCfg.GetNamespacedName()
+}
+
 // ////////////////////////////////////////////////////////
 // 	AccountMgmtInterface Implementation
 // ////////////////////////////////////////////////////////
@@ -89,6 +105,11 @@ func (c *awsCloud) AddProviderAccount(client client.Client, account *v1alpha1.Cl
 	return c.cloudCommon.AddCloudAccount(client, account, account.Spec.AWSConfig)
 }
 
+// ProbeProviderAccountCredentials checks cloud credentials of given account of a cloud provider, without adding it.
+func (c *awsCloud) ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	return c.cloudCommon.ProbeCloudAccountCredentials(client, account.Spec.AWSConfig)
+}
+
 // RemoveProviderAccount removes and cleans up any resources of given account of a cloud provider.
 func (c *awsCloud) RemoveProviderAccount(namespacedName *types.NamespacedName) {
 	c.cloudCommon.RemoveCloudAccount(namespacedName)
@@ -99,9 +120,16 @@ func (c *awsCloud) AddAccountResourceSelector(accNamespacedName *types.Namespace
 	return c.cloudCommon.AddSelector(accNamespacedName, selector)
 }
 
+// AddAccountResourceSelectorWithSnapshot adds account specific resource selector, serving its resources from the snapshot
+// until the first inventory of the account.
+func (c *awsCloud) AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector,
+	vms []*v1alpha1.VirtualMachine) error {
+	return c.cloudCommon.AddSelectorWithSnapshot(accNamespacedName, selector, vms)
+}
+
 // RemoveAccountResourcesSelector removes account specific resource selector.
-func (c *awsCloud) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorName string) {
-	c.cloudCommon.RemoveSelector(accNamespacedName, selectorName)
+func (c *awsCloud) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
+	c.cloudCommon.RemoveSelector(accNamespacedName, selectorNamespacedName)
 }
 
 func (c *awsCloud) GetAccountStatus(accNamespacedName *types.NamespacedName) (*cloudv1alpha1.CloudProviderAccountStatus, error) {
@@ -32,7 +32,18 @@ func convertToIPPermissionProtocol(protocol *int) *string {
 	return aws.String(strconv.FormatInt(int64(*protocol), 10))
 }
 
-func convertToIPPermissionPort(port *int, protocol *int) (*int64, *int64) {
+func convertToIPPermissionPort(port, endPort, icmpType, icmpCode, protocol *int) (*int64, *int64) {
+	if isICMPProtocol(protocol) {
+		// For ICMP, aws expects ICMP type and code as start and end port numbers, -1 indicates any.
+		if icmpType == nil {
+			return nil, nil
+		}
+		code := icmpAnyTypeCode
+		if icmpCode != nil {
+			code = int64(*icmpCode)
+		}
+		return aws.Int64(int64(*icmpType)), aws.Int64(code)
+	}
 	if port == nil {
 		// For TCP and UDP, aws expects explicit start and end port numbers (for all ports case)
 		if protocol != nil && (*protocol == 6 || *protocol == 17) {
@@ -41,29 +52,69 @@ func convertToIPPermissionPort(port *int, protocol *int) (*int64, *int64) {
 		return nil, nil
 	}
 	portVal := aws.Int64(int64(*port))
+	if endPort != nil {
+		return portVal, aws.Int64(int64(*endPort))
+	}
 	return portVal, portVal
 }
 
-func convertToEc2IpRanges(ips []*net.IPNet, ruleHasGroups bool) []*ec2.IpRange {
+func isICMPProtocol(protocol *int) bool {
+	return protocol != nil && (*protocol == securitygroup.ProtocolNameNumMap["icmp"] ||
+		*protocol == securitygroup.ProtocolNameNumMap["icmpv6"])
+}
+
+// splitICMPIpPermission returns ipPermission, with its IPv6 ranges moved to an ICMPv6 ip permission if it is for ICMP,
+// as aws does not match ICMPv6 traffic with ICMP ip permissions.
+func splitICMPIpPermission(ipPermission *ec2.IpPermission) []*ec2.IpPermission {
+	icmp := convertToIPPermissionProtocol(aws.Int(securitygroup.ProtocolNameNumMap["icmp"]))
+	if aws.StringValue(ipPermission.IpProtocol) != aws.StringValue(icmp) || len(ipPermission.Ipv6Ranges) == 0 {
+		return []*ec2.IpPermission{ipPermission}
+	}
+	ipv6Permission := &ec2.IpPermission{
+		FromPort:   ipPermission.FromPort,
+		ToPort:     ipPermission.ToPort,
+		IpProtocol: convertToIPPermissionProtocol(aws.Int(securitygroup.ProtocolNameNumMap["icmpv6"])),
+		Ipv6Ranges: ipPermission.Ipv6Ranges,
+	}
+	ipPermission.Ipv6Ranges = nil
+	if len(ipPermission.IpRanges) == 0 && len(ipPermission.UserIdGroupPairs) == 0 {
+		return []*ec2.IpPermission{ipv6Permission}
+	}
+	return []*ec2.IpPermission{ipPermission, ipv6Permission}
+}
+
+func convertToEc2IpRanges(ips []*net.IPNet, ruleHasGroups bool) ([]*ec2.IpRange, []*ec2.Ipv6Range) {
 	var ipRanges []*ec2.IpRange
+	var ipv6Ranges []*ec2.Ipv6Range
 	if len(ips) == 0 && !ruleHasGroups {
 		ipRange := &ec2.IpRange{
-			CidrIp: aws.String("0.0.0.0/0"),
+			CidrIp: aws.String(ipv4AnyCIDR),
+		}
+		ipv6Range := &ec2.Ipv6Range{
+			CidrIpv6: aws.String(ipv6AnyCIDR),
 		}
 		ipRanges = append(ipRanges, ipRange)
-		return ipRanges
+		ipv6Ranges = append(ipv6Ranges, ipv6Range)
+		return ipRanges, ipv6Ranges
 	}
 
-	for _, ip := range ips {
+	ipv4Nets, ipv6Nets := securitygroup.SplitIPNetsByFamily(ips)
+	for _, ip := range ipv4Nets {
 		ipRange := &ec2.IpRange{
 			CidrIp: aws.String(ip.String()),
 		}
 		ipRanges = append(ipRanges, ipRange)
 	}
-	return ipRanges
+	for _, ip := range ipv6Nets {
+		ipv6Range := &ec2.Ipv6Range{
+			CidrIpv6: aws.String(ip.String()),
+		}
+		ipv6Ranges = append(ipv6Ranges, ipv6Range)
+	}
+	return ipRanges, ipv6Ranges
 }
 
-func convertFromIPRange(ipRanges []*ec2.IpRange) []*net.IPNet {
+func convertFromIPRange(ipRanges []*ec2.IpRange, ipv6Ranges []*ec2.Ipv6Range) []*net.IPNet {
 	var srcIPNets []*net.IPNet
 	for _, ipRange := range ipRanges {
 		_, ipNet, err := net.ParseCIDR(*ipRange.CidrIp)
@@ -72,6 +123,13 @@ func convertFromIPRange(ipRanges []*ec2.IpRange) []*net.IPNet {
 		}
 		srcIPNets = append(srcIPNets, ipNet)
 	}
+	for _, ipv6Range := range ipv6Ranges {
+		_, ipNet, err := net.ParseCIDR(*ipv6Range.CidrIpv6)
+		if err != nil {
+			continue
+		}
+		srcIPNets = append(srcIPNets, ipNet)
+	}
 	return srcIPNets
 }
 
@@ -110,10 +168,14 @@ func convertFromIPPermissionToIngressRule(ipPermissions []*ec2.IpPermission, man
 	for _, ipPermission := range ipPermissions {
 		var ingressRule securitygroup.IngressRule
 
-		ingressRule.FromSrcIP = convertFromIPRange(ipPermission.IpRanges)
+		ingressRule.FromSrcIP = convertFromIPRange(ipPermission.IpRanges, ipPermission.Ipv6Ranges)
 		ingressRule.FromSecurityGroups = convertFromSecurityGroupPair(ipPermission.UserIdGroupPairs, managedSGs, unmanagedSGs)
 		ingressRule.Protocol = convertFromIPPermissionProtocol(*ipPermission.IpProtocol)
-		ingressRule.FromPort = convertFromIPPermissionPort(ipPermission.FromPort, ipPermission.ToPort)
+		if isICMPProtocol(ingressRule.Protocol) {
+			ingressRule.ICMPType, ingressRule.ICMPCode = convertFromIPPermissionICMP(ipPermission.FromPort, ipPermission.ToPort)
+		} else {
+			ingressRule.FromPort, ingressRule.FromEndPort = convertFromIPPermissionPort(ipPermission.FromPort, ipPermission.ToPort)
+		}
 
 		ingressRules = append(ingressRules, ingressRule)
 	}
@@ -126,39 +188,61 @@ func convertFromIPPermissionToEgressRule(ipPermissions []*ec2.IpPermission, mana
 	for _, ipPermission := range ipPermissions {
 		var egressRule securitygroup.EgressRule
 
-		egressRule.ToDstIP = convertFromIPRange(ipPermission.IpRanges)
+		egressRule.ToDstIP = convertFromIPRange(ipPermission.IpRanges, ipPermission.Ipv6Ranges)
 		egressRule.ToSecurityGroups = convertFromSecurityGroupPair(ipPermission.UserIdGroupPairs, managedSGs, unmanagedSGs)
 		egressRule.Protocol = convertFromIPPermissionProtocol(*ipPermission.IpProtocol)
-		egressRule.ToPort = convertFromIPPermissionPort(ipPermission.FromPort, ipPermission.ToPort)
+		if isICMPProtocol(egressRule.Protocol) {
+			egressRule.ICMPType, egressRule.ICMPCode = convertFromIPPermissionICMP(ipPermission.FromPort, ipPermission.ToPort)
+		} else {
+			egressRule.ToPort, egressRule.ToEndPort = convertFromIPPermissionPort(ipPermission.FromPort, ipPermission.ToPort)
+		}
 
 		egressRules = append(egressRules, egressRule)
 	}
 	return egressRules
 }
 
-func convertFromIPPermissionPort(startPort *int64, endPort *int64) *int {
+func convertFromIPPermissionPort(startPort *int64, endPort *int64) (*int, *int) {
 	if startPort == nil {
-		return nil
+		return nil, nil
 	}
+	retVal := int(*startPort)
 	if endPort == nil {
-		retVal := int(*startPort)
-		return &retVal
+		return &retVal, nil
 	}
 	if *startPort == -1 {
-		return nil
+		return nil, nil
 	}
 	if *startPort == *endPort {
-		retVal := int(*startPort)
-		return &retVal
+		return &retVal, nil
+	}
+	if *startPort == int64(tcpUDPPortStart) && *endPort == int64(tcpUDPPortEnd) {
+		// all tcp/udp ports.
+		return nil, nil
+	}
+	retEndVal := int(*endPort)
+	return &retVal, &retEndVal
+}
+
+func convertFromIPPermissionICMP(startPort *int64, endPort *int64) (*int, *int) {
+	var icmpType, icmpCode *int
+	if startPort != nil && *startPort != icmpAnyTypeCode {
+		icmpType = aws.Int(int(*startPort))
 	}
-	// other cases along with all (0 - 65535) tcp/udp ports returns nil
-	return nil
+	if endPort != nil && *endPort != icmpAnyTypeCode {
+		icmpCode = aws.Int(int(*endPort))
+	}
+	return icmpType, icmpCode
 }
 
 func convertFromIPPermissionProtocol(proto string) *int {
 	if strings.Compare(proto, awsAnyProtocolValue) == 0 {
 		return nil
 	}
-	protoNum := securitygroup.ProtocolNameNumMap[strings.ToLower(proto)]
+	protoNum, found := securitygroup.ProtocolNameNumMap[strings.ToLower(proto)]
+	if !found {
+		// aws returns protocol number for protocols other than tcp, udp and icmp, e.g. 58 for icmpv6.
+		protoNum, _ = strconv.Atoi(proto)
+	}
 	return &protoNum
 }
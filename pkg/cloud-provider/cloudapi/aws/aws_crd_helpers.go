@@ -15,6 +15,10 @@
 package aws
 
 import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
@@ -58,10 +62,18 @@ func ec2InstanceToVirtualMachineCRD(instance *ec2.Instance, namespace string) *v
 				}
 			}
 		}
+		for _, ipv6Address := range nwInf.Ipv6Addresses {
+			ipAddressCRD := v1alpha1.IPAddress{
+				AddressType: v1alpha1.AddressTypeInternalIP,
+				Address:     *ipv6Address.Ipv6Address,
+			}
+			ipAddressCRDs = append(ipAddressCRDs, ipAddressCRD)
+		}
 		networkInterface := v1alpha1.NetworkInterface{
-			Name: *nwInf.NetworkInterfaceId,
-			MAC:  *nwInf.MacAddress,
-			IPs:  ipAddressCRDs,
+			Name:   *nwInf.NetworkInterfaceId,
+			MAC:    *nwInf.MacAddress,
+			IPs:    ipAddressCRDs,
+			Subnet: aws.StringValue(nwInf.SubnetId),
 		}
 		networkInterfaces = append(networkInterfaces, networkInterface)
 	}
@@ -70,6 +82,25 @@ func ec2InstanceToVirtualMachineCRD(instance *ec2.Instance, namespace string) *v
 	cloudID := *instance.InstanceId
 	cloudNetwork := *instance.VpcId
 
-	return utils.GenerateVirtualMachineCRD(cloudID, cloudName, cloudID, namespace, cloudNetwork, cloudNetwork,
+	vmCRD := utils.GenerateVirtualMachineCRD(cloudID, cloudName, cloudID, namespace, cloudNetwork, cloudNetwork,
 		v1alpha1.VMState(*instance.State.Name), tags, networkInterfaces, providerType)
+	vmCRD.Status.Subnet = aws.StringValue(instance.SubnetId)
+	vmCRD.Status.InstanceType = aws.StringValue(instance.InstanceType)
+	vmCRD.Status.Image = aws.StringValue(instance.ImageId)
+	if instance.Placement != nil {
+		vmCRD.Status.Zone = aws.StringValue(instance.Placement.AvailabilityZone)
+	}
+	// platform is only set for windows instances.
+	vmCRD.Status.OSType = v1alpha1.Linux
+	if strings.EqualFold(aws.StringValue(instance.Platform), ec2.PlatformValuesWindows) {
+		vmCRD.Status.OSType = v1alpha1.Windows
+	}
+	for _, group := range instance.SecurityGroups {
+		vmCRD.Status.SecurityGroups = append(vmCRD.Status.SecurityGroups, aws.StringValue(group.GroupName))
+	}
+	sort.Strings(vmCRD.Status.SecurityGroups)
+	if instance.LaunchTime != nil {
+		vmCRD.Status.LaunchTime = utils.GenerateCRDTime(*instance.LaunchTime)
+	}
+	return vmCRD
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/cenkalti/backoff/v4"
+	"k8s.io/apimachinery/pkg/types"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
 	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
@@ -32,26 +33,34 @@ import (
 
 type ec2ServiceConfig struct {
 	accountName    string
+	region         string
 	apiClient      awsEC2Wrapper
 	resourcesCache *internal.CloudServiceResourcesCache
 	inventoryStats *internal.CloudServiceStats
-	// instanceFilters has following possible values
+	// instanceFilters is keyed by selector namespaced name and has following possible values
 	// - empty map indicates no selectors configured for this account. NO cloud api call for inventory will be made.
 	// - non-empty map indicates selectors are configured. Cloud api call for inventory will be made.
 	//	 - key with nil value indicates no filters. Get all instances for account.
 	//   - key with "some-filter-string" value indicates some filter. Get instances matching those filters only.
-	instanceFilters map[string][][]*ec2.Filter
+	instanceFilters map[types.NamespacedName][][]*ec2.Filter
+	// memberAccountID is the member account of a member account service, empty otherwise.
+	memberAccountID string
+	// preserveSecurityGroups keeps security groups not created by nephe-controller attached to network interfaces,
+	// unless overridden by the PreserveSecurityGroupsTagKey tag of their instances.
+	preserveSecurityGroups bool
 }
 
 // ec2ResourcesCacheSnapshot holds the results from querying for all instances.
 type ec2ResourcesCacheSnapshot struct {
-	instances   map[cloudcommon.InstanceID]*ec2.Instance
-	vpcIDs      map[string]struct{}
-	vpcNameToID map[string]string
-	vpcPeers    map[string][]string
+	instances map[cloudcommon.InstanceID]*ec2.Instance
+	vpcIDs    map[string]struct{}
+	vpcPeers  map[string][]string
+	// selectorInstances holds IDs of instances matched by each selector.
+	selectorInstances map[types.NamespacedName][]cloudcommon.InstanceID
 }
 
-func newEC2ServiceConfig(name string, service awsServiceClientCreateInterface) (internal.CloudServiceInterface, error) {
+func newEC2ServiceConfig(name string, service awsServiceClientCreateInterface, region string,
+	memberAccountID string, preserveSecurityGroups bool) (internal.CloudServiceInterface, error) {
 	// create ec2 sdk api client
 	apiClient, err := service.compute()
 	if err != nil {
@@ -61,9 +70,13 @@ func newEC2ServiceConfig(name string, service awsServiceClientCreateInterface) (
 	config := &ec2ServiceConfig{
 		apiClient:       apiClient,
 		accountName:     name,
+		region:          region,
+		memberAccountID: memberAccountID,
 		resourcesCache:  &internal.CloudServiceResourcesCache{},
 		inventoryStats:  &internal.CloudServiceStats{},
-		instanceFilters: make(map[string][][]*ec2.Filter),
+		instanceFilters: make(map[types.NamespacedName][][]*ec2.Filter),
+
+		preserveSecurityGroups: preserveSecurityGroups,
 	}
 	return config, nil
 }
@@ -73,7 +86,8 @@ func (p *awsServiceSdkConfigProvider) compute() (awsEC2Wrapper, error) {
 	ec2Client := ec2.New(p.session)
 
 	awsEC2 := &awsEC2WrapperImpl{
-		ec2: ec2Client,
+		ec2:        ec2Client,
+		apiLimiter: p.apiLimiter,
 	}
 
 	return awsEC2, nil
@@ -119,7 +133,7 @@ func (ec2Cfg *ec2ServiceConfig) getInstanceResourceFilters() ([][]*ec2.Filter, b
 func (ec2Cfg *ec2ServiceConfig) getCachedInstances() []*ec2.Instance {
 	snapshot := ec2Cfg.resourcesCache.GetSnapshot()
 	if snapshot == nil {
-		awsPluginLogger().V(4).Info("cache snapshot nil", "service", awsComputeServiceNameEC2, "account", ec2Cfg.accountName)
+		awsPluginLogger().V(4).Info("cache snapshot nil", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName)
 		return []*ec2.Instance{}
 	}
 	instances := snapshot.(*ec2ResourcesCacheSnapshot).instances
@@ -127,17 +141,35 @@ func (ec2Cfg *ec2ServiceConfig) getCachedInstances() []*ec2.Instance {
 	for _, instance := range instances {
 		instancesToReturn = append(instancesToReturn, instance)
 	}
-	awsPluginLogger().V(1).Info("cached instances", "service", awsComputeServiceNameEC2, "account", ec2Cfg.accountName,
+	awsPluginLogger().V(1).Info("cached instances", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName,
 		"instances", len(instancesToReturn))
 	return instancesToReturn
 }
 
+// getCachedSelectorInstances returns instances matched by the selector from the cache for the account.
+func (ec2Cfg *ec2ServiceConfig) getCachedSelectorInstances(selectorNamespacedName *types.NamespacedName) []*ec2.Instance {
+	snapshot := ec2Cfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		awsPluginLogger().V(4).Info("cache snapshot nil", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName)
+		return []*ec2.Instance{}
+	}
+	instances := snapshot.(*ec2ResourcesCacheSnapshot).instances
+	instanceIDs := snapshot.(*ec2ResourcesCacheSnapshot).selectorInstances[*selectorNamespacedName]
+	instancesToReturn := make([]*ec2.Instance, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		if instance, found := instances[id]; found {
+			instancesToReturn = append(instancesToReturn, instance)
+		}
+	}
+	return instancesToReturn
+}
+
 // getCachedVpcIDs returns vpcIDs from the cache for the account.
 func (ec2Cfg *ec2ServiceConfig) getCachedVpcIDs() map[string]struct{} {
 	vpcIDsCopy := make(map[string]struct{})
 	snapshot := ec2Cfg.resourcesCache.GetSnapshot()
 	if snapshot == nil {
-		awsPluginLogger().V(4).Info("cache snapshot nil", "service", awsComputeServiceNameEC2, "account", ec2Cfg.accountName)
+		awsPluginLogger().V(4).Info("cache snapshot nil", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName)
 		return vpcIDsCopy
 	}
 	vpcIDsSet := snapshot.(*ec2ResourcesCacheSnapshot).vpcIDs
@@ -149,23 +181,6 @@ func (ec2Cfg *ec2ServiceConfig) getCachedVpcIDs() map[string]struct{} {
 	return vpcIDsCopy
 }
 
-// getCachedVpcNameToID returns the map vpcNameToID from the cache.
-func (ec2Cfg *ec2ServiceConfig) getCachedVpcNameToID() map[string]string {
-	vpcNameToIDCopy := make(map[string]string)
-	snapshot := ec2Cfg.resourcesCache.GetSnapshot()
-	if snapshot == nil {
-		awsPluginLogger().V(4).Info("ec2 service cache snapshot nil", "type", providerType, "account", ec2Cfg.accountName)
-		return vpcNameToIDCopy
-	}
-	vpcNameToID := snapshot.(*ec2ResourcesCacheSnapshot).vpcNameToID
-
-	for k, v := range vpcNameToID {
-		vpcNameToIDCopy[k] = v
-	}
-
-	return vpcNameToIDCopy
-}
-
 // getVpcPeers returns all the peers of a vpc.
 func (ec2Cfg *ec2ServiceConfig) getVpcPeers(vpcID string) []string {
 	snapshot := ec2Cfg.resourcesCache.GetSnapshot()
@@ -180,83 +195,235 @@ func (ec2Cfg *ec2ServiceConfig) getVpcPeers(vpcID string) []string {
 	return vpcPeersCopy
 }
 
-// getInstances gets instance for the account from aws EC2 API.
-func (ec2Cfg *ec2ServiceConfig) getInstances() ([]*ec2.Instance, error) {
-	filters, _ := ec2Cfg.getInstanceResourceFilters()
-	if filters == nil {
-		var validInstanceStateFilters []*ec2.Filter
-		validInstanceStateFilters = append(validInstanceStateFilters, buildEc2FilterForValidInstanceStates())
-		request := &ec2.DescribeInstancesInput{Filters: validInstanceStateFilters}
-		return ec2Cfg.apiClient.pagedDescribeInstancesWrapper(request)
-	}
-
-	var instances []*ec2.Instance
-	for _, filter := range filters {
-		if len(filter) > 0 {
-			if *filter[0].Name == awsCustomFilterKeyVPCName {
-				filter = buildFilterForVPCIDFromFilterForVPCName(filter, ec2Cfg.getCachedVpcNameToID())
+// getInstances gets instances for the account from aws EC2 API, keyed by the selector matching them.
+// Filters on vpc name and vpc tags are resolved using vpcNameToID and vpcTags. If instanceIDs are given, only those
+// instances are fetched.
+func (ec2Cfg *ec2ServiceConfig) getInstances(vpcNameToID map[string]string, vpcTags map[string]map[string]string,
+	instanceIDs []string) (map[types.NamespacedName][]*ec2.Instance, error) {
+	var instanceIDFilters []*ec2.Filter
+	if len(instanceIDs) != 0 {
+		instanceIDFilters = append(instanceIDFilters, &ec2.Filter{Name: aws.String(awsFilterKeyVMID),
+			Values: aws.StringSlice(instanceIDs)})
+	}
+	selectorInstances := make(map[types.NamespacedName][]*ec2.Instance)
+	var allInstances []*ec2.Instance
+	allInstancesFetched := false
+	for selector, filters := range ec2Cfg.instanceFilters {
+		// nil filters indicates all instances, which are fetched only once for all such selectors.
+		if len(filters) == 0 {
+			if !allInstancesFetched {
+				var validInstanceStateFilters []*ec2.Filter
+				validInstanceStateFilters = append(validInstanceStateFilters, buildEc2FilterForValidInstanceStates())
+				validInstanceStateFilters = append(validInstanceStateFilters, instanceIDFilters...)
+				request := &ec2.DescribeInstancesInput{Filters: validInstanceStateFilters}
+				instances, e := ec2Cfg.apiClient.pagedDescribeInstancesWrapper(request)
+				if e != nil {
+					return nil, e
+				}
+				allInstances = instances
+				allInstancesFetched = true
 			}
+			selectorInstances[selector] = allInstances
+			continue
 		}
-		request := &ec2.DescribeInstancesInput{Filters: filter}
-		filterInstances, e := ec2Cfg.apiClient.pagedDescribeInstancesWrapper(request)
-		if e != nil {
-			return nil, e
+
+		var instances []*ec2.Instance
+		for _, filter := range filters {
+			filter, ok := resolveEc2CustomVpcFilters(filter, vpcNameToID, vpcTags)
+			if !ok {
+				// no vpc matches the filter, hence no instance either.
+				continue
+			}
+			filter, excludeFilters := splitEc2ExcludeFilters(filter)
+			filter = append(filter, instanceIDFilters...)
+			request := &ec2.DescribeInstancesInput{Filters: filter}
+			filterInstances, e := ec2Cfg.apiClient.pagedDescribeInstancesWrapper(request)
+			if e != nil {
+				return nil, e
+			}
+			for _, instance := range filterInstances {
+				if !ec2InstanceExcluded(instance, excludeFilters) {
+					instances = append(instances, instance)
+				}
+			}
 		}
-		instances = append(instances, filterInstances...)
-	}
+		selectorInstances[selector] = instances
 
-	awsPluginLogger().V(1).Info("instances from cloud", "service", awsComputeServiceNameEC2, "account", ec2Cfg.accountName,
-		"instances", len(instances))
+		awsPluginLogger().V(1).Info("instances from cloud", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName,
+			"selector", selector, "instances", len(instances))
+	}
 
-	return instances, nil
+	return selectorInstances, nil
 }
 
 // doInstancesInventoryWorker gets inventory from cloud for given cloud account.
 func (ec2Cfg *ec2ServiceConfig) DoResourceInventory() error {
-	instances, e := ec2Cfg.getInstances()
+	vpcNameToID, vpcTags, _ := ec2Cfg.buildMapsVpcNameToIDAndTags()
+	instances, e := ec2Cfg.getInstances(vpcNameToID, vpcTags, nil)
 	if e != nil {
 		awsPluginLogger().V(0).Info("error fetching ec2 instances", "account", ec2Cfg.accountName, "error", e)
 	} else {
 		exists := struct{}{}
 		vpcIDs := make(map[string]struct{})
 		instanceIDs := make(map[cloudcommon.InstanceID]*ec2.Instance)
-		vpcNameToID, _ := ec2Cfg.buildMapVpcNameToID()
+		selectorInstanceIDs := make(map[types.NamespacedName][]cloudcommon.InstanceID)
 		vpcPeers, _ := ec2Cfg.buildMapVpcPeers()
+		for selector, selectorInstances := range instances {
+			for _, instance := range selectorInstances {
+				id := cloudcommon.InstanceID(strings.ToLower(aws.StringValue(instance.InstanceId)))
+				instanceIDs[id] = instance
+				vpcIDs[strings.ToLower(*instance.VpcId)] = exists
+				selectorInstanceIDs[selector] = append(selectorInstanceIDs[selector], id)
+			}
+		}
+		ec2Cfg.resourcesCache.UpdateSnapshot(&ec2ResourcesCacheSnapshot{instanceIDs, vpcIDs, vpcPeers,
+			selectorInstanceIDs})
+		ec2Cfg.inventoryStats.UpdateInventoryResourceCount(len(instanceIDs))
+	}
+
+	return e
+}
+
+// DoEventResourceInventory gets instances of events of the service region and account from cloud, and updates them in
+// cache snapshot.
+func (ec2Cfg *ec2ServiceConfig) DoEventResourceInventory(events []*internal.CloudEvent) error {
+	instanceIDs := ec2Cfg.getEventInstanceIDs(events)
+	snapshot, _ := ec2Cfg.resourcesCache.GetSnapshot().(*ec2ResourcesCacheSnapshot)
+	if len(instanceIDs) == 0 || snapshot == nil {
+		return nil
+	}
+
+	vpcNameToID, vpcTags, _ := ec2Cfg.buildMapsVpcNameToIDAndTags()
+	instances, err := ec2Cfg.getInstances(vpcNameToID, vpcTags, instanceIDs)
+	if err != nil {
+		awsPluginLogger().V(0).Info("error fetching ec2 event instances", "account", ec2Cfg.accountName, "error", err)
+		return err
+	}
+	newSnapshot := snapshot.updateInstances(instanceIDs, instances)
+	ec2Cfg.resourcesCache.UpdateSnapshot(newSnapshot)
+	ec2Cfg.inventoryStats.UpdateInventoryResourceCount(len(newSnapshot.instances))
+
+	awsPluginLogger().V(1).Info("event instances from cloud", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName,
+		"instances", len(instanceIDs))
+	return nil
+}
+
+// getEventInstanceIDs returns IDs of instances of events of the service region and account. Services of the account
+// itself also get instances of events of other accounts, as the account ID is not known, such instances are not found.
+func (ec2Cfg *ec2ServiceConfig) getEventInstanceIDs(events []*internal.CloudEvent) []string {
+	var instanceIDs []string
+	for _, event := range events {
+		if len(event.Region) != 0 && event.Region != ec2Cfg.region {
+			continue
+		}
+		if len(event.AccountID) != 0 && len(ec2Cfg.memberAccountID) != 0 && event.AccountID != ec2Cfg.memberAccountID {
+			continue
+		}
+		instanceIDs = append(instanceIDs, event.ResourceIDs...)
+	}
+	return instanceIDs
+}
+
+// updateInstances returns a copy of the snapshot, in which instances of instanceIDs are replaced by instances found
+// for them, keyed by selector. Instances not found are removed.
+func (snapshot *ec2ResourcesCacheSnapshot) updateInstances(instanceIDs []string,
+	selectorInstances map[types.NamespacedName][]*ec2.Instance) *ec2ResourcesCacheSnapshot {
+	updated := make(map[cloudcommon.InstanceID]struct{})
+	for _, instanceID := range instanceIDs {
+		updated[cloudcommon.InstanceID(strings.ToLower(instanceID))] = struct{}{}
+	}
+
+	newSnapshot := &ec2ResourcesCacheSnapshot{
+		instances:         make(map[cloudcommon.InstanceID]*ec2.Instance),
+		vpcIDs:            make(map[string]struct{}),
+		vpcPeers:          snapshot.vpcPeers,
+		selectorInstances: make(map[types.NamespacedName][]cloudcommon.InstanceID),
+	}
+	for id, instance := range snapshot.instances {
+		if _, found := updated[id]; !found {
+			newSnapshot.instances[id] = instance
+		}
+	}
+	for selector, ids := range snapshot.selectorInstances {
+		for _, id := range ids {
+			if _, found := updated[id]; !found {
+				newSnapshot.selectorInstances[selector] = append(newSnapshot.selectorInstances[selector], id)
+			}
+		}
+	}
+	for selector, instances := range selectorInstances {
 		for _, instance := range instances {
 			id := cloudcommon.InstanceID(strings.ToLower(aws.StringValue(instance.InstanceId)))
-			instanceIDs[id] = instance
-			vpcIDs[strings.ToLower(*instance.VpcId)] = exists
+			newSnapshot.instances[id] = instance
+			newSnapshot.selectorInstances[selector] = append(newSnapshot.selectorInstances[selector], id)
 		}
-		ec2Cfg.resourcesCache.UpdateSnapshot(&ec2ResourcesCacheSnapshot{instanceIDs, vpcIDs, vpcNameToID, vpcPeers})
 	}
-	ec2Cfg.inventoryStats.UpdateInventoryPollStats(e)
+	for _, instance := range newSnapshot.instances {
+		newSnapshot.vpcIDs[strings.ToLower(aws.StringValue(instance.VpcId))] = struct{}{}
+	}
+	return newSnapshot
+}
 
-	return e
+// removeSelector returns a copy of the snapshot without instances of the selector. Instances also matched by other
+// selectors are kept.
+func (snapshot *ec2ResourcesCacheSnapshot) removeSelector(selector types.NamespacedName) *ec2ResourcesCacheSnapshot {
+	newSnapshot := &ec2ResourcesCacheSnapshot{
+		instances:         make(map[cloudcommon.InstanceID]*ec2.Instance),
+		vpcIDs:            make(map[string]struct{}),
+		vpcPeers:          snapshot.vpcPeers,
+		selectorInstances: make(map[types.NamespacedName][]cloudcommon.InstanceID),
+	}
+	for s, ids := range snapshot.selectorInstances {
+		if s == selector {
+			continue
+		}
+		newSnapshot.selectorInstances[s] = ids
+		for _, id := range ids {
+			if instance, found := snapshot.instances[id]; found {
+				newSnapshot.instances[id] = instance
+			}
+		}
+	}
+	for _, instance := range newSnapshot.instances {
+		newSnapshot.vpcIDs[strings.ToLower(aws.StringValue(instance.VpcId))] = struct{}{}
+	}
+	return newSnapshot
 }
 
 // setInstanceFilters add/updates instances resource filter for the service.
 func (ec2Cfg *ec2ServiceConfig) SetResourceFilters(selector *v1alpha1.CloudEntitySelector) {
 	if filters, found := convertSelectorToEC2InstanceFilters(selector); found {
-		ec2Cfg.instanceFilters[selector.GetName()] = filters
+		ec2Cfg.instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}] = filters
 	} else {
-		if selector != nil {
-			delete(ec2Cfg.instanceFilters, selector.GetName())
+		if selector == nil {
+			ec2Cfg.resourcesCache.UpdateSnapshot(nil)
+			return
 		}
-		ec2Cfg.resourcesCache.UpdateSnapshot(nil)
+		ec2Cfg.RemoveResourceFilters(&types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name})
 	}
 }
 
-func (ec2Cfg *ec2ServiceConfig) GetResourceCRDs(namespace string) *internal.CloudServiceResourceCRDs {
-	instances := ec2Cfg.getCachedInstances()
+// RemoveResourceFilters removes instances resource filter and cached instances of the selector, filters and cached
+// instances of other selectors are kept.
+func (ec2Cfg *ec2ServiceConfig) RemoveResourceFilters(selectorNamespacedName *types.NamespacedName) {
+	delete(ec2Cfg.instanceFilters, *selectorNamespacedName)
+	if snapshot := ec2Cfg.resourcesCache.GetSnapshot(); snapshot != nil {
+		ec2Cfg.resourcesCache.UpdateSnapshot(snapshot.(*ec2ResourcesCacheSnapshot).removeSelector(*selectorNamespacedName))
+	}
+}
+
+func (ec2Cfg *ec2ServiceConfig) GetResourceCRDs(selectorNamespacedName *types.NamespacedName) *internal.CloudServiceResourceCRDs {
+	instances := ec2Cfg.getCachedSelectorInstances(selectorNamespacedName)
 	vmCRDs := make([]*v1alpha1.VirtualMachine, 0, len(instances))
 	for _, instance := range instances {
 		// build VirtualMachine CRD
-		vmCRD := ec2InstanceToVirtualMachineCRD(instance, namespace)
+		vmCRD := ec2InstanceToVirtualMachineCRD(instance, selectorNamespacedName.Namespace)
+		vmCRD.Status.Region = ec2Cfg.region
 		vmCRDs = append(vmCRDs, vmCRD)
 	}
 
-	awsPluginLogger().V(1).Info("CRDs", "service", awsComputeServiceNameEC2, "account", ec2Cfg.accountName,
-		"virtual-machine CRDs", len(vmCRDs))
+	awsPluginLogger().V(1).Info("CRDs", "service", ec2Cfg.GetName(), "account", ec2Cfg.accountName,
+		"selector", selectorNamespacedName, "virtual-machine CRDs", len(vmCRDs))
 
 	serviceResourceCRDs := &internal.CloudServiceResourceCRDs{}
 	serviceResourceCRDs.SetComputeResourceCRDs(vmCRDs)
@@ -271,7 +438,20 @@ func (ec2Cfg *ec2ServiceConfig) HasFiltersConfigured() (bool, bool) {
 }
 
 func (ec2Cfg *ec2ServiceConfig) GetName() internal.CloudServiceName {
-	return awsComputeServiceNameEC2
+	if len(ec2Cfg.memberAccountID) != 0 {
+		return getMemberEC2ServiceName(ec2Cfg.memberAccountID, ec2Cfg.region)
+	}
+	return getEC2ServiceName(ec2Cfg.region)
+}
+
+// getEC2ServiceName returns the name of the ec2 service of a region.
+func getEC2ServiceName(region string) internal.CloudServiceName {
+	return internal.CloudServiceName(fmt.Sprintf("%v-%v", awsComputeServiceNameEC2, region))
+}
+
+// getMemberEC2ServiceName returns the name of the ec2 service of a region of a member account.
+func getMemberEC2ServiceName(memberAccountID string, region string) internal.CloudServiceName {
+	return internal.CloudServiceName(fmt.Sprintf("%v-%v-%v", awsComputeServiceNameEC2, memberAccountID, region))
 }
 
 func (ec2Cfg *ec2ServiceConfig) GetType() internal.CloudServiceType {
@@ -283,23 +463,32 @@ func (ec2Cfg *ec2ServiceConfig) GetInventoryStats() *internal.CloudServiceStats
 }
 
 func (ec2Cfg *ec2ServiceConfig) ResetCachedState() {
-	ec2Cfg.SetResourceFilters(nil)
+	ec2Cfg.instanceFilters = make(map[types.NamespacedName][][]*ec2.Filter)
+	ec2Cfg.resourcesCache.UpdateSnapshot(nil)
 	ec2Cfg.inventoryStats.ResetInventoryPollStats()
 }
 
 func (ec2Cfg *ec2ServiceConfig) UpdateServiceConfig(newConfig internal.CloudServiceInterface) {
 	newEc2ServiceConfig := newConfig.(*ec2ServiceConfig)
 	ec2Cfg.apiClient = newEc2ServiceConfig.apiClient
+	ec2Cfg.preserveSecurityGroups = newEc2ServiceConfig.preserveSecurityGroups
 }
 
-func (ec2Cfg *ec2ServiceConfig) buildMapVpcNameToID() (map[string]string, error) {
+// buildMapsVpcNameToIDAndTags returns vpc IDs keyed by vpc name, and tags of each vpc keyed by vpc ID.
+func (ec2Cfg *ec2ServiceConfig) buildMapsVpcNameToIDAndTags() (map[string]string, map[string]map[string]string, error) {
 	vpcNameToID := make(map[string]string)
+	vpcTags := make(map[string]map[string]string)
 	result, err := ec2Cfg.apiClient.describeVpcsWrapper(nil)
 	if err != nil {
 		awsPluginLogger().V(0).Info("error describing vpcs", "error", err)
-		return vpcNameToID, err
+		return vpcNameToID, vpcTags, err
 	}
 	for _, vpc := range result.Vpcs {
+		tags := make(map[string]string)
+		for _, tag := range vpc.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		vpcTags[aws.StringValue(vpc.VpcId)] = tags
 		if len(vpc.Tags) == 0 {
 			awsPluginLogger().V(4).Info("vpc name not found", "account", ec2Cfg.accountName, "vpc", vpc)
 			continue
@@ -313,7 +502,7 @@ func (ec2Cfg *ec2ServiceConfig) buildMapVpcNameToID() (map[string]string, error)
 		}
 		vpcNameToID[vpcName] = *vpc.VpcId
 	}
-	return vpcNameToID, nil
+	return vpcNameToID, vpcTags, nil
 }
 
 func (ec2Cfg *ec2ServiceConfig) buildMapVpcPeers() (map[string][]string, error) {
@@ -0,0 +1,194 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+)
+
+const (
+	// awsEventQueueWaitTimeSeconds is the long polling duration of receiving events from an SQS queue.
+	awsEventQueueWaitTimeSeconds = 20
+	// awsEventQueueMaxMessages is the maximum number of messages received, or deleted, by one SQS api call.
+	awsEventQueueMaxMessages = 10
+
+	awsEC2InstanceResourcePrefix = "instance/"
+)
+
+// awsEventQueue receives EC2 instance events of an account from an SQS queue. Events are sent to the queue by
+// EventBridge rules matching "EC2 Instance State-change Notification" and "Tag Change on Resource" events.
+// Implements internal.CloudEventQueue interface.
+type awsEventQueue struct {
+	queueURL  string
+	apiClient awsSQSWrapper
+}
+
+// awsEvent is an EventBridge event, as delivered to an SQS queue target.
+type awsEvent struct {
+	DetailType string   `json:"detail-type"`
+	Account    string   `json:"account"`
+	Region     string   `json:"region"`
+	Resources  []string `json:"resources"`
+	Detail     struct {
+		InstanceID string `json:"instance-id"`
+	} `json:"detail"`
+}
+
+// sqs returns AWS SQS SDK apiClient.
+func (p *awsServiceSdkConfigProvider) sqs() (awsSQSWrapper, error) {
+	awsSQS := &awsSQSWrapperImpl{
+		sqs:        sqs.New(p.session),
+		apiLimiter: p.apiLimiter,
+	}
+
+	return awsSQS, nil
+}
+
+// newAwsEventQueue returns the event queue of the account, nil if the account has no event queue. The queue is
+// accessed with the account credentials, in the queue region.
+func newAwsEventQueue(accCredentials interface{}, awsSpecificHelper interface{}) (internal.CloudEventQueue, error) {
+	awsServicesHelper := awsSpecificHelper.(awsServicesHelper)
+	awsAccountCredentials := accCredentials.(*awsAccountConfig)
+	if len(awsAccountCredentials.eventQueueURL) == 0 {
+		return nil, nil
+	}
+
+	region, err := getSQSQueueRegion(awsAccountCredentials.eventQueueURL)
+	if err != nil {
+		return nil, err
+	}
+	queueCredentials := *awsAccountCredentials
+	queueCredentials.region = region
+	awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(&queueCredentials)
+	if err != nil {
+		return nil, err
+	}
+	apiClient, err := awsServiceClientCreator.sqs()
+	if err != nil {
+		return nil, fmt.Errorf("error creating sqs sdk api client for queue %v, err: %v", awsAccountCredentials.eventQueueURL, err)
+	}
+	return &awsEventQueue{queueURL: awsAccountCredentials.eventQueueURL, apiClient: apiClient}, nil
+}
+
+// getSQSQueueRegion returns the region of an SQS queue URL, e.g. https://sqs.us-west-2.amazonaws.com/123456789012/q.
+// Legacy queue URLs, e.g. https://us-west-2.queue.amazonaws.com/123456789012/q, are also supported.
+func getSQSQueueRegion(queueURL string) (string, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid event queue URL %v: %v", queueURL, err)
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	if len(labels) > 2 && labels[0] == sqs.EndpointsID {
+		return labels[1], nil
+	}
+	if len(labels) > 2 && labels[1] == "queue" {
+		return labels[0], nil
+	}
+	return "", fmt.Errorf("unable to get region of event queue URL %v", queueURL)
+}
+
+// ReceiveEvents waits for events up to awsEventQueueWaitTimeSeconds, and returns events received from the queue.
+func (q *awsEventQueue) ReceiveEvents() ([]*internal.CloudEvent, error) {
+	output, err := q.apiClient.receiveMessageWrapper(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.queueURL),
+		MaxNumberOfMessages: aws.Int64(awsEventQueueMaxMessages),
+		WaitTimeSeconds:     aws.Int64(awsEventQueueWaitTimeSeconds),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to receive events from %v: %v", q.queueURL, err)
+	}
+
+	events := make([]*internal.CloudEvent, 0, len(output.Messages))
+	for _, message := range output.Messages {
+		event := parseAwsEvent(aws.StringValue(message.Body))
+		event.Handle = aws.StringValue(message.ReceiptHandle)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// DeleteEvents deletes events from the queue, in batches of awsEventQueueMaxMessages.
+func (q *awsEventQueue) DeleteEvents(events []*internal.CloudEvent) error {
+	for start := 0; start < len(events); start += awsEventQueueMaxMessages {
+		end := start + awsEventQueueMaxMessages
+		if end > len(events) {
+			end = len(events)
+		}
+		var entries []*sqs.DeleteMessageBatchRequestEntry
+		for i, event := range events[start:end] {
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: aws.String(event.Handle),
+			})
+		}
+		output, err := q.apiClient.deleteMessageBatchWrapper(&sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(q.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete events from %v: %v", q.queueURL, err)
+		}
+		if len(output.Failed) != 0 {
+			return fmt.Errorf("unable to delete %v events from %v: %v", len(output.Failed), q.queueURL,
+				aws.StringValue(output.Failed[0].Message))
+		}
+	}
+	return nil
+}
+
+// parseAwsEvent returns the cloud event of an EventBridge event, with IDs of the EC2 instances of the event. Events not
+// about EC2 instances have no resources, they are deleted from the queue without inventory.
+func parseAwsEvent(body string) *internal.CloudEvent {
+	event := &internal.CloudEvent{}
+	ec2Event := &awsEvent{}
+	if err := json.Unmarshal([]byte(body), ec2Event); err != nil {
+		awsPluginLogger().Info("unable to parse event", "error", err)
+		return event
+	}
+	event.AccountID, event.Region = ec2Event.Account, ec2Event.Region
+
+	instanceIDs := make(map[string]struct{})
+	if len(ec2Event.Detail.InstanceID) != 0 {
+		instanceIDs[ec2Event.Detail.InstanceID] = struct{}{}
+	}
+	for _, resource := range ec2Event.Resources {
+		resourceArn, err := arn.Parse(resource)
+		if err != nil || resourceArn.Service != ec2.EndpointsID ||
+			!strings.HasPrefix(resourceArn.Resource, awsEC2InstanceResourcePrefix) {
+			continue
+		}
+		instanceIDs[strings.TrimPrefix(resourceArn.Resource, awsEC2InstanceResourcePrefix)] = struct{}{}
+	}
+	for instanceID := range instanceIDs {
+		event.ResourceIDs = append(event.ResourceIDs, instanceID)
+	}
+	sort.Strings(event.ResourceIDs)
+
+	awsPluginLogger().V(1).Info("event", "type", ec2Event.DetailType, "account", event.AccountID, "region", event.Region,
+		"instances", event.ResourceIDs)
+	return event
+}
@@ -15,6 +15,7 @@
 package aws
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 // aws instance resource filter keys.
@@ -31,9 +33,15 @@ const (
 	awsFilterKeyVMName        = "tag:Name"
 	awsFilterKeyGroupName     = "group-name"
 	awsFilterKeyInstanceState = "instance-state-code"
+	awsFilterKeyPrefixTag     = "tag:"
+	awsFilterKeyTagKey        = "tag-key"
 
 	// Not supported by aws, internal use only.
-	awsCustomFilterKeyVPCName = "vpc-name"
+	awsCustomFilterKeyVPCName      = "vpc-name"
+	awsCustomFilterKeyPrefixVPCTag = "vpc-tag:"
+	awsCustomFilterKeyVPCTagKey    = "vpc-tag-key"
+	// exclude filters are named as prefix, index of excludeMatch section, ':' and the aws filter key.
+	awsCustomFilterKeyPrefixExclude = "exclude-"
 )
 
 var (
@@ -62,6 +70,7 @@ func buildEc2Filters(vmSelector []v1alpha1.VirtualMachineSelector) [][]*ec2.Filt
 	var vmIDOnlyMatches []v1alpha1.EntityMatch
 	var vmNameOnlyMatches []v1alpha1.EntityMatch
 	var vpcNameOnlyMatches []v1alpha1.VirtualMachineSelector
+	var compositeMatches []v1alpha1.VirtualMachineSelector
 
 	// vpcMatch contains VpcID and vmMatch contains nil:
 	// vpcIDsWithVpcIDOnlyMatches map contains the corresponding vmSelector section.
@@ -77,8 +86,17 @@ func buildEc2Filters(vmSelector []v1alpha1.VirtualMachineSelector) [][]*ec2.Filt
 	// vpcMatch contains nil and vmMatch contains only vmName:
 	// vmNameOnlyMatches slice contains the specific vmMatch section(EntityMatch).
 	// ec2.Filter is created to match only vms matching the matchName.
+	// vpcMatch or vmMatch contains matchTags/matchExpressions, or excludeMatch is configured:
+	// compositeMatches slice contains the corresponding vmSelector section.
+	// ec2.Filter is created for each combination of vpcMatch and vmMatch section,
+	// carrying all the match criteria configured in them and the excludeMatch criteria.
 
 	for _, match := range vmSelector {
+		if isCompositeMatch(match) {
+			compositeMatches = append(compositeMatches, match)
+			continue
+		}
+
 		isVpcIDPresent := false
 		isVpcNamePresent := false
 
@@ -142,7 +160,8 @@ func buildEc2Filters(vmSelector []v1alpha1.VirtualMachineSelector) [][]*ec2.Filt
 
 	awsPluginLogger().Info("selector stats", "VpcIdOnlyMatch", len(vpcIDsWithVpcIDOnlyMatches),
 		"VpcIdWithOtherMatches", len(vpcIDWithOtherMatches), "VmIdOnlyMatches", len(vmIDOnlyMatches),
-		"VmNameOnlyMatches", len(vmNameOnlyMatches), "VpcNameOnlyMatches", len(vpcNameOnlyMatches))
+		"VmNameOnlyMatches", len(vmNameOnlyMatches), "VpcNameOnlyMatches", len(vpcNameOnlyMatches),
+		"CompositeMatches", len(compositeMatches))
 
 	var allEc2Filters [][]*ec2.Filter
 
@@ -171,9 +190,31 @@ func buildEc2Filters(vmSelector []v1alpha1.VirtualMachineSelector) [][]*ec2.Filt
 	if vpcNameOnlyEc2Filter != nil {
 		allEc2Filters = append(allEc2Filters, vpcNameOnlyEc2Filter)
 	}
+
+	compositeEc2Filters := buildAwsEc2FiltersForCompositeMatches(compositeMatches)
+	if compositeEc2Filters != nil {
+		allEc2Filters = append(allEc2Filters, compositeEc2Filters...)
+	}
 	return allEc2Filters
 }
 
+// isCompositeMatch returns true if vpcMatch or any vmMatch section of the vm selector contains tag match criteria,
+// or the vm selector contains excludeMatch.
+func isCompositeMatch(match v1alpha1.VirtualMachineSelector) bool {
+	if len(match.ExcludeMatch) > 0 {
+		return true
+	}
+	if match.VpcMatch != nil && (len(match.VpcMatch.MatchTags) > 0 || len(match.VpcMatch.MatchExpressions) > 0) {
+		return true
+	}
+	for _, vmMatch := range match.VMMatch {
+		if len(vmMatch.MatchTags) > 0 || len(vmMatch.MatchExpressions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func buildAwsEc2FilterForVpcIDOnlyMatches(vpcIDsWithVpcIDOnlyMatches map[string]struct{}) []*ec2.Filter {
 	if len(vpcIDsWithVpcIDOnlyMatches) == 0 {
 		return nil
@@ -318,33 +359,288 @@ func buildAwsEc2FilterForVPCNameOnlyMatches(vpcNameOnlyMatches []v1alpha1.Virtua
 	return filters
 }
 
-func buildFilterForVPCIDFromFilterForVPCName(filtersForVPCName []*ec2.Filter, vpcNameToID map[string]string) []*ec2.Filter {
-	if len(filtersForVPCName) == 0 {
+func buildAwsEc2FiltersForCompositeMatches(compositeMatches []v1alpha1.VirtualMachineSelector) [][]*ec2.Filter {
+	if len(compositeMatches) == 0 {
 		return nil
 	}
 
+	var allFilters [][]*ec2.Filter
+	for _, match := range compositeMatches {
+		excludeFilters := buildEc2ExcludeFilters(match.ExcludeMatch)
+		var vpcFilters []*ec2.Filter
+		if match.VpcMatch != nil {
+			if vpcID := match.VpcMatch.MatchID; len(strings.TrimSpace(vpcID)) > 0 {
+				vpcFilters = append(vpcFilters, &ec2.Filter{
+					Name:   aws.String(awsFilterKeyVPCID),
+					Values: []*string{aws.String(vpcID)},
+				})
+			}
+			if vpcName := match.VpcMatch.MatchName; len(strings.TrimSpace(vpcName)) > 0 {
+				vpcFilters = append(vpcFilters, &ec2.Filter{
+					Name:   aws.String(awsCustomFilterKeyVPCName),
+					Values: []*string{aws.String(vpcName)},
+				})
+			}
+			vpcFilters = append(vpcFilters, buildEc2FiltersForTags(match.VpcMatch, awsCustomFilterKeyPrefixVPCTag,
+				awsCustomFilterKeyVPCTagKey)...)
+		}
+
+		if len(match.VMMatch) == 0 {
+			vpcFilters = append(vpcFilters, excludeFilters...)
+			vpcFilters = append(vpcFilters, buildEc2FilterForValidInstanceStates())
+			allFilters = append(allFilters, vpcFilters)
+			continue
+		}
+
+		for i := range match.VMMatch {
+			vmMatch := &match.VMMatch[i]
+			filters := append([]*ec2.Filter{}, vpcFilters...)
+			if vmID := vmMatch.MatchID; len(strings.TrimSpace(vmID)) > 0 {
+				filters = append(filters, &ec2.Filter{
+					Name:   aws.String(awsFilterKeyVMID),
+					Values: []*string{aws.String(vmID)},
+				})
+			}
+			if vmName := vmMatch.MatchName; len(strings.TrimSpace(vmName)) > 0 {
+				filters = append(filters, &ec2.Filter{
+					Name:   aws.String(awsFilterKeyVMName),
+					Values: []*string{aws.String(vmName)},
+				})
+			}
+			filters = append(filters, buildEc2FiltersForTags(vmMatch, awsFilterKeyPrefixTag, awsFilterKeyTagKey)...)
+			filters = append(filters, excludeFilters...)
+			filters = append(filters, buildEc2FilterForValidInstanceStates())
+			allFilters = append(allFilters, filters)
+		}
+	}
+	return allFilters
+}
+
+// buildEc2FiltersForTags builds ec2 filters for matchTags and matchExpressions of an EntityMatch.
+// Operators NotIn and DoesNotExist cannot be expressed as ec2 filters, and are rejected by the webhook.
+func buildEc2FiltersForTags(match *v1alpha1.EntityMatch, tagKeyPrefix, tagKeyFilterName string) []*ec2.Filter {
 	var filters []*ec2.Filter
-	var vpcIDs []*string
+	var keys []string
+	for key := range match.MatchTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String(tagKeyPrefix + key),
+			Values: []*string{aws.String(match.MatchTags[key])},
+		})
+	}
 
-	for _, filter := range filtersForVPCName {
-		if *filter.Name != awsFilterKeyInstanceState {
-			for _, vpcName := range filter.Values {
-				vpcIDs = append(vpcIDs, aws.String(vpcNameToID[*vpcName]))
+	for _, expr := range match.MatchExpressions {
+		switch expr.Operator {
+		case v1alpha1.TagMatchOperatorIn:
+			values := aws.StringSlice(expr.Values)
+			sort.Slice(values, func(i, j int) bool {
+				return strings.Compare(*values[i], *values[j]) < 0
+			})
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(tagKeyPrefix + expr.Key),
+				Values: values,
+			})
+		case v1alpha1.TagMatchOperatorExists:
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(tagKeyFilterName),
+				Values: []*string{aws.String(expr.Key)},
+			})
+		default:
+			awsPluginLogger().Info("unsupported tag match operator, ignored", "key", expr.Key, "operator", expr.Operator)
+		}
+	}
+	return filters
+}
+
+// buildEc2ExcludeFilters builds custom filters for excludeMatch sections. Aws filters cannot exclude instances, hence
+// instances matching them are excluded by the plugin on instances returned by the api.
+func buildEc2ExcludeFilters(excludeMatch []v1alpha1.EntityMatch) []*ec2.Filter {
+	var filters []*ec2.Filter
+	for i := range excludeMatch {
+		prefix := fmt.Sprintf("%s%d:", awsCustomFilterKeyPrefixExclude, i)
+		if vmID := excludeMatch[i].MatchID; len(strings.TrimSpace(vmID)) > 0 {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(prefix + awsFilterKeyVMID),
+				Values: []*string{aws.String(vmID)},
+			})
+		}
+		if vmName := excludeMatch[i].MatchName; len(strings.TrimSpace(vmName)) > 0 {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(prefix + awsFilterKeyVMName),
+				Values: []*string{aws.String(vmName)},
+			})
+		}
+		filters = append(filters, buildEc2FiltersForTags(&excludeMatch[i], prefix+awsFilterKeyPrefixTag,
+			prefix+awsFilterKeyTagKey)...)
+	}
+	return filters
+}
+
+// splitEc2ExcludeFilters separates the custom exclude filters from filters, and returns exclude filters grouped by
+// excludeMatch section.
+func splitEc2ExcludeFilters(filters []*ec2.Filter) ([]*ec2.Filter, [][]*ec2.Filter) {
+	var remaining []*ec2.Filter
+	var excludeFilters [][]*ec2.Filter
+	excludeIndex := make(map[string]int)
+	for _, filter := range filters {
+		name := aws.StringValue(filter.Name)
+		if !strings.HasPrefix(name, awsCustomFilterKeyPrefixExclude) {
+			remaining = append(remaining, filter)
+			continue
+		}
+		tokens := strings.SplitN(strings.TrimPrefix(name, awsCustomFilterKeyPrefixExclude), ":", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+		index, found := excludeIndex[tokens[0]]
+		if !found {
+			index = len(excludeFilters)
+			excludeIndex[tokens[0]] = index
+			excludeFilters = append(excludeFilters, nil)
+		}
+		excludeFilters[index] = append(excludeFilters[index], &ec2.Filter{Name: aws.String(tokens[1]), Values: filter.Values})
+	}
+	return remaining, excludeFilters
+}
+
+// ec2InstanceExcluded returns true if instance satisfies any group of exclude filters.
+func ec2InstanceExcluded(instance *ec2.Instance, excludeFilters [][]*ec2.Filter) bool {
+	for _, filters := range excludeFilters {
+		if ec2InstanceMatchesFilters(instance, filters) {
+			return true
+		}
+	}
+	return false
+}
+
+// ec2InstanceMatchesFilters returns true if instance satisfies all filters. Only filters on instance id and tags are
+// supported.
+func ec2InstanceMatchesFilters(instance *ec2.Instance, filters []*ec2.Filter) bool {
+	tags := make(map[string]string)
+	for _, tag := range instance.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	for _, filter := range filters {
+		name := aws.StringValue(filter.Name)
+		matched := false
+		for _, value := range filter.Values {
+			switch {
+			case name == awsFilterKeyVMID:
+				matched = strings.EqualFold(*value, aws.StringValue(instance.InstanceId))
+			case name == awsFilterKeyTagKey:
+				_, matched = tags[*value]
+			case strings.HasPrefix(name, awsFilterKeyPrefixTag):
+				tagValue, ok := tags[strings.TrimPrefix(name, awsFilterKeyPrefixTag)]
+				matched = ok && utils.MatchNamePattern(*value, tagValue)
+			}
+			if matched {
+				break
 			}
 		}
+		if !matched {
+			return false
+		}
 	}
+	return true
+}
 
-	sort.Slice(vpcIDs, func(i, j int) bool {
-		return strings.Compare(*vpcIDs[i], *vpcIDs[j]) < 0
+// resolveEc2CustomVpcFilters replaces the filters on vpc name and vpc tags, which are not supported by aws, with a
+// filter on IDs of the vpcs satisfying all of them. It returns false if no vpc satisfies them.
+func resolveEc2CustomVpcFilters(filters []*ec2.Filter, vpcNameToID map[string]string,
+	vpcTags map[string]map[string]string) ([]*ec2.Filter, bool) {
+	var resolved []*ec2.Filter
+	var vpcIDFilters []*ec2.Filter
+	// nil vpcIDs indicates no custom vpc filter is found.
+	var vpcIDs map[string]struct{}
+
+	for _, filter := range filters {
+		name := aws.StringValue(filter.Name)
+		matched := make(map[string]struct{})
+		switch {
+		case name == awsFilterKeyVPCID:
+			vpcIDFilters = append(vpcIDFilters, filter)
+			continue
+		case name == awsCustomFilterKeyVPCName:
+			for vpcName, vpcID := range vpcNameToID {
+				for _, pattern := range filter.Values {
+					if utils.MatchNamePattern(*pattern, vpcName) {
+						matched[vpcID] = struct{}{}
+					}
+				}
+			}
+		case name == awsCustomFilterKeyVPCTagKey:
+			for vpcID, tags := range vpcTags {
+				for _, key := range filter.Values {
+					if _, ok := tags[*key]; ok {
+						matched[vpcID] = struct{}{}
+					}
+				}
+			}
+		case strings.HasPrefix(name, awsCustomFilterKeyPrefixVPCTag):
+			key := strings.TrimPrefix(name, awsCustomFilterKeyPrefixVPCTag)
+			for vpcID, tags := range vpcTags {
+				value, ok := tags[key]
+				if !ok {
+					continue
+				}
+				for _, v := range filter.Values {
+					if *v == value {
+						matched[vpcID] = struct{}{}
+					}
+				}
+			}
+		default:
+			resolved = append(resolved, filter)
+			continue
+		}
+
+		if vpcIDs == nil {
+			vpcIDs = matched
+			continue
+		}
+		for vpcID := range vpcIDs {
+			if _, ok := matched[vpcID]; !ok {
+				delete(vpcIDs, vpcID)
+			}
+		}
+	}
+
+	if vpcIDs == nil {
+		return filters, true
+	}
+	for _, filter := range vpcIDFilters {
+		for vpcID := range vpcIDs {
+			found := false
+			for _, v := range filter.Values {
+				if strings.EqualFold(*v, vpcID) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				delete(vpcIDs, vpcID)
+			}
+		}
+	}
+	if len(vpcIDs) == 0 {
+		return nil, false
+	}
+
+	var ids []*string
+	for vpcID := range vpcIDs {
+		ids = append(ids, aws.String(vpcID))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return strings.Compare(*ids[i], *ids[j]) < 0
 	})
-	filter := &ec2.Filter{
+	vpcIDFilter := &ec2.Filter{
 		Name:   aws.String(awsFilterKeyVPCID),
-		Values: vpcIDs,
+		Values: ids,
 	}
-	filters = append(filters, filter)
-	filters = append(filters, buildEc2FilterForValidInstanceStates())
-
-	return filters
+	return append([]*ec2.Filter{vpcIDFilter}, resolved...), true
 }
 
 func buildAwsEc2FilterForSecurityGroupNameMatches(vpcIDsSet []string, cloudSGNamesSet map[string]struct{}) []*ec2.Filter {
@@ -0,0 +1,148 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+// GetVpcSecurityGroups returns security groups not created by nephe-controller of vpcID of an account, with the
+// instances they are attached to.
+func (c *awsCloud) GetVpcSecurityGroups(accNamespacedName *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent,
+	error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	accCfg, found := c.cloudCommon.GetCloudAccountByName(accNamespacedName)
+	if !found {
+		return nil, fmt.Errorf("aws account %v not found", accNamespacedName)
+	}
+	// vpc is in one of the regions of the account, security groups of other regions are filtered out by vpc.
+	for _, ec2Service := range getEC2ServiceConfigs(accCfg) {
+		contents, err := ec2Service.getVpcSecurityGroupsImportView(vpcID)
+		if err != nil {
+			return nil, err
+		}
+		if len(contents) > 0 {
+			return contents, nil
+		}
+	}
+	return nil, nil
+}
+
+// getVpcSecurityGroupsImportView returns security groups not created by nephe-controller of vpcID, ordered by name.
+func (ec2Cfg *ec2ServiceConfig) getVpcSecurityGroupsImportView(vpcID string) ([]securitygroup.ImportContent, error) {
+	vpcIDs := map[string]struct{}{vpcID: {}}
+	cloudSecurityGroups, err := ec2Cfg.getSecurityGroupsOfVpc(vpcIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(cloudSecurityGroups) == 0 {
+		return nil, nil
+	}
+	networkInterfaces, err := ec2Cfg.getNetworkInterfacesOfVpc(vpcIDs)
+	if err != nil {
+		return nil, err
+	}
+	managedSgIDToCloudSGObj, unmanagedSgIDToCloudSGObj := getCloudSecurityGroupsByType(cloudSecurityGroups)
+
+	// members are instances of network interfaces, other network interfaces, e.g. of load balancers, are reported.
+	sgIDToMembers := make(map[string][]securitygroup.CloudResource)
+	sgIDToUnsupported := make(map[string][]string)
+	for _, networkInterface := range networkInterfaces {
+		var instanceID string
+		if networkInterface.Attachment != nil {
+			instanceID = aws.StringValue(networkInterface.Attachment.InstanceId)
+		}
+		for _, group := range networkInterface.Groups {
+			sgID := aws.StringValue(group.GroupId)
+			if _, found := unmanagedSgIDToCloudSGObj[sgID]; !found {
+				continue
+			}
+			if len(instanceID) == 0 {
+				sgIDToUnsupported[sgID] = append(sgIDToUnsupported[sgID], fmt.Sprintf(
+					"attached to network interface %v of no instance", aws.StringValue(networkInterface.NetworkInterfaceId)))
+				continue
+			}
+			cloudResource := securitygroup.CloudResource{
+				Type: securitygroup.CloudResourceTypeVM,
+				Name: securitygroup.CloudResourceID{
+					Name: instanceID,
+					Vpc:  vpcID,
+				},
+			}
+			sgIDToMembers[sgID] = append(sgIDToMembers[sgID], cloudResource)
+		}
+	}
+
+	var contents []securitygroup.ImportContent
+	for sgID, cloudSgObj := range unmanagedSgIDToCloudSGObj {
+		ingressPermissions, ingressUnsupported := getImportableIPPermissions(cloudSgObj.IpPermissions, "ingress",
+			unmanagedSgIDToCloudSGObj)
+		egressPermissions, egressUnsupported := getImportableIPPermissions(cloudSgObj.IpPermissionsEgress, "egress",
+			unmanagedSgIDToCloudSGObj)
+		unsupported := append(sgIDToUnsupported[sgID], ingressUnsupported...)
+		content := securitygroup.ImportContent{
+			Resource: securitygroup.CloudResourceID{
+				Name: aws.StringValue(cloudSgObj.GroupName),
+				Vpc:  vpcID,
+			},
+			Members:      sgIDToMembers[sgID],
+			IngressRules: convertFromIPPermissionToIngressRule(ingressPermissions, managedSgIDToCloudSGObj, unmanagedSgIDToCloudSGObj),
+			EgressRules:  convertFromIPPermissionToEgressRule(egressPermissions, managedSgIDToCloudSGObj, unmanagedSgIDToCloudSGObj),
+			Unsupported:  append(unsupported, egressUnsupported...),
+		}
+		contents = append(contents, content)
+	}
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].Resource.Name < contents[j].Resource.Name
+	})
+	return contents, nil
+}
+
+// getImportableIPPermissions returns ipPermissions without the peers that cannot be imported, which are prefix lists and
+// security groups other than unmanagedSGs, and descriptions of the peers removed.
+func getImportableIPPermissions(ipPermissions []*ec2.IpPermission, direction string,
+	unmanagedSGs map[string]*ec2.SecurityGroup) ([]*ec2.IpPermission, []string) {
+	var importablePermissions []*ec2.IpPermission
+	var unsupported []string
+	for _, ipPermission := range ipPermissions {
+		for _, prefixList := range ipPermission.PrefixListIds {
+			unsupported = append(unsupported, fmt.Sprintf("%v rule refers to prefix list %v", direction,
+				aws.StringValue(prefixList.PrefixListId)))
+		}
+		var groupPairs []*ec2.UserIdGroupPair
+		for _, groupPair := range ipPermission.UserIdGroupPairs {
+			if _, found := unmanagedSGs[aws.StringValue(groupPair.GroupId)]; !found {
+				unsupported = append(unsupported, fmt.Sprintf("%v rule refers to security group %v not in vpc", direction,
+					aws.StringValue(groupPair.GroupId)))
+				continue
+			}
+			groupPairs = append(groupPairs, groupPair)
+		}
+		importablePermission := *ipPermission
+		importablePermission.PrefixListIds = nil
+		importablePermission.UserIdGroupPairs = groupPairs
+		importablePermissions = append(importablePermissions, &importablePermission)
+	}
+	return importablePermissions, unsupported
+}
@@ -23,10 +23,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/cenkalti/backoff/v4"
-	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/types"
 
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 const (
@@ -39,6 +41,9 @@ var (
 	awsAnyProtocolValue = "-1"
 	tcpUDPPortStart     = 0
 	tcpUDPPortEnd       = 65535
+	icmpAnyTypeCode     = int64(-1)
+	ipv4AnyCIDR         = "0.0.0.0/0"
+	ipv6AnyCIDR         = "::/0"
 )
 
 var vpcIDToDefaultSecurityGroup = make(map[string]string)
@@ -216,16 +221,18 @@ func (ec2Cfg *ec2ServiceConfig) realizeIngressIPPermissions(cloudSgObj *ec2.Secu
 				continue
 			}
 			idGroupPairs := buildEc2UserIDGroupPairs(rule.FromSecurityGroups, cloudSGNameToObj)
-			ipRanges := convertToEc2IpRanges(rule.FromSrcIP, len(rule.FromSecurityGroups) > 0)
-			startPort, endPort := convertToIPPermissionPort(rule.FromPort, rule.Protocol)
+			ipRanges, ipv6Ranges := convertToEc2IpRanges(rule.FromSrcIP, len(rule.FromSecurityGroups) > 0)
+			startPort, endPort := convertToIPPermissionPort(rule.FromPort, rule.FromEndPort, rule.ICMPType, rule.ICMPCode,
+				rule.Protocol)
 			ipPermission := &ec2.IpPermission{
 				FromPort:         startPort,
 				ToPort:           endPort,
 				IpProtocol:       convertToIPPermissionProtocol(rule.Protocol),
 				IpRanges:         ipRanges,
+				Ipv6Ranges:       ipv6Ranges,
 				UserIdGroupPairs: idGroupPairs,
 			}
-			ipPermissionsToAdd = append(ipPermissionsToAdd, ipPermission)
+			ipPermissionsToAdd = append(ipPermissionsToAdd, splitICMPIpPermission(ipPermission)...)
 		}
 		request := &ec2.AuthorizeSecurityGroupIngressInput{
 			GroupId:       cloudSgObj.GroupId,
@@ -261,16 +268,18 @@ func (ec2Cfg *ec2ServiceConfig) realizeEgressIPPermissions(group *ec2.SecurityGr
 				continue
 			}
 			idGroupPairs := buildEc2UserIDGroupPairs(rule.ToSecurityGroups, cloudSGNameToObj)
-			ipRanges := convertToEc2IpRanges(rule.ToDstIP, len(rule.ToSecurityGroups) > 0)
-			startPort, endPort := convertToIPPermissionPort(rule.ToPort, rule.Protocol)
+			ipRanges, ipv6Ranges := convertToEc2IpRanges(rule.ToDstIP, len(rule.ToSecurityGroups) > 0)
+			startPort, endPort := convertToIPPermissionPort(rule.ToPort, rule.ToEndPort, rule.ICMPType, rule.ICMPCode,
+				rule.Protocol)
 			ipPermission := &ec2.IpPermission{
 				FromPort:         startPort,
 				ToPort:           endPort,
 				IpProtocol:       convertToIPPermissionProtocol(rule.Protocol),
 				IpRanges:         ipRanges,
+				Ipv6Ranges:       ipv6Ranges,
 				UserIdGroupPairs: idGroupPairs,
 			}
-			ipPermissionsToAdd = append(ipPermissionsToAdd, ipPermission)
+			ipPermissionsToAdd = append(ipPermissionsToAdd, splitICMPIpPermission(ipPermission)...)
 		}
 
 		request := &ec2.AuthorizeSecurityGroupEgressInput{
@@ -349,6 +358,36 @@ func (ec2Cfg *ec2ServiceConfig) getSecurityGroupsOfVpc(vpcIDs map[string]struct{
 	return output.SecurityGroups, nil
 }
 
+// getInstancesPreserveSecurityGroups returns whether security groups not created by nephe-controller are preserved on
+// each cached instance, keyed by instance ID.
+func (ec2Cfg *ec2ServiceConfig) getInstancesPreserveSecurityGroups() map[string]bool {
+	instancesPreserveSecurityGroups := make(map[string]bool)
+	for _, instance := range ec2Cfg.getCachedInstances() {
+		var tagValue *string
+		for _, tag := range instance.Tags {
+			if aws.StringValue(tag.Key) == v1alpha1.PreserveSecurityGroupsTagKey {
+				tagValue = tag.Value
+				break
+			}
+		}
+		instancesPreserveSecurityGroups[aws.StringValue(instance.InstanceId)] =
+			utils.IsSecurityGroupsPreserved(tagValue, ec2Cfg.preserveSecurityGroups)
+	}
+	return instancesPreserveSecurityGroups
+}
+
+// isSecurityGroupsPreserved returns true if security groups not created by nephe-controller are preserved on the network
+// interface, by the tag of its instance, or by the account config otherwise.
+func (ec2Cfg *ec2ServiceConfig) isSecurityGroupsPreserved(networkInterface *ec2.NetworkInterface,
+	instancesPreserveSecurityGroups map[string]bool) bool {
+	if attachment := networkInterface.Attachment; attachment != nil && attachment.InstanceId != nil {
+		if preserved, found := instancesPreserveSecurityGroups[*attachment.InstanceId]; found {
+			return preserved
+		}
+	}
+	return ec2Cfg.preserveSecurityGroups
+}
+
 func (ec2Cfg *ec2ServiceConfig) updateSecurityGroupMembers(groupCloudSgID *string, groupCloudSgName string, vpcID string,
 	cloudResourceIdentifiers []*securitygroup.CloudResource, membershipOnly bool) error {
 	// find all network interfaces using this security group within VPC
@@ -365,6 +404,7 @@ func (ec2Cfg *ec2ServiceConfig) updateSecurityGroupMembers(groupCloudSgID *strin
 
 	// find all network interfaces which needs to be attached to SG
 	memberVirtualMachines, memberNetworkInterfaces := securitygroup.FindResourcesBasedOnKind(cloudResourceIdentifiers)
+	instancesPreserveSecurityGroups := ec2Cfg.getInstancesPreserveSecurityGroups()
 
 	// find network interfaces which are using or need to use the provided SG
 	networkInterfacesToModify := make(map[string]map[string]struct{})
@@ -402,10 +442,20 @@ func (ec2Cfg *ec2ServiceConfig) updateSecurityGroupMembers(groupCloudSgID *strin
 		// if network interface is owned by any of member virtual machines or member interface, its sg needs update
 		_, isNicAttachedToMemberVM := memberVirtualMachines[*attachment.InstanceId]
 		_, isNicMemberNetworkInterface := memberNetworkInterfaces[*networkInterface.NetworkInterfaceId]
+		isPreserved := ec2Cfg.isSecurityGroupsPreserved(networkInterface, instancesPreserveSecurityGroups)
 		if isGroupSgAttached {
 			if !isNicAttachedToMemberVM && !isNicMemberNetworkInterface {
 				delete(networkInterfaceNepheControllerCreatedCloudSgsSet, *groupCloudSgID)
 
+				// if security groups of network interface are preserved, keep all other sgs along with remaining nephe sgs.
+				if isPreserved {
+					hasAppliedToGroupSg := numAppliedToGroupSgsAttached > 1 || (membershipOnly && numAppliedToGroupSgsAttached == 1)
+					networkInterfacesToModify[*networkInterface.NetworkInterfaceId] = buildEc2SgsToAttachForCasePreservedSgs(
+						networkInterfaceNepheControllerCreatedCloudSgsSet, networkInterfaceOtherCloudSgsSet, hasAppliedToGroupSg,
+						vpcDefaultSgID)
+					continue
+				}
+
 				networkInterfaceCloudSgsSetToAttach := networkInterfaceNepheControllerCreatedCloudSgsSet
 
 				// If network interface has only one AT sg attached, and we are processing AT sg to be removed, network interface
@@ -426,6 +476,15 @@ func (ec2Cfg *ec2ServiceConfig) updateSecurityGroupMembers(groupCloudSgID *strin
 			if isNicAttachedToMemberVM || isNicMemberNetworkInterface {
 				networkInterfaceNepheControllerCreatedCloudSgsSet[*groupCloudSgID] = struct{}{}
 
+				// if security groups of network interface are preserved, keep all other sgs along with nephe sgs.
+				if isPreserved {
+					hasAppliedToGroupSg := !membershipOnly || numAppliedToGroupSgsAttached > 0
+					networkInterfacesToModify[*networkInterface.NetworkInterfaceId] = buildEc2SgsToAttachForCasePreservedSgs(
+						networkInterfaceNepheControllerCreatedCloudSgsSet, networkInterfaceOtherCloudSgsSet, hasAppliedToGroupSg,
+						vpcDefaultSgID)
+					continue
+				}
+
 				networkInterfaceCloudSgsSetToAttach := networkInterfaceNepheControllerCreatedCloudSgsSet
 
 				// if network interface is not attached to AT sg and we processing attach of AG sg, keep all existing sgs. Also,
@@ -446,29 +505,14 @@ func (ec2Cfg *ec2ServiceConfig) updateSecurityGroupMembers(groupCloudSgID *strin
 
 func (ec2Cfg *ec2ServiceConfig) processNetworkInterfaceModifyConcurrently(networkInterfacesToModify map[string]map[string]struct{},
 	vpcID string) error {
-	ch := make(chan error)
-	var err error
-	var wg sync.WaitGroup
-
-	wg.Add(len(networkInterfacesToModify))
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
-
+	var tasks []func() error
 	for networkInterfaceID, cloudSgIDSet := range networkInterfacesToModify {
-		go func(interfaceID string, sgIDSet map[string]struct{}, ch chan error) {
-			defer wg.Done()
-			ch <- ec2Cfg.updateNetworkInterfaceSecurityGroups(interfaceID, vpcID, sgIDSet)
-		}(networkInterfaceID, cloudSgIDSet, ch)
-	}
-	for e := range ch {
-		if e != nil {
-			err = multierr.Append(err, e)
-		}
+		interfaceID, sgIDSet := networkInterfaceID, cloudSgIDSet
+		tasks = append(tasks, func() error {
+			return ec2Cfg.updateNetworkInterfaceSecurityGroups(interfaceID, vpcID, sgIDSet)
+		})
 	}
-
-	return err
+	return internal.RunConcurrently(tasks)
 }
 
 func buildEc2SgsToAttachForCaseMemberOnlySgWithNoATSgAttached(networkInterfaceNepheControllerCreatedCloudSgsSet map[string]struct{},
@@ -493,6 +537,25 @@ func buildEc2SgsToAttachForCaseMemberOnlySgWithNoATSgAttached(networkInterfaceNe
 	return networkInterfaceCloudSgsSet
 }
 
+// buildEc2SgsToAttachForCasePreservedSgs returns nephe created sgs along with all other sgs of a network interface whose
+// security groups are preserved. Vpc default sg is attached, as for member-only sgs, only if network interface is left
+// without any AT sg and other sg.
+func buildEc2SgsToAttachForCasePreservedSgs(networkInterfaceNepheControllerCreatedCloudSgsSet map[string]struct{},
+	networkInterfaceOtherCloudSgsSet map[string]struct{}, hasAppliedToGroupSg bool, vpcDefaultSgID string) map[string]struct{} {
+	if !hasAppliedToGroupSg {
+		return buildEc2SgsToAttachForCaseMemberOnlySgWithNoATSgAttached(networkInterfaceNepheControllerCreatedCloudSgsSet,
+			networkInterfaceOtherCloudSgsSet, vpcDefaultSgID)
+	}
+	networkInterfaceCloudSgsSet := make(map[string]struct{})
+	for key, value := range networkInterfaceNepheControllerCreatedCloudSgsSet {
+		networkInterfaceCloudSgsSet[key] = value
+	}
+	for key, value := range networkInterfaceOtherCloudSgsSet {
+		networkInterfaceCloudSgsSet[key] = value
+	}
+	return networkInterfaceCloudSgsSet
+}
+
 func (ec2Cfg *ec2ServiceConfig) getNepheControllerManagedSecurityGroupsCloudView() []securitygroup.SynchronizationContent {
 	vpcIDs := ec2Cfg.getCachedVpcIDs()
 	if len(vpcIDs) == 0 {
@@ -513,7 +576,8 @@ func (ec2Cfg *ec2ServiceConfig) getNepheControllerManagedSecurityGroupsCloudView
 	managedSgIDToCloudSGObj, unmanagedSgIDToCloudSGObj := getCloudSecurityGroupsByType(cloudSecurityGroups)
 
 	// find all member network-interfaces-ids for managed cloud-security-groups
-	// also find all member network-interface-ids attached to non antrea+ sgs
+	// also find all member network-interface-ids attached to non antrea+ sgs, unless their sgs are preserved
+	instancesPreserveSecurityGroups := ec2Cfg.getInstancesPreserveSecurityGroups()
 	managedSgIDToMemberCloudResourcesMap := make(map[string][]securitygroup.CloudResource)
 	memberCloudResourcesWithOtherSGsAttachedMap := make(map[string]struct{})
 	for _, networkInterface := range networkInterfaces {
@@ -542,7 +606,8 @@ func (ec2Cfg *ec2ServiceConfig) getNepheControllerManagedSecurityGroupsCloudView
 			isAttachedToNepheControllerSG = true
 		}
 
-		if isAttachedToNepheControllerSG && isAttachedToOtherSG {
+		if isAttachedToNepheControllerSG && isAttachedToOtherSG &&
+			!ec2Cfg.isSecurityGroupsPreserved(networkInterface, instancesPreserveSecurityGroups) {
 			memberCloudResourcesWithOtherSGsAttachedMap[networkInterfaceID] = struct{}{}
 		}
 	}
@@ -640,15 +705,10 @@ func (c *awsCloud) CreateSecurityGroup(addressGroupIdentifier *securitygroup.Clo
 	defer mutex.Unlock()
 
 	vpcID := addressGroupIdentifier.Vpc
-	accCfg := c.getVpcAccount(vpcID)
-	if accCfg == nil {
+	_, ec2Service := c.getVpcAccount(vpcID)
+	if ec2Service == nil {
 		return nil, fmt.Errorf("aws account not found managing virtual private cloud [%v]", vpcID)
 	}
-	serviceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-	if err != nil {
-		return nil, err
-	}
-	ec2Service := serviceCfg.(*ec2ServiceConfig)
 
 	cloudSgName := addressGroupIdentifier.GetCloudName(membershipOnly)
 	resp, err := ec2Service.createOrGetSecurityGroups(addressGroupIdentifier.Vpc, map[string]struct{}{cloudSgName: {}})
@@ -662,21 +722,32 @@ func (c *awsCloud) CreateSecurityGroup(addressGroupIdentifier *securitygroup.Clo
 
 func (c *awsCloud) UpdateSecurityGroupRules(addressGroupIdentifier *securitygroup.CloudResourceID,
 	ingressRules []*securitygroup.IngressRule, egressRules []*securitygroup.EgressRule) error {
+	for _, rule := range ingressRules {
+		if rule == nil {
+			continue
+		}
+		if !c.IsRuleActionSupported(rule.Action) {
+			return fmt.Errorf("aws security group does not support %v ingress rules", rule.Action)
+		}
+	}
+	for _, rule := range egressRules {
+		if rule == nil {
+			continue
+		}
+		if !c.IsRuleActionSupported(rule.Action) {
+			return fmt.Errorf("aws security group does not support %v egress rules", rule.Action)
+		}
+	}
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
 	vpcID := addressGroupIdentifier.Vpc
-	accCfg := c.getVpcAccount(addressGroupIdentifier.Vpc)
-	if accCfg == nil {
+	_, ec2Service := c.getVpcAccount(addressGroupIdentifier.Vpc)
+	if ec2Service == nil {
 		return fmt.Errorf("aws account not found managing virtual private cloud [%v]", vpcID)
 	}
 
-	serviceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-	if err != nil {
-		return err
-	}
-	ec2Service := serviceCfg.(*ec2ServiceConfig)
-
 	// build from addressGroups, cloudSgNames from rules
 	cloudSgNames := buildEc2CloudSgNamesFromRules(addressGroupIdentifier, ingressRules, egressRules)
 
@@ -713,17 +784,11 @@ func (c *awsCloud) UpdateSecurityGroupMembers(groupIdentifier *securitygroup.Clo
 	defer mutex.Unlock()
 
 	vpcID := groupIdentifier.Vpc
-	accCfg := c.getVpcAccount(vpcID)
-	if accCfg == nil {
+	_, ec2Service := c.getVpcAccount(vpcID)
+	if ec2Service == nil {
 		return fmt.Errorf("aws account not found managing virtual private cloud [%v]", vpcID)
 	}
 
-	serviceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-	if err != nil {
-		return err
-	}
-	ec2Service := serviceCfg.(*ec2ServiceConfig)
-
 	groupCloudSgName := groupIdentifier.GetCloudName(membershipOnly)
 
 	// get addressGroup cloudSgID
@@ -752,17 +817,11 @@ func (c *awsCloud) DeleteSecurityGroup(groupIdentifier *securitygroup.CloudResou
 	defer mutex.Unlock()
 
 	vpcID := groupIdentifier.Vpc
-	accCfg := c.getVpcAccount(vpcID)
-	if accCfg == nil {
+	_, ec2Service := c.getVpcAccount(vpcID)
+	if ec2Service == nil {
 		return fmt.Errorf("aws account not found managing virtual private cloud [%v]", vpcID)
 	}
 
-	serviceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-	if err != nil {
-		return err
-	}
-	ec2Service := serviceCfg.(*ec2ServiceConfig)
-
 	// check if sg exists in cloud and get its cloud sg id to delete
 	vpcIDs := []string{vpcID}
 	cloudSgNameToDelete := groupIdentifier.GetCloudName(membershipOnly)
@@ -792,17 +851,36 @@ func (c *awsCloud) DeleteSecurityGroup(groupIdentifier *securitygroup.CloudResou
 	return nil
 }
 
+// IsRuleActionSupported returns true if action is supported by AWS security groups, which only permit traffic.
+func (c *awsCloud) IsRuleActionSupported(action securitygroup.RuleAction) bool {
+	return !action.IsDeny()
+}
+
 func (c *awsCloud) GetEnforcedSecurity() []securitygroup.SynchronizationContent {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	inventoryInitWaitDuration := 30 * time.Second
-
 	var accNamespacedNames []types.NamespacedName
 	accountConfigs := c.cloudCommon.GetCloudAccounts()
 	for _, accCfg := range accountConfigs {
 		accNamespacedNames = append(accNamespacedNames, *accCfg.GetNamespacedName())
 	}
+	return c.getEnforcedSecurity(accNamespacedNames)
+}
+
+func (c *awsCloud) GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, found := c.cloudCommon.GetCloudAccountByName(accNamespacedName); !found {
+		return nil
+	}
+	return c.getEnforcedSecurity([]types.NamespacedName{*accNamespacedName})
+}
+
+// getEnforcedSecurity returns the cloud view of enforced security of given accounts.
+func (c *awsCloud) getEnforcedSecurity(accNamespacedNames []types.NamespacedName) []securitygroup.SynchronizationContent {
+	inventoryInitWaitDuration := 30 * time.Second
 
 	var enforcedSecurityCloudView []securitygroup.SynchronizationContent
 	var wg sync.WaitGroup
@@ -828,18 +906,17 @@ func (c *awsCloud) GetEnforcedSecurity() []securitygroup.SynchronizationContent
 				return
 			}
 
-			serviceCfg, err := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			if err != nil {
-				awsPluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
-				return
-			}
-			ec2Service := serviceCfg.(*ec2ServiceConfig)
-			err = ec2Service.waitForInventoryInit(inventoryInitWaitDuration)
-			if err != nil {
-				awsPluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
-				return
+			var cloudView []securitygroup.SynchronizationContent
+			for _, ec2Service := range getEC2ServiceConfigs(accCfg) {
+				err := ec2Service.waitForInventoryInit(inventoryInitWaitDuration)
+				if err != nil {
+					awsPluginLogger().Error(err, "enforced-security-cloud-view GET for account region skipped", "account", accCfg.GetNamespacedName(),
+						"region", ec2Service.region)
+					continue
+				}
+				cloudView = append(cloudView, ec2Service.getNepheControllerManagedSecurityGroupsCloudView()...)
 			}
-			sendCh <- ec2Service.getNepheControllerManagedSecurityGroupsCloudView()
+			sendCh <- cloudView
 		}(accNamespacedNameCopy, ch)
 	}
 
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -31,6 +32,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
 )
 
@@ -210,6 +213,351 @@ var _ = Describe("AWS Cloud Security", func() {
 	})
 })
 
+var _ = Describe("AWS Cloud Security Rules", func() {
+	Context("Mixed IPv4 and IPv6 rule IPs", func() {
+		It("Should convert rule IPs to IPv4 and IPv6 ranges", func() {
+			_, ipv4Net, _ := net.ParseCIDR("10.0.0.0/24")
+			_, ipv6Net, _ := net.ParseCIDR("2001:db8::/64")
+
+			ipRanges, ipv6Ranges := convertToEc2IpRanges([]*net.IPNet{ipv4Net, ipv6Net}, false)
+			Expect(ipRanges).To(Equal([]*ec2.IpRange{{CidrIp: aws.String("10.0.0.0/24")}}))
+			Expect(ipv6Ranges).To(Equal([]*ec2.Ipv6Range{{CidrIpv6: aws.String("2001:db8::/64")}}))
+		})
+		It("Should convert no rule IPs to any IPv4 and IPv6 ranges", func() {
+			ipRanges, ipv6Ranges := convertToEc2IpRanges(nil, false)
+			Expect(ipRanges).To(Equal([]*ec2.IpRange{{CidrIp: aws.String(ipv4AnyCIDR)}}))
+			Expect(ipv6Ranges).To(Equal([]*ec2.Ipv6Range{{CidrIpv6: aws.String(ipv6AnyCIDR)}}))
+
+			ipRanges, ipv6Ranges = convertToEc2IpRanges(nil, true)
+			Expect(ipRanges).To(BeNil())
+			Expect(ipv6Ranges).To(BeNil())
+		})
+		It("Should convert IPv4 and IPv6 ranges to ingress rule IPs", func() {
+			ipPermissions := []*ec2.IpPermission{
+				{
+					IpProtocol: aws.String("6"),
+					FromPort:   aws.Int64(22),
+					ToPort:     aws.Int64(22),
+					IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("10.0.0.5/32")}},
+					Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String("2001:db8::5/128")}},
+				},
+			}
+			ingressRules := convertFromIPPermissionToIngressRule(ipPermissions, nil, nil)
+			Expect(ingressRules).To(HaveLen(1))
+			var ips []string
+			for _, ip := range ingressRules[0].FromSrcIP {
+				ips = append(ips, ip.String())
+			}
+			Expect(ips).To(Equal([]string{"10.0.0.5/32", "2001:db8::5/128"}))
+		})
+		It("Should convert IPv6 ranges of ICMP rule to ICMPv6 ip permission", func() {
+			icmp := 1
+			ipRanges, ipv6Ranges := convertToEc2IpRanges(nil, false)
+			startPort, endPort := convertToIPPermissionPort(nil, nil, aws.Int(8), nil, &icmp)
+			ipPermissions := splitICMPIpPermission(&ec2.IpPermission{
+				FromPort:   startPort,
+				ToPort:     endPort,
+				IpProtocol: convertToIPPermissionProtocol(&icmp),
+				IpRanges:   ipRanges,
+				Ipv6Ranges: ipv6Ranges,
+			})
+			Expect(ipPermissions).To(Equal([]*ec2.IpPermission{
+				{
+					FromPort:   startPort,
+					ToPort:     endPort,
+					IpProtocol: aws.String("1"),
+					IpRanges:   []*ec2.IpRange{{CidrIp: aws.String(ipv4AnyCIDR)}},
+				},
+				{
+					FromPort:   startPort,
+					ToPort:     endPort,
+					IpProtocol: aws.String("58"),
+					Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String(ipv6AnyCIDR)}},
+				},
+			}))
+
+			_, ipv6Net, _ := net.ParseCIDR("2001:db8::/64")
+			ipRanges, ipv6Ranges = convertToEc2IpRanges([]*net.IPNet{ipv6Net}, false)
+			ipPermissions = splitICMPIpPermission(&ec2.IpPermission{
+				IpProtocol: convertToIPPermissionProtocol(&icmp),
+				IpRanges:   ipRanges,
+				Ipv6Ranges: ipv6Ranges,
+			})
+			Expect(ipPermissions).To(HaveLen(1))
+			Expect(*ipPermissions[0].IpProtocol).To(Equal("58"))
+			Expect(ipPermissions[0].Ipv6Ranges).To(Equal([]*ec2.Ipv6Range{{CidrIpv6: aws.String("2001:db8::/64")}}))
+
+			tcp := 6
+			ipRanges, ipv6Ranges = convertToEc2IpRanges(nil, false)
+			ipPermissions = splitICMPIpPermission(&ec2.IpPermission{
+				IpProtocol: convertToIPPermissionProtocol(&tcp),
+				IpRanges:   ipRanges,
+				Ipv6Ranges: ipv6Ranges,
+			})
+			Expect(ipPermissions).To(HaveLen(1))
+			Expect(ipPermissions[0].Ipv6Ranges).To(HaveLen(1))
+		})
+	})
+	Context("Port ranges and ICMP", func() {
+		tcp, icmp := 6, 1
+
+		It("Should convert port range to ip permission ports", func() {
+			startPort, endPort := convertToIPPermissionPort(aws.Int(30000), aws.Int(32767), nil, nil, &tcp)
+			Expect(*startPort).To(Equal(int64(30000)))
+			Expect(*endPort).To(Equal(int64(32767)))
+
+			port, portEnd := convertFromIPPermissionPort(startPort, endPort)
+			Expect(*port).To(Equal(30000))
+			Expect(*portEnd).To(Equal(32767))
+		})
+		It("Should convert ICMP type and code to ip permission ports", func() {
+			startPort, endPort := convertToIPPermissionPort(nil, nil, aws.Int(8), nil, &icmp)
+			Expect(*startPort).To(Equal(int64(8)))
+			Expect(*endPort).To(Equal(icmpAnyTypeCode))
+
+			ipPermissions := []*ec2.IpPermission{
+				{IpProtocol: aws.String("icmp"), FromPort: startPort, ToPort: endPort},
+			}
+			egressRules := convertFromIPPermissionToEgressRule(ipPermissions, nil, nil)
+			Expect(egressRules).To(HaveLen(1))
+			Expect(*egressRules[0].ICMPType).To(Equal(8))
+			Expect(egressRules[0].ICMPCode).To(BeNil())
+			Expect(egressRules[0].ToPort).To(BeNil())
+		})
+	})
+	Context("Drop and Reject rule actions", func() {
+		c := &awsCloud{}
+
+		It("Should support Allow action only", func() {
+			Expect(c.IsRuleActionSupported(securitygroup.RuleActionAllow)).To(BeTrue())
+			Expect(c.IsRuleActionSupported(securitygroup.RuleActionDrop)).To(BeFalse())
+			Expect(c.IsRuleActionSupported(securitygroup.RuleActionReject)).To(BeFalse())
+		})
+		It("Should reject rules with Drop action", func() {
+			atID := &securitygroup.CloudResourceID{Name: "web", Vpc: "vpc-0"}
+			ingressRules := []*securitygroup.IngressRule{nil, {Action: securitygroup.RuleActionDrop}}
+
+			err := c.UpdateSecurityGroupRules(atID, ingressRules, nil)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+})
+
+var _ = Describe("AWS Cloud Security Preserved Security Groups", func() {
+	var (
+		testVpcID      = "vpc-0preserved"
+		testVMID       = "i-0preserved"
+		testNicID      = "eni-0preserved"
+		defaultSgID    = "sg-default"
+		userSgID       = "sg-user"
+		atSgID         = "sg-nephe-at"
+		appliedToGroup = &securitygroup.CloudResourceID{Name: "web", Vpc: testVpcID}
+		members        = []*securitygroup.CloudResource{
+			{Type: securitygroup.CloudResourceTypeVM, Name: securitygroup.CloudResourceID{Name: testVMID, Vpc: testVpcID}},
+		}
+
+		mockCtrl   *gomock.Controller
+		mockawsEC2 *MockawsEC2Wrapper
+		ec2Cfg     *ec2ServiceConfig
+		nicSgIDs   []string
+	)
+
+	setNetworkInterfaceSgs := func(sgIDs ...string) {
+		sgNames := map[string]string{defaultSgID: awsVpcDefaultSecurityGroupName, userSgID: "user",
+			atSgID: appliedToGroup.GetCloudName(false)}
+		var groups []*ec2.GroupIdentifier
+		for _, sgID := range sgIDs {
+			groups = append(groups, &ec2.GroupIdentifier{GroupId: aws.String(sgID), GroupName: aws.String(sgNames[sgID])})
+		}
+		networkInterface := &ec2.NetworkInterface{
+			NetworkInterfaceId: aws.String(testNicID),
+			VpcId:              aws.String(testVpcID),
+			Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String(testVMID)},
+			Groups:             groups,
+		}
+		mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{networkInterface}, nil).
+			AnyTimes()
+	}
+
+	setInstanceTag := func(value string) {
+		instance := &ec2.Instance{
+			InstanceId: aws.String(testVMID),
+			Tags:       []*ec2.Tag{{Key: aws.String(v1alpha1.PreserveSecurityGroupsTagKey), Value: aws.String(value)}},
+		}
+		ec2Cfg.resourcesCache.UpdateSnapshot(&ec2ResourcesCacheSnapshot{
+			instances: map[cloudcommon.InstanceID]*ec2.Instance{cloudcommon.InstanceID(testVMID): instance},
+			vpcIDs:    map[string]struct{}{testVpcID: {}},
+		})
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+		ec2Cfg = &ec2ServiceConfig{
+			apiClient:              mockawsEC2,
+			resourcesCache:         &internal.CloudServiceResourcesCache{},
+			preserveSecurityGroups: true,
+		}
+		nicSgIDs = nil
+
+		securityGroups := []*ec2.SecurityGroup{
+			{GroupId: aws.String(defaultSgID), GroupName: aws.String(awsVpcDefaultSecurityGroupName), VpcId: aws.String(testVpcID)},
+			{GroupId: aws.String(userSgID), GroupName: aws.String("user"), VpcId: aws.String(testVpcID)},
+			{GroupId: aws.String(atSgID), GroupName: aws.String(appliedToGroup.GetCloudName(false)), VpcId: aws.String(testVpcID)},
+		}
+		mockawsEC2.EXPECT().describeSecurityGroups(gomock.Any()).Return(
+			&ec2.DescribeSecurityGroupsOutput{SecurityGroups: securityGroups}, nil).AnyTimes()
+		mockawsEC2.EXPECT().modifyNetworkInterfaceAttribute(gomock.Any()).DoAndReturn(
+			func(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+				nicSgIDs = aws.StringValueSlice(input.Groups)
+				return &ec2.ModifyNetworkInterfaceAttributeOutput{}, nil
+			}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("Should attach AT security group alongside other security groups", func() {
+		setNetworkInterfaceSgs(userSgID)
+		err := ec2Cfg.updateSecurityGroupMembers(&atSgID, appliedToGroup.GetCloudName(false), testVpcID, members, false)
+		Expect(err).Should(BeNil())
+		Expect(nicSgIDs).To(ConsistOf(atSgID, userSgID))
+	})
+	It("Should detach AT security group without attaching default security group", func() {
+		setNetworkInterfaceSgs(atSgID, userSgID)
+		err := ec2Cfg.updateSecurityGroupMembers(&atSgID, appliedToGroup.GetCloudName(false), testVpcID, nil, false)
+		Expect(err).Should(BeNil())
+		Expect(nicSgIDs).To(ConsistOf(userSgID))
+	})
+	It("Should attach default security group to network interface left without security group", func() {
+		setNetworkInterfaceSgs(atSgID)
+		err := ec2Cfg.updateSecurityGroupMembers(&atSgID, appliedToGroup.GetCloudName(false), testVpcID, nil, false)
+		Expect(err).Should(BeNil())
+		Expect(nicSgIDs).To(ConsistOf(defaultSgID))
+	})
+	It("Should replace other security groups of instance not preserved by tag", func() {
+		setInstanceTag("false")
+		setNetworkInterfaceSgs(userSgID)
+		err := ec2Cfg.updateSecurityGroupMembers(&atSgID, appliedToGroup.GetCloudName(false), testVpcID, members, false)
+		Expect(err).Should(BeNil())
+		Expect(nicSgIDs).To(ConsistOf(atSgID))
+	})
+	It("Should report network interfaces with other security groups unless preserved", func() {
+		setInstanceTag("true")
+		setNetworkInterfaceSgs(atSgID, userSgID)
+		contents := ec2Cfg.getNepheControllerManagedSecurityGroupsCloudView()
+		Expect(contents).To(HaveLen(1))
+		Expect(contents[0].Members).To(HaveLen(1))
+		Expect(contents[0].MembersWithOtherSGAttached).To(BeEmpty())
+
+		setInstanceTag("false")
+		contents = ec2Cfg.getNepheControllerManagedSecurityGroupsCloudView()
+		Expect(contents).To(HaveLen(1))
+		Expect(contents[0].MembersWithOtherSGAttached).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("AWS Cloud Security Import", func() {
+	var (
+		testVpcID = "vpc-0import"
+		webSgID   = "sg-web"
+		dbSgID    = "sg-db"
+		atSgID    = "sg-nephe-at"
+
+		mockCtrl   *gomock.Controller
+		mockawsEC2 *MockawsEC2Wrapper
+		ec2Cfg     *ec2ServiceConfig
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+		ec2Cfg = &ec2ServiceConfig{
+			apiClient:      mockawsEC2,
+			resourcesCache: &internal.CloudServiceResourcesCache{},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("Should import security groups not created by nephe-controller with instance members", func() {
+		atSgName := (&securitygroup.CloudResourceID{Name: "web", Vpc: testVpcID}).GetCloudName(false)
+		securityGroups := []*ec2.SecurityGroup{
+			{
+				GroupId: aws.String(webSgID), GroupName: aws.String("web"), VpcId: aws.String(testVpcID),
+				IpPermissions: []*ec2.IpPermission{{
+					IpProtocol:       aws.String("tcp"),
+					FromPort:         aws.Int64(22),
+					ToPort:           aws.Int64(22),
+					IpRanges:         []*ec2.IpRange{{CidrIp: aws.String("10.0.0.0/16")}},
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String(dbSgID)}, {GroupId: aws.String("sg-peer")}},
+					PrefixListIds:    []*ec2.PrefixListId{{PrefixListId: aws.String("pl-01")}},
+				}},
+				IpPermissionsEgress: []*ec2.IpPermission{{
+					IpProtocol: aws.String(awsAnyProtocolValue),
+					IpRanges:   []*ec2.IpRange{{CidrIp: aws.String(ipv4AnyCIDR)}},
+				}},
+			},
+			{GroupId: aws.String(dbSgID), GroupName: aws.String("db"), VpcId: aws.String(testVpcID)},
+			{GroupId: aws.String(atSgID), GroupName: aws.String(atSgName), VpcId: aws.String(testVpcID)},
+		}
+		networkInterfaces := []*ec2.NetworkInterface{
+			{
+				NetworkInterfaceId: aws.String("eni-web"), VpcId: aws.String(testVpcID),
+				Attachment: &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-web")},
+				Groups:     []*ec2.GroupIdentifier{{GroupId: aws.String(webSgID)}},
+			},
+			{
+				NetworkInterfaceId: aws.String("eni-db"), VpcId: aws.String(testVpcID),
+				Attachment: &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-db")},
+				Groups:     []*ec2.GroupIdentifier{{GroupId: aws.String(dbSgID)}, {GroupId: aws.String(atSgID)}},
+			},
+			{
+				NetworkInterfaceId: aws.String("eni-lb"), VpcId: aws.String(testVpcID),
+				Groups: []*ec2.GroupIdentifier{{GroupId: aws.String(webSgID)}},
+			},
+		}
+		mockawsEC2.EXPECT().describeSecurityGroups(gomock.Any()).Return(
+			&ec2.DescribeSecurityGroupsOutput{SecurityGroups: securityGroups}, nil).Times(1)
+		mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return(networkInterfaces, nil).Times(1)
+
+		contents, err := ec2Cfg.getVpcSecurityGroupsImportView(testVpcID)
+		Expect(err).Should(BeNil())
+		Expect(contents).To(HaveLen(2))
+		Expect(contents[0].Resource).To(Equal(securitygroup.CloudResourceID{Name: "db", Vpc: testVpcID}))
+		Expect(contents[0].Members).To(Equal([]securitygroup.CloudResource{{Type: securitygroup.CloudResourceTypeVM,
+			Name: securitygroup.CloudResourceID{Name: "i-db", Vpc: testVpcID}}}))
+
+		web := contents[1]
+		Expect(web.Resource).To(Equal(securitygroup.CloudResourceID{Name: "web", Vpc: testVpcID}))
+		Expect(web.Members).To(Equal([]securitygroup.CloudResource{{Type: securitygroup.CloudResourceTypeVM,
+			Name: securitygroup.CloudResourceID{Name: "i-web", Vpc: testVpcID}}}))
+		Expect(web.Unsupported).To(ConsistOf("attached to network interface eni-lb of no instance",
+			"ingress rule refers to prefix list pl-01", "ingress rule refers to security group sg-peer not in vpc"))
+		Expect(web.IngressRules).To(HaveLen(1))
+		Expect(*web.IngressRules[0].Protocol).To(Equal(6))
+		Expect(*web.IngressRules[0].FromPort).To(Equal(22))
+		Expect(web.IngressRules[0].FromEndPort).To(BeNil())
+		Expect(web.IngressRules[0].FromSrcIP).To(HaveLen(1))
+		Expect(web.IngressRules[0].FromSrcIP[0].String()).To(Equal("10.0.0.0/16"))
+		Expect(web.IngressRules[0].FromSecurityGroups).To(Equal([]*securitygroup.CloudResourceID{{Name: "db", Vpc: testVpcID}}))
+		Expect(web.EgressRules).To(HaveLen(1))
+		Expect(web.EgressRules[0].Protocol).To(BeNil())
+		Expect(web.EgressRules[0].ToDstIP[0].String()).To(Equal(ipv4AnyCIDR))
+		// security groups referred to are not changed.
+		Expect(securityGroups[0].IpPermissions[0].UserIdGroupPairs).To(HaveLen(2))
+	})
+	It("Should import no security groups of vpc not in region", func() {
+		mockawsEC2.EXPECT().describeSecurityGroups(gomock.Any()).Return(&ec2.DescribeSecurityGroupsOutput{}, nil).Times(1)
+		contents, err := ec2Cfg.getVpcSecurityGroupsImportView(testVpcID)
+		Expect(err).Should(BeNil())
+		Expect(contents).To(BeEmpty())
+	})
+})
+
 func testAwsBuildDescribeSecurityGroupInput(vpcID string, sgNamesSet map[string]struct{}) *ec2.DescribeSecurityGroupsInput {
 	vpcIDs := []string{vpcID}
 	filters := buildAwsEc2FilterForSecurityGroupNameMatches(vpcIDs, sgNamesSet)
@@ -63,6 +63,51 @@ func (mr *MockawsServiceClientCreateInterfaceMockRecorder) compute() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "compute", reflect.TypeOf((*MockawsServiceClientCreateInterface)(nil).compute))
 }
 
+// identity mocks base method.
+func (m *MockawsServiceClientCreateInterface) identity() (awsSTSWrapper, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "identity")
+	ret0, _ := ret[0].(awsSTSWrapper)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// identity indicates an expected call of identity.
+func (mr *MockawsServiceClientCreateInterfaceMockRecorder) identity() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "identity", reflect.TypeOf((*MockawsServiceClientCreateInterface)(nil).identity))
+}
+
+// organizations mocks base method.
+func (m *MockawsServiceClientCreateInterface) organizations() (awsOrganizationsWrapper, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "organizations")
+	ret0, _ := ret[0].(awsOrganizationsWrapper)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// organizations indicates an expected call of organizations.
+func (mr *MockawsServiceClientCreateInterfaceMockRecorder) organizations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "organizations", reflect.TypeOf((*MockawsServiceClientCreateInterface)(nil).organizations))
+}
+
+// sqs mocks base method.
+func (m *MockawsServiceClientCreateInterface) sqs() (awsSQSWrapper, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "sqs")
+	ret0, _ := ret[0].(awsSQSWrapper)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// sqs indicates an expected call of sqs.
+func (mr *MockawsServiceClientCreateInterfaceMockRecorder) sqs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "sqs", reflect.TypeOf((*MockawsServiceClientCreateInterface)(nil).sqs))
+}
+
 // MockawsServicesHelper is a mock of awsServicesHelper interface.
 type MockawsServicesHelper struct {
 	ctrl     *gomock.Controller
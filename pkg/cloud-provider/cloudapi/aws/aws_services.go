@@ -16,32 +16,50 @@ package aws
 
 import (
 	"fmt"
+	"os"
+	"sort"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"k8s.io/apimachinery/pkg/types"
 
+	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 const (
 	awsComputeServiceNameEC2 = internal.CloudServiceName("EC2")
+
+	awsWebIdentityTokenFileEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	awsRoleSessionName         = "nephe-controller"
+	// awsDefaultRegion is the region of account wide api calls, when the account has no region other than all.
+	awsDefaultRegion = "us-east-1"
 )
 
 // awsServiceClientCreateInterface provides interface to create aws service clients.
 type awsServiceClientCreateInterface interface {
 	compute() (awsEC2Wrapper, error)
+	identity() (awsSTSWrapper, error)
+	organizations() (awsOrganizationsWrapper, error)
+	sqs() (awsSQSWrapper, error)
 	// Add any aws service (like rds, elb etc) apiClient creation methods here
 }
 
 // awsServiceSdkConfigProvider provides config required to create aws service (ec2) clients.
-// Implements awsServiceClientCreateInterface interface
-// NOTE: Currently supporting only static credentials based clients.
+// Implements awsServiceClientCreateInterface interface.
 type awsServiceSdkConfigProvider struct {
-	session *session.Session
+	session    *session.Session
+	apiLimiter *internal.CloudAPILimiter
 }
 
 // awsServicesHelper.
@@ -54,67 +72,269 @@ type awsServicesHelperImpl struct{}
 // newServiceSdkConfigProvider returns config to create aws services clients.
 func (h *awsServicesHelperImpl) newServiceSdkConfigProvider(accConfig *awsAccountConfig) (awsServiceClientCreateInterface, error) {
 	var creds *credentials.Credentials
+	var err error
 
-	if len(accConfig.RoleArn) != 0 {
-		// use role base access if role provided
-		// new session using worker node role, it should have AssumeRole permissions to the Customer's role ARN resource
-		sess, err := session.NewSession(&aws.Config{
-			Region:                        &accConfig.region,
-			CredentialsChainVerboseErrors: aws.Bool(true),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("unable to initialize AWS session: %v", err)
+	switch accConfig.credentialMode {
+	case v1alpha1.AWSCredentialModeWebIdentity:
+		creds, err = newWebIdentityCredentials(accConfig)
+	case v1alpha1.AWSCredentialModeInstanceProfile:
+		var sess *session.Session
+		if sess, err = newAwsSession(accConfig.region, nil); err != nil {
+			return nil, err
 		}
-
-		// configure to assume customer role and retrieve temporary credentials
-		externalID := &accConfig.ExternalID
-		if len(accConfig.ExternalID) == 0 {
-			externalID = nil
+		creds = credentials.NewCredentials(&ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(sess)})
+		if len(accConfig.RoleArn) != 0 {
+			creds, err = newAssumeRoleCredentials(accConfig, creds)
+		}
+	default:
+		if len(accConfig.AccessKeyID) != 0 && len(accConfig.AccessKeySecret) != 0 {
+			// use static credentials passed in
+			creds = credentials.NewStaticCredentials(accConfig.AccessKeyID, accConfig.AccessKeySecret, "")
+		}
+		if len(accConfig.RoleArn) != 0 {
+			// use role base access if role provided. Without static credentials, worker node role is used, it should
+			// have AssumeRole permissions to the Customer's role ARN resource.
+			creds, err = newAssumeRoleCredentials(accConfig, creds)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(accConfig.memberAccountID) != 0 {
+		// services of a member account assume the member role with the account credentials.
+		if creds, err = newMemberRoleCredentials(accConfig, creds); err != nil {
+			return nil, err
 		}
-		stsClient := sts.New(sess)
-		creds = credentials.NewCredentials(&stscreds.AssumeRoleProvider{
-			Client:     stsClient,
-			RoleARN:    accConfig.RoleArn,
-			ExternalID: externalID,
-		})
-	} else {
-		// use static credentials passed in
-		creds = credentials.NewStaticCredentials(accConfig.AccessKeyID, accConfig.AccessKeySecret, "")
-	}
-
-	awsConfig := &aws.Config{
-		Region:                        &accConfig.region,
-		Credentials:                   creds,
-		CredentialsChainVerboseErrors: aws.Bool(true),
 	}
 
-	sess, err := session.NewSession(awsConfig)
+	sess, err := newAwsSession(accConfig.region, creds)
 	if err != nil {
-		return nil, fmt.Errorf("unable to initialize AWS session: %v", err)
+		return nil, err
 	}
 	configProvider := &awsServiceSdkConfigProvider{
-		session: sess,
+		session:    sess,
+		apiLimiter: accConfig.apiLimiter,
 	}
 	return configProvider, nil
 }
 
+// newAwsSession returns an AWS session with given credentials. Default credentials chain is used if credentials are nil.
+// Throttled api calls are not retried by the session, but by the api limiter.
+func newAwsSession(region string, creds *credentials.Credentials) (*session.Session, error) {
+	sess, err := session.NewSession(request.WithRetryer(&aws.Config{
+		Region:                        &region,
+		Credentials:                   creds,
+		CredentialsChainVerboseErrors: aws.Bool(true),
+	}, awsRetryer{client.DefaultRetryer{NumMaxRetries: client.DefaultRetryerMaxNumRetries}}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize AWS session: %v", err)
+	}
+	return sess, nil
+}
+
+// newAssumeRoleCredentials returns temporary credentials of the account role, assumed with given base credentials.
+func newAssumeRoleCredentials(accConfig *awsAccountConfig, baseCreds *credentials.Credentials) (*credentials.Credentials, error) {
+	sess, err := newAwsSession(accConfig.region, baseCreds)
+	if err != nil {
+		return nil, err
+	}
+
+	// configure to assume customer role and retrieve temporary credentials
+	externalID := &accConfig.ExternalID
+	if len(accConfig.ExternalID) == 0 {
+		externalID = nil
+	}
+	return credentials.NewCredentials(&stscreds.AssumeRoleProvider{
+		Client:     sts.New(sess),
+		RoleARN:    accConfig.RoleArn,
+		ExternalID: externalID,
+		Duration:   accConfig.sessionDuration,
+	}), nil
+}
+
+// newMemberRoleCredentials returns temporary credentials of the member role in the member account of the config,
+// assumed with given account credentials.
+func newMemberRoleCredentials(accConfig *awsAccountConfig, baseCreds *credentials.Credentials) (*credentials.Credentials, error) {
+	sess, err := newAwsSession(accConfig.region, baseCreds)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewCredentials(&stscreds.AssumeRoleProvider{
+		Client:   sts.New(sess),
+		RoleARN:  getMemberRoleArn(accConfig.memberAccountID, accConfig.memberRoleName),
+		Duration: accConfig.sessionDuration,
+	}), nil
+}
+
+// getMemberRoleArn returns the ARN of the member role in a member account.
+func getMemberRoleArn(memberAccountID string, memberRoleName string) string {
+	return fmt.Sprintf("arn:aws:iam::%v:role/%v", memberAccountID, memberRoleName)
+}
+
+// newWebIdentityCredentials returns temporary credentials of the account role, assumed with the projected service
+// account token of nephe-controller.
+func newWebIdentityCredentials(accConfig *awsAccountConfig) (*credentials.Credentials, error) {
+	tokenFile := accConfig.webIdentityTokenFile
+	if len(tokenFile) == 0 {
+		tokenFile = os.Getenv(awsWebIdentityTokenFileEnv)
+	}
+	if len(tokenFile) == 0 {
+		return nil, fmt.Errorf("web identity token file not configured, and %v is not set", awsWebIdentityTokenFileEnv)
+	}
+
+	// AssumeRoleWithWebIdentity is not signed, the session needs no credentials.
+	sess, err := newAwsSession(accConfig.region, credentials.AnonymousCredentials)
+	if err != nil {
+		return nil, err
+	}
+	provider := stscreds.NewWebIdentityRoleProviderWithOptions(sts.New(sess), accConfig.RoleArn, awsRoleSessionName,
+		stscreds.FetchTokenPath(tokenFile))
+	provider.Duration = accConfig.sessionDuration
+	return credentials.NewCredentials(provider), nil
+}
+
+// identity returns AWS STS SDK apiClient.
+func (p *awsServiceSdkConfigProvider) identity() (awsSTSWrapper, error) {
+	awsSTS := &awsSTSWrapperImpl{
+		sts:        sts.New(p.session),
+		apiLimiter: p.apiLimiter,
+	}
+
+	return awsSTS, nil
+}
+
+// organizations returns AWS Organizations SDK apiClient.
+func (p *awsServiceSdkConfigProvider) organizations() (awsOrganizationsWrapper, error) {
+	awsOrganizations := &awsOrganizationsWrapperImpl{
+		organizations: organizations.New(p.session),
+		apiLimiter:    p.apiLimiter,
+	}
+
+	return awsOrganizations, nil
+}
+
 func newAwsServiceConfigs(accountNamespacedName *types.NamespacedName, accCredentials interface{}, awsSpecificHelper interface{}) (
 	[]internal.CloudServiceInterface, error) {
 	awsServicesHelper := awsSpecificHelper.(awsServicesHelper)
-	awsAccountCredentials := accCredentials.(*awsAccountConfig)
+	// account wide api calls, e.g. of member account discovery, share the rate limiter of the account.
+	awsAccountCredentials := *accCredentials.(*awsAccountConfig)
+	awsAccountCredentials.apiLimiter = newAwsAPILimiter(accountNamespacedName)
+
+	memberAccountIDs, err := getMemberAccountIDs(awsServicesHelper, &awsAccountCredentials)
+	if err != nil {
+		return nil, err
+	}
+	accountConfigs := []*awsAccountConfig{&awsAccountCredentials}
+	for _, memberAccountID := range memberAccountIDs {
+		memberCredentials := awsAccountCredentials
+		memberCredentials.memberAccountID = memberAccountID
+		accountConfigs = append(accountConfigs, &memberCredentials)
+	}
 
+	// one ec2 service is created per account and region, each with api clients of its account and region.
 	var serviceConfigs []internal.CloudServiceInterface
+	for _, accountConfig := range accountConfigs {
+		regions, err := getAccountRegions(awsServicesHelper, accountConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, region := range regions {
+			regionalCredentials := *accountConfig
+			regionalCredentials.region = region
+			// AWS throttles api calls per account and region, each has its own rate limiter.
+			regionalCredentials.apiLimiter = newAwsAPILimiter(accountNamespacedName)
+			awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(&regionalCredentials)
+			if err != nil {
+				return nil, err
+			}
+
+			ec2Service, err := newEC2ServiceConfig(accountNamespacedName.String(), awsServiceClientCreator, region,
+				accountConfig.memberAccountID, accountConfig.preserveSecurityGroups)
+			if err != nil {
+				return nil, err
+			}
+			serviceConfigs = append(serviceConfigs, ec2Service)
+		}
+	}
+
+	return serviceConfigs, nil
+}
+
+// getMemberAccountIDs returns the member accounts of the account. With member account discovery, the active accounts of
+// the organization of the account, other than the account itself, are added.
+func getMemberAccountIDs(awsServicesHelper awsServicesHelper, accConfig *awsAccountConfig) ([]string, error) {
+	if !accConfig.discoverMemberAccounts {
+		return accConfig.memberAccountIDs, nil
+	}
 
-	awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(awsAccountCredentials)
+	awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(accConfig)
 	if err != nil {
 		return nil, err
 	}
-
-	ec2Service, err := newEC2ServiceConfig(accountNamespacedName.String(), awsServiceClientCreator)
+	stsClient, err := awsServiceClientCreator.identity()
 	if err != nil {
 		return nil, err
 	}
-	serviceConfigs = append(serviceConfigs, ec2Service)
+	identity, err := stsClient.getCallerIdentityWrapper(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get account ID: %v", err)
+	}
+	organizationsClient, err := awsServiceClientCreator.organizations()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := organizationsClient.pagedListAccountsWrapper(&organizations.ListAccountsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover member accounts: %v", err)
+	}
 
-	return serviceConfigs, nil
+	memberAccountIDs := append([]string{}, accConfig.memberAccountIDs...)
+	for _, account := range accounts {
+		if aws.StringValue(account.Status) != organizations.AccountStatusActive ||
+			aws.StringValue(account.Id) == aws.StringValue(identity.Account) {
+			continue
+		}
+		memberAccountIDs = append(memberAccountIDs, aws.StringValue(account.Id))
+	}
+	memberAccountIDs = utils.GetAccountIDs(memberAccountIDs)
+	sort.Strings(memberAccountIDs)
+	return memberAccountIDs, nil
+}
+
+// hasAllRegions returns whether account credentials select all regions enabled for the account, in which case services
+// of the account are created again every inventory resync interval, for regions enabled since.
+func hasAllRegions(credentials interface{}) bool {
+	for _, region := range credentials.(*awsAccountConfig).regions {
+		if region == v1alpha1.AllRegions {
+			return true
+		}
+	}
+	return false
+}
+
+// getAccountRegions returns the regions of the account. all is resolved to the regions enabled for the account.
+func getAccountRegions(awsServicesHelper awsServicesHelper, accConfig *awsAccountConfig) ([]string, error) {
+	if !hasAllRegions(accConfig) {
+		return accConfig.regions, nil
+	}
+
+	awsServiceClientCreator, err := awsServicesHelper.newServiceSdkConfigProvider(accConfig)
+	if err != nil {
+		return nil, err
+	}
+	ec2Client, err := awsServiceClientCreator.compute()
+	if err != nil {
+		return nil, err
+	}
+	output, err := ec2Client.describeRegionsWrapper(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get regions enabled for account: %v", err)
+	}
+	var regions []string
+	for _, region := range output.Regions {
+		regions = append(regions, aws.StringValue(region.RegionName))
+	}
+	sort.Strings(regions)
+	return regions, nil
 }
@@ -17,15 +17,25 @@ package aws
 import (
 	"context"
 	"errors"
+	"net/http"
 	"reflect"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
+	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -33,11 +43,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 	. "github.com/onsi/ginkgo"
 )
 
 var (
 	testVpcID01 = "vpc-cb82c3b2"
+	testRegion  = "us-east-1"
 )
 
 var _ = Describe("AWS cloud", func() {
@@ -208,6 +220,204 @@ var _ = Describe("AWS cloud", func() {
 				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, instanceIds)
 				Expect(err).Should(BeNil())
 			})
+			It("Should discover instances except excluded", func() {
+				instanceIds := []string{"i-01", "i-02", "i-03"}
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(getEc2InstanceObject(instanceIds), nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				selector.Spec.VMSelector = []v1alpha1.VirtualMachineSelector{
+					{
+						ExcludeMatch: []v1alpha1.EntityMatch{{MatchID: "i-02"}},
+					},
+				}
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, []string{"i-01", "i-03"})
+				Expect(err).Should(BeNil())
+			})
+			It("Should report account status after inventory poll", func() {
+				instanceIds := []string{"i-01", "i-02"}
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(getEc2InstanceObject(instanceIds), nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				status, err := c.GetAccountStatus(&testAccountNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(meta.IsStatusConditionFalse(status.Conditions, v1alpha1.CloudProviderAccountConditionInventorySynced)).To(BeTrue())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+				Eventually(func() bool {
+					status, _ = c.GetAccountStatus(&testAccountNamespacedName)
+					return meta.IsStatusConditionTrue(status.Conditions, v1alpha1.CloudProviderAccountConditionInventorySynced)
+				}, 5*time.Second).Should(BeTrue())
+				Expect(meta.IsStatusConditionTrue(status.Conditions, v1alpha1.CloudProviderAccountConditionCredentialsValid)).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(status.Conditions,
+					v1alpha1.CloudProviderAccountConditionSecurityEnforcementReady)).To(BeTrue())
+				Expect(status.Error).To(BeEmpty())
+				Expect(status.LastSuccessfulPollTime).To(Not(BeNil()))
+				Expect(status.Services).To(HaveLen(1))
+				Expect(status.Services[0].Name).To(Equal(string(getEC2ServiceName(testRegion))))
+				Expect(status.Services[0].SuccessfulPollCount).To(Equal(status.Services[0].TotalPollCount))
+				Expect(status.Services[0].VirtualMachineCount).To(Equal(len(instanceIds)))
+			})
+			It("Should report account status on inventory poll failure", func() {
+				pollErr := awserr.New("AuthFailure", "AWS was not able to validate the provided access credentials", nil)
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(nil, pollErr).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+				var status *v1alpha1.CloudProviderAccountStatus
+				Eventually(func() string {
+					status, _ = c.GetAccountStatus(&testAccountNamespacedName)
+					return status.Error
+				}, 5*time.Second).Should(ContainSubstring("AuthFailure"))
+				Expect(meta.IsStatusConditionFalse(status.Conditions, v1alpha1.CloudProviderAccountConditionCredentialsValid)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(status.Conditions, v1alpha1.CloudProviderAccountConditionInventorySynced)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(status.Conditions,
+					v1alpha1.CloudProviderAccountConditionSecurityEnforcementReady)).To(BeTrue())
+				Expect(status.LastSuccessfulPollTime).To(BeNil())
+				Expect(status.Services).To(HaveLen(1))
+				Expect(status.Services[0].SuccessfulPollCount).To(BeZero())
+				Expect(status.Services[0].LastPollError).To(ContainSubstring("AuthFailure"))
+			})
+			It("Should not report credentials invalid on throttled inventory poll", func() {
+				pollErr := awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil)
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(nil, pollErr).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+				var status *v1alpha1.CloudProviderAccountStatus
+				Eventually(func() string {
+					status, _ = c.GetAccountStatus(&testAccountNamespacedName)
+					return status.Error
+				}, 5*time.Second).Should(ContainSubstring("RequestLimitExceeded"))
+				Expect(meta.FindStatusCondition(status.Conditions, v1alpha1.CloudProviderAccountConditionCredentialsValid)).To(BeNil())
+				Expect(meta.IsStatusConditionFalse(status.Conditions, v1alpha1.CloudProviderAccountConditionInventorySynced)).To(BeTrue())
+			})
+			It("Should keep serving restored snapshot on inventory poll failure", func() {
+				pollErr := errors.New("RequestLimitExceeded: Request limit exceeded")
+				var polled int32
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).DoAndReturn(
+					func(_ *ec2.DescribeInstancesInput) ([]*ec2.Instance, error) {
+						atomic.AddInt32(&polled, 1)
+						return nil, pollErr
+					}).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				restored := []*v1alpha1.VirtualMachine{{ObjectMeta: v1.ObjectMeta{Name: "i-01", Namespace: selector.Namespace}}}
+				err = c.AddAccountResourceSelectorWithSnapshot(&testAccountNamespacedName, selector, restored)
+				Expect(err).Should(BeNil())
+				Eventually(func() int32 { return atomic.LoadInt32(&polled) }, 5*time.Second).Should(BeNumerically(">=", 2))
+
+				selectorNamespacedName := &types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}
+				vms, err := c.InstancesGivenProviderAccountSelector(&testAccountNamespacedName, selectorNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(vms).To(HaveLen(1))
+				Expect(vms[0].Name).To(Equal("i-01"))
+			})
+			It("Should place instances in namespace of each selector", func() {
+				instanceIds := []string{"i-01", "i-02"}
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(getEc2InstanceObject(instanceIds), nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				// selector in other namespace, and selector in same namespace overlapping with selector-all.
+				otherNamespaceSelector := selector.DeepCopy()
+				otherNamespaceSelector.Namespace = "namespace02"
+				otherNamespaceSelector.Spec.AccountNamespace = testAccountNamespacedName.Namespace
+				otherNamespaceSelector.Spec.VMSelector = []v1alpha1.VirtualMachineSelector{
+					{VpcMatch: &v1alpha1.EntityMatch{MatchID: testVpcID01}},
+				}
+				overlappingSelector := selector.DeepCopy()
+				overlappingSelector.Name = "selector-overlap"
+				for _, s := range []*v1alpha1.CloudEntitySelector{selector, otherNamespaceSelector, overlappingSelector} {
+					Expect(c.AddAccountResourceSelector(&testAccountNamespacedName, s)).Should(BeNil())
+				}
+
+				selectorNamespacedName := &types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}
+				otherNamespaceSelectorNamespacedName := &types.NamespacedName{Namespace: otherNamespaceSelector.Namespace,
+					Name: otherNamespaceSelector.Name}
+				overlappingSelectorNamespacedName := &types.NamespacedName{Namespace: overlappingSelector.Namespace,
+					Name: overlappingSelector.Name}
+				vms, err := c.InstancesGivenProviderAccountSelector(&testAccountNamespacedName, selectorNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(vms).To(HaveLen(len(instanceIds)))
+				for _, vm := range vms {
+					Expect(vm.Namespace).To(Equal(selector.Namespace))
+				}
+				vms, err = c.InstancesGivenProviderAccountSelector(&testAccountNamespacedName, otherNamespaceSelectorNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(vms).To(HaveLen(len(instanceIds)))
+				for _, vm := range vms {
+					Expect(vm.Namespace).To(Equal(otherNamespaceSelector.Namespace))
+				}
+				// instances are already owned by selector-all in the same namespace.
+				vms, err = c.InstancesGivenProviderAccountSelector(&testAccountNamespacedName, overlappingSelectorNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(vms).To(BeEmpty())
+				vms, err = c.InstancesGivenProviderAccount(&testAccountNamespacedName)
+				Expect(err).Should(BeNil())
+				Expect(vms).To(HaveLen(2 * len(instanceIds)))
+
+				// removing a selector keeps filters and cached instances of other selectors.
+				c.RemoveAccountResourcesSelector(&testAccountNamespacedName, selectorNamespacedName)
+				accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+				instanceFilters := serviceConfig.(*ec2ServiceConfig).instanceFilters
+				Expect(instanceFilters).To(HaveLen(2))
+				Expect(instanceFilters).To(HaveKey(*otherNamespaceSelectorNamespacedName))
+				Expect(instanceFilters).To(HaveKey(*overlappingSelectorNamespacedName))
+				snapshot := serviceConfig.(*ec2ServiceConfig).resourcesCache.GetSnapshot().(*ec2ResourcesCacheSnapshot)
+				Expect(snapshot.selectorInstances).NotTo(HaveKey(*selectorNamespacedName))
+				Expect(snapshot.selectorInstances[*otherNamespaceSelectorNamespacedName]).To(HaveLen(len(instanceIds)))
+				Expect(snapshot.instances).To(HaveLen(len(instanceIds)))
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, instanceIds)
+				Expect(err).Should(BeNil())
+			})
 			It("Should not call cloud api's with NO selector", func() {
 				instanceIds := []string{}
 				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return(getEc2InstanceObject(instanceIds), nil).Times(0)
@@ -222,6 +432,410 @@ var _ = Describe("AWS cloud", func() {
 				Expect(found).To(BeTrue())
 				Expect(accCfg).To(Not(BeNil()))
 			})
+			It("Should stop retrying throttled api calls of a removed account", func() {
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				throttledErr := awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil)
+				done := make(chan error)
+				go func() {
+					done <- newAwsAPILimiter(&testAccountNamespacedName).Do("DescribeInstances", func() error {
+						return throttledErr
+					})
+				}()
+				Consistently(done, time.Second).ShouldNot(Receive())
+				c.RemoveProviderAccount(&testAccountNamespacedName)
+				Eventually(done, time.Second).Should(Receive(Equal(throttledErr)))
+			})
+		})
+		Context("Credentials probe", func() {
+			var (
+				mockawsService *MockawsServiceClientCreateInterface
+				mockawsEC2     *MockawsEC2Wrapper
+				mockawsSTS     *MockawsSTSWrapper
+			)
+
+			BeforeEach(func() {
+				mockawsService = NewMockawsServiceClientCreateInterface(mockCtrl)
+				mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+				mockawsSTS = NewMockawsSTSWrapper(mockCtrl)
+
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil)
+				mockawsService.EXPECT().compute().Return(mockawsEC2, nil).AnyTimes()
+				mockawsService.EXPECT().identity().Return(mockawsSTS, nil)
+				_ = fakeClient.Create(context.Background(), secret)
+			})
+
+			It("Should accept credentials accepted by cloud", func() {
+				mockawsSTS.EXPECT().getCallerIdentityWrapper(gomock.Any()).Return(&sts.GetCallerIdentityOutput{}, nil)
+				mockawsEC2.EXPECT().describeVpcsWrapper(&ec2.DescribeVpcsInput{MaxResults: aws.Int64(5)}).
+					Return(&ec2.DescribeVpcsOutput{}, nil)
+
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.ProbeProviderAccountCredentials(fakeClient, account)
+				Expect(err).Should(BeNil())
+				_, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeFalse())
+			})
+			It("Should reject credentials not accepted by cloud", func() {
+				mockawsSTS.EXPECT().getCallerIdentityWrapper(gomock.Any()).
+					Return(nil, errors.New("InvalidClientTokenId: The security token included in the request is invalid"))
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Times(0)
+
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.ProbeProviderAccountCredentials(fakeClient, account)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("InvalidClientTokenId"))
+			})
+		})
+		Context("Multiple regions", func() {
+			var (
+				mockawsService *MockawsServiceClientCreateInterface
+				mockawsEC2     *MockawsEC2Wrapper
+			)
+
+			BeforeEach(func() {
+				mockawsService = NewMockawsServiceClientCreateInterface(mockCtrl)
+				mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+				mockawsService.EXPECT().compute().Return(mockawsEC2, nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+			})
+
+			It("Should merge region and regions of account", func() {
+				account.Spec.AWSConfig.Regions = []string{" us-west-2", "us-east-1", ""}
+				accCfg, err := setAccountCredentials(fakeClient, account.Spec.AWSConfig)
+				Expect(err).Should(BeNil())
+				Expect(accCfg.(*awsAccountConfig).regions).To(Equal([]string{"us-east-1", "us-west-2"}))
+				Expect(accCfg.(*awsAccountConfig).region).To(Equal("us-east-1"))
+
+				account.Spec.AWSConfig.Region = ""
+				account.Spec.AWSConfig.Regions = []string{v1alpha1.AllRegions}
+				accCfg, err = setAccountCredentials(fakeClient, account.Spec.AWSConfig)
+				Expect(err).Should(BeNil())
+				Expect(accCfg.(*awsAccountConfig).regions).To(Equal([]string{v1alpha1.AllRegions}))
+				Expect(accCfg.(*awsAccountConfig).region).To(Equal(awsDefaultRegion))
+			})
+			It("Should create ec2 service per region and route vpc to its region", func() {
+				var clientRegions []string
+				var clientLimiters []*internal.CloudAPILimiter
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).DoAndReturn(
+					func(accCfg *awsAccountConfig) (awsServiceClientCreateInterface, error) {
+						clientRegions = append(clientRegions, accCfg.region)
+						clientLimiters = append(clientLimiters, accCfg.apiLimiter)
+						return mockawsService, nil
+					}).Times(3)
+
+				account.Spec.AWSConfig.Regions = []string{"us-west-2"}
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				Expect(clientRegions).To(Equal([]string{testRegion, "us-west-2"}))
+				// api calls are rate limited per region.
+				Expect(clientLimiters[0]).NotTo(BeNil())
+				Expect(clientLimiters[1]).NotTo(BeNil())
+				Expect(clientLimiters[0]).NotTo(BeIdenticalTo(clientLimiters[1]))
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				ec2Services := getEC2ServiceConfigs(accCfg)
+				Expect(ec2Services).To(HaveLen(2))
+				Expect(ec2Services[0].GetName()).To(Equal(getEC2ServiceName(testRegion)))
+				Expect(ec2Services[1].GetName()).To(Equal(getEC2ServiceName("us-west-2")))
+
+				ec2Services[1].resourcesCache.UpdateSnapshot(&ec2ResourcesCacheSnapshot{
+					vpcIDs: map[string]struct{}{testVpcID01: {}},
+				})
+				vpcAccCfg, ec2Service := c.getVpcAccount(testVpcID01)
+				Expect(vpcAccCfg).To(Equal(accCfg))
+				Expect(ec2Service).To(Equal(ec2Services[1]))
+				_, ec2Service = c.getVpcAccount("vpc-unknown")
+				Expect(ec2Service).To(BeNil())
+
+				// removing a region from account removes the ec2 service of the region.
+				account.Spec.AWSConfig.Regions = nil
+				err = c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				ec2Services = getEC2ServiceConfigs(accCfg)
+				Expect(ec2Services).To(HaveLen(1))
+				Expect(ec2Services[0].GetName()).To(Equal(getEC2ServiceName(testRegion)))
+				_, ec2Service = c.getVpcAccount(testVpcID01)
+				Expect(ec2Service).To(BeNil())
+			})
+			It("Should create ec2 service per region enabled for account with all regions", func() {
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil).Times(3)
+				mockawsEC2.EXPECT().describeRegionsWrapper(gomock.Any()).Return(&ec2.DescribeRegionsOutput{
+					Regions: []*ec2.Region{{RegionName: aws.String("us-west-2")}, {RegionName: aws.String("eu-west-1")}},
+				}, nil)
+
+				account.Spec.AWSConfig.Region = ""
+				account.Spec.AWSConfig.Regions = []string{v1alpha1.AllRegions}
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				ec2Services := getEC2ServiceConfigs(accCfg)
+				Expect(ec2Services).To(HaveLen(2))
+				Expect(ec2Services[0].GetName()).To(Equal(getEC2ServiceName("eu-west-1")))
+				Expect(ec2Services[1].GetName()).To(Equal(getEC2ServiceName("us-west-2")))
+			})
+			It("Should create ec2 service of region enabled for account with all regions every resync interval", func() {
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeRegionsWrapper(gomock.Any()).Return(&ec2.DescribeRegionsOutput{
+					Regions: []*ec2.Region{{RegionName: aws.String("us-west-2")}},
+				}, nil)
+				// eu-west-1 is enabled for the account after it is added.
+				mockawsEC2.EXPECT().describeRegionsWrapper(gomock.Any()).Return(&ec2.DescribeRegionsOutput{
+					Regions: []*ec2.Region{{RegionName: aws.String("us-west-2")}, {RegionName: aws.String("eu-west-1")}},
+				}, nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).Return([]*ec2.Instance{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+
+				account.Spec.AWSConfig.Region = ""
+				account.Spec.AWSConfig.Regions = []string{v1alpha1.AllRegions}
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				Expect(getEC2ServiceConfigs(accCfg)).To(HaveLen(1))
+
+				selector := &v1alpha1.CloudEntitySelector{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "selector-all",
+						Namespace: testAccountNamespacedName.Namespace,
+					},
+					Spec: v1alpha1.CloudEntitySelectorSpec{
+						AccountName: testAccountNamespacedName.Name,
+						VMSelector:  []v1alpha1.VirtualMachineSelector{},
+					},
+				}
+				err = c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(err).Should(BeNil())
+				Eventually(func() []string {
+					var names []string
+					for _, ec2Service := range getEC2ServiceConfigs(accCfg) {
+						names = append(names, string(ec2Service.GetName()))
+					}
+					return names
+				}, 5*time.Second).Should(Equal([]string{string(getEC2ServiceName("eu-west-1")),
+					string(getEC2ServiceName("us-west-2"))}))
+				c.RemoveProviderAccount(&testAccountNamespacedName)
+			})
+			It("Should fail account add when regions enabled for account are unknown", func() {
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil)
+				mockawsEC2.EXPECT().describeRegionsWrapper(gomock.Any()).Return(nil, errors.New("UnauthorizedOperation"))
+
+				account.Spec.AWSConfig.Regions = []string{v1alpha1.AllRegions}
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("UnauthorizedOperation"))
+			})
+		})
+		Context("Member accounts", func() {
+			var (
+				mockawsService       *MockawsServiceClientCreateInterface
+				mockawsEC2           *MockawsEC2Wrapper
+				mockawsSTS           *MockawsSTSWrapper
+				mockawsOrganizations *MockawsOrganizationsWrapper
+				memberAccountID      = "111111111111"
+			)
+
+			BeforeEach(func() {
+				mockawsService = NewMockawsServiceClientCreateInterface(mockCtrl)
+				mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+				mockawsSTS = NewMockawsSTSWrapper(mockCtrl)
+				mockawsOrganizations = NewMockawsOrganizationsWrapper(mockCtrl)
+				mockawsService.EXPECT().compute().Return(mockawsEC2, nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+			})
+
+			It("Should create ec2 services of member accounts assuming member role", func() {
+				var memberConfigs []*awsAccountConfig
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).DoAndReturn(
+					func(accCfg *awsAccountConfig) (awsServiceClientCreateInterface, error) {
+						if len(accCfg.memberAccountID) != 0 {
+							memberConfigs = append(memberConfigs, accCfg)
+						}
+						return mockawsService, nil
+					}).Times(2)
+
+				account.Spec.AWSConfig.MemberAccountIDs = []string{memberAccountID}
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				ec2Services := getEC2ServiceConfigs(accCfg)
+				Expect(ec2Services).To(HaveLen(2))
+				Expect(ec2Services[0].GetName()).To(Equal(getEC2ServiceName(testRegion)))
+				Expect(ec2Services[1].GetName()).To(Equal(getMemberEC2ServiceName(memberAccountID, testRegion)))
+
+				Expect(memberConfigs).To(HaveLen(1))
+				Expect(getMemberRoleArn(memberConfigs[0].memberAccountID, memberConfigs[0].memberRoleName)).
+					To(Equal("arn:aws:iam::111111111111:role/" + v1alpha1.AWSDefaultMemberRoleName))
+				_, err = (&awsServicesHelperImpl{}).newServiceSdkConfigProvider(memberConfigs[0])
+				Expect(err).Should(BeNil())
+			})
+			It("Should discover active member accounts of organization", func() {
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil).Times(4)
+				mockawsService.EXPECT().identity().Return(mockawsSTS, nil)
+				mockawsService.EXPECT().organizations().Return(mockawsOrganizations, nil)
+				mockawsSTS.EXPECT().getCallerIdentityWrapper(gomock.Any()).
+					Return(&sts.GetCallerIdentityOutput{Account: aws.String("000000000000")}, nil)
+				mockawsOrganizations.EXPECT().pagedListAccountsWrapper(gomock.Any()).Return([]*organizations.Account{
+					{Id: aws.String("000000000000"), Status: aws.String(organizations.AccountStatusActive)},
+					{Id: aws.String("333333333333"), Status: aws.String(organizations.AccountStatusSuspended)},
+					{Id: aws.String(memberAccountID), Status: aws.String(organizations.AccountStatusActive)},
+				}, nil)
+
+				account.Spec.AWSConfig.MemberAccountIDs = []string{"222222222222"}
+				account.Spec.AWSConfig.DiscoverMemberAccounts = true
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				ec2Services := getEC2ServiceConfigs(accCfg)
+				Expect(ec2Services).To(HaveLen(3))
+				Expect(ec2Services[0].GetName()).To(Equal(getEC2ServiceName(testRegion)))
+				Expect(ec2Services[1].GetName()).To(Equal(getMemberEC2ServiceName(memberAccountID, testRegion)))
+				Expect(ec2Services[2].GetName()).To(Equal(getMemberEC2ServiceName("222222222222", testRegion)))
+			})
+			It("Should fail account add when member accounts cannot be discovered", func() {
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil)
+				mockawsService.EXPECT().identity().Return(mockawsSTS, nil)
+				mockawsService.EXPECT().organizations().Return(mockawsOrganizations, nil)
+				mockawsSTS.EXPECT().getCallerIdentityWrapper(gomock.Any()).
+					Return(&sts.GetCallerIdentityOutput{Account: aws.String("000000000000")}, nil)
+				mockawsOrganizations.EXPECT().pagedListAccountsWrapper(gomock.Any()).
+					Return(nil, errors.New("AWSOrganizationsNotInUseException"))
+
+				account.Spec.AWSConfig.DiscoverMemberAccounts = true
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("AWSOrganizationsNotInUseException"))
+			})
+		})
+		Context("Event queue", func() {
+			var (
+				selector       *v1alpha1.CloudEntitySelector
+				mockawsService *MockawsServiceClientCreateInterface
+				mockawsEC2     *MockawsEC2Wrapper
+				mockawsSQS     *MockawsSQSWrapper
+				fullInventory  int32
+				eventInventory int32
+			)
+
+			BeforeEach(func() {
+				var resyncIntv uint = 3600
+				account.Spec.ResyncIntervalInSeconds = &resyncIntv
+				account.Spec.AWSConfig.EventQueueURL = "https://sqs.us-east-1.amazonaws.com/000000000000/nephe-events"
+				selector = &v1alpha1.CloudEntitySelector{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "selector-all",
+						Namespace: testAccountNamespacedName.Namespace,
+					},
+					Spec: v1alpha1.CloudEntitySelectorSpec{
+						AccountName: testAccountNamespacedName.Name,
+						VMSelector:  []v1alpha1.VirtualMachineSelector{},
+					},
+				}
+				atomic.StoreInt32(&fullInventory, 0)
+				atomic.StoreInt32(&eventInventory, 0)
+
+				mockawsService = NewMockawsServiceClientCreateInterface(mockCtrl)
+				mockawsEC2 = NewMockawsEC2Wrapper(mockCtrl)
+				mockawsSQS = NewMockawsSQSWrapper(mockCtrl)
+				mockawsCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockawsService, nil).AnyTimes()
+				mockawsService.EXPECT().compute().Return(mockawsEC2, nil).AnyTimes()
+				mockawsService.EXPECT().sqs().Return(mockawsSQS, nil).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeInstancesWrapper(gomock.Any()).DoAndReturn(
+					func(input *ec2.DescribeInstancesInput) ([]*ec2.Instance, error) {
+						for _, filter := range input.Filters {
+							if aws.StringValue(filter.Name) == awsFilterKeyVMID {
+								atomic.AddInt32(&eventInventory, 1)
+								// i-01 is terminated, i-02 is launched.
+								return getEc2InstanceObject([]string{"i-02"}), nil
+							}
+						}
+						atomic.AddInt32(&fullInventory, 1)
+						return getEc2InstanceObject([]string{"i-01"}), nil
+					}).AnyTimes()
+				mockawsEC2.EXPECT().pagedDescribeNetworkInterfaces(gomock.Any()).Return([]*ec2.NetworkInterface{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcsWrapper(gomock.Any()).Return(&ec2.DescribeVpcsOutput{}, nil).AnyTimes()
+				mockawsEC2.EXPECT().describeVpcPeeringConnectionsWrapper(gomock.Any()).Return(&ec2.DescribeVpcPeeringConnectionsOutput{},
+					nil).AnyTimes()
+				_ = fakeClient.Create(context.Background(), secret)
+			})
+
+			It("Should parse instance IDs of EventBridge events", func() {
+				event := parseAwsEvent(`{"detail-type": "Tag Change on Resource", "account": "000000000000",
+					"region": "us-east-1", "resources": ["arn:aws:ec2:us-east-1:000000000000:instance/i-02",
+					"arn:aws:ec2:us-east-1:000000000000:volume/vol-01"], "detail": {"instance-id": "i-01"}}`)
+				Expect(event.AccountID).To(Equal("000000000000"))
+				Expect(event.Region).To(Equal(testRegion))
+				Expect(event.ResourceIDs).To(Equal([]string{"i-01", "i-02"}))
+
+				event = parseAwsEvent("not an event")
+				Expect(event.ResourceIDs).To(BeEmpty())
+
+				region, err := getSQSQueueRegion("https://us-west-2.queue.amazonaws.com/000000000000/nephe-events")
+				Expect(err).Should(BeNil())
+				Expect(region).To(Equal("us-west-2"))
+			})
+			It("Should update instances of events, and delete events", func() {
+				var deleted int32
+				received := false
+				mockawsSQS.EXPECT().receiveMessageWrapper(gomock.Any()).DoAndReturn(
+					func(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+						// events are sent once the first inventory is done, and are received once.
+						if received || atomic.LoadInt32(&fullInventory) == 0 {
+							return &sqs.ReceiveMessageOutput{}, nil
+						}
+						received = true
+						return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{
+							{
+								ReceiptHandle: aws.String("handle-01"),
+								Body: aws.String(`{"detail-type": "EC2 Instance State-change Notification",
+									"account": "000000000000", "region": "us-east-1", "detail": {"instance-id": "i-01"}}`),
+							},
+							{
+								ReceiptHandle: aws.String("handle-02"),
+								Body: aws.String(`{"detail-type": "EC2 Instance State-change Notification",
+									"account": "000000000000", "region": "us-east-1", "detail": {"instance-id": "i-02"}}`),
+							},
+						}}, nil
+					}).AnyTimes()
+				mockawsSQS.EXPECT().deleteMessageBatchWrapper(gomock.Any()).DoAndReturn(
+					func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+						Expect(aws.StringValue(input.Entries[0].ReceiptHandle)).To(Equal("handle-01"))
+						Expect(aws.StringValue(input.Entries[1].ReceiptHandle)).To(Equal("handle-02"))
+						atomic.AddInt32(&deleted, int32(len(input.Entries)))
+						return &sqs.DeleteMessageBatchOutput{}, nil
+					}).AnyTimes()
+
+				c := newAWSCloud(mockawsCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+				err = c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(err).Should(BeNil())
+
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, []string{"i-01"})
+				Expect(err).Should(BeNil())
+				Eventually(func() int32 { return atomic.LoadInt32(&deleted) }, 15*time.Second).Should(Equal(int32(2)))
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, []string{"i-02"})
+				Expect(err).Should(BeNil())
+				Expect(atomic.LoadInt32(&eventInventory)).To(Equal(int32(1)))
+				// full inventory is done once per resync interval, not per poll interval.
+				Expect(atomic.LoadInt32(&fullInventory)).To(Equal(int32(1)))
+			})
 		})
 	})
 
@@ -349,8 +963,8 @@ var _ = Describe("AWS cloud", func() {
 				Expect(err).Should(BeNil())
 
 				accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-				serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-				filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+				serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+				filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 				Expect(filters).To(Equal(expectedFilters))
 			})
 		})
@@ -381,8 +995,8 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
 		It("Should match expected filter - multiple vpcName only match", func() {
@@ -412,8 +1026,8 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
 		It("Should match expected filter - multiple vpcID & vmName match", func() {
@@ -467,8 +1081,8 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
 		It("Should match expected filter - multiple with one all", func() {
@@ -494,8 +1108,8 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
 		It("Should match expected filter - multiple vm names only match", func() {
@@ -531,8 +1145,8 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
 		It("Should match expected filter - multiple vm IDs only match", func() {
@@ -568,10 +1182,334 @@ var _ = Describe("AWS cloud", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
-			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedFilters))
 		})
+		It("Should match expected filter - vm name pattern with exclude match", func() {
+			c := SetAwsAccount(mockawsCloudHelper)
+			var expectedFilters [][]*ec2.Filter
+			var vmFilters []*ec2.Filter
+			vpcFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyVPCID),
+				Values: []*string{aws.String(testVpcID01)},
+			}
+			vmNameFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyVMName),
+				Values: []*string{aws.String("web-*")},
+			}
+			excludeFilter := &ec2.Filter{
+				Name:   aws.String(awsCustomFilterKeyPrefixExclude + "0:" + awsFilterKeyVMName),
+				Values: []*string{aws.String("web-bastion")},
+			}
+			vmFilters = append(vmFilters, vpcFilter, vmNameFilter, excludeFilter, buildEc2FilterForValidInstanceStates())
+			expectedFilters = append(expectedFilters, vmFilters)
+
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VpcMatch:     &v1alpha1.EntityMatch{MatchID: testVpcID01},
+					VMMatch:      []v1alpha1.EntityMatch{{MatchName: "web-*"}},
+					ExcludeMatch: []v1alpha1.EntityMatch{{MatchName: "web-bastion"}},
+				},
+			}
+
+			selector.Spec.VMSelector = vmSelector
+			err := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+			Expect(err).Should(BeNil())
+
+			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
+			Expect(filters).To(Equal(expectedFilters))
+		})
+		It("Should match expected filter - vpc and vm tag match", func() {
+			c := SetAwsAccount(mockawsCloudHelper)
+			var expectedFilters [][]*ec2.Filter
+			var tagFilters []*ec2.Filter
+			vpcTagFilter := &ec2.Filter{
+				Name:   aws.String(awsCustomFilterKeyPrefixVPCTag + "env"),
+				Values: []*string{aws.String("prod")},
+			}
+			vmTagFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyPrefixTag + "team"),
+				Values: []*string{aws.String("payments")},
+			}
+			vmTagInFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyPrefixTag + "tier"),
+				Values: []*string{aws.String("db"), aws.String("web")},
+			}
+			vmTagKeyFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyTagKey),
+				Values: []*string{aws.String("owner")},
+			}
+			tagFilters = append(tagFilters, vpcTagFilter, vmTagFilter, vmTagInFilter, vmTagKeyFilter,
+				buildEc2FilterForValidInstanceStates())
+			var vmIDFilters []*ec2.Filter
+			vmIDFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyVMID),
+				Values: []*string{aws.String(testVMID01)},
+			}
+			vmIDFilters = append(vmIDFilters, vpcTagFilter, vmIDFilter, buildEc2FilterForValidInstanceStates())
+			expectedFilters = append(expectedFilters, tagFilters, vmIDFilters)
+
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VpcMatch: &v1alpha1.EntityMatch{MatchTags: map[string]string{"env": "prod"}},
+					VMMatch: []v1alpha1.EntityMatch{
+						{
+							MatchTags: map[string]string{"team": "payments"},
+							MatchExpressions: []v1alpha1.TagMatchExpression{
+								{Key: "tier", Operator: v1alpha1.TagMatchOperatorIn, Values: []string{"web", "db"}},
+								{Key: "owner", Operator: v1alpha1.TagMatchOperatorExists},
+							},
+						},
+						{
+							MatchID: testVMID01,
+						},
+					},
+				},
+			}
+
+			selector.Spec.VMSelector = vmSelector
+			err := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+			Expect(err).Should(BeNil())
+
+			accCfg, _ := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
+			filters := serviceConfig.(*ec2ServiceConfig).instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
+			Expect(filters).To(Equal(expectedFilters))
+		})
+	})
+
+	Context("Tag match", func() {
+		It("Should exclude instances matching exclude filters", func() {
+			instances := getEc2InstanceObject([]string{"i-01", "i-02", "i-03"})
+			instances[0].Tags = []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web-bastion")}}
+			instances[1].Tags = []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web-01")},
+				{Key: aws.String("temp"), Value: aws.String("true")}}
+			instances[2].Tags = []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web-02")}}
+			excludeMatch := []v1alpha1.EntityMatch{
+				{MatchName: "*-bastion"},
+				{
+					MatchName:        "web-?1",
+					MatchExpressions: []v1alpha1.TagMatchExpression{{Key: "temp", Operator: v1alpha1.TagMatchOperatorExists}},
+				},
+			}
+			filters := append([]*ec2.Filter{buildEc2FilterForValidInstanceStates()}, buildEc2ExcludeFilters(excludeMatch)...)
+
+			remaining, excludeFilters := splitEc2ExcludeFilters(filters)
+			Expect(remaining).To(Equal([]*ec2.Filter{buildEc2FilterForValidInstanceStates()}))
+			Expect(excludeFilters).To(HaveLen(2))
+			Expect(ec2InstanceExcluded(instances[0], excludeFilters)).To(BeTrue())
+			Expect(ec2InstanceExcluded(instances[1], excludeFilters)).To(BeTrue())
+			Expect(ec2InstanceExcluded(instances[2], excludeFilters)).To(BeFalse())
+		})
+		It("Should resolve vpc name and vpc tag filters to vpc ID filter", func() {
+			vpcNameToID := map[string]string{"vpcName-01": "vpc-01", "vpcName-02": "vpc-02"}
+			vpcTags := map[string]map[string]string{
+				"vpc-01": {"Name": "vpcName-01", "env": "prod"},
+				"vpc-02": {"Name": "vpcName-02", "env": "dev", "team": "payments"},
+			}
+			vmTagFilter := &ec2.Filter{
+				Name:   aws.String(awsFilterKeyPrefixTag + "team"),
+				Values: []*string{aws.String("payments")},
+			}
+			filters := []*ec2.Filter{
+				{
+					Name:   aws.String(awsCustomFilterKeyVPCName),
+					Values: []*string{aws.String("vpcName-01"), aws.String("vpcName-02")},
+				},
+				{
+					Name:   aws.String(awsCustomFilterKeyPrefixVPCTag + "env"),
+					Values: []*string{aws.String("prod")},
+				},
+				vmTagFilter,
+			}
+			resolved, ok := resolveEc2CustomVpcFilters(filters, vpcNameToID, vpcTags)
+			Expect(ok).To(BeTrue())
+			Expect(resolved).To(Equal([]*ec2.Filter{
+				{
+					Name:   aws.String(awsFilterKeyVPCID),
+					Values: []*string{aws.String("vpc-01")},
+				},
+				vmTagFilter,
+			}))
+
+			filters = []*ec2.Filter{
+				{
+					Name:   aws.String(awsCustomFilterKeyVPCTagKey),
+					Values: []*string{aws.String("team")},
+				},
+				{
+					Name:   aws.String(awsCustomFilterKeyPrefixVPCTag + "env"),
+					Values: []*string{aws.String("prod")},
+				},
+			}
+			_, ok = resolveEc2CustomVpcFilters(filters, vpcNameToID, vpcTags)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("API throttling", func() {
+		It("Should retry throttled api calls", func() {
+			calls := 0
+			err := newAwsAPILimiter(&testAccountNamespacedName).Do("DescribeInstances", func() error {
+				calls++
+				if calls == 1 {
+					return awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil)
+				}
+				return nil
+			})
+			Expect(err).Should(BeNil())
+			Expect(calls).To(Equal(2))
+		})
+		It("Should not retry throttled api calls in SDK", func() {
+			retryer := awsRetryer{awsclient.DefaultRetryer{NumMaxRetries: awsclient.DefaultRetryerMaxNumRetries}}
+			req := &request.Request{Error: awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil)}
+			Expect(retryer.ShouldRetry(req)).To(BeFalse())
+			req = &request.Request{Error: awserr.New("InternalError", "An internal error has occurred.", nil),
+				HTTPResponse: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+			Expect(retryer.ShouldRetry(req)).To(BeTrue())
+		})
+		It("Should not retry failed api calls", func() {
+			calls := 0
+			err := newAwsAPILimiter(&testAccountNamespacedName).Do("DescribeInstances", func() error {
+				calls++
+				return awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil)
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(calls).To(Equal(1))
+		})
+		It("Should bound concurrent api calls", func() {
+			var inFlight, maxInFlight int32
+			var tasks []func() error
+			for i := 0; i < 4*internal.CloudAPIMaxConcurrency; i++ {
+				i := i
+				tasks = append(tasks, func() error {
+					n := atomic.AddInt32(&inFlight, 1)
+					defer atomic.AddInt32(&inFlight, -1)
+					for {
+						m := atomic.LoadInt32(&maxInFlight)
+						if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+							break
+						}
+					}
+					time.Sleep(10 * time.Millisecond)
+					if i%2 == 0 {
+						return errors.New("failed")
+					}
+					return nil
+				})
+			}
+			err := internal.RunConcurrently(tasks)
+			Expect(err).To(HaveOccurred())
+			Expect(multierr.Errors(err)).To(HaveLen(2 * internal.CloudAPIMaxConcurrency))
+			Expect(atomic.LoadInt32(&maxInFlight)).To(BeNumerically("<=", internal.CloudAPIMaxConcurrency))
+		})
+	})
+
+	Context("Credential modes", func() {
+		var (
+			fakeClient client.WithWatch
+			awsConfig  *v1alpha1.CloudProviderAccountAWSConfig
+		)
+
+		BeforeEach(func() {
+			var sessionDuration int64 = 3600
+			fakeClient = fake.NewClientBuilder().Build()
+			awsConfig = &v1alpha1.CloudProviderAccountAWSConfig{
+				CredentialMode:         v1alpha1.AWSCredentialModeWebIdentity,
+				RoleArn:                "arn:aws:iam::123456789012:role/nephe",
+				SessionDurationSeconds: &sessionDuration,
+				WebIdentityTokenFile:   "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+				Region:                 "us-east-1",
+			}
+		})
+
+		It("Should not read secret in WebIdentity credential mode", func() {
+			accCfg, err := setAccountCredentials(fakeClient, awsConfig)
+			Expect(err).Should(BeNil())
+			awsAccCfg := accCfg.(*awsAccountConfig)
+			Expect(awsAccCfg.credentialMode).To(Equal(v1alpha1.AWSCredentialModeWebIdentity))
+			Expect(awsAccCfg.RoleArn).To(Equal(awsConfig.RoleArn))
+			Expect(awsAccCfg.sessionDuration).To(Equal(time.Hour))
+			Expect(awsAccCfg.AccessKeyID).To(BeEmpty())
+
+			_, err = (&awsServicesHelperImpl{}).newServiceSdkConfigProvider(awsAccCfg)
+			Expect(err).Should(BeNil())
+		})
+		It("Should fail WebIdentity credential mode without token file", func() {
+			awsConfig.WebIdentityTokenFile = ""
+			accCfg, err := setAccountCredentials(fakeClient, awsConfig)
+			Expect(err).Should(BeNil())
+			_, err = (&awsServicesHelperImpl{}).newServiceSdkConfigProvider(accCfg.(*awsAccountConfig))
+			Expect(err).To(HaveOccurred())
+		})
+		It("Should use instance profile in InstanceProfile credential mode", func() {
+			awsConfig.CredentialMode = v1alpha1.AWSCredentialModeInstanceProfile
+			awsConfig.ExternalID = "external-id"
+			accCfg, err := setAccountCredentials(fakeClient, awsConfig)
+			Expect(err).Should(BeNil())
+			Expect(accCfg.(*awsAccountConfig).ExternalID).To(Equal("external-id"))
+			_, err = (&awsServicesHelperImpl{}).newServiceSdkConfigProvider(accCfg.(*awsAccountConfig))
+			Expect(err).Should(BeNil())
+		})
+		It("Should read secret in Static credential mode", func() {
+			awsConfig.CredentialMode = ""
+			awsConfig.SecretRef = &v1alpha1.SecretReference{Name: "secret01", Namespace: "namespace01", Key: "credentials"}
+			_, err := setAccountCredentials(fakeClient, awsConfig)
+			Expect(err).To(HaveOccurred())
+
+			secret := &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: "secret01", Namespace: "namespace01"},
+				Data: map[string][]byte{
+					"credentials": []byte(`{"accessKeyId": "keyId","accessKeySecret": "keySecret","roleArn": "secretRoleArn"}`),
+				},
+			}
+			Expect(fakeClient.Create(context.Background(), secret)).Should(BeNil())
+			accCfg, err := setAccountCredentials(fakeClient, awsConfig)
+			Expect(err).Should(BeNil())
+			awsAccCfg := accCfg.(*awsAccountConfig)
+			Expect(awsAccCfg.credentialMode).To(Equal(v1alpha1.AWSCredentialModeStatic))
+			Expect(awsAccCfg.AccessKeyID).To(Equal("keyId"))
+			// role ARN of the account overrides the one of the secret.
+			Expect(awsAccCfg.RoleArn).To(Equal(awsConfig.RoleArn))
+
+			changed := awsAccCfg.AwsAccountCredential
+			changed.RoleArn = "secretRoleArn"
+			Expect(compareAccountCredentials("account01", awsAccCfg,
+				&awsAccountConfig{AwsAccountCredential: changed, region: awsAccCfg.region, credentialMode: awsAccCfg.credentialMode,
+					sessionDuration: awsAccCfg.sessionDuration, webIdentityTokenFile: awsAccCfg.webIdentityTokenFile})).To(BeTrue())
+		})
+	})
+
+	Context("VirtualMachine CRD", func() {
+		It("Should convert instance placement, image and security groups", func() {
+			launchTime := time.Date(2022, 6, 1, 10, 30, 15, 500, time.UTC)
+			instance := getEc2InstanceObject([]string{"i-01"})[0]
+			instance.SubnetId = aws.String("subnet-01")
+			instance.InstanceType = aws.String(ec2.InstanceTypeT2Micro)
+			instance.ImageId = aws.String("ami-01")
+			instance.Placement = &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")}
+			instance.Platform = aws.String(ec2.PlatformValuesWindows)
+			instance.LaunchTime = &launchTime
+			instance.SecurityGroups = []*ec2.GroupIdentifier{{GroupName: aws.String("web")}, {GroupName: aws.String("default")}}
+			instance.NetworkInterfaces = []*ec2.InstanceNetworkInterface{{NetworkInterfaceId: aws.String("eni-01"),
+				MacAddress: aws.String("00:00:00:00:00:01"), SubnetId: aws.String("subnet-01")}}
+
+			vm := ec2InstanceToVirtualMachineCRD(instance, "namespace01")
+			Expect(vm.Status.Zone).To(Equal("us-east-1a"))
+			Expect(vm.Status.Subnet).To(Equal("subnet-01"))
+			Expect(vm.Status.NetworkInterfaces[0].Subnet).To(Equal("subnet-01"))
+			Expect(vm.Status.InstanceType).To(Equal(ec2.InstanceTypeT2Micro))
+			Expect(vm.Status.Image).To(Equal("ami-01"))
+			Expect(vm.Status.OSType).To(Equal(v1alpha1.Windows))
+			Expect(vm.Status.SecurityGroups).To(Equal([]string{"default", "web"}))
+			Expect(vm.Status.LaunchTime.Time).To(Equal(launchTime.Truncate(time.Second)))
+
+			instance.Platform = nil
+			Expect(ec2InstanceToVirtualMachineCRD(instance, "namespace01").Status.OSType).To(Equal(v1alpha1.Linux))
+		})
 	})
 })
 
@@ -581,6 +1519,7 @@ func getEc2InstanceObject(instanceIDs []string) []*ec2.Instance {
 		ec2Instance := &ec2.Instance{
 			VpcId:      &testVpcID01,
 			InstanceId: aws.String(instanceID),
+			State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
 		}
 		ec2Instances = append(ec2Instances, ec2Instance)
 	}
@@ -594,7 +1533,7 @@ func checkAccountAddSuccessCondition(c *awsCloud, namespacedName types.Namespace
 			return true, errors.New("failed to find account")
 		}
 
-		serviceConfig, _ := accCfg.GetServiceConfigByName(awsComputeServiceNameEC2)
+		serviceConfig, _ := accCfg.GetServiceConfigByName(getEC2ServiceName(testRegion))
 		instances := serviceConfig.(*ec2ServiceConfig).getCachedInstances()
 		instanceIds := make([]string, 0, len(instances))
 		for _, instance := range instances {
@@ -19,6 +19,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -27,29 +28,100 @@ import (
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 type azureAccountConfig struct {
 	v1alpha1.AzureAccountCredential
-	region string
+	// region is the first configured region of the account. In the config of a regional service, it is the service region.
+	region             string
+	regions            []string
+	credentialMode     v1alpha1.AzureCredentialMode
+	federatedTokenFile string
+	// subscriptionIDs are additional subscriptions whose virtual machines are imported with the account credentials.
+	subscriptionIDs []string
+	// additionalSubscription is true in the config of a service of an additional subscription, given by SubscriptionID.
+	additionalSubscription bool
+	// eventQueueURL is the Storage queue receiving virtual machine events of the account subscriptions.
+	eventQueueURL string
+	// preserveSecurityGroups keeps network security groups not created by nephe-controller attached to network
+	// interfaces.
+	preserveSecurityGroups bool
+	// securityGroupName is the network security group security rules are merged into, instead of the per vnet one.
+	securityGroupName string
+	// apiLimiter limits the rate of api calls of all services of the account, it is set when services are created.
+	apiLimiter *internal.CloudAPILimiter
 }
 
 // setAccountCredentials sets account credentials.
 func setAccountCredentials(client client.Client, credentials interface{}) (interface{}, error) {
 	azureProviderConfig := credentials.(*v1alpha1.CloudProviderAccountAzureConfig)
-	accCred, err := extractSecret(client, azureProviderConfig.SecretRef)
-	if err != nil {
-		return nil, err
-	}
-
 	azureConfig := &azureAccountConfig{
-		AzureAccountCredential: *accCred,
-		region:                 strings.TrimSpace(azureProviderConfig.Region),
+		regions:            utils.GetAccountRegions(azureProviderConfig.Region, azureProviderConfig.Regions),
+		credentialMode:     azureProviderConfig.CredentialMode,
+		federatedTokenFile: strings.TrimSpace(azureProviderConfig.FederatedTokenFile),
+		eventQueueURL:      strings.TrimSpace(azureProviderConfig.EventQueueURL),
+
+		preserveSecurityGroups: azureProviderConfig.PreserveSecurityGroups,
+		securityGroupName:      strings.TrimSpace(azureProviderConfig.SecurityGroupName),
+	}
+	for _, region := range azureConfig.regions {
+		if region != v1alpha1.AllRegions {
+			azureConfig.region = region
+			break
+		}
+	}
+	if len(azureConfig.credentialMode) == 0 {
+		azureConfig.credentialMode = v1alpha1.AzureCredentialModeClientSecret
+	}
+	// only service principal credential modes read credentials from a secret.
+	if azureConfig.credentialMode == v1alpha1.AzureCredentialModeClientSecret ||
+		azureConfig.credentialMode == v1alpha1.AzureCredentialModeClientCertificate {
+		accCred, err := extractSecret(client, azureProviderConfig.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		azureConfig.AzureAccountCredential = *accCred
+	}
+	if subscriptionID := strings.TrimSpace(azureProviderConfig.SubscriptionID); len(subscriptionID) != 0 {
+		azureConfig.SubscriptionID = subscriptionID
+	}
+	if tenantID := strings.TrimSpace(azureProviderConfig.TenantID); len(tenantID) != 0 {
+		azureConfig.TenantID = tenantID
+	}
+	if clientID := strings.TrimSpace(azureProviderConfig.ClientID); len(clientID) != 0 {
+		azureConfig.ClientID = clientID
+	}
+	for _, subscriptionID := range utils.GetAccountIDs(azureProviderConfig.SubscriptionIDs) {
+		if !strings.EqualFold(subscriptionID, azureConfig.SubscriptionID) {
+			azureConfig.subscriptionIDs = append(azureConfig.subscriptionIDs, subscriptionID)
+		}
 	}
 
 	return azureConfig, nil
 }
 
+// probeAccountCredentials checks account credentials by acquiring a token and querying a resource of the account
+// subscription through resource graph.
+func probeAccountCredentials(credentials interface{}, azureSpecificHelper interface{}) error {
+	azureServicesHelper := azureSpecificHelper.(azureServicesHelper)
+	azureAccountCredentials := credentials.(*azureAccountConfig)
+	azureServiceClientCreator, err := azureServicesHelper.newServiceSdkConfigProvider(azureAccountCredentials)
+	if err != nil {
+		return err
+	}
+
+	resourceGraphAPIClient, err := azureServiceClientCreator.resourceGraph()
+	if err != nil {
+		return err
+	}
+	query := "Resources | project id | limit 1"
+	if _, _, err = invokeResourceGraphQuery(resourceGraphAPIClient, &query, []string{azureAccountCredentials.SubscriptionID}); err != nil {
+		return fmt.Errorf("unable to query subscription %v resources: %v", azureAccountCredentials.SubscriptionID, err)
+	}
+	return nil
+}
+
 func compareAccountCredentials(accountName string, existing interface{}, new interface{}) bool {
 	existingConfig := existing.(*azureAccountConfig)
 	newConfig := new.(*azureAccountConfig)
@@ -71,10 +143,37 @@ func compareAccountCredentials(accountName string, existing interface{}, new int
 		credsChanged = true
 		azurePluginLogger().Info("account client key updated", "account", accountName)
 	}
-	if strings.Compare(existingConfig.region, newConfig.region) != 0 {
+	if strings.Compare(existingConfig.region, newConfig.region) != 0 ||
+		strings.Join(existingConfig.regions, ",") != strings.Join(newConfig.regions, ",") {
 		credsChanged = true
 		azurePluginLogger().Info("account region updated", "account", accountName)
 	}
+	if strings.Compare(existingConfig.ClientCertificate, newConfig.ClientCertificate) != 0 ||
+		strings.Compare(existingConfig.ClientCertificatePassword, newConfig.ClientCertificatePassword) != 0 {
+		credsChanged = true
+		azurePluginLogger().Info("account client certificate updated", "account", accountName)
+	}
+	if existingConfig.credentialMode != newConfig.credentialMode {
+		credsChanged = true
+		azurePluginLogger().Info("account credential mode updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.federatedTokenFile, newConfig.federatedTokenFile) != 0 {
+		credsChanged = true
+		azurePluginLogger().Info("account federated token file updated", "account", accountName)
+	}
+	if strings.Join(existingConfig.subscriptionIDs, ",") != strings.Join(newConfig.subscriptionIDs, ",") {
+		credsChanged = true
+		azurePluginLogger().Info("account subscriptions updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.eventQueueURL, newConfig.eventQueueURL) != 0 {
+		credsChanged = true
+		azurePluginLogger().Info("account event queue updated", "account", accountName)
+	}
+	if existingConfig.preserveSecurityGroups != newConfig.preserveSecurityGroups ||
+		strings.Compare(existingConfig.securityGroupName, newConfig.securityGroupName) != 0 {
+		credsChanged = true
+		azurePluginLogger().Info("account security groups config updated", "account", accountName)
+	}
 	return credsChanged
 }
 
@@ -108,25 +207,46 @@ func extractSecret(c client.Client, s *v1alpha1.SecretReference) (*v1alpha1.Azur
 	return cred, nil
 }
 
-// getVnetAccount returns first found account config to which this vnet id belongs.
-func (c *azureCloud) getVnetAccount(vpcID string) internal.CloudAccountInterface {
+// getVnetAccount returns first found account config to which this vnet id belongs, and the compute service config of
+// the vnet region.
+func (c *azureCloud) getVnetAccount(vpcID string) (internal.CloudAccountInterface, *computeServiceConfig) {
 	accCfgs := c.cloudCommon.GetCloudAccounts()
 	if len(accCfgs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	for _, accCfg := range accCfgs {
-		ec2ServiceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-		if err != nil {
-			continue
+		for _, computeService := range getComputeServiceConfigs(accCfg) {
+			if _, found := computeService.getCachedVnetIDs()[strings.ToLower(vpcID)]; found {
+				return accCfg, computeService
+			}
 		}
-		accVpcIDs := ec2ServiceCfg.(*computeServiceConfig).getCachedVnetIDs()
-		if len(accVpcIDs) == 0 {
+	}
+	return nil, nil
+}
+
+// getComputeServiceConfigs returns compute service configs of all regions of the account subscription, followed by the
+// ones of additional subscriptions, ordered by subscription and region.
+func getComputeServiceConfigs(accCfg internal.CloudAccountInterface) []*computeServiceConfig {
+	var computeServices []*computeServiceConfig
+	for name := range accCfg.GetServiceConfigs() {
+		serviceCfg, err := accCfg.GetServiceConfigByName(name)
+		if err != nil {
 			continue
 		}
-		if _, found := accVpcIDs[strings.ToLower(vpcID)]; found {
-			return accCfg
+		if computeService, ok := serviceCfg.(*computeServiceConfig); ok {
+			computeServices = append(computeServices, computeService)
 		}
 	}
-	return nil
+	sort.Slice(computeServices, func(i, j int) bool {
+		iCredentials, jCredentials := computeServices[i].credentials, computeServices[j].credentials
+		if iCredentials.additionalSubscription != jCredentials.additionalSubscription {
+			return !iCredentials.additionalSubscription
+		}
+		if iCredentials.SubscriptionID != jCredentials.SubscriptionID {
+			return iCredentials.SubscriptionID < jCredentials.SubscriptionID
+		}
+		return iCredentials.region < jCredentials.region
+	})
+	return computeServices
 }
@@ -22,6 +22,7 @@ package azure
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	network "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
 	resourcegraph "github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
@@ -335,3 +336,55 @@ func (mr *MockazureVirtualNetworksWrapperMockRecorder) listAllComplete(ctx inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "listAllComplete", reflect.TypeOf((*MockazureVirtualNetworksWrapper)(nil).listAllComplete), ctx)
 }
+
+// MockazureStorageQueueWrapper is a mock of azureStorageQueueWrapper interface.
+type MockazureStorageQueueWrapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockazureStorageQueueWrapperMockRecorder
+}
+
+// MockazureStorageQueueWrapperMockRecorder is the mock recorder for MockazureStorageQueueWrapper.
+type MockazureStorageQueueWrapperMockRecorder struct {
+	mock *MockazureStorageQueueWrapper
+}
+
+// NewMockazureStorageQueueWrapper creates a new mock instance.
+func NewMockazureStorageQueueWrapper(ctrl *gomock.Controller) *MockazureStorageQueueWrapper {
+	mock := &MockazureStorageQueueWrapper{ctrl: ctrl}
+	mock.recorder = &MockazureStorageQueueWrapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockazureStorageQueueWrapper) EXPECT() *MockazureStorageQueueWrapperMockRecorder {
+	return m.recorder
+}
+
+// deleteMessage mocks base method.
+func (m *MockazureStorageQueueWrapper) deleteMessage(ctx context.Context, messageID, popReceipt string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "deleteMessage", ctx, messageID, popReceipt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// deleteMessage indicates an expected call of deleteMessage.
+func (mr *MockazureStorageQueueWrapperMockRecorder) deleteMessage(ctx, messageID, popReceipt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "deleteMessage", reflect.TypeOf((*MockazureStorageQueueWrapper)(nil).deleteMessage), ctx, messageID, popReceipt)
+}
+
+// receiveMessages mocks base method.
+func (m *MockazureStorageQueueWrapper) receiveMessages(ctx context.Context, count int, visibilityTimeout time.Duration) ([]*azureQueueMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "receiveMessages", ctx, count, visibilityTimeout)
+	ret0, _ := ret[0].([]*azureQueueMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// receiveMessages indicates an expected call of receiveMessages.
+func (mr *MockazureStorageQueueWrapperMockRecorder) receiveMessages(ctx, count, visibilityTimeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "receiveMessages", reflect.TypeOf((*MockazureStorageQueueWrapper)(nil).receiveMessages), ctx, count, visibilityTimeout)
+}
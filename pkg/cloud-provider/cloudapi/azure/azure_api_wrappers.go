@@ -16,13 +16,101 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 )
 
+// azureThrottledError is the error of an api request throttled by Azure with HTTP status 429.
+type azureThrottledError struct {
+	retryAfter time.Duration
+}
+
+func (e *azureThrottledError) Error() string {
+	return fmt.Sprintf("request throttled, retry after %v", e.retryAfter)
+}
+
+// azureLimitedSender sends api requests of all api clients of an account at the rate of the account, and retries
+// requests throttled by Azure after their Retry-After delay. As it sends every request, pages of list calls and polls
+// of long-running operations are limited as well.
+type azureLimitedSender struct {
+	sender     autorest.Sender
+	apiLimiter *internal.CloudAPILimiter
+}
+
+// newAzureAPILimiter returns the api rate limiter shared by all services of an account.
+func newAzureAPILimiter(accountNamespacedName *types.NamespacedName) *internal.CloudAPILimiter {
+	return internal.NewCloudAPILimiter(string(providerType), accountNamespacedName.String(), isAzureThrottledError,
+		azurePluginLogger)
+}
+
+// isAzureThrottledError returns whether an api request was throttled, and its Retry-After delay.
+func isAzureThrottledError(err error) (bool, time.Duration) {
+	var throttledErr *azureThrottledError
+	if errors.As(err, &throttledErr) {
+		return true, throttledErr.retryAfter
+	}
+	return false, 0
+}
+
+// isAzureAuthError returns whether an api request failed as Azure rejected the account credentials, either when getting
+// a token or with HTTP status 401 or 403.
+func isAzureAuthError(err error) bool {
+	var tokenErr adal.TokenRefreshError
+	if errors.As(err, &tokenErr) {
+		return true
+	}
+	var detailedErr autorest.DetailedError
+	if !errors.As(err, &detailedErr) {
+		return false
+	}
+	return detailedErr.StatusCode == http.StatusUnauthorized || detailedErr.StatusCode == http.StatusForbidden
+}
+
+func (s *azureLimitedSender) Do(req *http.Request) (*http.Response, error) {
+	rr := autorest.NewRetriableRequest(req)
+	var resp *http.Response
+	err := s.apiLimiter.Do(getAzureAPIOperation(req), func() (err error) {
+		if err = rr.Prepare(); err != nil {
+			return err
+		}
+		resp, err = s.sender.Do(rr.Request())
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+		retryAfter := autorest.GetRetryAfter(resp, 0)
+		_ = autorest.Respond(resp, autorest.ByDiscardingBody(), autorest.ByClosing())
+		return &azureThrottledError{retryAfter: retryAfter}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getAzureAPIOperation returns the method and resource type of an api request, e.g. PUT
+// Microsoft.Network/networkInterfaces, or the method and host for requests of data plane apis.
+func getAzureAPIOperation(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "providers") && i+2 < len(segments) {
+			return req.Method + " " + segments[i+1] + "/" + segments[i+2]
+		}
+	}
+	return req.Method + " " + req.URL.Host
+}
+
 type azureNwIntfWrapper interface {
 	createOrUpdate(ctx context.Context, resourceGroupName string, networkInterfaceName string,
 		parameters network.Interface) (network.Interface, error)
@@ -250,3 +338,73 @@ func (vnet *azureVirtualNetworksWrapperImpl) listAllComplete(ctx context.Context
 
 	return VNListResultIterators, nil
 }
+
+// azureQueueMessage is a message received from a Storage queue.
+type azureQueueMessage struct {
+	MessageID   string `xml:"MessageId"`
+	PopReceipt  string `xml:"PopReceipt"`
+	MessageText string `xml:"MessageText"`
+}
+
+type azureStorageQueueWrapper interface {
+	receiveMessages(ctx context.Context, count int, visibilityTimeout time.Duration) ([]*azureQueueMessage, error)
+	deleteMessage(ctx context.Context, messageID, popReceipt string) error
+}
+
+// azureStorageQueueWrapperImpl calls the Storage queue REST api of a queue, given by its URL.
+type azureStorageQueueWrapperImpl struct {
+	client   autorest.Client
+	queueURL string
+}
+
+func (queue *azureStorageQueueWrapperImpl) receiveMessages(ctx context.Context, count int, visibilityTimeout time.Duration) (
+	[]*azureQueueMessage, error) {
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsGet(),
+		autorest.WithBaseURL(queue.queueURL),
+		autorest.WithPath("messages"),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"numofmessages":     count,
+			"visibilitytimeout": int(visibilityTimeout.Seconds()),
+		}),
+		autorest.WithHeader(azureStorageVersionHeader, azureStorageAPIVersion))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare queue messages request, reason %v", err)
+	}
+	resp, err := queue.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue messages, reason %v", err)
+	}
+
+	var result struct {
+		Messages []*azureQueueMessage `xml:"QueueMessage"`
+	}
+	err = autorest.Respond(resp, autorest.WithErrorUnlessStatusCode(http.StatusOK), autorest.ByUnmarshallingXML(&result),
+		autorest.ByClosing())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue messages, reason %v", err)
+	}
+	return result.Messages, nil
+}
+
+func (queue *azureStorageQueueWrapperImpl) deleteMessage(ctx context.Context, messageID, popReceipt string) error {
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsDelete(),
+		autorest.WithBaseURL(queue.queueURL),
+		autorest.WithPath("messages/"+url.PathEscape(messageID)),
+		autorest.WithQueryParameters(map[string]interface{}{"popreceipt": popReceipt}),
+		autorest.WithHeader(azureStorageVersionHeader, azureStorageAPIVersion))
+	if err != nil {
+		return fmt.Errorf("failed to prepare queue message delete request, reason %v", err)
+	}
+	resp, err := queue.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete queue message, reason %v", err)
+	}
+	err = autorest.Respond(resp, autorest.WithErrorUnlessStatusCode(http.StatusNoContent, http.StatusNotFound),
+		autorest.ByClosing())
+	if err != nil {
+		return fmt.Errorf("failed to delete queue message, reason %v", err)
+	}
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 func (p *azureServiceSdkConfigProvider) applicationSecurityGroups(subscriptionID string) (azureAsgWrapper, error) {
 	applicationSecurityGroupsClient := network.NewApplicationSecurityGroupsClient(subscriptionID)
 	applicationSecurityGroupsClient.Authorizer = p.authorizer
+	applicationSecurityGroupsClient.Sender = p.sender
 	return &azureAsgWrapperImpl{asgAPIClient: applicationSecurityGroupsClient}, nil
 }
 
@@ -29,3 +29,19 @@ func (h *azureCloudCommonHelperImpl) SetAccountCredentialsFunc() internal.CloudC
 func (h *azureCloudCommonHelperImpl) GetCloudCredentialsComparatorFunc() internal.CloudCredentialComparatorFunc {
 	return compareAccountCredentials
 }
+
+func (h *azureCloudCommonHelperImpl) GetCloudCredentialsProbeFunc() internal.CloudCredentialProbeFunc {
+	return probeAccountCredentials
+}
+
+func (h *azureCloudCommonHelperImpl) GetCloudEventQueueCreateFunc() internal.CloudEventQueueCreatorFunc {
+	return newAzureEventQueue
+}
+
+func (h *azureCloudCommonHelperImpl) GetCloudAuthErrorFunc() internal.CloudAuthErrorFunc {
+	return isAzureAuthError
+}
+
+func (h *azureCloudCommonHelperImpl) GetCloudServicesRefreshFunc() internal.CloudServicesRefreshFunc {
+	return hasAllRegions
+}
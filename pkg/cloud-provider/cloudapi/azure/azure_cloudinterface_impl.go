@@ -73,14 +73,30 @@ func (c *azureCloud) InstancesGivenProviderAccount(accountNamespacedName *types.
 	return vmCRDs, err
 }
 
+// InstancesGivenProviderAccountSelector returns VM CRD for virtualMachines of a given cloud provider account matched by the selector.
+func (c *azureCloud) InstancesGivenProviderAccountSelector(accountNamespacedName *types.NamespacedName,
+	selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error) {
+	vmCRDs, err := c.cloudCommon.GetCloudAccountSelectorComputeResourceCRDs(accountNamespacedName, selectorNamespacedName)
+	return vmCRDs, err
+}
+
 // IsVirtualPrivateCloudPresent returns true if given ID is managed by the cloud, else false.
 func (c *azureCloud) IsVirtualPrivateCloudPresent(vpcUniqueIdentifier string) bool {
-	if accCfg := c.getVnetAccount(vpcUniqueIdentifier); accCfg == nil {
+	if accCfg, _ := c.getVnetAccount(vpcUniqueIdentifier); accCfg == nil {
 		return false
 	}
 	return true
 }
 
+// GetVirtualPrivateCloudAccount returns the account managing given ID, nil if it is not managed by the cloud.
+func (c *azureCloud) GetVirtualPrivateCloudAccount(vpcUniqueIdentifier string) *types.NamespacedName {
+	accCfg, _ := c.getVnetAccount(vpcUniqueIdentifier)
+	if accCfg == nil {
+		return nil
+	}
+	return accCfg.GetNamespacedName()
+}
+
 // ////////////////////////////////////////////////////////
 // 	AccountMgmtInterface Implementation
 // ////////////////////////////////////////////////////////
@@ -89,6 +105,11 @@ func (c *azureCloud) AddProviderAccount(client client.Client, account *v1alpha1.
 	return c.cloudCommon.AddCloudAccount(client, account, account.Spec.AzureConfig)
 }
 
+// ProbeProviderAccountCredentials checks cloud credentials of given account of a cloud provider, without adding it.
+func (c *azureCloud) ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	return c.cloudCommon.ProbeCloudAccountCredentials(client, account.Spec.AzureConfig)
+}
+
 // RemoveProviderAccount removes and cleans up any resources of given account of a cloud provider.
 func (c *azureCloud) RemoveProviderAccount(namespacedName *types.NamespacedName) {
 	c.cloudCommon.RemoveCloudAccount(namespacedName)
@@ -99,9 +120,16 @@ func (c *azureCloud) AddAccountResourceSelector(accNamespacedName *types.Namespa
 	return c.cloudCommon.AddSelector(accNamespacedName, selector)
 }
 
+// AddAccountResourceSelectorWithSnapshot adds account specific resource selector, serving its resources from the snapshot
+// until the first inventory of the account.
+func (c *azureCloud) AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector,
+	vms []*v1alpha1.VirtualMachine) error {
+	return c.cloudCommon.AddSelectorWithSnapshot(accNamespacedName, selector, vms)
+}
+
 // RemoveAccountResourcesSelector removes account specific resource selector.
-func (c *azureCloud) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorName string) {
-	c.cloudCommon.RemoveSelector(accNamespacedName, selectorName)
+func (c *azureCloud) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
+	c.cloudCommon.RemoveSelector(accNamespacedName, selectorNamespacedName)
 }
 
 func (c *azureCloud) GetAccountStatus(accNamespacedName *types.NamespacedName) (*cloudv1alpha1.CloudProviderAccountStatus, error) {
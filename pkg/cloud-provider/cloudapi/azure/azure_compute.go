@@ -23,6 +23,7 @@ import (
 	"github.com/mohae/deepcopy"
 
 	"github.com/cenkalti/backoff/v4"
+	"k8s.io/apimachinery/pkg/types"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
 	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
@@ -39,13 +40,16 @@ type computeServiceConfig struct {
 	resourcesCache         *internal.CloudServiceResourcesCache
 	inventoryStats         *internal.CloudServiceStats
 	credentials            *azureAccountConfig
-	computeFilters         map[string][]*string
+	// computeFilters is keyed by selector namespaced name. Key with nil value indicates all virtual machines.
+	computeFilters map[types.NamespacedName][]*string
 }
 
 type computeResourcesCacheSnapshot struct {
 	virtualMachines map[cloudcommon.InstanceID]*virtualMachineTable
 	vnetIDs         map[string]struct{}
 	vnetPeers       map[string][][]string
+	// selectorVirtualMachines holds IDs of virtual machines matched by each selector.
+	selectorVirtualMachines map[types.NamespacedName][]cloudcommon.InstanceID
 }
 
 func newComputeServiceConfig(name string, service azureServiceClientCreateInterface,
@@ -87,7 +91,7 @@ func newComputeServiceConfig(name string, service azureServiceClientCreateInterf
 		resourcesCache:         &internal.CloudServiceResourcesCache{},
 		inventoryStats:         &internal.CloudServiceStats{},
 		credentials:            credentials,
-		computeFilters:         make(map[string][]*string),
+		computeFilters:         make(map[types.NamespacedName][]*string),
 	}
 	return config, nil
 }
@@ -120,11 +124,30 @@ func (computeCfg *computeServiceConfig) getCachedVirtualMachines() []*virtualMac
 		instancesToReturn = append(instancesToReturn, virtualMachine)
 	}
 
-	azurePluginLogger().V(1).Info("cached instances", "service", azureComputeServiceNameCompute, "account", computeCfg.accountName,
+	azurePluginLogger().V(1).Info("cached instances", "service", computeCfg.GetName(), "account", computeCfg.accountName,
 		"instances", len(instancesToReturn))
 	return instancesToReturn
 }
 
+// getCachedSelectorVirtualMachines returns virtualMachines matched by the selector from the cache for the subscription.
+func (computeCfg *computeServiceConfig) getCachedSelectorVirtualMachines(
+	selectorNamespacedName *types.NamespacedName) []*virtualMachineTable {
+	snapshot := computeCfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		azurePluginLogger().V(4).Info("compute service cache snapshot nil", "type", providerType, "account", computeCfg.accountName)
+		return []*virtualMachineTable{}
+	}
+	virtualMachines := snapshot.(*computeResourcesCacheSnapshot).virtualMachines
+	ids := snapshot.(*computeResourcesCacheSnapshot).selectorVirtualMachines[*selectorNamespacedName]
+	instancesToReturn := make([]*virtualMachineTable, 0, len(ids))
+	for _, id := range ids {
+		if virtualMachine, found := virtualMachines[id]; found {
+			instancesToReturn = append(instancesToReturn, virtualMachine)
+		}
+	}
+	return instancesToReturn
+}
+
 func (computeCfg *computeServiceConfig) getCachedVnetIDs() map[string]struct{} {
 	vnetIDsCopy := make(map[string]struct{})
 	snapshot := computeCfg.resourcesCache.GetSnapshot()
@@ -155,23 +178,70 @@ func (computeCfg *computeServiceConfig) getVnetPeers(vnetID string) [][]string {
 }
 
 func (computeCfg *computeServiceConfig) getVirtualMachines() ([]*virtualMachineTable, error) {
+	selectorVirtualMachines, err := computeCfg.getSelectorVirtualMachines(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var virtualMachines []*virtualMachineTable
+	for _, virtualMachineRows := range selectorVirtualMachines {
+		virtualMachines = append(virtualMachines, virtualMachineRows...)
+	}
+	return virtualMachines, nil
+}
+
+// getSelectorVirtualMachines gets virtualMachines for the subscription keyed by the selector matching them. If vmIDs
+// are given, only those virtualMachines are fetched.
+func (computeCfg *computeServiceConfig) getSelectorVirtualMachines(vmIDs []string) (map[types.NamespacedName][]*virtualMachineTable,
+	error) {
+	var vmIDsClause string
+	if len(vmIDs) != 0 {
+		vmIDsClause = fmt.Sprintf(" | where id in (%v)", convertStrSliceToLowercaseCommaSeparatedStr(vmIDs))
+	}
 	var subscriptions []string
 	subscriptions = append(subscriptions, computeCfg.credentials.SubscriptionID)
 
-	var virtualMachines []*virtualMachineTable
-	filters, _ := computeCfg.getComputeResourceFilters()
-	for _, filter := range filters {
-		virtualMachineRows, _, err := getVirtualMachineTable(computeCfg.resourceGraphAPIClient, filter, subscriptions)
-		if err != nil {
-			return nil, err
+	selectorVirtualMachines := make(map[types.NamespacedName][]*virtualMachineTable)
+	// same query may be used by more than one selector, query cloud only once for it.
+	queryVirtualMachines := make(map[string][]*virtualMachineTable)
+	for selector, filters := range computeCfg.computeFilters {
+		if len(filters) == 0 {
+			queryStr, err := computeCfg.getAllVirtualMachinesQuery()
+			if err != nil {
+				return nil, err
+			}
+			filters = []*string{queryStr}
 		}
-		virtualMachines = append(virtualMachines, virtualMachineRows...)
+
+		var virtualMachines []*virtualMachineTable
+		for _, filter := range filters {
+			virtualMachineRows, found := queryVirtualMachines[*filter]
+			if !found {
+				var err error
+				query := *filter + vmIDsClause
+				virtualMachineRows, _, err = getVirtualMachineTable(computeCfg.resourceGraphAPIClient, &query, subscriptions)
+				if err != nil {
+					return nil, err
+				}
+				queryVirtualMachines[*filter] = virtualMachineRows
+			}
+			virtualMachines = append(virtualMachines, virtualMachineRows...)
+		}
+		selectorVirtualMachines[selector] = virtualMachines
+
+		azurePluginLogger().V(1).Info("instances from cloud", "service", computeCfg.GetName(), "account", computeCfg.accountName,
+			"selector", selector, "instances", len(virtualMachines))
 	}
 
-	azurePluginLogger().V(1).Info("instances from cloud", "service", azureComputeServiceNameCompute, "account", computeCfg.accountName,
-		"instances", len(virtualMachines))
+	return selectorVirtualMachines, nil
+}
 
-	return virtualMachines, nil
+// getAllVirtualMachinesQuery returns query to get all virtualMachines of the subscription in the account region.
+func (computeCfg *computeServiceConfig) getAllVirtualMachinesQuery() (*string, error) {
+	subscriptionIDs := []string{computeCfg.credentials.SubscriptionID}
+	tenantIDs := []string{computeCfg.credentials.TenantID}
+	locations := []string{computeCfg.credentials.region}
+	return getVMsBySubscriptionIDsAndTenantIDsAndLocationsMatchQuery(subscriptionIDs, tenantIDs, locations)
 }
 
 func (computeCfg *computeServiceConfig) getComputeResourceFilters() ([]*string, bool) {
@@ -186,10 +256,7 @@ func (computeCfg *computeServiceConfig) getComputeResourceFilters() ([]*string,
 		// if any selector found with nil filter, skip all other selectors. As nil indicates all
 		if len(filters) == 0 {
 			var queries []*string
-			subscriptionIDs := []string{computeCfg.credentials.SubscriptionID}
-			tenantIDs := []string{computeCfg.credentials.TenantID}
-			locations := []string{computeCfg.credentials.region}
-			queryStr, err := getVMsBySubscriptionIDsAndTenantIDsAndLocationsMatchQuery(subscriptionIDs, tenantIDs, locations)
+			queryStr, err := computeCfg.getAllVirtualMachinesQuery()
 			if err != nil {
 				azurePluginLogger().Error(err, "query string creation failed", "account", computeCfg.accountName)
 				return nil, false
@@ -203,49 +270,128 @@ func (computeCfg *computeServiceConfig) getComputeResourceFilters() ([]*string,
 }
 
 func (computeCfg *computeServiceConfig) DoResourceInventory() error {
-	virtualMachines, err := computeCfg.getVirtualMachines()
+	selectorVirtualMachines, err := computeCfg.getSelectorVirtualMachines(nil)
 	if err == nil {
 		exists := struct{}{}
 		vnetIDs := make(map[string]struct{})
 		vpcPeers, _ := computeCfg.buildMapVpcPeers()
 		vmIDToInfoMap := make(map[cloudcommon.InstanceID]*virtualMachineTable)
-		for _, vm := range virtualMachines {
-			id := cloudcommon.InstanceID(strings.ToLower(*vm.ID))
-			vmIDToInfoMap[id] = vm
-			vnetIDs[*vm.VnetID] = exists
+		selectorVMIDs := make(map[types.NamespacedName][]cloudcommon.InstanceID)
+		for selector, virtualMachines := range selectorVirtualMachines {
+			for _, vm := range virtualMachines {
+				id := cloudcommon.InstanceID(strings.ToLower(*vm.ID))
+				vmIDToInfoMap[id] = vm
+				vnetIDs[*vm.VnetID] = exists
+				selectorVMIDs[selector] = append(selectorVMIDs[selector], id)
+			}
 		}
-		computeCfg.resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{vmIDToInfoMap, vnetIDs, vpcPeers})
+		computeCfg.resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{vmIDToInfoMap, vnetIDs, vpcPeers, selectorVMIDs})
+		computeCfg.inventoryStats.UpdateInventoryResourceCount(len(vmIDToInfoMap))
 	}
 
 	return err
 }
 
+// DoEventResourceInventory gets virtualMachines of events of the service subscription from cloud, and updates them in
+// cache snapshot. Events of other regions are not filtered out, as their virtualMachines are not found in the service
+// region.
+func (computeCfg *computeServiceConfig) DoEventResourceInventory(events []*internal.CloudEvent) error {
+	var vmIDs []string
+	for _, event := range events {
+		if strings.EqualFold(event.AccountID, computeCfg.credentials.SubscriptionID) {
+			vmIDs = append(vmIDs, event.ResourceIDs...)
+		}
+	}
+	snapshot, _ := computeCfg.resourcesCache.GetSnapshot().(*computeResourcesCacheSnapshot)
+	if len(vmIDs) == 0 || snapshot == nil {
+		return nil
+	}
+
+	selectorVirtualMachines, err := computeCfg.getSelectorVirtualMachines(vmIDs)
+	if err != nil {
+		return err
+	}
+	newSnapshot := snapshot.updateVirtualMachines(vmIDs, selectorVirtualMachines)
+	computeCfg.resourcesCache.UpdateSnapshot(newSnapshot)
+	computeCfg.inventoryStats.UpdateInventoryResourceCount(len(newSnapshot.virtualMachines))
+
+	azurePluginLogger().V(1).Info("event instances from cloud", "service", computeCfg.GetName(), "account",
+		computeCfg.accountName, "instances", len(vmIDs))
+	return nil
+}
+
+// updateVirtualMachines returns a copy of the snapshot, in which virtualMachines of vmIDs are replaced by
+// virtualMachines found for them, keyed by selector. VirtualMachines not found are removed.
+func (snapshot *computeResourcesCacheSnapshot) updateVirtualMachines(vmIDs []string,
+	selectorVirtualMachines map[types.NamespacedName][]*virtualMachineTable) *computeResourcesCacheSnapshot {
+	updated := make(map[cloudcommon.InstanceID]struct{})
+	for _, vmID := range vmIDs {
+		updated[cloudcommon.InstanceID(strings.ToLower(vmID))] = struct{}{}
+	}
+
+	newSnapshot := &computeResourcesCacheSnapshot{
+		virtualMachines:         make(map[cloudcommon.InstanceID]*virtualMachineTable),
+		vnetIDs:                 make(map[string]struct{}),
+		vnetPeers:               snapshot.vnetPeers,
+		selectorVirtualMachines: make(map[types.NamespacedName][]cloudcommon.InstanceID),
+	}
+	for id, vm := range snapshot.virtualMachines {
+		if _, found := updated[id]; !found {
+			newSnapshot.virtualMachines[id] = vm
+		}
+	}
+	for selector, ids := range snapshot.selectorVirtualMachines {
+		for _, id := range ids {
+			if _, found := updated[id]; !found {
+				newSnapshot.selectorVirtualMachines[selector] = append(newSnapshot.selectorVirtualMachines[selector], id)
+			}
+		}
+	}
+	for selector, virtualMachines := range selectorVirtualMachines {
+		for _, vm := range virtualMachines {
+			id := cloudcommon.InstanceID(strings.ToLower(*vm.ID))
+			newSnapshot.virtualMachines[id] = vm
+			newSnapshot.selectorVirtualMachines[selector] = append(newSnapshot.selectorVirtualMachines[selector], id)
+		}
+	}
+	for _, vm := range newSnapshot.virtualMachines {
+		newSnapshot.vnetIDs[*vm.VnetID] = struct{}{}
+	}
+	return newSnapshot
+}
+
 func (computeCfg *computeServiceConfig) SetResourceFilters(selector *v1alpha1.CloudEntitySelector) {
 	subscriptionIDs := []string{computeCfg.credentials.SubscriptionID}
 	tenantIDs := []string{computeCfg.credentials.TenantID}
 	locations := []string{computeCfg.credentials.region}
 	if filters, found := convertSelectorToComputeQuery(selector, subscriptionIDs, tenantIDs, locations); found {
-		computeCfg.computeFilters[selector.GetName()] = filters
+		computeCfg.computeFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}] = filters
 	} else {
 		if selector != nil {
-			delete(computeCfg.computeFilters, selector.GetName())
+			delete(computeCfg.computeFilters, types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name})
 		}
 		computeCfg.resourcesCache.UpdateSnapshot(nil)
 	}
 }
 
-func (computeCfg *computeServiceConfig) GetResourceCRDs(namespace string) *internal.CloudServiceResourceCRDs {
-	virtualMachines := computeCfg.getCachedVirtualMachines()
+// RemoveResourceFilters removes compute filters of the selector, filters of other selectors are kept.
+func (computeCfg *computeServiceConfig) RemoveResourceFilters(selectorNamespacedName *types.NamespacedName) {
+	delete(computeCfg.computeFilters, *selectorNamespacedName)
+}
+
+func (computeCfg *computeServiceConfig) GetResourceCRDs(selectorNamespacedName *types.NamespacedName) *internal.CloudServiceResourceCRDs {
+	virtualMachines := computeCfg.getCachedSelectorVirtualMachines(selectorNamespacedName)
 	vmCRDs := make([]*v1alpha1.VirtualMachine, 0, len(virtualMachines))
 
 	for _, virtualMachine := range virtualMachines {
 		// build VirtualMachine CRD
-		vmCRD := computeInstanceToVirtualMachineCRD(virtualMachine, namespace)
+		vmCRD := computeInstanceToVirtualMachineCRD(virtualMachine, selectorNamespacedName.Namespace)
+		vmCRD.Status.Region = computeCfg.credentials.region
 		vmCRDs = append(vmCRDs, vmCRD)
 	}
 
-	azurePluginLogger().V(1).Info("CRDs", "service", azureComputeServiceNameCompute, "account", computeCfg.accountName,
-		"virtual-machine CRDs", len(vmCRDs))
+	azurePluginLogger().V(1).Info("CRDs", "service", computeCfg.GetName(), "account", computeCfg.accountName,
+		"selector", selectorNamespacedName, "virtual-machine CRDs", len(vmCRDs))
 
 	serviceResourceCRDs := &internal.CloudServiceResourceCRDs{}
 	serviceResourceCRDs.SetComputeResourceCRDs(vmCRDs)
@@ -259,7 +405,20 @@ func (computeCfg *computeServiceConfig) HasFiltersConfigured() (bool, bool) {
 }
 
 func (computeCfg *computeServiceConfig) GetName() internal.CloudServiceName {
-	return azureComputeServiceNameCompute
+	if computeCfg.credentials.additionalSubscription {
+		return getSubscriptionComputeServiceName(computeCfg.credentials.SubscriptionID, computeCfg.credentials.region)
+	}
+	return getComputeServiceName(computeCfg.credentials.region)
+}
+
+// getComputeServiceName returns the name of the compute service of a region.
+func getComputeServiceName(region string) internal.CloudServiceName {
+	return internal.CloudServiceName(fmt.Sprintf("%v-%v", azureComputeServiceNameCompute, region))
+}
+
+// getSubscriptionComputeServiceName returns the name of the compute service of a region of an additional subscription.
+func getSubscriptionComputeServiceName(subscriptionID string, region string) internal.CloudServiceName {
+	return internal.CloudServiceName(fmt.Sprintf("%v-%v-%v", azureComputeServiceNameCompute, subscriptionID, region))
 }
 
 func (computeCfg *computeServiceConfig) GetType() internal.CloudServiceType {
@@ -271,7 +430,8 @@ func (computeCfg *computeServiceConfig) GetInventoryStats() *internal.CloudServi
 }
 
 func (computeCfg *computeServiceConfig) ResetCachedState() {
-	computeCfg.SetResourceFilters(nil)
+	computeCfg.computeFilters = make(map[types.NamespacedName][]*string)
+	computeCfg.resourcesCache.UpdateSnapshot(nil)
 	computeCfg.inventoryStats.ResetInventoryPollStats()
 }
 
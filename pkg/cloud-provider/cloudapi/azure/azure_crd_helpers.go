@@ -15,7 +15,12 @@
 package azure
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2021-03-01/compute"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
@@ -48,6 +53,8 @@ func computeInstanceToVirtualMachineCRD(instance *virtualMachineTable, namespace
 	// Network interfaces associated with Virtual machine
 	instNetworkInterfaces := instance.NetworkInterfaces
 	networkInterfaces := make([]v1alpha1.NetworkInterface, 0, len(instNetworkInterfaces))
+	primarySubnet := ""
+	securityGroups := make(map[string]struct{})
 	for _, nwInf := range instNetworkInterfaces {
 		var ipAddressCRDs []v1alpha1.IPAddress
 		if len(nwInf.PrivateIps) > 0 {
@@ -74,11 +81,26 @@ func computeInstanceToVirtualMachineCRD(instance *virtualMachineTable, namespace
 		}
 
 		networkInterface := v1alpha1.NetworkInterface{
-			Name: *nwInf.ID,
-			MAC:  macAddress,
-			IPs:  ipAddressCRDs,
+			Name:   *nwInf.ID,
+			MAC:    macAddress,
+			IPs:    ipAddressCRDs,
+			Subnet: getSubnetShortID(nwInf.SubnetID),
 		}
 		networkInterfaces = append(networkInterfaces, networkInterface)
+		// primary is only set on virtual machines with multiple network interfaces.
+		if nwInf.Primary == nil || *nwInf.Primary {
+			primarySubnet = networkInterface.Subnet
+		}
+
+		sgIDs := getResourceIDs(nwInf.ApplicationSecurityGroups)
+		if nwInf.NsgID != nil {
+			sgIDs = append(sgIDs, *nwInf.NsgID)
+		}
+		for _, sgID := range sgIDs {
+			if _, _, sgName, err := extractFieldsFromAzureResourceID(sgID); err == nil {
+				securityGroups[sgName] = struct{}{}
+			}
+		}
 	}
 
 	cloudNetworkID := strings.ToLower(*instance.VnetID)
@@ -98,7 +120,76 @@ func computeInstanceToVirtualMachineCRD(instance *virtualMachineTable, namespace
 	} else {
 		state = v1alpha1.Unknown
 	}
-	return utils.GenerateVirtualMachineCRD(crdName, strings.ToLower(cloudName), strings.ToLower(cloudID), namespace,
+	vmCRD := utils.GenerateVirtualMachineCRD(crdName, strings.ToLower(cloudName), strings.ToLower(cloudID), namespace,
 		strings.ToLower(cloudNetworkID), cloudNetworkShortID,
 		state, tags, networkInterfaces, providerType)
+	vmCRD.Status.Subnet = primarySubnet
+	for sgName := range securityGroups {
+		vmCRD.Status.SecurityGroups = append(vmCRD.Status.SecurityGroups, sgName)
+	}
+	sort.Strings(vmCRD.Status.SecurityGroups)
+	// azure zones are numbered within a location, e.g. eastus-1 for zone 1 of eastus.
+	if len(instance.Zones) > 0 && instance.Zones[0] != nil && instance.Location != nil {
+		vmCRD.Status.Zone = fmt.Sprintf("%v-%v", *instance.Location, *instance.Zones[0])
+	}
+	if instance.TimeCreated != nil {
+		if timeCreated, err := time.Parse(time.RFC3339Nano, *instance.TimeCreated); err == nil {
+			vmCRD.Status.LaunchTime = utils.GenerateCRDTime(timeCreated)
+		}
+	}
+	if properties := instance.Properties; properties != nil {
+		if properties.HardwareProfile != nil {
+			vmCRD.Status.InstanceType = string(properties.HardwareProfile.VMSize)
+		}
+		if storageProfile := properties.StorageProfile; storageProfile != nil {
+			if storageProfile.OsDisk != nil && len(storageProfile.OsDisk.OsType) > 0 {
+				vmCRD.Status.OSType = v1alpha1.VMOSType(strings.ToLower(string(storageProfile.OsDisk.OsType)))
+			}
+			vmCRD.Status.Image = getImageName(storageProfile.ImageReference)
+		}
+	}
+	return vmCRD
+}
+
+// getSubnetShortID returns the short identifier of a subnet, as for its virtual network.
+func getSubnetShortID(subnetID *string) string {
+	if subnetID == nil || len(*subnetID) == 0 {
+		return ""
+	}
+	id := strings.ToLower(*subnetID)
+	return utils.GenerateShortResourceIdentifier(id, id[strings.LastIndex(id, "/")+1:])
+}
+
+// getResourceIDs returns IDs of the resource references in refs, which may be nested in lists.
+func getResourceIDs(refs interface{}) []string {
+	var ids []string
+	switch ref := refs.(type) {
+	case []interface{}:
+		for _, r := range ref {
+			ids = append(ids, getResourceIDs(r)...)
+		}
+	case map[string]interface{}:
+		if id, ok := ref["id"].(string); ok {
+			ids = append(ids, strings.ToLower(id))
+		}
+	}
+	return ids
+}
+
+// getImageName returns the name of a marketplace image as publisher:offer:sku:version, or the ID of a custom image.
+func getImageName(image *compute.ImageReference) string {
+	if image == nil {
+		return ""
+	}
+	if image.ID != nil {
+		return strings.ToLower(*image.ID)
+	}
+	var fields []string
+	for _, field := range []*string{image.Publisher, image.Offer, image.Sku, image.Version} {
+		if field == nil {
+			return ""
+		}
+		fields = append(fields, *field)
+	}
+	return strings.Join(fields, ":")
 }
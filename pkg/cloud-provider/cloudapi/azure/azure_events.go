@@ -0,0 +1,163 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+)
+
+const (
+	// azureEventQueueMaxMessages is the maximum number of messages received by one Storage queue api call.
+	azureEventQueueMaxMessages = 32
+	// azureEventQueueVisibilityTimeout is the duration received messages are hidden from the queue, until deleted.
+	azureEventQueueVisibilityTimeout = 5 * time.Minute
+
+	azureStorageVersionHeader = "x-ms-version"
+	azureStorageAPIVersion    = "2019-12-12"
+
+	azureVirtualMachineResourceType = "microsoft.compute/virtualmachines"
+)
+
+// azureEventQueue receives virtual machine events of an account from a Storage queue. Events are sent to the queue by
+// Event Grid subscriptions of the account subscriptions, e.g. on "Microsoft.Resources.ResourceWriteSuccess" and
+// "Microsoft.Resources.ResourceDeleteSuccess" events.
+// Implements internal.CloudEventQueue interface.
+type azureEventQueue struct {
+	queueURL  string
+	apiClient azureStorageQueueWrapper
+}
+
+// azureEvent is an Event Grid event, as delivered to a Storage queue endpoint.
+type azureEvent struct {
+	EventType string `json:"eventType"`
+	Subject   string `json:"subject"`
+	Data      struct {
+		ResourceURI string `json:"resourceUri"`
+	} `json:"data"`
+}
+
+// storageQueue returns Azure Storage queue apiClient of a queue URL.
+func (p *azureServiceSdkConfigProvider) storageQueue(queueURL string) (azureStorageQueueWrapper, error) {
+	if p.storageAuthorizer == nil {
+		return nil, fmt.Errorf("storage authorizer not initialized")
+	}
+	client := autorest.NewClientWithUserAgent("nephe")
+	client.Authorizer = p.storageAuthorizer
+	client.Sender = p.sender
+	return &azureStorageQueueWrapperImpl{client: client, queueURL: strings.TrimSuffix(queueURL, "/")}, nil
+}
+
+// newAzureEventQueue returns the event queue of the account, nil if the account has no event queue.
+func newAzureEventQueue(accCredentials interface{}, azureSpecificHelper interface{}) (internal.CloudEventQueue, error) {
+	azureServicesHelper := azureSpecificHelper.(azureServicesHelper)
+	azureAccountCredentials := accCredentials.(*azureAccountConfig)
+	if len(azureAccountCredentials.eventQueueURL) == 0 {
+		return nil, nil
+	}
+
+	azureServiceClientCreator, err := azureServicesHelper.newServiceSdkConfigProvider(azureAccountCredentials)
+	if err != nil {
+		return nil, err
+	}
+	apiClient, err := azureServiceClientCreator.storageQueue(azureAccountCredentials.eventQueueURL)
+	if err != nil {
+		return nil, fmt.Errorf("error creating storage queue sdk api client for queue %v, err: %v",
+			azureAccountCredentials.eventQueueURL, err)
+	}
+	return &azureEventQueue{queueURL: azureAccountCredentials.eventQueueURL, apiClient: apiClient}, nil
+}
+
+// ReceiveEvents returns events available in the queue. Storage queues do not support long polling, an empty result is
+// returned if the queue is empty.
+func (q *azureEventQueue) ReceiveEvents() ([]*internal.CloudEvent, error) {
+	messages, err := q.apiClient.receiveMessages(context.Background(), azureEventQueueMaxMessages,
+		azureEventQueueVisibilityTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("unable to receive events from %v: %v", q.queueURL, err)
+	}
+
+	events := make([]*internal.CloudEvent, 0, len(messages))
+	for _, message := range messages {
+		event := parseAzureEvent(message.MessageText)
+		event.Handle = message.MessageID + "/" + message.PopReceipt
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// DeleteEvents deletes events from the queue.
+func (q *azureEventQueue) DeleteEvents(events []*internal.CloudEvent) error {
+	for _, event := range events {
+		handle := strings.SplitN(event.Handle, "/", 2)
+		if len(handle) != 2 {
+			return fmt.Errorf("invalid event handle %v", event.Handle)
+		}
+		if err := q.apiClient.deleteMessage(context.Background(), handle[0], handle[1]); err != nil {
+			return fmt.Errorf("unable to delete events from %v: %v", q.queueURL, err)
+		}
+	}
+	return nil
+}
+
+// parseAzureEvent returns the cloud event of an Event Grid event, with the ID of the virtual machine of the event.
+// Storage queue messages of Event Grid are base64 encoded. Events not about virtual machines have no resources, they
+// are deleted from the queue without inventory.
+func parseAzureEvent(text string) *internal.CloudEvent {
+	event := &internal.CloudEvent{}
+	body := []byte(text)
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+		body = decoded
+	}
+	vmEvent := &azureEvent{}
+	if err := json.Unmarshal(body, vmEvent); err != nil {
+		azurePluginLogger().Info("unable to parse event", "error", err)
+		return event
+	}
+
+	resourceID := vmEvent.Data.ResourceURI
+	if len(resourceID) == 0 {
+		resourceID = vmEvent.Subject
+	}
+	vmID, subscriptionID, ok := getVirtualMachineResourceID(resourceID)
+	if ok {
+		event.AccountID = subscriptionID
+		event.ResourceIDs = []string{vmID}
+	}
+
+	azurePluginLogger().V(1).Info("event", "type", vmEvent.EventType, "account", event.AccountID,
+		"instances", event.ResourceIDs)
+	return event
+}
+
+// getVirtualMachineResourceID returns the lowercase ID and subscription of the virtual machine of a resource ID, e.g.
+// of /subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Compute/virtualMachines/<vm>/extensions/<ext>.
+func getVirtualMachineResourceID(resourceID string) (string, string, bool) {
+	// leading "/" yields an empty first segment.
+	segments := strings.Split(strings.ToLower(resourceID), "/")
+	if len(segments) < 9 || segments[1] != "subscriptions" || segments[3] != "resourcegroups" || segments[5] != "providers" ||
+		strings.Join(segments[6:8], "/") != azureVirtualMachineResourceType || len(segments[8]) == 0 {
+		return "", "", false
+	}
+	return strings.Join(segments[:9], "/"), segments[2], true
+}
@@ -0,0 +1,289 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
+)
+
+var anyIPNets = []*net.IPNet{
+	{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+	{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+}
+
+// GetVpcSecurityGroups returns network security groups not created by nephe-controller attached to network interfaces
+// of virtual network vpcID of an account, and application security groups their rules refer to.
+func (c *azureCloud) GetVpcSecurityGroups(accNamespacedName *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent,
+	error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	accCfg, found := c.cloudCommon.GetCloudAccountByName(accNamespacedName)
+	if !found {
+		return nil, fmt.Errorf("azure account %v not found", accNamespacedName)
+	}
+	// vnet is in one of the locations of the account, network interfaces of other locations are filtered out by vnet.
+	for _, computeService := range getComputeServiceConfigs(accCfg) {
+		contents, err := computeService.getVnetSecurityGroupsImportView(strings.ToLower(vpcID))
+		if err != nil {
+			return nil, err
+		}
+		if len(contents) > 0 {
+			return contents, nil
+		}
+	}
+	return nil, nil
+}
+
+// getVnetSecurityGroupsImportView returns network security groups not created by nephe-controller attached to network
+// interfaces of vnetID ordered by name, followed by application security groups their rules refer to.
+func (computeCfg *computeServiceConfig) getVnetSecurityGroupsImportView(vnetID string) ([]securitygroup.ImportContent, error) {
+	networkInterfaces, err := computeCfg.getNetworkInterfacesOfVnet(map[string]struct{}{vnetID: {}})
+	if err != nil {
+		return nil, err
+	}
+
+	// members are virtual machines of network interfaces, other network interfaces, e.g. of private endpoints, are reported.
+	nsgIDToMembers := make(map[string][]securitygroup.CloudResource)
+	nsgIDToUnsupported := make(map[string][]string)
+	asgIDToMembers := make(map[string][]securitygroup.CloudResource)
+	for _, networkInterface := range networkInterfaces {
+		var vmCRDName string
+		if vmID := strings.ToLower(to.String(networkInterface.VirtualMachineID)); len(vmID) != 0 {
+			if _, _, vmName, err := extractFieldsFromAzureResourceID(vmID); err == nil {
+				vmCRDName = utils.GenerateShortResourceIdentifier(vmID, vmName)
+			}
+		}
+		cloudResource := securitygroup.CloudResource{
+			Type: securitygroup.CloudResourceTypeVM,
+			Name: securitygroup.CloudResourceID{
+				Name: vmCRDName,
+				Vpc:  vnetID,
+			},
+		}
+		if nsgID := strings.ToLower(to.String(networkInterface.NetworkSecurityGroupID)); len(nsgID) != 0 {
+			if len(vmCRDName) == 0 {
+				nsgIDToUnsupported[nsgID] = append(nsgIDToUnsupported[nsgID], fmt.Sprintf(
+					"attached to network interface %v of no virtual machine", to.String(networkInterface.ID)))
+			} else {
+				nsgIDToMembers[nsgID] = append(nsgIDToMembers[nsgID], cloudResource)
+			}
+		}
+		for _, asgID := range networkInterface.ApplicationSecurityGroupIDs {
+			if asgID == nil || len(vmCRDName) == 0 {
+				continue
+			}
+			asgIDLowerCase := strings.ToLower(*asgID)
+			asgIDToMembers[asgIDLowerCase] = append(asgIDToMembers[asgIDLowerCase], cloudResource)
+		}
+	}
+	if len(nsgIDToMembers) == 0 && len(nsgIDToUnsupported) == 0 {
+		return nil, nil
+	}
+
+	networkSecurityGroups, err := computeCfg.nsgAPIClient.listAllComplete(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var contents []securitygroup.ImportContent
+	referredAsgIDs := make(map[string]struct{})
+	for i := range networkSecurityGroups {
+		nsg := &networkSecurityGroups[i]
+		nsgID := strings.ToLower(to.String(nsg.ID))
+		members, isAttached := nsgIDToMembers[nsgID]
+		unsupported, isAttachedToOthers := nsgIDToUnsupported[nsgID]
+		if (!isAttached && !isAttachedToOthers) || isNepheControllerCreatedNsg(nsg) {
+			continue
+		}
+		_, _, nsgName, err := extractFieldsFromAzureResourceID(nsgID)
+		if err != nil {
+			continue
+		}
+		content := securitygroup.ImportContent{
+			Resource: securitygroup.CloudResourceID{
+				Name: nsgName,
+				Vpc:  vnetID,
+			},
+			Members: members,
+		}
+		if nsg.SecurityGroupPropertiesFormat != nil && nsg.Subnets != nil {
+			for _, subnet := range *nsg.Subnets {
+				unsupported = append(unsupported, fmt.Sprintf("attached to subnet %v", to.String(subnet.ID)))
+			}
+		}
+		if nsg.SecurityGroupPropertiesFormat != nil && nsg.SecurityRules != nil {
+			for _, rule := range *nsg.SecurityRules {
+				// rules of the network security group designated by the account may be created by nephe-controller.
+				if isNepheControllerCreatedSecurityRule(rule) {
+					continue
+				}
+				ingressRules, egressRules, ruleUnsupported := convertFromAzureSecurityRuleToImportRules(rule, vnetID)
+				for _, ingressRule := range ingressRules {
+					for _, sg := range ingressRule.FromSecurityGroups {
+						referredAsgIDs[sg.Name] = struct{}{}
+					}
+				}
+				for _, egressRule := range egressRules {
+					for _, sg := range egressRule.ToSecurityGroups {
+						referredAsgIDs[sg.Name] = struct{}{}
+					}
+				}
+				content.IngressRules = append(content.IngressRules, ingressRules...)
+				content.EgressRules = append(content.EgressRules, egressRules...)
+				unsupported = append(unsupported, ruleUnsupported...)
+			}
+		}
+		content.Unsupported = unsupported
+		contents = append(contents, content)
+	}
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].Resource.Name < contents[j].Resource.Name
+	})
+
+	asgIDs := make([]string, 0, len(referredAsgIDs))
+	for asgID := range referredAsgIDs {
+		asgIDs = append(asgIDs, asgID)
+	}
+	sort.Strings(asgIDs)
+	for _, asgID := range asgIDs {
+		content := securitygroup.ImportContent{
+			Resource: securitygroup.CloudResourceID{
+				Name: asgID,
+				Vpc:  vnetID,
+			},
+			MembershipOnly: true,
+			Members:        asgIDToMembers[asgID],
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// convertFromAzureSecurityRuleToImportRules converts an azure security rule to nephe-controller rules of its direction,
+// one rule per destination port range, application security groups are referred to by lowercase ID. Settings of the
+// rule that cannot be converted are described in the returned strings, the rule is dropped if it can only be partially
+// enforced.
+func convertFromAzureSecurityRuleToImportRules(rule network.SecurityRule, vnetID string) ([]securitygroup.IngressRule,
+	[]securitygroup.EgressRule, []string) {
+	if rule.SecurityRulePropertiesFormat == nil {
+		return nil, nil, nil
+	}
+	ruleName := to.String(rule.Name)
+	protoNum, err := convertFromAzureProtocolToNepheControllerProtocol(rule.Protocol)
+	if err != nil {
+		return nil, nil, []string{fmt.Sprintf("rule %v uses protocol %v", ruleName, rule.Protocol)}
+	}
+	if !isAnyAzureSecurityRuleField(rule.SourcePortRange, rule.SourcePortRanges) {
+		return nil, nil, []string{fmt.Sprintf("rule %v restricts source ports", ruleName)}
+	}
+
+	isIngress := rule.Direction == network.SecurityRuleDirectionInbound
+	localPrefix, localPrefixes, localAsgs := rule.DestinationAddressPrefix, rule.DestinationAddressPrefixes,
+		rule.DestinationApplicationSecurityGroups
+	peerPrefix, peerPrefixes, peerAsgs := rule.SourceAddressPrefix, rule.SourceAddressPrefixes, rule.SourceApplicationSecurityGroups
+	if !isIngress {
+		localPrefix, localPrefixes, localAsgs, peerPrefix, peerPrefixes, peerAsgs = peerPrefix, peerPrefixes, peerAsgs,
+			localPrefix, localPrefixes, localAsgs
+	}
+	// VirtualNetwork always includes addresses of the network interfaces the rule is applied to.
+	if (localAsgs != nil && len(*localAsgs) > 0) ||
+		(!isAnyAzureSecurityRuleField(localPrefix, localPrefixes) && to.String(localPrefix) != virtualnetworkAddressPrefix) {
+		return nil, nil, []string{fmt.Sprintf("rule %v restricts addresses of network interfaces it is attached to", ruleName)}
+	}
+
+	var unsupported []string
+	var peerIPs []*net.IPNet
+	var peerSgs []*securitygroup.CloudResourceID
+	prefixes := to.StringSlice(peerPrefixes)
+	if peerPrefix != nil {
+		prefixes = append(prefixes, *peerPrefix)
+	}
+	for _, prefix := range prefixes {
+		ipNet, err := convertFromAzureAddressPrefixToImportIPs(prefix)
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("rule %v refers to %v", ruleName, prefix))
+			continue
+		}
+		peerIPs = append(peerIPs, ipNet...)
+	}
+	if peerAsgs != nil {
+		for _, asg := range *peerAsgs {
+			peerSgs = append(peerSgs, &securitygroup.CloudResourceID{
+				Name: strings.ToLower(to.String(asg.ID)),
+				Vpc:  vnetID,
+			})
+		}
+	}
+	if len(peerIPs) == 0 && len(peerSgs) == 0 {
+		return nil, nil, unsupported
+	}
+
+	portRanges := to.StringSlice(rule.DestinationPortRanges)
+	if rule.DestinationPortRange != nil || len(portRanges) == 0 {
+		portRanges = append(portRanges, to.String(rule.DestinationPortRange))
+	}
+	var ingressRules []securitygroup.IngressRule
+	var egressRules []securitygroup.EgressRule
+	for i := range portRanges {
+		port, endPort := convertFromAzurePortToNepheControllerPort(&portRanges[i])
+		priority := &securitygroup.RulePriority{RuleIndex: to.Int32(rule.Priority)}
+		action := convertFromAzureSecurityRuleAccess(rule.Access)
+		if isIngress {
+			ingressRules = append(ingressRules, securitygroup.IngressRule{FromPort: port, FromEndPort: endPort,
+				FromSrcIP: peerIPs, FromSecurityGroups: peerSgs, Protocol: protoNum, Action: action, Priority: priority})
+		} else {
+			egressRules = append(egressRules, securitygroup.EgressRule{ToPort: port, ToEndPort: endPort,
+				ToDstIP: peerIPs, ToSecurityGroups: peerSgs, Protocol: protoNum, Action: action, Priority: priority})
+		}
+	}
+	return ingressRules, egressRules, unsupported
+}
+
+// isAnyAzureSecurityRuleField returns true if an address prefix or port range field of azure security rule matches any.
+func isAnyAzureSecurityRuleField(value *string, values *[]string) bool {
+	return (value == nil || *value == emptyPort) && len(to.StringSlice(values)) == 0
+}
+
+// convertFromAzureAddressPrefixToImportIPs returns ip blocks of an azure address prefix, which is an IP address, CIDR or
+// "*", and error for service tags.
+func convertFromAzureAddressPrefixToImportIPs(prefix string) ([]*net.IPNet, error) {
+	if prefix == emptyPort {
+		return anyIPNets, nil
+	}
+	if _, ipNet, err := net.ParseCIDR(prefix); err == nil {
+		return []*net.IPNet{ipNet}, nil
+	}
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return nil, fmt.Errorf("unsupported address prefix %v", prefix)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+	return []*net.IPNet{{IP: ip, Mask: net.CIDRMask(bits, bits)}}, nil
+}
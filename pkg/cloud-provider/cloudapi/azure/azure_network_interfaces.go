@@ -29,6 +29,7 @@ import (
 func (p *azureServiceSdkConfigProvider) networkInterfaces(subscriptionID string) (azureNwIntfWrapper, error) {
 	interfacesClient := network.NewInterfacesClient(subscriptionID)
 	interfacesClient.Authorizer = p.authorizer
+	interfacesClient.Sender = p.sender
 	return &azureNwIntfWrapperImpl{nwIntfAPIClient: interfacesClient}, nil
 }
 
@@ -50,14 +51,14 @@ func updateNetworkInterfaceAsg(nwIntfAPIClient azureNwIntfWrapper, nwIntfObj net
 
 func updateNetworkInterfaceNsg(nwIntfAPIClient azureNwIntfWrapper, nwIntfObj network.Interface,
 	nsgObjToAttachOrDetach network.SecurityGroup, asgObjToAttachOrDetach network.ApplicationSecurityGroup,
-	isAttach bool, tagKey string) error {
+	isAttach bool, isPreserved bool, tagKey string) error {
 	if nwIntfObj.ID == nil {
 		return fmt.Errorf("network interface object is empty")
 	}
 
 	_, rgName, resName, _ := extractFieldsFromAzureResourceID(*nwIntfObj.ID)
 
-	nsg, tags := getUpdatedNetworkInterfaceNsgAndTags(&nwIntfObj, nsgObjToAttachOrDetach, isAttach, tagKey)
+	nsg, tags := getUpdatedNetworkInterfaceNsgAndTags(&nwIntfObj, nsgObjToAttachOrDetach, isAttach, isPreserved, tagKey)
 	ipConfigurations := getAsgUpdatedIPConfigurations(&nwIntfObj, asgObjToAttachOrDetach, isAttach)
 
 	nwIntfObj.IPConfigurations = ipConfigurations
@@ -120,18 +121,23 @@ func getAsgUpdatedIPConfigurations(nwIntfObj *network.Interface, asgObjToAttachO
 	return ipConfigurations
 }
 
+// getUpdatedNetworkInterfaceNsgAndTags returns NSG and tags of network interface attached to, or detached from, AT sg of
+// tagKey. NSG not created by nephe-controller of a network interface whose security groups are preserved is kept.
 func getUpdatedNetworkInterfaceNsgAndTags(nwIntfObj *network.Interface, nsgObjToAttachOrDetach network.SecurityGroup, isAttach bool,
-	tagKey string) (*network.SecurityGroup, map[string]*string) {
+	isPreserved bool, tagKey string) (*network.SecurityGroup, map[string]*string) {
 	currentTags := nwIntfObj.Tags
+	isNsgPreserved := isPreserved && nwIntfObj.NetworkSecurityGroup != nil && !isNepheControllerCreatedNsg(nwIntfObj.NetworkSecurityGroup)
 	if isAttach {
-		nwIntfObj.NetworkSecurityGroup = &nsgObjToAttachOrDetach
+		if !isNsgPreserved {
+			nwIntfObj.NetworkSecurityGroup = &nsgObjToAttachOrDetach
+		}
 		if currentTags == nil {
 			currentTags = make(map[string]*string)
 		}
 		currentTags[tagKey] = to.StringPtr("true")
 	} else {
 		delete(currentTags, tagKey)
-		if !hasAnyNepheControllerSecurityGroupTags(currentTags) {
+		if !hasAnyNepheControllerSecurityGroupTags(currentTags) && !isNsgPreserved {
 			nwIntfObj.NetworkSecurityGroup = nil
 		}
 	}
@@ -139,6 +145,24 @@ func getUpdatedNetworkInterfaceNsgAndTags(nwIntfObj *network.Interface, nsgObjTo
 	return nwIntfObj.NetworkSecurityGroup, currentTags
 }
 
+// isNepheControllerCreatedNsg returns true if NSG is the per vnet NSG created by nephe-controller.
+func isNepheControllerCreatedNsg(nsg *network.SecurityGroup) bool {
+	if nsg.ID == nil {
+		return false
+	}
+	_, _, nsgName, err := extractFieldsFromAzureResourceID(*nsg.ID)
+	if err != nil {
+		return false
+	}
+	return isNepheControllerCreatedNsgName(nsgName)
+}
+
+// isNepheControllerCreatedNsgName returns true if NSG name is the name of the per vnet NSG created by nephe-controller.
+func isNepheControllerCreatedNsgName(nsgName string) bool {
+	_, _, isAT := securitygroup.IsNepheControllerCreatedSG(nsgName)
+	return isAT
+}
+
 func hasAnyNepheControllerSecurityGroupTags(tags map[string]*string) bool {
 	for key := range tags {
 		_, _, isATSG := securitygroup.IsNepheControllerCreatedSG(key)
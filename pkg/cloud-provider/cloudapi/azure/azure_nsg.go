@@ -27,6 +27,7 @@ import (
 func (p *azureServiceSdkConfigProvider) securityGroups(subscriptionID string) (azureNsgWrapper, error) {
 	securityGroupsClient := network.NewSecurityGroupsClient(subscriptionID)
 	securityGroupsClient.Authorizer = p.authorizer
+	securityGroupsClient.Sender = p.sender
 	return &azureNsgWrapperImpl{nsgAPIClient: securityGroupsClient}, nil
 }
 
@@ -17,6 +17,7 @@ package azure
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -29,6 +30,7 @@ import (
 const (
 	ruleStartPriority             = 100
 	vnetToVnetDenyRulePriority    = 4096
+	tierRulePriorityBandSize      = 100
 	vnetToVnetDenyRuleDescription = "nephe-at-" + appliedToSecurityGroupNamePerVnet
 	emptyPort                     = "*"
 	virtualnetworkAddressPrefix   = "VirtualNetwork"
@@ -46,31 +48,62 @@ var azureProtoNameToNumMap = map[network.SecurityRuleProtocol]int{
 	network.SecurityRuleProtocolUDP:  17,
 }
 
-func updateSecurityRuleNameAndPriority(existingRules []network.SecurityRule, newRules []network.SecurityRule) []network.SecurityRule {
+// updateSecurityRuleNameAndPriority orders existingRules and newRules by tier, policy priority and rule index, with
+// deny rules ahead of allow rules of same order, and assigns azure priorities accordingly. Rules of each tier start at
+// a multiple of tierRulePriorityBandSize when possible, so that their priorities stay stable when other tiers change.
+func updateSecurityRuleNameAndPriority(existingRules []network.SecurityRule,
+	newRules []network.SecurityRule) ([]network.SecurityRule, error) {
+	return updateSecurityRuleNameAndPriorityAroundRules(existingRules, newRules, nil)
+}
+
+// updateSecurityRuleNameAndPriorityAroundRules is updateSecurityRuleNameAndPriority skipping azure priorities of
+// otherRules, which are security rules not created by nephe-controller in the same direction and network security
+// group.
+func updateSecurityRuleNameAndPriorityAroundRules(existingRules []network.SecurityRule,
+	newRules []network.SecurityRule, otherRules []network.SecurityRule) ([]network.SecurityRule, error) {
 	var rules []network.SecurityRule
+	var orderedRules []network.SecurityRule
 	defaultRulesByName := make(map[string]network.SecurityRule)
+	otherRulePriorities := make(map[int32]struct{})
+	for _, rule := range otherRules {
+		if rule.Priority != nil {
+			otherRulePriorities[*rule.Priority] = struct{}{}
+		}
+	}
 
-	rulePriority := int32(ruleStartPriority)
-	for _, rule := range existingRules {
+	allRules := make([]network.SecurityRule, 0, len(existingRules)+len(newRules))
+	allRules = append(allRules, existingRules...)
+	allRules = append(allRules, newRules...)
+	for _, rule := range allRules {
 		if *rule.Priority == vnetToVnetDenyRulePriority {
 			defaultRulesByName[*rule.Name] = rule
 			continue
 		}
-		ruleName := fmt.Sprintf("%v-%v", rulePriority, rule.Direction)
-		rule.Name = &ruleName
-		rule.Priority = to.Int32Ptr(rulePriority)
-
-		rules = append(rules, rule)
-		rulePriority++
+		orderedRules = append(orderedRules, rule)
 	}
+	sort.SliceStable(orderedRules, func(i, j int) bool {
+		return isSecurityRuleEvaluatedBefore(orderedRules[i], orderedRules[j])
+	})
 
-	for _, rule := range newRules {
-		if *rule.Priority == vnetToVnetDenyRulePriority {
-			defaultRulesByName[*rule.Name] = rule
-			continue
+	rulePriority := int32(ruleStartPriority)
+	var lastPriority *securitygroup.RulePriority
+	for i, rule := range orderedRules {
+		priority := getSecurityRulePriority(rule.Name)
+		if i > 0 && !isSameTier(lastPriority, priority) {
+			bandPriority := (rulePriority + tierRulePriorityBandSize - 1) / tierRulePriorityBandSize * tierRulePriorityBandSize
+			if bandPriority+int32(len(orderedRules)-i) <= vnetToVnetDenyRulePriority {
+				rulePriority = bandPriority
+			}
+		}
+		lastPriority = priority
+		for _, found := otherRulePriorities[rulePriority]; found; _, found = otherRulePriorities[rulePriority] {
+			rulePriority++
+		}
+		if rulePriority >= vnetToVnetDenyRulePriority {
+			return nil, fmt.Errorf("%v security rules exceed azure priority range [%v, %v)", rule.Direction,
+				ruleStartPriority, vnetToVnetDenyRulePriority)
 		}
-		ruleName := fmt.Sprintf("%v-%v", rulePriority, rule.Direction)
-		rule.Name = &ruleName
+		rule.Name = to.StringPtr(buildSecurityRuleName(rulePriority, rule.Direction, priority))
 		rule.Priority = to.Int32Ptr(rulePriority)
 
 		rules = append(rules, rule)
@@ -81,7 +114,107 @@ func updateSecurityRuleNameAndPriority(existingRules []network.SecurityRule, new
 		rules = append(rules, rule)
 	}
 
-	return rules
+	return rules, nil
+}
+
+// isNepheControllerCreatedSecurityRule returns true if azure security rule is created by nephe-controller.
+func isNepheControllerCreatedSecurityRule(rule network.SecurityRule) bool {
+	if rule.Description == nil {
+		return false
+	}
+	_, _, isNepheControllerCreatedRule := securitygroup.IsNepheControllerCreatedSG(*rule.Description)
+	return isNepheControllerCreatedRule
+}
+
+// getSecurityRulesOfDirection returns azure security rules of the direction.
+func getSecurityRulesOfDirection(rules []network.SecurityRule, direction network.SecurityRuleDirection) []network.SecurityRule {
+	var rulesOfDirection []network.SecurityRule
+	for _, rule := range rules {
+		if rule.Direction == direction {
+			rulesOfDirection = append(rulesOfDirection, rule)
+		}
+	}
+	return rulesOfDirection
+}
+
+// removeVnetToVnetDenyRule returns rules without the vnet to vnet deny rule. It is not added to a network security group
+// designated by the account, where it would deny traffic of network interfaces not managed by nephe-controller as well.
+func removeVnetToVnetDenyRule(rules []network.SecurityRule) []network.SecurityRule {
+	var rulesToKeep []network.SecurityRule
+	for _, rule := range rules {
+		if rule.Priority != nil && *rule.Priority == vnetToVnetDenyRulePriority {
+			continue
+		}
+		rulesToKeep = append(rulesToKeep, rule)
+	}
+	return rulesToKeep
+}
+
+// isSecurityRuleEvaluatedBefore returns true if azure security rule a shall be assigned higher priority than b.
+func isSecurityRuleEvaluatedBefore(a, b network.SecurityRule) bool {
+	aPriority, bPriority := getSecurityRulePriority(a.Name), getSecurityRulePriority(b.Name)
+	if aPriority.Less(bPriority) {
+		return true
+	}
+	if bPriority.Less(aPriority) {
+		return false
+	}
+	return a.Access == network.SecurityRuleAccessDeny && b.Access != network.SecurityRuleAccessDeny
+}
+
+// isSameTier returns true if rule priorities a and b belong to same tier, rules without priority are of same tier.
+func isSameTier(a, b *securitygroup.RulePriority) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.TierPriority == b.TierPriority
+}
+
+// buildSecurityRuleName returns name of azure security rule. Rule priority is kept in the name so that security rules
+// of other applied to groups can be ordered when security rules of an applied to group are updated.
+func buildSecurityRuleName(rulePriority int32, direction network.SecurityRuleDirection,
+	priority *securitygroup.RulePriority) string {
+	if priority == nil {
+		return fmt.Sprintf("%v-%v", rulePriority, direction)
+	}
+	return fmt.Sprintf("%v-%v-%v-%v-%v", rulePriority, direction, priority.TierPriority,
+		strconv.FormatFloat(priority.PolicyPriority, 'f', -1, 64), priority.RuleIndex)
+}
+
+// getSecurityRulePriority returns rule priority kept in name of azure security rule, or nil if name has no priority.
+func getSecurityRulePriority(name *string) *securitygroup.RulePriority {
+	if name == nil {
+		return nil
+	}
+	fields := strings.Split(*name, "-")
+	if len(fields) != 5 {
+		return nil
+	}
+	tierPriority, err := strconv.ParseInt(fields[2], 10, 32)
+	if err != nil {
+		return nil
+	}
+	policyPriority, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return nil
+	}
+	ruleIndex, err := strconv.ParseInt(fields[4], 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &securitygroup.RulePriority{
+		TierPriority:   int32(tierPriority),
+		PolicyPriority: policyPriority,
+		RuleIndex:      int32(ruleIndex),
+	}
+}
+
+// setSecurityRulesPriority keeps rule priority in names of azure security rules built from one rule.
+func setSecurityRulesPriority(securityRules []network.SecurityRule, priority *securitygroup.RulePriority) {
+	for i := range securityRules {
+		securityRules[i].Name = to.StringPtr(buildSecurityRuleName(*securityRules[i].Priority, securityRules[i].Direction,
+			priority))
+	}
 }
 
 func convertIngressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.CloudResourceID, rules []*securitygroup.IngressRule,
@@ -96,7 +229,7 @@ func convertIngressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.Cloud
 
 	rulePriority := int32(ruleStartPriority)
 	description := appliedToGroupID.GetCloudName(false)
-	for _, rule := range rules {
+	for _, rule := range orderIngressRulesByAction(rules) {
 		if rule == nil {
 			continue
 		}
@@ -104,16 +237,21 @@ func convertIngressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.Cloud
 		if err != nil {
 			return []network.SecurityRule{}, err
 		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return []network.SecurityRule{}, fmt.Errorf("azure security rule does not support icmp type and code")
+		}
+		access := convertToAzureSecurityRuleAccess(rule.Action)
+		ruleStart := len(securityRules)
 
-		srcPort := convertToAzurePortRange(rule.FromPort)
+		srcPort := convertToAzurePortRange(rule.FromPort, rule.FromEndPort)
 
 		if len(rule.FromSrcIP) != 0 || len(rule.FromSecurityGroups) == 0 {
-			srcAddrPrefix, srcAddrPrefixes := convertToAzureAddressPrefix(rule.FromSrcIP)
-			if srcAddrPrefix != nil || srcAddrPrefixes != nil {
+			for _, srcIPs := range splitAzureRuleIPsByFamily(rule.FromSrcIP) {
+				srcAddrPrefix, srcAddrPrefixes := convertToAzureAddressPrefix(srcIPs)
 				securityRule := buildSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionInbound,
 					to.StringPtr(emptyPort), srcAddrPrefix, srcAddrPrefixes, nil,
 					&srcPort, nil, nil, &[]network.ApplicationSecurityGroup{dstAsgObj}, &description,
-					network.SecurityRuleAccessAllow)
+					access)
 				securityRules = append(securityRules, securityRule)
 				rulePriority++
 			}
@@ -124,10 +262,11 @@ func convertIngressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.Cloud
 			securityRule := buildSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionInbound,
 				to.StringPtr(emptyPort), nil, nil, srcApplicationSecurityGroups,
 				&srcPort, nil, nil, &[]network.ApplicationSecurityGroup{dstAsgObj}, &description,
-				network.SecurityRuleAccessAllow)
+				access)
 			securityRules = append(securityRules, securityRule)
 			rulePriority++
 		}
+		setSecurityRulesPriority(securityRules[ruleStart:], rule.Priority)
 	}
 	// add vnet to vnet deny all rule
 	securityRule := buildSecurityRule(to.Int32Ptr(vnetToVnetDenyRulePriority), network.SecurityRuleProtocolAsterisk,
@@ -146,7 +285,7 @@ func convertIngressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.C
 
 	rulePriority := int32(ruleStartPriority)
 	description := appliedToGroupID.GetCloudName(false)
-	for _, rule := range rules {
+	for _, rule := range orderIngressRulesByAction(rules) {
 		if rule == nil {
 			continue
 		}
@@ -154,16 +293,21 @@ func convertIngressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.C
 		if err != nil {
 			return []network.SecurityRule{}, err
 		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return []network.SecurityRule{}, fmt.Errorf("azure security rule does not support icmp type and code")
+		}
+		access := convertToAzureSecurityRuleAccess(rule.Action)
+		ruleStart := len(securityRules)
 
-		srcPort := convertToAzurePortRange(rule.FromPort)
+		srcPort := convertToAzurePortRange(rule.FromPort, rule.FromEndPort)
 
 		if len(rule.FromSrcIP) != 0 || len(rule.FromSecurityGroups) == 0 {
-			srcAddrPrefix, srcAddrPrefixes := convertToAzureAddressPrefix(rule.FromSrcIP)
-			if srcAddrPrefix != nil || srcAddrPrefixes != nil {
+			for _, srcIPs := range splitAzureRuleIPsByFamily(rule.FromSrcIP) {
+				srcAddrPrefix, srcAddrPrefixes := convertToAzureAddressPrefix(srcIPs)
 				securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionInbound,
 					to.StringPtr(emptyPort), srcAddrPrefix, srcAddrPrefixes, nil,
 					&srcPort, to.StringPtr(emptyPort), nil, nil, &description,
-					network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+					access, appliedToGroupID.Name)
 				securityRules = append(securityRules, securityRule)
 				rulePriority++
 			}
@@ -176,7 +320,7 @@ func convertIngressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.C
 					securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionInbound,
 						to.StringPtr(emptyPort), nil, nil, srcApplicationSecurityGroups,
 						&srcPort, to.StringPtr(emptyPort), nil, nil, &description,
-						network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+						access, appliedToGroupID.Name)
 					securityRules = append(securityRules, securityRule)
 					rulePriority++
 					flag = 1
@@ -188,10 +332,11 @@ func convertIngressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.C
 			securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionInbound,
 				to.StringPtr(emptyPort), ruleIP, nil, nil,
 				&srcPort, to.StringPtr(emptyPort), nil, nil, &description,
-				network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+				access, appliedToGroupID.Name)
 			securityRules = append(securityRules, securityRule)
 			rulePriority++
 		}
+		setSecurityRulesPriority(securityRules[ruleStart:], rule.Priority)
 	}
 	// add vnet to vnet deny all rule
 	securityRule := buildPeerSecurityRule(to.Int32Ptr(vnetToVnetDenyRulePriority), network.SecurityRuleProtocolAsterisk,
@@ -215,7 +360,7 @@ func convertEgressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.CloudR
 
 	rulePriority := int32(ruleStartPriority)
 	description := appliedToGroupID.GetCloudName(false)
-	for _, rule := range rules {
+	for _, rule := range orderEgressRulesByAction(rules) {
 		if rule == nil {
 			continue
 		}
@@ -223,15 +368,20 @@ func convertEgressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.CloudR
 		if err != nil {
 			return []network.SecurityRule{}, err
 		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return []network.SecurityRule{}, fmt.Errorf("azure security rule does not support icmp type and code")
+		}
+		access := convertToAzureSecurityRuleAccess(rule.Action)
+		ruleStart := len(securityRules)
 
-		dstPort := convertToAzurePortRange(rule.ToPort)
+		dstPort := convertToAzurePortRange(rule.ToPort, rule.ToEndPort)
 
 		if len(rule.ToDstIP) != 0 || len(rule.ToSecurityGroups) == 0 {
-			dstAddrPrefix, dstAddrPrefixes := convertToAzureAddressPrefix(rule.ToDstIP)
-			if dstAddrPrefix != nil || dstAddrPrefixes != nil {
+			for _, dstIPs := range splitAzureRuleIPsByFamily(rule.ToDstIP) {
+				dstAddrPrefix, dstAddrPrefixes := convertToAzureAddressPrefix(dstIPs)
 				securityRule := buildSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionOutbound,
 					to.StringPtr(emptyPort), nil, nil, &[]network.ApplicationSecurityGroup{srcAsgObj},
-					&dstPort, dstAddrPrefix, dstAddrPrefixes, nil, &description, network.SecurityRuleAccessAllow)
+					&dstPort, dstAddrPrefix, dstAddrPrefixes, nil, &description, access)
 				securityRules = append(securityRules, securityRule)
 				rulePriority++
 			}
@@ -241,10 +391,11 @@ func convertEgressToAzureNsgSecurityRules(appliedToGroupID *securitygroup.CloudR
 		if dstApplicationSecurityGroups != nil && len(*dstApplicationSecurityGroups) != 0 {
 			securityRule := buildSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionOutbound,
 				to.StringPtr(emptyPort), nil, nil, &[]network.ApplicationSecurityGroup{srcAsgObj},
-				&dstPort, nil, nil, dstApplicationSecurityGroups, &description, network.SecurityRuleAccessAllow)
+				&dstPort, nil, nil, dstApplicationSecurityGroups, &description, access)
 			securityRules = append(securityRules, securityRule)
 			rulePriority++
 		}
+		setSecurityRulesPriority(securityRules[ruleStart:], rule.Priority)
 	}
 
 	// add vnet to vnet deny all rule
@@ -264,7 +415,7 @@ func convertEgressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.Cl
 
 	rulePriority := int32(ruleStartPriority)
 	description := appliedToGroupID.GetCloudName(false)
-	for _, rule := range rules {
+	for _, rule := range orderEgressRulesByAction(rules) {
 		if rule == nil {
 			continue
 		}
@@ -272,15 +423,20 @@ func convertEgressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.Cl
 		if err != nil {
 			return []network.SecurityRule{}, err
 		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return []network.SecurityRule{}, fmt.Errorf("azure security rule does not support icmp type and code")
+		}
+		access := convertToAzureSecurityRuleAccess(rule.Action)
+		ruleStart := len(securityRules)
 
-		dstPort := convertToAzurePortRange(rule.ToPort)
+		dstPort := convertToAzurePortRange(rule.ToPort, rule.ToEndPort)
 
 		if len(rule.ToDstIP) != 0 || len(rule.ToSecurityGroups) == 0 {
-			dstAddrPrefix, dstAddrPrefixes := convertToAzureAddressPrefix(rule.ToDstIP)
-			if dstAddrPrefix != nil || dstAddrPrefixes != nil {
+			for _, dstIPs := range splitAzureRuleIPsByFamily(rule.ToDstIP) {
+				dstAddrPrefix, dstAddrPrefixes := convertToAzureAddressPrefix(dstIPs)
 				securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionOutbound,
 					to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
-					&dstPort, dstAddrPrefix, dstAddrPrefixes, nil, &description, network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+					&dstPort, dstAddrPrefix, dstAddrPrefixes, nil, &description, access, appliedToGroupID.Name)
 				securityRules = append(securityRules, securityRule)
 				rulePriority++
 			}
@@ -292,7 +448,7 @@ func convertEgressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.Cl
 				if dstApplicationSecurityGroups != nil && len(*dstApplicationSecurityGroups) != 0 {
 					securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionOutbound,
 						to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
-						&dstPort, nil, nil, dstApplicationSecurityGroups, &description, network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+						&dstPort, nil, nil, dstApplicationSecurityGroups, &description, access, appliedToGroupID.Name)
 					securityRules = append(securityRules, securityRule)
 					rulePriority++
 					flag = 1
@@ -303,10 +459,11 @@ func convertEgressToAzurePeerNsgSecurityRules(appliedToGroupID *securitygroup.Cl
 		if flag == 0 {
 			securityRule := buildPeerSecurityRule(to.Int32Ptr(rulePriority), protoName, network.SecurityRuleDirectionOutbound,
 				to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
-				&dstPort, ruleIP, nil, nil, &description, network.SecurityRuleAccessAllow, appliedToGroupID.Name)
+				&dstPort, ruleIP, nil, nil, &description, access, appliedToGroupID.Name)
 			securityRules = append(securityRules, securityRule)
 			rulePriority++
 		}
+		setSecurityRulesPriority(securityRules[ruleStart:], rule.Priority)
 	}
 
 	// add vnet to vnet deny all rule
@@ -375,6 +532,43 @@ func buildPeerSecurityRule(rulePriority *int32, protoName network.SecurityRulePr
 	return securityRule
 }
 
+// convertToAzureSecurityRuleAccess returns azure security rule access of rule action. Azure does not notify the peer
+// of denied traffic, both Drop and Reject are realized as Deny.
+func convertToAzureSecurityRuleAccess(action securitygroup.RuleAction) network.SecurityRuleAccess {
+	if action.IsDeny() {
+		return network.SecurityRuleAccessDeny
+	}
+	return network.SecurityRuleAccessAllow
+}
+
+// orderIngressRulesByAction returns rules with Drop/Reject rules ahead of Allow rules, so that deny security rules are
+// assigned higher priority than allow security rules.
+func orderIngressRulesByAction(rules []*securitygroup.IngressRule) []*securitygroup.IngressRule {
+	var denyRules, allowRules []*securitygroup.IngressRule
+	for _, rule := range rules {
+		if rule != nil && rule.Action.IsDeny() {
+			denyRules = append(denyRules, rule)
+		} else {
+			allowRules = append(allowRules, rule)
+		}
+	}
+	return append(denyRules, allowRules...)
+}
+
+// orderEgressRulesByAction returns rules with Drop/Reject rules ahead of Allow rules, so that deny security rules are
+// assigned higher priority than allow security rules.
+func orderEgressRulesByAction(rules []*securitygroup.EgressRule) []*securitygroup.EgressRule {
+	var denyRules, allowRules []*securitygroup.EgressRule
+	for _, rule := range rules {
+		if rule != nil && rule.Action.IsDeny() {
+			denyRules = append(denyRules, rule)
+		} else {
+			allowRules = append(allowRules, rule)
+		}
+	}
+	return append(denyRules, allowRules...)
+}
+
 func convertToAzureApplicationSecurityGroups(securityGroups []*securitygroup.CloudResourceID,
 	asgByNepheControllerName map[string]network.ApplicationSecurityGroup) *[]network.ApplicationSecurityGroup {
 	var asgsToReturn []network.ApplicationSecurityGroup
@@ -402,13 +596,34 @@ func convertToAzureProtocolName(protoNum *int) (network.SecurityRuleProtocol, er
 	return protocolName, nil
 }
 
-func convertToAzurePortRange(port *int) string {
+func convertToAzurePortRange(port *int, endPort *int) string {
 	if port == nil {
 		return emptyPort
 	}
+	if endPort != nil {
+		return fmt.Sprintf("%v-%v", *port, *endPort)
+	}
 	return strconv.Itoa(*port)
 }
 
+// splitAzureRuleIPsByFamily groups rule IPs by IP family, as an azure security rule can not mix IPv4 and IPv6 address
+// prefixes. A single empty group is returned when there are no IPs, which is converted to any address prefix.
+func splitAzureRuleIPsByFamily(ruleIPs []*net.IPNet) [][]*net.IPNet {
+	ipv4IPs, ipv6IPs := securitygroup.SplitIPNetsByFamily(ruleIPs)
+	if len(ipv4IPs) == 0 && len(ipv6IPs) == 0 {
+		return [][]*net.IPNet{nil}
+	}
+
+	var ipGroups [][]*net.IPNet
+	if len(ipv4IPs) != 0 {
+		ipGroups = append(ipGroups, ipv4IPs)
+	}
+	if len(ipv6IPs) != 0 {
+		ipGroups = append(ipGroups, ipv6IPs)
+	}
+	return ipGroups
+}
+
 func convertToAzureAddressPrefix(ruleIPs []*net.IPNet) (*string, *[]string) {
 	var prefixes []string
 	for _, ip := range ruleIPs {
@@ -430,6 +645,10 @@ func convertToNepheControllerRulesByAppliedToSGName(azureSecurityRules *[]networ
 	nepheControllerATSgNameToIngressRules := make(map[string][]securitygroup.IngressRule)
 	nepheControllerATSgNameToEgressRules := make(map[string][]securitygroup.EgressRule)
 	for _, azureSecurityRule := range *azureSecurityRules {
+		// skip rules not created by nephe, e.g. in a network security group designated by the account.
+		if azureSecurityRule.Description == nil {
+			continue
+		}
 		sgName, _, isATSg := securitygroup.IsNepheControllerCreatedSG(*azureSecurityRule.Description)
 		if !isATSg {
 			continue
@@ -460,7 +679,7 @@ func convertToNepheControllerRulesByAppliedToSGName(azureSecurityRules *[]networ
 }
 
 func convertFromAzureSecurityRuleToNepheControllerIngressRule(rule network.SecurityRule, vnetID string) (securitygroup.IngressRule, error) {
-	port := convertFromAzurePortToNepheControllerPort(rule.DestinationPortRange)
+	port, endPort := convertFromAzurePortToNepheControllerPort(rule.DestinationPortRange)
 	srcIP := convertFromAzurePrefixesToNepheControllerIPs(rule.SourceAddressPrefix, rule.SourceAddressPrefixes)
 	securityGroups := convertFromAzureASGsToNepheControllerSecurityGroups(rule.SourceApplicationSecurityGroups, vnetID)
 	protoNum, err := convertFromAzureProtocolToNepheControllerProtocol(rule.Protocol)
@@ -469,16 +688,19 @@ func convertFromAzureSecurityRuleToNepheControllerIngressRule(rule network.Secur
 	}
 	ingressRule := securitygroup.IngressRule{
 		FromPort:           port,
+		FromEndPort:        endPort,
 		FromSrcIP:          srcIP,
 		FromSecurityGroups: securityGroups,
 		Protocol:           protoNum,
+		Action:             convertFromAzureSecurityRuleAccess(rule.Access),
+		Priority:           getSecurityRulePriority(rule.Name),
 	}
 
 	return ingressRule, nil
 }
 
 func convertFromAzureSecurityRuleToNepheControllerEgressRule(rule network.SecurityRule, vnetID string) (securitygroup.EgressRule, error) {
-	port := convertFromAzurePortToNepheControllerPort(rule.DestinationPortRange)
+	port, endPort := convertFromAzurePortToNepheControllerPort(rule.DestinationPortRange)
 	dstIP := convertFromAzurePrefixesToNepheControllerIPs(rule.DestinationAddressPrefix, rule.DestinationAddressPrefixes)
 	securityGroups := convertFromAzureASGsToNepheControllerSecurityGroups(rule.DestinationApplicationSecurityGroups, vnetID)
 	protoNum, err := convertFromAzureProtocolToNepheControllerProtocol(rule.Protocol)
@@ -488,14 +710,24 @@ func convertFromAzureSecurityRuleToNepheControllerEgressRule(rule network.Securi
 
 	egressRule := securitygroup.EgressRule{
 		ToPort:           port,
+		ToEndPort:        endPort,
 		ToDstIP:          dstIP,
 		ToSecurityGroups: securityGroups,
 		Protocol:         protoNum,
+		Action:           convertFromAzureSecurityRuleAccess(rule.Access),
+		Priority:         getSecurityRulePriority(rule.Name),
 	}
 
 	return egressRule, err
 }
 
+func convertFromAzureSecurityRuleAccess(access network.SecurityRuleAccess) securitygroup.RuleAction {
+	if access == network.SecurityRuleAccessDeny {
+		return securitygroup.RuleActionDrop
+	}
+	return securitygroup.RuleActionAllow
+}
+
 func convertFromAzureProtocolToNepheControllerProtocol(azureProtoName network.SecurityRuleProtocol) (*int, error) {
 	if azureProtoName == network.SecurityRuleProtocolAsterisk {
 		return nil, nil
@@ -558,13 +790,24 @@ func convertFromAzurePrefixesToNepheControllerIPs(ipPrefix *string, ipPrefixes *
 	return ipNetList
 }
 
-func convertFromAzurePortToNepheControllerPort(port *string) *int {
+func convertFromAzurePortToNepheControllerPort(port *string) (*int, *int) {
 	if port == nil || *port == emptyPort {
-		return nil
+		return nil, nil
 	}
-	portNum, err := strconv.ParseInt(*port, 10, 32)
+	portRange := strings.Split(*port, "-")
+	portNum, err := strconv.ParseInt(portRange[0], 10, 32)
 	if err != nil {
-		return nil
+		return nil, nil
+	}
+	if len(portRange) != 2 {
+		return to.IntPtr(int(portNum)), nil
+	}
+	endPortNum, err := strconv.ParseInt(portRange[1], 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+	if endPortNum == portNum {
+		return to.IntPtr(int(portNum)), nil
 	}
-	return to.IntPtr(int(portNum))
+	return to.IntPtr(int(portNum)), to.IntPtr(int(endPortNum))
 }
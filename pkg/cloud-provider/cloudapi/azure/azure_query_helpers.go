@@ -15,10 +15,13 @@
 package azure
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 func convertSelectorToComputeQuery(selector *v1alpha1.CloudEntitySelector, subscriptionIDs []string,
@@ -47,6 +50,7 @@ func buildQueries(vmSelector []v1alpha1.VirtualMachineSelector, subscriptionIDs
 	var vmIDOnlyMatches []v1alpha1.EntityMatch
 	var vmIDAndVMNameMatches []v1alpha1.EntityMatch
 	var vmNameOnlyMatches []v1alpha1.EntityMatch
+	var compositeMatches []v1alpha1.VirtualMachineSelector
 
 	// vpcMatch contains VpcID and vmMatch contains nil:
 	// vpcIDsWithVpcIDOnlyMatches map contains the corresponding vmSelector section.
@@ -61,8 +65,17 @@ func buildQueries(vmSelector []v1alpha1.VirtualMachineSelector, subscriptionIDs
 	// vpcMatch contains nil and vmMatch contains only vmName:
 	// vmNameOnlyMatches slice contains the specific vmMatch section(EntityMatch).
 	// Azure query is created to match only vms matching the matchName.
+	// vpcMatch or vmMatch contains matchTags/matchExpressions or vm name pattern, or excludeMatch is configured:
+	// compositeMatches slice contains the corresponding vmSelector section.
+	// Azure query is created for each combination of vpcMatch and vmMatch section,
+	// carrying all the match criteria configured in them and the excludeMatch criteria.
 
 	for _, match := range vmSelector {
+		if isCompositeMatch(match) {
+			compositeMatches = append(compositeMatches, match)
+			continue
+		}
+
 		isVpcIDPresent := false
 
 		networkMatch := match.VpcMatch
@@ -121,7 +134,8 @@ func buildQueries(vmSelector []v1alpha1.VirtualMachineSelector, subscriptionIDs
 
 	azurePluginLogger().Info("selector stats", "VpcIdOnlyMatch", len(vpcIDsWithVpcIDOnlyMatches),
 		"VpcIdWithOtherMatches", len(vpcIDWithOtherMatches), "VmIdOnlyMatches", len(vmIDOnlyMatches),
-		"VmIdAndVmNameMatches", len(vmIDAndVMNameMatches), "VmNameOnlyMatches", len(vmNameOnlyMatches))
+		"VmIdAndVmNameMatches", len(vmIDAndVMNameMatches), "VmNameOnlyMatches", len(vmNameOnlyMatches),
+		"CompositeMatches", len(compositeMatches))
 
 	var allQueries []*string
 
@@ -156,9 +170,32 @@ func buildQueries(vmSelector []v1alpha1.VirtualMachineSelector, subscriptionIDs
 		allQueries = append(allQueries, vmIDOnlyQuery)
 	}
 
+	compositeQueries, err := buildQueriesForCompositeMatches(compositeMatches, subscriptionIDs, tenantIDs, locations)
+	if err != nil {
+		return nil, err
+	}
+	allQueries = append(allQueries, compositeQueries...)
+
 	return allQueries, nil
 }
 
+// isCompositeMatch returns true if vpcMatch or any vmMatch section of the vm selector contains tag match criteria or
+// vm name pattern, or the vm selector contains excludeMatch.
+func isCompositeMatch(match v1alpha1.VirtualMachineSelector) bool {
+	if len(match.ExcludeMatch) > 0 {
+		return true
+	}
+	if match.VpcMatch != nil && (len(match.VpcMatch.MatchTags) > 0 || len(match.VpcMatch.MatchExpressions) > 0) {
+		return true
+	}
+	for _, vmMatch := range match.VMMatch {
+		if len(vmMatch.MatchTags) > 0 || len(vmMatch.MatchExpressions) > 0 || utils.IsNamePattern(vmMatch.MatchName) {
+			return true
+		}
+	}
+	return false
+}
+
 func buildQueryForVpcIDOnlyMatches(vpcIDsWithVpcIDOnlyMatches map[string]struct{}, subscriptionIDs []string, tenantIDs []string,
 	locations []string) (*string, error) {
 	if len(vpcIDsWithVpcIDOnlyMatches) == 0 {
@@ -263,3 +300,125 @@ func buildFilterForVpcIDWithOtherMatches(vpcIDWithOtherMatches []v1alpha1.Virtua
 	}
 	return allQueries, nil
 }
+
+func buildQueriesForCompositeMatches(compositeMatches []v1alpha1.VirtualMachineSelector, subscriptionIDs []string,
+	tenantIDs []string, locations []string) ([]*string, error) {
+	if len(compositeMatches) == 0 {
+		return nil, nil
+	}
+
+	var allQueries []*string
+	for _, match := range compositeMatches {
+		var vpcIDs []string
+		var vnetClause string
+		if match.VpcMatch != nil {
+			if vpcID := match.VpcMatch.MatchID; len(strings.TrimSpace(vpcID)) > 0 {
+				vpcIDs = append(vpcIDs, vpcID)
+			}
+			vnetClause = buildTagMatchClause(match.VpcMatch)
+		}
+		excludeClause := buildExcludeMatchClause(match.ExcludeMatch)
+
+		if len(match.VMMatch) == 0 {
+			queryString, err := getVMsByCompositeMatchesQuery(vpcIDs, vnetClause, nil, nil, "", excludeClause,
+				subscriptionIDs, tenantIDs, locations)
+			if err != nil {
+				return nil, err
+			}
+			allQueries = append(allQueries, queryString)
+			continue
+		}
+
+		for i := range match.VMMatch {
+			// Build query for each vpcMatch and a vmMatch combination.
+			vmMatch := &match.VMMatch[i]
+			var vmIDs []string
+			var vmNames []string
+			if vmID := vmMatch.MatchID; len(strings.TrimSpace(vmID)) > 0 {
+				vmIDs = append(vmIDs, vmID)
+			}
+			// name pattern is matched along with tags in the vm clause.
+			if vmName := vmMatch.MatchName; len(strings.TrimSpace(vmName)) > 0 && !utils.IsNamePattern(vmName) {
+				vmNames = append(vmNames, vmName)
+			}
+			vmClause := buildVMMatchClause(vmMatch, true)
+			queryString, err := getVMsByCompositeMatchesQuery(vpcIDs, vnetClause, vmNames, vmIDs, vmClause, excludeClause,
+				subscriptionIDs, tenantIDs, locations)
+			if err != nil {
+				return nil, err
+			}
+			allQueries = append(allQueries, queryString)
+		}
+	}
+	return allQueries, nil
+}
+
+// buildExcludeMatchClause builds the Resource Graph predicate matching vms satisfying any excludeMatch section.
+// Empty string is returned if there is no excludeMatch section.
+func buildExcludeMatchClause(excludeMatch []v1alpha1.EntityMatch) string {
+	var predicates []string
+	for i := range excludeMatch {
+		if clause := buildVMMatchClause(&excludeMatch[i], false); len(clause) > 0 {
+			predicates = append(predicates, "("+clause+")")
+		}
+	}
+	return strings.Join(predicates, " or ")
+}
+
+// buildVMMatchClause builds the Resource Graph predicate on vms for an EntityMatch. Predicates are ANDed. vm ID and
+// exact vm name are skipped if matchedByParams is set, as they are then matched by query parameters.
+func buildVMMatchClause(match *v1alpha1.EntityMatch, matchedByParams bool) string {
+	var predicates []string
+	if vmID := match.MatchID; len(strings.TrimSpace(vmID)) > 0 && !matchedByParams {
+		predicates = append(predicates, fmt.Sprintf("id == %s", strconv.Quote(strings.ToLower(vmID))))
+	}
+	if vmName := strings.ToLower(match.MatchName); len(strings.TrimSpace(vmName)) > 0 {
+		if utils.IsNamePattern(vmName) {
+			predicates = append(predicates, fmt.Sprintf("name matches regex %s",
+				strconv.Quote(utils.NamePatternToRegex(vmName))))
+		} else if !matchedByParams {
+			predicates = append(predicates, fmt.Sprintf("name == %s", strconv.Quote(vmName)))
+		}
+	}
+	if tagClause := buildTagMatchClause(match); len(tagClause) > 0 {
+		predicates = append(predicates, tagClause)
+	}
+	return strings.Join(predicates, " and ")
+}
+
+// buildTagMatchClause builds the Resource Graph predicate on tags of resources, for matchTags and matchExpressions of
+// an EntityMatch. Predicates are ANDed. Empty string is returned if the EntityMatch has no tag match criteria.
+func buildTagMatchClause(match *v1alpha1.EntityMatch) string {
+	var predicates []string
+	var keys []string
+	for key := range match.MatchTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		predicates = append(predicates, fmt.Sprintf("tostring(tags[%s]) == %s", strconv.Quote(key),
+			strconv.Quote(match.MatchTags[key])))
+	}
+
+	for _, expr := range match.MatchExpressions {
+		key := strconv.Quote(expr.Key)
+		var values []string
+		for _, value := range expr.Values {
+			values = append(values, strconv.Quote(value))
+		}
+		sort.Strings(values)
+		switch expr.Operator {
+		case v1alpha1.TagMatchOperatorIn:
+			predicates = append(predicates, fmt.Sprintf("tostring(tags[%s]) in (%s)", key, strings.Join(values, ", ")))
+		case v1alpha1.TagMatchOperatorNotIn:
+			predicates = append(predicates, fmt.Sprintf("tostring(tags[%s]) !in (%s)", key, strings.Join(values, ", ")))
+		case v1alpha1.TagMatchOperatorExists:
+			predicates = append(predicates, fmt.Sprintf("isnotnull(tags[%s])", key))
+		case v1alpha1.TagMatchOperatorDoesNotExist:
+			predicates = append(predicates, fmt.Sprintf("isnull(tags[%s])", key))
+		default:
+			azurePluginLogger().Info("unsupported tag match operator, ignored", "key", expr.Key, "operator", expr.Operator)
+		}
+	}
+	return strings.Join(predicates, " and ")
+}
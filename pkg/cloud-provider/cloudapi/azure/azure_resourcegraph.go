@@ -16,8 +16,10 @@ package azure
 
 import (
 	"context"
+	"sort"
 
 	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
+	"github.com/mitchellh/mapstructure"
 )
 
 const (
@@ -29,12 +31,17 @@ const (
 	vmIDsNotFoundErrorMsg           = "vm ID(s) required for the query"
 	vmNamesNotFoundErrorMsg         = "vm name(s) required for the query"
 	vmIDorNameNotFoundErrorMsg      = "vm ID(s) or name(s) required for the query"
+
+	vnetLocationsQuery = "Resources" +
+		"| where type =~ 'microsoft.network/virtualnetworks'" +
+		"| distinct location"
 )
 
 // resourceGraph returns resource-graph SDK apiClient.
 func (p *azureServiceSdkConfigProvider) resourceGraph() (azureResourceGraphWrapper, error) {
 	baseClient := resourcegraph.New()
 	baseClient.Authorizer = p.authorizer
+	baseClient.Sender = p.sender
 	return &azureResourceGraphWrapperImpl{resourceGraphAPIClient: baseClient}, nil
 }
 
@@ -57,3 +64,30 @@ func invokeResourceGraphQuery(resourceGraphAPIClient azureResourceGraphWrapper,
 	}
 	return nil, 0, queryErr
 }
+
+// getVnetLocations returns the sorted locations of the virtual networks of the subscription.
+func getVnetLocations(resourceGraphAPIClient azureResourceGraphWrapper, subscriptionID string) ([]string, error) {
+	query := vnetLocationsQuery
+	data, _, err := invokeResourceGraphQuery(resourceGraphAPIClient, &query, []string{subscriptionID})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var locations []string
+	for _, row := range data.([]interface{}) {
+		var vnetLocation struct {
+			Location string
+		}
+		if err = mapstructure.Decode(row, &vnetLocation); err != nil {
+			return nil, err
+		}
+		if len(vnetLocation.Location) != 0 {
+			locations = append(locations, vnetLocation.Location)
+		}
+	}
+	sort.Strings(locations)
+	return locations, nil
+}
@@ -31,6 +31,9 @@ type virtualMachineTable struct {
 	Tags              map[string]*string
 	Status            *string
 	VnetID            *string
+	Location          *string
+	Zones             []*string
+	TimeCreated       *string
 }
 type networkInterface struct {
 	ID         *string
@@ -40,6 +43,11 @@ type networkInterface struct {
 	PublicIps  []*string
 	Tags       map[string]*string
 	VnetID     *string
+	Primary    *bool
+	SubnetID   *string
+	NsgID      *string
+	// ApplicationSecurityGroups are the applicationSecurityGroups references of each ip configuration.
+	ApplicationSecurityGroups []interface{}
 }
 
 type vmTableQueryParameters struct {
@@ -49,6 +57,9 @@ type vmTableQueryParameters struct {
 	VnetIDs         *string
 	VMNames         *string
 	VMIDs           *string
+	VMClause        *string
+	VMExcludeClause *string
+	VnetClause      *string
 }
 
 const (
@@ -74,8 +85,14 @@ const (
 		"{{ if .VMIDs}} " +
 		"| where id in ({{ .VMIDs }})" +
 		"{{ end }}" +
+		"{{ if .VMClause }} " +
+		"| where {{ .VMClause }}" +
+		"{{ end }}" +
+		"{{ if .VMExcludeClause }} " +
+		"| where not({{ .VMExcludeClause }})" +
+		"{{ end }}" +
 		"| mvexpand nic = properties.networkProfile.networkInterfaces" +
-		"| extend nicId = tolower(tostring(nic.id))" +
+		"| extend nicId = tolower(tostring(nic.id)), nicPrimary = tobool(nic.properties.primary)" +
 		"| join kind = innerunique (" +
 		"	Resources" +
 		"	| where type =~ 'microsoft.network/networkinterfaces'" +
@@ -86,22 +103,38 @@ const (
 		"	{{ if .VnetIDs }} " +
 		"	| where vnetId in ({{ .VnetIDs }}) " +
 		"	{{ end }}" +
+		"	{{ if .VnetClause }} " +
+		"	| join kind = inner (" +
+		"		Resources" +
+		"		| where type =~ 'microsoft.network/virtualnetworks'" +
+		"		| where {{ .VnetClause }}" +
+		"		| project vnetId = tolower(id)" +
+		"	) on vnetId" +
+		"	{{ end }}" +
 		"	| extend publicIpId = tolower(tostring(ipconfig.properties.publicIPAddress.id))" +
 		"	| extend nicPrivateIp = ipconfig.properties.privateIPAddress" +
+		"	| extend subnetId = tolower(tostring(ipconfig.properties.subnet.id))" +
+		"	| extend nsgId = tolower(tostring(properties.networkSecurityGroup.id))" +
+		"	| extend asgs = ipconfig.properties.applicationSecurityGroups" +
 		"	| join kind = leftouter (" +
 		"		Resources" +
 		"		| where type =~ 'microsoft.network/publicipaddresses'" +
 		"		| project publicIpId = tolower(id), nicPublicIp = properties.ipAddress" +
 		"	) on publicIpId" +
 		"	| summarize nicTags = any(tags), macAddress = any(macAddress), vnetId = any(vnetId), " +
-		"nicPublicIps = make_list(nicPublicIp), nicPrivateIps = make_list(nicPrivateIp) by id, name" +
-		"	| project nicId = tolower(id), nicName = name, nicPublicIps, nicPrivateIps, vnetId, macAddress, nicTags" +
+		"nicPublicIps = make_list(nicPublicIp), nicPrivateIps = make_list(nicPrivateIp), subnetId = any(subnetId), " +
+		"nsgId = any(nsgId), asgs = make_list(asgs) by id, name" +
+		"	| project nicId = tolower(id), nicName = name, nicPublicIps, nicPrivateIps, vnetId, macAddress, nicTags, " +
+		"subnetId, nsgId, asgs" +
 		") on nicId" +
 		"| extend networkInterfaceDetails = pack(\"id\", nicId, \"name\", nicName, \"macAddress\", macAddress, \"privateIps\"," +
-		"nicPrivateIps, \"publicIps\", nicPublicIps, \"tags\", nicTags, \"vnetId\", vnetId)" +
+		"nicPrivateIps, \"publicIps\", nicPublicIps, \"tags\", nicTags, \"vnetId\", vnetId, \"primary\", nicPrimary, " +
+		"\"subnetId\", subnetId, \"nsgId\", nsgId, \"applicationSecurityGroups\", asgs)" +
 		"| summarize vnetId = any(vnetId), properties = make_bag(properties), tags = make_bag(tags), " +
-		"networkInterfaces = make_list(networkInterfaceDetails) by id, name" +
-		"| project id, name, properties, status=properties.extended.instanceView.powerState.code, networkInterfaces, tags, vnetId"
+		"networkInterfaces = make_list(networkInterfaceDetails), location = any(locationLowerCase), zones = any(zones), " +
+		"timeCreated = any(tostring(properties.timeCreated)) by id, name" +
+		"| project id, name, properties, status=properties.extended.instanceView.powerState.code, networkInterfaces, tags, vnetId, " +
+		"location, zones, timeCreated"
 )
 
 func getVirtualMachineTable(resourceGraphAPIClient azureResourceGraphWrapper, query *string,
@@ -321,6 +354,54 @@ func getVMsByVnetAndOtherMatchesQuery(vnetIDs []string, vmNames []string, vmIDs
 	return queryString, nil
 }
 
+func getVMsByCompositeMatchesQuery(vnetIDs []string, vnetClause string, vmNames []string, vmIDs []string,
+	vmClause string, vmExcludeClause string, subscriptionIDs []string, tenantIDs []string, locations []string) (*string, error) {
+	commaSeparatedSubscriptionIDs := convertStrSliceToLowercaseCommaSeparatedStr(subscriptionIDs)
+	if len(commaSeparatedSubscriptionIDs) == 0 {
+		return nil, fmt.Errorf(subscriptionIDsNotFoundErrorMsg)
+	}
+
+	commaSeparatedTenantIDs := convertStrSliceToLowercaseCommaSeparatedStr(tenantIDs)
+	if len(commaSeparatedTenantIDs) == 0 {
+		return nil, fmt.Errorf(tenantIDsNotFoundErrorMsg)
+	}
+
+	commaSeparatedLocations := convertStrSliceToLowercaseCommaSeparatedStr(locations)
+	if len(commaSeparatedLocations) == 0 {
+		return nil, fmt.Errorf(locationsNotFoundErrorMsg)
+	}
+
+	queryParams := &vmTableQueryParameters{
+		SubscriptionIDs: &commaSeparatedSubscriptionIDs,
+		TenantIDs:       &commaSeparatedTenantIDs,
+		Locations:       &commaSeparatedLocations,
+	}
+	if commaSeparatedVnetIDs := convertStrSliceToLowercaseCommaSeparatedStr(vnetIDs); len(commaSeparatedVnetIDs) > 0 {
+		queryParams.VnetIDs = &commaSeparatedVnetIDs
+	}
+	if commaSeparatedVMNames := convertStrSliceToLowercaseCommaSeparatedStr(vmNames); len(commaSeparatedVMNames) > 0 {
+		queryParams.VMNames = &commaSeparatedVMNames
+	}
+	if commaSeparatedVMIDs := convertStrSliceToLowercaseCommaSeparatedStr(vmIDs); len(commaSeparatedVMIDs) > 0 {
+		queryParams.VMIDs = &commaSeparatedVMIDs
+	}
+	if len(vnetClause) > 0 {
+		queryParams.VnetClause = &vnetClause
+	}
+	if len(vmClause) > 0 {
+		queryParams.VMClause = &vmClause
+	}
+	if len(vmExcludeClause) > 0 {
+		queryParams.VMExcludeClause = &vmExcludeClause
+	}
+
+	queryString, err := buildVmsTableQueryWithParams("getVMsByCompositeMatchesQuery", queryParams)
+	if err != nil {
+		return nil, err
+	}
+	return queryString, nil
+}
+
 func buildVmsTableQueryWithParams(name string, queryParams *vmTableQueryParameters) (*string, error) {
 	var vmTableData bytes.Buffer
 	queryTemplate, err := template.New(name).Parse(vmsTableQueryTemplate)
@@ -23,10 +23,11 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
-	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/types"
 
+	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
 	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
@@ -58,18 +59,70 @@ func (computeCfg *computeServiceConfig) getNetworkInterfacesOfVnet(vnetIDSet map
 	return nwIntfs, err
 }
 
+// getAppliedToNsgName returns the name of the NSG holding security rules of AT sgs of the vnet. It is the NSG designated
+// by the account, or the per vnet NSG created by nephe-controller otherwise.
+func (computeCfg *computeServiceConfig) getAppliedToNsgName(vnetID string) string {
+	if computeCfg.credentials != nil && len(computeCfg.credentials.securityGroupName) != 0 {
+		return strings.ToLower(computeCfg.credentials.securityGroupName)
+	}
+	appliedToSG := securitygroup.CloudResourceID{
+		Name: appliedToSecurityGroupNamePerVnet,
+		Vpc:  vnetID,
+	}
+	tokens := strings.Split(vnetID, "/")
+	suffix := tokens[len(tokens)-1]
+	return appliedToSG.GetCloudName(false) + "-" + suffix
+}
+
+// getAppliedToNsgID returns the lowercase ID of the NSG holding security rules of AT sgs of the vnet, in the resource
+// group of the vnet.
+func (computeCfg *computeServiceConfig) getAppliedToNsgID(vnetID string) string {
+	vnetIDLowerCase := strings.ToLower(vnetID)
+	idx := strings.LastIndex(vnetIDLowerCase, "/virtualnetworks/")
+	if idx < 0 {
+		return ""
+	}
+	return vnetIDLowerCase[:idx] + "/networksecuritygroups/" + strings.ToLower(computeCfg.getAppliedToNsgName(vnetID))
+}
+
+// getVirtualMachinesPreserveSecurityGroups returns whether security groups not created by nephe-controller are preserved
+// on each cached virtual machine, keyed by lowercase virtual machine ID.
+func (computeCfg *computeServiceConfig) getVirtualMachinesPreserveSecurityGroups() map[string]bool {
+	virtualMachinesPreserveSecurityGroups := make(map[string]bool)
+	for _, virtualMachine := range computeCfg.getCachedVirtualMachines() {
+		if virtualMachine.ID == nil {
+			continue
+		}
+		virtualMachinesPreserveSecurityGroups[strings.ToLower(*virtualMachine.ID)] = utils.IsSecurityGroupsPreserved(
+			virtualMachine.Tags[v1alpha1.PreserveSecurityGroupsTagKey], computeCfg.isAccountSecurityGroupsPreserved())
+	}
+	return virtualMachinesPreserveSecurityGroups
+}
+
+// isAccountSecurityGroupsPreserved returns true if security groups not created by nephe-controller are preserved on
+// network interfaces of the account.
+func (computeCfg *computeServiceConfig) isAccountSecurityGroupsPreserved() bool {
+	return computeCfg.credentials != nil && computeCfg.credentials.preserveSecurityGroups
+}
+
+// isSecurityGroupsPreserved returns true if security groups not created by nephe-controller are preserved on the network
+// interface, by the tag of its virtual machine, or by the account config otherwise.
+func (computeCfg *computeServiceConfig) isSecurityGroupsPreserved(networkInterface *networkInterfaceTable,
+	virtualMachinesPreserveSecurityGroups map[string]bool) bool {
+	if networkInterface.VirtualMachineID != nil {
+		if preserved, found := virtualMachinesPreserveSecurityGroups[strings.ToLower(*networkInterface.VirtualMachineID)]; found {
+			return preserved
+		}
+	}
+	return computeCfg.isAccountSecurityGroupsPreserved()
+}
+
 func (computeCfg *computeServiceConfig) processAppliedToMembership(addrGroupIdentifier *securitygroup.CloudResourceID,
 	networkInterfaces []*networkInterfaceTable, rgName string, memberVirtualMachines map[string]struct{},
 	memberNetworkInterfaces map[string]struct{}, isPeer bool) error {
 	// appliedTo sg has asg as well as nsg created corresponding to it. Hence update membership for both asg and nsg.
 	addrGroupOriginalNameToBeUsedAsTag := addrGroupIdentifier.GetCloudName(false)
-	appliedToSG := securitygroup.CloudResourceID{
-		Name: appliedToSecurityGroupNamePerVnet,
-		Vpc:  addrGroupIdentifier.Vpc,
-	}
-	tokens := strings.Split(addrGroupIdentifier.Vpc, "/")
-	suffix := tokens[len(tokens)-1]
-	perVnetNsgNameLowercase := appliedToSG.GetCloudName(false) + "-" + suffix
+	perVnetNsgNameLowercase := computeCfg.getAppliedToNsgName(addrGroupIdentifier.Vpc)
 	cloudSgNameLowercase := addrGroupIdentifier.GetCloudName(isPeer)
 
 	// get NSG and ASG details corresponding to applied to group
@@ -85,6 +138,8 @@ func (computeCfg *computeServiceConfig) processAppliedToMembership(addrGroupIden
 	// find network interfaces which are using or need to use the provided NSG
 	nwIntfIDSetNsgToAttach := make(map[string]struct{})
 	nwIntfIDSetNsgToDettach := make(map[string]struct{})
+	nwIntfIDSetNsgPreserved := make(map[string]struct{})
+	virtualMachinesPreserveSecurityGroups := computeCfg.getVirtualMachinesPreserveSecurityGroups()
 	for _, networkInterface := range networkInterfaces {
 		nwIntfIDLowerCase := strings.ToLower(*networkInterface.ID)
 		// 	for network interfaces not attached to any virtual machines, skip processing
@@ -93,42 +148,61 @@ func (computeCfg *computeServiceConfig) processAppliedToMembership(addrGroupIden
 			continue
 		}
 
-		isNsgAttached := false
+		// network security group of a network interface whose security groups are preserved is kept, the network interface
+		// is attached to AT sg through its tag and asg only.
+		isPreserved := computeCfg.isSecurityGroupsPreserved(networkInterface, virtualMachinesPreserveSecurityGroups)
+		if isPreserved {
+			nwIntfIDSetNsgPreserved[nwIntfIDLowerCase] = struct{}{}
+		}
+		var nsgNameLowercase string
 		if networkInterface.NetworkSecurityGroupID != nil && len(*networkInterface.NetworkSecurityGroupID) > 0 {
 			nsgID := strings.ToLower(*networkInterface.NetworkSecurityGroupID)
-			_, _, nsgNameLowercase, err := extractFieldsFromAzureResourceID(nsgID)
+			_, _, nsgNameLowercase, err = extractFieldsFromAzureResourceID(nsgID)
 			if err != nil {
 				azurePluginLogger().Error(err, "nsg ID format not valid", "nsgID", nsgID)
 				return err
 			}
-			if len(networkInterface.Tags) > 0 {
-				tags := networkInterface.Tags[0]
-				_, found := tags[cloudSgNameLowercase]
-				if strings.Compare(nsgNameLowercase, perVnetNsgNameLowercase) == 0 && found {
-					isNsgAttached = true
-				}
-			}
 		}
+		isTagged := false
+		if len(networkInterface.Tags) > 0 {
+			_, isTagged = networkInterface.Tags[0][cloudSgNameLowercase]
+		}
+		isNsgAttached := strings.Compare(nsgNameLowercase, perVnetNsgNameLowercase) == 0 && isTagged
 		_, isNicAttachedToMemberVM := memberVirtualMachines[strings.ToLower(*vmID)]
 		_, isNicMemberNetworkInterface := memberNetworkInterfaces[strings.ToLower(*networkInterface.ID)]
+		isMember := isNicAttachedToMemberVM || isNicMemberNetworkInterface
+
+		// security rules of AT sg are not enforced on network interface whose preserved NSG is not the AT NSG, it is not
+		// attached to AT sg.
+		if isPreserved && len(nsgNameLowercase) > 0 && strings.Compare(nsgNameLowercase, perVnetNsgNameLowercase) != 0 &&
+			!isNepheControllerCreatedNsgName(nsgNameLowercase) {
+			if isMember {
+				azurePluginLogger().Info("security rules not enforced on network interface with preserved nsg",
+					"nwIntfID", nwIntfIDLowerCase, "nsg", nsgNameLowercase, "appliedToNsg", perVnetNsgNameLowercase)
+			}
+			if isTagged {
+				nwIntfIDSetNsgToDettach[nwIntfIDLowerCase] = struct{}{}
+			}
+			continue
+		}
 		if isNsgAttached {
-			if !isNicAttachedToMemberVM && !isNicMemberNetworkInterface {
+			if !isMember {
 				nwIntfIDSetNsgToDettach[nwIntfIDLowerCase] = struct{}{}
 			}
 		} else {
-			if isNicAttachedToMemberVM || isNicMemberNetworkInterface {
+			if isMember {
 				nwIntfIDSetNsgToAttach[nwIntfIDLowerCase] = struct{}{}
 			}
 		}
 	}
 
 	return computeCfg.processNsgAttachDetachConcurrently(nsgObj, asgObj, nwIntfIDSetNsgToAttach,
-		nwIntfIDSetNsgToDettach, addrGroupOriginalNameToBeUsedAsTag)
+		nwIntfIDSetNsgToDettach, nwIntfIDSetNsgPreserved, addrGroupOriginalNameToBeUsedAsTag)
 }
 
 func (computeCfg *computeServiceConfig) processNsgAttachDetachConcurrently(nsgObj network.SecurityGroup,
 	asgObj network.ApplicationSecurityGroup, nwIntfIDSetNsgToAttach map[string]struct{},
-	nwIntfIDSetNsgToDetach map[string]struct{}, nwIntfTagKeyToUpdate string) error {
+	nwIntfIDSetNsgToDetach map[string]struct{}, nwIntfIDSetNsgPreserved map[string]struct{}, nwIntfTagKeyToUpdate string) error {
 	allNwIntfIDs := mergeSet(nwIntfIDSetNsgToAttach, nwIntfIDSetNsgToDetach)
 
 	nwIntfAPIClient := computeCfg.nwIntfAPIClient
@@ -137,35 +211,21 @@ func (computeCfg *computeServiceConfig) processNsgAttachDetachConcurrently(nsgOb
 		return nil
 	}
 
-	ch := make(chan error)
-	var err error
-	var wg sync.WaitGroup
-
-	wg.Add(len(allNwIntfIDs))
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
-
+	var tasks []func() error
 	for _, nwIntfObj := range nwIntfIDToObjMap {
 		isAttach := false
 		nwIntfIDLowercase := strings.ToLower(*nwIntfObj.ID)
 		if _, found := nwIntfIDSetNsgToAttach[nwIntfIDLowercase]; found {
 			isAttach = true
 		}
+		_, isPreserved := nwIntfIDSetNsgPreserved[nwIntfIDLowercase]
 
-		go func(nwIntfObj network.Interface, nsgObj network.SecurityGroup, isAttach bool, ch chan error) {
-			defer wg.Done()
-			ch <- updateNetworkInterfaceNsg(nwIntfAPIClient, nwIntfObj, nsgObj, asgObj, isAttach, nwIntfTagKeyToUpdate)
-		}(nwIntfObj, nsgObj, isAttach, ch)
+		nwIntfObj := nwIntfObj
+		tasks = append(tasks, func() error {
+			return updateNetworkInterfaceNsg(nwIntfAPIClient, nwIntfObj, nsgObj, asgObj, isAttach, isPreserved, nwIntfTagKeyToUpdate)
+		})
 	}
-	for e := range ch {
-		if e != nil {
-			err = multierr.Append(err, e)
-		}
-	}
-
-	return err
+	return internal.RunConcurrently(tasks)
 }
 
 func (computeCfg *computeServiceConfig) processAddressGroupMembership(addressGroupIdentifier *securitygroup.CloudResourceID,
@@ -231,16 +291,7 @@ func (computeCfg *computeServiceConfig) processAsgAttachDetachConcurrently(asgOb
 		return nil
 	}
 
-	ch := make(chan error)
-	var err error
-	var wg sync.WaitGroup
-
-	wg.Add(len(allNwIntfIDs))
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
-
+	var tasks []func() error
 	for _, nwIntfObj := range nwIntfIDToObjMap {
 		isAttach := false
 		nwIntfIDLowercase := strings.ToLower(*nwIntfObj.ID)
@@ -248,18 +299,12 @@ func (computeCfg *computeServiceConfig) processAsgAttachDetachConcurrently(asgOb
 			isAttach = true
 		}
 
-		go func(nwIntfObj network.Interface, asgObj network.ApplicationSecurityGroup, isAttach bool, ch chan error) {
-			defer wg.Done()
-			ch <- updateNetworkInterfaceAsg(nwIntfAPIClient, nwIntfObj, asgObj, isAttach)
-		}(nwIntfObj, asgObj, isAttach, ch)
-	}
-	for e := range ch {
-		if e != nil {
-			err = multierr.Append(err, e)
-		}
+		nwIntfObj := nwIntfObj
+		tasks = append(tasks, func() error {
+			return updateNetworkInterfaceAsg(nwIntfAPIClient, nwIntfObj, asgObj, isAttach)
+		})
 	}
-
-	return err
+	return internal.RunConcurrently(tasks)
 }
 
 func (computeCfg *computeServiceConfig) buildEffectiveNSGSecurityRulesToApply(appliedToGroupID *securitygroup.CloudResourceID,
@@ -273,19 +318,20 @@ func (computeCfg *computeServiceConfig) buildEffectiveNSGSecurityRulesToApply(ap
 
 	var currentNsgIngressRules []network.SecurityRule
 	var currentNsgEgressRules []network.SecurityRule
+	var otherNsgRules []network.SecurityRule
+	isDesignatedNsg := !isNepheControllerCreatedNsgName(perVnetAppliedToNsgName)
 	currentNsgSecurityRules := nsgObj.SecurityRules
 	appliedToGroupNepheControllerName := appliedToGroupID.GetCloudName(false)
 	azurePluginLogger().Info("building security rules", "applied to security group", appliedToGroupNepheControllerName)
 	for _, rule := range *currentNsgSecurityRules {
-		// skip any rules not created by nephe
-		if rule.Description == nil {
+		// skip any rules not created by nephe, rules of a network security group designated by the account are kept
+		if !isNepheControllerCreatedSecurityRule(rule) {
+			if isDesignatedNsg {
+				otherNsgRules = append(otherNsgRules, rule)
+			}
 			continue
 		}
 		ruleAddrGroupName := *rule.Description
-		_, _, isNepheControllerCreatedRule := securitygroup.IsNepheControllerCreatedSG(ruleAddrGroupName)
-		if !isNepheControllerCreatedRule {
-			continue
-		}
 		// skip any rules created by current processing appliedToGroup (as we have new rules for this group)
 		if strings.Compare(ruleAddrGroupName, appliedToGroupNepheControllerName) == 0 {
 			continue
@@ -313,12 +359,25 @@ func (computeCfg *computeServiceConfig) buildEffectiveNSGSecurityRulesToApply(ap
 	if err != nil {
 		return []network.SecurityRule{}, err
 	}
-	allIngressRules := updateSecurityRuleNameAndPriority(currentNsgIngressRules, newIngressSecurityRules)
-	allEgressRules := updateSecurityRuleNameAndPriority(currentNsgEgressRules, newEgressSecurityRules)
+	if isDesignatedNsg {
+		newIngressSecurityRules = removeVnetToVnetDenyRule(newIngressSecurityRules)
+		newEgressSecurityRules = removeVnetToVnetDenyRule(newEgressSecurityRules)
+	}
+	allIngressRules, err := updateSecurityRuleNameAndPriorityAroundRules(currentNsgIngressRules, newIngressSecurityRules,
+		getSecurityRulesOfDirection(otherNsgRules, network.SecurityRuleDirectionInbound))
+	if err != nil {
+		return []network.SecurityRule{}, err
+	}
+	allEgressRules, err := updateSecurityRuleNameAndPriorityAroundRules(currentNsgEgressRules, newEgressSecurityRules,
+		getSecurityRulesOfDirection(otherNsgRules, network.SecurityRuleDirectionOutbound))
+	if err != nil {
+		return []network.SecurityRule{}, err
+	}
 
 	var rules []network.SecurityRule
 	rules = append(rules, allIngressRules...)
 	rules = append(rules, allEgressRules...)
+	rules = append(rules, otherNsgRules...)
 
 	return rules, nil
 }
@@ -334,19 +393,20 @@ func (computeCfg *computeServiceConfig) buildEffectivePeerNSGSecurityRulesToAppl
 
 	var currentNsgIngressRules []network.SecurityRule
 	var currentNsgEgressRules []network.SecurityRule
+	var otherNsgRules []network.SecurityRule
+	isDesignatedNsg := !isNepheControllerCreatedNsgName(perVnetAppliedToNsgName)
 	currentNsgSecurityRules := nsgObj.SecurityRules
 	appliedToGroupNepheControllerName := appliedToGroupID.GetCloudName(false)
 	azurePluginLogger().Info("building peering security rules", "applied to security group", appliedToGroupNepheControllerName)
 	for _, rule := range *currentNsgSecurityRules {
-		// skip any rules not created by nephe
-		if rule.Description == nil {
+		// skip any rules not created by nephe, rules of a network security group designated by the account are kept
+		if !isNepheControllerCreatedSecurityRule(rule) {
+			if isDesignatedNsg {
+				otherNsgRules = append(otherNsgRules, rule)
+			}
 			continue
 		}
 		ruleAddrGroupName := *rule.Description
-		_, _, isNepheControllerCreatedRule := securitygroup.IsNepheControllerCreatedSG(ruleAddrGroupName)
-		if !isNepheControllerCreatedRule {
-			continue
-		}
 		// skip any rules created by current processing appliedToGroup (as we have new rules for this group)
 		if strings.Compare(ruleAddrGroupName, appliedToGroupNepheControllerName) == 0 {
 			continue
@@ -373,12 +433,25 @@ func (computeCfg *computeServiceConfig) buildEffectivePeerNSGSecurityRulesToAppl
 	if err != nil {
 		return []network.SecurityRule{}, err
 	}
-	allIngressRules := updateSecurityRuleNameAndPriority(currentNsgIngressRules, newIngressSecurityRules)
-	allEgressRules := updateSecurityRuleNameAndPriority(currentNsgEgressRules, newEgressSecurityRules)
+	if isDesignatedNsg {
+		newIngressSecurityRules = removeVnetToVnetDenyRule(newIngressSecurityRules)
+		newEgressSecurityRules = removeVnetToVnetDenyRule(newEgressSecurityRules)
+	}
+	allIngressRules, err := updateSecurityRuleNameAndPriorityAroundRules(currentNsgIngressRules, newIngressSecurityRules,
+		getSecurityRulesOfDirection(otherNsgRules, network.SecurityRuleDirectionInbound))
+	if err != nil {
+		return []network.SecurityRule{}, err
+	}
+	allEgressRules, err := updateSecurityRuleNameAndPriorityAroundRules(currentNsgEgressRules, newEgressSecurityRules,
+		getSecurityRulesOfDirection(otherNsgRules, network.SecurityRuleDirectionOutbound))
+	if err != nil {
+		return []network.SecurityRule{}, err
+	}
 
 	var rules []network.SecurityRule
 	rules = append(rules, allIngressRules...)
 	rules = append(rules, allEgressRules...)
+	rules = append(rules, otherNsgRules...)
 
 	return rules, nil
 }
@@ -418,13 +491,7 @@ func (computeCfg *computeServiceConfig) updateSecurityGroupMembers(addressGroupI
 
 func (computeCfg *computeServiceConfig) removeReferencesToSecurityGroup(id *securitygroup.CloudResourceID, rgName string,
 	location string, membershiponly bool) error {
-	appliedToSG := securitygroup.CloudResourceID{
-		Name: appliedToSecurityGroupNamePerVnet,
-		Vpc:  id.Vpc,
-	}
-	tokens := strings.Split(id.Vpc, "/")
-	suffix := tokens[len(tokens)-1]
-	perVnetNsgNepheControllerName := appliedToSG.GetCloudName(false) + "-" + suffix
+	perVnetNsgNepheControllerName := computeCfg.getAppliedToNsgName(id.Vpc)
 
 	nsgObj, err := computeCfg.nsgAPIClient.get(context.Background(), rgName, perVnetNsgNepheControllerName, "")
 	if err != nil {
@@ -557,44 +624,41 @@ func (computeCfg *computeServiceConfig) processAndBuildATSgView(networkInterface
 		if networkInterface.NetworkSecurityGroupID == nil {
 			continue
 		}
-		nsgIDLowerCase := strings.ToLower(*networkInterface.NetworkSecurityGroupID)
-		// proceed only if network-interface attached to nephe per-vnet NSG
-		_, _, nsgName, err := extractFieldsFromAzureResourceID(nsgIDLowerCase)
-		if err != nil {
+		// proceed only if network-interface attached to nephe per-vnet NSG, or NSG designated by the account, which hold
+		// security rules of AT sgs.
+		vnetIDLowerCase := strings.ToLower(*networkInterface.VnetID)
+		nsgIDLowerCase := computeCfg.getAppliedToNsgID(vnetIDLowerCase)
+		if strings.Compare(strings.ToLower(*networkInterface.NetworkSecurityGroupID), nsgIDLowerCase) != 0 {
 			continue
 		}
-		sgName, _, isAT := securitygroup.IsNepheControllerCreatedSG(nsgName)
-		if !isAT {
+		nsgIDToVnetIDMap[nsgIDLowerCase] = vnetIDLowerCase
+		if len(networkInterface.Tags) == 0 {
 			continue
 		}
-		vnetIDLowerCase := strings.ToLower(*networkInterface.VnetID)
-		nsgIDToVnetIDMap[nsgIDLowerCase] = vnetIDLowerCase
-		if strings.Compare(sgName, strings.ToLower(appliedToSecurityGroupNamePerVnet)) == 0 {
-			// from tags find nephe AT SG(s) and build membership map
-			newNepheControllerAppliedToSGNameSet := make(map[string]struct{})
-			for key := range networkInterface.Tags[0] {
-				ATSgName, _, isATSG := securitygroup.IsNepheControllerCreatedSG(key)
-				if !isATSG {
-					continue
-				}
-				cloudResource := securitygroup.CloudResource{
-					Type: securitygroup.CloudResourceTypeNIC,
-					Name: securitygroup.CloudResourceID{
-						Name: utils.GenerateShortResourceIdentifier(*networkInterface.ID, common.NetworkInterfaceCRDKind),
-						Vpc:  vnetIDLowerCase,
-					},
-				}
-				cloudResources := nepheControllerATSgNameToMemberCloudResourcesMap[ATSgName]
-				cloudResources = append(cloudResources, cloudResource)
-				nepheControllerATSgNameToMemberCloudResourcesMap[ATSgName] = cloudResources
-
-				newNepheControllerAppliedToSGNameSet[ATSgName] = struct{}{}
+		// from tags find nephe AT SG(s) and build membership map
+		newNepheControllerAppliedToSGNameSet := make(map[string]struct{})
+		for key := range networkInterface.Tags[0] {
+			ATSgName, _, isATSG := securitygroup.IsNepheControllerCreatedSG(key)
+			if !isATSG {
+				continue
 			}
-			if len(newNepheControllerAppliedToSGNameSet) > 0 {
-				existingNepheControllerAppliedToSGNameSet := perVnetNsgIDToNepheControllerAppliedToSGNameSet[nsgIDLowerCase]
-				completeSet := mergeSet(existingNepheControllerAppliedToSGNameSet, newNepheControllerAppliedToSGNameSet)
-				perVnetNsgIDToNepheControllerAppliedToSGNameSet[nsgIDLowerCase] = completeSet
+			cloudResource := securitygroup.CloudResource{
+				Type: securitygroup.CloudResourceTypeNIC,
+				Name: securitygroup.CloudResourceID{
+					Name: utils.GenerateShortResourceIdentifier(*networkInterface.ID, common.NetworkInterfaceCRDKind),
+					Vpc:  vnetIDLowerCase,
+				},
 			}
+			cloudResources := nepheControllerATSgNameToMemberCloudResourcesMap[ATSgName]
+			cloudResources = append(cloudResources, cloudResource)
+			nepheControllerATSgNameToMemberCloudResourcesMap[ATSgName] = cloudResources
+
+			newNepheControllerAppliedToSGNameSet[ATSgName] = struct{}{}
+		}
+		if len(newNepheControllerAppliedToSGNameSet) > 0 {
+			existingNepheControllerAppliedToSGNameSet := perVnetNsgIDToNepheControllerAppliedToSGNameSet[nsgIDLowerCase]
+			completeSet := mergeSet(existingNepheControllerAppliedToSGNameSet, newNepheControllerAppliedToSGNameSet)
+			perVnetNsgIDToNepheControllerAppliedToSGNameSet[nsgIDLowerCase] = completeSet
 		}
 	}
 
@@ -697,8 +761,8 @@ func (c *azureCloud) CreateSecurityGroup(addressGroupIdentifier *securitygroup.C
 
 	// find account managing the vnet
 	vnetID := addressGroupIdentifier.Vpc
-	accCfg := c.getVnetAccount(vnetID)
-	if accCfg == nil {
+	_, computeService := c.getVnetAccount(vnetID)
+	if computeService == nil {
 		azurePluginLogger().Info("azure account not found managing virtual network", vnetID, "vnetID")
 		return nil, fmt.Errorf("azure account not found managing virtual network [%v]", vnetID)
 	}
@@ -710,25 +774,16 @@ func (c *azureCloud) CreateSecurityGroup(addressGroupIdentifier *securitygroup.C
 	}
 
 	// create/get nsg/asg on/from cloud
-	serviceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-	if err != nil {
-		return nil, err
-	}
-	computeService := serviceCfg.(*computeServiceConfig)
 	location := computeService.credentials.region
 
 	if !membershipOnly {
-		// per vnet only one appliedTo SG will be created. Hence always use the same pre-assigned name.
-		appliedToAddrID := securitygroup.CloudResourceID{
-			Name: appliedToSecurityGroupNamePerVnet,
-			Vpc:  addressGroupIdentifier.Vpc,
-		}
-		tokens := strings.Split(addressGroupIdentifier.Vpc, "/")
-		suffix := tokens[len(tokens)-1]
-		cloudNsgName := appliedToAddrID.GetCloudName(false) + "-" + suffix
+		// per vnet only one appliedTo SG will be created. Hence always use the same pre-assigned name, or the name designated
+		// by the account.
+		cloudNsgName := computeService.getAppliedToNsgName(addressGroupIdentifier.Vpc)
 		cloudSecurityGroupID, err = createOrGetNetworkSecurityGroup(computeService.nsgAPIClient, location, rgName, cloudNsgName)
 		if err != nil {
-			return nil, fmt.Errorf("azure per vnet nsg %v create failed for AT sg %v, reason: %w", cloudNsgName, appliedToAddrID.Name, err)
+			return nil, fmt.Errorf("azure per vnet nsg %v create failed for AT sg %v, reason: %w", cloudNsgName,
+				addressGroupIdentifier.Name, err)
 		}
 
 		// create azure asg corresponding to AT sg.
@@ -756,15 +811,10 @@ func (c *azureCloud) UpdateSecurityGroupRules(addressGroupIdentifier *securitygr
 
 	// find account managing the vnet and get compute service config
 	vnetID := addressGroupIdentifier.Vpc
-	accCfg := c.getVnetAccount(vnetID)
-	if accCfg == nil {
+	_, computeService := c.getVnetAccount(vnetID)
+	if computeService == nil {
 		return fmt.Errorf("azure account not found managing virtual network [%v]", vnetID)
 	}
-	serviceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-	if err != nil {
-		return err
-	}
-	computeService := serviceCfg.(*computeServiceConfig)
 	location := computeService.credentials.region
 
 	// extract resource-group-name from vnet ID
@@ -778,14 +828,8 @@ func (c *azureCloud) UpdateSecurityGroupRules(addressGroupIdentifier *securitygr
 	vnetCachedIDs := computeService.getCachedVnetIDs()
 	vnetVMs, _ := computeService.getVirtualMachines()
 	// ruleIP := vnetVMs[len(vnetVMs)-1].NetworkInterfaces[0].PrivateIps[0]
-	// AT sg name per vnet is fixed and predefined. Get azure nsg name for it.
-	appliedToSgID := securitygroup.CloudResourceID{
-		Name: appliedToSecurityGroupNamePerVnet,
-		Vpc:  vnetID,
-	}
-	tokens := strings.Split(addressGroupIdentifier.Vpc, "/")
-	suffix := tokens[len(tokens)-1]
-	appliedToGroupPerVnetNsgNepheControllerName := appliedToSgID.GetCloudName(false) + "-" + suffix
+	// AT sg name per vnet is fixed and predefined, unless designated by the account. Get azure nsg name for it.
+	appliedToGroupPerVnetNsgNepheControllerName := computeService.getAppliedToNsgName(vnetID)
 	// convert to azure security rules and build effective rules to be applied to AT sg azure NSG
 	rules := []network.SecurityRule{}
 	flag := 0
@@ -832,15 +876,10 @@ func (c *azureCloud) UpdateSecurityGroupMembers(addressGroupIdentifier *security
 	defer mutex.Unlock()
 
 	vnetID := addressGroupIdentifier.Vpc
-	accCfg := c.getVnetAccount(vnetID)
-	if accCfg == nil {
+	_, computeService := c.getVnetAccount(vnetID)
+	if computeService == nil {
 		return fmt.Errorf("azure account not found managing virtual network [%v]", vnetID)
 	}
-	serviceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-	if err != nil {
-		return err
-	}
-	computeService := serviceCfg.(*computeServiceConfig)
 
 	return computeService.updateSecurityGroupMembers(addressGroupIdentifier, computeResourceIdentifier, membershipOnly)
 }
@@ -850,21 +889,15 @@ func (c *azureCloud) DeleteSecurityGroup(addressGroupIdentifier *securitygroup.C
 	defer mutex.Unlock()
 
 	vnetID := addressGroupIdentifier.Vpc
-	accCfg := c.getVnetAccount(vnetID)
-	if accCfg == nil {
+	_, computeService := c.getVnetAccount(vnetID)
+	if computeService == nil {
 		return fmt.Errorf("azure account not found managing virtual network [%v]", vnetID)
 	}
-	serviceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-	if err != nil {
-		return err
-	}
-	computeService := serviceCfg.(*computeServiceConfig)
 	location := computeService.credentials.region
 
 	_ = computeService.updateSecurityGroupMembers(addressGroupIdentifier, nil, membershipOnly)
 
-	var rgName string
-	_, rgName, _, err = extractFieldsFromAzureResourceID(addressGroupIdentifier.Vpc)
+	_, rgName, _, err := extractFieldsFromAzureResourceID(addressGroupIdentifier.Vpc)
 	if err != nil {
 		return err
 	}
@@ -884,17 +917,36 @@ func (c *azureCloud) DeleteSecurityGroup(addressGroupIdentifier *securitygroup.C
 	return err
 }
 
+// IsRuleActionSupported returns true for all actions, deny rules are realized as Azure Deny security rules.
+func (c *azureCloud) IsRuleActionSupported(_ securitygroup.RuleAction) bool {
+	return true
+}
+
 func (c *azureCloud) GetEnforcedSecurity() []securitygroup.SynchronizationContent {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	inventoryInitWaitDuration := 30 * time.Second
-
 	var accNamespacedNames []types.NamespacedName
 	accountConfigs := c.cloudCommon.GetCloudAccounts()
 	for _, accCfg := range accountConfigs {
 		accNamespacedNames = append(accNamespacedNames, *accCfg.GetNamespacedName())
 	}
+	return c.getEnforcedSecurity(accNamespacedNames)
+}
+
+func (c *azureCloud) GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, found := c.cloudCommon.GetCloudAccountByName(accNamespacedName); !found {
+		return nil
+	}
+	return c.getEnforcedSecurity([]types.NamespacedName{*accNamespacedName})
+}
+
+// getEnforcedSecurity returns the cloud view of enforced security of given accounts.
+func (c *azureCloud) getEnforcedSecurity(accNamespacedNames []types.NamespacedName) []securitygroup.SynchronizationContent {
+	inventoryInitWaitDuration := 30 * time.Second
 
 	var enforcedSecurityCloudView []securitygroup.SynchronizationContent
 	var wg sync.WaitGroup
@@ -920,18 +972,16 @@ func (c *azureCloud) GetEnforcedSecurity() []securitygroup.SynchronizationConten
 				return
 			}
 
-			serviceCfg, err := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-			if err != nil {
-				azurePluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
-				return
-			}
-			computeService := serviceCfg.(*computeServiceConfig)
-			err = computeService.waitForInventoryInit(inventoryInitWaitDuration)
-			if err != nil {
-				azurePluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
-				return
+			var cloudView []securitygroup.SynchronizationContent
+			for _, computeService := range getComputeServiceConfigs(accCfg) {
+				if err := computeService.waitForInventoryInit(inventoryInitWaitDuration); err != nil {
+					azurePluginLogger().Error(err, "enforced-security-cloud-view GET for account region skipped", "account",
+						accCfg.GetNamespacedName(), "region", computeService.credentials.region)
+					continue
+				}
+				cloudView = append(cloudView, computeService.getNepheControllerManagedSecurityGroupsCloudView()...)
 			}
-			sendCh <- computeService.getNepheControllerManagedSecurityGroupsCloudView()
+			sendCh <- cloudView
 		}(accNamespacedNameCopy, ch)
 	}
 
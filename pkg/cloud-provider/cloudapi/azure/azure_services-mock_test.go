@@ -108,6 +108,21 @@ func (mr *MockazureServiceClientCreateInterfaceMockRecorder) securityGroups(subs
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "securityGroups", reflect.TypeOf((*MockazureServiceClientCreateInterface)(nil).securityGroups), subscriptionID)
 }
 
+// storageQueue mocks base method.
+func (m *MockazureServiceClientCreateInterface) storageQueue(queueURL string) (azureStorageQueueWrapper, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "storageQueue", queueURL)
+	ret0, _ := ret[0].(azureStorageQueueWrapper)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// storageQueue indicates an expected call of storageQueue.
+func (mr *MockazureServiceClientCreateInterfaceMockRecorder) storageQueue(queueURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storageQueue", reflect.TypeOf((*MockazureServiceClientCreateInterface)(nil).storageQueue), queueURL)
+}
+
 // virtualNetworks mocks base method.
 func (m *MockazureServiceClientCreateInterface) virtualNetworks(subscriptionID string) (azureVirtualNetworksWrapper, error) {
 	m.ctrl.T.Helper()
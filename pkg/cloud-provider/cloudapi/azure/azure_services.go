@@ -15,17 +15,29 @@
 package azure
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"k8s.io/apimachinery/pkg/types"
 
+	"antrea.io/nephe/apis/crd/v1alpha1"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
 )
 
 const (
 	azureComputeServiceNameCompute = internal.CloudServiceName("COMPUTE")
+
+	azureFederatedTokenFileEnv = "AZURE_FEDERATED_TOKEN_FILE"
+	azureTenantIDEnv           = "AZURE_TENANT_ID"
+	azureClientIDEnv           = "AZURE_CLIENT_ID"
+	azureClientAssertionType   = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
 )
 
 // azureServiceClientCreateInterface provides interface to create aws service clients.
@@ -35,6 +47,7 @@ type azureServiceClientCreateInterface interface {
 	securityGroups(subscriptionID string) (azureNsgWrapper, error)
 	applicationSecurityGroups(subscriptionID string) (azureAsgWrapper, error)
 	virtualNetworks(subscriptionID string) (azureVirtualNetworksWrapper, error)
+	storageQueue(queueURL string) (azureStorageQueueWrapper, error)
 	// Add any azure service api client creation methods here
 }
 
@@ -42,6 +55,10 @@ type azureServiceClientCreateInterface interface {
 // Implements azureServiceClientCreateInterface interface.
 type azureServiceSdkConfigProvider struct {
 	authorizer autorest.Authorizer
+	// storageAuthorizer authorizes Storage api calls, it is set only if the account has an event queue.
+	storageAuthorizer autorest.Authorizer
+	// sender sends api requests of all clients at the rate of the account, default sender is used if nil.
+	sender autorest.Sender
 }
 
 // azureServicesHelper.
@@ -54,36 +71,181 @@ type azureServicesHelperImpl struct{}
 // newServiceSdkConfigProvider returns config to create azure services clients.
 func (h *azureServicesHelperImpl) newServiceSdkConfigProvider(accCreds *azureAccountConfig) (
 	azureServiceClientCreateInterface, error) {
-	var authorizer autorest.Authorizer
-	var err error
-	clientConfig := auth.NewClientCredentialsConfig(accCreds.ClientID, accCreds.ClientKey, accCreds.TenantID)
-	authorizer, err = clientConfig.Authorizer()
+	token, err := newServicePrincipalToken(accCreds, azure.PublicCloud.ResourceManagerEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize Azure authorizer from credentials: %v", err)
 	}
+	// bearer authorizer refreshes the token before it expires, service clients are not re-created on token refresh.
 	configProvider := &azureServiceSdkConfigProvider{
-		authorizer: authorizer,
+		authorizer: autorest.NewBearerAuthorizer(token),
+	}
+	if accCreds.apiLimiter != nil {
+		configProvider.sender = &azureLimitedSender{sender: autorest.CreateSender(), apiLimiter: accCreds.apiLimiter}
+	}
+	if len(accCreds.eventQueueURL) != 0 {
+		storageToken, err := newServicePrincipalToken(accCreds, azure.PublicCloud.ResourceIdentifiers.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize Azure storage authorizer from credentials: %v", err)
+		}
+		configProvider.storageAuthorizer = autorest.NewBearerAuthorizer(storageToken)
 	}
 	return configProvider, nil
 }
 
+// newServicePrincipalToken returns a token of the account credentials for the given resource.
+func newServicePrincipalToken(accCreds *azureAccountConfig, resource string) (*adal.ServicePrincipalToken, error) {
+	switch accCreds.credentialMode {
+	case v1alpha1.AzureCredentialModeClientCertificate:
+		return newClientCertificateToken(accCreds, resource)
+	case v1alpha1.AzureCredentialModeManagedIdentity:
+		// system-assigned managed identity is used, if client ID of a user-assigned managed identity is not configured.
+		return adal.NewServicePrincipalTokenFromManagedIdentity(resource, &adal.ManagedIdentityOptions{ClientID: accCreds.ClientID})
+	case v1alpha1.AzureCredentialModeWorkloadIdentity:
+		return newWorkloadIdentityToken(accCreds, resource)
+	default:
+		clientCredentialsConfig := auth.NewClientCredentialsConfig(accCreds.ClientID, accCreds.ClientKey, accCreds.TenantID)
+		clientCredentialsConfig.Resource = resource
+		return clientCredentialsConfig.ServicePrincipalToken()
+	}
+}
+
+// newClientCertificateToken returns a token of the account service principal, authenticated with the client
+// certificate of the account secret.
+func newClientCertificateToken(accCreds *azureAccountConfig, resource string) (*adal.ServicePrincipalToken, error) {
+	pfxData, err := base64.StdEncoding.DecodeString(accCreds.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode client certificate: %v", err)
+	}
+	certificate, privateKey, err := adal.DecodePfxCertificateData(pfxData, accCreds.ClientCertificatePassword)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode client certificate: %v", err)
+	}
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, accCreds.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return adal.NewServicePrincipalTokenFromCertificate(*oauthConfig, accCreds.ClientID, certificate, privateKey, resource)
+}
+
+// newWorkloadIdentityToken returns a token of the account application, authenticated with the projected service
+// account token of nephe-controller. Tenant ID, client ID and token file default to the environment variables
+// injected by Azure workload identity.
+func newWorkloadIdentityToken(accCreds *azureAccountConfig, resource string) (*adal.ServicePrincipalToken, error) {
+	tenantID := getEnvIfEmpty(accCreds.TenantID, azureTenantIDEnv)
+	clientID := getEnvIfEmpty(accCreds.ClientID, azureClientIDEnv)
+	tokenFile := getEnvIfEmpty(accCreds.federatedTokenFile, azureFederatedTokenFileEnv)
+	if len(tenantID) == 0 {
+		return nil, fmt.Errorf("tenant ID not configured, and %v is not set", azureTenantIDEnv)
+	}
+	if len(clientID) == 0 {
+		return nil, fmt.Errorf("client ID not configured, and %v is not set", azureClientIDEnv)
+	}
+	if len(tokenFile) == 0 {
+		return nil, fmt.Errorf("federated token file not configured, and %v is not set", azureFederatedTokenFileEnv)
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return adal.NewServicePrincipalTokenWithSecret(*oauthConfig, clientID, resource, &azureFederatedTokenSecret{tokenFile: tokenFile})
+}
+
+// getEnvIfEmpty returns value, or the value of environment variable env if value is empty.
+func getEnvIfEmpty(value string, env string) string {
+	if len(value) != 0 {
+		return value
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+// azureFederatedTokenSecret implements adal.ServicePrincipalSecret for federated identity credentials. Projected
+// service account token is rotated by kubelet, it is read from token file on every token refresh.
+type azureFederatedTokenSecret struct {
+	tokenFile string
+}
+
+// SetAuthenticationValues populates the form submitted during token acquisition with the projected service account
+// token as client assertion.
+func (s *azureFederatedTokenSecret) SetAuthenticationValues(_ *adal.ServicePrincipalToken, v *url.Values) error {
+	assertion, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return fmt.Errorf("unable to read federated token file %v: %v", s.tokenFile, err)
+	}
+	v.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	v.Set("client_assertion_type", azureClientAssertionType)
+	return nil
+}
+
 func newAzureServiceConfigs(accountNamespacedName *types.NamespacedName, accCredentials interface{}, azureSpecificHelper interface{}) (
 	[]internal.CloudServiceInterface, error) {
 	azureServicesHelper := azureSpecificHelper.(azureServicesHelper)
-	azureAccountCredentials := accCredentials.(*azureAccountConfig)
+	// api calls of all services of the account share the rate limiter of the account.
+	azureAccountCredentials := *accCredentials.(*azureAccountConfig)
+	azureAccountCredentials.apiLimiter = newAzureAPILimiter(accountNamespacedName)
 
 	var serviceConfigs []internal.CloudServiceInterface
 
-	azureServiceClientCreator, err := azureServicesHelper.newServiceSdkConfigProvider(azureAccountCredentials)
+	azureServiceClientCreator, err := azureServicesHelper.newServiceSdkConfigProvider(&azureAccountCredentials)
 	if err != nil {
 		return nil, err
 	}
 
-	ec2Service, err := newComputeServiceConfig(accountNamespacedName.String(), azureServiceClientCreator, azureAccountCredentials)
-	if err != nil {
-		return nil, err
+	accountConfigs := []*azureAccountConfig{&azureAccountCredentials}
+	for _, subscriptionID := range azureAccountCredentials.subscriptionIDs {
+		subscriptionCredentials := azureAccountCredentials
+		subscriptionCredentials.SubscriptionID = subscriptionID
+		subscriptionCredentials.additionalSubscription = true
+		accountConfigs = append(accountConfigs, &subscriptionCredentials)
+	}
+
+	// one compute service is created per subscription and region, with api clients of its subscription.
+	for _, accountConfig := range accountConfigs {
+		regions, err := getAccountRegions(azureServiceClientCreator, accountConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, region := range regions {
+			regionalCredentials := *accountConfig
+			regionalCredentials.region = region
+			computeService, err := newComputeServiceConfig(accountNamespacedName.String(), azureServiceClientCreator,
+				&regionalCredentials)
+			if err != nil {
+				return nil, err
+			}
+			serviceConfigs = append(serviceConfigs, computeService)
+		}
 	}
-	serviceConfigs = append(serviceConfigs, ec2Service)
 
 	return serviceConfigs, nil
 }
+
+// hasAllRegions returns whether account credentials select all regions with a virtual network, in which case services
+// of the account are created again every inventory resync interval, for virtual networks created in new regions since.
+func hasAllRegions(credentials interface{}) bool {
+	for _, region := range credentials.(*azureAccountConfig).regions {
+		if region == v1alpha1.AllRegions {
+			return true
+		}
+	}
+	return false
+}
+
+// getAccountRegions returns the regions of the account. all is resolved to the locations of the virtual networks of
+// the account subscription.
+func getAccountRegions(azureServiceClientCreator azureServiceClientCreateInterface, accConfig *azureAccountConfig) ([]string, error) {
+	if !hasAllRegions(accConfig) {
+		return accConfig.regions, nil
+	}
+
+	resourceGraphAPIClient, err := azureServiceClientCreator.resourceGraph()
+	if err != nil {
+		return nil, err
+	}
+	regions, err := getVnetLocations(resourceGraphAPIClient, accConfig.SubscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get virtual network locations of subscription %v: %v", accConfig.SubscriptionID, err)
+	}
+	return regions, nil
+}
@@ -16,11 +16,24 @@ package azure
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2021-03-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2021-03-01/resourcegraph"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/mock/gomock"
+	"github.com/mitchellh/mapstructure"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -30,6 +43,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
 )
 
 var _ = Describe("Azure", func() {
@@ -182,8 +199,9 @@ var _ = Describe("Azure", func() {
 				Expect(err).Should(BeNil())
 
 				accCfg, _ := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
-				serviceConfig, _ := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-				filters := serviceConfig.(*computeServiceConfig).computeFilters[selector.Name]
+				serviceConfig, _ := accCfg.GetServiceConfigByName(getComputeServiceName(testRegion))
+				selectorNamespacedName := types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}
+				filters := serviceConfig.(*computeServiceConfig).computeFilters[selectorNamespacedName]
 				Expect(filters).To(Equal(expectedQueryStrs))
 			})
 		})
@@ -214,8 +232,8 @@ var _ = Describe("Azure", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-			filters := serviceConfig.(*computeServiceConfig).computeFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getComputeServiceName(testRegion))
+			filters := serviceConfig.(*computeServiceConfig).computeFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedQueryStrs))
 		})
 
@@ -240,10 +258,1036 @@ var _ = Describe("Azure", func() {
 			Expect(err).Should(BeNil())
 
 			accCfg, _ := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
-			serviceConfig, _ := accCfg.GetServiceConfigByName(azureComputeServiceNameCompute)
-			filters := serviceConfig.(*computeServiceConfig).computeFilters[selector.Name]
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getComputeServiceName(testRegion))
+			filters := serviceConfig.(*computeServiceConfig).computeFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
 			Expect(filters).To(Equal(expectedQueryStrs))
 		})
+
+		It("Should match expected filter - vnet and vm tag match", func() {
+			var expectedQueryStrs []*string
+			vnetTags := `tostring(tags["env"]) == "prod"`
+			vmTags := `tostring(tags["team"]) == "payments" and tostring(tags["tier"]) in ("db", "web") and ` +
+				`isnull(tags["temp"])`
+			expectedQueryStr, _ := getVMsByCompositeMatchesQuery([]string{testVnetID01}, vnetTags, nil, nil, vmTags, "",
+				subIDs, tenantIDs, locations)
+			expectedQueryStrs = append(expectedQueryStrs, expectedQueryStr)
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VpcMatch: &v1alpha1.EntityMatch{
+						MatchID:   testVnetID01,
+						MatchTags: map[string]string{"env": "prod"},
+					},
+					VMMatch: []v1alpha1.EntityMatch{
+						{
+							MatchTags: map[string]string{"team": "payments"},
+							MatchExpressions: []v1alpha1.TagMatchExpression{
+								{Key: "tier", Operator: v1alpha1.TagMatchOperatorIn, Values: []string{"web", "db"}},
+								{Key: "temp", Operator: v1alpha1.TagMatchOperatorDoesNotExist},
+							},
+						},
+					},
+				},
+			}
+
+			err := fakeClient.Create(context.Background(), secret)
+			Expect(err).Should(BeNil())
+			err = c.AddProviderAccount(fakeClient, account)
+			Expect(err).Should(BeNil())
+			selector.Spec.VMSelector = vmSelector
+			err = c.AddAccountResourceSelector(testAccountNamespacedName, selector)
+			Expect(err).Should(BeNil())
+
+			accCfg, _ := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getComputeServiceName(testRegion))
+			filters := serviceConfig.(*computeServiceConfig).computeFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
+			Expect(filters).To(Equal(expectedQueryStrs))
+			Expect(*filters[0]).To(ContainSubstring("| where " + vmTags))
+			Expect(*filters[0]).To(ContainSubstring("microsoft.network/virtualnetworks'		| where " + vnetTags))
+		})
+
+		It("Should match expected filter - vm name pattern and exclude match", func() {
+			var expectedQueryStrs []*string
+			vmClause := `name matches regex "^web-.*$"`
+			excludeClause := `(name == "web-bastion") or (id == "vmid-01" and isnotnull(tags["temp"]))`
+			expectedQueryStr, _ := getVMsByCompositeMatchesQuery([]string{testVnetID01}, "", nil, nil, vmClause,
+				excludeClause, subIDs, tenantIDs, locations)
+			expectedQueryStrs = append(expectedQueryStrs, expectedQueryStr)
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VpcMatch: &v1alpha1.EntityMatch{MatchID: testVnetID01},
+					VMMatch:  []v1alpha1.EntityMatch{{MatchName: "web-*"}},
+					ExcludeMatch: []v1alpha1.EntityMatch{
+						{MatchName: "web-bastion"},
+						{
+							MatchID:          "vmID-01",
+							MatchExpressions: []v1alpha1.TagMatchExpression{{Key: "temp", Operator: v1alpha1.TagMatchOperatorExists}},
+						},
+					},
+				},
+			}
+
+			err := fakeClient.Create(context.Background(), secret)
+			Expect(err).Should(BeNil())
+			err = c.AddProviderAccount(fakeClient, account)
+			Expect(err).Should(BeNil())
+			selector.Spec.VMSelector = vmSelector
+			err = c.AddAccountResourceSelector(testAccountNamespacedName, selector)
+			Expect(err).Should(BeNil())
+
+			accCfg, _ := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
+			serviceConfig, _ := accCfg.GetServiceConfigByName(getComputeServiceName(testRegion))
+			filters := serviceConfig.(*computeServiceConfig).computeFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}]
+			Expect(filters).To(Equal(expectedQueryStrs))
+			Expect(*filters[0]).To(ContainSubstring("| where " + vmClause))
+			Expect(*filters[0]).To(ContainSubstring("| where not(" + excludeClause + ")"))
+		})
+	})
+
+	Context("Tag match", func() {
+		It("Should escape quotes in tag match", func() {
+			clause := buildTagMatchClause(&v1alpha1.EntityMatch{
+				MatchTags: map[string]string{`o"wner`: `a"b`},
+				MatchExpressions: []v1alpha1.TagMatchExpression{
+					{Key: "env", Operator: v1alpha1.TagMatchOperatorNotIn, Values: []string{"dev"}},
+					{Key: "team", Operator: v1alpha1.TagMatchOperatorExists},
+				},
+			})
+			Expect(clause).To(Equal(`tostring(tags["o\"wner"]) == "a\"b" and tostring(tags["env"]) !in ("dev") and ` +
+				`isnotnull(tags["team"])`))
+		})
+	})
+
+	Context("Mixed IPv4 and IPv6 rule IPs", func() {
+		var (
+			appliedToGroupID = &securitygroup.CloudResourceID{Name: "web", Vpc: testVnetID01}
+			atAsgMap         = map[string]network.ApplicationSecurityGroup{
+				"web": {ID: to.StringPtr("asgID"), Name: to.StringPtr(appliedToGroupID.GetCloudName(false))},
+			}
+		)
+
+		It("Should build separate ingress security rules for IPv4 and IPv6 prefixes", func() {
+			_, ipv4Net, _ := net.ParseCIDR("10.0.0.0/24")
+			_, ipv6Net, _ := net.ParseCIDR("2001:db8::/64")
+			rules := []*securitygroup.IngressRule{
+				{FromSrcIP: []*net.IPNet{ipv4Net, ipv6Net}},
+			}
+
+			securityRules, err := convertIngressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).Should(BeNil())
+			// IPv4 rule, IPv6 rule and vnet to vnet deny rule.
+			Expect(securityRules).To(HaveLen(3))
+			Expect(*securityRules[0].SourceAddressPrefixes).To(Equal([]string{"10.0.0.0/24"}))
+			Expect(*securityRules[1].SourceAddressPrefixes).To(Equal([]string{"2001:db8::/64"}))
+			Expect(*securityRules[0].Priority).NotTo(Equal(*securityRules[1].Priority))
+		})
+		It("Should build single egress security rule for any destination", func() {
+			rules := []*securitygroup.EgressRule{{}}
+
+			securityRules, err := convertEgressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).Should(BeNil())
+			Expect(securityRules).To(HaveLen(2))
+			Expect(*securityRules[0].DestinationAddressPrefix).To(Equal(emptyPort))
+			Expect(securityRules[0].DestinationAddressPrefixes).To(BeNil())
+		})
+		It("Should convert IPv4 and IPv6 prefixes to rule IPs", func() {
+			ips := convertFromAzurePrefixesToNepheControllerIPs(nil, &[]string{"10.0.0.5/32", "2001:db8::5/128"})
+			Expect(ips).To(HaveLen(2))
+			Expect(ips[0].String()).To(Equal("10.0.0.5/32"))
+			Expect(ips[1].String()).To(Equal("2001:db8::5/128"))
+		})
+	})
+
+	Context("Drop and Reject rule actions", func() {
+		var (
+			appliedToGroupID = &securitygroup.CloudResourceID{Name: "web", Vpc: testVnetID01}
+			atAsgMap         = map[string]network.ApplicationSecurityGroup{
+				"web": {ID: to.StringPtr("asgID"), Name: to.StringPtr(appliedToGroupID.GetCloudName(false))},
+			}
+		)
+
+		It("Should build deny security rules ahead of allow security rules", func() {
+			_, allowNet, _ := net.ParseCIDR("10.0.0.0/24")
+			_, dropNet, _ := net.ParseCIDR("10.0.1.0/24")
+			_, rejectNet, _ := net.ParseCIDR("10.0.2.0/24")
+			rules := []*securitygroup.IngressRule{
+				{FromSrcIP: []*net.IPNet{allowNet}},
+				{FromSrcIP: []*net.IPNet{dropNet}, Action: securitygroup.RuleActionDrop},
+				{FromSrcIP: []*net.IPNet{rejectNet}, Action: securitygroup.RuleActionReject},
+			}
+
+			securityRules, err := convertIngressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).Should(BeNil())
+			Expect(securityRules).To(HaveLen(4))
+			Expect(*securityRules[0].SourceAddressPrefixes).To(Equal([]string{"10.0.1.0/24"}))
+			Expect(securityRules[0].Access).To(Equal(network.SecurityRuleAccessDeny))
+			Expect(*securityRules[1].SourceAddressPrefixes).To(Equal([]string{"10.0.2.0/24"}))
+			Expect(securityRules[1].Access).To(Equal(network.SecurityRuleAccessDeny))
+			Expect(*securityRules[2].SourceAddressPrefixes).To(Equal([]string{"10.0.0.0/24"}))
+			Expect(securityRules[2].Access).To(Equal(network.SecurityRuleAccessAllow))
+			Expect(*securityRules[0].Priority).To(BeNumerically("<", *securityRules[2].Priority))
+		})
+		It("Should keep deny security rules ahead of allow security rules of other applied to groups", func() {
+			existingRules := []network.SecurityRule{
+				buildSecurityRule(to.Int32Ptr(ruleStartPriority), network.SecurityRuleProtocolAsterisk,
+					network.SecurityRuleDirectionInbound, to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
+					to.StringPtr(emptyPort), nil, nil, nil, to.StringPtr("nephe-at-db"), network.SecurityRuleAccessAllow),
+			}
+			newRules := []network.SecurityRule{
+				buildSecurityRule(to.Int32Ptr(ruleStartPriority), network.SecurityRuleProtocolAsterisk,
+					network.SecurityRuleDirectionInbound, to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
+					to.StringPtr(emptyPort), nil, nil, nil, to.StringPtr("nephe-at-web"), network.SecurityRuleAccessDeny),
+			}
+
+			rules, err := updateSecurityRuleNameAndPriority(existingRules, newRules)
+			Expect(err).Should(BeNil())
+			Expect(rules).To(HaveLen(2))
+			Expect(*rules[0].Description).To(Equal("nephe-at-web"))
+			Expect(*rules[0].Priority).To(Equal(int32(ruleStartPriority)))
+			Expect(*rules[1].Description).To(Equal("nephe-at-db"))
+			Expect(*rules[1].Priority).To(Equal(int32(ruleStartPriority + 1)))
+		})
+		It("Should convert deny security rule to Drop rule", func() {
+			securityRule := buildSecurityRule(to.Int32Ptr(ruleStartPriority), network.SecurityRuleProtocolTCP,
+				network.SecurityRuleDirectionOutbound, to.StringPtr(emptyPort), nil, nil, nil,
+				to.StringPtr("22"), to.StringPtr(emptyPort), nil, nil, to.StringPtr("nephe-at-web"), network.SecurityRuleAccessDeny)
+
+			egressRule, err := convertFromAzureSecurityRuleToNepheControllerEgressRule(securityRule, testVnetID01)
+			Expect(err).Should(BeNil())
+			Expect(egressRule.Action).To(Equal(securitygroup.RuleActionDrop))
+			Expect(*egressRule.ToPort).To(Equal(22))
+		})
+	})
+
+	Context("Port ranges and ICMP", func() {
+		var (
+			appliedToGroupID = &securitygroup.CloudResourceID{Name: "web", Vpc: testVnetID01}
+			atAsgMap         = map[string]network.ApplicationSecurityGroup{
+				"web": {ID: to.StringPtr("asgID"), Name: to.StringPtr(appliedToGroupID.GetCloudName(false))},
+			}
+		)
+
+		It("Should build security rule with port range", func() {
+			rules := []*securitygroup.IngressRule{
+				{Protocol: to.IntPtr(6), FromPort: to.IntPtr(30000), FromEndPort: to.IntPtr(32767)},
+			}
+
+			securityRules, err := convertIngressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).Should(BeNil())
+			Expect(*securityRules[0].DestinationPortRange).To(Equal("30000-32767"))
+
+			ingressRule, err := convertFromAzureSecurityRuleToNepheControllerIngressRule(securityRules[0], testVnetID01)
+			Expect(err).Should(BeNil())
+			Expect(*ingressRule.FromPort).To(Equal(30000))
+			Expect(*ingressRule.FromEndPort).To(Equal(32767))
+		})
+		It("Should fail security rule with ICMP type", func() {
+			rules := []*securitygroup.EgressRule{{Protocol: to.IntPtr(1), ICMPType: to.IntPtr(8)}}
+
+			_, err := convertEgressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("Rule priorities", func() {
+		var (
+			appliedToGroupID = &securitygroup.CloudResourceID{Name: "web", Vpc: testVnetID01}
+			atAsgMap         = map[string]network.ApplicationSecurityGroup{
+				"web": {ID: to.StringPtr("asgID"), Name: to.StringPtr(appliedToGroupID.GetCloudName(false))},
+			}
+		)
+
+		buildRule := func(description string, access network.SecurityRuleAccess,
+			priority *securitygroup.RulePriority) network.SecurityRule {
+			rule := buildSecurityRule(to.Int32Ptr(ruleStartPriority), network.SecurityRuleProtocolAsterisk,
+				network.SecurityRuleDirectionInbound, to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
+				to.StringPtr(emptyPort), nil, nil, nil, to.StringPtr(description), access)
+			rules := []network.SecurityRule{rule}
+			setSecurityRulesPriority(rules, priority)
+			return rules[0]
+		}
+
+		It("Should keep rule priority in security rule name", func() {
+			priority := &securitygroup.RulePriority{TierPriority: 250, PolicyPriority: 5.5, RuleIndex: 2}
+			name := buildSecurityRuleName(ruleStartPriority, network.SecurityRuleDirectionInbound, priority)
+			Expect(getSecurityRulePriority(&name)).To(Equal(priority))
+
+			name = buildSecurityRuleName(ruleStartPriority, network.SecurityRuleDirectionInbound, nil)
+			Expect(name).To(Equal("100-Inbound"))
+			Expect(getSecurityRulePriority(&name)).To(BeNil())
+		})
+		It("Should build security rules with rule priority", func() {
+			priority := &securitygroup.RulePriority{TierPriority: 250, PolicyPriority: 1, RuleIndex: 0}
+			rules := []*securitygroup.IngressRule{{Priority: priority}}
+
+			securityRules, err := convertIngressToAzureNsgSecurityRules(appliedToGroupID, rules, nil, atAsgMap)
+			Expect(err).Should(BeNil())
+			Expect(securityRules).To(HaveLen(2))
+			Expect(getSecurityRulePriority(securityRules[0].Name)).To(Equal(priority))
+
+			ingressRule, err := convertFromAzureSecurityRuleToNepheControllerIngressRule(securityRules[0], testVnetID01)
+			Expect(err).Should(BeNil())
+			Expect(ingressRule.Priority).To(Equal(priority))
+		})
+		It("Should order security rules by tier, policy priority and rule index", func() {
+			existingRules := []network.SecurityRule{
+				buildRule("nephe-at-db", network.SecurityRuleAccessAllow, nil),
+				buildRule("nephe-at-db", network.SecurityRuleAccessAllow,
+					&securitygroup.RulePriority{TierPriority: 250, PolicyPriority: 5, RuleIndex: 0}),
+			}
+			newRules := []network.SecurityRule{
+				buildRule("nephe-at-web", network.SecurityRuleAccessAllow,
+					&securitygroup.RulePriority{TierPriority: 250, PolicyPriority: 1, RuleIndex: 1}),
+				buildRule("nephe-at-web", network.SecurityRuleAccessDeny,
+					&securitygroup.RulePriority{TierPriority: 250, PolicyPriority: 1, RuleIndex: 0}),
+				buildRule("nephe-at-web", network.SecurityRuleAccessDeny,
+					&securitygroup.RulePriority{TierPriority: 50, PolicyPriority: 10, RuleIndex: 0}),
+			}
+
+			rules, err := updateSecurityRuleNameAndPriority(existingRules, newRules)
+			Expect(err).Should(BeNil())
+			var priorities []int32
+			var tiers []int32
+			for _, rule := range rules {
+				priorities = append(priorities, *rule.Priority)
+				if priority := getSecurityRulePriority(rule.Name); priority != nil {
+					tiers = append(tiers, priority.TierPriority)
+				}
+			}
+			Expect(priorities).To(Equal([]int32{100, 200, 201, 202, 300}))
+			Expect(tiers).To(Equal([]int32{50, 250, 250, 250}))
+			Expect(rules[1].Access).To(Equal(network.SecurityRuleAccessDeny))
+			Expect(*rules[3].Description).To(Equal("nephe-at-db"))
+		})
+		It("Should fail security rules exceeding priority range", func() {
+			var newRules []network.SecurityRule
+			for i := ruleStartPriority; i <= vnetToVnetDenyRulePriority; i++ {
+				newRules = append(newRules, buildRule("nephe-at-web", network.SecurityRuleAccessAllow, nil))
+			}
+
+			_, err := updateSecurityRuleNameAndPriority(nil, newRules)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("Preserved security groups", func() {
+		var (
+			rgID       = "/subscriptions/" + testSubID + "/resourceGroups/" + testRG + "/providers/Microsoft.Network"
+			userNsgID  = rgID + "/networkSecurityGroups/user-nsg"
+			nepheNsgID = rgID + "/networkSecurityGroups/nephe-at-per-vnet-default-vnet"
+			tagKey     = "nephe-at-web"
+		)
+
+		buildNetworkInterface := func(nsgID string, tags map[string]*string) *network.Interface {
+			return &network.Interface{
+				ID: to.StringPtr(rgID + "/networkInterfaces/nic"),
+				InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+					NetworkSecurityGroup: &network.SecurityGroup{ID: to.StringPtr(nsgID)},
+				},
+				Tags: tags,
+			}
+		}
+
+		It("Should keep network security group not created by nephe-controller", func() {
+			nwIntf := buildNetworkInterface(userNsgID, nil)
+			nsg, tags := getUpdatedNetworkInterfaceNsgAndTags(nwIntf, network.SecurityGroup{ID: to.StringPtr(nepheNsgID)},
+				true, true, tagKey)
+			Expect(*nsg.ID).To(Equal(userNsgID))
+			Expect(tags).To(HaveKey(tagKey))
+
+			nwIntf.Tags = tags
+			nsg, tags = getUpdatedNetworkInterfaceNsgAndTags(nwIntf, network.SecurityGroup{ID: to.StringPtr(nepheNsgID)},
+				false, true, tagKey)
+			Expect(nsg).ToNot(BeNil())
+			Expect(*nsg.ID).To(Equal(userNsgID))
+			Expect(tags).ToNot(HaveKey(tagKey))
+		})
+		It("Should replace network security group of network interface not preserved", func() {
+			nwIntf := buildNetworkInterface(userNsgID, nil)
+			nsg, _ := getUpdatedNetworkInterfaceNsgAndTags(nwIntf, network.SecurityGroup{ID: to.StringPtr(nepheNsgID)},
+				true, false, tagKey)
+			Expect(*nsg.ID).To(Equal(nepheNsgID))
+		})
+		It("Should detach network security group created by nephe-controller of preserved network interface", func() {
+			nwIntf := buildNetworkInterface(nepheNsgID, map[string]*string{tagKey: to.StringPtr("true")})
+			nsg, tags := getUpdatedNetworkInterfaceNsgAndTags(nwIntf, network.SecurityGroup{ID: to.StringPtr(nepheNsgID)},
+				false, true, tagKey)
+			Expect(nsg).To(BeNil())
+			Expect(tags).To(BeEmpty())
+		})
+		It("Should assign priorities around security rules not created by nephe-controller", func() {
+			buildRule := func(priority int32, description *string) network.SecurityRule {
+				return buildSecurityRule(to.Int32Ptr(priority), network.SecurityRuleProtocolAsterisk,
+					network.SecurityRuleDirectionInbound, to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil,
+					to.StringPtr(emptyPort), nil, nil, nil, description, network.SecurityRuleAccessAllow)
+			}
+			otherRules := []network.SecurityRule{buildRule(100, nil), buildRule(102, to.StringPtr("user rule"))}
+			newRules := []network.SecurityRule{buildRule(100, to.StringPtr(tagKey)), buildRule(101, to.StringPtr(tagKey))}
+
+			rules, err := updateSecurityRuleNameAndPriorityAroundRules(nil, newRules, otherRules)
+			Expect(err).Should(BeNil())
+			Expect(rules).To(HaveLen(2))
+			Expect(*rules[0].Priority).To(Equal(int32(101)))
+			Expect(*rules[1].Priority).To(Equal(int32(103)))
+			Expect(isNepheControllerCreatedSecurityRule(otherRules[0])).To(BeFalse())
+			Expect(isNepheControllerCreatedSecurityRule(otherRules[1])).To(BeFalse())
+			Expect(isNepheControllerCreatedSecurityRule(rules[0])).To(BeTrue())
+		})
+		It("Should merge security rules into network security group designated by the account", func() {
+			vnetID := "/subscriptions/" + testSubID + "/resourcegroups/" + testRG + "/providers/microsoft.network/virtualnetworks/vnet"
+			computeCfg := &computeServiceConfig{credentials: &azureAccountConfig{}}
+			Expect(computeCfg.getAppliedToNsgName(vnetID)).To(Equal("nephe-at-per-vnet-default-vnet"))
+			Expect(computeCfg.getAppliedToNsgID(vnetID)).To(Equal(strings.ToLower(nepheNsgID)))
+			Expect(isNepheControllerCreatedNsgName(computeCfg.getAppliedToNsgName(vnetID))).To(BeTrue())
+
+			computeCfg.credentials.securityGroupName = "User-NSG"
+			Expect(computeCfg.getAppliedToNsgName(vnetID)).To(Equal("user-nsg"))
+			Expect(computeCfg.getAppliedToNsgID(vnetID)).To(Equal(strings.ToLower(userNsgID)))
+			Expect(isNepheControllerCreatedNsgName(computeCfg.getAppliedToNsgName(vnetID))).To(BeFalse())
+
+			appliedToGroupID := &securitygroup.CloudResourceID{Name: "web", Vpc: vnetID}
+			atAsgMap := map[string]network.ApplicationSecurityGroup{
+				"web": {ID: to.StringPtr("asgID"), Name: to.StringPtr(appliedToGroupID.GetCloudName(false))},
+			}
+			securityRules, err := convertIngressToAzureNsgSecurityRules(appliedToGroupID, []*securitygroup.IngressRule{{}}, nil,
+				atAsgMap)
+			Expect(err).Should(BeNil())
+			Expect(securityRules).To(HaveLen(2))
+			securityRules = removeVnetToVnetDenyRule(securityRules)
+			Expect(securityRules).To(HaveLen(1))
+			Expect(*securityRules[0].Priority).ToNot(Equal(int32(vnetToVnetDenyRulePriority)))
+		})
+		It("Should report members of AT sg only for network interfaces of NSG holding its rules", func() {
+			vnetID := strings.ToLower("/subscriptions/" + testSubID + "/resourcegroups/" + testRG +
+				"/providers/microsoft.network/virtualnetworks/vnet")
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			mockNsg := NewMockazureNsgWrapper(mockCtrl)
+			computeCfg := &computeServiceConfig{nsgAPIClient: mockNsg,
+				credentials: &azureAccountConfig{securityGroupName: "user-nsg", preserveSecurityGroups: true}}
+			mockNsg.EXPECT().listAllComplete(gomock.Any()).Return([]network.SecurityGroup{
+				{ID: to.StringPtr(userNsgID), SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+					SecurityRules: &[]network.SecurityRule{},
+				}},
+			}, nil)
+
+			atSgTag := map[string]*string{(&securitygroup.CloudResourceID{Name: "web", Vpc: vnetID}).GetCloudName(false): nil}
+			buildNetworkInterfaceTable := func(name, nsgID string) *networkInterfaceTable {
+				return &networkInterfaceTable{
+					ID:                     to.StringPtr(rgID + "/networkInterfaces/" + name),
+					VnetID:                 to.StringPtr(vnetID),
+					VirtualMachineID:       to.StringPtr(rgID + "/virtualMachines/" + name),
+					NetworkSecurityGroupID: to.StringPtr(nsgID),
+					Tags:                   []map[string]*string{atSgTag},
+				}
+			}
+			networkInterfaces := []*networkInterfaceTable{
+				buildNetworkInterfaceTable("designated", userNsgID),
+				buildNetworkInterfaceTable("other", rgID+"/networkSecurityGroups/other-nsg"),
+			}
+			contents, atSgNames, err := computeCfg.processAndBuildATSgView(networkInterfaces)
+			Expect(err).Should(BeNil())
+			Expect(atSgNames).To(HaveKey("web"))
+			Expect(contents).To(HaveLen(1))
+			Expect(contents[0].Members).To(HaveLen(1))
+			Expect(contents[0].Members[0].Name.Name).To(Equal(
+				utils.GenerateShortResourceIdentifier(*networkInterfaces[0].ID, cloudcommon.NetworkInterfaceCRDKind)))
+		})
+		It("Should preserve security groups by virtual machine tag", func() {
+			Expect(utils.IsSecurityGroupsPreserved(nil, true)).To(BeTrue())
+			Expect(utils.IsSecurityGroupsPreserved(to.StringPtr("false"), true)).To(BeFalse())
+			Expect(utils.IsSecurityGroupsPreserved(to.StringPtr("True"), false)).To(BeTrue())
+			Expect(utils.IsSecurityGroupsPreserved(to.StringPtr("invalid"), false)).To(BeFalse())
+		})
+	})
+
+	Context("Import security groups", func() {
+		var (
+			mockCtrl   *gomock.Controller
+			mockNsg    *MockazureNsgWrapper
+			mockRG     *MockazureResourceGraphWrapper
+			rgID       = strings.ToLower("/subscriptions/" + testSubID + "/resourcegroups/" + testRG)
+			vnetID     = rgID + "/providers/microsoft.network/virtualnetworks/vnet"
+			vmID       = rgID + "/providers/microsoft.compute/virtualmachines/vm"
+			nsgID      = rgID + "/providers/microsoft.network/networksecuritygroups/user-nsg"
+			asgID      = rgID + "/providers/microsoft.network/applicationsecuritygroups/db"
+			subnetID   = vnetID + "/subnets/default"
+			computeCfg *computeServiceConfig
+		)
+
+		BeforeEach(func() {
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockNsg = NewMockazureNsgWrapper(mockCtrl)
+			mockRG = NewMockazureResourceGraphWrapper(mockCtrl)
+			computeCfg = &computeServiceConfig{
+				resourceGraphAPIClient: mockRG,
+				nsgAPIClient:           mockNsg,
+				credentials: &azureAccountConfig{
+					AzureAccountCredential: v1alpha1.AzureAccountCredential{SubscriptionID: testSubID, TenantID: "tenant"},
+					region:                 "eastus",
+				},
+			}
+		})
+
+		AfterEach(func() {
+			mockCtrl.Finish()
+		})
+
+		It("Should return network security groups with virtual machine members and rules", func() {
+			result := getResourceGraphResult()
+			result.Data = []interface{}{
+				map[string]interface{}{
+					"id":                          rgID + "/providers/microsoft.network/networkinterfaces/nic",
+					"vnetId":                      vnetID,
+					"virtualMachineID":            vmID,
+					"networkSecurityGroupID":      nsgID,
+					"applicationSecurityGroupIDs": []interface{}{asgID},
+				},
+			}
+			mockRG.EXPECT().resources(gomock.Any(), gomock.Any()).Return(result, nil)
+			rules := []network.SecurityRule{
+				buildSecurityRule(to.Int32Ptr(200), network.SecurityRuleProtocolTCP, network.SecurityRuleDirectionInbound,
+					to.StringPtr(emptyPort), to.StringPtr("10.0.0.0/16"), nil, nil, to.StringPtr("22"), to.StringPtr(emptyPort),
+					nil, nil, nil, network.SecurityRuleAccessAllow),
+				buildSecurityRule(to.Int32Ptr(100), network.SecurityRuleProtocolAsterisk, network.SecurityRuleDirectionInbound,
+					to.StringPtr(emptyPort), nil, nil, &[]network.ApplicationSecurityGroup{{ID: to.StringPtr(asgID)}},
+					to.StringPtr(emptyPort), to.StringPtr(virtualnetworkAddressPrefix), nil, nil, nil, network.SecurityRuleAccessDeny),
+				buildSecurityRule(to.Int32Ptr(300), network.SecurityRuleProtocolAsterisk, network.SecurityRuleDirectionOutbound,
+					to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil, to.StringPtr(emptyPort), to.StringPtr("Internet"),
+					nil, nil, nil, network.SecurityRuleAccessAllow),
+				buildSecurityRule(to.Int32Ptr(400), network.SecurityRuleProtocolEsp, network.SecurityRuleDirectionInbound,
+					to.StringPtr(emptyPort), to.StringPtr(emptyPort), nil, nil, to.StringPtr(emptyPort), to.StringPtr(emptyPort),
+					nil, nil, nil, network.SecurityRuleAccessAllow),
+			}
+			nsgs := []network.SecurityGroup{
+				{
+					ID: to.StringPtr(strings.ToUpper(nsgID)),
+					SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+						SecurityRules: &rules,
+						Subnets:       &[]network.Subnet{{ID: to.StringPtr(subnetID)}},
+					},
+				},
+				{ID: to.StringPtr(rgID + "/providers/microsoft.network/networksecuritygroups/other")},
+			}
+			mockNsg.EXPECT().listAllComplete(gomock.Any()).Return(nsgs, nil)
+
+			contents, err := computeCfg.getVnetSecurityGroupsImportView(vnetID)
+			Expect(err).Should(BeNil())
+			Expect(contents).To(HaveLen(2))
+			vmMember := securitygroup.CloudResource{
+				Type: securitygroup.CloudResourceTypeVM,
+				Name: securitygroup.CloudResourceID{Name: utils.GenerateShortResourceIdentifier(vmID, "vm"), Vpc: vnetID},
+			}
+
+			Expect(contents[0].Resource).To(Equal(securitygroup.CloudResourceID{Name: "user-nsg", Vpc: vnetID}))
+			Expect(contents[0].MembershipOnly).To(BeFalse())
+			Expect(contents[0].Members).To(Equal([]securitygroup.CloudResource{vmMember}))
+			Expect(contents[0].Unsupported).To(ConsistOf(
+				"attached to subnet "+subnetID,
+				"rule 300-Outbound refers to Internet",
+				"rule 400-Inbound uses protocol Esp",
+			))
+			Expect(contents[0].EgressRules).To(BeEmpty())
+			Expect(contents[0].IngressRules).To(HaveLen(2))
+			Expect(*contents[0].IngressRules[0].Protocol).To(Equal(6))
+			Expect(*contents[0].IngressRules[0].FromPort).To(Equal(22))
+			Expect(contents[0].IngressRules[0].FromSrcIP).To(HaveLen(1))
+			Expect(contents[0].IngressRules[0].FromSrcIP[0].String()).To(Equal("10.0.0.0/16"))
+			Expect(contents[0].IngressRules[0].Priority.RuleIndex).To(Equal(int32(200)))
+			Expect(contents[0].IngressRules[1].Protocol).To(BeNil())
+			Expect(contents[0].IngressRules[1].Action).To(Equal(securitygroup.RuleActionDrop))
+			Expect(contents[0].IngressRules[1].FromSecurityGroups).To(Equal(
+				[]*securitygroup.CloudResourceID{{Name: asgID, Vpc: vnetID}}))
+
+			Expect(contents[1].Resource).To(Equal(securitygroup.CloudResourceID{Name: asgID, Vpc: vnetID}))
+			Expect(contents[1].MembershipOnly).To(BeTrue())
+			Expect(contents[1].Members).To(Equal([]securitygroup.CloudResource{vmMember}))
+		})
+		It("Should drop security rules restricting addresses of attached network interfaces", func() {
+			rule := buildSecurityRule(to.Int32Ptr(100), network.SecurityRuleProtocolUDP, network.SecurityRuleDirectionOutbound,
+				to.StringPtr(emptyPort), to.StringPtr("10.0.0.4"), nil, nil, nil, to.StringPtr("10.1.0.1"), nil, nil, nil,
+				network.SecurityRuleAccessAllow)
+			rule.DestinationPortRanges = &[]string{"53", "8000-8080"}
+			ingressRules, egressRules, unsupported := convertFromAzureSecurityRuleToImportRules(rule, vnetID)
+			Expect(ingressRules).To(BeEmpty())
+			Expect(egressRules).To(BeEmpty())
+			Expect(unsupported).To(ConsistOf("rule 100-Outbound restricts addresses of network interfaces it is attached to"))
+
+			rule.SourceAddressPrefix = to.StringPtr(emptyPort)
+			_, egressRules, unsupported = convertFromAzureSecurityRuleToImportRules(rule, vnetID)
+			Expect(unsupported).To(BeEmpty())
+			Expect(egressRules).To(HaveLen(2))
+			Expect(egressRules[0].ToDstIP[0].String()).To(Equal("10.1.0.1/32"))
+			Expect(*egressRules[0].ToPort).To(Equal(53))
+			Expect(*egressRules[1].ToPort).To(Equal(8000))
+			Expect(*egressRules[1].ToEndPort).To(Equal(8080))
+		})
+	})
+
+	Context("Credential modes", func() {
+		var (
+			fakeClient  client.WithWatch
+			azureConfig *v1alpha1.CloudProviderAccountAzureConfig
+			tokenDir    string
+			tokenFile   string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tokenDir, err = os.MkdirTemp("", "nephe-azure-test")
+			Expect(err).Should(BeNil())
+			fakeClient = fake.NewClientBuilder().Build()
+			tokenFile = filepath.Join(tokenDir, "azure-identity-token")
+			azureConfig = &v1alpha1.CloudProviderAccountAzureConfig{
+				CredentialMode:     v1alpha1.AzureCredentialModeWorkloadIdentity,
+				SubscriptionID:     testSubID,
+				TenantID:           testTenantID,
+				ClientID:           testClientID,
+				FederatedTokenFile: tokenFile,
+				Region:             testRegion,
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tokenDir)).Should(BeNil())
+		})
+
+		It("Should not read secret in WorkloadIdentity credential mode", func() {
+			accCfg, err := setAccountCredentials(fakeClient, azureConfig)
+			Expect(err).Should(BeNil())
+			azureAccCfg := accCfg.(*azureAccountConfig)
+			Expect(azureAccCfg.credentialMode).To(Equal(v1alpha1.AzureCredentialModeWorkloadIdentity))
+			Expect(azureAccCfg.SubscriptionID).To(Equal(testSubID))
+			Expect(azureAccCfg.federatedTokenFile).To(Equal(tokenFile))
+			Expect(azureAccCfg.ClientKey).To(BeEmpty())
+
+			_, err = (&azureServicesHelperImpl{}).newServiceSdkConfigProvider(azureAccCfg)
+			Expect(err).Should(BeNil())
+		})
+		It("Should read rotated federated token on token refresh", func() {
+			secret := &azureFederatedTokenSecret{tokenFile: tokenFile}
+			values := &url.Values{}
+			Expect(secret.SetAuthenticationValues(nil, values)).To(HaveOccurred())
+
+			Expect(os.WriteFile(tokenFile, []byte("token01\n"), 0600)).Should(BeNil())
+			Expect(secret.SetAuthenticationValues(nil, values)).Should(BeNil())
+			Expect(values.Get("client_assertion")).To(Equal("token01"))
+			Expect(values.Get("client_assertion_type")).To(Equal(azureClientAssertionType))
+
+			Expect(os.WriteFile(tokenFile, []byte("token02"), 0600)).Should(BeNil())
+			Expect(secret.SetAuthenticationValues(nil, values)).Should(BeNil())
+			Expect(values.Get("client_assertion")).To(Equal("token02"))
+		})
+		It("Should fail WorkloadIdentity credential mode without token file", func() {
+			if value, found := os.LookupEnv(azureFederatedTokenFileEnv); found {
+				Expect(os.Unsetenv(azureFederatedTokenFileEnv)).Should(BeNil())
+				defer os.Setenv(azureFederatedTokenFileEnv, value)
+			}
+			azureConfig.FederatedTokenFile = ""
+			accCfg, err := setAccountCredentials(fakeClient, azureConfig)
+			Expect(err).Should(BeNil())
+			_, err = (&azureServicesHelperImpl{}).newServiceSdkConfigProvider(accCfg.(*azureAccountConfig))
+			Expect(err).To(HaveOccurred())
+		})
+		It("Should use user-assigned identity in ManagedIdentity credential mode", func() {
+			azureConfig.CredentialMode = v1alpha1.AzureCredentialModeManagedIdentity
+			azureConfig.TenantID = ""
+			accCfg, err := setAccountCredentials(fakeClient, azureConfig)
+			Expect(err).Should(BeNil())
+			Expect(accCfg.(*azureAccountConfig).ClientID).To(Equal(testClientID))
+			_, err = (&azureServicesHelperImpl{}).newServiceSdkConfigProvider(accCfg.(*azureAccountConfig))
+			Expect(err).Should(BeNil())
+		})
+		It("Should read client certificate in ClientCertificate credential mode", func() {
+			azureConfig.CredentialMode = v1alpha1.AzureCredentialModeClientCertificate
+			azureConfig.SecretRef = &v1alpha1.SecretReference{Name: "secret01", Namespace: "namespace01", Key: credentials}
+			azureConfig.TenantID = ""
+			_, err := setAccountCredentials(fakeClient, azureConfig)
+			Expect(err).To(HaveOccurred())
+
+			secret := &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: "secret01", Namespace: "namespace01"},
+				Data: map[string][]byte{
+					credentials: []byte(`{"subscriptionId": "secretSubID", "clientId": "secretClientID", "tenantId": "TenantID",
+						"clientCertificate": "invalid", "clientCertificatePassword": "password"}`),
+				},
+			}
+			Expect(fakeClient.Create(context.Background(), secret)).Should(BeNil())
+			accCfg, err := setAccountCredentials(fakeClient, azureConfig)
+			Expect(err).Should(BeNil())
+			azureAccCfg := accCfg.(*azureAccountConfig)
+			// subscription and client IDs of the account override the ones of the secret.
+			Expect(azureAccCfg.SubscriptionID).To(Equal(testSubID))
+			Expect(azureAccCfg.ClientID).To(Equal(testClientID))
+			Expect(azureAccCfg.TenantID).To(Equal(testTenantID))
+			Expect(azureAccCfg.ClientCertificatePassword).To(Equal("password"))
+
+			_, err = (&azureServicesHelperImpl{}).newServiceSdkConfigProvider(azureAccCfg)
+			Expect(err).To(HaveOccurred())
+
+			changed := *azureAccCfg
+			changed.ClientCertificate = "rotated"
+			Expect(compareAccountCredentials("account01", azureAccCfg, &changed)).To(BeTrue())
+		})
+	})
+
+	Context("Multiple regions", func() {
+		var (
+			account    *v1alpha1.CloudProviderAccount
+			fakeClient client.WithWatch
+
+			mockCtrl               *gomock.Controller
+			mockAzureServiceHelper *MockazureServicesHelper
+			mockazureService       *MockazureServiceClientCreateInterface
+			mockazureResourceGraph *MockazureResourceGraphWrapper
+		)
+
+		BeforeEach(func() {
+			var pollIntv uint = 2
+			account = &v1alpha1.CloudProviderAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      testAccountNamespacedName.Name,
+					Namespace: testAccountNamespacedName.Namespace,
+				},
+				Spec: v1alpha1.CloudProviderAccountSpec{
+					PollIntervalInSeconds: &pollIntv,
+					AzureConfig: &v1alpha1.CloudProviderAccountAzureConfig{
+						CredentialMode: v1alpha1.AzureCredentialModeManagedIdentity,
+						SubscriptionID: testSubID,
+						Region:         testRegion,
+						Regions:        []string{"westus"},
+					},
+				},
+			}
+			fakeClient = fake.NewClientBuilder().Build()
+
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockAzureServiceHelper = NewMockazureServicesHelper(mockCtrl)
+			mockazureService = NewMockazureServiceClientCreateInterface(mockCtrl)
+			mockazureResourceGraph = NewMockazureResourceGraphWrapper(mockCtrl)
+
+			mockAzureServiceHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockazureService, nil).Times(1)
+			mockazureService.EXPECT().networkInterfaces(gomock.Any()).Return(NewMockazureNwIntfWrapper(mockCtrl), nil).AnyTimes()
+			mockazureService.EXPECT().securityGroups(gomock.Any()).Return(NewMockazureNsgWrapper(mockCtrl), nil).AnyTimes()
+			mockazureService.EXPECT().applicationSecurityGroups(gomock.Any()).Return(NewMockazureAsgWrapper(mockCtrl), nil).AnyTimes()
+			mockazureService.EXPECT().virtualNetworks(gomock.Any()).Return(NewMockazureVirtualNetworksWrapper(mockCtrl), nil).AnyTimes()
+			mockazureService.EXPECT().resourceGraph().Return(mockazureResourceGraph, nil).AnyTimes()
+		})
+
+		AfterEach(func() {
+			mockCtrl.Finish()
+		})
+
+		It("Should create compute service per region and route vnet to its region", func() {
+			c := newAzureCloud(mockAzureServiceHelper)
+			err := c.AddProviderAccount(fakeClient, account)
+			Expect(err).Should(BeNil())
+			accCfg, found := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
+			Expect(found).To(BeTrue())
+			computeServices := getComputeServiceConfigs(accCfg)
+			Expect(computeServices).To(HaveLen(2))
+			Expect(computeServices[0].GetName()).To(Equal(getComputeServiceName(testRegion)))
+			Expect(computeServices[1].GetName()).To(Equal(getComputeServiceName("westus")))
+			Expect(computeServices[1].credentials.region).To(Equal("westus"))
+
+			computeServices[1].resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{
+				vnetIDs: map[string]struct{}{strings.ToLower(testVnetID01): {}},
+			})
+			vnetAccCfg, computeService := c.getVnetAccount(testVnetID01)
+			Expect(vnetAccCfg).To(Equal(accCfg))
+			Expect(computeService).To(Equal(computeServices[1]))
+			_, computeService = c.getVnetAccount(testVnetID02)
+			Expect(computeService).To(BeNil())
+		})
+		It("Should create compute service per virtual network location with all regions", func() {
+			result := getResourceGraphResult()
+			result.Data = []interface{}{
+				map[string]interface{}{"location": "westus"},
+				map[string]interface{}{"location": "centralus"},
+			}
+			mockazureResourceGraph.EXPECT().resources(gomock.Any(), gomock.Any()).Return(result, nil)
+
+			account.Spec.AzureConfig.Region = ""
+			account.Spec.AzureConfig.Regions = []string{v1alpha1.AllRegions}
+			c := newAzureCloud(mockAzureServiceHelper)
+			err := c.AddProviderAccount(fakeClient, account)
+			Expect(err).Should(BeNil())
+			accCfg, found := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
+			Expect(found).To(BeTrue())
+			computeServices := getComputeServiceConfigs(accCfg)
+			Expect(computeServices).To(HaveLen(2))
+			Expect(computeServices[0].GetName()).To(Equal(getComputeServiceName("centralus")))
+			Expect(computeServices[1].GetName()).To(Equal(getComputeServiceName("westus")))
+		})
+		It("Should create compute services of additional subscriptions", func() {
+			testSubID02 := "SubID02"
+			account.Spec.AzureConfig.Regions = nil
+			account.Spec.AzureConfig.SubscriptionIDs = []string{testSubID02, testSubID, " "}
+			c := newAzureCloud(mockAzureServiceHelper)
+			err := c.AddProviderAccount(fakeClient, account)
+			Expect(err).Should(BeNil())
+			accCfg, found := c.cloudCommon.GetCloudAccountByName(testAccountNamespacedName)
+			Expect(found).To(BeTrue())
+			computeServices := getComputeServiceConfigs(accCfg)
+			Expect(computeServices).To(HaveLen(2))
+			Expect(computeServices[0].GetName()).To(Equal(getComputeServiceName(testRegion)))
+			Expect(computeServices[0].credentials.SubscriptionID).To(Equal(testSubID))
+			Expect(computeServices[1].GetName()).To(Equal(getSubscriptionComputeServiceName(testSubID02, testRegion)))
+			Expect(computeServices[1].credentials.SubscriptionID).To(Equal(testSubID02))
+
+			// vnets of an additional subscription are managed by the compute service of the subscription.
+			testVnetID03 := fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Network/virtualNetworks/%v",
+				testSubID02, testRG, testVnet01)
+			computeServices[1].resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{
+				vnetIDs: map[string]struct{}{strings.ToLower(testVnetID03): {}},
+			})
+			_, computeService := c.getVnetAccount(testVnetID03)
+			Expect(computeService).To(Equal(computeServices[1]))
+		})
+	})
+
+	Context("Event queue", func() {
+		var (
+			testVMID01 = fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Compute/virtualMachines/vm01",
+				testSubID, testRG)
+			testVMID02 = fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Compute/virtualMachines/vm02",
+				testSubID, testRG)
+			testQueueURL = "https://account01.queue.core.windows.net/nephe-events"
+
+			mockCtrl                 *gomock.Controller
+			mockAzureServiceHelper   *MockazureServicesHelper
+			mockazureService         *MockazureServiceClientCreateInterface
+			mockazureStorageQueue    *MockazureStorageQueueWrapper
+			mockazureResourceGraph   *MockazureResourceGraphWrapper
+			azureAccountCredentials  *azureAccountConfig
+			selectorNamespacedName   = types.NamespacedName{Namespace: "namespace01", Name: "selector01"}
+			getVirtualMachineEventFn = func(eventType, vmID string) string {
+				return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"eventType": "%v", "subject": "%v",
+					"data": {"resourceUri": "%v"}}`, eventType, vmID, vmID)))
+			}
+		)
+
+		BeforeEach(func() {
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockAzureServiceHelper = NewMockazureServicesHelper(mockCtrl)
+			mockazureService = NewMockazureServiceClientCreateInterface(mockCtrl)
+			mockazureStorageQueue = NewMockazureStorageQueueWrapper(mockCtrl)
+			mockazureResourceGraph = NewMockazureResourceGraphWrapper(mockCtrl)
+			azureAccountCredentials = &azureAccountConfig{
+				AzureAccountCredential: v1alpha1.AzureAccountCredential{SubscriptionID: testSubID},
+				region:                 testRegion,
+				eventQueueURL:          testQueueURL,
+			}
+		})
+
+		AfterEach(func() {
+			mockCtrl.Finish()
+		})
+
+		It("Should parse virtual machine IDs of Event Grid events", func() {
+			event := parseAzureEvent(getVirtualMachineEventFn("Microsoft.Resources.ResourceWriteSuccess", testVMID01+"/extensions/ext01"))
+			Expect(event.AccountID).To(Equal(strings.ToLower(testSubID)))
+			Expect(event.ResourceIDs).To(Equal([]string{strings.ToLower(testVMID01)}))
+
+			// events are also accepted without base64 encoding.
+			event = parseAzureEvent(fmt.Sprintf(`{"eventType": "Microsoft.Resources.ResourceDeleteSuccess", "subject": "%v"}`,
+				testVMID02))
+			Expect(event.ResourceIDs).To(Equal([]string{strings.ToLower(testVMID02)}))
+
+			event = parseAzureEvent(getVirtualMachineEventFn("Microsoft.Resources.ResourceWriteSuccess", testVnetID01))
+			Expect(event.ResourceIDs).To(BeEmpty())
+		})
+		It("Should receive and delete events of storage queue", func() {
+			mockAzureServiceHelper.EXPECT().newServiceSdkConfigProvider(azureAccountCredentials).Return(mockazureService, nil)
+			mockazureService.EXPECT().storageQueue(testQueueURL).Return(mockazureStorageQueue, nil)
+			mockazureStorageQueue.EXPECT().receiveMessages(gomock.Any(), azureEventQueueMaxMessages,
+				azureEventQueueVisibilityTimeout).Return([]*azureQueueMessage{
+				{
+					MessageID:   "message01",
+					PopReceipt:  "receipt/01",
+					MessageText: getVirtualMachineEventFn("Microsoft.Resources.ResourceDeleteSuccess", testVMID01),
+				},
+			}, nil)
+			mockazureStorageQueue.EXPECT().deleteMessage(gomock.Any(), "message01", "receipt/01").Return(nil)
+
+			queue, err := newAzureEventQueue(azureAccountCredentials, mockAzureServiceHelper)
+			Expect(err).Should(BeNil())
+			events, err := queue.ReceiveEvents()
+			Expect(err).Should(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].ResourceIDs).To(Equal([]string{strings.ToLower(testVMID01)}))
+			Expect(queue.DeleteEvents(events)).Should(BeNil())
+
+			azureAccountCredentials.eventQueueURL = ""
+			queue, err = newAzureEventQueue(azureAccountCredentials, mockAzureServiceHelper)
+			Expect(err).Should(BeNil())
+			Expect(queue).To(BeNil())
+		})
+		It("Should call storage queue REST api", func() {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.String())
+				Expect(r.Header.Get(azureStorageVersionHeader)).To(Equal(azureStorageAPIVersion))
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><QueueMessagesList><QueueMessage>
+					<MessageId>message01</MessageId><PopReceipt>receipt01</PopReceipt><MessageText>text01</MessageText>
+					</QueueMessage></QueueMessagesList>`))
+			}))
+			defer server.Close()
+
+			provider := &azureServiceSdkConfigProvider{storageAuthorizer: autorest.NullAuthorizer{}}
+			queue, err := provider.storageQueue(server.URL + "/nephe-events/")
+			Expect(err).Should(BeNil())
+			messages, err := queue.receiveMessages(context.Background(), 2, time.Minute)
+			Expect(err).Should(BeNil())
+			Expect(messages).To(Equal([]*azureQueueMessage{{MessageID: "message01", PopReceipt: "receipt01", MessageText: "text01"}}))
+			Expect(queue.deleteMessage(context.Background(), "message01", "receipt01")).Should(BeNil())
+			Expect(requests).To(Equal([]string{
+				"GET /nephe-events/messages?numofmessages=2&visibilitytimeout=60",
+				"DELETE /nephe-events/messages/message01?popreceipt=receipt01",
+			}))
+		})
+		It("Should update virtual machines of events in cache", func() {
+			vnetID := strings.ToLower(testVnetID01)
+			mockazureResourceGraph.EXPECT().resources(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, query resourcegraph.QueryRequest) (resourcegraph.QueryResponse, error) {
+					Expect(*query.Query).To(ContainSubstring(fmt.Sprintf(`Resources | where id in ("%v", "%v")`,
+						strings.ToLower(testVMID01), strings.ToLower(testVMID02))))
+					result := getResourceGraphResult()
+					result.Data = []interface{}{
+						map[string]interface{}{"id": strings.ToLower(testVMID02), "name": "vm02", "vnetId": vnetID},
+					}
+					return result, nil
+				})
+			computeCfg := &computeServiceConfig{
+				resourceGraphAPIClient: mockazureResourceGraph,
+				resourcesCache:         &internal.CloudServiceResourcesCache{},
+				inventoryStats:         &internal.CloudServiceStats{},
+				credentials:            azureAccountCredentials,
+				computeFilters:         map[types.NamespacedName][]*string{selectorNamespacedName: {to.StringPtr("Resources")}},
+			}
+			Expect(computeCfg.DoEventResourceInventory([]*internal.CloudEvent{{AccountID: testSubID,
+				ResourceIDs: []string{testVMID01}}})).Should(BeNil())
+			Expect(computeCfg.resourcesCache.GetSnapshot()).To(BeNil())
+
+			vm01 := &virtualMachineTable{ID: to.StringPtr(strings.ToLower(testVMID01)), VnetID: &vnetID}
+			computeCfg.resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{
+				virtualMachines:         map[cloudcommon.InstanceID]*virtualMachineTable{cloudcommon.InstanceID(*vm01.ID): vm01},
+				vnetIDs:                 map[string]struct{}{vnetID: {}},
+				selectorVirtualMachines: map[types.NamespacedName][]cloudcommon.InstanceID{selectorNamespacedName: {cloudcommon.InstanceID(*vm01.ID)}},
+			})
+			// events of other subscriptions are ignored.
+			Expect(computeCfg.DoEventResourceInventory([]*internal.CloudEvent{{AccountID: "SubID02",
+				ResourceIDs: []string{testVMID02}}})).Should(BeNil())
+			Expect(computeCfg.DoEventResourceInventory([]*internal.CloudEvent{{AccountID: strings.ToLower(testSubID),
+				ResourceIDs: []string{strings.ToLower(testVMID01), strings.ToLower(testVMID02)}}})).Should(BeNil())
+
+			snapshot := computeCfg.resourcesCache.GetSnapshot().(*computeResourcesCacheSnapshot)
+			Expect(snapshot.virtualMachines).To(HaveLen(1))
+			Expect(snapshot.virtualMachines).To(HaveKey(cloudcommon.InstanceID(strings.ToLower(testVMID02))))
+			Expect(snapshot.selectorVirtualMachines[selectorNamespacedName]).
+				To(Equal([]cloudcommon.InstanceID{cloudcommon.InstanceID(strings.ToLower(testVMID02))}))
+			Expect(snapshot.vnetIDs).To(HaveKey(vnetID))
+		})
+	})
+
+	Context("API throttling", func() {
+		It("Should retry throttled requests after Retry-After delay", func() {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					w.Header().Set(autorest.HeaderRetryAfter, "1")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			sender := &azureLimitedSender{sender: autorest.CreateSender(), apiLimiter: newAzureAPILimiter(testAccountNamespacedName)}
+			req, err := autorest.Prepare(&http.Request{}, autorest.AsPut(), autorest.WithBaseURL(server.URL),
+				autorest.WithPath("/subscriptions/SubID/providers/Microsoft.Network/networkInterfaces/nic01"),
+				autorest.WithJSON(map[string]string{"name": "nic01"}))
+			Expect(err).Should(BeNil())
+			Expect(getAzureAPIOperation(req)).To(Equal("PUT Microsoft.Network/networkInterfaces"))
+
+			start := time.Now()
+			resp, err := sender.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+			// request body is sent again on retry.
+			Expect(bodies).To(Equal([]string{`{"name":"nic01"}`, `{"name":"nic01"}`}))
+		})
+		It("Should not retry failed requests", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			sender := &azureLimitedSender{sender: autorest.CreateSender(), apiLimiter: newAzureAPILimiter(testAccountNamespacedName)}
+			req, err := autorest.Prepare(&http.Request{}, autorest.AsGet(), autorest.WithBaseURL(server.URL))
+			Expect(err).Should(BeNil())
+			resp, err := sender.Do(req)
+			Expect(err).Should(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(requests).To(Equal(1))
+		})
+	})
+
+	Context("VirtualMachine CRD", func() {
+		It("Should convert virtual machine placement, image and security groups", func() {
+			nicID := fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Network/networkInterfaces/nic01",
+				testSubID, testRG)
+			securityGroupID := fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Network/%%v/%%v",
+				testSubID, testRG)
+			// row as returned by resource graph vm table query.
+			row := map[string]interface{}{
+				"id":   fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Compute/virtualMachines/vm01", testSubID, testRG),
+				"name": "vm01",
+				"properties": map[string]interface{}{
+					"hardwareProfile": map[string]interface{}{"vmSize": "Standard_B1s"},
+					"storageProfile": map[string]interface{}{
+						"osDisk": map[string]interface{}{"osType": "Windows"},
+						"imageReference": map[string]interface{}{"publisher": "MicrosoftWindowsServer",
+							"offer": "WindowsServer", "sku": "2019-Datacenter", "version": "latest"},
+					},
+				},
+				"networkInterfaces": []interface{}{map[string]interface{}{
+					"id":         nicID,
+					"privateIps": []interface{}{"10.0.1.4"},
+					"vnetId":     strings.ToLower(testVnetID01),
+					"subnetId":   strings.ToLower(testVnetID01 + "/subnets/subnet01"),
+					"nsgId":      strings.ToLower(fmt.Sprintf(securityGroupID, "networkSecurityGroups", "nsg01")),
+					"applicationSecurityGroups": []interface{}{[]interface{}{
+						map[string]interface{}{"id": fmt.Sprintf(securityGroupID, "applicationSecurityGroups", "asg01")},
+					}},
+				}},
+				"status":      "PowerState/running",
+				"vnetId":      strings.ToLower(testVnetID01),
+				"location":    "eastus",
+				"zones":       []interface{}{"1"},
+				"timeCreated": "2022-06-01T10:30:15.1234567Z",
+			}
+			var instance virtualMachineTable
+			Expect(mapstructure.Decode(row, &instance)).To(Succeed())
+
+			vm := computeInstanceToVirtualMachineCRD(&instance, "namespace01")
+			Expect(vm.Status.Zone).To(Equal("eastus-1"))
+			Expect(vm.Status.Subnet).To(HavePrefix("subnet01-"))
+			Expect(vm.Status.NetworkInterfaces[0].Subnet).To(Equal(vm.Status.Subnet))
+			Expect(vm.Status.InstanceType).To(Equal("Standard_B1s"))
+			Expect(vm.Status.Image).To(Equal("MicrosoftWindowsServer:WindowsServer:2019-Datacenter:latest"))
+			Expect(vm.Status.OSType).To(Equal(v1alpha1.Windows))
+			Expect(vm.Status.SecurityGroups).To(Equal([]string{"asg01", "nsg01"}))
+			Expect(vm.Status.LaunchTime.Time).To(Equal(time.Date(2022, 6, 1, 10, 30, 15, 0, time.UTC)))
+		})
 	})
 })
 
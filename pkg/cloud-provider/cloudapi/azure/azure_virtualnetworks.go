@@ -22,5 +22,6 @@ import (
 func (p *azureServiceSdkConfigProvider) virtualNetworks(subscriptionID string) (azureVirtualNetworksWrapper, error) {
 	virtualNetworksClient := network.NewVirtualNetworksClient(subscriptionID)
 	virtualNetworksClient.Authorizer = p.authorizer
+	virtualNetworksClient.Sender = p.sender
 	return &azureVirtualNetworksWrapperImpl{virtualNetworksClient: virtualNetworksClient}, nil
 }
@@ -66,6 +66,20 @@ func (mr *MockCloudInterfaceMockRecorder) AddAccountResourceSelector(accNamespac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountResourceSelector", reflect.TypeOf((*MockCloudInterface)(nil).AddAccountResourceSelector), accNamespacedName, selector)
 }
 
+// AddAccountResourceSelectorWithSnapshot mocks base method.
+func (m *MockCloudInterface) AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector, vms []*v1alpha1.VirtualMachine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountResourceSelectorWithSnapshot", accNamespacedName, selector, vms)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccountResourceSelectorWithSnapshot indicates an expected call of AddAccountResourceSelectorWithSnapshot.
+func (mr *MockCloudInterfaceMockRecorder) AddAccountResourceSelectorWithSnapshot(accNamespacedName, selector, vms interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountResourceSelectorWithSnapshot", reflect.TypeOf((*MockCloudInterface)(nil).AddAccountResourceSelectorWithSnapshot), accNamespacedName, selector, vms)
+}
+
 // AddProviderAccount mocks base method.
 func (m *MockCloudInterface) AddProviderAccount(client client.Client, account *v1alpha1.CloudProviderAccount) error {
 	m.ctrl.T.Helper()
@@ -109,6 +123,20 @@ func (mr *MockCloudInterfaceMockRecorder) DeleteSecurityGroup(addressGroupIdenti
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecurityGroup", reflect.TypeOf((*MockCloudInterface)(nil).DeleteSecurityGroup), addressGroupIdentifier, membershipOnly)
 }
 
+// GetAccountEnforcedSecurity mocks base method.
+func (m *MockCloudInterface) GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountEnforcedSecurity", accNamespacedName)
+	ret0, _ := ret[0].([]securitygroup.SynchronizationContent)
+	return ret0
+}
+
+// GetAccountEnforcedSecurity indicates an expected call of GetAccountEnforcedSecurity.
+func (mr *MockCloudInterfaceMockRecorder) GetAccountEnforcedSecurity(accNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountEnforcedSecurity", reflect.TypeOf((*MockCloudInterface)(nil).GetAccountEnforcedSecurity), accNamespacedName)
+}
+
 // GetAccountStatus mocks base method.
 func (m *MockCloudInterface) GetAccountStatus(accNamespacedName *types.NamespacedName) (*v1alpha1.CloudProviderAccountStatus, error) {
 	m.ctrl.T.Helper()
@@ -138,6 +166,35 @@ func (mr *MockCloudInterfaceMockRecorder) GetEnforcedSecurity() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEnforcedSecurity", reflect.TypeOf((*MockCloudInterface)(nil).GetEnforcedSecurity))
 }
 
+// GetVirtualPrivateCloudAccount mocks base method.
+func (m *MockCloudInterface) GetVirtualPrivateCloudAccount(uniqueIdentifier string) *types.NamespacedName {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualPrivateCloudAccount", uniqueIdentifier)
+	ret0, _ := ret[0].(*types.NamespacedName)
+	return ret0
+}
+
+// GetVirtualPrivateCloudAccount indicates an expected call of GetVirtualPrivateCloudAccount.
+func (mr *MockCloudInterfaceMockRecorder) GetVirtualPrivateCloudAccount(uniqueIdentifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualPrivateCloudAccount", reflect.TypeOf((*MockCloudInterface)(nil).GetVirtualPrivateCloudAccount), uniqueIdentifier)
+}
+
+// GetVpcSecurityGroups mocks base method.
+func (m *MockCloudInterface) GetVpcSecurityGroups(accNamespacedName *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVpcSecurityGroups", accNamespacedName, vpcID)
+	ret0, _ := ret[0].([]securitygroup.ImportContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVpcSecurityGroups indicates an expected call of GetVpcSecurityGroups.
+func (mr *MockCloudInterfaceMockRecorder) GetVpcSecurityGroups(accNamespacedName, vpcID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVpcSecurityGroups", reflect.TypeOf((*MockCloudInterface)(nil).GetVpcSecurityGroups), accNamespacedName, vpcID)
+}
+
 // Instances mocks base method.
 func (m *MockCloudInterface) Instances() ([]*v1alpha1.VirtualMachine, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +225,35 @@ func (mr *MockCloudInterfaceMockRecorder) InstancesGivenProviderAccount(namespac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstancesGivenProviderAccount", reflect.TypeOf((*MockCloudInterface)(nil).InstancesGivenProviderAccount), namespacedName)
 }
 
+// InstancesGivenProviderAccountSelector mocks base method.
+func (m *MockCloudInterface) InstancesGivenProviderAccountSelector(accNamespacedName, selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstancesGivenProviderAccountSelector", accNamespacedName, selectorNamespacedName)
+	ret0, _ := ret[0].([]*v1alpha1.VirtualMachine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InstancesGivenProviderAccountSelector indicates an expected call of InstancesGivenProviderAccountSelector.
+func (mr *MockCloudInterfaceMockRecorder) InstancesGivenProviderAccountSelector(accNamespacedName, selectorNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstancesGivenProviderAccountSelector", reflect.TypeOf((*MockCloudInterface)(nil).InstancesGivenProviderAccountSelector), accNamespacedName, selectorNamespacedName)
+}
+
+// IsRuleActionSupported mocks base method.
+func (m *MockCloudInterface) IsRuleActionSupported(action securitygroup.RuleAction) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRuleActionSupported", action)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRuleActionSupported indicates an expected call of IsRuleActionSupported.
+func (mr *MockCloudInterfaceMockRecorder) IsRuleActionSupported(action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRuleActionSupported", reflect.TypeOf((*MockCloudInterface)(nil).IsRuleActionSupported), action)
+}
+
 // IsVirtualPrivateCloudPresent mocks base method.
 func (m *MockCloudInterface) IsVirtualPrivateCloudPresent(uniqueIdentifier string) bool {
 	m.ctrl.T.Helper()
@@ -197,15 +283,29 @@ func (mr *MockCloudInterfaceMockRecorder) ProviderType() *gomock.Call {
 }
 
 // RemoveAccountResourcesSelector mocks base method.
-func (m *MockCloudInterface) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selector string) {
+func (m *MockCloudInterface) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "RemoveAccountResourcesSelector", accNamespacedName, selector)
+	m.ctrl.Call(m, "RemoveAccountResourcesSelector", accNamespacedName, selectorNamespacedName)
 }
 
 // RemoveAccountResourcesSelector indicates an expected call of RemoveAccountResourcesSelector.
-func (mr *MockCloudInterfaceMockRecorder) RemoveAccountResourcesSelector(accNamespacedName, selector interface{}) *gomock.Call {
+func (mr *MockCloudInterfaceMockRecorder) RemoveAccountResourcesSelector(accNamespacedName, selectorNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccountResourcesSelector", reflect.TypeOf((*MockCloudInterface)(nil).RemoveAccountResourcesSelector), accNamespacedName, selectorNamespacedName)
+}
+
+// ProbeProviderAccountCredentials mocks base method.
+func (m *MockCloudInterface) ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeProviderAccountCredentials", client, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProbeProviderAccountCredentials indicates an expected call of ProbeProviderAccountCredentials.
+func (mr *MockCloudInterfaceMockRecorder) ProbeProviderAccountCredentials(client, account interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccountResourcesSelector", reflect.TypeOf((*MockCloudInterface)(nil).RemoveAccountResourcesSelector), accNamespacedName, selector)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeProviderAccountCredentials", reflect.TypeOf((*MockCloudInterface)(nil).ProbeProviderAccountCredentials), client, account)
 }
 
 // RemoveProviderAccount mocks base method.
@@ -285,6 +385,20 @@ func (mr *MockAccountMgmtInterfaceMockRecorder) AddAccountResourceSelector(accNa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountResourceSelector", reflect.TypeOf((*MockAccountMgmtInterface)(nil).AddAccountResourceSelector), accNamespacedName, selector)
 }
 
+// AddAccountResourceSelectorWithSnapshot mocks base method.
+func (m *MockAccountMgmtInterface) AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector, vms []*v1alpha1.VirtualMachine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountResourceSelectorWithSnapshot", accNamespacedName, selector, vms)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccountResourceSelectorWithSnapshot indicates an expected call of AddAccountResourceSelectorWithSnapshot.
+func (mr *MockAccountMgmtInterfaceMockRecorder) AddAccountResourceSelectorWithSnapshot(accNamespacedName, selector, vms interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountResourceSelectorWithSnapshot", reflect.TypeOf((*MockAccountMgmtInterface)(nil).AddAccountResourceSelectorWithSnapshot), accNamespacedName, selector, vms)
+}
+
 // AddProviderAccount mocks base method.
 func (m *MockAccountMgmtInterface) AddProviderAccount(client client.Client, account *v1alpha1.CloudProviderAccount) error {
 	m.ctrl.T.Helper()
@@ -315,15 +429,29 @@ func (mr *MockAccountMgmtInterfaceMockRecorder) GetAccountStatus(accNamespacedNa
 }
 
 // RemoveAccountResourcesSelector mocks base method.
-func (m *MockAccountMgmtInterface) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selector string) {
+func (m *MockAccountMgmtInterface) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "RemoveAccountResourcesSelector", accNamespacedName, selector)
+	m.ctrl.Call(m, "RemoveAccountResourcesSelector", accNamespacedName, selectorNamespacedName)
 }
 
 // RemoveAccountResourcesSelector indicates an expected call of RemoveAccountResourcesSelector.
-func (mr *MockAccountMgmtInterfaceMockRecorder) RemoveAccountResourcesSelector(accNamespacedName, selector interface{}) *gomock.Call {
+func (mr *MockAccountMgmtInterfaceMockRecorder) RemoveAccountResourcesSelector(accNamespacedName, selectorNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccountResourcesSelector", reflect.TypeOf((*MockAccountMgmtInterface)(nil).RemoveAccountResourcesSelector), accNamespacedName, selectorNamespacedName)
+}
+
+// ProbeProviderAccountCredentials mocks base method.
+func (m *MockAccountMgmtInterface) ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeProviderAccountCredentials", client, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProbeProviderAccountCredentials indicates an expected call of ProbeProviderAccountCredentials.
+func (mr *MockAccountMgmtInterfaceMockRecorder) ProbeProviderAccountCredentials(client, account interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccountResourcesSelector", reflect.TypeOf((*MockAccountMgmtInterface)(nil).RemoveAccountResourcesSelector), accNamespacedName, selector)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeProviderAccountCredentials", reflect.TypeOf((*MockAccountMgmtInterface)(nil).ProbeProviderAccountCredentials), client, account)
 }
 
 // RemoveProviderAccount mocks base method.
@@ -361,6 +489,20 @@ func (m *MockComputeInterface) EXPECT() *MockComputeInterfaceMockRecorder {
 	return m.recorder
 }
 
+// GetVirtualPrivateCloudAccount mocks base method.
+func (m *MockComputeInterface) GetVirtualPrivateCloudAccount(uniqueIdentifier string) *types.NamespacedName {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualPrivateCloudAccount", uniqueIdentifier)
+	ret0, _ := ret[0].(*types.NamespacedName)
+	return ret0
+}
+
+// GetVirtualPrivateCloudAccount indicates an expected call of GetVirtualPrivateCloudAccount.
+func (mr *MockComputeInterfaceMockRecorder) GetVirtualPrivateCloudAccount(uniqueIdentifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualPrivateCloudAccount", reflect.TypeOf((*MockComputeInterface)(nil).GetVirtualPrivateCloudAccount), uniqueIdentifier)
+}
+
 // Instances mocks base method.
 func (m *MockComputeInterface) Instances() ([]*v1alpha1.VirtualMachine, error) {
 	m.ctrl.T.Helper()
@@ -391,6 +533,21 @@ func (mr *MockComputeInterfaceMockRecorder) InstancesGivenProviderAccount(namesp
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstancesGivenProviderAccount", reflect.TypeOf((*MockComputeInterface)(nil).InstancesGivenProviderAccount), namespacedName)
 }
 
+// InstancesGivenProviderAccountSelector mocks base method.
+func (m *MockComputeInterface) InstancesGivenProviderAccountSelector(accNamespacedName, selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstancesGivenProviderAccountSelector", accNamespacedName, selectorNamespacedName)
+	ret0, _ := ret[0].([]*v1alpha1.VirtualMachine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InstancesGivenProviderAccountSelector indicates an expected call of InstancesGivenProviderAccountSelector.
+func (mr *MockComputeInterfaceMockRecorder) InstancesGivenProviderAccountSelector(accNamespacedName, selectorNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstancesGivenProviderAccountSelector", reflect.TypeOf((*MockComputeInterface)(nil).InstancesGivenProviderAccountSelector), accNamespacedName, selectorNamespacedName)
+}
+
 // IsVirtualPrivateCloudPresent mocks base method.
 func (m *MockComputeInterface) IsVirtualPrivateCloudPresent(uniqueIdentifier string) bool {
 	m.ctrl.T.Helper()
@@ -457,6 +614,20 @@ func (mr *MockSecurityInterfaceMockRecorder) DeleteSecurityGroup(addressGroupIde
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecurityGroup", reflect.TypeOf((*MockSecurityInterface)(nil).DeleteSecurityGroup), addressGroupIdentifier, membershipOnly)
 }
 
+// GetAccountEnforcedSecurity mocks base method.
+func (m *MockSecurityInterface) GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountEnforcedSecurity", accNamespacedName)
+	ret0, _ := ret[0].([]securitygroup.SynchronizationContent)
+	return ret0
+}
+
+// GetAccountEnforcedSecurity indicates an expected call of GetAccountEnforcedSecurity.
+func (mr *MockSecurityInterfaceMockRecorder) GetAccountEnforcedSecurity(accNamespacedName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountEnforcedSecurity", reflect.TypeOf((*MockSecurityInterface)(nil).GetAccountEnforcedSecurity), accNamespacedName)
+}
+
 // GetEnforcedSecurity mocks base method.
 func (m *MockSecurityInterface) GetEnforcedSecurity() []securitygroup.SynchronizationContent {
 	m.ctrl.T.Helper()
@@ -471,6 +642,35 @@ func (mr *MockSecurityInterfaceMockRecorder) GetEnforcedSecurity() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEnforcedSecurity", reflect.TypeOf((*MockSecurityInterface)(nil).GetEnforcedSecurity))
 }
 
+// GetVpcSecurityGroups mocks base method.
+func (m *MockSecurityInterface) GetVpcSecurityGroups(accNamespacedName *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVpcSecurityGroups", accNamespacedName, vpcID)
+	ret0, _ := ret[0].([]securitygroup.ImportContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVpcSecurityGroups indicates an expected call of GetVpcSecurityGroups.
+func (mr *MockSecurityInterfaceMockRecorder) GetVpcSecurityGroups(accNamespacedName, vpcID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVpcSecurityGroups", reflect.TypeOf((*MockSecurityInterface)(nil).GetVpcSecurityGroups), accNamespacedName, vpcID)
+}
+
+// IsRuleActionSupported mocks base method.
+func (m *MockSecurityInterface) IsRuleActionSupported(action securitygroup.RuleAction) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRuleActionSupported", action)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRuleActionSupported indicates an expected call of IsRuleActionSupported.
+func (mr *MockSecurityInterfaceMockRecorder) IsRuleActionSupported(action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRuleActionSupported", reflect.TypeOf((*MockSecurityInterface)(nil).IsRuleActionSupported), action)
+}
+
 // UpdateSecurityGroupMembers mocks base method.
 func (m *MockSecurityInterface) UpdateSecurityGroupMembers(addressGroupIdentifier *securitygroup.CloudResourceID, computeResourceIdentifier []*securitygroup.CloudResource, membershipOnly bool) error {
 	m.ctrl.T.Helper()
@@ -54,12 +54,18 @@ type CloudInterface interface {
 type AccountMgmtInterface interface {
 	// AddProviderAccount adds and initializes given account of a cloud provider.
 	AddProviderAccount(client client.Client, account *v1alpha1.CloudProviderAccount) error
+	// ProbeProviderAccountCredentials checks cloud credentials of given account of a cloud provider, without adding it.
+	ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error
 	// RemoveProviderAccount removes and cleans up any resources of given account of a cloud provider.
 	RemoveProviderAccount(namespacedName *types.NamespacedName)
 	// AddAccountResourceSelector adds account specific resource selector.
 	AddAccountResourceSelector(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector) error
+	// AddAccountResourceSelectorWithSnapshot adds account specific resource selector without waiting for inventory of
+	// the account. Until the first inventory succeeds, resources of the selector are served from the given snapshot.
+	AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector,
+		vms []*v1alpha1.VirtualMachine) error
 	// RemoveAccountResourcesSelector removes account specific resource selector.
-	RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selector string)
+	RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName)
 	// GetAccountStatus gets accounts status.
 	GetAccountStatus(accNamespacedName *types.NamespacedName) (*cloudv1alpha1.CloudProviderAccountStatus, error)
 }
@@ -70,8 +76,15 @@ type ComputeInterface interface {
 	Instances() ([]*v1alpha1.VirtualMachine, error)
 	// InstancesGivenProviderAccount returns VirtualMachineStatus for a given account of a cloud provider.
 	InstancesGivenProviderAccount(namespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error)
+	// InstancesGivenProviderAccountSelector returns VirtualMachineStatus for a given account of a cloud provider matched by the
+	// given selector, in the selector namespace.
+	InstancesGivenProviderAccountSelector(accNamespacedName *types.NamespacedName,
+		selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error)
 	// IsVirtualPrivateCloudPresent returns true if given virtual private cloud uniqueIdentifier is managed by the cloud, else false.
 	IsVirtualPrivateCloudPresent(uniqueIdentifier string) bool
+	// GetVirtualPrivateCloudAccount returns the account managing given virtual private cloud uniqueIdentifier, nil if
+	// it is not managed by the cloud.
+	GetVirtualPrivateCloudAccount(uniqueIdentifier string) *types.NamespacedName
 }
 
 type SecurityInterface interface {
@@ -94,4 +107,12 @@ type SecurityInterface interface {
 	DeleteSecurityGroup(addressGroupIdentifier *securitygroup.CloudResourceID, membershipOnly bool) error
 	// GetEnforcedSecurity returns the cloud view of enforced security
 	GetEnforcedSecurity() []securitygroup.SynchronizationContent
+	// GetAccountEnforcedSecurity returns the cloud view of enforced security of an account, nil if account is not
+	// managed by cloud.
+	GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent
+	// IsRuleActionSupported returns true if cloud security group is able to enforce rules with provided action.
+	IsRuleActionSupported(action securitygroup.RuleAction) bool
+	// GetVpcSecurityGroups returns cloud security groups not created by nephe attached to compute resources of given
+	// virtual private cloud of an account, and security groups their rules refer to, for import as Antrea NetworkPolicies.
+	GetVpcSecurityGroups(accNamespacedName *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent, error)
 }
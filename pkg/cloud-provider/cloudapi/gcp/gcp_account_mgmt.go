@@ -0,0 +1,162 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+)
+
+type gcpAccountConfig struct {
+	v1alpha1.GCPAccountCredential
+	projectID string
+	region    string
+}
+
+// setAccountCredentials sets account credentials.
+func setAccountCredentials(client client.Client, credentials interface{}) (interface{}, error) {
+	gcpProviderConfig := credentials.(*v1alpha1.CloudProviderAccountGCPConfig)
+	accCred, err := extractSecret(client, gcpProviderConfig.SecretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// project from account spec takes precedence over project of the service account.
+	projectID := strings.TrimSpace(gcpProviderConfig.ProjectID)
+	if len(projectID) == 0 {
+		projectID = strings.TrimSpace(accCred.ProjectID)
+	}
+	if len(projectID) == 0 {
+		return nil, fmt.Errorf("project id not found in account config or service account credentials")
+	}
+
+	gcpConfig := &gcpAccountConfig{
+		GCPAccountCredential: *accCred,
+		projectID:            projectID,
+		region:               strings.TrimSpace(gcpProviderConfig.Region),
+	}
+
+	return gcpConfig, nil
+}
+
+// probeAccountCredentials checks account credentials by listing networks of the account project.
+func probeAccountCredentials(credentials interface{}, gcpSpecificHelper interface{}) error {
+	gcpServicesHelper := gcpSpecificHelper.(gcpServicesHelper)
+	gcpAccountCredentials := credentials.(*gcpAccountConfig)
+	gcpServiceClientCreator, err := gcpServicesHelper.newServiceSdkConfigProvider(gcpAccountCredentials)
+	if err != nil {
+		return err
+	}
+
+	computeWrapper, err := gcpServiceClientCreator.compute()
+	if err != nil {
+		return err
+	}
+	if _, err = computeWrapper.listNetworks(gcpAccountCredentials.projectID); err != nil {
+		return fmt.Errorf("unable to list networks of project %v: %v", gcpAccountCredentials.projectID, err)
+	}
+	return nil
+}
+
+func compareAccountCredentials(accountName string, existing interface{}, new interface{}) bool {
+	existingConfig := existing.(*gcpAccountConfig)
+	newConfig := new.(*gcpAccountConfig)
+
+	credsChanged := false
+	if strings.Compare(existingConfig.ClientEmail, newConfig.ClientEmail) != 0 {
+		credsChanged = true
+		gcpPluginLogger().Info("account service account email updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.PrivateKeyID, newConfig.PrivateKeyID) != 0 ||
+		strings.Compare(existingConfig.PrivateKey, newConfig.PrivateKey) != 0 {
+		credsChanged = true
+		gcpPluginLogger().Info("account service account key updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.projectID, newConfig.projectID) != 0 {
+		credsChanged = true
+		gcpPluginLogger().Info("account project updated", "account", accountName)
+	}
+	if strings.Compare(existingConfig.region, newConfig.region) != 0 {
+		credsChanged = true
+		gcpPluginLogger().Info("account region updated", "account", accountName)
+	}
+	return credsChanged
+}
+
+// extractSecret extracts credentials from a Kubernetes secret.
+func extractSecret(c client.Client, s *v1alpha1.SecretReference) (*v1alpha1.GCPAccountCredential, error) {
+	if s == nil {
+		return nil, fmt.Errorf("secret reference not found")
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "",
+		Kind:    "Secret",
+		Version: "v1",
+	})
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, u); err != nil {
+		return nil, err
+	}
+
+	data := u.Object["data"].(map[string]interface{})
+	decode, err := base64.StdEncoding.DecodeString(data[s.Key].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	cred := &v1alpha1.GCPAccountCredential{}
+	if err = json.Unmarshal(decode, cred); err != nil {
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// getVpcAccount returns first found account config to which this vpc id belongs.
+func (c *gcpCloud) getVpcAccount(vpcID string) internal.CloudAccountInterface {
+	accCfgs := c.cloudCommon.GetCloudAccounts()
+	if len(accCfgs) == 0 {
+		return nil
+	}
+
+	for _, accCfg := range accCfgs {
+		computeServiceCfg, err := accCfg.GetServiceConfigByName(gcpComputeServiceNameCompute)
+		if err != nil {
+			gcpPluginLogger().Error(err, "get compute service config failed", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+			continue
+		}
+		accVpcIDs := computeServiceCfg.(*computeServiceConfig).getCachedVpcIDs()
+		if len(accVpcIDs) == 0 {
+			gcpPluginLogger().Info("no vpc found for account", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+			continue
+		}
+		if _, found := accVpcIDs[strings.ToLower(vpcID)]; found {
+			return accCfg
+		}
+		gcpPluginLogger().Info("vpcID not found in cache", "vpcID", vpcID, "account", accCfg.GetNamespacedName())
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	gcpOperationStatusDone = "DONE"
+)
+
+// isGcpAuthError returns whether an api call failed as GCP rejected the account credentials, with HTTP status 401 or
+// 403.
+func isGcpAuthError(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden)
+}
+
+// gcpComputeWrapper is layer above gcp compute sdk apis to allow for unit-testing.
+type gcpComputeWrapper interface {
+	// instances
+	aggregatedListInstances(project string, filter string) ([]*compute.Instance, error)
+	setInstanceTags(project string, zone string, instance string, tags *compute.Tags) error
+
+	// networks
+	listNetworks(project string) ([]*compute.Network, error)
+
+	// firewalls
+	listFirewalls(project string) ([]*compute.Firewall, error)
+	insertFirewall(project string, firewall *compute.Firewall) error
+	updateFirewall(project string, firewall *compute.Firewall) error
+	deleteFirewall(project string, name string) error
+}
+type gcpComputeWrapperImpl struct {
+	compute *compute.Service
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) aggregatedListInstances(project string, filter string) ([]*compute.Instance, error) {
+	var instances []*compute.Instance
+	call := computeWrapper.compute.Instances.AggregatedList(project)
+	if len(filter) > 0 {
+		call = call.Filter(filter)
+	}
+	err := call.Pages(context.Background(), func(page *compute.InstanceAggregatedList) error {
+		for _, scopedList := range page.Items {
+			instances = append(instances, scopedList.Instances...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing gcp instances : %w", err)
+	}
+	return instances, nil
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) setInstanceTags(project string, zone string, instance string, tags *compute.Tags) error {
+	op, err := computeWrapper.compute.Instances.SetTags(project, zone, instance, tags).Do()
+	if err != nil {
+		return err
+	}
+	return computeWrapper.waitForZoneOperation(project, zone, op)
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) listNetworks(project string) ([]*compute.Network, error) {
+	var networks []*compute.Network
+	err := computeWrapper.compute.Networks.List(project).Pages(context.Background(), func(page *compute.NetworkList) error {
+		networks = append(networks, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing gcp networks : %w", err)
+	}
+	return networks, nil
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) listFirewalls(project string) ([]*compute.Firewall, error) {
+	var firewalls []*compute.Firewall
+	err := computeWrapper.compute.Firewalls.List(project).Pages(context.Background(), func(page *compute.FirewallList) error {
+		firewalls = append(firewalls, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing gcp firewalls : %w", err)
+	}
+	return firewalls, nil
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) insertFirewall(project string, firewall *compute.Firewall) error {
+	op, err := computeWrapper.compute.Firewalls.Insert(project, firewall).Do()
+	if err != nil {
+		return err
+	}
+	return computeWrapper.waitForGlobalOperation(project, op)
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) updateFirewall(project string, firewall *compute.Firewall) error {
+	op, err := computeWrapper.compute.Firewalls.Update(project, firewall.Name, firewall).Do()
+	if err != nil {
+		return err
+	}
+	return computeWrapper.waitForGlobalOperation(project, op)
+}
+
+func (computeWrapper *gcpComputeWrapperImpl) deleteFirewall(project string, name string) error {
+	op, err := computeWrapper.compute.Firewalls.Delete(project, name).Do()
+	if err != nil {
+		return err
+	}
+	return computeWrapper.waitForGlobalOperation(project, op)
+}
+
+// waitForGlobalOperation blocks till global operation (like firewall changes) is complete.
+func (computeWrapper *gcpComputeWrapperImpl) waitForGlobalOperation(project string, op *compute.Operation) error {
+	var err error
+	for op.Status != gcpOperationStatusDone {
+		op, err = computeWrapper.compute.GlobalOperations.Wait(project, op.Name).Do()
+		if err != nil {
+			return err
+		}
+	}
+	return convertOperationError(op)
+}
+
+// waitForZoneOperation blocks till zonal operation (like instance changes) is complete.
+func (computeWrapper *gcpComputeWrapperImpl) waitForZoneOperation(project string, zone string, op *compute.Operation) error {
+	var err error
+	for op.Status != gcpOperationStatusDone {
+		op, err = computeWrapper.compute.ZoneOperations.Wait(project, zone, op.Name).Do()
+		if err != nil {
+			return err
+		}
+	}
+	return convertOperationError(op)
+}
+
+func convertOperationError(op *compute.Operation) error {
+	if op.Error == nil || len(op.Error.Errors) == 0 {
+		return nil
+	}
+	opErr := op.Error.Errors[0]
+	return fmt.Errorf("gcp operation %v failed [code: %v, message: %v]", op.Name, opErr.Code, opErr.Message)
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import "antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+
+type gcpCloudCommonHelperImpl struct{}
+
+func (h *gcpCloudCommonHelperImpl) GetCloudServicesCreateFunc() internal.CloudServiceConfigCreatorFunc {
+	return newGcpServiceConfigs
+}
+
+func (h *gcpCloudCommonHelperImpl) SetAccountCredentialsFunc() internal.CloudCredentialValidatorFunc {
+	return setAccountCredentials
+}
+
+func (h *gcpCloudCommonHelperImpl) GetCloudCredentialsComparatorFunc() internal.CloudCredentialComparatorFunc {
+	return compareAccountCredentials
+}
+
+func (h *gcpCloudCommonHelperImpl) GetCloudCredentialsProbeFunc() internal.CloudCredentialProbeFunc {
+	return probeAccountCredentials
+}
+
+// GetCloudEventQueueCreateFunc returns nil, event queues are not supported for GCP accounts.
+func (h *gcpCloudCommonHelperImpl) GetCloudEventQueueCreateFunc() internal.CloudEventQueueCreatorFunc {
+	return nil
+}
+
+func (h *gcpCloudCommonHelperImpl) GetCloudAuthErrorFunc() internal.CloudAuthErrorFunc {
+	return isGcpAuthError
+}
+
+// GetCloudServicesRefreshFunc returns nil, services of GCP accounts change with account updates only.
+func (h *gcpCloudCommonHelperImpl) GetCloudServicesRefreshFunc() internal.CloudServicesRefreshFunc {
+	return nil
+}
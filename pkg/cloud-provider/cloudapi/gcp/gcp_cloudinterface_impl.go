@@ -0,0 +1,137 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/logging"
+)
+
+var gcpPluginLogger = func() logging.Logger {
+	return logging.GetLogger("gcp-plugin")
+}
+
+const (
+	providerType = cloudcommon.ProviderType(v1alpha1.GCPCloudProvider)
+)
+
+// gcpCloud implements CloudInterface for GCP.
+type gcpCloud struct {
+	cloudCommon internal.CloudCommonInterface
+}
+
+// newGCPCloud creates a new instance of gcpCloud.
+func newGCPCloud(gcpSpecificHelper gcpServicesHelper) *gcpCloud {
+	gcpCloud := &gcpCloud{
+		cloudCommon: internal.NewCloudCommon(gcpPluginLogger, &gcpCloudCommonHelperImpl{}, gcpSpecificHelper),
+	}
+	return gcpCloud
+}
+
+// Register registers cloud provider type and creates gcpCloud object for the provider. Any cloud account added at later
+// point with this cloud provider using CloudInterface API will get added to this gcpCloud object.
+func Register() cloudcommon.CloudInterface {
+	return newGCPCloud(&gcpServicesHelperImpl{})
+}
+
+// ProviderType returns the cloud provider type (aws, azure, gce etc).
+func (c *gcpCloud) ProviderType() cloudcommon.ProviderType {
+	return providerType
+}
+
+// /////////////////////////////////////////////
+// 	ComputeInterface Implementation
+// /////////////////////////////////////////////.
+// Instances returns VM status for all instances across all accounts of a cloud provider.
+func (c *gcpCloud) Instances() ([]*v1alpha1.VirtualMachine, error) {
+	vmCRDs, err := c.cloudCommon.GetAllCloudAccountsComputeResourceCRDs()
+	return vmCRDs, err
+}
+
+// InstancesGivenProviderAccount returns VM CRD for all instances of a given cloud provider account.
+func (c *gcpCloud) InstancesGivenProviderAccount(accountNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine,
+	error) {
+	vmCRDs, err := c.cloudCommon.GetCloudAccountComputeResourceCRDs(accountNamespacedName)
+	return vmCRDs, err
+}
+
+// InstancesGivenProviderAccountSelector returns VM CRD for instances of a given cloud provider account matched by the selector.
+func (c *gcpCloud) InstancesGivenProviderAccountSelector(accountNamespacedName *types.NamespacedName,
+	selectorNamespacedName *types.NamespacedName) ([]*v1alpha1.VirtualMachine, error) {
+	vmCRDs, err := c.cloudCommon.GetCloudAccountSelectorComputeResourceCRDs(accountNamespacedName, selectorNamespacedName)
+	return vmCRDs, err
+}
+
+// IsVirtualPrivateCloudPresent returns true if given ID is managed by the cloud, else false.
+func (c *gcpCloud) IsVirtualPrivateCloudPresent(vpcUniqueIdentifier string) bool {
+	if accCfg := c.getVpcAccount(vpcUniqueIdentifier); accCfg == nil {
+		return false
+	}
+	return true
+}
+
+// GetVirtualPrivateCloudAccount returns the account managing given ID, nil if it is not managed by the cloud.
+func (c *gcpCloud) GetVirtualPrivateCloudAccount(vpcUniqueIdentifier string) *types.NamespacedName {
+	accCfg := c.getVpcAccount(vpcUniqueIdentifier)
+	if accCfg == nil {
+		return nil
+	}
+	return accCfg.GetNamespacedName()
+}
+
+// ////////////////////////////////////////////////////////
+// 	AccountMgmtInterface Implementation
+// ////////////////////////////////////////////////////////
+// AddProviderAccount adds and initializes given account of a cloud provider.
+func (c *gcpCloud) AddProviderAccount(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	return c.cloudCommon.AddCloudAccount(client, account, account.Spec.GCPConfig)
+}
+
+// ProbeProviderAccountCredentials checks cloud credentials of given account of a cloud provider, without adding it.
+func (c *gcpCloud) ProbeProviderAccountCredentials(client client.Client, account *v1alpha1.CloudProviderAccount) error {
+	return c.cloudCommon.ProbeCloudAccountCredentials(client, account.Spec.GCPConfig)
+}
+
+// RemoveProviderAccount removes and cleans up any resources of given account of a cloud provider.
+func (c *gcpCloud) RemoveProviderAccount(namespacedName *types.NamespacedName) {
+	c.cloudCommon.RemoveCloudAccount(namespacedName)
+}
+
+// AddAccountResourceSelector adds account specific resource selector.
+func (c *gcpCloud) AddAccountResourceSelector(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector) error {
+	return c.cloudCommon.AddSelector(accNamespacedName, selector)
+}
+
+// AddAccountResourceSelectorWithSnapshot adds account specific resource selector, serving its resources from the snapshot
+// until the first inventory of the account.
+func (c *gcpCloud) AddAccountResourceSelectorWithSnapshot(accNamespacedName *types.NamespacedName, selector *v1alpha1.CloudEntitySelector,
+	vms []*v1alpha1.VirtualMachine) error {
+	return c.cloudCommon.AddSelectorWithSnapshot(accNamespacedName, selector, vms)
+}
+
+// RemoveAccountResourcesSelector removes account specific resource selector.
+func (c *gcpCloud) RemoveAccountResourcesSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
+	c.cloudCommon.RemoveSelector(accNamespacedName, selectorNamespacedName)
+}
+
+func (c *gcpCloud) GetAccountStatus(accNamespacedName *types.NamespacedName) (*cloudv1alpha1.CloudProviderAccountStatus, error) {
+	return c.cloudCommon.GetStatus(accNamespacedName)
+}
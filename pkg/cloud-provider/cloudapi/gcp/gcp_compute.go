@@ -0,0 +1,411 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/api/compute/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+)
+
+type computeServiceConfig struct {
+	accountName    string
+	projectID      string
+	region         string
+	apiClient      gcpComputeWrapper
+	resourcesCache *internal.CloudServiceResourcesCache
+	inventoryStats *internal.CloudServiceStats
+	// instanceFilters is keyed by selector namespaced name and has following possible values
+	// - empty map indicates no selectors configured for this account. NO cloud api call for inventory will be made.
+	// - non-empty map indicates selectors are configured. Cloud api call for inventory will be made.
+	//	 - key with nil value indicates no filters. Get all instances for account.
+	//   - key with non-nil value indicates some filter. Get instances matching those filters only.
+	instanceFilters map[types.NamespacedName][]*gcpInstanceFilter
+}
+
+// computeResourcesCacheSnapshot holds the results from querying for all instances.
+type computeResourcesCacheSnapshot struct {
+	instances map[cloudcommon.InstanceID]*compute.Instance
+	vpcIDs    map[string]struct{}
+	// networks is keyed by network self link.
+	networks map[string]*compute.Network
+	// selectorInstances holds IDs of instances matched by each selector.
+	selectorInstances map[types.NamespacedName][]cloudcommon.InstanceID
+}
+
+func newComputeServiceConfig(name string, projectID string, region string, service gcpServiceClientCreateInterface) (
+	internal.CloudServiceInterface, error) {
+	// create compute sdk api client
+	apiClient, err := service.compute()
+	if err != nil {
+		return nil, fmt.Errorf("error creating compute sdk api client for account : %v, err: %v", name, err)
+	}
+
+	config := &computeServiceConfig{
+		apiClient:       apiClient,
+		accountName:     name,
+		projectID:       projectID,
+		region:          region,
+		resourcesCache:  &internal.CloudServiceResourcesCache{},
+		inventoryStats:  &internal.CloudServiceStats{},
+		instanceFilters: make(map[types.NamespacedName][]*gcpInstanceFilter),
+	}
+	return config, nil
+}
+
+func (computeCfg *computeServiceConfig) waitForInventoryInit(duration time.Duration) error {
+	operation := func() error {
+		done := computeCfg.inventoryStats.IsInventoryInitialized()
+		if !done {
+			return fmt.Errorf("inventory for account %v not initialized (waited %v duration)", computeCfg.accountName, duration)
+		}
+		return nil
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = duration
+
+	return backoff.Retry(operation, b)
+}
+
+// getInstanceResourceFilters returns filters to be applied to instances list api if filters are configured.
+// Otherwise returns (nil, false). false indicates no selectors configured for the account and hence no cloud api needs
+// to be made for instance inventory.
+func (computeCfg *computeServiceConfig) getInstanceResourceFilters() ([]*gcpInstanceFilter, bool) {
+	var allFilters []*gcpInstanceFilter
+
+	instanceFilters := computeCfg.instanceFilters
+	if len(instanceFilters) == 0 {
+		return nil, false
+	}
+
+	for _, filters := range computeCfg.instanceFilters {
+		// if any selector found with nil filter, skip all other selectors. As nil indicates all
+		if len(filters) == 0 {
+			return nil, true
+		}
+		allFilters = append(allFilters, filters...)
+	}
+	return allFilters, true
+}
+
+// getCachedInstances returns instances from the cache for the account.
+func (computeCfg *computeServiceConfig) getCachedInstances() []*compute.Instance {
+	snapshot := computeCfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		gcpPluginLogger().V(4).Info("cache snapshot nil", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName)
+		return []*compute.Instance{}
+	}
+	instances := snapshot.(*computeResourcesCacheSnapshot).instances
+	instancesToReturn := make([]*compute.Instance, 0, len(instances))
+	for _, instance := range instances {
+		instancesToReturn = append(instancesToReturn, instance)
+	}
+	gcpPluginLogger().V(1).Info("cached instances", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName,
+		"instances", len(instancesToReturn))
+	return instancesToReturn
+}
+
+// getCachedSelectorInstances returns instances matched by the selector from the cache for the account.
+func (computeCfg *computeServiceConfig) getCachedSelectorInstances(selectorNamespacedName *types.NamespacedName) []*compute.Instance {
+	snapshot := computeCfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		gcpPluginLogger().V(4).Info("cache snapshot nil", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName)
+		return []*compute.Instance{}
+	}
+	instances := snapshot.(*computeResourcesCacheSnapshot).instances
+	instanceIDs := snapshot.(*computeResourcesCacheSnapshot).selectorInstances[*selectorNamespacedName]
+	instancesToReturn := make([]*compute.Instance, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		if instance, found := instances[id]; found {
+			instancesToReturn = append(instancesToReturn, instance)
+		}
+	}
+	return instancesToReturn
+}
+
+// getCachedVpcIDs returns vpcIDs from the cache for the account.
+func (computeCfg *computeServiceConfig) getCachedVpcIDs() map[string]struct{} {
+	vpcIDsCopy := make(map[string]struct{})
+	snapshot := computeCfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		gcpPluginLogger().V(4).Info("cache snapshot nil", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName)
+		return vpcIDsCopy
+	}
+	vpcIDsSet := snapshot.(*computeResourcesCacheSnapshot).vpcIDs
+
+	for vpcID := range vpcIDsSet {
+		vpcIDsCopy[vpcID] = struct{}{}
+	}
+
+	return vpcIDsCopy
+}
+
+// getCachedNetworks returns the map of network self link to network from the cache.
+func (computeCfg *computeServiceConfig) getCachedNetworks() map[string]*compute.Network {
+	networksCopy := make(map[string]*compute.Network)
+	snapshot := computeCfg.resourcesCache.GetSnapshot()
+	if snapshot == nil {
+		gcpPluginLogger().V(4).Info("compute service cache snapshot nil", "type", providerType, "account", computeCfg.accountName)
+		return networksCopy
+	}
+	networks := snapshot.(*computeResourcesCacheSnapshot).networks
+
+	for k, v := range networks {
+		networksCopy[k] = v
+	}
+
+	return networksCopy
+}
+
+// getCachedNetworkByID returns network with given vpc ID from the cache.
+func (computeCfg *computeServiceConfig) getCachedNetworkByID(vpcID string) (*compute.Network, error) {
+	for _, network := range computeCfg.getCachedNetworks() {
+		if strings.EqualFold(getNetworkID(network), vpcID) {
+			return network, nil
+		}
+	}
+	return nil, fmt.Errorf("gcp network with id %v not found for account %v", vpcID, computeCfg.accountName)
+}
+
+// getInstances gets instances for the account from gcp compute API, keyed by the selector matching them. Only instances
+// in the zones of account region are returned.
+func (computeCfg *computeServiceConfig) getInstances(networks map[string]*compute.Network) (
+	map[types.NamespacedName][]*compute.Instance, error) {
+	selectorInstances := make(map[types.NamespacedName][]*compute.Instance)
+	// same filter expression may be used by more than one selector, query cloud only once for it.
+	expressionInstances := make(map[string][]*compute.Instance)
+	listInstances := func(expression string) ([]*compute.Instance, error) {
+		if instances, found := expressionInstances[expression]; found {
+			return instances, nil
+		}
+		instances, err := computeCfg.apiClient.aggregatedListInstances(computeCfg.projectID, expression)
+		if err != nil {
+			return nil, err
+		}
+		instances = computeCfg.filterInstancesByRegion(instances)
+		expressionInstances[expression] = instances
+		return instances, nil
+	}
+
+	for selector, filters := range computeCfg.instanceFilters {
+		if len(filters) == 0 {
+			instances, err := listInstances("")
+			if err != nil {
+				return nil, err
+			}
+			selectorInstances[selector] = instances
+			continue
+		}
+
+		var instances []*compute.Instance
+		instanceIDs := make(map[uint64]struct{})
+		for _, filter := range filters {
+			filterInstances, e := listInstances(filter.expression)
+			if e != nil {
+				return nil, e
+			}
+			for _, instance := range filterInstances {
+				if _, found := instanceIDs[instance.Id]; found {
+					continue
+				}
+				if !instanceMatchesNetworkFilter(instance, filter, networks) ||
+					!filter.matchesInstance(strconv.FormatUint(instance.Id, 10), instance.Name) {
+					continue
+				}
+				instanceIDs[instance.Id] = struct{}{}
+				instances = append(instances, instance)
+			}
+		}
+		selectorInstances[selector] = instances
+
+		gcpPluginLogger().V(1).Info("instances from cloud", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName,
+			"selector", selector, "instances", len(instances))
+	}
+
+	return selectorInstances, nil
+}
+
+// filterInstancesByRegion returns instances which belong to the zones of account region.
+func (computeCfg *computeServiceConfig) filterInstancesByRegion(instances []*compute.Instance) []*compute.Instance {
+	var regionInstances []*compute.Instance
+	for _, instance := range instances {
+		if strings.EqualFold(getRegionFromZone(getResourceNameFromURL(instance.Zone)), computeCfg.region) {
+			regionInstances = append(regionInstances, instance)
+		}
+	}
+	return regionInstances
+}
+
+// instanceMatchesNetworkFilter returns true if any of the instance network interfaces is in network matching the filter.
+func instanceMatchesNetworkFilter(instance *compute.Instance, filter *gcpInstanceFilter, networks map[string]*compute.Network) bool {
+	for _, nwIntf := range instance.NetworkInterfaces {
+		network, found := networks[nwIntf.Network]
+		if !found {
+			continue
+		}
+		if filter.matchesNetwork(getNetworkID(network), network.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DoResourceInventory gets inventory from cloud for given cloud account.
+func (computeCfg *computeServiceConfig) DoResourceInventory() error {
+	networks, e := computeCfg.buildMapNetworkSelfLinkToNetwork()
+	if e != nil {
+		gcpPluginLogger().V(0).Info("error fetching gcp networks", "account", computeCfg.accountName, "error", e)
+		return e
+	}
+
+	instances, e := computeCfg.getInstances(networks)
+	if e != nil {
+		gcpPluginLogger().V(0).Info("error fetching gcp instances", "account", computeCfg.accountName, "error", e)
+	} else {
+		exists := struct{}{}
+		vpcIDs := make(map[string]struct{})
+		instanceIDs := make(map[cloudcommon.InstanceID]*compute.Instance)
+		selectorInstanceIDs := make(map[types.NamespacedName][]cloudcommon.InstanceID)
+		for selector, selectorInstances := range instances {
+			for _, instance := range selectorInstances {
+				id := cloudcommon.InstanceID(strconv.FormatUint(instance.Id, 10))
+				instanceIDs[id] = instance
+				selectorInstanceIDs[selector] = append(selectorInstanceIDs[selector], id)
+				for _, nwIntf := range instance.NetworkInterfaces {
+					if network, found := networks[nwIntf.Network]; found {
+						vpcIDs[getNetworkID(network)] = exists
+					}
+				}
+			}
+		}
+		computeCfg.resourcesCache.UpdateSnapshot(&computeResourcesCacheSnapshot{instanceIDs, vpcIDs, networks, selectorInstanceIDs})
+		computeCfg.inventoryStats.UpdateInventoryResourceCount(len(instanceIDs))
+	}
+
+	return e
+}
+
+// SetResourceFilters add/updates instances resource filter for the service.
+func (computeCfg *computeServiceConfig) SetResourceFilters(selector *v1alpha1.CloudEntitySelector) {
+	if filters, found := convertSelectorToComputeInstanceFilters(selector); found {
+		computeCfg.instanceFilters[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}] = filters
+	} else {
+		if selector != nil {
+			delete(computeCfg.instanceFilters, types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name})
+		}
+		computeCfg.resourcesCache.UpdateSnapshot(nil)
+	}
+}
+
+// RemoveResourceFilters removes instances resource filter of the selector, filters of other selectors are kept.
+func (computeCfg *computeServiceConfig) RemoveResourceFilters(selectorNamespacedName *types.NamespacedName) {
+	delete(computeCfg.instanceFilters, *selectorNamespacedName)
+}
+
+func (computeCfg *computeServiceConfig) GetResourceCRDs(selectorNamespacedName *types.NamespacedName) *internal.CloudServiceResourceCRDs {
+	instances := computeCfg.getCachedSelectorInstances(selectorNamespacedName)
+	networks := computeCfg.getCachedNetworks()
+	vmCRDs := make([]*v1alpha1.VirtualMachine, 0, len(instances))
+	for _, instance := range instances {
+		// build VirtualMachine CRD
+		vmCRD := computeInstanceToVirtualMachineCRD(instance, networks, selectorNamespacedName.Namespace)
+		if vmCRD == nil {
+			continue
+		}
+		vmCRD.Status.Region = computeCfg.region
+		vmCRDs = append(vmCRDs, vmCRD)
+	}
+
+	gcpPluginLogger().V(1).Info("CRDs", "service", gcpComputeServiceNameCompute, "account", computeCfg.accountName,
+		"selector", selectorNamespacedName, "virtual-machine CRDs", len(vmCRDs))
+
+	serviceResourceCRDs := &internal.CloudServiceResourceCRDs{}
+	serviceResourceCRDs.SetComputeResourceCRDs(vmCRDs)
+
+	return serviceResourceCRDs
+}
+
+func (computeCfg *computeServiceConfig) HasFiltersConfigured() (bool, bool) {
+	filters, found := computeCfg.getInstanceResourceFilters()
+
+	return found, filters == nil
+}
+
+func (computeCfg *computeServiceConfig) GetName() internal.CloudServiceName {
+	return gcpComputeServiceNameCompute
+}
+
+func (computeCfg *computeServiceConfig) GetType() internal.CloudServiceType {
+	return internal.CloudServiceTypeCompute
+}
+
+func (computeCfg *computeServiceConfig) GetInventoryStats() *internal.CloudServiceStats {
+	return computeCfg.inventoryStats
+}
+
+func (computeCfg *computeServiceConfig) ResetCachedState() {
+	computeCfg.instanceFilters = make(map[types.NamespacedName][]*gcpInstanceFilter)
+	computeCfg.resourcesCache.UpdateSnapshot(nil)
+	computeCfg.inventoryStats.ResetInventoryPollStats()
+}
+
+func (computeCfg *computeServiceConfig) UpdateServiceConfig(newConfig internal.CloudServiceInterface) {
+	newComputeServiceConfig := newConfig.(*computeServiceConfig)
+	computeCfg.apiClient = newComputeServiceConfig.apiClient
+	computeCfg.projectID = newComputeServiceConfig.projectID
+	computeCfg.region = newComputeServiceConfig.region
+}
+
+func (computeCfg *computeServiceConfig) buildMapNetworkSelfLinkToNetwork() (map[string]*compute.Network, error) {
+	networks := make(map[string]*compute.Network)
+	result, err := computeCfg.apiClient.listNetworks(computeCfg.projectID)
+	if err != nil {
+		gcpPluginLogger().V(0).Info("error listing networks", "error", err)
+		return networks, err
+	}
+	for _, network := range result {
+		networks[network.SelfLink] = network
+	}
+	return networks, nil
+}
+
+// getNetworkID returns the vpc ID of gcp network, which is the unique numeric ID assigned to network by gcp.
+func getNetworkID(network *compute.Network) string {
+	return strconv.FormatUint(network.Id, 10)
+}
+
+// getResourceNameFromURL returns the resource name, which is the last segment of gcp resource URL.
+func getResourceNameFromURL(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// getRegionFromZone returns the region of a gcp zone. e.g. us-west1 for zone us-west1-a.
+func getRegionFromZone(zone string) string {
+	idx := strings.LastIndex(zone, "-")
+	if idx < 0 {
+		return zone
+	}
+	return zone[:idx]
+}
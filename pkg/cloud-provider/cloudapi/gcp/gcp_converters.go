@@ -0,0 +1,199 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+func convertToGcpFirewallAllowed(protocol *int, port *int, endPort *int) []*compute.FirewallAllowed {
+	allowed := &compute.FirewallAllowed{
+		IPProtocol: convertToGcpFirewallProtocol(protocol),
+	}
+	// ports can be specified only for tcp, udp and sctp protocols. no ports indicates all ports.
+	if port != nil && protocol != nil {
+		portRange := strconv.Itoa(*port)
+		if endPort != nil {
+			portRange = fmt.Sprintf("%v-%v", *port, *endPort)
+		}
+		allowed.Ports = []string{portRange}
+	}
+	return []*compute.FirewallAllowed{allowed}
+}
+
+func convertToGcpFirewallProtocol(protocol *int) string {
+	if protocol == nil {
+		return gcpAnyProtocolValue
+	}
+	for name, num := range securitygroup.ProtocolNameNumMap {
+		if num == *protocol {
+			return name
+		}
+	}
+	return strconv.Itoa(*protocol)
+}
+
+func convertToGcpFirewallRanges(ips []*net.IPNet) []string {
+	var ranges []string
+	for _, ip := range ips {
+		ranges = append(ranges, ip.String())
+	}
+	return ranges
+}
+
+func convertFromGcpFirewallRanges(ranges []string, excludedIPs map[string]struct{}) []*net.IPNet {
+	var ipNets []*net.IPNet
+	for _, cidr := range ranges {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if _, found := excludedIPs[ipNet.String()]; found {
+			continue
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets
+}
+
+func convertFromGcpFirewallProtocol(proto string) *int {
+	if strings.Compare(proto, gcpAnyProtocolValue) == 0 {
+		return nil
+	}
+	if protoNum, err := strconv.Atoi(proto); err == nil {
+		return &protoNum
+	}
+	protoNum := securitygroup.ProtocolNameNumMap[strings.ToLower(proto)]
+	return &protoNum
+}
+
+func convertFromGcpFirewallPorts(ports []string) (*int, *int) {
+	if len(ports) != 1 {
+		return nil, nil
+	}
+	portRange := strings.Split(ports[0], "-")
+	startPort, err := strconv.Atoi(portRange[0])
+	if err != nil {
+		return nil, nil
+	}
+	if len(portRange) != 2 || portRange[1] == portRange[0] {
+		return &startPort, nil
+	}
+	endPort, err := strconv.Atoi(portRange[1])
+	if err != nil {
+		return nil, nil
+	}
+	return &startPort, &endPort
+}
+
+// convertFromSourceTags converts address group network tags used in ingress firewall to address group identifiers.
+func convertFromSourceTags(tags []string, vpcID string) []*securitygroup.CloudResourceID {
+	var cloudResourceIDs []*securitygroup.CloudResourceID
+	for _, tag := range tags {
+		sgName, isAG, _ := securitygroup.IsNepheControllerCreatedSG(tag)
+		if !isAG {
+			sgName = tag
+		}
+		cloudResourceIDs = append(cloudResourceIDs, &securitygroup.CloudResourceID{
+			Name: sgName,
+			Vpc:  vpcID,
+		})
+	}
+	return cloudResourceIDs
+}
+
+// convertToFirewallDescription builds firewall description, recording address groups realized using member IPs. When
+// ipsOnly is set, address groups are recorded only for excluding their member IPs from the firewall ranges.
+func convertToFirewallDescription(peerGroups []*securitygroup.CloudResourceID, ipsOnly bool) string {
+	if len(peerGroups) == 0 {
+		return gcpFirewallDescription
+	}
+	var groups []string
+	for _, group := range peerGroups {
+		groups = append(groups, group.String())
+	}
+	key := gcpFirewallPeerGroupsKey
+	if ipsOnly {
+		key = gcpFirewallPeerGroupIPsKey
+	}
+	return gcpFirewallDescription + " " + key + strings.Join(groups, ",")
+}
+
+// convertFromFirewallDescription returns address groups recorded in firewall description with given key.
+func convertFromFirewallDescription(description string, key string) []*securitygroup.CloudResourceID {
+	idx := strings.Index(description, key)
+	if idx < 0 {
+		return nil
+	}
+	var cloudResourceIDs []*securitygroup.CloudResourceID
+	for _, group := range strings.Split(description[idx+len(key):], ",") {
+		fields := strings.SplitN(group, "/", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		cloudResourceIDs = append(cloudResourceIDs, &securitygroup.CloudResourceID{
+			Name: fields[0],
+			Vpc:  fields[1],
+		})
+	}
+	return cloudResourceIDs
+}
+
+func convertFromFirewallToIngressRule(firewall *compute.Firewall, vpcID string,
+	groupMemberIPs map[string]map[string]struct{}) securitygroup.IngressRule {
+	var ingressRule securitygroup.IngressRule
+
+	peerGroups := convertFromFirewallDescription(firewall.Description, gcpFirewallPeerGroupsKey)
+	excludedGroups := append(convertFromFirewallDescription(firewall.Description, gcpFirewallPeerGroupIPsKey), peerGroups...)
+	ingressRule.FromSrcIP = convertFromGcpFirewallRanges(firewall.SourceRanges, getPeerGroupsMemberIPs(excludedGroups, groupMemberIPs))
+	ingressRule.FromSecurityGroups = append(convertFromSourceTags(firewall.SourceTags, vpcID), peerGroups...)
+	if len(firewall.Allowed) > 0 {
+		ingressRule.Protocol = convertFromGcpFirewallProtocol(firewall.Allowed[0].IPProtocol)
+		ingressRule.FromPort, ingressRule.FromEndPort = convertFromGcpFirewallPorts(firewall.Allowed[0].Ports)
+	}
+	return ingressRule
+}
+
+func convertFromFirewallToEgressRule(firewall *compute.Firewall, groupMemberIPs map[string]map[string]struct{}) securitygroup.EgressRule {
+	var egressRule securitygroup.EgressRule
+
+	peerGroups := convertFromFirewallDescription(firewall.Description, gcpFirewallPeerGroupsKey)
+	excludedGroups := append(convertFromFirewallDescription(firewall.Description, gcpFirewallPeerGroupIPsKey), peerGroups...)
+	egressRule.ToDstIP = convertFromGcpFirewallRanges(firewall.DestinationRanges, getPeerGroupsMemberIPs(excludedGroups, groupMemberIPs))
+	egressRule.ToSecurityGroups = peerGroups
+	if len(firewall.Allowed) > 0 {
+		egressRule.Protocol = convertFromGcpFirewallProtocol(firewall.Allowed[0].IPProtocol)
+		egressRule.ToPort, egressRule.ToEndPort = convertFromGcpFirewallPorts(firewall.Allowed[0].Ports)
+	}
+	return egressRule
+}
+
+// getPeerGroupsMemberIPs returns the union of member IPs of given address groups.
+func getPeerGroupsMemberIPs(peerGroups []*securitygroup.CloudResourceID, groupMemberIPs map[string]map[string]struct{}) map[string]struct{} {
+	ips := make(map[string]struct{})
+	for _, group := range peerGroups {
+		for ip := range groupMemberIPs[group.String()] {
+			ips[ip] = struct{}{}
+		}
+	}
+	return ips
+}
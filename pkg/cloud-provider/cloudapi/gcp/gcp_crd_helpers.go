@@ -0,0 +1,147 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
+)
+
+var gcpStateMap = map[string]v1alpha1.VMState{
+	"PROVISIONING": v1alpha1.Starting,
+	"STAGING":      v1alpha1.Starting,
+	"RUNNING":      v1alpha1.Running,
+	"STOPPING":     v1alpha1.Stopping,
+	"SUSPENDING":   v1alpha1.Stopping,
+	"SUSPENDED":    v1alpha1.Stopped,
+	"TERMINATED":   v1alpha1.Stopped,
+	"REPAIRING":    v1alpha1.Unknown,
+}
+
+// computeInstanceToVirtualMachineCRD converts gcp compute instance to VirtualMachine CRD.
+func computeInstanceToVirtualMachineCRD(instance *compute.Instance, networks map[string]*compute.Network,
+	namespace string) *v1alpha1.VirtualMachine {
+	tags := make(map[string]string)
+	for key, value := range instance.Labels {
+		tags[key] = value
+	}
+
+	// Network interfaces associated with Virtual machine
+	instNetworkInterfaces := instance.NetworkInterfaces
+	networkInterfaces := make([]v1alpha1.NetworkInterface, 0, len(instNetworkInterfaces))
+	for _, nwInf := range instNetworkInterfaces {
+		var ipAddressCRDs []v1alpha1.IPAddress
+		if len(nwInf.NetworkIP) > 0 {
+			ipAddressCRD := v1alpha1.IPAddress{
+				AddressType: v1alpha1.AddressTypeInternalIP,
+				Address:     nwInf.NetworkIP,
+			}
+			ipAddressCRDs = append(ipAddressCRDs, ipAddressCRD)
+		}
+		if len(nwInf.Ipv6Address) > 0 {
+			ipAddressCRD := v1alpha1.IPAddress{
+				AddressType: v1alpha1.AddressTypeInternalIP,
+				Address:     nwInf.Ipv6Address,
+			}
+			ipAddressCRDs = append(ipAddressCRDs, ipAddressCRD)
+		}
+		for _, accessConfig := range nwInf.AccessConfigs {
+			if len(accessConfig.NatIP) == 0 {
+				continue
+			}
+			ipAddressCRD := v1alpha1.IPAddress{
+				AddressType: v1alpha1.AddressTypeExternalIP,
+				Address:     accessConfig.NatIP,
+			}
+			ipAddressCRDs = append(ipAddressCRDs, ipAddressCRD)
+		}
+		for _, accessConfig := range nwInf.Ipv6AccessConfigs {
+			if len(accessConfig.ExternalIpv6) == 0 {
+				continue
+			}
+			ipAddressCRD := v1alpha1.IPAddress{
+				AddressType: v1alpha1.AddressTypeExternalIP,
+				Address:     accessConfig.ExternalIpv6,
+			}
+			ipAddressCRDs = append(ipAddressCRDs, ipAddressCRD)
+		}
+
+		// gcp does not expose mac address of the network interface.
+		networkInterface := v1alpha1.NetworkInterface{
+			Name:   nwInf.Name,
+			IPs:    ipAddressCRDs,
+			Subnet: getResourceNameFromURL(nwInf.Subnetwork),
+		}
+		networkInterfaces = append(networkInterfaces, networkInterface)
+	}
+
+	// instance vpc is the network of its primary network interface.
+	if len(instNetworkInterfaces) == 0 {
+		gcpPluginLogger().Info("failed to create VirtualMachine CRD, no network interfaces found", "instance", instance.Name)
+		return nil
+	}
+	network, found := networks[instNetworkInterfaces[0].Network]
+	if !found {
+		gcpPluginLogger().Info("failed to create VirtualMachine CRD, network not found", "instance", instance.Name,
+			"network", instNetworkInterfaces[0].Network)
+		return nil
+	}
+
+	cloudName := instance.Name
+	cloudID := strconv.FormatUint(instance.Id, 10)
+	crdName := utils.GenerateShortResourceIdentifier(cloudID, cloudName)
+	cloudNetwork := getNetworkID(network)
+
+	state, found := gcpStateMap[instance.Status]
+	if !found {
+		state = v1alpha1.Unknown
+	}
+	vmCRD := utils.GenerateVirtualMachineCRD(crdName, cloudName, cloudID, namespace, cloudNetwork, cloudNetwork,
+		state, tags, networkInterfaces, providerType)
+	vmCRD.Status.Zone = getResourceNameFromURL(instance.Zone)
+	vmCRD.Status.Subnet = networkInterfaces[0].Subnet
+	vmCRD.Status.InstanceType = getResourceNameFromURL(instance.MachineType)
+	vmCRD.Status.OSType = v1alpha1.Linux
+	for _, disk := range instance.Disks {
+		if !disk.Boot {
+			continue
+		}
+		for _, license := range disk.Licenses {
+			if strings.Contains(getResourceNameFromURL(license), string(v1alpha1.Windows)) {
+				vmCRD.Status.OSType = v1alpha1.Windows
+			}
+		}
+	}
+	// network tags are the targets of firewall rules, and hence the security groups of an instance.
+	if instance.Tags != nil {
+		vmCRD.Status.SecurityGroups = append(vmCRD.Status.SecurityGroups, instance.Tags.Items...)
+		sort.Strings(vmCRD.Status.SecurityGroups)
+	}
+	launchTimestamp := instance.LastStartTimestamp
+	if len(launchTimestamp) == 0 {
+		launchTimestamp = instance.CreationTimestamp
+	}
+	if launchTime, err := time.Parse(time.RFC3339, launchTimestamp); err == nil {
+		vmCRD.Status.LaunchTime = utils.GenerateCRDTime(launchTime)
+	}
+	return vmCRD
+}
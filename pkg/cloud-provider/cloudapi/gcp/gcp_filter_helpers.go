@@ -0,0 +1,153 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"fmt"
+	"strings"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/utils"
+)
+
+// gcp instance resource filter keys.
+const (
+	gcpFilterKeyVMID   = "id"
+	gcpFilterKeyVMName = "name"
+)
+
+// gcpInstanceFilter is the instance filter built from a vm selector section. GCP list api filter expressions
+// cannot match on instance network, name pattern or exclusion, hence those are applied by the plugin on instances
+// returned by the api.
+type gcpInstanceFilter struct {
+	// expression is the server side filter expression, empty expression matches all instances.
+	expression string
+	// vpcID and vpcName are matched against instance network, empty value matches all networks.
+	vpcID   string
+	vpcName string
+	// vmNamePattern is matched against instance name, empty value matches all instances.
+	vmNamePattern string
+	// excludes are instances excluded from those matching the filter.
+	excludes []gcpExcludeMatch
+}
+
+// gcpExcludeMatch matches instances to exclude, empty value matches all instances.
+type gcpExcludeMatch struct {
+	vmID   string
+	vmName string
+}
+
+// convertSelectorToComputeInstanceFilters converts vm selector to gcp instance filters.
+func convertSelectorToComputeInstanceFilters(selector *v1alpha1.CloudEntitySelector) ([]*gcpInstanceFilter, bool) {
+	if selector == nil {
+		return nil, false
+	}
+	if selector.Spec.VMSelector == nil {
+		return nil, true
+	}
+
+	return buildGcpInstanceFilters(selector.Spec.VMSelector), true
+}
+
+// buildGcpInstanceFilters builds gcp instance filters for VirtualMachineSelector.
+// A nil return value indicates a select all entry is found.
+func buildGcpInstanceFilters(vmSelector []v1alpha1.VirtualMachineSelector) []*gcpInstanceFilter {
+	var filters []*gcpInstanceFilter
+	for _, match := range vmSelector {
+		var vpcID, vpcName string
+		if match.VpcMatch != nil {
+			vpcID = strings.TrimSpace(match.VpcMatch.MatchID)
+			vpcName = strings.TrimSpace(match.VpcMatch.MatchName)
+		}
+
+		var excludes []gcpExcludeMatch
+		for _, excludeMatch := range match.ExcludeMatch {
+			excludes = append(excludes, gcpExcludeMatch{
+				vmID:   strings.TrimSpace(excludeMatch.MatchID),
+				vmName: strings.TrimSpace(excludeMatch.MatchName),
+			})
+		}
+
+		// select all entry found. No need to process any other matches.
+		if len(vpcID) == 0 && len(vpcName) == 0 && len(match.VMMatch) == 0 && len(excludes) == 0 {
+			return nil
+		}
+
+		if len(match.VMMatch) == 0 {
+			filters = append(filters, &gcpInstanceFilter{vpcID: vpcID, vpcName: vpcName, excludes: excludes})
+			continue
+		}
+
+		for _, vmMatch := range match.VMMatch {
+			vmName := strings.TrimSpace(vmMatch.MatchName)
+			var vmNamePattern string
+			if utils.IsNamePattern(vmName) {
+				vmNamePattern = vmName
+				vmName = ""
+			}
+			filter := &gcpInstanceFilter{
+				expression:    buildGcpFilterExpression(strings.TrimSpace(vmMatch.MatchID), vmName),
+				vpcID:         vpcID,
+				vpcName:       vpcName,
+				vmNamePattern: vmNamePattern,
+				excludes:      excludes,
+			}
+			filters = append(filters, filter)
+		}
+	}
+
+	gcpPluginLogger().Info("selector stats", "filters", len(filters))
+	return filters
+}
+
+// buildGcpFilterExpression builds gcp list api filter expression matching instance id and/or name.
+func buildGcpFilterExpression(vmID string, vmName string) string {
+	var expressions []string
+	if len(vmID) > 0 {
+		expressions = append(expressions, fmt.Sprintf("(%v = %v)", gcpFilterKeyVMID, vmID))
+	}
+	if len(vmName) > 0 {
+		expressions = append(expressions, fmt.Sprintf("(%v = %q)", gcpFilterKeyVMName, vmName))
+	}
+	return strings.Join(expressions, " AND ")
+}
+
+// matchesNetwork returns true if the network matches vpc match criteria of the filter.
+func (f *gcpInstanceFilter) matchesNetwork(networkID string, networkName string) bool {
+	if len(f.vpcID) > 0 && !strings.EqualFold(f.vpcID, networkID) {
+		return false
+	}
+	if len(f.vpcName) > 0 && !utils.MatchNamePattern(strings.ToLower(f.vpcName), strings.ToLower(networkName)) {
+		return false
+	}
+	return true
+}
+
+// matchesInstance returns true if the instance matches name pattern of the filter, and does not match any excludes.
+func (f *gcpInstanceFilter) matchesInstance(instanceID string, instanceName string) bool {
+	if len(f.vmNamePattern) > 0 && !utils.MatchNamePattern(f.vmNamePattern, instanceName) {
+		return false
+	}
+	for _, exclude := range f.excludes {
+		if len(exclude.vmID) > 0 && exclude.vmID != instanceID {
+			continue
+		}
+		if len(exclude.vmName) > 0 && !utils.MatchNamePattern(exclude.vmName, instanceName) {
+			continue
+		}
+		return false
+	}
+	return true
+}
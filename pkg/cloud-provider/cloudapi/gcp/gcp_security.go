@@ -0,0 +1,821 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/api/compute/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+const (
+	gcpFirewallAllowPriority    = 1000
+	gcpFirewallDenyPriority     = 65000
+	gcpFirewallDirectionIngress = "INGRESS"
+	gcpFirewallDirectionEgress  = "EGRESS"
+	gcpFirewallDescription      = "Managed by nephe controller."
+	gcpFirewallPeerGroupsKey    = "peer-groups="
+	gcpFirewallPeerGroupIPsKey  = "peer-group-ips="
+	gcpFirewallSuffixIngress    = "in"
+	gcpFirewallSuffixEgress     = "eg"
+	gcpFirewallSuffixDeny       = "deny"
+	gcpFirewallSuffixIPv6       = "v6"
+	gcpAnyProtocolValue         = "all"
+	gcpAnyIPv4CIDR              = "0.0.0.0/0"
+	gcpAnyIPv6CIDR              = "::/0"
+
+	// gcpFirewallRuleSuffixMask keeps 5 hex digits of rule content hash, firewall names are limited to 63 characters.
+	gcpFirewallRuleSuffixMask = 0xfffff
+)
+
+var (
+	mutex sync.Mutex
+)
+
+// getFirewallNamePrefix returns name prefix of all firewalls created for an appliedTo group in a network. Firewalls
+// are global resources in a gcp project, hence network is encoded in the name along with the appliedTo group.
+func getFirewallNamePrefix(atCloudName string, vpcID string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(vpcID))
+	return fmt.Sprintf("%v-%08x", atCloudName, h.Sum32())
+}
+
+func getFirewallName(namePrefix string, direction string, suffix string) string {
+	return fmt.Sprintf("%v-%v-%v", namePrefix, direction, suffix)
+}
+
+func getFirewallIPv6Suffix(suffix string) string {
+	return fmt.Sprintf("%v-%v", suffix, gcpFirewallSuffixIPv6)
+}
+
+// getFirewallRuleSuffix returns the name suffix of the firewall of a rule and IP family, hashed from the rule content
+// instead of its index, so that reordering rules keeps their firewalls. Member IPs of peer groups are not part of the
+// content, as they are updated in place. A suffix already in usedSuffixes, e.g. of a duplicate rule, is rehashed.
+func getFirewallRuleSuffix(protocol, port, endPort *int, ipNets []*net.IPNet, groups []*securitygroup.CloudResourceID,
+	ipv6 bool, usedSuffixes map[string]struct{}) string {
+	intString := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	var ips, groupIDs []string
+	for _, ipNet := range ipNets {
+		ips = append(ips, ipNet.String())
+	}
+	for _, group := range groups {
+		groupIDs = append(groupIDs, strings.ToLower(group.String()))
+	}
+	content := fmt.Sprintf("%v/%v/%v/%v/%v/%v", intString(protocol), intString(port), intString(endPort),
+		sortedStrings(ips), sortedStrings(groupIDs), ipv6)
+	for i := 0; ; i++ {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(content))
+		if i > 0 {
+			_, _ = h.Write([]byte(strconv.Itoa(i)))
+		}
+		suffix := fmt.Sprintf("%05x", h.Sum32()&gcpFirewallRuleSuffixMask)
+		if _, found := usedSuffixes[suffix]; !found {
+			usedSuffixes[suffix] = struct{}{}
+			return suffix
+		}
+	}
+}
+
+// buildDenyAllFirewalls builds deny all ingress and egress firewalls for appliedTo group. A gcp firewall can not mix
+// IPv4 and IPv6 ranges, hence separate firewalls are built for each IP family.
+func buildDenyAllFirewalls(network *compute.Network, atCloudName string) []*compute.Firewall {
+	namePrefix := getFirewallNamePrefix(atCloudName, getNetworkID(network))
+	denied := []*compute.FirewallDenied{{IPProtocol: gcpAnyProtocolValue}}
+
+	var firewalls []*compute.Firewall
+	for _, anyCIDR := range []string{gcpAnyIPv4CIDR, gcpAnyIPv6CIDR} {
+		suffix := gcpFirewallSuffixDeny
+		if anyCIDR == gcpAnyIPv6CIDR {
+			suffix = getFirewallIPv6Suffix(suffix)
+		}
+		ingress := &compute.Firewall{
+			Name:         getFirewallName(namePrefix, gcpFirewallSuffixIngress, suffix),
+			Description:  gcpFirewallDescription,
+			Network:      network.SelfLink,
+			Direction:    gcpFirewallDirectionIngress,
+			Priority:     gcpFirewallDenyPriority,
+			Denied:       denied,
+			SourceRanges: []string{anyCIDR},
+			TargetTags:   []string{atCloudName},
+		}
+		egress := &compute.Firewall{
+			Name:              getFirewallName(namePrefix, gcpFirewallSuffixEgress, suffix),
+			Description:       gcpFirewallDescription,
+			Network:           network.SelfLink,
+			Direction:         gcpFirewallDirectionEgress,
+			Priority:          gcpFirewallDenyPriority,
+			Denied:            denied,
+			DestinationRanges: []string{anyCIDR},
+			TargetTags:        []string{atCloudName},
+		}
+		firewalls = append(firewalls, ingress, egress)
+	}
+	return firewalls
+}
+
+// buildIngressFirewalls builds allow firewalls for ingress rules. Address groups in the same network are realized
+// using source tags. Address groups in other (peered) networks are realized using IPs of their members, as source
+// tags are not applicable across networks. IPv6 ranges of a rule are realized using a separate firewall, which only
+// records address groups for excluding their member IPs, so that the address groups are reported once per rule.
+func buildIngressFirewalls(network *compute.Network, atCloudName string, rules []*securitygroup.IngressRule,
+	groupMemberIPs map[string]map[string]struct{}) []*compute.Firewall {
+	vpcID := getNetworkID(network)
+	namePrefix := getFirewallNamePrefix(atCloudName, vpcID)
+
+	var firewalls []*compute.Firewall
+	usedSuffixes := make(map[string]struct{})
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		var sourceTags []string
+		var peerGroups []*securitygroup.CloudResourceID
+		sourceRanges := convertToGcpFirewallRanges(rule.FromSrcIP)
+		for _, group := range rule.FromSecurityGroups {
+			if strings.EqualFold(group.Vpc, vpcID) {
+				sourceTags = append(sourceTags, group.GetCloudName(true))
+				continue
+			}
+			peerGroups = append(peerGroups, group)
+			for ip := range groupMemberIPs[group.String()] {
+				sourceRanges = append(sourceRanges, ip)
+			}
+		}
+		if len(rule.FromSecurityGroups) == 0 && len(sourceRanges) == 0 {
+			sourceRanges = []string{gcpAnyIPv4CIDR, gcpAnyIPv6CIDR}
+		}
+		ipv4Ranges, ipv6Ranges := splitGcpFirewallRanges(sourceRanges)
+		if len(sourceTags) == 0 && len(ipv4Ranges) == 0 && len(ipv6Ranges) == 0 {
+			gcpPluginLogger().Info("ingress rule skipped, no member found for peer groups", "appliedTo", atCloudName,
+				"vpcID", vpcID, "peerGroups", peerGroups)
+			continue
+		}
+
+		ipsOnly := false
+		if len(sourceTags) != 0 || len(ipv4Ranges) != 0 {
+			firewall := &compute.Firewall{
+				Name: getFirewallName(namePrefix, gcpFirewallSuffixIngress, getFirewallRuleSuffix(rule.Protocol,
+					rule.FromPort, rule.FromEndPort, rule.FromSrcIP, rule.FromSecurityGroups, false, usedSuffixes)),
+				Description:  convertToFirewallDescription(peerGroups, ipsOnly),
+				Network:      network.SelfLink,
+				Direction:    gcpFirewallDirectionIngress,
+				Priority:     gcpFirewallAllowPriority,
+				Allowed:      convertToGcpFirewallAllowed(rule.Protocol, rule.FromPort, rule.FromEndPort),
+				SourceRanges: sortedStrings(ipv4Ranges),
+				SourceTags:   sortedStrings(sourceTags),
+				TargetTags:   []string{atCloudName},
+			}
+			firewalls = append(firewalls, firewall)
+			ipsOnly = true
+		}
+		if len(ipv6Ranges) != 0 {
+			firewall := &compute.Firewall{
+				Name: getFirewallName(namePrefix, gcpFirewallSuffixIngress, getFirewallRuleSuffix(rule.Protocol,
+					rule.FromPort, rule.FromEndPort, rule.FromSrcIP, rule.FromSecurityGroups, true, usedSuffixes)),
+				Description:  convertToFirewallDescription(peerGroups, ipsOnly),
+				Network:      network.SelfLink,
+				Direction:    gcpFirewallDirectionIngress,
+				Priority:     gcpFirewallAllowPriority,
+				Allowed:      convertToGcpFirewallAllowed(rule.Protocol, rule.FromPort, rule.FromEndPort),
+				SourceRanges: sortedStrings(ipv6Ranges),
+				TargetTags:   []string{atCloudName},
+			}
+			firewalls = append(firewalls, firewall)
+		}
+	}
+	return firewalls
+}
+
+// buildEgressFirewalls builds allow firewalls for egress rules. Egress firewalls do not support tags, hence address
+// groups are realized using IPs of their members.
+func buildEgressFirewalls(network *compute.Network, atCloudName string, rules []*securitygroup.EgressRule,
+	groupMemberIPs map[string]map[string]struct{}) []*compute.Firewall {
+	vpcID := getNetworkID(network)
+	namePrefix := getFirewallNamePrefix(atCloudName, vpcID)
+
+	var firewalls []*compute.Firewall
+	usedSuffixes := make(map[string]struct{})
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		destinationRanges := convertToGcpFirewallRanges(rule.ToDstIP)
+		for _, group := range rule.ToSecurityGroups {
+			for ip := range groupMemberIPs[group.String()] {
+				destinationRanges = append(destinationRanges, ip)
+			}
+		}
+		if len(rule.ToSecurityGroups) == 0 && len(destinationRanges) == 0 {
+			destinationRanges = []string{gcpAnyIPv4CIDR, gcpAnyIPv6CIDR}
+		}
+		if len(destinationRanges) == 0 {
+			gcpPluginLogger().Info("egress rule skipped, no member found for peer groups", "appliedTo", atCloudName,
+				"vpcID", vpcID, "peerGroups", rule.ToSecurityGroups)
+			continue
+		}
+
+		ipsOnly := false
+		ipv4Ranges, ipv6Ranges := splitGcpFirewallRanges(destinationRanges)
+		if len(ipv4Ranges) != 0 {
+			firewall := &compute.Firewall{
+				Name: getFirewallName(namePrefix, gcpFirewallSuffixEgress, getFirewallRuleSuffix(rule.Protocol,
+					rule.ToPort, rule.ToEndPort, rule.ToDstIP, rule.ToSecurityGroups, false, usedSuffixes)),
+				Description:       convertToFirewallDescription(rule.ToSecurityGroups, ipsOnly),
+				Network:           network.SelfLink,
+				Direction:         gcpFirewallDirectionEgress,
+				Priority:          gcpFirewallAllowPriority,
+				Allowed:           convertToGcpFirewallAllowed(rule.Protocol, rule.ToPort, rule.ToEndPort),
+				DestinationRanges: sortedStrings(ipv4Ranges),
+				TargetTags:        []string{atCloudName},
+			}
+			firewalls = append(firewalls, firewall)
+			ipsOnly = true
+		}
+		if len(ipv6Ranges) != 0 {
+			firewall := &compute.Firewall{
+				Name: getFirewallName(namePrefix, gcpFirewallSuffixEgress, getFirewallRuleSuffix(rule.Protocol,
+					rule.ToPort, rule.ToEndPort, rule.ToDstIP, rule.ToSecurityGroups, true, usedSuffixes)),
+				Description:       convertToFirewallDescription(rule.ToSecurityGroups, ipsOnly),
+				Network:           network.SelfLink,
+				Direction:         gcpFirewallDirectionEgress,
+				Priority:          gcpFirewallAllowPriority,
+				Allowed:           convertToGcpFirewallAllowed(rule.Protocol, rule.ToPort, rule.ToEndPort),
+				DestinationRanges: sortedStrings(ipv6Ranges),
+				TargetTags:        []string{atCloudName},
+			}
+			firewalls = append(firewalls, firewall)
+		}
+	}
+	return firewalls
+}
+
+// splitGcpFirewallRanges splits firewall ranges into IPv4 and IPv6 ranges.
+func splitGcpFirewallRanges(ranges []string) ([]string, []string) {
+	var ipv4Ranges, ipv6Ranges []string
+	for _, cidr := range ranges {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ip.To4() != nil {
+			ipv4Ranges = append(ipv4Ranges, cidr)
+		} else {
+			ipv6Ranges = append(ipv6Ranges, cidr)
+		}
+	}
+	return ipv4Ranges, ipv6Ranges
+}
+
+// isFirewallChanged returns true if the firewall in cloud differs from the firewall to be realized.
+func isFirewallChanged(cloudFirewall *compute.Firewall, firewall *compute.Firewall) bool {
+	return cloudFirewall.Description != firewall.Description ||
+		cloudFirewall.Direction != firewall.Direction ||
+		cloudFirewall.Priority != firewall.Priority ||
+		!reflect.DeepEqual(cloudFirewall.Allowed, firewall.Allowed) ||
+		!reflect.DeepEqual(cloudFirewall.Denied, firewall.Denied) ||
+		!reflect.DeepEqual(sortedStrings(cloudFirewall.SourceRanges), firewall.SourceRanges) ||
+		!reflect.DeepEqual(sortedStrings(cloudFirewall.DestinationRanges), firewall.DestinationRanges) ||
+		!reflect.DeepEqual(sortedStrings(cloudFirewall.SourceTags), firewall.SourceTags) ||
+		!reflect.DeepEqual(cloudFirewall.TargetTags, firewall.TargetTags)
+}
+
+// getAppliedToFirewallsFromCloud returns firewalls, keyed by name, created for appliedTo group in the network.
+func (computeCfg *computeServiceConfig) getAppliedToFirewallsFromCloud(network *compute.Network, atCloudName string) (
+	map[string]*compute.Firewall, error) {
+	firewalls, err := computeCfg.apiClient.listFirewalls(computeCfg.projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	namePrefix := getFirewallNamePrefix(atCloudName, getNetworkID(network)) + "-"
+	nameToFirewall := make(map[string]*compute.Firewall)
+	for _, firewall := range firewalls {
+		if firewall.Network != network.SelfLink || !strings.HasPrefix(firewall.Name, namePrefix) {
+			continue
+		}
+		nameToFirewall[firewall.Name] = firewall
+	}
+	return nameToFirewall, nil
+}
+
+// realizeFirewalls creates, updates and deletes firewalls in cloud to match the given firewalls.
+func (computeCfg *computeServiceConfig) realizeFirewalls(firewalls []*compute.Firewall, cloudFirewalls map[string]*compute.Firewall,
+	deleteStale bool) error {
+	var err error
+	firewallNames := make(map[string]struct{})
+	for _, firewall := range firewalls {
+		firewallNames[firewall.Name] = struct{}{}
+		cloudFirewall, found := cloudFirewalls[firewall.Name]
+		if !found {
+			err = multierr.Append(err, computeCfg.apiClient.insertFirewall(computeCfg.projectID, firewall))
+			continue
+		}
+		if isFirewallChanged(cloudFirewall, firewall) {
+			err = multierr.Append(err, computeCfg.apiClient.updateFirewall(computeCfg.projectID, firewall))
+		}
+	}
+	if !deleteStale {
+		return err
+	}
+
+	for name := range cloudFirewalls {
+		if _, found := firewallNames[name]; found {
+			continue
+		}
+		err = multierr.Append(err, computeCfg.apiClient.deleteFirewall(computeCfg.projectID, name))
+	}
+	return err
+}
+
+// getInstancesOfNetworks returns instances of the account region, which have a network interface in any of the given networks.
+func (computeCfg *computeServiceConfig) getInstancesOfNetworks(networkSelfLinks map[string]struct{}) ([]*compute.Instance, error) {
+	instances, err := computeCfg.apiClient.aggregatedListInstances(computeCfg.projectID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var networkInstances []*compute.Instance
+	for _, instance := range computeCfg.filterInstancesByRegion(instances) {
+		for _, nwIntf := range instance.NetworkInterfaces {
+			if _, found := networkSelfLinks[nwIntf.Network]; found {
+				networkInstances = append(networkInstances, instance)
+				break
+			}
+		}
+	}
+	return networkInstances, nil
+}
+
+// buildGroupMemberIPs returns IPs of the network interfaces of instances tagged with nephe created network tags. Result
+// is keyed by address group identifier string.
+func buildGroupMemberIPs(instances []*compute.Instance, networks map[string]*compute.Network) map[string]map[string]struct{} {
+	groupMemberIPs := make(map[string]map[string]struct{})
+	for _, instance := range instances {
+		if instance.Tags == nil {
+			continue
+		}
+		for _, tag := range instance.Tags.Items {
+			sgName, isAG, _ := securitygroup.IsNepheControllerCreatedSG(tag)
+			if !isAG {
+				continue
+			}
+			for _, nwIntf := range instance.NetworkInterfaces {
+				network, found := networks[nwIntf.Network]
+				if !found {
+					continue
+				}
+				groupID := securitygroup.CloudResourceID{Name: sgName, Vpc: getNetworkID(network)}
+				ips, found := groupMemberIPs[groupID.String()]
+				if !found {
+					ips = make(map[string]struct{})
+					groupMemberIPs[groupID.String()] = ips
+				}
+				for _, ip := range []string{nwIntf.NetworkIP, nwIntf.Ipv6Address} {
+					if ipNet := securitygroup.IPNetFromIPString(ip); ipNet != nil {
+						ips[ipNet.String()] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return groupMemberIPs
+}
+
+func (computeCfg *computeServiceConfig) updateSecurityGroupMembers(network *compute.Network, groupCloudName string,
+	cloudResourceIdentifiers []*securitygroup.CloudResource) error {
+	// find all instances in the network
+	instances, err := computeCfg.getInstancesOfNetworks(map[string]struct{}{network.SelfLink: {}})
+	if err != nil {
+		return err
+	}
+
+	// find all instances which needs to be tagged with group network tag. network interfaces are not addressable in gcp,
+	// hence only virtual machine members are supported.
+	memberVirtualMachines, memberNetworkInterfaces := securitygroup.FindResourcesBasedOnKind(cloudResourceIdentifiers)
+	if len(memberNetworkInterfaces) > 0 {
+		gcpPluginLogger().Info("network interface members not supported, ignored", "group", groupCloudName,
+			"networkInterfaces", len(memberNetworkInterfaces))
+	}
+
+	// find instances which are using or need to use the provided group network tag
+	instancesToModify := make(map[*compute.Instance]*compute.Tags)
+	for _, instance := range instances {
+		var tagItems []string
+		var fingerprint string
+		if instance.Tags != nil {
+			tagItems = instance.Tags.Items
+			fingerprint = instance.Tags.Fingerprint
+		}
+
+		isGroupTagAttached := false
+		var otherTagItems []string
+		for _, tag := range tagItems {
+			if strings.Compare(tag, groupCloudName) == 0 {
+				isGroupTagAttached = true
+				continue
+			}
+			otherTagItems = append(otherTagItems, tag)
+		}
+
+		_, isMemberVM := memberVirtualMachines[strconv.FormatUint(instance.Id, 10)]
+		if isGroupTagAttached && !isMemberVM {
+			instancesToModify[instance] = &compute.Tags{Items: otherTagItems, Fingerprint: fingerprint}
+		} else if !isGroupTagAttached && isMemberVM {
+			instancesToModify[instance] = &compute.Tags{Items: append(otherTagItems, groupCloudName), Fingerprint: fingerprint}
+		}
+	}
+
+	// update instance network tags
+	return computeCfg.processInstanceTagsModifyConcurrently(instancesToModify)
+}
+
+func (computeCfg *computeServiceConfig) processInstanceTagsModifyConcurrently(instancesToModify map[*compute.Instance]*compute.Tags) error {
+	var tasks []func() error
+	for instance, tags := range instancesToModify {
+		instance, tags := instance, tags
+		tasks = append(tasks, func() error {
+			return computeCfg.apiClient.setInstanceTags(computeCfg.projectID, getResourceNameFromURL(instance.Zone), instance.Name, tags)
+		})
+	}
+	return internal.RunConcurrently(tasks)
+}
+
+func (computeCfg *computeServiceConfig) getNepheControllerManagedSecurityGroupsCloudView() []securitygroup.SynchronizationContent {
+	vpcIDs := computeCfg.getCachedVpcIDs()
+	if len(vpcIDs) == 0 {
+		return []securitygroup.SynchronizationContent{}
+	}
+
+	// build managed networks
+	networks := computeCfg.getCachedNetworks()
+	managedNetworkSelfLinks := make(map[string]struct{})
+	for selfLink, network := range networks {
+		if _, found := vpcIDs[getNetworkID(network)]; found {
+			managedNetworkSelfLinks[selfLink] = struct{}{}
+		}
+	}
+
+	// get all instances for managed networks
+	instances, err := computeCfg.getInstancesOfNetworks(managedNetworkSelfLinks)
+	if err != nil {
+		return []securitygroup.SynchronizationContent{}
+	}
+
+	// get all firewalls
+	firewalls, err := computeCfg.apiClient.listFirewalls(computeCfg.projectID)
+	if err != nil {
+		return []securitygroup.SynchronizationContent{}
+	}
+
+	// find all member instances for nephe created network tags
+	groupToSyncObj := make(map[string]*securitygroup.SynchronizationContent)
+	getSyncObj := func(cloudName string, vpcID string) *securitygroup.SynchronizationContent {
+		sgName, isAG, _ := securitygroup.IsNepheControllerCreatedSG(cloudName)
+		resource := securitygroup.CloudResourceID{Name: sgName, Vpc: vpcID}
+		key := cloudName + "/" + vpcID
+		syncObj, found := groupToSyncObj[key]
+		if !found {
+			syncObj = &securitygroup.SynchronizationContent{
+				Resource:       resource,
+				MembershipOnly: isAG,
+			}
+			groupToSyncObj[key] = syncObj
+		}
+		return syncObj
+	}
+	for _, instance := range instances {
+		if instance.Tags == nil {
+			continue
+		}
+		for _, tag := range instance.Tags.Items {
+			_, isAG, isAT := securitygroup.IsNepheControllerCreatedSG(tag)
+			if !isAG && !isAT {
+				continue
+			}
+			for _, nwIntf := range instance.NetworkInterfaces {
+				if _, found := managedNetworkSelfLinks[nwIntf.Network]; !found {
+					continue
+				}
+				vpcID := getNetworkID(networks[nwIntf.Network])
+				syncObj := getSyncObj(tag, vpcID)
+				syncObj.Members = append(syncObj.Members, securitygroup.CloudResource{
+					Type: securitygroup.CloudResourceTypeVM,
+					Name: securitygroup.CloudResourceID{
+						Name: strconv.FormatUint(instance.Id, 10),
+						Vpc:  vpcID,
+					},
+				})
+			}
+		}
+	}
+
+	// build ingress and egress rules from firewalls of appliedTo groups
+	groupMemberIPs := buildGroupMemberIPs(instances, networks)
+	for _, firewall := range firewalls {
+		if _, found := managedNetworkSelfLinks[firewall.Network]; !found || len(firewall.TargetTags) != 1 {
+			continue
+		}
+		_, _, isAT := securitygroup.IsNepheControllerCreatedSG(firewall.Name)
+		if !isAT {
+			continue
+		}
+		vpcID := getNetworkID(networks[firewall.Network])
+		syncObj := getSyncObj(firewall.TargetTags[0], vpcID)
+		if firewall.Priority != gcpFirewallAllowPriority {
+			continue
+		}
+		if firewall.Direction == gcpFirewallDirectionIngress {
+			syncObj.IngressRules = append(syncObj.IngressRules, convertFromFirewallToIngressRule(firewall, vpcID, groupMemberIPs))
+		} else {
+			syncObj.EgressRules = append(syncObj.EgressRules, convertFromFirewallToEgressRule(firewall, groupMemberIPs))
+		}
+	}
+
+	// build sync objects for managed security groups
+	var enforcedSecurityCloudView []securitygroup.SynchronizationContent
+	for _, syncObj := range groupToSyncObj {
+		enforcedSecurityCloudView = append(enforcedSecurityCloudView, *syncObj)
+	}
+
+	return enforcedSecurityCloudView
+}
+
+func sortedStrings(items []string) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	sorted := make([]string, len(items))
+	copy(sorted, items)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// ////////////////////////////////////////////////////////
+// 	SecurityInterface Implementation
+// ////////////////////////////////////////////////////////.
+func (c *gcpCloud) getComputeServiceConfigForVpc(vpcID string) (*computeServiceConfig, error) {
+	accCfg := c.getVpcAccount(vpcID)
+	if accCfg == nil {
+		return nil, fmt.Errorf("gcp account not found managing virtual private cloud [%v]", vpcID)
+	}
+	serviceCfg, err := accCfg.GetServiceConfigByName(gcpComputeServiceNameCompute)
+	if err != nil {
+		return nil, err
+	}
+	return serviceCfg.(*computeServiceConfig), nil
+}
+
+func (c *gcpCloud) CreateSecurityGroup(addressGroupIdentifier *securitygroup.CloudResourceID, membershipOnly bool) (*string, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	computeService, err := c.getComputeServiceConfigForVpc(addressGroupIdentifier.Vpc)
+	if err != nil {
+		return nil, err
+	}
+	network, err := computeService.getCachedNetworkByID(addressGroupIdentifier.Vpc)
+	if err != nil {
+		return nil, err
+	}
+
+	// address groups are realized using network tags, and do not need any cloud resource. appliedTo groups need
+	// deny all firewalls, so that only traffic allowed by group rules is permitted for members.
+	cloudSgName := addressGroupIdentifier.GetCloudName(membershipOnly)
+	if !membershipOnly {
+		cloudFirewalls, err := computeService.getAppliedToFirewallsFromCloud(network, cloudSgName)
+		if err != nil {
+			return nil, err
+		}
+		err = computeService.realizeFirewalls(buildDenyAllFirewalls(network, cloudSgName), cloudFirewalls, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &cloudSgName, nil
+}
+
+func (c *gcpCloud) UpdateSecurityGroupRules(addressGroupIdentifier *securitygroup.CloudResourceID,
+	ingressRules []*securitygroup.IngressRule, egressRules []*securitygroup.EgressRule) error {
+	for _, rule := range ingressRules {
+		if rule == nil {
+			continue
+		}
+		if !c.IsRuleActionSupported(rule.Action) {
+			return fmt.Errorf("gcp firewall does not support %v ingress rules", rule.Action)
+		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return fmt.Errorf("gcp firewall does not support icmp type and code")
+		}
+	}
+	for _, rule := range egressRules {
+		if rule == nil {
+			continue
+		}
+		if !c.IsRuleActionSupported(rule.Action) {
+			return fmt.Errorf("gcp firewall does not support %v egress rules", rule.Action)
+		}
+		if rule.ICMPType != nil || rule.ICMPCode != nil {
+			return fmt.Errorf("gcp firewall does not support icmp type and code")
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	vpcID := addressGroupIdentifier.Vpc
+	computeService, err := c.getComputeServiceConfigForVpc(vpcID)
+	if err != nil {
+		return err
+	}
+	network, err := computeService.getCachedNetworkByID(vpcID)
+	if err != nil {
+		return err
+	}
+
+	// find member IPs of address groups, which can not be realized using network tags
+	networks := computeService.getCachedNetworks()
+	networkSelfLinks := make(map[string]struct{})
+	for selfLink := range networks {
+		networkSelfLinks[selfLink] = struct{}{}
+	}
+	instances, err := computeService.getInstancesOfNetworks(networkSelfLinks)
+	if err != nil {
+		return err
+	}
+	groupMemberIPs := buildGroupMemberIPs(instances, networks)
+
+	// realize appliedTo group ingress and egress firewalls
+	cloudSgName := addressGroupIdentifier.GetCloudName(false)
+	firewalls := buildDenyAllFirewalls(network, cloudSgName)
+	firewalls = append(firewalls, buildIngressFirewalls(network, cloudSgName, ingressRules, groupMemberIPs)...)
+	firewalls = append(firewalls, buildEgressFirewalls(network, cloudSgName, egressRules, groupMemberIPs)...)
+	cloudFirewalls, err := computeService.getAppliedToFirewallsFromCloud(network, cloudSgName)
+	if err != nil {
+		return err
+	}
+
+	return computeService.realizeFirewalls(firewalls, cloudFirewalls, true)
+}
+
+func (c *gcpCloud) UpdateSecurityGroupMembers(groupIdentifier *securitygroup.CloudResourceID,
+	cloudResourceIdentifiers []*securitygroup.CloudResource, membershipOnly bool) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	computeService, err := c.getComputeServiceConfigForVpc(groupIdentifier.Vpc)
+	if err != nil {
+		return err
+	}
+	network, err := computeService.getCachedNetworkByID(groupIdentifier.Vpc)
+	if err != nil {
+		return err
+	}
+
+	return computeService.updateSecurityGroupMembers(network, groupIdentifier.GetCloudName(membershipOnly), cloudResourceIdentifiers)
+}
+
+func (c *gcpCloud) DeleteSecurityGroup(groupIdentifier *securitygroup.CloudResourceID, membershipOnly bool) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	computeService, err := c.getComputeServiceConfigForVpc(groupIdentifier.Vpc)
+	if err != nil {
+		return err
+	}
+	network, err := computeService.getCachedNetworkByID(groupIdentifier.Vpc)
+	if err != nil {
+		return err
+	}
+
+	cloudSgNameToDelete := groupIdentifier.GetCloudName(membershipOnly)
+	err = computeService.updateSecurityGroupMembers(network, cloudSgNameToDelete, nil)
+	if err != nil {
+		return err
+	}
+	if membershipOnly {
+		return nil
+	}
+
+	// delete all firewalls of appliedTo group
+	cloudFirewalls, err := computeService.getAppliedToFirewallsFromCloud(network, cloudSgNameToDelete)
+	if err != nil {
+		return err
+	}
+	return computeService.realizeFirewalls(nil, cloudFirewalls, true)
+}
+
+// IsRuleActionSupported returns true if action is supported by nephe managed GCP firewalls, which only permit traffic.
+func (c *gcpCloud) IsRuleActionSupported(action securitygroup.RuleAction) bool {
+	return !action.IsDeny()
+}
+
+func (c *gcpCloud) GetEnforcedSecurity() []securitygroup.SynchronizationContent {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var accNamespacedNames []types.NamespacedName
+	accountConfigs := c.cloudCommon.GetCloudAccounts()
+	for _, accCfg := range accountConfigs {
+		accNamespacedNames = append(accNamespacedNames, *accCfg.GetNamespacedName())
+	}
+	return c.getEnforcedSecurity(accNamespacedNames)
+}
+
+func (c *gcpCloud) GetAccountEnforcedSecurity(accNamespacedName *types.NamespacedName) []securitygroup.SynchronizationContent {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, found := c.cloudCommon.GetCloudAccountByName(accNamespacedName); !found {
+		return nil
+	}
+	return c.getEnforcedSecurity([]types.NamespacedName{*accNamespacedName})
+}
+
+// GetVpcSecurityGroups returns an error, GCP firewall rules apply to network tags and service accounts of instances,
+// which cannot be imported as Antrea NetworkPolicies.
+func (c *gcpCloud) GetVpcSecurityGroups(_ *types.NamespacedName, vpcID string) ([]securitygroup.ImportContent, error) {
+	return nil, fmt.Errorf("importing firewall rules of network %v is not supported for GCP", vpcID)
+}
+
+// getEnforcedSecurity returns the cloud view of enforced security of given accounts.
+func (c *gcpCloud) getEnforcedSecurity(accNamespacedNames []types.NamespacedName) []securitygroup.SynchronizationContent {
+	inventoryInitWaitDuration := 30 * time.Second
+
+	var enforcedSecurityCloudView []securitygroup.SynchronizationContent
+	var wg sync.WaitGroup
+	ch := make(chan []securitygroup.SynchronizationContent)
+	wg.Add(len(accNamespacedNames))
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+
+	for _, accNamespacedName := range accNamespacedNames {
+		accNamespacedNameCopy := &types.NamespacedName{
+			Namespace: accNamespacedName.Namespace,
+			Name:      accNamespacedName.Name,
+		}
+
+		go func(name *types.NamespacedName, sendCh chan<- []securitygroup.SynchronizationContent) {
+			defer wg.Done()
+
+			accCfg, found := c.cloudCommon.GetCloudAccountByName(name)
+			if !found {
+				gcpPluginLogger().Info("enforced-security-cloud-view GET for account skipped (account no longer exists)", "account", name)
+				return
+			}
+
+			serviceCfg, err := accCfg.GetServiceConfigByName(gcpComputeServiceNameCompute)
+			if err != nil {
+				gcpPluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
+				return
+			}
+			computeService := serviceCfg.(*computeServiceConfig)
+			err = computeService.waitForInventoryInit(inventoryInitWaitDuration)
+			if err != nil {
+				gcpPluginLogger().Error(err, "enforced-security-cloud-view GET for account skipped", "account", accCfg.GetNamespacedName())
+				return
+			}
+			sendCh <- computeService.getNepheControllerManagedSecurityGroupsCloudView()
+		}(accNamespacedNameCopy, ch)
+	}
+
+	for val := range ch {
+		if val != nil {
+			enforcedSecurityCloudView = append(enforcedSecurityCloudView, val...)
+		}
+	}
+	return enforcedSecurityCloudView
+}
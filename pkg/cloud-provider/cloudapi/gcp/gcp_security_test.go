@@ -0,0 +1,310 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/compute/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+var _ = Describe("GCP Cloud Security", func() {
+	var (
+		testVMID01 = uint64(11)
+		testVMID02 = uint64(12)
+
+		testAccountNamespacedName = &types.NamespacedName{Namespace: "namespace01", Name: "account01"}
+		testEntitySelectorName    = "testEntitySelector01"
+		credentials               = "credentials"
+
+		cloudInterface *gcpCloud
+		account        *v1alpha1.CloudProviderAccount
+		selector       *v1alpha1.CloudEntitySelector
+		secret         *corev1.Secret
+
+		mockCtrl           *gomock.Controller
+		mockgcpCloudHelper *MockgcpServicesHelper
+		mockgcpCompute     *MockgcpComputeWrapper
+		mockgcpService     *MockgcpServiceClientCreateInterface
+	)
+
+	BeforeEach(func() {
+		var pollIntv uint = 1
+		account = &v1alpha1.CloudProviderAccount{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      testAccountNamespacedName.Name,
+				Namespace: testAccountNamespacedName.Namespace,
+			},
+			Spec: v1alpha1.CloudProviderAccountSpec{
+				PollIntervalInSeconds: &pollIntv,
+				GCPConfig: &v1alpha1.CloudProviderAccountGCPConfig{
+					ProjectID: testProjectID,
+					Region:    testRegion,
+					SecretRef: &v1alpha1.SecretReference{
+						Name:      testAccountNamespacedName.Name,
+						Namespace: testAccountNamespacedName.Namespace,
+						Key:       credentials,
+					},
+				},
+			},
+		}
+		credential := `{"type": "service_account", "private_key": "key", "client_email": "sa@test-project.iam.gserviceaccount.com"}`
+
+		secret = &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      testAccountNamespacedName.Name,
+				Namespace: testAccountNamespacedName.Namespace,
+			},
+			Data: map[string][]byte{
+				"credentials": []byte(credential),
+			},
+		}
+		selector = &v1alpha1.CloudEntitySelector{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      testEntitySelectorName,
+				Namespace: testAccountNamespacedName.Namespace,
+			},
+			Spec: v1alpha1.CloudEntitySelectorSpec{
+				AccountName: testAccountNamespacedName.Name,
+				VMSelector: []v1alpha1.VirtualMachineSelector{
+					{
+						VpcMatch: &v1alpha1.EntityMatch{
+							MatchID: testVpcID01,
+						},
+					},
+				},
+			},
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockgcpCloudHelper = NewMockgcpServicesHelper(mockCtrl)
+
+		mockgcpService = NewMockgcpServiceClientCreateInterface(mockCtrl)
+		mockgcpCompute = NewMockgcpComputeWrapper(mockCtrl)
+
+		mockgcpCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockgcpService, nil).Times(1)
+		mockgcpService.EXPECT().compute().Return(mockgcpCompute, nil).AnyTimes()
+
+		instances := getComputeInstanceObjects([]uint64{testVMID01, testVMID02}, testZone)
+		mockgcpCompute.EXPECT().listNetworks(testProjectID).Return(getComputeNetworkObjects(), nil).AnyTimes()
+		mockgcpCompute.EXPECT().aggregatedListInstances(testProjectID, "").Return(instances, nil).AnyTimes()
+
+		fakeClient := fake.NewClientBuilder().Build()
+		_ = fakeClient.Create(context.Background(), secret)
+		cloudInterface = newGCPCloud(mockgcpCloudHelper)
+		err := cloudInterface.AddProviderAccount(fakeClient, account)
+		Expect(err).Should(BeNil())
+
+		err = cloudInterface.AddAccountResourceSelector(testAccountNamespacedName, selector)
+		Expect(err).Should(BeNil())
+
+		// wait for instances to be populated
+		time.Sleep(time.Duration(pollIntv+1) * time.Second)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("CreateSecurityGroup", func() {
+		It("Should not create firewalls for address group", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			mockgcpCompute.EXPECT().listFirewalls(gomock.Any()).Times(0)
+			mockgcpCompute.EXPECT().insertFirewall(gomock.Any(), gomock.Any()).Times(0)
+
+			cloudSgName, err := cloudInterface.CreateSecurityGroup(webAddressGroupIdentifier, true)
+			Expect(err).Should(BeNil())
+			Expect(*cloudSgName).To(Equal(webAddressGroupIdentifier.GetCloudName(true)))
+		})
+		It("Should create deny all firewalls for appliedTo group", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			mockgcpCompute.EXPECT().listFirewalls(testProjectID).Return([]*compute.Firewall{}, nil).Times(1)
+			mockgcpCompute.EXPECT().insertFirewall(testProjectID, gomock.Any()).DoAndReturn(
+				func(project string, firewall *compute.Firewall) error {
+					Expect(firewall.Priority).To(Equal(int64(gcpFirewallDenyPriority)))
+					Expect(firewall.TargetTags).To(Equal([]string{webAddressGroupIdentifier.GetCloudName(false)}))
+					return nil
+				}).Times(4)
+
+			cloudSgName, err := cloudInterface.CreateSecurityGroup(webAddressGroupIdentifier, false)
+			Expect(err).Should(BeNil())
+			Expect(*cloudSgName).To(Equal(webAddressGroupIdentifier.GetCloudName(false)))
+		})
+		It("Should fail to create security group for unknown vpc", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  "9999",
+			}
+			_, err := cloudInterface.CreateSecurityGroup(webAddressGroupIdentifier, false)
+			Expect(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("UpdateSecurityGroupRules", func() {
+		It("Should realize ingress rule with same network address group using source tags", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			dbAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Db",
+				Vpc:  testVpcID01,
+			}
+			port := 22
+			protocol := 6
+			_, ipNet, _ := net.ParseCIDR("1.1.1.0/24")
+			ingressRules := []*securitygroup.IngressRule{
+				{
+					FromPort:           &port,
+					Protocol:           &protocol,
+					FromSrcIP:          []*net.IPNet{ipNet},
+					FromSecurityGroups: []*securitygroup.CloudResourceID{dbAddressGroupIdentifier},
+				},
+			}
+			cloudSgName := webAddressGroupIdentifier.GetCloudName(false)
+			denyFirewalls := buildDenyAllFirewalls(getComputeNetworkObjects()[0], cloudSgName)
+
+			mockgcpCompute.EXPECT().listFirewalls(testProjectID).Return(denyFirewalls, nil).Times(1)
+			mockgcpCompute.EXPECT().insertFirewall(testProjectID, gomock.Any()).DoAndReturn(
+				func(project string, firewall *compute.Firewall) error {
+					Expect(firewall.Direction).To(Equal(gcpFirewallDirectionIngress))
+					Expect(firewall.SourceRanges).To(Equal([]string{"1.1.1.0/24"}))
+					Expect(firewall.SourceTags).To(Equal([]string{dbAddressGroupIdentifier.GetCloudName(true)}))
+					Expect(firewall.Allowed).To(Equal([]*compute.FirewallAllowed{{IPProtocol: "tcp", Ports: []string{"22"}}}))
+					return nil
+				}).Times(1)
+			mockgcpCompute.EXPECT().updateFirewall(gomock.Any(), gomock.Any()).Times(0)
+			mockgcpCompute.EXPECT().deleteFirewall(gomock.Any(), gomock.Any()).Times(0)
+
+			err := cloudInterface.UpdateSecurityGroupRules(webAddressGroupIdentifier, ingressRules, nil)
+			Expect(err).Should(BeNil())
+		})
+		It("Should realize mixed IPv4 and IPv6 rule IPs using separate firewalls", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			_, ipv4Net, _ := net.ParseCIDR("1.1.1.0/24")
+			_, ipv6Net, _ := net.ParseCIDR("2001:db8::/64")
+			egressRules := []*securitygroup.EgressRule{
+				nil,
+				{ToDstIP: []*net.IPNet{ipv4Net, ipv6Net}},
+			}
+			cloudSgName := webAddressGroupIdentifier.GetCloudName(false)
+			denyFirewalls := buildDenyAllFirewalls(getComputeNetworkObjects()[0], cloudSgName)
+
+			var destinationRanges [][]string
+			mockgcpCompute.EXPECT().listFirewalls(testProjectID).Return(denyFirewalls, nil).Times(1)
+			mockgcpCompute.EXPECT().insertFirewall(testProjectID, gomock.Any()).DoAndReturn(
+				func(project string, firewall *compute.Firewall) error {
+					Expect(firewall.Direction).To(Equal(gcpFirewallDirectionEgress))
+					destinationRanges = append(destinationRanges, firewall.DestinationRanges)
+					return nil
+				}).Times(2)
+
+			err := cloudInterface.UpdateSecurityGroupRules(webAddressGroupIdentifier, nil, egressRules)
+			Expect(err).Should(BeNil())
+			Expect(destinationRanges).To(ConsistOf([]string{"1.1.1.0/24"}, []string{"2001:db8::/64"}))
+		})
+		It("Should delete stale allow firewalls", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			cloudSgName := webAddressGroupIdentifier.GetCloudName(false)
+			network := getComputeNetworkObjects()[0]
+			denyFirewalls := buildDenyAllFirewalls(network, cloudSgName)
+			staleFirewalls := buildEgressFirewalls(network, cloudSgName, []*securitygroup.EgressRule{{}}, nil)
+			Expect(staleFirewalls).To(HaveLen(2))
+
+			mockgcpCompute.EXPECT().listFirewalls(testProjectID).Return(append(denyFirewalls, staleFirewalls...), nil).Times(1)
+			for _, firewall := range staleFirewalls {
+				mockgcpCompute.EXPECT().deleteFirewall(testProjectID, firewall.Name).Return(nil).Times(1)
+			}
+
+			err := cloudInterface.UpdateSecurityGroupRules(webAddressGroupIdentifier, nil, nil)
+			Expect(err).Should(BeNil())
+		})
+		It("Should keep firewalls of reordered rules", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			_, sshNet, _ := net.ParseCIDR("1.1.1.0/24")
+			_, httpNet, _ := net.ParseCIDR("2.2.2.0/24")
+			tcp, sshPort, httpPort := 6, 22, 80
+			egressRules := []*securitygroup.EgressRule{
+				{Protocol: &tcp, ToPort: &sshPort, ToDstIP: []*net.IPNet{sshNet}},
+				{Protocol: &tcp, ToPort: &httpPort, ToDstIP: []*net.IPNet{httpNet}},
+			}
+			cloudSgName := webAddressGroupIdentifier.GetCloudName(false)
+			network := getComputeNetworkObjects()[0]
+			denyFirewalls := buildDenyAllFirewalls(network, cloudSgName)
+			cloudFirewalls := buildEgressFirewalls(network, cloudSgName, egressRules, nil)
+			Expect(cloudFirewalls).To(HaveLen(2))
+
+			mockgcpCompute.EXPECT().listFirewalls(testProjectID).Return(append(denyFirewalls, cloudFirewalls...), nil).Times(1)
+			mockgcpCompute.EXPECT().insertFirewall(gomock.Any(), gomock.Any()).Times(0)
+			mockgcpCompute.EXPECT().updateFirewall(gomock.Any(), gomock.Any()).Times(0)
+			mockgcpCompute.EXPECT().deleteFirewall(gomock.Any(), gomock.Any()).Times(0)
+
+			reorderedRules := []*securitygroup.EgressRule{egressRules[1], egressRules[0]}
+			err := cloudInterface.UpdateSecurityGroupRules(webAddressGroupIdentifier, nil, reorderedRules)
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Context("UpdateSecurityGroupMembers", func() {
+		It("Should add address group network tag to member instances", func() {
+			webAddressGroupIdentifier := &securitygroup.CloudResourceID{
+				Name: "Web",
+				Vpc:  testVpcID01,
+			}
+			members := []*securitygroup.CloudResource{
+				{
+					Type: securitygroup.CloudResourceTypeVM,
+					Name: securitygroup.CloudResourceID{Name: strconv.FormatUint(testVMID01, 10), Vpc: testVpcID01},
+				},
+			}
+			expectedTags := &compute.Tags{
+				Items:       []string{webAddressGroupIdentifier.GetCloudName(true)},
+				Fingerprint: "fp",
+			}
+			mockgcpCompute.EXPECT().setInstanceTags(testProjectID, testZone, "vm-11", expectedTags).Return(nil).Times(1)
+
+			err := cloudInterface.UpdateSecurityGroupMembers(webAddressGroupIdentifier, members, true)
+			Expect(err).Should(BeNil())
+		})
+	})
+})
@@ -0,0 +1,100 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	"k8s.io/apimachinery/pkg/types"
+
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/internal"
+)
+
+const (
+	gcpComputeServiceNameCompute = internal.CloudServiceName("COMPUTE")
+)
+
+// gcpServiceClientCreateInterface provides interface to create gcp service clients.
+type gcpServiceClientCreateInterface interface {
+	compute() (gcpComputeWrapper, error)
+	// Add any gcp service api client creation methods here
+}
+
+// gcpServiceSdkConfigProvider provides config required to create gcp service (compute) clients.
+// Implements gcpServiceClientCreateInterface interface.
+// NOTE: Currently supporting only service account key based clients.
+type gcpServiceSdkConfigProvider struct {
+	credentialsJSON []byte
+}
+
+// gcpServicesHelper.
+type gcpServicesHelper interface {
+	newServiceSdkConfigProvider(accCfg *gcpAccountConfig) (gcpServiceClientCreateInterface, error)
+}
+
+type gcpServicesHelperImpl struct{}
+
+// newServiceSdkConfigProvider returns config to create gcp services clients.
+func (h *gcpServicesHelperImpl) newServiceSdkConfigProvider(accConfig *gcpAccountConfig) (gcpServiceClientCreateInterface, error) {
+	credentialsJSON, err := json.Marshal(accConfig.GCPAccountCredential)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build GCP service account credentials: %v", err)
+	}
+
+	configProvider := &gcpServiceSdkConfigProvider{
+		credentialsJSON: credentialsJSON,
+	}
+	return configProvider, nil
+}
+
+// compute returns GCP Compute Engine SDK apiClient.
+func (p *gcpServiceSdkConfigProvider) compute() (gcpComputeWrapper, error) {
+	computeService, err := compute.NewService(context.Background(), option.WithCredentialsJSON(p.credentialsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize GCP compute service: %v", err)
+	}
+
+	gcpCompute := &gcpComputeWrapperImpl{
+		compute: computeService,
+	}
+
+	return gcpCompute, nil
+}
+
+func newGcpServiceConfigs(accountNamespacedName *types.NamespacedName, accCredentials interface{}, gcpSpecificHelper interface{}) (
+	[]internal.CloudServiceInterface, error) {
+	gcpServicesHelper := gcpSpecificHelper.(gcpServicesHelper)
+	gcpAccountCredentials := accCredentials.(*gcpAccountConfig)
+
+	var serviceConfigs []internal.CloudServiceInterface
+
+	gcpServiceClientCreator, err := gcpServicesHelper.newServiceSdkConfigProvider(gcpAccountCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	computeService, err := newComputeServiceConfig(accountNamespacedName.String(), gcpAccountCredentials.projectID,
+		gcpAccountCredentials.region, gcpServiceClientCreator)
+	if err != nil {
+		return nil, err
+	}
+	serviceConfigs = append(serviceConfigs, computeService)
+
+	return serviceConfigs, nil
+}
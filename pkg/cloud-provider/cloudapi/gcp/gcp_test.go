@@ -0,0 +1,333 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/compute/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"antrea.io/nephe/apis/crd/v1alpha1"
+	. "github.com/onsi/ginkgo"
+)
+
+var (
+	testProjectID     = "test-project"
+	testRegion        = "us-central1"
+	testZone          = "us-central1-a"
+	testNetworkID01   = uint64(1001)
+	testNetworkName01 = "network01"
+	testVpcID01       = strconv.FormatUint(testNetworkID01, 10)
+)
+
+var _ = Describe("GCP cloud", func() {
+	var (
+		testAccountNamespacedName = types.NamespacedName{Namespace: "namespace01", Name: "account01"}
+		credentials               = "credentials"
+	)
+
+	Context("AddProviderAccount", func() {
+		var (
+			account            *v1alpha1.CloudProviderAccount
+			mockCtrl           *gomock.Controller
+			mockgcpCloudHelper *MockgcpServicesHelper
+			secret             *corev1.Secret
+			fakeClient         client.WithWatch
+		)
+
+		BeforeEach(func() {
+			var pollIntv uint = 1
+			account = &v1alpha1.CloudProviderAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      testAccountNamespacedName.Name,
+					Namespace: testAccountNamespacedName.Namespace,
+				},
+				Spec: v1alpha1.CloudProviderAccountSpec{
+					PollIntervalInSeconds: &pollIntv,
+					GCPConfig: &v1alpha1.CloudProviderAccountGCPConfig{
+						Region: testRegion,
+						SecretRef: &v1alpha1.SecretReference{
+							Name:      testAccountNamespacedName.Name,
+							Namespace: testAccountNamespacedName.Namespace,
+							Key:       credentials,
+						},
+					},
+				},
+			}
+			credential := `{"type": "service_account", "project_id": "test-project", "private_key": "key",
+				"client_email": "sa@test-project.iam.gserviceaccount.com"}`
+			secret = &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      testAccountNamespacedName.Name,
+					Namespace: testAccountNamespacedName.Namespace,
+				},
+				Data: map[string][]byte{
+					"credentials": []byte(credential),
+				},
+			}
+			fakeClient = fake.NewClientBuilder().Build()
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockgcpCloudHelper = NewMockgcpServicesHelper(mockCtrl)
+		})
+
+		AfterEach(func() {
+			mockCtrl.Finish()
+		})
+		Context("New account add success scenarios", func() {
+			var (
+				selector *v1alpha1.CloudEntitySelector
+
+				mockgcpService *MockgcpServiceClientCreateInterface
+				mockgcpCompute *MockgcpComputeWrapper
+			)
+
+			BeforeEach(func() {
+				selector = &v1alpha1.CloudEntitySelector{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "selector-all",
+						Namespace: testAccountNamespacedName.Namespace,
+					},
+					Spec: v1alpha1.CloudEntitySelectorSpec{
+						AccountName: testAccountNamespacedName.Name,
+						VMSelector:  []v1alpha1.VirtualMachineSelector{},
+					},
+				}
+
+				mockgcpService = NewMockgcpServiceClientCreateInterface(mockCtrl)
+				mockgcpCompute = NewMockgcpComputeWrapper(mockCtrl)
+
+				mockgcpCloudHelper.EXPECT().newServiceSdkConfigProvider(gomock.Any()).Return(mockgcpService, nil)
+				mockgcpService.EXPECT().compute().Return(mockgcpCompute, nil).AnyTimes()
+			})
+
+			It("Should discover few instances with get ALL selector", func() {
+				instanceIds := []uint64{1, 2}
+				mockgcpCompute.EXPECT().listNetworks(testProjectID).Return(getComputeNetworkObjects(), nil).AnyTimes()
+				mockgcpCompute.EXPECT().aggregatedListInstances(testProjectID, "").Return(
+					getComputeInstanceObjects(instanceIds, testZone), nil).AnyTimes()
+
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newGCPCloud(mockgcpCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+
+				Expect(err).Should(BeNil())
+				accCfg, found := c.cloudCommon.GetCloudAccountByName(&testAccountNamespacedName)
+				Expect(found).To(BeTrue())
+				Expect(accCfg).To(Not(BeNil()))
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, instanceIds)
+				Expect(err).Should(BeNil())
+			})
+			It("Should discover only instances of account region", func() {
+				instanceIds := []uint64{1, 2}
+				instances := getComputeInstanceObjects(instanceIds, testZone)
+				instances = append(instances, getComputeInstanceObjects([]uint64{3}, "us-east1-b")...)
+				mockgcpCompute.EXPECT().listNetworks(testProjectID).Return(getComputeNetworkObjects(), nil).AnyTimes()
+				mockgcpCompute.EXPECT().aggregatedListInstances(testProjectID, "").Return(instances, nil).AnyTimes()
+
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newGCPCloud(mockgcpCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, instanceIds)
+				Expect(err).Should(BeNil())
+			})
+			It("Should discover instances matching name pattern except excluded", func() {
+				instanceIds := []uint64{1, 2, 3}
+				mockgcpCompute.EXPECT().listNetworks(testProjectID).Return(getComputeNetworkObjects(), nil).AnyTimes()
+				mockgcpCompute.EXPECT().aggregatedListInstances(testProjectID, "").Return(
+					getComputeInstanceObjects(instanceIds, testZone), nil).AnyTimes()
+				selector.Spec.VMSelector = []v1alpha1.VirtualMachineSelector{
+					{
+						VMMatch:      []v1alpha1.EntityMatch{{MatchName: "vm-*"}},
+						ExcludeMatch: []v1alpha1.EntityMatch{{MatchName: "vm-2"}},
+					},
+				}
+
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newGCPCloud(mockgcpCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+
+				errSelAdd := c.AddAccountResourceSelector(&testAccountNamespacedName, selector)
+				Expect(errSelAdd).Should(BeNil())
+
+				err = checkAccountAddSuccessCondition(c, testAccountNamespacedName, []uint64{1, 3})
+				Expect(err).Should(BeNil())
+			})
+			It("Should not call cloud api's with NO selector", func() {
+				mockgcpCompute.EXPECT().listNetworks(gomock.Any()).Times(0)
+				mockgcpCompute.EXPECT().aggregatedListInstances(gomock.Any(), gomock.Any()).Times(0)
+
+				_ = fakeClient.Create(context.Background(), secret)
+				c := newGCPCloud(mockgcpCloudHelper)
+				err := c.AddProviderAccount(fakeClient, account)
+				Expect(err).Should(BeNil())
+			})
+		})
+	})
+
+	Context("AddAccountResourceSelector", func() {
+		It("Should match expected filter - single vpcID only match", func() {
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{VpcMatch: &v1alpha1.EntityMatch{MatchID: testVpcID01}},
+			}
+			expectedFilters := []*gcpInstanceFilter{{vpcID: testVpcID01}}
+
+			filters := buildGcpInstanceFilters(vmSelector)
+			Expect(filters).To(Equal(expectedFilters))
+		})
+		It("Should match expected filter - vpcName & vmName match", func() {
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VpcMatch: &v1alpha1.EntityMatch{MatchName: testNetworkName01},
+					VMMatch: []v1alpha1.EntityMatch{
+						{MatchName: "vm01"},
+						{MatchID: "12345"},
+					},
+				},
+			}
+			expectedFilters := []*gcpInstanceFilter{
+				{expression: `(name = "vm01")`, vpcName: testNetworkName01},
+				{expression: "(id = 12345)", vpcName: testNetworkName01},
+			}
+
+			filters := buildGcpInstanceFilters(vmSelector)
+			Expect(filters).To(Equal(expectedFilters))
+		})
+		It("Should match expected filter - vmName pattern & exclude match", func() {
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{
+					VMMatch: []v1alpha1.EntityMatch{
+						{MatchName: "web-*"},
+						{MatchID: "12345"},
+					},
+					ExcludeMatch: []v1alpha1.EntityMatch{{MatchName: "web-bastion"}},
+				},
+				{
+					ExcludeMatch: []v1alpha1.EntityMatch{{MatchID: "67890"}},
+				},
+			}
+			expectedFilters := []*gcpInstanceFilter{
+				{vmNamePattern: "web-*", excludes: []gcpExcludeMatch{{vmName: "web-bastion"}}},
+				{expression: "(id = 12345)", excludes: []gcpExcludeMatch{{vmName: "web-bastion"}}},
+				{excludes: []gcpExcludeMatch{{vmID: "67890"}}},
+			}
+
+			filters := buildGcpInstanceFilters(vmSelector)
+			Expect(filters).To(Equal(expectedFilters))
+			Expect(filters[0].matchesInstance("1", "web-01")).To(BeTrue())
+			Expect(filters[0].matchesInstance("2", "web-bastion")).To(BeFalse())
+			Expect(filters[0].matchesInstance("3", "db-01")).To(BeFalse())
+			Expect(filters[2].matchesInstance("67890", "db-01")).To(BeFalse())
+		})
+		It("Should match expected filter - multiple with one all", func() {
+			vmSelector := []v1alpha1.VirtualMachineSelector{
+				{VpcMatch: &v1alpha1.EntityMatch{MatchID: testVpcID01}},
+				{VpcMatch: &v1alpha1.EntityMatch{}},
+			}
+
+			filters := buildGcpInstanceFilters(vmSelector)
+			Expect(filters).To(BeNil())
+		})
+	})
+})
+
+func getComputeNetworkObjects() []*compute.Network {
+	return []*compute.Network{
+		{
+			Id:       testNetworkID01,
+			Name:     testNetworkName01,
+			SelfLink: getNetworkSelfLink(testNetworkName01),
+		},
+	}
+}
+
+func getNetworkSelfLink(name string) string {
+	return fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%v/global/networks/%v", testProjectID, name)
+}
+
+func getComputeInstanceObjects(instanceIds []uint64, zone string) []*compute.Instance {
+	var instances []*compute.Instance
+	for idx, id := range instanceIds {
+		instance := &compute.Instance{
+			Id:     id,
+			Name:   fmt.Sprintf("vm-%v", id),
+			Status: "RUNNING",
+			Zone:   fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%v/zones/%v", testProjectID, zone),
+			NetworkInterfaces: []*compute.NetworkInterface{
+				{
+					Name:      "nic0",
+					Network:   getNetworkSelfLink(testNetworkName01),
+					NetworkIP: fmt.Sprintf("10.10.0.%v", idx+10),
+				},
+			},
+			Tags: &compute.Tags{Fingerprint: "fp"},
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+func checkAccountAddSuccessCondition(c *gcpCloud, namespacedName types.NamespacedName, ids []uint64) error {
+	conditionFunc := func() (done bool, e error) {
+		accCfg, found := c.cloudCommon.GetCloudAccountByName(&namespacedName)
+		if !found {
+			return true, errors.New("failed to find account")
+		}
+
+		serviceConfig, _ := accCfg.GetServiceConfigByName(gcpComputeServiceNameCompute)
+		instances := serviceConfig.(*computeServiceConfig).getCachedInstances()
+		instanceIds := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			instanceIds = append(instanceIds, strconv.FormatUint(instance.Id, 10))
+		}
+		expectedIds := make([]string, 0, len(ids))
+		for _, id := range ids {
+			expectedIds = append(expectedIds, strconv.FormatUint(id, 10))
+		}
+
+		sort.Strings(instanceIds)
+		sort.Strings(expectedIds)
+		equal := reflect.DeepEqual(instanceIds, expectedIds)
+		if equal {
+			return true, nil
+		}
+		return false, nil
+	}
+
+	return wait.PollImmediate(1*time.Second, 5*time.Second, conditionFunc)
+}
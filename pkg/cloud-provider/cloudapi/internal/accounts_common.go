@@ -17,10 +17,13 @@ package internal
 import (
 	"fmt"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -29,6 +32,17 @@ import (
 	"antrea.io/nephe/pkg/logging"
 )
 
+const (
+	AccountConditionReasonInventoryPending       = "InventoryPending"
+	AccountConditionReasonInventoryPollSucceeded = "InventoryPollSucceeded"
+	AccountConditionReasonInventoryPollFailed    = "InventoryPollFailed"
+	AccountConditionReasonInventoryAvailable     = "InventoryAvailable"
+	AccountConditionReasonInventoryUnavailable   = "InventoryUnavailable"
+)
+
+// cloudEventPollInterval is the interval to receive events from the event queue of an account, once the queue is empty.
+const cloudEventPollInterval = 10 * time.Second
+
 type CloudAccountInterface interface {
 	GetNamespacedName() *types.NamespacedName
 	GetServiceConfigs() map[CloudServiceName]*CloudServiceCommon
@@ -36,27 +50,73 @@ type CloudAccountInterface interface {
 	GetStatus() *cloudv1alpha1.CloudProviderAccountStatus
 
 	startPeriodicInventorySync() error
+	startPeriodicInventorySyncInBackground()
 	stopPeriodicInventorySync()
+
+	addSelector(selector *cloudv1alpha1.CloudEntitySelector)
+	removeSelector(selectorNamespacedName *types.NamespacedName) int
+	getSelectors() []types.NamespacedName
+	restoreSelectorSnapshot(selectorNamespacedName *types.NamespacedName, vms []*cloudv1alpha1.VirtualMachine)
+	getSelectorSnapshot(selectorNamespacedName *types.NamespacedName) ([]*cloudv1alpha1.VirtualMachine, bool)
+}
+
+// selectorSnapshot is a snapshot of compute resource CRDs of a selector, e.g. checkpointed before a controller restart.
+type selectorSnapshot struct {
+	restoreTime     time.Time
+	virtualMachines []*cloudv1alpha1.VirtualMachine
 }
 
 type cloudAccountConfig struct {
-	mutex                 sync.Mutex
-	namespacedName        *types.NamespacedName
-	credentials           interface{}
+	// mutex protects the account config, it is not held during cloud api calls, so that the account can be updated
+	// and selectors added while inventory waits for throttled calls.
+	mutex sync.Mutex
+	// inventoryMutex serializes full and event inventories of the account.
+	inventoryMutex sync.Mutex
+	namespacedName *types.NamespacedName
+	credentials    interface{}
+	// serviceMutex protects serviceConfigs, which change when account credentials change, e.g. regions are added or
+	// removed. It is not held during inventory, so that services can be looked up while inventory is in progress.
+	serviceMutex          sync.RWMutex
 	serviceConfigs        map[CloudServiceName]*CloudServiceCommon
 	inventoryPollInterval time.Duration
 	inventoryChannel      chan struct{}
+	selectors             map[types.NamespacedName]*cloudv1alpha1.CloudEntitySelector
 	logger                func() logging.Logger
-	Status                *cloudv1alpha1.CloudProviderAccountStatus
+	// eventQueue, if configured, delivers events of resources changed in between full inventories, which are then
+	// done every inventoryResyncInterval instead of every inventoryPollInterval.
+	eventQueue              CloudEventQueue
+	inventoryResyncInterval time.Duration
+	lastInventorySyncTime   time.Time
+	// snapshots of selectors are served in place of inventory, until the first full inventory started after their
+	// restore succeeds.
+	snapshotMutex sync.Mutex
+	snapshots     map[types.NamespacedName]*selectorSnapshot
+	// isAuthError tells inventory poll errors caused by rejected credentials from other errors, e.g. throttling.
+	isAuthError CloudAuthErrorFunc
+	// createServiceConfigs creates services of the account with given credentials. Services are created again every
+	// inventoryResyncInterval, since lastServicesRefreshTime, if isServicesRefreshed returns true for the credentials.
+	createServiceConfigs    func(credentials interface{}) ([]CloudServiceInterface, error)
+	isServicesRefreshed     CloudServicesRefreshFunc
+	lastServicesRefreshTime time.Time
 }
 
 type CloudCredentialValidatorFunc func(client client.Client, credentials interface{}) (interface{}, error)
 type CloudCredentialComparatorFunc func(accountName string, existing interface{}, new interface{}) bool
+type CloudCredentialProbeFunc func(credentials interface{}, helper interface{}) error
 type CloudServiceConfigCreatorFunc func(namespacedName *types.NamespacedName, cloudConvertedCredentials interface{},
 	helper interface{}) ([]CloudServiceInterface, error)
 
+// CloudAuthErrorFunc returns whether a cloud api call failed as the cloud rejected the account credentials, e.g. as
+// they are invalid, expired or not authorized.
+type CloudAuthErrorFunc func(err error) bool
+
+// CloudServicesRefreshFunc returns whether services of cloud converted account credentials may change without an
+// account update, e.g. as they are created for all regions enabled for the account, so that they are created again
+// every inventory resync interval.
+type CloudServicesRefreshFunc func(credentials interface{}) bool
+
 func (c *cloudCommon) newCloudAccountConfig(client client.Client, namespacedName *types.NamespacedName, credentials interface{},
-	pollInterval time.Duration, loggerFunc func() logging.Logger) (CloudAccountInterface, error) {
+	pollInterval time.Duration, resyncInterval time.Duration, loggerFunc func() logging.Logger) (CloudAccountInterface, error) {
 	credentialsValidatorFunc := c.commonHelper.SetAccountCredentialsFunc()
 	if credentialsValidatorFunc == nil {
 		return nil, fmt.Errorf("registered cloud-credentials validator function cannot be nil")
@@ -84,19 +144,41 @@ func (c *cloudCommon) newCloudAccountConfig(client client.Client, namespacedName
 		}
 		serviceConfigMap[serviceCfg.GetName()] = serviceConfig
 	}
+	eventQueue, err := c.newCloudEventQueue(cloudConvertedCredential)
+	if err != nil {
+		return nil, err
+	}
 
-	status := &cloudv1alpha1.CloudProviderAccountStatus{}
 	return &cloudAccountConfig{
-		logger:                loggerFunc,
-		namespacedName:        namespacedName,
-		inventoryPollInterval: pollInterval,
-		serviceConfigs:        serviceConfigMap,
-		credentials:           cloudConvertedCredential,
-		Status:                status,
+		logger:                  loggerFunc,
+		namespacedName:          namespacedName,
+		inventoryPollInterval:   pollInterval,
+		inventoryResyncInterval: resyncInterval,
+		serviceConfigs:          serviceConfigMap,
+		eventQueue:              eventQueue,
+		credentials:             cloudConvertedCredential,
+		selectors:               make(map[types.NamespacedName]*cloudv1alpha1.CloudEntitySelector),
+		snapshots:               make(map[types.NamespacedName]*selectorSnapshot),
+		isAuthError:             c.commonHelper.GetCloudAuthErrorFunc(),
+		createServiceConfigs: func(credentials interface{}) ([]CloudServiceInterface, error) {
+			return cloudServicesCreateFunc(namespacedName, credentials, c.cloudSpecificHelper)
+		},
+		isServicesRefreshed:     c.commonHelper.GetCloudServicesRefreshFunc(),
+		lastServicesRefreshTime: time.Now(),
 	}, nil
 }
 
-func (c *cloudCommon) updateCloudAccountConfig(client client.Client, credentials interface{}, config CloudAccountInterface) error {
+// newCloudEventQueue returns the event queue configured in cloud converted account credentials, nil if none.
+func (c *cloudCommon) newCloudEventQueue(cloudConvertedCredentials interface{}) (CloudEventQueue, error) {
+	eventQueueCreateFunc := c.commonHelper.GetCloudEventQueueCreateFunc()
+	if eventQueueCreateFunc == nil {
+		return nil, nil
+	}
+	return eventQueueCreateFunc(cloudConvertedCredentials, c.cloudSpecificHelper)
+}
+
+func (c *cloudCommon) updateCloudAccountConfig(client client.Client, credentials interface{}, resyncInterval time.Duration,
+	config CloudAccountInterface) error {
 	currentConfig := config.(*cloudAccountConfig)
 	credentialsValidatorFunc := c.commonHelper.SetAccountCredentialsFunc()
 	if credentialsValidatorFunc == nil {
@@ -129,17 +211,24 @@ func (c *cloudCommon) updateCloudAccountConfig(client client.Client, credentials
 	for _, serviceCfg := range serviceConfigs {
 		serviceConfigMap[serviceCfg.GetName()] = serviceCfg
 	}
+	eventQueue, err := c.newCloudEventQueue(cloudConvertedNewCredential)
+	if err != nil {
+		return err
+	}
 
-	currentConfig.update(credentialsComparatorFunc, cloudConvertedNewCredential, serviceConfigMap, c.logger())
+	currentConfig.update(credentialsComparatorFunc, cloudConvertedNewCredential, serviceConfigMap, eventQueue, resyncInterval,
+		c.logger())
 
 	return nil
 }
 
 func (accCfg *cloudAccountConfig) update(credentialComparator CloudCredentialComparatorFunc, newCredentials interface{},
-	newSvcConfigMap map[CloudServiceName]CloudServiceInterface, logger logging.Logger) {
+	newSvcConfigMap map[CloudServiceName]CloudServiceInterface, newEventQueue CloudEventQueue, resyncInterval time.Duration,
+	logger logging.Logger) {
 	accCfg.mutex.Lock()
 	defer accCfg.mutex.Unlock()
 
+	accCfg.inventoryResyncInterval = resyncInterval
 	credentialsChanged := credentialComparator(accCfg.namespacedName.String(), newCredentials, accCfg.credentials)
 	if !credentialsChanged {
 		logger.Info("credentials not changed.", "account", accCfg.namespacedName)
@@ -147,26 +236,98 @@ func (accCfg *cloudAccountConfig) update(credentialComparator CloudCredentialCom
 	}
 
 	accCfg.credentials = newCredentials
+	accCfg.eventQueue = newEventQueue
+	accCfg.lastServicesRefreshTime = time.Now()
 	logger.Info("credentials updated.", "account", accCfg.namespacedName)
 
-	existingSvcConfigMap := accCfg.serviceConfigs
-	for name, svcConfig := range existingSvcConfigMap {
+	accCfg.setServiceConfigs(newSvcConfigMap, true, logger)
+}
+
+// setServiceConfigs sets services of the account to newSvcConfigMap. Existing services are kept, and updated with
+// their new config if updateExisting is true, e.g. with new credentials. It must be called with accCfg.mutex held.
+func (accCfg *cloudAccountConfig) setServiceConfigs(newSvcConfigMap map[CloudServiceName]CloudServiceInterface,
+	updateExisting bool, logger logging.Logger) {
+	serviceConfigMap := make(map[CloudServiceName]*CloudServiceCommon)
+	for name, svcConfig := range accCfg.GetServiceConfigs() {
 		newSvcCfg, found := newSvcConfigMap[name]
 		if !found {
-			// should not happen
+			// service no longer applies to the account, e.g. its region is removed.
+			svcConfig.resetCachedState()
+			logger.Info("service config removed", "account", accCfg.namespacedName, "serviceName", name)
+			continue
+		}
+		serviceConfigMap[name] = svcConfig
+		if !updateExisting {
 			continue
 		}
 		svcConfig.updateServiceConfig(newSvcCfg)
 		logger.Info("service config updated (api-clients to use new creds)", "account", accCfg.namespacedName,
 			"serviceName", name)
 	}
+	for name, newSvcCfg := range newSvcConfigMap {
+		if _, found := serviceConfigMap[name]; found {
+			continue
+		}
+		// service newly applies to the account, e.g. its region is added. Inventory is done for existing selectors.
+		svcConfig := &CloudServiceCommon{serviceInterface: newSvcCfg}
+		for _, selector := range accCfg.selectors {
+			svcConfig.setResourceFilters(selector)
+		}
+		serviceConfigMap[name] = svcConfig
+		logger.Info("service config added", "account", accCfg.namespacedName, "serviceName", name)
+	}
+
+	accCfg.serviceMutex.Lock()
+	defer accCfg.serviceMutex.Unlock()
+	accCfg.serviceConfigs = serviceConfigMap
 }
 
-func (accCfg *cloudAccountConfig) performInventorySync() error {
+// refreshServiceConfigs creates services of the account again, if they may change without an account update, e.g.
+// with all regions, and inventoryResyncInterval has elapsed since they were last created. Services added, e.g. of a
+// region enabled since, are inventoried with the selectors of the account, services removed are dropped, and existing
+// services are kept as is.
+func (accCfg *cloudAccountConfig) refreshServiceConfigs() {
+	accCfg.mutex.Lock()
+	credentials := accCfg.credentials
+	due := accCfg.isServicesRefreshed != nil && accCfg.isServicesRefreshed(credentials) &&
+		time.Since(accCfg.lastServicesRefreshTime) >= accCfg.inventoryResyncInterval
+	if due {
+		accCfg.lastServicesRefreshTime = time.Now()
+	}
+	accCfg.mutex.Unlock()
+	if !due {
+		return
+	}
+
+	// services are created without accCfg.mutex held, as it involves cloud api calls, e.g. to list regions.
+	serviceConfigs, err := accCfg.createServiceConfigs(credentials)
+	if err != nil {
+		accCfg.logger().Info("failed to refresh services", "account", accCfg.namespacedName, "error", err)
+		return
+	}
+	newSvcConfigMap := make(map[CloudServiceName]CloudServiceInterface)
+	for _, serviceCfg := range serviceConfigs {
+		newSvcConfigMap[serviceCfg.GetName()] = serviceCfg
+	}
+
 	accCfg.mutex.Lock()
 	defer accCfg.mutex.Unlock()
+	// services are already created with new credentials of an account update since.
+	if accCfg.credentials != credentials {
+		return
+	}
+	accCfg.setServiceConfigs(newSvcConfigMap, false, accCfg.logger())
+}
 
-	serviceConfigs := accCfg.serviceConfigs
+func (accCfg *cloudAccountConfig) performInventorySync() error {
+	accCfg.inventoryMutex.Lock()
+	defer accCfg.inventoryMutex.Unlock()
+
+	syncStartTime := time.Now()
+	accCfg.mutex.Lock()
+	accCfg.lastInventorySyncTime = syncStartTime
+	accCfg.mutex.Unlock()
+	serviceConfigs := accCfg.GetServiceConfigs()
 
 	ch := make(chan error)
 	var wg sync.WaitGroup
@@ -190,7 +351,6 @@ func (accCfg *cloudAccountConfig) performInventorySync() error {
 			if err != nil {
 				accCfg.logger().Error(err, "error fetching resources from cloud", "service", serviceCfg.getName(),
 					"account", accCfg.namespacedName)
-				accCfg.Status.Error = err.Error()
 			} else {
 				if isFilterNil {
 					accCfg.logger().V(1).Info("fetching resources from cloud", "service", serviceCfg.getName(),
@@ -202,24 +362,110 @@ func (accCfg *cloudAccountConfig) performInventorySync() error {
 			}
 			inventoryStats := serviceCfg.getInventoryStats()
 			inventoryStats.UpdateInventoryPollStats(err)
+			ch <- err
 		}(serviceConfig)
 	}
 
 	var err error
+	inventoryCount := 0
 	for e := range ch {
+		inventoryCount++
 		if e != nil {
 			err = multierr.Append(err, e)
 		}
 	}
+	// restored snapshots are served until an inventory of all services succeeds.
+	if err == nil && inventoryCount > 0 {
+		accCfg.removeSelectorSnapshots(syncStartTime)
+	}
 
 	return err
 }
 
+// isInventorySyncDue returns true if periodic full inventory of the account is due. With an event queue, full
+// inventory is done every inventoryResyncInterval only, resources changed in between are received as events.
+func (accCfg *cloudAccountConfig) isInventorySyncDue() bool {
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	if accCfg.eventQueue == nil {
+		return true
+	}
+	return time.Since(accCfg.lastInventorySyncTime) >= accCfg.inventoryResyncInterval
+}
+
+func (accCfg *cloudAccountConfig) getEventQueue() CloudEventQueue {
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	return accCfg.eventQueue
+}
+
+// performEventInventorySync receives events from the event queue of the account, until the queue is empty or stopCh
+// is closed, and performs inventory of the resources of the events.
+func (accCfg *cloudAccountConfig) performEventInventorySync(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		eventQueue := accCfg.getEventQueue()
+		if eventQueue == nil {
+			return
+		}
+		events, err := eventQueue.ReceiveEvents()
+		if err != nil {
+			accCfg.logger().Error(err, "error receiving events from cloud", "account", accCfg.namespacedName)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+		if err = accCfg.performEventInventory(eventQueue, events); err != nil {
+			return
+		}
+	}
+}
+
+// performEventInventory performs inventory of the resources of events with every service of the account. Events are
+// deleted from the event queue only if inventory succeeds for all services, otherwise they are received again.
+func (accCfg *cloudAccountConfig) performEventInventory(eventQueue CloudEventQueue, events []*CloudEvent) error {
+	accCfg.inventoryMutex.Lock()
+	defer accCfg.inventoryMutex.Unlock()
+
+	var err error
+	for _, serviceCfg := range accCfg.GetServiceConfigs() {
+		if hasFilters, _ := serviceCfg.hasFiltersConfigured(); !hasFilters {
+			continue
+		}
+		if e := serviceCfg.doEventResourceInventory(events); e != nil {
+			accCfg.logger().Error(e, "error fetching event resources from cloud", "service", serviceCfg.getName(),
+				"account", accCfg.namespacedName)
+			err = multierr.Append(err, e)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	accCfg.logger().V(1).Info("fetching event resources from cloud", "account", accCfg.namespacedName,
+		"events", len(events))
+
+	if err = eventQueue.DeleteEvents(events); err != nil {
+		accCfg.logger().Error(err, "error deleting events from cloud", "account", accCfg.namespacedName)
+	}
+	return err
+}
+
 func (accCfg *cloudAccountConfig) GetNamespacedName() *types.NamespacedName {
 	return accCfg.namespacedName
 }
 
 func (accCfg *cloudAccountConfig) GetServiceConfigs() map[CloudServiceName]*CloudServiceCommon {
+	accCfg.serviceMutex.RLock()
+	defer accCfg.serviceMutex.RUnlock()
+
 	svcNameCfgMap := make(map[CloudServiceName]*CloudServiceCommon)
 	for name, serviceCommon := range accCfg.serviceConfigs {
 		svcNameCfgMap[name] = serviceCommon
@@ -228,6 +474,9 @@ func (accCfg *cloudAccountConfig) GetServiceConfigs() map[CloudServiceName]*Clou
 }
 
 func (accCfg *cloudAccountConfig) GetServiceConfigByName(name CloudServiceName) (CloudServiceInterface, error) {
+	accCfg.serviceMutex.RLock()
+	defer accCfg.serviceMutex.RUnlock()
+
 	if serviceCfg, found := accCfg.serviceConfigs[name]; found {
 		return serviceCfg.serviceInterface, nil
 	}
@@ -235,31 +484,147 @@ func (accCfg *cloudAccountConfig) GetServiceConfigByName(name CloudServiceName)
 	return nil, fmt.Errorf("%v service not found for account %v", name, accCfg.namespacedName)
 }
 
+// GetStatus returns the account status computed from the inventory poll statistics of the account services.
 func (accCfg *cloudAccountConfig) GetStatus() *cloudv1alpha1.CloudProviderAccountStatus {
-	return accCfg.Status
+	status := &cloudv1alpha1.CloudProviderAccountStatus{}
+	var errMsgs, authErrMsgs []string
+	var polledCnt, failedCnt, authFailedCnt, syncedCnt int
+	serviceConfigs := accCfg.GetServiceConfigs()
+	for name, serviceCfg := range serviceConfigs {
+		inventoryStats := serviceCfg.getInventoryStats()
+		serviceStatus := inventoryStats.getServiceStatus(name)
+		status.Services = append(status.Services, serviceStatus)
+		if serviceStatus.TotalPollCount == 0 {
+			continue
+		}
+		polledCnt++
+		if inventoryStats.lastPollFailed() {
+			failedCnt++
+			errMsgs = append(errMsgs, serviceStatus.LastPollError)
+			if accCfg.isAuthError != nil && accCfg.isAuthError(inventoryStats.getLastPollError()) {
+				authFailedCnt++
+				authErrMsgs = append(authErrMsgs, serviceStatus.LastPollError)
+			}
+		}
+		if serviceStatus.LastSuccessfulPollTime != nil {
+			syncedCnt++
+			if status.LastSuccessfulPollTime == nil || serviceStatus.LastSuccessfulPollTime.Before(status.LastSuccessfulPollTime) {
+				status.LastSuccessfulPollTime = serviceStatus.LastSuccessfulPollTime
+			}
+		}
+	}
+	sort.Slice(status.Services, func(i, j int) bool {
+		return status.Services[i].Name < status.Services[j].Name
+	})
+	sort.Strings(errMsgs)
+	sort.Strings(authErrMsgs)
+	status.Error = strings.Join(errMsgs, "; ")
+	if syncedCnt != len(serviceConfigs) {
+		status.LastSuccessfulPollTime = nil
+	}
+	status.Conditions = computeAccountConditions(polledCnt, failedCnt, authFailedCnt, syncedCnt == len(serviceConfigs),
+		status.Error, strings.Join(authErrMsgs, "; "))
+	return status
+}
+
+// computeAccountConditions computes the account conditions from the number of services polled, the number of
+// services whose last poll failed, the number of those failed as credentials were rejected, and whether every service
+// has been polled successfully at least once. CredentialsValid is owned by the credentials probe, it is reported only
+// as False when polls fail with credentials rejected, and as True once polls succeed again, other poll errors like
+// throttling leave it unchanged.
+func computeAccountConditions(polledCnt, failedCnt, authFailedCnt int, allSynced bool, errMsg, authErrMsg string) []metav1.Condition {
+	inventory := metav1.Condition{Type: cloudv1alpha1.CloudProviderAccountConditionInventorySynced}
+	enforcement := metav1.Condition{Type: cloudv1alpha1.CloudProviderAccountConditionSecurityEnforcementReady}
+
+	switch {
+	case polledCnt == 0:
+		inventory.Status, inventory.Reason = metav1.ConditionFalse, AccountConditionReasonInventoryPending
+		inventory.Message = "no inventory poll performed yet"
+		enforcement.Status, enforcement.Reason = metav1.ConditionFalse, AccountConditionReasonInventoryUnavailable
+		enforcement.Message = "inventory of all cloud services has not been polled successfully yet"
+		return []metav1.Condition{inventory, enforcement}
+	case failedCnt > 0:
+		inventory.Status, inventory.Reason, inventory.Message = metav1.ConditionFalse, AccountConditionReasonInventoryPollFailed, errMsg
+	default:
+		inventory.Status, inventory.Reason = metav1.ConditionTrue, AccountConditionReasonInventoryPollSucceeded
+	}
+
+	if failedCnt < polledCnt && authFailedCnt == 0 && allSynced {
+		enforcement.Status, enforcement.Reason = metav1.ConditionTrue, AccountConditionReasonInventoryAvailable
+	} else {
+		enforcement.Status, enforcement.Reason = metav1.ConditionFalse, AccountConditionReasonInventoryUnavailable
+		enforcement.Message = "inventory of all cloud services has not been polled successfully yet"
+	}
+	conditions := []metav1.Condition{inventory, enforcement}
+
+	credentials := metav1.Condition{Type: cloudv1alpha1.CloudProviderAccountConditionCredentialsValid}
+	switch {
+	case authFailedCnt > 0:
+		credentials.Status, credentials.Reason = metav1.ConditionFalse, cloudv1alpha1.CloudProviderAccountReasonCloudAPIAuthFailed
+		credentials.Message = authErrMsg
+	case failedCnt < polledCnt:
+		credentials.Status, credentials.Reason = metav1.ConditionTrue, cloudv1alpha1.CloudProviderAccountReasonCloudAPIAuthSucceeded
+	default:
+		return conditions
+	}
+	return append([]metav1.Condition{credentials}, conditions...)
 }
 
 func (accCfg *cloudAccountConfig) startPeriodicInventorySync() error {
-	err := accCfg.performInventorySync()
-	if err != nil {
-		return err
+	// inventory failure is reported in account status, and inventory is retried by the periodic inventory.
+	if err := accCfg.performInventorySync(); err != nil {
+		accCfg.logger().Info("inventory failed, retrying periodically", "account", accCfg.namespacedName, "error", err)
+	}
+
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	if accCfg.inventoryChannel == nil {
+		accCfg.startInventoryPoll(false)
 	}
 
+	return nil
+}
+
+// startPeriodicInventorySyncInBackground starts periodic inventory of the account without waiting for the first
+// inventory, which is performed immediately. If periodic inventory is already started, an inventory is performed
+// immediately for resource filters changed since the last one.
+func (accCfg *cloudAccountConfig) startPeriodicInventorySyncInBackground() {
 	accCfg.mutex.Lock()
 	defer accCfg.mutex.Unlock()
 
 	if accCfg.inventoryChannel == nil {
-		ch := make(chan struct{})
-		condFunc := func() (bool, error) {
+		accCfg.startInventoryPoll(true)
+		return
+	}
+	go func() {
+		_ = accCfg.performInventorySync()
+	}()
+}
+
+// startInventoryPoll starts periodic inventory and event inventory of the account. It must be called with
+// accCfg.mutex held.
+func (accCfg *cloudAccountConfig) startInventoryPoll(immediate bool) {
+	ch := make(chan struct{})
+	condFunc := func() (bool, error) {
+		accCfg.refreshServiceConfigs()
+		if accCfg.isInventorySyncDue() {
 			_ = accCfg.performInventorySync()
-			return false, nil
 		}
+		return false, nil
+	}
+	if immediate {
+		// nolint:errcheck
+		go wait.PollImmediateUntil(accCfg.inventoryPollInterval, condFunc, ch)
+	} else {
 		// nolint:errcheck
 		go wait.PollUntil(accCfg.inventoryPollInterval, condFunc, ch)
-		accCfg.inventoryChannel = ch
 	}
-
-	return err
+	// event queue may be configured later by an account update, it is looked up on every receive.
+	go wait.Until(func() {
+		accCfg.performEventInventorySync(ch)
+	}, cloudEventPollInterval, ch)
+	accCfg.inventoryChannel = ch
 }
 
 func (accCfg *cloudAccountConfig) stopPeriodicInventorySync() {
@@ -268,7 +633,83 @@ func (accCfg *cloudAccountConfig) stopPeriodicInventorySync() {
 		accCfg.inventoryChannel = nil
 	}
 
-	for _, serviceConfig := range accCfg.serviceConfigs {
+	for _, serviceConfig := range accCfg.GetServiceConfigs() {
 		serviceConfig.resetCachedState()
 	}
+	accCfg.removeSelectorSnapshots(time.Now())
+}
+
+// addSelector adds selector to the set of selectors configured for the account. Selectors are kept to configure
+// resource filters of services added to the account later.
+func (accCfg *cloudAccountConfig) addSelector(selector *cloudv1alpha1.CloudEntitySelector) {
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	accCfg.selectors[types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}] = selector.DeepCopy()
+}
+
+// removeSelector removes selector from the set of selectors configured for the account and returns the number of
+// selectors remaining.
+func (accCfg *cloudAccountConfig) removeSelector(selectorNamespacedName *types.NamespacedName) int {
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	delete(accCfg.selectors, *selectorNamespacedName)
+	accCfg.snapshotMutex.Lock()
+	delete(accCfg.snapshots, *selectorNamespacedName)
+	accCfg.snapshotMutex.Unlock()
+	return len(accCfg.selectors)
+}
+
+// getSelectors returns selectors configured for the account.
+func (accCfg *cloudAccountConfig) getSelectors() []types.NamespacedName {
+	accCfg.mutex.Lock()
+	defer accCfg.mutex.Unlock()
+
+	selectors := make([]types.NamespacedName, 0, len(accCfg.selectors))
+	for selector := range accCfg.selectors {
+		selectors = append(selectors, selector)
+	}
+	return selectors
+}
+
+// restoreSelectorSnapshot restores a snapshot of compute resource CRDs of the selector, which is served until the
+// first full inventory of the account started after the restore succeeds.
+func (accCfg *cloudAccountConfig) restoreSelectorSnapshot(selectorNamespacedName *types.NamespacedName,
+	vms []*cloudv1alpha1.VirtualMachine) {
+	accCfg.snapshotMutex.Lock()
+	defer accCfg.snapshotMutex.Unlock()
+
+	accCfg.snapshots[*selectorNamespacedName] = &selectorSnapshot{restoreTime: time.Now(), virtualMachines: vms}
+}
+
+// getSelectorSnapshot returns a copy of the restored snapshot of compute resource CRDs of the selector, if any.
+func (accCfg *cloudAccountConfig) getSelectorSnapshot(selectorNamespacedName *types.NamespacedName) (
+	[]*cloudv1alpha1.VirtualMachine, bool) {
+	accCfg.snapshotMutex.Lock()
+	defer accCfg.snapshotMutex.Unlock()
+
+	snapshot, found := accCfg.snapshots[*selectorNamespacedName]
+	if !found {
+		return nil, false
+	}
+	vms := make([]*cloudv1alpha1.VirtualMachine, 0, len(snapshot.virtualMachines))
+	for _, vm := range snapshot.virtualMachines {
+		vms = append(vms, vm.DeepCopy())
+	}
+	return vms, true
+}
+
+// removeSelectorSnapshots removes snapshots restored before given time, which are superseded by inventory.
+func (accCfg *cloudAccountConfig) removeSelectorSnapshots(before time.Time) {
+	accCfg.snapshotMutex.Lock()
+	defer accCfg.snapshotMutex.Unlock()
+
+	for name, snapshot := range accCfg.snapshots {
+		if snapshot.restoreTime.Before(before) {
+			accCfg.logger().Info("inventory snapshot superseded by inventory", "account", accCfg.namespacedName,
+				"selector", name)
+			delete(accCfg.snapshots, name)
+		}
+	}
 }
@@ -17,6 +17,7 @@ package internal
 import (
 	"fmt"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
 	"sync"
 	"time"
 
@@ -34,6 +35,10 @@ type CloudCommonHelperInterface interface {
 	GetCloudServicesCreateFunc() CloudServiceConfigCreatorFunc
 	SetAccountCredentialsFunc() CloudCredentialValidatorFunc
 	GetCloudCredentialsComparatorFunc() CloudCredentialComparatorFunc
+	GetCloudCredentialsProbeFunc() CloudCredentialProbeFunc
+	GetCloudEventQueueCreateFunc() CloudEventQueueCreatorFunc
+	GetCloudAuthErrorFunc() CloudAuthErrorFunc
+	GetCloudServicesRefreshFunc() CloudServicesRefreshFunc
 }
 
 // CloudCommonInterface implements functionality common across all supported cloud-plugins. Each cloud plugin uses
@@ -44,13 +49,18 @@ type CloudCommonInterface interface {
 
 	GetCloudAccountComputeResourceCRDs(namespacedName *types.NamespacedName) ([]*cloudv1alpha1.VirtualMachine,
 		error)
+	GetCloudAccountSelectorComputeResourceCRDs(accNamespacedName *types.NamespacedName,
+		selectorNamespacedName *types.NamespacedName) ([]*cloudv1alpha1.VirtualMachine, error)
 	GetAllCloudAccountsComputeResourceCRDs() ([]*cloudv1alpha1.VirtualMachine, error)
 
 	AddCloudAccount(client client.Client, account *cloudv1alpha1.CloudProviderAccount, credentials interface{}) error
 	RemoveCloudAccount(namespacedName *types.NamespacedName)
+	ProbeCloudAccountCredentials(client client.Client, credentials interface{}) error
 
 	AddSelector(namespacedName *types.NamespacedName, selector *cloudv1alpha1.CloudEntitySelector) error
-	RemoveSelector(accNamespacedName *types.NamespacedName, selectorName string)
+	AddSelectorWithSnapshot(namespacedName *types.NamespacedName, selector *cloudv1alpha1.CloudEntitySelector,
+		vms []*cloudv1alpha1.VirtualMachine) error
+	RemoveSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName)
 
 	GetStatus(accNamespacedName *types.NamespacedName) (*cloudv1alpha1.CloudProviderAccountStatus, error)
 }
@@ -83,13 +93,18 @@ func (c *cloudCommon) AddCloudAccount(client client.Client, account *cloudv1alph
 		Name:      account.GetName(),
 	}
 
+	pollInterval := time.Duration(*account.Spec.PollIntervalInSeconds) * time.Second
+	resyncInterval := pollInterval
+	if account.Spec.ResyncIntervalInSeconds != nil {
+		resyncInterval = time.Duration(*account.Spec.ResyncIntervalInSeconds) * time.Second
+	}
+
 	existingConfig, found := c.accountConfigs[*namespacedName]
 	if found {
-		return c.updateCloudAccountConfig(client, credentials, existingConfig)
+		return c.updateCloudAccountConfig(client, credentials, resyncInterval, existingConfig)
 	}
 
-	config, err := c.newCloudAccountConfig(client, namespacedName, credentials,
-		time.Duration(*account.Spec.PollIntervalInSeconds)*time.Second, c.logger)
+	config, err := c.newCloudAccountConfig(client, namespacedName, credentials, pollInterval, resyncInterval, c.logger)
 	if err != nil {
 		return err
 	}
@@ -99,11 +114,35 @@ func (c *cloudCommon) AddCloudAccount(client client.Client, account *cloudv1alph
 	return nil
 }
 
+// ProbeCloudAccountCredentials makes lightweight cloud API calls with the given account credentials, to check they
+// are accepted by the cloud. The account is not added.
+func (c *cloudCommon) ProbeCloudAccountCredentials(client client.Client, credentials interface{}) error {
+	credentialsValidatorFunc := c.commonHelper.SetAccountCredentialsFunc()
+	if credentialsValidatorFunc == nil {
+		return fmt.Errorf("registered cloud-credentials validator function cannot be nil")
+	}
+	cloudConvertedCredential, err := credentialsValidatorFunc(client, credentials)
+	if err != nil {
+		return err
+	}
+	if cloudConvertedCredential == nil {
+		return fmt.Errorf("cloud credentials cannot be nil")
+	}
+
+	credentialsProbeFunc := c.commonHelper.GetCloudCredentialsProbeFunc()
+	if credentialsProbeFunc == nil {
+		c.logger().V(1).Info("cloud credentials probe func nil. credentials not probed.")
+		return nil
+	}
+	return credentialsProbeFunc(cloudConvertedCredential, c.cloudSpecificHelper)
+}
+
 func (c *cloudCommon) deleteCloudAccount(namespacedName *types.NamespacedName) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	delete(c.accountConfigs, *namespacedName)
+	cancelCloudAPICalls(namespacedName.String())
 }
 
 func (c *cloudCommon) RemoveCloudAccount(namespacedName *types.NamespacedName) {
@@ -136,15 +175,10 @@ func (c *cloudCommon) GetCloudAccountComputeResourceCRDs(accountNamespacedName *
 	if !found {
 		return nil, fmt.Errorf("unable to find cloud account:%v", *accountNamespacedName)
 	}
-	namespace := accCfg.GetNamespacedName().Namespace
 
 	var computeCRDs []*cloudv1alpha1.VirtualMachine
-	serviceConfigs := accCfg.GetServiceConfigs()
-	for _, serviceConfig := range serviceConfigs {
-		if serviceConfig.getType() == CloudServiceTypeCompute {
-			resourceCRDs := serviceConfig.getResourceCRDs(namespace)
-			computeCRDs = append(computeCRDs, resourceCRDs.virtualMachines...)
-		}
+	for _, selectorComputeCRDs := range getComputeResourceCRDsBySelector(accCfg) {
+		computeCRDs = append(computeCRDs, selectorComputeCRDs...)
 	}
 
 	c.logger().V(1).Info("account CRDs", "account", accountNamespacedName, "service-type", CloudServiceTypeCompute,
@@ -153,6 +187,64 @@ func (c *cloudCommon) GetCloudAccountComputeResourceCRDs(accountNamespacedName *
 	return computeCRDs, nil
 }
 
+func (c *cloudCommon) GetCloudAccountSelectorComputeResourceCRDs(accountNamespacedName *types.NamespacedName,
+	selectorNamespacedName *types.NamespacedName) ([]*cloudv1alpha1.VirtualMachine, error) {
+	accCfg, found := c.GetCloudAccountByName(accountNamespacedName)
+	if !found {
+		return nil, fmt.Errorf("unable to find cloud account:%v", *accountNamespacedName)
+	}
+
+	computeCRDs := getComputeResourceCRDsBySelector(accCfg)[*selectorNamespacedName]
+
+	c.logger().V(1).Info("selector CRDs", "account", accountNamespacedName, "selector", selectorNamespacedName,
+		"service-type", CloudServiceTypeCompute, "compute", len(computeCRDs))
+
+	return computeCRDs, nil
+}
+
+// getComputeResourceCRDsBySelector returns compute resource CRDs of the account keyed by the selector which matched
+// them. Each CRD is placed in the namespace of its selector. When more than one selector in a namespace matches the
+// same resource, the resource is given to the selector with the lowest name, so that its CRD has only one owner.
+// A selector with a restored snapshot is given the CRDs of its snapshot instead.
+func getComputeResourceCRDsBySelector(accCfg CloudAccountInterface) map[types.NamespacedName][]*cloudv1alpha1.VirtualMachine {
+	selectors := accCfg.getSelectors()
+	sort.Slice(selectors, func(i, j int) bool {
+		if selectors[i].Namespace != selectors[j].Namespace {
+			return selectors[i].Namespace < selectors[j].Namespace
+		}
+		return selectors[i].Name < selectors[j].Name
+	})
+
+	computeCRDs := make(map[types.NamespacedName][]*cloudv1alpha1.VirtualMachine)
+	assigned := make(map[types.NamespacedName]struct{})
+	serviceConfigs := accCfg.GetServiceConfigs()
+	for i := range selectors {
+		selector := selectors[i]
+		if vms, found := accCfg.getSelectorSnapshot(&selector); found {
+			for _, vm := range vms {
+				assigned[types.NamespacedName{Namespace: vm.Namespace, Name: vm.Name}] = struct{}{}
+			}
+			computeCRDs[selector] = vms
+			continue
+		}
+		for _, serviceConfig := range serviceConfigs {
+			if serviceConfig.getType() != CloudServiceTypeCompute {
+				continue
+			}
+			resourceCRDs := serviceConfig.getResourceCRDs(&selector)
+			for _, vm := range resourceCRDs.virtualMachines {
+				vmNamespacedName := types.NamespacedName{Namespace: vm.Namespace, Name: vm.Name}
+				if _, found := assigned[vmNamespacedName]; found {
+					continue
+				}
+				assigned[vmNamespacedName] = struct{}{}
+				computeCRDs[selector] = append(computeCRDs[selector], vm)
+			}
+		}
+	}
+	return computeCRDs
+}
+
 func (c *cloudCommon) GetAllCloudAccountsComputeResourceCRDs() ([]*cloudv1alpha1.VirtualMachine,
 	error) {
 	var err error
@@ -175,6 +267,7 @@ func (c *cloudCommon) AddSelector(accountNamespacedName *types.NamespacedName, s
 		return fmt.Errorf("account not found %v", *accountNamespacedName)
 	}
 
+	accCfg.addSelector(selector)
 	for _, serviceCfg := range accCfg.GetServiceConfigs() {
 		serviceCfg.setResourceFilters(selector)
 	}
@@ -187,13 +280,40 @@ func (c *cloudCommon) AddSelector(accountNamespacedName *types.NamespacedName, s
 	return nil
 }
 
-func (c *cloudCommon) RemoveSelector(accNamespacedName *types.NamespacedName, selectorName string) {
+// AddSelectorWithSnapshot adds selector as AddSelector does, except that inventory of the account is performed in
+// background. Until the first inventory succeeds, compute resource CRDs of the selector are served from the given
+// snapshot, e.g. checkpointed before a controller restart.
+func (c *cloudCommon) AddSelectorWithSnapshot(accountNamespacedName *types.NamespacedName,
+	selector *cloudv1alpha1.CloudEntitySelector, vms []*cloudv1alpha1.VirtualMachine) error {
+	accCfg, found := c.GetCloudAccountByName(accountNamespacedName)
+	if !found {
+		return fmt.Errorf("account not found %v", *accountNamespacedName)
+	}
+
+	accCfg.addSelector(selector)
+	accCfg.restoreSelectorSnapshot(&types.NamespacedName{Namespace: selector.Namespace, Name: selector.Name}, vms)
+	for _, serviceCfg := range accCfg.GetServiceConfigs() {
+		serviceCfg.setResourceFilters(selector)
+	}
+	accCfg.startPeriodicInventorySyncInBackground()
+
+	return nil
+}
+
+func (c *cloudCommon) RemoveSelector(accNamespacedName *types.NamespacedName, selectorNamespacedName *types.NamespacedName) {
 	accCfg, found := c.GetCloudAccountByName(accNamespacedName)
 	if !found {
-		c.logger().Info("Not found", "account", *accNamespacedName, "name", selectorName)
+		c.logger().Info("Not found", "account", *accNamespacedName, "selector", *selectorNamespacedName)
 		return
 	}
 
+	// inventory continues for the account as long as other selectors remain.
+	if remaining := accCfg.removeSelector(selectorNamespacedName); remaining > 0 {
+		for _, serviceCfg := range accCfg.GetServiceConfigs() {
+			serviceCfg.removeResourceFilters(selectorNamespacedName)
+		}
+		return
+	}
 	accCfg.stopPeriodicInventorySync()
 }
 
@@ -0,0 +1,49 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+// CloudEvent is a notification from the cloud that resources of an account changed, e.g. a VM was started,
+// terminated or re-tagged.
+type CloudEvent struct {
+	// AccountID is the cloud account (AWS account ID, Azure subscription ID) of the resources, empty if unknown.
+	AccountID string
+	// Region of the resources, empty if unknown.
+	Region string
+	// ResourceIDs are cloud IDs of the changed resources. It is empty for events not about a supported resource.
+	ResourceIDs []string
+	// Handle identifies the event message in the queue, to delete it once handled.
+	Handle string
+}
+
+// CloudEventQueue is a queue of cloud events of an account, e.g. an AWS SQS queue or an Azure Storage queue. Events
+// are deleted from the queue only after inventory of their resources succeeds, events of a failed inventory are
+// received again once their visibility timeout expires.
+type CloudEventQueue interface {
+	// ReceiveEvents waits for events, and returns events available in the queue.
+	ReceiveEvents() ([]*CloudEvent, error)
+	// DeleteEvents deletes handled events from the queue.
+	DeleteEvents(events []*CloudEvent) error
+}
+
+// CloudServiceEventInterface is implemented by cloud-services supporting incremental inventory from cloud events.
+type CloudServiceEventInterface interface {
+	// DoEventResourceInventory performs inventory of the resources of events belonging to the service, and updates
+	// them in service cache CloudServiceResourcesCache. Resources no longer found, or no longer matched by resource
+	// filters, are removed from the cache. It is a no-op until the first full inventory of the service.
+	DoEventResourceInventory(events []*CloudEvent) error
+}
+
+// CloudEventQueueCreatorFunc returns the event queue configured in cloud converted account credentials, nil if none.
+type CloudEventQueueCreatorFunc func(cloudConvertedCredentials interface{}, helper interface{}) (CloudEventQueue, error)
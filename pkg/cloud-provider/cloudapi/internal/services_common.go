@@ -18,6 +18,9 @@ import (
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
 	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
 )
 
@@ -42,8 +45,11 @@ type CloudServiceInterface interface {
 	// more fields of the service.
 	UpdateServiceConfig(newServiceConfig CloudServiceInterface)
 	// SetResourceFilters will be used by service to get resources from cloud for the service. Each will convert
-	// CloudEntitySelector to service understandable filters.
+	// CloudEntitySelector to service understandable filters. Filters are kept per selector and inventory is done
+	// for the union of filters of all selectors.
 	SetResourceFilters(selector *cloudv1alpha1.CloudEntitySelector)
+	// RemoveResourceFilters removes resource filters of the given selector, filters of other selectors are kept.
+	RemoveResourceFilters(selectorNamespacedName *types.NamespacedName)
 	// HasFiltersConfigured returns if service has filters configured and if the configured filters are nil or not.
 	HasFiltersConfigured() (bool, bool)
 	// DoResourceInventory performs resource inventory for the cloud service based on configured filters. As part
@@ -51,8 +57,9 @@ type CloudServiceInterface interface {
 	DoResourceInventory() error
 	// GetInventoryStats returns Inventory statistics for the service.
 	GetInventoryStats() *CloudServiceStats
-	// GetResourceCRDs returns Service resource saved in CloudServiceResourcesCache in terms of CRD.
-	GetResourceCRDs(namespace string) *CloudServiceResourceCRDs
+	// GetResourceCRDs returns Service resource saved in CloudServiceResourcesCache matched by the given selector,
+	// in terms of CRD in the selector namespace.
+	GetResourceCRDs(selectorNamespacedName *types.NamespacedName) *CloudServiceResourceCRDs
 	// GetName returns cloud name of the service.
 	GetName() CloudServiceName
 	// GetType returns service type (compute, any other type etc.)
@@ -75,6 +82,13 @@ func (cfg *CloudServiceCommon) setResourceFilters(selector *cloudv1alpha1.CloudE
 	cfg.serviceInterface.SetResourceFilters(selector)
 }
 
+func (cfg *CloudServiceCommon) removeResourceFilters(selectorNamespacedName *types.NamespacedName) {
+	cfg.mutex.Lock()
+	defer cfg.mutex.Unlock()
+
+	cfg.serviceInterface.RemoveResourceFilters(selectorNamespacedName)
+}
+
 func (cfg *CloudServiceCommon) hasFiltersConfigured() (bool, bool) {
 	cfg.mutex.Lock()
 	defer cfg.mutex.Unlock()
@@ -89,6 +103,19 @@ func (cfg *CloudServiceCommon) doResourceInventory() error {
 	return cfg.serviceInterface.DoResourceInventory()
 }
 
+// doEventResourceInventory performs inventory of the resources of given events, if the service supports incremental
+// inventory. Otherwise, changes are picked by the next full inventory.
+func (cfg *CloudServiceCommon) doEventResourceInventory(events []*CloudEvent) error {
+	cfg.mutex.Lock()
+	defer cfg.mutex.Unlock()
+
+	eventService, ok := cfg.serviceInterface.(CloudServiceEventInterface)
+	if !ok {
+		return nil
+	}
+	return eventService.DoEventResourceInventory(events)
+}
+
 func (cfg *CloudServiceCommon) getInventoryStats() *CloudServiceStats {
 	cfg.mutex.Lock()
 	defer cfg.mutex.Unlock()
@@ -96,11 +123,11 @@ func (cfg *CloudServiceCommon) getInventoryStats() *CloudServiceStats {
 	return cfg.serviceInterface.GetInventoryStats()
 }
 
-func (cfg *CloudServiceCommon) getResourceCRDs(namespace string) *CloudServiceResourceCRDs {
+func (cfg *CloudServiceCommon) getResourceCRDs(selectorNamespacedName *types.NamespacedName) *CloudServiceResourceCRDs {
 	cfg.mutex.Lock()
 	defer cfg.mutex.Unlock()
 
-	return cfg.serviceInterface.GetResourceCRDs(namespace)
+	return cfg.serviceInterface.GetResourceCRDs(selectorNamespacedName)
 }
 
 func (cfg *CloudServiceCommon) getName() CloudServiceName {
@@ -156,11 +183,13 @@ func (cache *CloudServiceResourcesCache) GetSnapshot() interface{} {
 }
 
 type CloudServiceStats struct {
-	mutex           sync.Mutex
-	totalPollCnt    uint64
-	successPollCnt  uint64
-	lastPollErr     error
-	lastPollErrTime time.Time
+	mutex               sync.Mutex
+	totalPollCnt        uint64
+	successPollCnt      uint64
+	lastPollErr         error
+	lastPollErrTime     time.Time
+	lastPollSuccessTime time.Time
+	resourceCnt         int
 }
 
 func (s *CloudServiceStats) IsInventoryInitialized() bool {
@@ -177,6 +206,7 @@ func (s *CloudServiceStats) UpdateInventoryPollStats(err error) {
 	s.totalPollCnt++
 	if err == nil {
 		s.successPollCnt++
+		s.lastPollSuccessTime = time.Now()
 		return
 	}
 	s.lastPollErrTime = time.Now()
@@ -191,4 +221,54 @@ func (s *CloudServiceStats) ResetInventoryPollStats() {
 	s.successPollCnt = 0
 	s.lastPollErrTime = time.Time{}
 	s.lastPollErr = nil
+	s.lastPollSuccessTime = time.Time{}
+	s.resourceCnt = 0
+}
+
+// UpdateInventoryResourceCount updates the number of resources found by the last successful inventory poll.
+func (s *CloudServiceStats) UpdateInventoryResourceCount(count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.resourceCnt = count
+}
+
+// getServiceStatus returns inventory poll statistics of the service in terms of CloudServiceStatus.
+func (s *CloudServiceStats) getServiceStatus(name CloudServiceName) cloudv1alpha1.CloudServiceStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := cloudv1alpha1.CloudServiceStatus{
+		Name:                string(name),
+		TotalPollCount:      int64(s.totalPollCnt),
+		SuccessfulPollCount: int64(s.successPollCnt),
+		VirtualMachineCount: s.resourceCnt,
+	}
+	if !s.lastPollSuccessTime.IsZero() {
+		status.LastSuccessfulPollTime = &metav1.Time{Time: s.lastPollSuccessTime}
+	}
+	if s.lastPollErr != nil {
+		status.LastPollError = s.lastPollErr.Error()
+		status.LastPollErrorTime = &metav1.Time{Time: s.lastPollErrTime}
+	}
+	return status
+}
+
+// getLastPollError returns the error of the last inventory poll of the service, nil if it succeeded.
+func (s *CloudServiceStats) getLastPollError() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.lastPollErr == nil || !s.lastPollErrTime.After(s.lastPollSuccessTime) {
+		return nil
+	}
+	return s.lastPollErr
+}
+
+// lastPollFailed returns true if the last inventory poll of the service failed.
+func (s *CloudServiceStats) lastPollFailed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastPollErr != nil && s.lastPollErrTime.After(s.lastPollSuccessTime)
 }
@@ -0,0 +1,233 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+	"golang.org/x/time/rate"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"antrea.io/nephe/pkg/logging"
+)
+
+const (
+	// CloudAPIRateLimit is the rate of cloud api calls of an account, in calls per second.
+	CloudAPIRateLimit = 10
+	// CloudAPIRateBurst is the number of cloud api calls of an account allowed at once, above CloudAPIRateLimit.
+	CloudAPIRateBurst = 20
+	// CloudAPIMaxConcurrency is the maximum number of cloud api calls of one operation in flight, e.g. of updating
+	// security groups of many network interfaces.
+	CloudAPIMaxConcurrency = 8
+
+	// cloudAPIMinRateLimit is the rate of cloud api calls of an account, below which throttling does not lower it.
+	cloudAPIMinRateLimit = 1
+	// cloudAPIRateLimitStep is the increase of the rate of cloud api calls of an account after a call succeeds, until
+	// CloudAPIRateLimit is restored.
+	cloudAPIRateLimitStep = 0.1
+	// cloudAPIMaxRetryTime is the maximum duration throttled cloud api calls are retried for.
+	cloudAPIMaxRetryTime = 2 * time.Minute
+)
+
+var (
+	cloudAPIThrottledCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "nephe_cloud_api_throttled_total",
+		Help: "Number of cloud api calls throttled by cloud.",
+	}, []string{"provider", "account", "operation"})
+	cloudAPIRateLimitedSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "nephe_cloud_api_rate_limited_seconds_total",
+		Help: "Time cloud api calls waited for the account rate limiter, in seconds.",
+	}, []string{"provider", "account"})
+)
+
+// cloudAPIContexts are contexts of cloud api calls, keyed by account. A context is cancelled once its account is
+// removed, so that calls of the account waiting for the account rate or for retries return at once.
+var (
+	cloudAPIContextsMutex sync.Mutex
+	cloudAPIContexts      = make(map[string]*cloudAPIContext)
+)
+
+type cloudAPIContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func init() {
+	metrics.Registry.MustRegister(cloudAPIThrottledCount, cloudAPIRateLimitedSeconds)
+}
+
+// getCloudAPIContext returns the context of cloud api calls of account.
+func getCloudAPIContext(account string) context.Context {
+	cloudAPIContextsMutex.Lock()
+	defer cloudAPIContextsMutex.Unlock()
+
+	apiCtx, found := cloudAPIContexts[account]
+	if !found {
+		ctx, cancel := context.WithCancel(context.Background())
+		apiCtx = &cloudAPIContext{ctx: ctx, cancel: cancel}
+		cloudAPIContexts[account] = apiCtx
+	}
+	return apiCtx.ctx
+}
+
+// cancelCloudAPICalls cancels the context of cloud api calls of account, e.g. once the account is removed. Limiters
+// created for the account afterwards use a new context.
+func cancelCloudAPICalls(account string) {
+	cloudAPIContextsMutex.Lock()
+	defer cloudAPIContextsMutex.Unlock()
+
+	if apiCtx, found := cloudAPIContexts[account]; found {
+		apiCtx.cancel()
+		delete(cloudAPIContexts, account)
+	}
+}
+
+// CloudAPIThrottleFunc returns whether a cloud api call failed as it was throttled by cloud, and the duration to wait
+// before retrying it given by cloud, e.g. by a Retry-After header, zero if none.
+type CloudAPIThrottleFunc func(err error) (throttled bool, retryAfter time.Duration)
+
+// CloudAPILimiter limits the rate of cloud api calls of an account with a token bucket, shared by all services of the
+// account. Calls throttled by cloud are retried with exponential backoff and jitter, and lower the rate of the account
+// until calls succeed again.
+type CloudAPILimiter struct {
+	limiter     *rate.Limiter
+	ctx         context.Context
+	provider    string
+	account     string
+	isThrottled CloudAPIThrottleFunc
+	logger      func() logging.Logger
+}
+
+// NewCloudAPILimiter returns the cloud api rate limiter of an account.
+func NewCloudAPILimiter(provider string, account string, isThrottled CloudAPIThrottleFunc,
+	logger func() logging.Logger) *CloudAPILimiter {
+	return &CloudAPILimiter{
+		limiter:     rate.NewLimiter(CloudAPIRateLimit, CloudAPIRateBurst),
+		ctx:         getCloudAPIContext(account),
+		provider:    provider,
+		account:     account,
+		isThrottled: isThrottled,
+		logger:      logger,
+	}
+}
+
+// Do calls a cloud api operation once the account rate allows it, and retries it while it is throttled by cloud, for
+// up to cloudAPIMaxRetryTime. Retries wait for the exponential backoff, or for the duration given by cloud if longer.
+// Waits end once the account is removed, the last error of the operation is then returned, if any.
+// A nil limiter calls the operation once, e.g. for calls outside of account services like credentials probes.
+func (l *CloudAPILimiter) Do(operation string, call func() error) error {
+	if l == nil {
+		return call()
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = cloudAPIMaxRetryTime
+	for {
+		start := time.Now()
+		if err := l.limiter.Wait(l.ctx); err != nil {
+			return err
+		}
+		cloudAPIRateLimitedSeconds.WithLabelValues(l.provider, l.account).Add(time.Since(start).Seconds())
+
+		err := call()
+		throttled, retryAfter := false, time.Duration(0)
+		if err != nil {
+			throttled, retryAfter = l.isThrottled(err)
+		}
+		if !throttled {
+			l.restoreRate()
+			return err
+		}
+
+		cloudAPIThrottledCount.WithLabelValues(l.provider, l.account, operation).Inc()
+		limit := l.lowerRate()
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		l.logger().Info("cloud api call throttled", "account", l.account, "operation", operation, "backoff", wait,
+			"rate-limit", limit)
+		select {
+		case <-l.ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// lowerRate halves the rate of cloud api calls of the account, down to cloudAPIMinRateLimit, and returns it.
+func (l *CloudAPILimiter) lowerRate() rate.Limit {
+	limit := l.limiter.Limit() / 2
+	if limit < cloudAPIMinRateLimit {
+		limit = cloudAPIMinRateLimit
+	}
+	l.limiter.SetLimit(limit)
+	return limit
+}
+
+// restoreRate increases a lowered rate of cloud api calls of the account by cloudAPIRateLimitStep, up to
+// CloudAPIRateLimit.
+func (l *CloudAPILimiter) restoreRate() {
+	limit := l.limiter.Limit()
+	if limit >= CloudAPIRateLimit {
+		return
+	}
+	limit += cloudAPIRateLimitStep
+	if limit > CloudAPIRateLimit {
+		limit = CloudAPIRateLimit
+	}
+	l.limiter.SetLimit(limit)
+}
+
+// RunConcurrently runs tasks, at most CloudAPIMaxConcurrency at a time, and returns errors of all failed tasks.
+func RunConcurrently(tasks []func() error) error {
+	var err error
+	var errMutex sync.Mutex
+	var wg sync.WaitGroup
+	taskCh := make(chan func() error)
+
+	workers := CloudAPIMaxConcurrency
+	if len(tasks) < workers {
+		workers = len(tasks)
+	}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for task := range taskCh {
+				if e := task(); e != nil {
+					errMutex.Lock()
+					err = multierr.Append(err, e)
+					errMutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, task := range tasks {
+		taskCh <- task
+	}
+	close(taskCh)
+	wg.Wait()
+
+	return err
+}
@@ -22,6 +22,7 @@ import (
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/aws"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/azure"
 	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/gcp"
 	"antrea.io/nephe/pkg/logging"
 )
 
@@ -38,6 +39,7 @@ var (
 func init() {
 	registerCloudProvider(cloudcommon.ProviderType(cloudv1alpha1.AWSCloudProvider), aws.Register())
 	registerCloudProvider(cloudcommon.ProviderType(cloudv1alpha1.AzureCloudProvider), azure.Register())
+	registerCloudProvider(cloudcommon.ProviderType(cloudv1alpha1.GCPCloudProvider), gcp.Register())
 }
 
 // registerCloudProvider registers a cloudv1alpha1 provider factory by type.  This
@@ -15,6 +15,7 @@
 package securitygroup
 
 import (
+	"net"
 	"strings"
 )
 
@@ -53,3 +54,43 @@ func FindResourcesBasedOnKind(cloudResources []*CloudResource) (map[string]struc
 	}
 	return virtualMachineIDs, networkInterfaceIDs
 }
+
+// SplitIPNetsByFamily splits IPs into IPv4 and IPv6 IPs, as cloud security rules generally need them in separate fields.
+func SplitIPNetsByFamily(ipNets []*net.IPNet) ([]*net.IPNet, []*net.IPNet) {
+	var ipv4Nets, ipv6Nets []*net.IPNet
+	for _, ipNet := range ipNets {
+		if ipNet == nil {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			ipv4Nets = append(ipv4Nets, ipNet)
+		} else {
+			ipv6Nets = append(ipv6Nets, ipNet)
+		}
+	}
+	return ipv4Nets, ipv6Nets
+}
+
+// IPNetFromIPString returns a host IPNet for an IP address, or the IPNet for a CIDR. Host mask length is
+// chosen based on IP family.
+func IPNetFromIPString(ip string) *net.IPNet {
+	if _, ipNet, err := net.ParseCIDR(ip); err == nil {
+		return ipNet
+	}
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return nil
+	}
+	if ipv4 := parsedIP.To4(); ipv4 != nil {
+		return &net.IPNet{IP: ipv4, Mask: net.CIDRMask(net.IPv4len*8, net.IPv4len*8)}
+	}
+	return &net.IPNet{IP: parsedIP, Mask: net.CIDRMask(net.IPv6len*8, net.IPv6len*8)}
+}
+
+// IPNetFromPrefix returns IPNet for an IP and prefix length. Mask length is chosen based on IP family.
+func IPNetFromPrefix(ip net.IP, prefixLength int) *net.IPNet {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return &net.IPNet{IP: ipv4, Mask: net.CIDRMask(prefixLength, net.IPv4len*8)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(prefixLength, net.IPv6len*8)}
+}
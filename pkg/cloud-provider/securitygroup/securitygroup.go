@@ -20,6 +20,8 @@ import (
 	"reflect"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/types"
+
 	cloud "antrea.io/nephe/apis/crd/v1alpha1"
 )
 
@@ -31,9 +33,11 @@ Network CRD
 Each Antrea internal NetworkPolicy contains
 -- name and namespace that uniquely identifies an Antrea internal NetworkPolicy.
    name and namespace corresponds to user facing Antrea NetworkPolicy.
--- list of rules (presently all are while list rules), each rule contains
+-- list of rules, each rule contains
     -- direction
-    -- service (port) of this rule.
+    -- action (Allow, Drop or Reject) of this rule.
+    -- priority of this rule, derived from tier and priority of the NetworkPolicy and index of the rule.
+    -- service (port, port range or ICMP type and code) of this rule.
     -- To/From:  IPBlock and  reference to AddressGroup.
 -- list of references to appliedToGroup
 
@@ -58,7 +62,7 @@ AppliedToGroup is used to the To/From field of Rule in a Network policy, each ap
      -- a list of Endpoint, each contains IP and ports.
 
 A SecurityGroup
--- is whitelist.
+-- is whitelist, unless cloud supports Drop/Reject rules.
 -- is configured per VPC, and is uniquely identified by its name or ID.
 -- contains zero or more NIC/(VM??). A NIC/(VM??) may be associated with zero or more securityGroups.
 -- contains Ingress rules, each rule contains
@@ -151,20 +155,79 @@ func (c *CloudResourceID) String() string {
 	return c.Name + "/" + c.Vpc
 }
 
+// RuleAction specifies the action of a cloud SecurityGroup rule.
+type RuleAction string
+
+const (
+	// RuleActionAllow permits matching traffic, it is the default action of a rule.
+	RuleActionAllow RuleAction = ""
+	// RuleActionDrop silently discards matching traffic.
+	RuleActionDrop RuleAction = "Drop"
+	// RuleActionReject discards matching traffic and notifies the peer.
+	RuleActionReject RuleAction = "Reject"
+)
+
+// IsDeny returns true if the action discards matching traffic.
+func (a RuleAction) IsDeny() bool {
+	return a == RuleActionDrop || a == RuleActionReject
+}
+
+// RulePriority specifies the evaluation order of a cloud SecurityGroup rule.
+type RulePriority struct {
+	// TierPriority is the priority of tier of the policy, lower value is evaluated first.
+	TierPriority int32
+	// PolicyPriority is the priority of the policy within its tier, lower value is evaluated first.
+	PolicyPriority float64
+	// RuleIndex is the index of the rule within its policy.
+	RuleIndex int32
+}
+
+// Less returns true if rule of priority p is evaluated before rule of priority o.
+// Rules without priority are evaluated last.
+func (p *RulePriority) Less(o *RulePriority) bool {
+	if p == nil {
+		return false
+	}
+	if o == nil {
+		return true
+	}
+	if p.TierPriority != o.TierPriority {
+		return p.TierPriority < o.TierPriority
+	}
+	if p.PolicyPriority != o.PolicyPriority {
+		return p.PolicyPriority < o.PolicyPriority
+	}
+	return p.RuleIndex < o.RuleIndex
+}
+
 // IngressRule specifies one ingress rule of cloud SecurityGroup.
+// FromPort and FromEndPort specify destination port range of tcp/udp protocols, a nil FromEndPort indicates a single
+// port. ICMPType and ICMPCode specify ICMP message type and code of icmp/icmpv6 protocols, nil indicates any.
 type IngressRule struct {
 	FromPort           *int
+	FromEndPort        *int
+	ICMPType           *int
+	ICMPCode           *int
 	FromSrcIP          []*net.IPNet
 	FromSecurityGroups []*CloudResourceID
 	Protocol           *int
+	Action             RuleAction
+	Priority           *RulePriority
 }
 
 // EgressRule specifies one egress rule of cloud SecurityGroup.
+// ToPort and ToEndPort specify destination port range of tcp/udp protocols, a nil ToEndPort indicates a single
+// port. ICMPType and ICMPCode specify ICMP message type and code of icmp/icmpv6 protocols, nil indicates any.
 type EgressRule struct {
 	ToPort           *int
+	ToEndPort        *int
+	ICMPType         *int
+	ICMPCode         *int
 	ToDstIP          []*net.IPNet
 	ToSecurityGroups []*CloudResourceID
 	Protocol         *int
+	Action           RuleAction
+	Priority         *RulePriority
 }
 
 // SynchronizationContent returns a SecurityGroup content in cloud.
@@ -177,6 +240,19 @@ type SynchronizationContent struct {
 	EgressRules                []EgressRule
 }
 
+// ImportContent returns a SecurityGroup in cloud not created by nephe, to be imported as Antrea NetworkPolicy.
+// Members are the VirtualMachines the SecurityGroup is attached to, a MembershipOnly SecurityGroup is only referred
+// to by rules of other SecurityGroups. Unsupported describes settings of the SecurityGroup that cannot be represented
+// by its members and rules.
+type ImportContent struct {
+	Resource       CloudResourceID
+	MembershipOnly bool
+	Members        []CloudResource
+	IngressRules   []IngressRule
+	EgressRules    []EgressRule
+	Unsupported    []string
+}
+
 // CloudSecurityGroupAPI declares interface to program cloud security groups.
 type CloudSecurityGroupAPI interface {
 	// CreateSecurityGroup request to create SecurityGroup name.
@@ -212,4 +288,15 @@ type CloudSecurityGroupAPI interface {
 	// This API ensures cloud plug-in stays stateless.
 	// - Correct SGs accidentally changed by customers via cloud API/console directly.
 	GetSecurityGroupSyncChan() <-chan SynchronizationContent
+
+	// GetAccountSecurityGroupSyncChan is GetSecurityGroupSyncChan limited to SGs of account, for the controller to
+	// reconcile an account on demand or on its own schedule.
+	GetAccountSecurityGroupSyncChan(account *types.NamespacedName) <-chan SynchronizationContent
+
+	// IsRuleActionSupported returns true if the cloud managing SecurityGroup name is able to
+	// enforce rules with action.
+	IsRuleActionSupported(name *CloudResourceID, action RuleAction) bool
+
+	// GetSecurityGroupAccount returns the account managing SecurityGroup name, nil if the account is unknown.
+	GetSecurityGroupAccount(name *CloudResourceID) *types.NamespacedName
 }
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"sync"
 
+	"k8s.io/apimachinery/pkg/types"
+
 	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
 )
@@ -147,7 +149,39 @@ func (sg *SecurityGroupImpl) DeleteSecurityGroup(addressGroupIdentifier *securit
 	return ch
 }
 
+func (sg *SecurityGroupImpl) IsRuleActionSupported(addressGroupIdentifier *securitygroup.CloudResourceID,
+	action securitygroup.RuleAction) bool {
+	cloudInterface, err := getCloudInterfaceForCloudResource(addressGroupIdentifier)
+	if err != nil {
+		// Unknown virtual private cloud is reported by security group operations.
+		return true
+	}
+	return cloudInterface.IsRuleActionSupported(action)
+}
+
+func (sg *SecurityGroupImpl) GetSecurityGroupAccount(addressGroupIdentifier *securitygroup.CloudResourceID) *types.NamespacedName {
+	cloudInterface, err := getCloudInterfaceForCloudResource(addressGroupIdentifier)
+	if err != nil {
+		return nil
+	}
+	return cloudInterface.GetVirtualPrivateCloudAccount(addressGroupIdentifier.Vpc)
+}
+
 func (sg *SecurityGroupImpl) GetSecurityGroupSyncChan() <-chan securitygroup.SynchronizationContent {
+	return getSecurityGroupSyncChan(func(cloudInterface cloudcommon.CloudInterface) []securitygroup.SynchronizationContent {
+		return cloudInterface.GetEnforcedSecurity()
+	})
+}
+
+func (sg *SecurityGroupImpl) GetAccountSecurityGroupSyncChan(account *types.NamespacedName) <-chan securitygroup.SynchronizationContent {
+	return getSecurityGroupSyncChan(func(cloudInterface cloudcommon.CloudInterface) []securitygroup.SynchronizationContent {
+		return cloudInterface.GetAccountEnforcedSecurity(account)
+	})
+}
+
+// getSecurityGroupSyncChan returns a channel of SGs retrieved from every cloud plug-in with getEnforcedSecurity.
+func getSecurityGroupSyncChan(
+	getEnforcedSecurity func(cloudcommon.CloudInterface) []securitygroup.SynchronizationContent) <-chan securitygroup.SynchronizationContent {
 	retCh := make(chan securitygroup.SynchronizationContent)
 
 	go func() {
@@ -171,7 +205,7 @@ func (sg *SecurityGroupImpl) GetSecurityGroupSyncChan() <-chan securitygroup.Syn
 			}
 
 			go func() {
-				ch <- cloudInterface.GetEnforcedSecurity()
+				ch <- getEnforcedSecurity(cloudInterface)
 				wg.Done()
 			}()
 		}
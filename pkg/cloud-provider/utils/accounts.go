@@ -0,0 +1,59 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetAccountRegions returns the regions of an account, from its region and its list of regions, without blank or
+// duplicate entries.
+func GetAccountRegions(region string, regions []string) []string {
+	return getUniqueValues(append([]string{region}, regions...))
+}
+
+// GetAccountIDs returns account or subscription IDs without blank or duplicate entries.
+func GetAccountIDs(ids []string) []string {
+	return getUniqueValues(ids)
+}
+
+// IsSecurityGroupsPreserved returns true if security groups not created by nephe-controller are preserved on a VM, given
+// the value of its PreserveSecurityGroupsTagKey tag, nil if the VM has no such tag, and the config of its account.
+func IsSecurityGroupsPreserved(tagValue *string, accountPreserved bool) bool {
+	if tagValue == nil {
+		return accountPreserved
+	}
+	preserved, err := strconv.ParseBool(strings.TrimSpace(*tagValue))
+	if err != nil {
+		return accountPreserved
+	}
+	return preserved
+}
+
+// getUniqueValues returns trimmed values, in order, without blank or duplicate entries.
+func getUniqueValues(values []string) []string {
+	var uniqueValues []string
+	found := make(map[string]struct{})
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if _, ok := found[v]; ok || len(v) == 0 {
+			continue
+		}
+		found[v] = struct{}{}
+		uniqueValues = append(uniqueValues, v)
+	}
+	return uniqueValues
+}
@@ -17,6 +17,7 @@ package utils
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -58,6 +59,13 @@ func GenerateVirtualMachineCRD(crdName string, cloudName string, cloudID string,
 	return vmCrd
 }
 
+// GenerateCRDTime returns time t for a CRD field, truncated to the precision the field is serialized with, so that
+// times discovered from cloud compare equal to the same times read back from the CRD.
+func GenerateCRDTime(t time.Time) *v1.Time {
+	crdTime := v1.NewTime(t.Truncate(time.Second))
+	return &crdTime
+}
+
 func GenerateShortResourceIdentifier(id string, prefixToAdd string) string {
 	idTrim := strings.Trim(id, " ")
 	if len(idTrim) == 0 {
@@ -0,0 +1,53 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// IsNamePattern returns true if name is a glob pattern, i.e. contains wildcard '*' or '?'.
+func IsNamePattern(name string) bool {
+	return strings.ContainsAny(name, "*?")
+}
+
+// NamePatternToRegex converts glob pattern to an anchored regular expression, where '*' matches any sequence of
+// characters and '?' matches any single character.
+func NamePatternToRegex(pattern string) string {
+	var regex strings.Builder
+	regex.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			regex.WriteString(".*")
+		case '?':
+			regex.WriteString(".")
+		default:
+			regex.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	regex.WriteString("$")
+	return regex.String()
+}
+
+// MatchNamePattern returns true if name matches glob pattern.
+func MatchNamePattern(pattern string, name string) bool {
+	if !IsNamePattern(pattern) {
+		return pattern == name
+	}
+	matched, _ := regexp.MatchString(NamePatternToRegex(pattern), name)
+	return matched
+}
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"reflect"
@@ -29,6 +31,7 @@ import (
 	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
 	cloudprovider "antrea.io/nephe/pkg/cloud-provider"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/controllers/utils"
 )
 
 const (
@@ -47,6 +50,8 @@ type accountPoller struct {
 	namespacedName    *types.NamespacedName
 	selector          *cloudv1alpha1.CloudEntitySelector
 	ch                chan struct{}
+	// snapshotChecksum is the checksum of the last checkpointed inventory snapshot of the selector.
+	snapshotChecksum string
 }
 
 func (p *accountPoller) doAccountPoller() {
@@ -66,14 +71,17 @@ func (p *accountPoller) doAccountPoller() {
 	if e != nil {
 		p.log.Info("failed to get account status", "account", p.namespacedName, "error", e)
 	} else {
-		updateAccountStatus(&account.Status, discoveredStatus)
+		updateAccountStatus(&account.Status, discoveredStatus, account.Generation)
 	}
 
 	e = p.Client.Status().Update(context.TODO(), account)
 	if e != nil {
 		p.log.Info("failed to update account status", "account", p.namespacedName, "err", e)
 	}
-	virtualMachines := p.getComputeResources(cloudInterface)
+	virtualMachines, e := p.getComputeResources(cloudInterface)
+	if e == nil {
+		p.saveInventorySnapshot(virtualMachines)
+	}
 
 	e = p.doVirtualMachineOperations(virtualMachines)
 	if e != nil {
@@ -81,19 +89,27 @@ func (p *accountPoller) doAccountPoller() {
 	}
 }
 
-func (p *accountPoller) getComputeResources(cloudInterface common.CloudInterface) []*cloudv1alpha1.VirtualMachine {
+func (p *accountPoller) getComputeResources(cloudInterface common.CloudInterface) ([]*cloudv1alpha1.VirtualMachine, error) {
 	var e error
 
-	virtualMachines, e := cloudInterface.InstancesGivenProviderAccount(p.namespacedName)
+	selectorNamespacedName := &types.NamespacedName{Namespace: p.selector.Namespace, Name: p.selector.Name}
+	virtualMachines, e := cloudInterface.InstancesGivenProviderAccountSelector(p.namespacedName, selectorNamespacedName)
 	if e != nil {
-		p.log.Info("failed to discover compute resources", "account", p.namespacedName, "error", e)
-		return []*cloudv1alpha1.VirtualMachine{}
+		p.log.Info("failed to discover compute resources", "account", p.namespacedName, "selector", selectorNamespacedName,
+			"error", e)
+		return []*cloudv1alpha1.VirtualMachine{}, e
 	}
 
-	p.log.Info("discovered compute resources statistics", "account", p.namespacedName, "virtual-machines",
-		len(virtualMachines))
+	p.log.Info("discovered compute resources statistics", "account", p.namespacedName, "selector", selectorNamespacedName,
+		"virtual-machines", len(virtualMachines))
+
+	for _, vm := range virtualMachines {
+		if _, e := utils.GetVMNamedPorts(vm); e != nil {
+			vm.Status.Error = e.Error()
+		}
+	}
 
-	return virtualMachines
+	return virtualMachines, nil
 }
 
 func (p *accountPoller) doVirtualMachineOperations(virtualMachines []*cloudv1alpha1.VirtualMachine) error {
@@ -112,6 +128,8 @@ func (p *accountPoller) doVirtualMachineOperations(virtualMachines []*cloudv1alp
 				continue
 			}
 			// save status since Create will update vm object and remove status field from it
+			now := metav1.Now()
+			vm.Status.StateTransitionTime = &now
 			vmStatus := vm.Status
 			e = p.Client.Create(context.TODO(), vm)
 			if e != nil {
@@ -274,6 +292,21 @@ func areDiscoveredFieldsSameVirtualMachineStatus(s1, s2 cloudv1alpha1.VirtualMac
 	if s1.VirtualPrivateCloud != s2.VirtualPrivateCloud {
 		return false
 	}
+	if s1.Region != s2.Region || s1.Zone != s2.Zone || s1.Subnet != s2.Subnet {
+		return false
+	}
+	if s1.InstanceType != s2.InstanceType || s1.Image != s2.Image || s1.OSType != s2.OSType {
+		return false
+	}
+	if !reflect.DeepEqual(s1.SecurityGroups, s2.SecurityGroups) {
+		return false
+	}
+	if !s1.LaunchTime.Equal(s2.LaunchTime) {
+		return false
+	}
+	if s1.Error != s2.Error {
+		return false
+	}
 	if len(s1.Tags) != len(s2.Tags) ||
 		len(s1.NetworkInterfaces) != len(s2.NetworkInterfaces) {
 		return false
@@ -321,6 +354,9 @@ func areNetworkInterfacesSame(s1, s2 []cloudv1alpha1.NetworkInterface) bool {
 		if strings.Compare(strings.ToLower(value1.MAC), strings.ToLower(value2.MAC)) != 0 {
 			return false
 		}
+		if value1.Subnet != value2.Subnet {
+			return false
+		}
 		if len(value1.IPs) != len(value2.IPs) {
 			return false
 		}
@@ -362,13 +398,42 @@ func convertNetworkInterfacesToMap(nwInterfaces []cloudv1alpha1.NetworkInterface
 }
 
 func updateCloudDiscoveredFieldsOfVirtualMachineStatus(current, discovered *cloudv1alpha1.VirtualMachineStatus) {
+	if current.State != discovered.State || current.StateTransitionTime == nil {
+		now := metav1.Now()
+		current.StateTransitionTime = &now
+	}
 	current.Provider = discovered.Provider
 	current.State = discovered.State
 	current.NetworkInterfaces = discovered.NetworkInterfaces
 	current.VirtualPrivateCloud = discovered.VirtualPrivateCloud
+	current.Region = discovered.Region
+	current.Zone = discovered.Zone
+	current.Subnet = discovered.Subnet
+	current.InstanceType = discovered.InstanceType
+	current.Image = discovered.Image
+	current.OSType = discovered.OSType
+	current.SecurityGroups = discovered.SecurityGroups
+	current.LaunchTime = discovered.LaunchTime
 	current.Tags = discovered.Tags
+	current.Error = discovered.Error
 }
 
-func updateAccountStatus(current, discovered *cloudv1alpha1.CloudProviderAccountStatus) {
+// updateAccountStatus updates the current account status with the discovered one. Conditions are merged so that the
+// transition time of a condition changes only when its status does. CredentialsValid is set by the credentials probe,
+// inventory polls only set it to False when credentials are rejected, and back to True once they succeed again.
+func updateAccountStatus(current, discovered *cloudv1alpha1.CloudProviderAccountStatus, generation int64) {
 	current.Error = discovered.Error
+	current.LastSuccessfulPollTime = discovered.LastSuccessfulPollTime
+	current.Services = discovered.Services
+	for _, condition := range discovered.Conditions {
+		if condition.Type == cloudv1alpha1.CloudProviderAccountConditionCredentialsValid &&
+			condition.Status == metav1.ConditionTrue {
+			credentials := meta.FindStatusCondition(current.Conditions, condition.Type)
+			if credentials == nil || credentials.Reason != cloudv1alpha1.CloudProviderAccountReasonCloudAPIAuthFailed {
+				continue
+			}
+		}
+		condition.ObservedGeneration = generation
+		meta.SetStatusCondition(&current.Conditions, condition)
+	}
 }
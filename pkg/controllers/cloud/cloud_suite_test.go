@@ -24,10 +24,12 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
 	cloudtest "antrea.io/nephe/pkg/testing/cloudsecurity"
 	"antrea.io/nephe/pkg/testing/controllerruntimeclient"
 )
@@ -48,6 +50,33 @@ var _ = BeforeSuite(func() {
 	_ = antreanetworking.AddToScheme(scheme)
 })
 
+// newTestVM returns cloud resource of VM name in vpc.
+func newTestVM(name, vpc string) *securitygroup.CloudResource {
+	return &securitygroup.CloudResource{Type: securitygroup.CloudResourceTypeVM,
+		Name: securitygroup.CloudResourceID{Name: name, Vpc: vpc}}
+}
+
+// newTestNetworkPolicyReconciler creates mock client and cloud plug-in, and returns a NetworkPolicyReconciler using them
+// that is synchronized with cloud.
+func newTestNetworkPolicyReconciler(dryRun bool, recorder record.EventRecorder) *NetworkPolicyReconciler {
+	mockCtrl = mock.NewController(GinkgoT())
+	mockClient = controllerruntimeclient.NewMockClient(mockCtrl)
+	mockStatusWriter = controllerruntimeclient.NewMockStatusWriter(mockCtrl)
+	mockCloudSecurityAPI = cloudtest.NewMockCloudSecurityGroupAPI(mockCtrl)
+	securitygroup.CloudSecurityGroup = mockCloudSecurityAPI
+	reconciler := &NetworkPolicyReconciler{
+		Log:             logf.Log,
+		Client:          mockClient,
+		Recorder:        recorder,
+		DryRun:          dryRun,
+		syncedWithCloud: true,
+		bookmarkCnt:     npSyncReadyBookMarkCnt,
+	}
+	err := reconciler.SetupWithManager(nil)
+	Expect(err).ToNot(HaveOccurred())
+	return reconciler
+}
+
 func TestCloud(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Cloud Suite")
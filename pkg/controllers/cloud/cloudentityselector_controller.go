@@ -63,11 +63,28 @@ func (r *CloudEntitySelectorReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	if !r.isSelectorAllowed(entitySelector) {
+		// VMs of the account are no longer imported, e.g. the account no longer allows the selector namespace.
+		r.Log.Info("selector namespace not allowed by account", "selector", req.NamespacedName,
+			"account", entitySelector.GetAccountNamespacedName())
+		err = r.processDelete(&req.NamespacedName)
+		return ctrl.Result{}, err
+	}
 	err = r.processCreateOrUpdate(entitySelector, &req.NamespacedName)
 
 	return ctrl.Result{}, err
 }
 
+// isSelectorAllowed returns false if the account of selector is in another namespace, which does not allow selectors
+// in the selector namespace.
+func (r *CloudEntitySelectorReconciler) isSelectorAllowed(selector *cloudv1alpha1.CloudEntitySelector) bool {
+	account := &cloudv1alpha1.CloudProviderAccount{}
+	if err := r.Get(context.TODO(), *selector.GetAccountNamespacedName(), account); err != nil {
+		return true
+	}
+	return account.IsSelectorNamespaceAllowed(selector.Namespace)
+}
+
 func (r *CloudEntitySelectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.accPollers = make(map[types.NamespacedName]*accountPoller)
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &cloudv1alpha1.VirtualMachine{},
@@ -116,7 +133,18 @@ func (r *CloudEntitySelectorReconciler) processCreateOrUpdate(selector *cloudv1a
 		return err
 	}
 
-	err = cloudInterface.AddAccountResourceSelector(accPoller.namespacedName, selector)
+	// a new poller, e.g. after a controller restart, serves the inventory snapshot of the selector until the first
+	// inventory of the account, instead of waiting for it.
+	var snapshot []*cloudv1alpha1.VirtualMachine
+	hasSnapshot := false
+	if !preExists {
+		snapshot, hasSnapshot = accPoller.loadInventorySnapshot()
+	}
+	if hasSnapshot {
+		err = cloudInterface.AddAccountResourceSelectorWithSnapshot(accPoller.namespacedName, selector, snapshot)
+	} else {
+		err = cloudInterface.AddAccountResourceSelector(accPoller.namespacedName, selector)
+	}
 	if err != nil {
 		if !preExists {
 			_ = r.processDelete(selectorNamespacedName)
@@ -140,7 +168,7 @@ func (r *CloudEntitySelectorReconciler) processDelete(selectorNamespacedName *ty
 	if err != nil {
 		return err
 	}
-	cloudInterface.RemoveAccountResourcesSelector(poller.namespacedName, selectorNamespacedName.Name)
+	cloudInterface.RemoveAccountResourcesSelector(poller.namespacedName, selectorNamespacedName)
 
 	return nil
 }
@@ -159,10 +187,7 @@ func (r *CloudEntitySelectorReconciler) addAccountPoller(selector *cloudv1alpha1
 		return pollerScope, exists
 	}
 
-	accountNamespacedName := &types.NamespacedName{
-		Namespace: selector.Namespace,
-		Name:      selector.Spec.AccountName,
-	}
+	accountNamespacedName := selector.GetAccountNamespacedName()
 	account := &cloudv1alpha1.CloudProviderAccount{}
 	_ = r.Get(context.TODO(), *accountNamespacedName, account)
 	accountCloudType, err := account.GetAccountProviderType()
@@ -0,0 +1,74 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/testing/controllerruntimeclient"
+)
+
+var _ = Describe("CloudEntitySelectorController", func() {
+	var (
+		reconciler *CloudEntitySelectorReconciler
+		account    *cloud.CloudProviderAccount
+	)
+
+	newSelector := func(namespace string) *cloud.CloudEntitySelector {
+		return &cloud.CloudEntitySelector{
+			ObjectMeta: v1.ObjectMeta{Name: "selector01", Namespace: namespace},
+			Spec:       cloud.CloudEntitySelectorSpec{AccountName: account.Name, AccountNamespace: account.Namespace},
+		}
+	}
+
+	BeforeEach(func() {
+		mockCtrl = mock.NewController(GinkgoT())
+		mockClient = controllerruntimeclient.NewMockClient(mockCtrl)
+		reconciler = &CloudEntitySelectorReconciler{
+			Log:    logf.Log,
+			Client: mockClient,
+		}
+		account = &cloud.CloudProviderAccount{ObjectMeta: v1.ObjectMeta{Name: "account01", Namespace: testNamespace}}
+		accountNamespacedName := types.NamespacedName{Namespace: account.Namespace, Name: account.Name}
+		mockClient.EXPECT().Get(mock.Any(), accountNamespacedName, mock.Any()).
+			Do(func(_ context.Context, _ types.NamespacedName, obj *cloud.CloudProviderAccount) {
+				account.DeepCopyInto(obj)
+			}).Return(nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("Should allow selectors in other namespaces only if the account allows them", func() {
+		Expect(reconciler.isSelectorAllowed(newSelector(testNamespace))).To(BeTrue())
+		Expect(reconciler.isSelectorAllowed(newSelector("team-ns"))).To(BeFalse())
+
+		account.Annotations = map[string]string{cloud.CloudProviderAccountSelectorNamespacesAnnotation: "dev-ns, team-ns"}
+		Expect(reconciler.isSelectorAllowed(newSelector("team-ns"))).To(BeTrue())
+		Expect(reconciler.isSelectorAllowed(newSelector("other-ns"))).To(BeFalse())
+
+		account.Annotations = map[string]string{cloud.CloudProviderAccountSelectorNamespacesAnnotation: "*"}
+		Expect(reconciler.isSelectorAllowed(newSelector("other-ns"))).To(BeTrue())
+	})
+})
@@ -19,26 +19,55 @@ import (
 	"sync"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
 	cloudprovider "antrea.io/nephe/pkg/cloud-provider"
 	"antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
 )
 
+const (
+	credentialsProbeSucceededReason = "CredentialsProbeSucceeded"
+	credentialsProbeFailedReason    = "CredentialsProbeFailed"
+)
+
 // CloudProviderAccountReconciler reconciles a CloudProviderAccount object.
 // nolint:golint
 type CloudProviderAccountReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// SecretNamespace is the namespace of secrets holding account credentials. Changes to these secrets are
+	// applied to the accounts referring to them.
+	SecretNamespace string
 
 	mutex               sync.Mutex
 	accountProviderType map[types.NamespacedName]common.ProviderType
+	// appliedAccounts keeps the account generation and resync annotation last added to the cloud plugin, so that
+	// account services are re-created only when they change, or when the account credentials secret changes.
+	appliedAccounts map[types.NamespacedName]appliedAccount
+	// secretChangedAccounts are accounts whose credentials secret changed since they were last added.
+	secretChangedAccounts map[types.NamespacedName]struct{}
+}
+
+// appliedAccount is the state of an account last added to the cloud plugin.
+type appliedAccount struct {
+	generation    int64
+	resyncRequest string
 }
 
 // nolint:lll
@@ -58,21 +87,80 @@ func (r *CloudProviderAccountReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, err
 	}
 
+	if !r.isAccountChanged(&req.NamespacedName, providerAccount) {
+		return ctrl.Result{}, nil
+	}
+	_, added := r.getAppliedAccount(&req.NamespacedName)
 	err = r.processCreate(&req.NamespacedName, providerAccount)
 	if err != nil {
-		_ = r.processDelete(&req.NamespacedName)
+		// an account failing to update keeps its services and inventory, the update is retried.
+		if !added {
+			_ = r.processDelete(&req.NamespacedName)
+		}
+		return ctrl.Result{}, err
 	}
+	r.setAppliedAccount(&req.NamespacedName, providerAccount)
 
-	return ctrl.Result{}, err
+	return ctrl.Result{}, nil
 }
 
 func (r *CloudProviderAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.accountProviderType = make(map[types.NamespacedName]common.ProviderType)
+	r.appliedAccounts = make(map[types.NamespacedName]appliedAccount)
+	r.secretChangedAccounts = make(map[types.NamespacedName]struct{})
+	// secrets are cached only in the namespace nephe-controller is allowed to read them.
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper(),
+		Namespace: r.SecretNamespace})
+	if err != nil {
+		return err
+	}
+	if err = mgr.Add(secretCache); err != nil {
+		return err
+	}
+	// account status, updated on every inventory poll, does not trigger reconcile.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&cloudv1alpha1.CloudProviderAccount{}).
+		For(&cloudv1alpha1.CloudProviderAccount{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, resyncAnnotationChangedPredicate()))).
+		Watches(source.NewKindWithCache(&corev1.Secret{}, secretCache), handler.EnqueueRequestsFromMapFunc(r.getSecretAccounts)).
 		Complete(r)
 }
 
+// resyncAnnotationChangedPredicate returns a predicate passing account updates changing the resync annotation.
+func resyncAnnotationChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return e.ObjectOld.GetAnnotations()[cloudv1alpha1.CloudProviderAccountResyncAnnotation] !=
+				e.ObjectNew.GetAnnotations()[cloudv1alpha1.CloudProviderAccountResyncAnnotation]
+		},
+	}
+}
+
+// getSecretAccounts returns reconcile requests of the accounts whose credentials are held in given secret.
+func (r *CloudProviderAccountReconciler) getSecretAccounts(secret client.Object) []reconcile.Request {
+	accountList := &cloudv1alpha1.CloudProviderAccountList{}
+	if err := r.List(context.TODO(), accountList); err != nil {
+		r.Log.Error(err, "failed to list accounts", "secret", client.ObjectKeyFromObject(secret))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range accountList.Items {
+		account := &accountList.Items[i]
+		secretRef := account.GetAccountSecretRef()
+		if secretRef == nil || secretRef.Namespace != secret.GetNamespace() || secretRef.Name != secret.GetName() {
+			continue
+		}
+		r.Log.Info("account credentials secret changed", "account", client.ObjectKeyFromObject(account),
+			"secret", client.ObjectKeyFromObject(secret))
+		r.setSecretChanged(client.ObjectKeyFromObject(account))
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(account)})
+	}
+	return requests
+}
+
 func (r *CloudProviderAccountReconciler) processCreate(namespacedName *types.NamespacedName,
 	account *cloudv1alpha1.CloudProviderAccount) error {
 	accountCloudType, err := account.GetAccountProviderType()
@@ -84,7 +172,46 @@ func (r *CloudProviderAccountReconciler) processCreate(namespacedName *types.Nam
 	if err != nil {
 		return err
 	}
-	return cloudInterface.AddProviderAccount(r.Client, account)
+	if err = cloudInterface.AddProviderAccount(r.Client, account); err != nil {
+		return err
+	}
+
+	r.updateCredentialsStatus(account, cloudInterface.ProbeProviderAccountCredentials(r.Client, account))
+	return nil
+}
+
+// ProbeAccountCredentials checks cloud credentials of given account with its cloud provider, without adding it.
+func ProbeAccountCredentials(client client.Client, account *cloudv1alpha1.CloudProviderAccount) error {
+	accountCloudType, err := account.GetAccountProviderType()
+	if err != nil {
+		return err
+	}
+	cloudInterface, err := cloudprovider.GetCloudInterface(common.ProviderType(accountCloudType))
+	if err != nil {
+		return err
+	}
+	return cloudInterface.ProbeProviderAccountCredentials(client, account)
+}
+
+// updateCredentialsStatus updates CredentialsValid condition of the account with the result of probing its
+// credentials with the cloud.
+func (r *CloudProviderAccountReconciler) updateCredentialsStatus(account *cloudv1alpha1.CloudProviderAccount, probeErr error) {
+	condition := metav1.Condition{
+		Type:               cloudv1alpha1.CloudProviderAccountConditionCredentialsValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             credentialsProbeSucceededReason,
+		ObservedGeneration: account.Generation,
+	}
+	if probeErr != nil {
+		r.Log.Info("account credentials probe failed", "account", client.ObjectKeyFromObject(account), "error", probeErr)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = credentialsProbeFailedReason
+		condition.Message = probeErr.Error()
+	}
+	meta.SetStatusCondition(&account.Status.Conditions, condition)
+	if err := r.Status().Update(context.TODO(), account); err != nil {
+		r.Log.Info("failed to update account status", "account", client.ObjectKeyFromObject(account), "error", err)
+	}
 }
 
 func (r *CloudProviderAccountReconciler) processDelete(namespacedName *types.NamespacedName) error {
@@ -95,6 +222,7 @@ func (r *CloudProviderAccountReconciler) processDelete(namespacedName *types.Nam
 	}
 	cloudInterface.RemoveProviderAccount(namespacedName)
 	r.removeAccountProviderType(namespacedName)
+	r.removeAppliedAccount(namespacedName)
 
 	return nil
 }
@@ -123,3 +251,53 @@ func (r *CloudProviderAccountReconciler) getAccountProviderType(namespacedName *
 
 	return r.accountProviderType[*namespacedName]
 }
+
+// isAccountChanged returns true if the account is not added to the cloud plugin yet, or if its generation, its resync
+// annotation or its credentials secret changed since it was last added.
+func (r *CloudProviderAccountReconciler) isAccountChanged(namespacedName *types.NamespacedName,
+	account *cloudv1alpha1.CloudProviderAccount) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, found := r.secretChangedAccounts[*namespacedName]; found {
+		return true
+	}
+	applied, found := r.appliedAccounts[*namespacedName]
+	return !found || applied.generation != account.Generation ||
+		applied.resyncRequest != account.Annotations[cloudv1alpha1.CloudProviderAccountResyncAnnotation]
+}
+
+func (r *CloudProviderAccountReconciler) getAppliedAccount(namespacedName *types.NamespacedName) (appliedAccount, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	applied, found := r.appliedAccounts[*namespacedName]
+	return applied, found
+}
+
+func (r *CloudProviderAccountReconciler) setAppliedAccount(namespacedName *types.NamespacedName,
+	account *cloudv1alpha1.CloudProviderAccount) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.appliedAccounts[*namespacedName] = appliedAccount{
+		generation:    account.Generation,
+		resyncRequest: account.Annotations[cloudv1alpha1.CloudProviderAccountResyncAnnotation],
+	}
+	delete(r.secretChangedAccounts, *namespacedName)
+}
+
+func (r *CloudProviderAccountReconciler) removeAppliedAccount(namespacedName *types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.appliedAccounts, *namespacedName)
+	delete(r.secretChangedAccounts, *namespacedName)
+}
+
+func (r *CloudProviderAccountReconciler) setSecretChanged(namespacedName types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.secretChangedAccounts[namespacedName] = struct{}{}
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"errors"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/testing/controllerruntimeclient"
+)
+
+var _ = Describe("CloudProviderAccountController", func() {
+	var (
+		reconciler       *CloudProviderAccountReconciler
+		mockStatusWriter *controllerruntimeclient.MockStatusWriter
+		accounts         []cloud.CloudProviderAccount
+	)
+
+	newAccount := func(name, secretNamespace, secretName string) cloud.CloudProviderAccount {
+		return cloud.CloudProviderAccount{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace, Generation: 2},
+			Spec: cloud.CloudProviderAccountSpec{
+				AWSConfig: &cloud.CloudProviderAccountAWSConfig{
+					Region: "us-east-1",
+					SecretRef: &cloud.SecretReference{
+						Name:      secretName,
+						Namespace: secretNamespace,
+						Key:       "credentials",
+					},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		mockCtrl = mock.NewController(GinkgoT())
+		mockClient = controllerruntimeclient.NewMockClient(mockCtrl)
+		mockStatusWriter = controllerruntimeclient.NewMockStatusWriter(mockCtrl)
+		reconciler = &CloudProviderAccountReconciler{
+			Log:             logf.Log,
+			Client:          mockClient,
+			SecretNamespace: "nephe-system",
+
+			accountProviderType:   make(map[types.NamespacedName]common.ProviderType),
+			appliedAccounts:       make(map[types.NamespacedName]appliedAccount),
+			secretChangedAccounts: make(map[types.NamespacedName]struct{}),
+		}
+		accounts = []cloud.CloudProviderAccount{
+			newAccount("account01", "nephe-system", "secret01"),
+			newAccount("account02", "nephe-system", "secret02"),
+			newAccount("account03", "nephe-system", "secret01"),
+			newAccount("account04", "other-namespace", "secret01"),
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("Should reconcile accounts referring to changed secret", func() {
+		mockClient.EXPECT().List(mock.Any(), mock.Any()).
+			Do(func(_ context.Context, accountList *cloud.CloudProviderAccountList) {
+				accountList.Items = accounts
+			}).Return(nil)
+
+		secret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "secret01", Namespace: "nephe-system"}}
+		requests := reconciler.getSecretAccounts(secret)
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: testNamespace, Name: "account01"}))
+		Expect(requests[1].NamespacedName).To(Equal(types.NamespacedName{Namespace: testNamespace, Name: "account03"}))
+		Expect(reconciler.isAccountChanged(&requests[0].NamespacedName, &accounts[0])).To(BeTrue())
+	})
+
+	It("Should leave credentials condition to probe unless inventory poll credentials are rejected", func() {
+		account := accounts[0].DeepCopy()
+		mockClient.EXPECT().Status().Return(mockStatusWriter).Times(1)
+		mockStatusWriter.EXPECT().Update(mock.Any(), account).Return(nil).Times(1)
+		reconciler.updateCredentialsStatus(account, errors.New("InvalidClientTokenId"))
+
+		authSucceeded := v1.Condition{Type: cloud.CloudProviderAccountConditionCredentialsValid, Status: v1.ConditionTrue,
+			Reason: cloud.CloudProviderAccountReasonCloudAPIAuthSucceeded}
+		authFailed := v1.Condition{Type: cloud.CloudProviderAccountConditionCredentialsValid, Status: v1.ConditionFalse,
+			Reason: cloud.CloudProviderAccountReasonCloudAPIAuthFailed, Message: "AuthFailure"}
+		updateAccountStatus(&account.Status, &cloud.CloudProviderAccountStatus{Conditions: []v1.Condition{authSucceeded}},
+			account.Generation)
+		condition := meta.FindStatusCondition(account.Status.Conditions, cloud.CloudProviderAccountConditionCredentialsValid)
+		Expect(condition.Status).To(Equal(v1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(credentialsProbeFailedReason))
+
+		updateAccountStatus(&account.Status, &cloud.CloudProviderAccountStatus{Conditions: []v1.Condition{authFailed}},
+			account.Generation)
+		condition = meta.FindStatusCondition(account.Status.Conditions, cloud.CloudProviderAccountConditionCredentialsValid)
+		Expect(condition.Reason).To(Equal(cloud.CloudProviderAccountReasonCloudAPIAuthFailed))
+
+		updateAccountStatus(&account.Status, &cloud.CloudProviderAccountStatus{Conditions: []v1.Condition{authSucceeded}},
+			account.Generation)
+		condition = meta.FindStatusCondition(account.Status.Conditions, cloud.CloudProviderAccountConditionCredentialsValid)
+		Expect(condition.Status).To(Equal(v1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(cloud.CloudProviderAccountReasonCloudAPIAuthSucceeded))
+	})
+
+	It("Should add account again only when its generation, resync annotation or secret changes", func() {
+		account := accounts[0].DeepCopy()
+		namespacedName := types.NamespacedName{Namespace: account.Namespace, Name: account.Name}
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeTrue())
+
+		reconciler.setAppliedAccount(&namespacedName, account)
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeFalse())
+
+		account.Generation++
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeTrue())
+		reconciler.setAppliedAccount(&namespacedName, account)
+
+		account.Annotations = map[string]string{cloud.CloudProviderAccountResyncAnnotation: "1"}
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeTrue())
+		reconciler.setAppliedAccount(&namespacedName, account)
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeFalse())
+
+		reconciler.setSecretChanged(namespacedName)
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeTrue())
+		reconciler.setAppliedAccount(&namespacedName, account)
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeFalse())
+	})
+
+	It("Should keep added account when its update fails", func() {
+		account := accounts[0].DeepCopy()
+		namespacedName := types.NamespacedName{Namespace: account.Namespace, Name: account.Name}
+		reconciler.addAccountProviderType(&namespacedName, cloud.AWSCloudProvider)
+		reconciler.setAppliedAccount(&namespacedName, account)
+
+		account.Generation++
+		account.Spec.AWSConfig = nil
+		mockClient.EXPECT().Get(mock.Any(), namespacedName, mock.Any()).
+			Do(func(_ context.Context, _ types.NamespacedName, obj *cloud.CloudProviderAccount) {
+				account.DeepCopyInto(obj)
+			}).Return(nil)
+
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+		_, added := reconciler.getAppliedAccount(&namespacedName)
+		Expect(added).To(BeTrue())
+		Expect(reconciler.getAccountProviderType(&namespacedName)).To(Equal(common.ProviderType(cloud.AWSCloudProvider)))
+		Expect(reconciler.isAccountChanged(&namespacedName, account)).To(BeTrue())
+	})
+
+	It("Should pass account updates changing generation or resync annotation only", func() {
+		account := accounts[0].DeepCopy()
+		updated := account.DeepCopy()
+		updated.Status.Error = "poll failed"
+		p := predicate.Or(predicate.GenerationChangedPredicate{}, resyncAnnotationChangedPredicate())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: account, ObjectNew: updated})).To(BeFalse())
+
+		updated.Annotations = map[string]string{cloud.CloudProviderAccountResyncAnnotation: "1"}
+		Expect(p.Update(event.UpdateEvent{ObjectOld: account, ObjectNew: updated})).To(BeTrue())
+
+		updated = account.DeepCopy()
+		updated.Generation++
+		Expect(p.Update(event.UpdateEvent{ObjectOld: account, ObjectNew: updated})).To(BeTrue())
+	})
+
+	It("Should report credentials probe result in account status", func() {
+		account := accounts[0].DeepCopy()
+		mockClient.EXPECT().Status().Return(mockStatusWriter).Times(2)
+		mockStatusWriter.EXPECT().Update(mock.Any(), account).Return(nil).Times(2)
+
+		reconciler.updateCredentialsStatus(account, errors.New("InvalidClientTokenId"))
+		condition := meta.FindStatusCondition(account.Status.Conditions, cloud.CloudProviderAccountConditionCredentialsValid)
+		Expect(condition).To(Not(BeNil()))
+		Expect(condition.Status).To(Equal(v1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(credentialsProbeFailedReason))
+		Expect(condition.Message).To(Equal("InvalidClientTokenId"))
+		Expect(condition.ObservedGeneration).To(Equal(account.Generation))
+
+		reconciler.updateCredentialsStatus(account, nil)
+		condition = meta.FindStatusCondition(account.Status.Conditions, cloud.CloudProviderAccountConditionCredentialsValid)
+		Expect(condition.Status).To(Equal(v1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(credentialsProbeSucceededReason))
+		Expect(account.Status.Conditions).To(HaveLen(1))
+	})
+})
@@ -19,10 +19,12 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/mohae/deepcopy"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -102,11 +104,15 @@ var (
 		antreanetworking.ProtocolTCP:  6,
 		antreanetworking.ProtocolUDP:  17,
 		antreanetworking.ProtocolSCTP: 132,
+		antreanetworking.ProtocolICMP: 1,
 	}
 )
 
 const (
 	uniqueGroupNameMemberPrefix = "mm_"
+	// k8sNetworkPolicyTierPriority orders K8s NetworkPolicy rules after rules of Antrea-native policies in
+	// Application tier(250), and before rules of those in Baseline tier(253).
+	k8sNetworkPolicyTierPriority = int32(251)
 )
 
 func getGroupUniqueName(name string, memberOnly bool) string {
@@ -116,6 +122,13 @@ func getGroupUniqueName(name string, memberOnly bool) string {
 	return name
 }
 
+// GetSecurityGroupObjectName returns the name of API objects of SecurityGroup id, the cloud SecurityGroup name suffixed
+// with its VPC name.
+func GetSecurityGroupObjectName(id *securitygroup.CloudResourceID, membershipOnly bool) string {
+	vpc := id.Vpc[strings.LastIndex(id.Vpc, "/")+1:]
+	return id.GetCloudName(membershipOnly) + "." + strings.ToLower(vpc)
+}
+
 func getGroupIDFromUniqueName(name string) (string, bool) {
 	if strings.HasPrefix(name, uniqueGroupNameMemberPrefix) {
 		return name[len(uniqueGroupNameMemberPrefix):], true
@@ -236,8 +249,9 @@ func vpcsFromGroupMembers(members []antreanetworking.GroupMember, r *NetworkPoli
 			if apierrors.IsNotFound(err) {
 				if ips, ok := r.fedExternalEntityIPs[key.String()]; ok {
 					for _, ip := range ips {
-						_, ipnet, _ := net.ParseCIDR(ip + "/32")
-						ipBlocks = append(ipBlocks, ipnet)
+						if ipnet := securitygroup.IPNetFromIPString(ip); ipnet != nil {
+							ipBlocks = append(ipBlocks, ipnet)
+						}
 					}
 				} else {
 					notFoundMember = append(notFoundMember, m.ExternalEntity.Name)
@@ -281,11 +295,9 @@ func vpcsFromGroupMembers(members []antreanetworking.GroupMember, r *NetworkPoli
 			cloudRsc.Name.Name = cloudAssignedID
 		} else {
 			for _, ep := range e.Spec.Endpoints {
-				var ipnet *net.IPNet
-				if _, ipnet, _ = net.ParseCIDR(ep.IP); ipnet == nil {
-					_, ipnet, _ = net.ParseCIDR(ep.IP + "/32")
+				if ipnet := securitygroup.IPNetFromIPString(ep.IP); ipnet != nil {
+					ipBlocks = append(ipBlocks, ipnet)
 				}
-				ipBlocks = append(ipBlocks, ipnet)
 			}
 		}
 	}
@@ -312,8 +324,51 @@ func getOwnerAnnotations(e *antreanetcore.ExternalEntity, r *NetworkPolicyReconc
 
 // deduplicateKey is used for deduplicate network policy rules.
 type deduplicateKey struct {
-	port     int
-	protocol int
+	port        int
+	endPort     int
+	icmpType    int
+	icmpCode    int
+	protocol    int
+	action      securitygroup.RuleAction
+	priority    securitygroup.RulePriority
+	hasPriority bool
+}
+
+// newDeduplicateKey returns deduplicateKey of a rule. Unset ICMP type and code are kept as -1, as 0 is a valid value.
+func newDeduplicateKey(port, endPort, icmpType, icmpCode, protocol *int, action securitygroup.RuleAction,
+	priority *securitygroup.RulePriority) deduplicateKey {
+	key := deduplicateKey{port: intValue(port, 0), endPort: intValue(endPort, 0), icmpType: intValue(icmpType, -1),
+		icmpCode: intValue(icmpCode, -1), protocol: intValue(protocol, 0), action: action}
+	if priority != nil {
+		key.priority = *priority
+		key.hasPriority = true
+	}
+	return key
+}
+
+// rulePriority returns rule priority of deduplicateKey.
+func (k deduplicateKey) rulePriority() *securitygroup.RulePriority {
+	if !k.hasPriority {
+		return nil
+	}
+	priority := k.priority
+	return &priority
+}
+
+// intValue returns value of p, or unset if p is nil.
+func intValue(p *int, unset int) int {
+	if p == nil {
+		return unset
+	}
+	return *p
+}
+
+// intPointer returns pointer to v, or nil if v is unset.
+func intPointer(v int, unset int) *int {
+	if v == unset {
+		return nil
+	}
+	return &v
 }
 
 // overlap decides whether two ip blocks overlap(one contains the other).
@@ -380,28 +435,15 @@ func deduplicateIngressRules(ingressRules []*securitygroup.IngressRule) []*secur
 	inRuleSGSet := make(map[deduplicateKey][]*securitygroup.CloudResourceID)
 	mergedInRules := make([]*securitygroup.IngressRule, 0)
 	for _, r := range ingressRules {
-		port, protocol := 0, 0
-		if r.FromPort != nil {
-			port = *(r.FromPort)
-		}
-		if r.Protocol != nil {
-			protocol = *(r.Protocol)
-		}
-		ruleKey := deduplicateKey{port: port, protocol: protocol}
+		ruleKey := newDeduplicateKey(r.FromPort, r.FromEndPort, r.ICMPType, r.ICMPCode, r.Protocol, r.Action, r.Priority)
 		inRuleIPSet[ruleKey] = append(inRuleIPSet[ruleKey], r.FromSrcIP...)
 		inRuleSGSet[ruleKey] = append(inRuleSGSet[ruleKey], r.FromSecurityGroups...)
 	}
 	for k, v := range inRuleIPSet {
-		port, protocol := k.port, k.protocol
-		var portP, protocolP *int
-		if port != 0 {
-			portP = &(port)
-		}
-		if protocol != 0 {
-			protocolP = &(protocol)
-		}
-		inRule := securitygroup.IngressRule{FromPort: portP, FromSrcIP: deduplicateIP(v),
-			FromSecurityGroups: deduplicateSG(inRuleSGSet[k]), Protocol: protocolP}
+		inRule := securitygroup.IngressRule{FromPort: intPointer(k.port, 0), FromEndPort: intPointer(k.endPort, 0),
+			ICMPType: intPointer(k.icmpType, -1), ICMPCode: intPointer(k.icmpCode, -1), FromSrcIP: deduplicateIP(v),
+			FromSecurityGroups: deduplicateSG(inRuleSGSet[k]), Protocol: intPointer(k.protocol, 0), Action: k.action,
+			Priority: k.rulePriority()}
 		mergedInRules = append(mergedInRules, &inRule)
 	}
 	return mergedInRules
@@ -413,28 +455,15 @@ func deduplicateEgressRules(egressRules []*securitygroup.EgressRule) []*security
 	eRuleSGSet := make(map[deduplicateKey][]*securitygroup.CloudResourceID)
 	mergedERules := make([]*securitygroup.EgressRule, 0)
 	for _, r := range egressRules {
-		port, protocol := 0, 0
-		if r.ToPort != nil {
-			port = *(r.ToPort)
-		}
-		if r.Protocol != nil {
-			protocol = *(r.Protocol)
-		}
-		ruleKey := deduplicateKey{port: port, protocol: protocol}
+		ruleKey := newDeduplicateKey(r.ToPort, r.ToEndPort, r.ICMPType, r.ICMPCode, r.Protocol, r.Action, r.Priority)
 		eRuleIPSet[ruleKey] = append(eRuleIPSet[ruleKey], r.ToDstIP...)
 		eRuleSGSet[ruleKey] = append(eRuleSGSet[ruleKey], r.ToSecurityGroups...)
 	}
 	for k, v := range eRuleIPSet {
-		port, protocol := k.port, k.protocol
-		var portP, protocolP *int
-		if port != 0 {
-			portP = &(port)
-		}
-		if protocol != 0 {
-			protocolP = &(protocol)
-		}
-		eRule := securitygroup.EgressRule{ToPort: portP, ToDstIP: deduplicateIP(v),
-			ToSecurityGroups: deduplicateSG(eRuleSGSet[k]), Protocol: protocolP}
+		eRule := securitygroup.EgressRule{ToPort: intPointer(k.port, 0), ToEndPort: intPointer(k.endPort, 0),
+			ICMPType: intPointer(k.icmpType, -1), ICMPCode: intPointer(k.icmpCode, -1), ToDstIP: deduplicateIP(v),
+			ToSecurityGroups: deduplicateSG(eRuleSGSet[k]), Protocol: intPointer(k.protocol, 0), Action: k.action,
+			Priority: k.rulePriority()}
 		mergedERules = append(mergedERules, &eRule)
 	}
 	return mergedERules
@@ -864,17 +893,10 @@ func (a *appliedToSecurityGroup) updateRules(r *NetworkPolicyReconciler) error {
 		a.hasRules = false
 		return nil
 	}
-	irules := make([]*securitygroup.IngressRule, 0)
-	erules := make([]*securitygroup.EgressRule, 0)
-	for _, i := range nps {
-		np := i.(*networkPolicy)
-		if !np.rulesReady {
-			return nil
-		}
-		irules = append(irules, deepcopy.Copy(np.ingressRules).([]*securitygroup.IngressRule)...)
-		erules = append(erules, deepcopy.Copy(np.egressRules).([]*securitygroup.EgressRule)...)
+	irules, erules, ready := a.getRules(nps, r)
+	if !ready {
+		return nil
 	}
-	irules, erules = deduplicateIngressRules(irules), deduplicateEgressRules(erules)
 	r.Log.V(1).Info("AppliedToSecurityGroup update rules", "Name", a.id,
 		"ingressRules", irules, "egressRules", erules)
 	ch := securitygroup.CloudSecurityGroup.UpdateSecurityGroupRules(&a.id, irules, erules)
@@ -885,6 +907,28 @@ func (a *appliedToSecurityGroup) updateRules(r *NetworkPolicyReconciler) error {
 	return nil
 }
 
+// getRules returns deduplicated rules of networkPolicies nps applied to appliedToSecurityGroup, and false if rules of
+// any networkPolicy are not ready.
+func (a *appliedToSecurityGroup) getRules(nps []interface{}, r *NetworkPolicyReconciler) (
+	[]*securitygroup.IngressRule, []*securitygroup.EgressRule, bool) {
+	irules := make([]*securitygroup.IngressRule, 0)
+	erules := make([]*securitygroup.EgressRule, 0)
+	for _, i := range nps {
+		np := i.(*networkPolicy)
+		if !np.rulesReady {
+			return nil, nil, false
+		}
+		if err := np.getRuleActionStatus(&a.id); err != nil {
+			r.Log.V(1).Info("AppliedToSecurityGroup skip networkPolicy rules", "Name", a.id,
+				"networkPolicy", np.Name, "reason", err)
+			continue
+		}
+		irules = append(irules, deepcopy.Copy(np.ingressRules).([]*securitygroup.IngressRule)...)
+		erules = append(erules, deepcopy.Copy(np.egressRules).([]*securitygroup.EgressRule)...)
+	}
+	return deduplicateIngressRules(irules), deduplicateEgressRules(erules), true
+}
+
 // update invokes cloud plug-in to update appliedToSecurityGroup's membership.
 func (a *appliedToSecurityGroup) update(added, removed []*securitygroup.CloudResource, r *NetworkPolicyReconciler) error {
 	for _, rsc := range removed {
@@ -984,7 +1028,11 @@ func (a *appliedToSecurityGroup) notifyNetworkPolicyChange(r *NetworkPolicyRecon
 
 // networkPolicyRule describe an Antrea networkPolicy rule.
 type networkPolicyRule struct {
-	rule *antreanetworking.NetworkPolicyRule
+	rule            *antreanetworking.NetworkPolicyRule
+	priority        *securitygroup.RulePriority
+	appliedToGroups []string
+	// namedPortConflicts are named ports of the rule resolved to different port numbers by group members.
+	namedPortConflicts []string
 }
 
 // rules generate cloud plug-in ingressRule and/or egressRule from an networkPolicyRule.
@@ -992,11 +1040,12 @@ func (r *networkPolicyRule) rules(rr *NetworkPolicyReconciler) (ingressList []*s
 	egressList []*securitygroup.EgressRule, ready bool) {
 	ready = true
 	rule := r.rule
+	action := ruleActionMap[getRuleAction(rule.Action)]
 	if rule.Direction == antreanetworking.DirectionIn {
-		ingress := &securitygroup.IngressRule{}
+		ingress := &securitygroup.IngressRule{Action: action, Priority: r.priority}
 		for _, ip := range rule.From.IPBlocks {
-			ipNet := net.IPNet{IP: net.IP(ip.CIDR.IP), Mask: net.CIDRMask(int(ip.CIDR.PrefixLength), 32)}
-			ingress.FromSrcIP = append(ingress.FromSrcIP, &ipNet)
+			ipNet := securitygroup.IPNetFromPrefix(net.IP(ip.CIDR.IP), int(ip.CIDR.PrefixLength))
+			ingress.FromSrcIP = append(ingress.FromSrcIP, ipNet)
 		}
 		for _, ag := range rule.From.AddressGroups {
 			sgs, err := rr.addrSGIndexer.ByIndex(addrAppliedToIndexerByGroupID, ag)
@@ -1025,25 +1074,24 @@ func (r *networkPolicyRule) rules(rr *NetworkPolicyReconciler) (ingressList []*s
 			ingressList = append(ingressList, ingress)
 			return
 		}
-		for _, s := range rule.Services {
+		services, conflicts := rr.resolveNamedPorts(rule.Services, r.appliedToGroups, false, action.IsDeny())
+		r.namedPortConflicts = append(r.namedPortConflicts, conflicts...)
+		for _, s := range services {
 			ii := deepcopy.Copy(ingress).(*securitygroup.IngressRule)
 			if s.Protocol != nil {
 				if p, ok := AntreaProtocolMap[*s.Protocol]; ok {
 					ii.Protocol = &p
 				}
 			}
-			if s.Port != nil {
-				port := int(s.Port.IntVal)
-				ii.FromPort = &port
-			}
+			ii.FromPort, ii.FromEndPort, ii.ICMPType, ii.ICMPCode = getServicePorts(&s)
 			ingressList = append(ingressList, ii)
 		}
 		return
 	}
-	egress := &securitygroup.EgressRule{}
+	egress := &securitygroup.EgressRule{Action: action, Priority: r.priority}
 	for _, ip := range rule.To.IPBlocks {
-		ipNet := net.IPNet{IP: net.IP(ip.CIDR.IP), Mask: net.CIDRMask(int(ip.CIDR.PrefixLength), 32)}
-		egress.ToDstIP = append(egress.ToDstIP, &ipNet)
+		ipNet := securitygroup.IPNetFromPrefix(net.IP(ip.CIDR.IP), int(ip.CIDR.PrefixLength))
+		egress.ToDstIP = append(egress.ToDstIP, ipNet)
 	}
 	for _, ag := range rule.To.AddressGroups {
 		sgs, err := rr.addrSGIndexer.ByIndex(addrAppliedToIndexerByGroupID, ag)
@@ -1072,7 +1120,9 @@ func (r *networkPolicyRule) rules(rr *NetworkPolicyReconciler) (ingressList []*s
 		egressList = append(egressList, egress)
 		return
 	}
-	for _, s := range rule.Services {
+	services, conflicts := rr.resolveNamedPorts(rule.Services, rule.To.AddressGroups, true, action.IsDeny())
+	r.namedPortConflicts = append(r.namedPortConflicts, conflicts...)
+	for _, s := range services {
 		// No deep copy ??
 		ee := deepcopy.Copy(egress).(*securitygroup.EgressRule)
 		if s.Protocol != nil {
@@ -1080,21 +1130,116 @@ func (r *networkPolicyRule) rules(rr *NetworkPolicyReconciler) (ingressList []*s
 				ee.Protocol = &p
 			}
 		}
-		if s.Port != nil {
-			port := int(s.Port.IntVal)
-			ee.ToPort = &port
-		}
+		ee.ToPort, ee.ToEndPort, ee.ICMPType, ee.ICMPCode = getServicePorts(&s)
 		egressList = append(egressList, ee)
 	}
 	return
 }
 
+// resolveNamedPorts returns services with named ports resolved to port numbers, using named ports
+// of members of AppliedToGroups or AddressGroups. A service is expanded into one service per port number.
+// A service whose named port cannot be resolved is dropped from allow rules, and widened to its whole
+// protocol in deny rules, so that an unresolved named port never grants more access than intended.
+// Likewise, a named port that members resolve to different port numbers is returned as a conflict, as
+// a cloud rule applies the same ports to all members. It is dropped from allow rules, and resolved to
+// the port numbers of all members in deny rules.
+func (r *NetworkPolicyReconciler) resolveNamedPorts(services []antreanetworking.Service, groups []string,
+	isAddrGrp, isDeny bool) (resolved []antreanetworking.Service, conflicts []string) {
+	for _, s := range services {
+		if s.Port == nil || s.Port.Type != intstr.String {
+			resolved = append(resolved, s)
+			continue
+		}
+		protocol := antreanetworking.ProtocolTCP
+		if s.Protocol != nil {
+			protocol = *s.Protocol
+		}
+		portSet := make(map[int32]struct{})
+		for _, g := range groups {
+			for _, ports := range r.groupNamedPorts[getGroupUniqueName(g, isAddrGrp)] {
+				for _, p := range ports {
+					if p.Name == s.Port.StrVal && p.Protocol == protocol {
+						portSet[p.Port] = struct{}{}
+					}
+				}
+			}
+		}
+		if len(portSet) > 1 {
+			conflicts = append(conflicts, s.Port.StrVal)
+			if !isDeny {
+				r.Log.Info("Conflicting named port, ignore allow service", "port", s.Port.StrVal, "groups", groups)
+				continue
+			}
+		}
+		if len(portSet) == 0 {
+			if !isDeny {
+				r.Log.Info("Unresolved named port, ignore allow service", "port", s.Port.StrVal, "groups", groups)
+				continue
+			}
+			r.Log.Info("Unresolved named port, deny all ports of protocol", "port", s.Port.StrVal,
+				"protocol", protocol, "groups", groups)
+			ss := *s.DeepCopy()
+			ss.Port = nil
+			ss.EndPort = nil
+			resolved = append(resolved, ss)
+			continue
+		}
+		ports := make([]int, 0, len(portSet))
+		for p := range portSet {
+			ports = append(ports, int(p))
+		}
+		sort.Ints(ports)
+		for _, p := range ports {
+			ss := *s.DeepCopy()
+			port := intstr.FromInt(p)
+			ss.Port = &port
+			ss.EndPort = nil
+			resolved = append(resolved, ss)
+		}
+	}
+	return resolved, conflicts
+}
+
+// getServicePorts returns port range and ICMP type and code of an Antrea service.
+func getServicePorts(s *antreanetworking.Service) (port, endPort, icmpType, icmpCode *int) {
+	if s.Port != nil {
+		p := int(s.Port.IntVal)
+		port = &p
+		if s.EndPort != nil && int(*s.EndPort) != p {
+			e := int(*s.EndPort)
+			endPort = &e
+		}
+	}
+	if s.ICMPType != nil {
+		t := int(*s.ICMPType)
+		icmpType = &t
+	}
+	if s.ICMPCode != nil {
+		c := int(*s.ICMPCode)
+		icmpCode = &c
+	}
+	return
+}
+
 // networkPolicy describe an Antrea internal/user facing networkPolicy.
 type networkPolicy struct {
 	antreanetworking.NetworkPolicy
 	ingressRules []*securitygroup.IngressRule
 	egressRules  []*securitygroup.EgressRule
 	rulesReady   bool
+	// namedPortConflicts are named ports resolved to different port numbers by members of a rule's groups.
+	namedPortConflicts map[string]struct{}
+}
+
+// getNetworkPolicyKey returns key that uniquely identifies a networkPolicy, as user facing
+// networkPolicies of different types may have the same name and namespace.
+func getNetworkPolicyKey(np *antreanetworking.NetworkPolicy) string {
+	return fmt.Sprintf("%s:%s/%s", np.SourceRef.Type, np.Namespace, np.Name)
+}
+
+// getStatusKey returns key of networkPolicy in VirtualMachinePolicy status, in the format of <type>:<name>.
+func (n *networkPolicy) getStatusKey() string {
+	return fmt.Sprintf("%s:%s", n.SourceRef.Type, n.Name)
 }
 
 // update an networkPolicy from Antrea controller.
@@ -1117,6 +1262,14 @@ func (n *networkPolicy) update(anp *antreanetworking.NetworkPolicy, recompute bo
 		}
 		modifiedAppliedTo = n.AppliedToGroups
 	} else {
+		if !reflect.DeepEqual(anp.TierPriority, n.TierPriority) || !reflect.DeepEqual(anp.Priority, n.Priority) {
+			// Rule priorities are derived from tier and policy priorities.
+			n.TierPriority = anp.TierPriority
+			n.Priority = anp.Priority
+			if ok := n.computeRules(r); ok {
+				modifiedAppliedTo = n.AppliedToGroups
+			}
+		}
 		if !reflect.DeepEqual(anp.Rules, n.Rules) {
 			// Indexer does not work with in-place update. Do delete->update->add
 			if err := r.networkPolicyIndexer.Delete(n); err != nil {
@@ -1286,8 +1439,17 @@ func (n *networkPolicy) computeRules(rr *NetworkPolicyReconciler) bool {
 	n.ingressRules = nil
 	n.egressRules = nil
 	n.rulesReady = false
+	n.namedPortConflicts = make(map[string]struct{})
 	for _, r := range n.Rules {
-		ing, eg, ready := (&networkPolicyRule{rule: &r}).rules(rr)
+		appliedToGroups := n.AppliedToGroups
+		if len(r.AppliedToGroups) > 0 {
+			appliedToGroups = r.AppliedToGroups
+		}
+		npRule := &networkPolicyRule{rule: &r, priority: n.getRulePriority(&r), appliedToGroups: appliedToGroups}
+		ing, eg, ready := npRule.rules(rr)
+		for _, name := range npRule.namedPortConflicts {
+			n.namedPortConflicts[name] = struct{}{}
+		}
 		if !ready {
 			n.ingressRules = nil
 			n.egressRules = nil
@@ -1304,6 +1466,21 @@ func (n *networkPolicy) computeRules(rr *NetworkPolicyReconciler) bool {
 	return n.rulesReady
 }
 
+// getRulePriority returns priority of rule in networkPolicy, or nil if networkPolicy has no tier and priority.
+func (n *networkPolicy) getRulePriority(rule *antreanetworking.NetworkPolicyRule) *securitygroup.RulePriority {
+	if n.SourceRef != nil && n.SourceRef.Type == antreanetworking.K8sNetworkPolicy {
+		return &securitygroup.RulePriority{TierPriority: k8sNetworkPolicyTierPriority, RuleIndex: rule.Priority}
+	}
+	if n.TierPriority == nil || n.Priority == nil {
+		return nil
+	}
+	return &securitygroup.RulePriority{
+		TierPriority:   *n.TierPriority,
+		PolicyPriority: *n.Priority,
+		RuleIndex:      rule.Priority,
+	}
+}
+
 // markDirty marks all cloud resources this NetworkPolicy applied to dirty.
 func (n *networkPolicy) markDirty(r *NetworkPolicyReconciler) {
 	for _, key := range n.AppliedToGroups {
@@ -1319,8 +1496,30 @@ func (n *networkPolicy) markDirty(r *NetworkPolicyReconciler) {
 	}
 }
 
+// getRuleActionStatus returns error if cloud of appliedToSecurityGroup id cannot enforce networkPolicy rule actions.
+func (n *networkPolicy) getRuleActionStatus(id *securitygroup.CloudResourceID) error {
+	for _, rule := range n.Rules {
+		action := ruleActionMap[getRuleAction(rule.Action)]
+		if !action.IsDeny() {
+			continue
+		}
+		if !securitygroup.CloudSecurityGroup.IsRuleActionSupported(id, action) {
+			return fmt.Errorf("%v action is not supported by cloud of %v", getRuleAction(rule.Action), id.Vpc)
+		}
+	}
+	return nil
+}
+
 // getStatus returns status of networkPolicy.
 func (n *networkPolicy) getStatus(r *NetworkPolicyReconciler) error {
+	if len(n.namedPortConflicts) > 0 {
+		names := make([]string, 0, len(n.namedPortConflicts))
+		for name := range n.namedPortConflicts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("named ports %s are resolved to different ports by members", strings.Join(names, ","))
+	}
 	if n.ingressRules == nil && n.egressRules == nil {
 		return &InProgress{}
 	}
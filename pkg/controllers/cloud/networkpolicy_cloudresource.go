@@ -55,6 +55,7 @@ func vmNPStatusSetter(tracker *cloudResourceNPTracker, r *NetworkPolicyReconcile
 	}
 	for _, vm := range vmList.Items {
 		npStatus, ok := status[vm.Namespace]
+		// cluster scoped policies apply to VMs in all namespaces.
 		if len(status[""]) > 0 {
 			if npStatus == nil {
 				npStatus = make(map[string]string)
@@ -62,6 +63,7 @@ func vmNPStatusSetter(tracker *cloudResourceNPTracker, r *NetworkPolicyReconcile
 			for k, v := range status[""] {
 				npStatus[k] = v
 			}
+			ok = true
 		}
 		indexKey := types.NamespacedName{Namespace: vm.Namespace, Name: vm.Name}
 		obj, found, _ := r.virtualMachinePolicyIndexer.GetByKey(indexKey.String())
@@ -229,20 +231,24 @@ func (c *cloudResourceNPTracker) computeNPStatus(r *NetworkPolicyReconciler) map
 		// networkPolicy rules are ready to be sent, and
 		// appliedToSG of this cloud resource is ready.
 		if status := np.getStatus(r); status != nil {
-			npList[np.Name] = status.Error()
+			npList[np.getStatusKey()] = status.Error()
 			continue
 		}
 		i, found, _ := r.appliedToSGIndexer.GetByKey(asgName)
 		if !found {
-			npList[np.Name] = asgName + "=Internal Error "
+			npList[np.getStatusKey()] = asgName + "=Internal Error "
 			continue
 		}
 		asg := i.(*appliedToSecurityGroup)
+		if status := np.getRuleActionStatus(&asg.id); status != nil {
+			npList[np.getStatusKey()] = asgName + "=" + status.Error()
+			continue
+		}
 		if status := asg.getStatus(); status != nil {
-			npList[np.Name] = asgName + "=" + status.Error()
+			npList[np.getStatusKey()] = asgName + "=" + status.Error()
 			continue
 		}
-		npList[np.Name] = NetworkPolicyStatusApplied
+		npList[np.getStatusKey()] = NetworkPolicyStatusApplied
 	}
 
 	newPrevSgs := make(map[string]*appliedToSecurityGroup)
@@ -269,7 +275,7 @@ func (c *cloudResourceNPTracker) computeNPStatus(r *NetworkPolicyReconciler) map
 				npList = make(map[string]string)
 				ret[np.Namespace] = npList
 			}
-			npList[np.Name] = errMsg
+			npList[np.getStatusKey()] = errMsg
 		}
 		if len(nps) == 0 {
 			// handle dangling appliedToGroups with no namespaces.
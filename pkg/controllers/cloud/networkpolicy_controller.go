@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -51,8 +52,9 @@ const (
 	virtualMachineIndexerByCloudID              = "metadata.annotations.cloud-assigned-id"
 	virtualMachineIndexerByCloudName            = "metadata.annotations.cloud-assigned-name"
 
-	operationCount    = 15
-	cloudSyncInterval = 0xff // 256 Seconds
+	operationCount = 15
+	// DefaultCloudSyncInterval is the interval security groups are synchronized with cloud, if not specified.
+	DefaultCloudSyncInterval = 256 * time.Second
 
 	// NetworkPolicy controller is ready to sync after it receives bookmarks from
 	// networkpolicy, addrssGroup and appliedToGroup.
@@ -73,6 +75,17 @@ type NetworkPolicyReconciler struct {
 	Log          logr.Logger
 	Scheme       *runtime.Scheme
 	antreaClient *antreanetworkingclient.ControlplaneV1beta2Client
+	// SnapshotNamespace is the namespace of the cloud security snapshot, which is checkpointed on every
+	// synchronization with cloud. No snapshot is kept if empty.
+	SnapshotNamespace string
+	// DryRun is true if cloud SecurityGroup operations are recorded instead of invoking cloud plug-in, and are
+	// exposed as plans against the cloud view of enforced security.
+	DryRun bool
+	dryRun *dryRunSecurityGroup
+	// Recorder records events of drift detected in cloud security groups.
+	Recorder record.EventRecorder
+	// CloudSyncInterval is the interval security groups are synchronized with cloud.
+	CloudSyncInterval time.Duration
 
 	// Watcher interfaces
 	addrGroupWatcher      watch.Interface
@@ -85,6 +98,7 @@ type NetworkPolicyReconciler struct {
 	appliedToSGIndexer            cache.Indexer
 	cloudResourceNPTrackerIndexer cache.Indexer
 	virtualMachinePolicyIndexer   cache.Indexer
+	cloudSecurityDriftIndexer     cache.Indexer
 
 	// pendingDeleteGroups keep tracks of deleting AddressGroup or AppliedToGroup.
 	pendingDeleteGroups *PendingItemQueue
@@ -95,6 +109,18 @@ type NetworkPolicyReconciler struct {
 
 	// syncedWithCloud is true if controller has synchronized with cloud at least once.
 	syncedWithCloud bool
+	// syncedWithSnapshot is true if controller has synchronized with cloud security snapshot only, and is to
+	// synchronize with cloud as soon as cloud inventory is available.
+	syncedWithSnapshot bool
+	// lastCloudSyncTime is the time security groups were last synchronized with cloud.
+	lastCloudSyncTime time.Time
+	// accountCloudSyncTimes keeps track of the time security groups of an account were last synchronized with cloud
+	// on their own, keyed by account.
+	accountCloudSyncTimes map[types.NamespacedName]time.Time
+	// accountResyncRequests keeps track of the last handled resync annotation of an account, keyed by account.
+	accountResyncRequests map[types.NamespacedName]string
+	// securitySnapshotChecksum is the checksum of the last checkpointed cloud security snapshot.
+	securitySnapshotChecksum string
 	// Bookmark events received prior to sync with the cloud.
 	bookmarkCnt int
 
@@ -103,6 +129,10 @@ type NetworkPolicyReconciler struct {
 
 	// Federated ExternalEntities IPs.
 	fedExternalEntityIPs map[string][]string
+
+	// groupNamedPorts keeps track of named ports of AddressGroup and AppliedToGroup members,
+	// keyed by group unique name and member.
+	groupNamedPorts map[string]map[string][]antreanetworking.NamedPort
 }
 
 // isNetworkPolicySupported check if network policy is supported.
@@ -110,18 +140,35 @@ func (r *NetworkPolicyReconciler) isNetworkPolicySupported(anp *antreanetworking
 	if anp.SourceRef == nil {
 		return fmt.Errorf("source reference not set in network policy")
 	}
-	if anp.SourceRef.Type != antreanetworking.AntreaNetworkPolicy {
-		return fmt.Errorf("only antrea network policy is supported")
+	switch anp.SourceRef.Type {
+	case antreanetworking.AntreaNetworkPolicy, antreanetworking.AntreaClusterNetworkPolicy, antreanetworking.K8sNetworkPolicy:
+	default:
+		return fmt.Errorf("unsupported network policy type %v", anp.SourceRef.Type)
 	}
 	// Check for support actions
 	for _, rule := range anp.Rules {
-		if rule.Action != nil && *rule.Action != v1alpha1.RuleActionAllow {
-			return fmt.Errorf("only Allow action is supported in antrea network policy")
+		if _, ok := ruleActionMap[getRuleAction(rule.Action)]; !ok {
+			return fmt.Errorf("only Allow, Drop and Reject actions are supported in antrea network policy")
 		}
 	}
 	return nil
 }
 
+// ruleActionMap maps supported antrea rule actions to cloud SecurityGroup rule actions.
+var ruleActionMap = map[v1alpha1.RuleAction]securitygroup.RuleAction{
+	v1alpha1.RuleActionAllow:  securitygroup.RuleActionAllow,
+	v1alpha1.RuleActionDrop:   securitygroup.RuleActionDrop,
+	v1alpha1.RuleActionReject: securitygroup.RuleActionReject,
+}
+
+// getRuleAction returns antrea rule action, a rule without action is an Allow rule.
+func getRuleAction(action *v1alpha1.RuleAction) v1alpha1.RuleAction {
+	if action == nil {
+		return v1alpha1.RuleActionAllow
+	}
+	return *action
+}
+
 // processMemberGrp is common function to process AppliedTo/AddressGroup updates from Antrea controller.
 func (r *NetworkPolicyReconciler) processMemberGrp(name string, eventType watch.EventType, isAddrGrp bool,
 	added, removed []antreanetworking.GroupMember) error {
@@ -163,6 +210,8 @@ func (r *NetworkPolicyReconciler) processMemberGrp(name string, eventType watch.
 		creator = newAppliedToSecurityGroup
 	}
 
+	namedPortsChanged := r.updateGroupNamedPorts(uName, eventType, added, removed)
+
 	var addedMembers, removedMembers map[string][]*securitygroup.CloudResource
 	var addedIPs, removedIPs []*net.IPNet
 	var notFoundMember []string
@@ -281,8 +330,12 @@ func (r *NetworkPolicyReconciler) processMemberGrp(name string, eventType watch.
 		_ = sg.(*addrSecurityGroup).add(r)
 		sgChanges = true
 	}
-	if sgChanges && isAddrGrp {
-		nps, _ := r.networkPolicyIndexer.ByIndex(networkPolicyIndexerByAddrGrp, name)
+	if (sgChanges && isAddrGrp) || namedPortsChanged {
+		npIndex := networkPolicyIndexerByAppliedToGrp
+		if isAddrGrp {
+			npIndex = networkPolicyIndexerByAddrGrp
+		}
+		nps, _ := r.networkPolicyIndexer.ByIndex(npIndex, name)
 		for _, i := range nps {
 			np := i.(*networkPolicy)
 			np.update(nil, true, r)
@@ -291,6 +344,47 @@ func (r *NetworkPolicyReconciler) processMemberGrp(name string, eventType watch.
 	return nil
 }
 
+// updateGroupNamedPorts updates named ports of group members, and returns true if named ports of the group have changed.
+func (r *NetworkPolicyReconciler) updateGroupNamedPorts(uName string, eventType watch.EventType,
+	added, removed []antreanetworking.GroupMember) bool {
+	if eventType == watch.Deleted {
+		delete(r.groupNamedPorts, uName)
+		return false
+	}
+	current := r.groupNamedPorts[uName]
+	namedPorts := make(map[string][]antreanetworking.NamedPort)
+	if eventType == watch.Modified {
+		for k, v := range current {
+			namedPorts[k] = v
+		}
+	}
+	for _, m := range removed {
+		if m.ExternalEntity != nil {
+			delete(namedPorts, types.NamespacedName{Namespace: m.ExternalEntity.Namespace, Name: m.ExternalEntity.Name}.String())
+		}
+	}
+	for _, m := range added {
+		if m.ExternalEntity == nil {
+			continue
+		}
+		key := types.NamespacedName{Namespace: m.ExternalEntity.Namespace, Name: m.ExternalEntity.Name}.String()
+		if len(m.Ports) == 0 {
+			delete(namedPorts, key)
+		} else {
+			namedPorts[key] = m.Ports
+		}
+	}
+	if len(namedPorts) == 0 {
+		delete(r.groupNamedPorts, uName)
+	} else {
+		r.groupNamedPorts[uName] = namedPorts
+	}
+	if len(current) == 0 && len(namedPorts) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(current, namedPorts)
+}
+
 // processAddrGrp processes AddrGroup updates from Antrea controller.
 func (r *NetworkPolicyReconciler) processAddrGrp(event watch.Event) error {
 	accessor, _ := meta.Accessor(event.Object)
@@ -354,7 +448,7 @@ func (r *NetworkPolicyReconciler) processNetworkPolicy(event watch.Event) error
 
 	var np *networkPolicy
 	isCreate := false
-	npKey := types.NamespacedName{Name: anp.Name, Namespace: anp.Namespace}.String()
+	npKey := getNetworkPolicyKey(anp)
 	if i, ok, _ := r.networkPolicyIndexer.GetByKey(npKey); !ok {
 		np = &networkPolicy{}
 		anp.DeepCopyInto(&np.NetworkPolicy)
@@ -370,7 +464,8 @@ func (r *NetworkPolicyReconciler) processNetworkPolicy(event watch.Event) error
 		return nil
 	}
 	if !isCreate && reflect.DeepEqual(anp.Rules, np.Rules) &&
-		reflect.DeepEqual(anp.AppliedToGroups, np.AppliedToGroups) {
+		reflect.DeepEqual(anp.AppliedToGroups, np.AppliedToGroups) &&
+		reflect.DeepEqual(anp.TierPriority, np.TierPriority) && reflect.DeepEqual(anp.Priority, np.Priority) {
 		r.Log.V(1).Info("Ignore update unchanged NetworkPolicy", "Name", anp.Name, "Namespace", anp.Namespace)
 		return nil
 	}
@@ -460,9 +555,10 @@ func (r *NetworkPolicyReconciler) Start(stop context.Context) error {
 		case <-ticker.C:
 			r.backgroupProcess()
 			r.retryQueue.CheckToRun()
-			if time.Now().Unix()&cloudSyncInterval == 0 {
+			if time.Since(r.lastCloudSyncTime) >= r.CloudSyncInterval || (r.syncedWithSnapshot && r.isCloudSecurityEnforcementReady()) {
 				r.syncWithCloud()
 			}
+			r.syncAccountsWithCloud()
 		case <-stop.Done():
 			r.Log.Info("is stopped")
 			return nil
@@ -486,6 +582,11 @@ func (r *NetworkPolicyReconciler) backgroupProcess() {
 
 // SetupWithManager sets up NetworkPolicyReconciler with manager.
 func (r *NetworkPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.DryRun {
+		r.Log.Info("Running in dry-run mode, cloud security is not changed")
+		r.dryRun = newDryRunSecurityGroup(securitygroup.CloudSecurityGroup)
+		securitygroup.CloudSecurityGroup = r.dryRun
+	}
 	r.addrSGIndexer = cache.NewIndexer(
 		// Each addrSecurityGroup is uniquely identified by its ID.
 		func(obj interface{}) (string, error) {
@@ -515,7 +616,7 @@ func (r *NetworkPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.networkPolicyIndexer = cache.NewIndexer(
 		func(obj interface{}) (string, error) {
 			np := obj.(*networkPolicy)
-			return types.NamespacedName{Name: np.Name, Namespace: np.Namespace}.String(), nil
+			return getNetworkPolicyKey(&np.NetworkPolicy), nil
 		},
 		cache.Indexers{
 			// networkPolicy indexed by Antrea AddrGroup ID.
@@ -568,10 +669,22 @@ func (r *NetworkPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				return ret, nil
 			},
 		})
+	r.cloudSecurityDriftIndexer = cache.NewIndexer(
+		// Each CloudSecurityDrift is uniquely identified by its SecurityGroup.
+		func(obj interface{}) (string, error) {
+			drift := obj.(*CloudSecurityDrift)
+			return drift.String(), nil
+		}, cache.Indexers{})
 	r.localRequest = make(chan watch.Event)
 	r.cloudResponse = make(chan *securityGroupStatus)
 	r.pendingDeleteGroups = NewPendingItemQueue(r, nil)
 	r.fedExternalEntityIPs = make(map[string][]string)
+	r.groupNamedPorts = make(map[string]map[string][]antreanetworking.NamedPort)
+	r.accountCloudSyncTimes = make(map[types.NamespacedName]time.Time)
+	r.accountResyncRequests = make(map[types.NamespacedName]string)
+	if r.CloudSyncInterval <= 0 {
+		r.CloudSyncInterval = DefaultCloudSyncInterval
+	}
 	opCnt := operationCount
 	r.retryQueue = NewPendingItemQueue(r, &opCnt)
 
@@ -0,0 +1,271 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// CloudSecurityDriftType is the type of a discrepancy between a cloud SecurityGroup realized by controller and the
+// SecurityGroup in cloud.
+type CloudSecurityDriftType string
+
+const (
+	// CloudSecurityDriftExtraRule is a rule in cloud not computed by controller.
+	CloudSecurityDriftExtraRule CloudSecurityDriftType = "ExtraRule"
+	// CloudSecurityDriftMissingRule is a rule computed by controller missing in cloud.
+	CloudSecurityDriftMissingRule CloudSecurityDriftType = "MissingRule"
+	// CloudSecurityDriftForeignMember is a member in cloud not computed by controller.
+	CloudSecurityDriftForeignMember CloudSecurityDriftType = "ForeignMember"
+	// CloudSecurityDriftMissingMember is a member computed by controller missing in cloud.
+	CloudSecurityDriftMissingMember CloudSecurityDriftType = "MissingMember"
+	// CloudSecurityDriftDeletedSecurityGroup is a SecurityGroup created by controller missing in cloud.
+	CloudSecurityDriftDeletedSecurityGroup CloudSecurityDriftType = "DeletedSecurityGroup"
+
+	// cloudSecurityDriftEventReason is the reason of events reporting drift.
+	cloudSecurityDriftEventReason = "CloudSecurityDrift"
+)
+
+var cloudSecurityDriftCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "nephe_cloud_security_drift_total",
+	Help: "Number of discrepancies detected between cloud security groups realized by controller and cloud.",
+}, []string{"account", "type", "policy"})
+
+func init() {
+	metrics.Registry.MustRegister(cloudSecurityDriftCount)
+}
+
+// CloudSecurityDiscrepancy is a discrepancy of a cloud SecurityGroup.
+type CloudSecurityDiscrepancy struct {
+	Type CloudSecurityDriftType
+	Item string
+}
+
+// CloudSecurityDrift is the drift of a cloud SecurityGroup realized by controller, detected on synchronization with
+// cloud.
+type CloudSecurityDrift struct {
+	ID             securitygroup.CloudResourceID
+	MembershipOnly bool
+	// Account is the account managing the SecurityGroup, nil if unknown.
+	Account       *types.NamespacedName
+	Policy        cloudv1alpha1.CloudSecurityDriftPolicy
+	Discrepancies []CloudSecurityDiscrepancy
+	// DetectionTime is the time the drift is first detected.
+	DetectionTime time.Time
+}
+
+// String returns the key of CloudSecurityDrift.
+func (d *CloudSecurityDrift) String() string {
+	return getGroupUniqueName(d.ID.String(), d.MembershipOnly)
+}
+
+// isSecurityGroupRealized returns true if SecurityGroup s has been realized in cloud, with no operation in progress
+// or to be retried, so that any discrepancy with cloud is drift.
+func isSecurityGroupRealized(s *securityGroupImpl) bool {
+	return s.state == securityGroupStateCreated && s.status == nil && s.retryOp == nil && !s.deletePending
+}
+
+// getMemberDiscrepancies returns discrepancies between members of SecurityGroup s and members in cloud content c.
+func getMemberDiscrepancies(s *securityGroupImpl, c *securitygroup.SynchronizationContent,
+	r *NetworkPolicyReconciler) []CloudSecurityDiscrepancy {
+	members := s.members
+	if len(c.Members) > 0 && c.Members[0].Type == securitygroup.CloudResourceTypeNIC {
+		members, _ = r.getNICsOfCloudResources(s.members)
+	}
+	items := make(map[string]int)
+	for _, m := range members {
+		items[m.String()]++
+	}
+	for i := range c.Members {
+		items[c.Members[i].String()]--
+	}
+	missing, foreign := diffItems(items)
+	var discrepancies []CloudSecurityDiscrepancy
+	for _, m := range foreign {
+		discrepancies = append(discrepancies, CloudSecurityDiscrepancy{Type: CloudSecurityDriftForeignMember, Item: m})
+	}
+	for _, m := range missing {
+		discrepancies = append(discrepancies, CloudSecurityDiscrepancy{Type: CloudSecurityDriftMissingMember, Item: m})
+	}
+	return discrepancies
+}
+
+// getRuleDiscrepancies returns discrepancies between rules of appliedToSecurityGroup a and rules in cloud content c.
+func getRuleDiscrepancies(a *appliedToSecurityGroup, c *securitygroup.SynchronizationContent,
+	r *NetworkPolicyReconciler) []CloudSecurityDiscrepancy {
+	if !a.hasRules {
+		return nil
+	}
+	nps, err := r.networkPolicyIndexer.ByIndex(networkPolicyIndexerByAppliedToGrp, a.id.Name)
+	if err != nil || len(nps) == 0 {
+		return nil
+	}
+	irules, erules, ready := a.getRules(nps, r)
+	if !ready {
+		return nil
+	}
+	ingressRules := make([]securitygroup.IngressRule, 0, len(irules))
+	for _, rule := range irules {
+		ingressRules = append(ingressRules, *rule)
+	}
+	egressRules := make([]securitygroup.EgressRule, 0, len(erules))
+	for _, rule := range erules {
+		egressRules = append(egressRules, *rule)
+	}
+	items := make(map[string]int)
+	countRuleItems(items, ingressRules, egressRules, 1)
+	countRuleItems(items, c.IngressRules, c.EgressRules, -1)
+	missing, extra := diffItems(items)
+	var discrepancies []CloudSecurityDiscrepancy
+	for _, rule := range extra {
+		discrepancies = append(discrepancies, CloudSecurityDiscrepancy{Type: CloudSecurityDriftExtraRule, Item: rule})
+	}
+	for _, rule := range missing {
+		discrepancies = append(discrepancies, CloudSecurityDiscrepancy{Type: CloudSecurityDriftMissingRule, Item: rule})
+	}
+	return discrepancies
+}
+
+// getSecurityGroupDiscrepancies returns discrepancies between SecurityGroup sg realized by controller and cloud
+// content c, nil if sg is not realized.
+func getSecurityGroupDiscrepancies(sg cloudSecurityGroup, c *securitygroup.SynchronizationContent,
+	r *NetworkPolicyReconciler) []CloudSecurityDiscrepancy {
+	var s *securityGroupImpl
+	var appliedTo *appliedToSecurityGroup
+	switch g := sg.(type) {
+	case *addrSecurityGroup:
+		s = &g.securityGroupImpl
+	case *appliedToSecurityGroup:
+		s, appliedTo = &g.securityGroupImpl, g
+	}
+	if s == nil || !isSecurityGroupRealized(s) {
+		return nil
+	}
+	if c == nil {
+		// Cloud may not report SecurityGroups without members.
+		if len(s.members) == 0 {
+			return nil
+		}
+		return []CloudSecurityDiscrepancy{{Type: CloudSecurityDriftDeletedSecurityGroup, Item: s.id.String()}}
+	}
+	discrepancies := getMemberDiscrepancies(s, c, r)
+	if appliedTo != nil {
+		discrepancies = append(discrepancies, getRuleDiscrepancies(appliedTo, c, r)...)
+	}
+	return discrepancies
+}
+
+// getCloudSecurityDriftPolicy returns the account managing SecurityGroup id and its drift policy. Drift is remediated
+// if the account is unknown.
+func (r *NetworkPolicyReconciler) getCloudSecurityDriftPolicy(id *securitygroup.CloudResourceID) (
+	*cloudv1alpha1.CloudProviderAccount, cloudv1alpha1.CloudSecurityDriftPolicy) {
+	accountName := securitygroup.CloudSecurityGroup.GetSecurityGroupAccount(id)
+	if accountName == nil {
+		return nil, cloudv1alpha1.CloudSecurityDriftPolicyRemediate
+	}
+	account := &cloudv1alpha1.CloudProviderAccount{}
+	if err := r.Get(context.TODO(), *accountName, account); err != nil {
+		r.Log.V(1).Info("Failed to get account", "account", accountName, "error", err)
+		return nil, cloudv1alpha1.CloudSecurityDriftPolicyRemediate
+	}
+	if len(account.Spec.DriftPolicy) == 0 {
+		return account, cloudv1alpha1.CloudSecurityDriftPolicyRemediate
+	}
+	return account, account.Spec.DriftPolicy
+}
+
+// checkCloudSecurityDrift detects drift of SecurityGroup sg against cloud content c, and reports drift not ignored by
+// policy of its account in drifts. It returns true if sg is to be synchronized with cloud.
+func (r *NetworkPolicyReconciler) checkCloudSecurityDrift(sg cloudSecurityGroup, c *securitygroup.SynchronizationContent,
+	membershipOnly bool, drifts map[string]*CloudSecurityDrift) bool {
+	discrepancies := getSecurityGroupDiscrepancies(sg, c, r)
+	if len(discrepancies) == 0 {
+		return true
+	}
+	id := sg.getID()
+	account, policy := r.getCloudSecurityDriftPolicy(&id)
+	if policy == cloudv1alpha1.CloudSecurityDriftPolicyIgnore {
+		return false
+	}
+	drift := &CloudSecurityDrift{
+		ID:             id,
+		MembershipOnly: membershipOnly,
+		Policy:         policy,
+		Discrepancies:  discrepancies,
+		DetectionTime:  time.Now(),
+	}
+	accountLabel := ""
+	if account != nil {
+		drift.Account = &types.NamespacedName{Namespace: account.Namespace, Name: account.Name}
+		accountLabel = drift.Account.String()
+	}
+	reported := make(map[CloudSecurityDiscrepancy]struct{})
+	if i, ok, _ := r.cloudSecurityDriftIndexer.GetByKey(drift.String()); ok {
+		prev := i.(*CloudSecurityDrift)
+		drift.DetectionTime = prev.DetectionTime
+		for _, d := range prev.Discrepancies {
+			reported[d] = struct{}{}
+		}
+	}
+	for _, d := range discrepancies {
+		if _, ok := reported[d]; ok {
+			continue
+		}
+		r.Log.Info("Detected cloud security drift", "SecurityGroup", id.GetCloudName(membershipOnly), "VPC", id.Vpc,
+			"Type", d.Type, "Item", d.Item, "Account", accountLabel, "Policy", policy)
+		cloudSecurityDriftCount.WithLabelValues(accountLabel, string(d.Type), string(policy)).Inc()
+		if r.Recorder != nil && account != nil {
+			r.Recorder.Eventf(account, corev1.EventTypeWarning, cloudSecurityDriftEventReason,
+				"%s of security group %s in %s: %s, policy %s", d.Type, id.GetCloudName(membershipOnly), id.Vpc, d.Item,
+				policy)
+		}
+	}
+	drifts[drift.String()] = drift
+	return policy == cloudv1alpha1.CloudSecurityDriftPolicyRemediate
+}
+
+// isCloudSecurityDriftRemediated returns true if drift of SecurityGroup id is remediated by policy of its account.
+func (r *NetworkPolicyReconciler) isCloudSecurityDriftRemediated(id *securitygroup.CloudResourceID) bool {
+	_, policy := r.getCloudSecurityDriftPolicy(id)
+	return policy == cloudv1alpha1.CloudSecurityDriftPolicyRemediate
+}
+
+// setCloudSecurityDrifts replaces drifts of SecurityGroups with drifts detected on synchronization with cloud.
+func (r *NetworkPolicyReconciler) setCloudSecurityDrifts(drifts map[string]*CloudSecurityDrift) {
+	list := make([]interface{}, 0, len(drifts))
+	for _, drift := range drifts {
+		list = append(list, drift)
+	}
+	if err := r.cloudSecurityDriftIndexer.Replace(list, ""); err != nil {
+		r.Log.Error(err, "Failed to update cloud security drifts")
+	}
+}
+
+// GetCloudSecurityDriftIndexer returns drifts of SecurityGroups detected on the last synchronization with cloud.
+func (r *NetworkPolicyReconciler) GetCloudSecurityDriftIndexer() cache.Indexer {
+	return r.cloudSecurityDriftIndexer
+}
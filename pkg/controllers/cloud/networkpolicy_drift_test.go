@@ -0,0 +1,125 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+var _ = Describe("NetworkPolicy drift", func() {
+	var (
+		reconciler  *NetworkPolicyReconciler
+		recorder    *record.FakeRecorder
+		vpc         = "vpc-1"
+		vm1         = newTestVM("vm-1", vpc)
+		vm2         = newTestVM("vm-2", vpc)
+		addrID      = securitygroup.CloudResourceID{Name: "addr-grp", Vpc: vpc}
+		accountName = types.NamespacedName{Namespace: "default", Name: "account"}
+	)
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = newTestNetworkPolicyReconciler(false, recorder)
+
+		state := securityGroupStateCreated
+		sg := newAddrSecurityGroup(&addrID, []*securitygroup.CloudResource{vm1}, &state)
+		Expect(reconciler.addrSGIndexer.Add(sg)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	// syncWithCloud synchronizes with addrID having an extra member vm2 in cloud, under drift policy of its account.
+	syncWithCloud := func(policy cloudv1alpha1.CloudSecurityDriftPolicy) {
+		ch := make(chan securitygroup.SynchronizationContent, 1)
+		ch <- securitygroup.SynchronizationContent{Resource: addrID, MembershipOnly: true,
+			Members: []securitygroup.CloudResource{*vm1, *vm2}}
+		close(ch)
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupSyncChan().Return(ch)
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupAccount(&addrID).Return(&accountName).AnyTimes()
+		mockClient.EXPECT().Get(mock.Any(), client.ObjectKey(accountName), mock.Any()).Return(nil).AnyTimes().
+			Do(func(_ context.Context, key client.ObjectKey, out *cloudv1alpha1.CloudProviderAccount) {
+				out.Namespace = key.Namespace
+				out.Name = key.Name
+				out.Spec.DriftPolicy = policy
+			})
+		reconciler.syncWithCloud()
+	}
+
+	It("Should report drift without remediation", func() {
+		syncWithCloud(cloudv1alpha1.CloudSecurityDriftPolicyReportOnly)
+		drifts := reconciler.GetCloudSecurityDriftIndexer().List()
+		Expect(drifts).To(HaveLen(1))
+		drift := drifts[0].(*CloudSecurityDrift)
+		Expect(drift.ID).To(Equal(addrID))
+		Expect(drift.MembershipOnly).To(BeTrue())
+		Expect(drift.Account).To(Equal(&accountName))
+		Expect(drift.Policy).To(Equal(cloudv1alpha1.CloudSecurityDriftPolicyReportOnly))
+		Expect(drift.Discrepancies).To(Equal([]CloudSecurityDiscrepancy{
+			{Type: CloudSecurityDriftForeignMember, Item: vm2.String()},
+		}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ForeignMember")))
+
+		// Drift already reported is not reported again.
+		detectionTime := drift.DetectionTime
+		syncWithCloud(cloudv1alpha1.CloudSecurityDriftPolicyReportOnly)
+		Expect(recorder.Events).ToNot(Receive())
+		drifts = reconciler.GetCloudSecurityDriftIndexer().List()
+		Expect(drifts).To(HaveLen(1))
+		Expect(drifts[0].(*CloudSecurityDrift).DetectionTime).To(Equal(detectionTime))
+	})
+
+	It("Should report and remediate drift", func() {
+		ch := make(chan error, 1)
+		ch <- nil
+		mockCloudSecurityAPI.EXPECT().UpdateSecurityGroupMembers(&addrID, []*securitygroup.CloudResource{vm1}, true).
+			Return(ch)
+		syncWithCloud(cloudv1alpha1.CloudSecurityDriftPolicyRemediate)
+		Expect(reconciler.GetCloudSecurityDriftIndexer().List()).To(HaveLen(1))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ForeignMember")))
+	})
+
+	It("Should not remediate drift from snapshot under report only policy", func() {
+		ch := make(chan securitygroup.SynchronizationContent, 1)
+		ch <- securitygroup.SynchronizationContent{Resource: addrID, MembershipOnly: true,
+			Members: []securitygroup.CloudResource{*vm1, *vm2}}
+		close(ch)
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupAccount(&addrID).Return(&accountName).AnyTimes()
+		mockClient.EXPECT().Get(mock.Any(), client.ObjectKey(accountName), mock.Any()).Return(nil).AnyTimes().
+			Do(func(_ context.Context, key client.ObjectKey, out *cloudv1alpha1.CloudProviderAccount) {
+				out.Spec.DriftPolicy = cloudv1alpha1.CloudSecurityDriftPolicyReportOnly
+			})
+		reconciler.syncWithCloudContents(ch, true, nil)
+		Expect(reconciler.GetCloudSecurityDriftIndexer().List()).To(BeEmpty())
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("Should ignore drift", func() {
+		syncWithCloud(cloudv1alpha1.CloudSecurityDriftPolicyIgnore)
+		Expect(reconciler.GetCloudSecurityDriftIndexer().List()).To(BeEmpty())
+		Expect(recorder.Events).ToNot(Receive())
+	})
+})
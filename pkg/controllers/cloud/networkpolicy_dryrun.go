@@ -0,0 +1,304 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"sort"
+	"sync"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+// SecurityGroupPlanOperation is the operation a dry-run controller would perform on a cloud SecurityGroup.
+type SecurityGroupPlanOperation string
+
+const (
+	SecurityGroupPlanOperationCreate SecurityGroupPlanOperation = "Create"
+	SecurityGroupPlanOperationUpdate SecurityGroupPlanOperation = "Update"
+	SecurityGroupPlanOperationDelete SecurityGroupPlanOperation = "Delete"
+)
+
+// SecurityGroupPlan is the difference between a cloud SecurityGroup computed by a dry-run controller and the
+// SecurityGroup enforced in cloud.
+type SecurityGroupPlan struct {
+	ID             securitygroup.CloudResourceID
+	MembershipOnly bool
+	Operation      SecurityGroupPlanOperation
+	// MembersToAdd and MembersToRemove are cloud resources to be attached to or detached from the SecurityGroup.
+	MembersToAdd    []string
+	MembersToRemove []string
+	// RulesToAdd and RulesToRevoke are rules to be added to or revoked from the SecurityGroup.
+	RulesToAdd    []string
+	RulesToRevoke []string
+}
+
+// dryRunGroupKey uniquely identifies a cloud SecurityGroup.
+type dryRunGroupKey struct {
+	id             securitygroup.CloudResourceID
+	membershipOnly bool
+}
+
+// dryRunGroup is the SecurityGroup computed by a dry-run controller.
+type dryRunGroup struct {
+	deleted         bool
+	membersRecorded bool
+	members         []securitygroup.CloudResource
+	rulesRecorded   bool
+	ingressRules    []securitygroup.IngressRule
+	egressRules     []securitygroup.EgressRule
+}
+
+// dryRunSecurityGroup implements securitygroup.CloudSecurityGroupAPI. It records SecurityGroup operations instead
+// of invoking cloud plug-in, and retrieves enforced SecurityGroups from cloud plug-in.
+type dryRunSecurityGroup struct {
+	securitygroup.CloudSecurityGroupAPI
+	mutex    sync.Mutex
+	groups   map[dryRunGroupKey]*dryRunGroup
+	enforced map[dryRunGroupKey]*securitygroup.SynchronizationContent
+}
+
+func newDryRunSecurityGroup(cloudSecurityGroup securitygroup.CloudSecurityGroupAPI) *dryRunSecurityGroup {
+	return &dryRunSecurityGroup{
+		CloudSecurityGroupAPI: cloudSecurityGroup,
+		groups:                make(map[dryRunGroupKey]*dryRunGroup),
+		enforced:              make(map[dryRunGroupKey]*securitygroup.SynchronizationContent),
+	}
+}
+
+// dryRunResult returns a channel of a successful SecurityGroup operation.
+func dryRunResult() <-chan error {
+	ch := make(chan error, 1)
+	ch <- nil
+	return ch
+}
+
+// getGroup returns recorded SecurityGroup of key, it must be called with mutex held.
+func (d *dryRunSecurityGroup) getGroup(key dryRunGroupKey) *dryRunGroup {
+	g, ok := d.groups[key]
+	if !ok {
+		g = &dryRunGroup{}
+		d.groups[key] = g
+	}
+	return g
+}
+
+func (d *dryRunSecurityGroup) CreateSecurityGroup(name *securitygroup.CloudResourceID, membershipOnly bool) <-chan error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.getGroup(dryRunGroupKey{id: *name, membershipOnly: membershipOnly}).deleted = false
+	return dryRunResult()
+}
+
+func (d *dryRunSecurityGroup) UpdateSecurityGroupMembers(name *securitygroup.CloudResourceID,
+	members []*securitygroup.CloudResource, membershipOnly bool) <-chan error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	g := d.getGroup(dryRunGroupKey{id: *name, membershipOnly: membershipOnly})
+	g.membersRecorded = true
+	g.members = make([]securitygroup.CloudResource, 0, len(members))
+	for _, m := range members {
+		g.members = append(g.members, *m)
+	}
+	return dryRunResult()
+}
+
+func (d *dryRunSecurityGroup) DeleteSecurityGroup(name *securitygroup.CloudResourceID, membershipOnly bool) <-chan error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.groups[dryRunGroupKey{id: *name, membershipOnly: membershipOnly}] = &dryRunGroup{deleted: true}
+	return dryRunResult()
+}
+
+func (d *dryRunSecurityGroup) UpdateSecurityGroupRules(name *securitygroup.CloudResourceID,
+	ingressRules []*securitygroup.IngressRule, egressRules []*securitygroup.EgressRule) <-chan error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	g := d.getGroup(dryRunGroupKey{id: *name})
+	g.rulesRecorded = true
+	g.ingressRules = make([]securitygroup.IngressRule, 0, len(ingressRules))
+	for _, rule := range ingressRules {
+		g.ingressRules = append(g.ingressRules, *rule)
+	}
+	g.egressRules = make([]securitygroup.EgressRule, 0, len(egressRules))
+	for _, rule := range egressRules {
+		g.egressRules = append(g.egressRules, *rule)
+	}
+	return dryRunResult()
+}
+
+// setEnforcedSecurity sets the cloud view of enforced SecurityGroups against which plans are computed.
+func (d *dryRunSecurityGroup) setEnforcedSecurity(contents []securitygroup.SynchronizationContent) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.enforced = make(map[dryRunGroupKey]*securitygroup.SynchronizationContent, len(contents))
+	for i := range contents {
+		c := contents[i]
+		d.enforced[dryRunGroupKey{id: c.Resource, membershipOnly: c.MembershipOnly}] = &c
+	}
+}
+
+// setAccountEnforcedSecurity sets the cloud view of enforced SecurityGroups of an account, whose SecurityGroups are
+// identified by isAccountSG.
+func (d *dryRunSecurityGroup) setAccountEnforcedSecurity(contents []securitygroup.SynchronizationContent,
+	isAccountSG func(*securitygroup.CloudResourceID) bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for key := range d.enforced {
+		if isAccountSG(&key.id) {
+			delete(d.enforced, key)
+		}
+	}
+	for i := range contents {
+		c := contents[i]
+		d.enforced[dryRunGroupKey{id: c.Resource, membershipOnly: c.MembershipOnly}] = &c
+	}
+}
+
+// getRuleActionItem returns action of a rule when computing plans.
+func getRuleActionItem(action securitygroup.RuleAction) string {
+	if action == securitygroup.RuleActionAllow {
+		return "action=Allow"
+	}
+	return "action=" + string(action)
+}
+
+// getRuleItems returns a rule when computing plans, one item per peer of the rule.
+func getRuleItems(direction string, action securitygroup.RuleAction, service string, ips []string,
+	sgs []*securitygroup.CloudResourceID) []string {
+	prefix := direction + " " + getRuleActionItem(action)
+	if service != "" {
+		prefix += " " + service
+	}
+	peers := ips
+	for _, sg := range sgs {
+		peers = append(peers, "securityGroup="+sg.String())
+	}
+	if len(peers) == 0 {
+		peers = []string{"any"}
+	}
+	items := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		items = append(items, prefix+" peer="+peer)
+	}
+	return items
+}
+
+// countRuleItems counts items of ingress and egress rules into items by delta.
+func countRuleItems(items map[string]int, ingressRules []securitygroup.IngressRule,
+	egressRules []securitygroup.EgressRule, delta int) {
+	for _, rule := range ingressRules {
+		ips := make([]string, 0, len(rule.FromSrcIP))
+		for _, ip := range rule.FromSrcIP {
+			ips = append(ips, ip.String())
+		}
+		service := getRuleServiceItem(rule.Protocol, rule.FromPort, rule.FromEndPort, rule.ICMPType, rule.ICMPCode)
+		for _, item := range getRuleItems("ingress", rule.Action, service, ips, rule.FromSecurityGroups) {
+			items[item] += delta
+		}
+	}
+	for _, rule := range egressRules {
+		ips := make([]string, 0, len(rule.ToDstIP))
+		for _, ip := range rule.ToDstIP {
+			ips = append(ips, ip.String())
+		}
+		service := getRuleServiceItem(rule.Protocol, rule.ToPort, rule.ToEndPort, rule.ICMPType, rule.ICMPCode)
+		for _, item := range getRuleItems("egress", rule.Action, service, ips, rule.ToSecurityGroups) {
+			items[item] += delta
+		}
+	}
+}
+
+// diffItems returns sorted items counted positive and negative respectively.
+func diffItems(items map[string]int) ([]string, []string) {
+	var added, removed []string
+	for item, cnt := range items {
+		if cnt > 0 {
+			added = append(added, item)
+		} else if cnt < 0 {
+			removed = append(removed, item)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// getPlans returns plans of recorded SecurityGroups differing from enforced SecurityGroups. Recorded members are
+// translated with getNICs if enforced members are NICs.
+func (d *dryRunSecurityGroup) getPlans(getNICs func([]*securitygroup.CloudResource) ([]*securitygroup.CloudResource,
+	error)) []*SecurityGroupPlan {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	plans := make([]*SecurityGroupPlan, 0)
+	for key, g := range d.groups {
+		c := d.enforced[key]
+		plan := &SecurityGroupPlan{ID: key.id, MembershipOnly: key.membershipOnly, Operation: SecurityGroupPlanOperationUpdate}
+		members := make(map[string]int)
+		rules := make(map[string]int)
+		if g.deleted {
+			if c == nil {
+				continue
+			}
+			plan.Operation = SecurityGroupPlanOperationDelete
+		} else if c == nil {
+			plan.Operation = SecurityGroupPlanOperationCreate
+			c = &securitygroup.SynchronizationContent{}
+		}
+
+		if g.deleted || g.membersRecorded {
+			desired := make([]*securitygroup.CloudResource, 0, len(g.members))
+			for i := range g.members {
+				desired = append(desired, &g.members[i])
+			}
+			if len(c.Members) > 0 && c.Members[0].Type == securitygroup.CloudResourceTypeNIC {
+				if nics, err := getNICs(desired); err == nil {
+					desired = nics
+				}
+			}
+			for _, m := range desired {
+				members[m.String()]++
+			}
+			for i := range c.Members {
+				members[c.Members[i].String()]--
+			}
+		}
+		if g.deleted || g.rulesRecorded {
+			countRuleItems(rules, g.ingressRules, g.egressRules, 1)
+			countRuleItems(rules, c.IngressRules, c.EgressRules, -1)
+		}
+		plan.MembersToAdd, plan.MembersToRemove = diffItems(members)
+		plan.RulesToAdd, plan.RulesToRevoke = diffItems(rules)
+		if plan.Operation == SecurityGroupPlanOperationUpdate && len(plan.MembersToAdd)+len(plan.MembersToRemove)+
+			len(plan.RulesToAdd)+len(plan.RulesToRevoke) == 0 {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].MembershipOnly != plans[j].MembershipOnly {
+			return plans[i].MembershipOnly
+		}
+		return plans[i].ID.String() < plans[j].ID.String()
+	})
+	return plans
+}
+
+// GetSecurityGroupPlans returns cloud SecurityGroup changes computed in dry-run mode, nil if not in dry-run mode.
+func (r *NetworkPolicyReconciler) GetSecurityGroupPlans() []*SecurityGroupPlan {
+	if r.dryRun == nil {
+		return nil
+	}
+	return r.dryRun.getPlans(r.getNICsOfCloudResources)
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"net"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+var _ = Describe("NetworkPolicy dry-run", func() {
+	var (
+		reconciler *NetworkPolicyReconciler
+		vpc        = "vpc-1"
+		vm1        = newTestVM("vm-1", vpc)
+		vm2        = newTestVM("vm-2", vpc)
+		port       = 22
+		protocol   = 6
+		ipNet      = &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(24, 32)}
+	)
+
+	BeforeEach(func() {
+		reconciler = newTestNetworkPolicyReconciler(true, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("Should record security group operations instead of invoking cloud plug-in", func() {
+		Expect(securitygroup.CloudSecurityGroup).To(BeIdenticalTo(reconciler.dryRun))
+		mockCloudSecurityAPI.EXPECT().IsRuleActionSupported(mock.Any(), securitygroup.RuleActionDrop).Return(false)
+		Expect(securitygroup.CloudSecurityGroup.IsRuleActionSupported(&vm1.Name, securitygroup.RuleActionDrop)).To(BeFalse())
+
+		addrID := &securitygroup.CloudResourceID{Name: "addr-grp", Vpc: vpc}
+		appliedToID := &securitygroup.CloudResourceID{Name: "applied-grp", Vpc: vpc}
+		idleID := &securitygroup.CloudResourceID{Name: "idle-grp", Vpc: vpc}
+		Expect(<-securitygroup.CloudSecurityGroup.CreateSecurityGroup(addrID, true)).To(BeNil())
+		Expect(<-securitygroup.CloudSecurityGroup.UpdateSecurityGroupMembers(addrID,
+			[]*securitygroup.CloudResource{vm1}, true)).To(BeNil())
+		Expect(<-securitygroup.CloudSecurityGroup.UpdateSecurityGroupMembers(appliedToID,
+			[]*securitygroup.CloudResource{vm2}, false)).To(BeNil())
+		Expect(<-securitygroup.CloudSecurityGroup.UpdateSecurityGroupRules(appliedToID,
+			[]*securitygroup.IngressRule{{FromPort: &port, Protocol: &protocol, FromSrcIP: []*net.IPNet{ipNet}}},
+			[]*securitygroup.EgressRule{{ToSecurityGroups: []*securitygroup.CloudResourceID{addrID},
+				Action: securitygroup.RuleActionDrop}})).To(BeNil())
+		Expect(<-securitygroup.CloudSecurityGroup.UpdateSecurityGroupMembers(idleID,
+			[]*securitygroup.CloudResource{vm1}, false)).To(BeNil())
+
+		reconciler.dryRun.setEnforcedSecurity([]securitygroup.SynchronizationContent{
+			{Resource: *appliedToID, Members: []securitygroup.CloudResource{*vm1},
+				IngressRules: []securitygroup.IngressRule{{FromSrcIP: []*net.IPNet{ipNet}}}},
+			{Resource: *idleID, Members: []securitygroup.CloudResource{*vm1}},
+		})
+		Expect(reconciler.GetSecurityGroupPlans()).To(Equal([]*SecurityGroupPlan{
+			{
+				ID:             *addrID,
+				MembershipOnly: true,
+				Operation:      SecurityGroupPlanOperationCreate,
+				MembersToAdd:   []string{vm1.String()},
+			},
+			{
+				ID:              *appliedToID,
+				Operation:       SecurityGroupPlanOperationUpdate,
+				MembersToAdd:    []string{vm2.String()},
+				MembersToRemove: []string{vm1.String()},
+				RulesToAdd: []string{
+					"egress action=Drop peer=securityGroup=addr-grp/vpc-1",
+					"ingress action=Allow protocol=6,port=22 peer=10.0.0.0/24",
+				},
+				RulesToRevoke: []string{"ingress action=Allow peer=10.0.0.0/24"},
+			},
+		}))
+	})
+
+	It("Should plan deletion of unknown security group on synchronization with cloud", func() {
+		content := securitygroup.SynchronizationContent{
+			Resource:     securitygroup.CloudResourceID{Name: "unknown-grp", Vpc: vpc},
+			Members:      []securitygroup.CloudResource{*vm1},
+			IngressRules: []securitygroup.IngressRule{{FromSrcIP: []*net.IPNet{ipNet}}},
+		}
+		ch := make(chan securitygroup.SynchronizationContent, 1)
+		ch <- content
+		close(ch)
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupSyncChan().Return(ch)
+		reconciler.syncWithCloud()
+		Expect(reconciler.GetSecurityGroupPlans()).To(Equal([]*SecurityGroupPlan{
+			{
+				ID:              content.Resource,
+				Operation:       SecurityGroupPlanOperationDelete,
+				MembersToRemove: []string{vm1.String()},
+				RulesToRevoke:   []string{"ingress action=Allow peer=10.0.0.0/24"},
+			},
+		}))
+	})
+
+	It("Should not plan without dry-run", func() {
+		Expect((&NetworkPolicyReconciler{}).GetSecurityGroupPlans()).To(BeNil())
+	})
+})
@@ -19,10 +19,36 @@ import (
 	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
 	"context"
 	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
 )
 
+// denyActionItem counts Drop/Reject rules when comparing rules with cloud.
+const denyActionItem = "action=deny"
+
+// getRuleServiceItem returns service of a rule when comparing rules with cloud, or empty string if rule applies to
+// any service.
+func getRuleServiceItem(protocol, port, endPort, icmpType, icmpCode *int) string {
+	proto, p := intValue(protocol, 0), intValue(port, 0)
+	if proto == 0 && p == 0 {
+		return ""
+	}
+	item := fmt.Sprintf("protocol=%v,port=%v", proto, p)
+	if endPort != nil {
+		item += fmt.Sprintf(",endPort=%v", *endPort)
+	}
+	if icmpType != nil {
+		item += fmt.Sprintf(",icmpType=%v", *icmpType)
+	}
+	if icmpCode != nil {
+		item += fmt.Sprintf(",icmpCode=%v", *icmpCode)
+	}
+	return item
+}
+
 // sync synchronizes securityGroup memberships with cloud.
 // Return true if cloud and controller has same membership.
 func (s *securityGroupImpl) syncImpl(csg cloudSecurityGroup, c *securitygroup.SynchronizationContent, membershipOnly bool,
@@ -93,18 +119,15 @@ func (a *appliedToSecurityGroup) sync(c *securitygroup.SynchronizationContent,
 			log.V(1).Info("Skip sync, networkPolicy not ready", "Name", np.Name, "Namespace", np.Namespace)
 			return
 		}
+		if np.getRuleActionStatus(&a.id) != nil {
+			continue
+		}
 		for _, iRule := range np.ingressRules {
-			proto := 0
-			if iRule.Protocol != nil {
-				proto = *iRule.Protocol
-			}
-			port := 0
-			if iRule.FromPort != nil {
-				port = *iRule.FromPort
+			if item := getRuleServiceItem(iRule.Protocol, iRule.FromPort, iRule.FromEndPort, iRule.ICMPType, iRule.ICMPCode); item != "" {
+				items[item]++
 			}
-			if proto > 0 || port > 0 {
-				portStr := fmt.Sprintf("protocol=%v,port=%v", proto, port)
-				items[portStr]++
+			if iRule.Action.IsDeny() {
+				items[denyActionItem]++
 			}
 			for _, ip := range iRule.FromSrcIP {
 				items[ip.String()]++
@@ -114,17 +137,11 @@ func (a *appliedToSecurityGroup) sync(c *securitygroup.SynchronizationContent,
 			}
 		}
 		for _, eRule := range np.egressRules {
-			proto := 0
-			if eRule.Protocol != nil {
-				proto = *eRule.Protocol
+			if item := getRuleServiceItem(eRule.Protocol, eRule.ToPort, eRule.ToEndPort, eRule.ICMPType, eRule.ICMPCode); item != "" {
+				items[item]++
 			}
-			port := 0
-			if eRule.ToPort != nil {
-				port = *eRule.ToPort
-			}
-			if proto > 0 || port > 0 {
-				portStr := fmt.Sprintf("protocol=%v,port=%v", proto, port)
-				items[portStr]++
+			if eRule.Action.IsDeny() {
+				items[denyActionItem]++
 			}
 			for _, ip := range eRule.ToDstIP {
 				items[ip.String()]++
@@ -140,17 +157,11 @@ func (a *appliedToSecurityGroup) sync(c *securitygroup.SynchronizationContent,
 	}
 	// Rough compare rules
 	for _, iRule := range c.IngressRules {
-		proto := 0
-		if iRule.Protocol != nil {
-			proto = *iRule.Protocol
-		}
-		port := 0
-		if iRule.FromPort != nil {
-			port = *iRule.FromPort
+		if item := getRuleServiceItem(iRule.Protocol, iRule.FromPort, iRule.FromEndPort, iRule.ICMPType, iRule.ICMPCode); item != "" {
+			items[item]--
 		}
-		if proto > 0 || port > 0 {
-			portStr := fmt.Sprintf("protocol=%v,port=%v", proto, port)
-			items[portStr]--
+		if iRule.Action.IsDeny() {
+			items[denyActionItem]--
 		}
 		for _, ip := range iRule.FromSrcIP {
 			items[ip.String()]--
@@ -160,17 +171,11 @@ func (a *appliedToSecurityGroup) sync(c *securitygroup.SynchronizationContent,
 		}
 	}
 	for _, eRule := range c.EgressRules {
-		proto := 0
-		if eRule.Protocol != nil {
-			proto = *eRule.Protocol
+		if item := getRuleServiceItem(eRule.Protocol, eRule.ToPort, eRule.ToEndPort, eRule.ICMPType, eRule.ICMPCode); item != "" {
+			items[item]--
 		}
-		port := 0
-		if eRule.ToPort != nil {
-			port = *eRule.ToPort
-		}
-		if proto > 0 || port > 0 {
-			portStr := fmt.Sprintf("protocol=%v,port=%v", proto, port)
-			items[portStr]--
+		if eRule.Action.IsDeny() {
+			items[denyActionItem]--
 		}
 		for _, ip := range eRule.ToDstIP {
 			items[ip.String()]--
@@ -195,21 +200,118 @@ func (a *appliedToSecurityGroup) sync(c *securitygroup.SynchronizationContent,
 	}
 }
 
+// getSecurityGroupSyncChan returns the cloud view of security groups. Prior to the first synchronization, the view
+// is restored from cloud security snapshot if available, and true is returned.
+func (r *NetworkPolicyReconciler) getSecurityGroupSyncChan() (<-chan securitygroup.SynchronizationContent, bool) {
+	if !r.syncedWithCloud {
+		if contents, ok := r.loadCloudSecuritySnapshot(); ok {
+			ch := make(chan securitygroup.SynchronizationContent, len(contents))
+			for _, content := range contents {
+				ch <- content
+			}
+			close(ch)
+			return ch, true
+		}
+	}
+	return securitygroup.CloudSecurityGroup.GetSecurityGroupSyncChan(), false
+}
+
 // syncWithCloud synchronizes security group in controller with cloud.
 // This is a blocking call intentionally so that no other events are accepted during
 // synchronization.
 func (r *NetworkPolicyReconciler) syncWithCloud() {
-	log := r.Log.WithName("CloudSync")
-
 	if r.bookmarkCnt < npSyncReadyBookMarkCnt {
 		return
 	}
-	ch := securitygroup.CloudSecurityGroup.GetSecurityGroupSyncChan()
+	ch, fromSnapshot := r.getSecurityGroupSyncChan()
+	r.syncWithCloudContents(ch, fromSnapshot, nil)
+	r.lastCloudSyncTime = time.Now()
+}
+
+// syncAccountWithCloud synchronizes security groups of account in controller with cloud. It is a blocking call as
+// syncWithCloud.
+func (r *NetworkPolicyReconciler) syncAccountWithCloud(account *types.NamespacedName) {
+	r.syncWithCloudContents(securitygroup.CloudSecurityGroup.GetAccountSecurityGroupSyncChan(account), false, account)
+	r.accountCloudSyncTimes[*account] = time.Now()
+}
+
+// syncAccountsWithCloud synchronizes security groups of accounts with cloud on demand of their resync annotation, or
+// on their own cloud sync interval.
+func (r *NetworkPolicyReconciler) syncAccountsWithCloud() {
+	if !r.syncedWithCloud || r.syncedWithSnapshot {
+		return
+	}
+	accountList := &v1alpha1.CloudProviderAccountList{}
+	if err := r.List(context.TODO(), accountList); err != nil {
+		r.Log.V(1).Info("Failed to list accounts", "error", err)
+		return
+	}
+	accounts := make(map[types.NamespacedName]struct{})
+	for i := range accountList.Items {
+		account := &accountList.Items[i]
+		name := types.NamespacedName{Namespace: account.Namespace, Name: account.Name}
+		accounts[name] = struct{}{}
+		request := account.Annotations[v1alpha1.CloudProviderAccountResyncAnnotation]
+		resync := len(request) > 0 && request != account.Status.ResyncRequest && request != r.accountResyncRequests[name]
+		due := false
+		if interval := account.Spec.CloudSyncIntervalInSeconds; interval != nil {
+			lastSyncTime := r.accountCloudSyncTimes[name]
+			if r.lastCloudSyncTime.After(lastSyncTime) {
+				lastSyncTime = r.lastCloudSyncTime
+			}
+			due = time.Since(lastSyncTime) >= time.Duration(*interval)*time.Second
+		}
+		if !resync && !due {
+			continue
+		}
+		r.Log.Info("Sync account with cloud", "account", name, "request", request, "onDemand", resync)
+		r.syncAccountWithCloud(&name)
+		if resync {
+			r.updateResyncStatus(account, request)
+		}
+	}
+	for name := range r.accountCloudSyncTimes {
+		if _, ok := accounts[name]; !ok {
+			delete(r.accountCloudSyncTimes, name)
+			delete(r.accountResyncRequests, name)
+		}
+	}
+}
+
+// updateResyncStatus reports completion of synchronization with cloud on demand of request in status of account.
+func (r *NetworkPolicyReconciler) updateResyncStatus(account *v1alpha1.CloudProviderAccount, request string) {
+	now := metav1.Now()
+	account.Status.ResyncRequest = request
+	account.Status.LastResyncTime = &now
+	if err := r.Status().Update(context.TODO(), account); err != nil {
+		// Resync is to be performed again, to report its completion.
+		r.Log.Info("Failed to update account status", "account", client.ObjectKeyFromObject(account), "error", err)
+		return
+	}
+	r.accountResyncRequests[client.ObjectKeyFromObject(account)] = request
+}
+
+// syncWithCloudContents synchronizes security groups in controller with the cloud view of security groups received
+// from ch. Only security groups of account are synchronized if account is not nil.
+func (r *NetworkPolicyReconciler) syncWithCloudContents(ch <-chan securitygroup.SynchronizationContent, fromSnapshot bool,
+	account *types.NamespacedName) {
+	log := r.Log.WithName("CloudSync")
+
+	// isAccountSG returns true if SecurityGroup id is to be synchronized.
+	isAccountSG := func(id *securitygroup.CloudResourceID) bool {
+		if account == nil {
+			return true
+		}
+		sgAccount := securitygroup.CloudSecurityGroup.GetSecurityGroupAccount(id)
+		return sgAccount != nil && *sgAccount == *account
+	}
+	var contents []securitygroup.SynchronizationContent
 	cloudAddrSGs := make(map[securitygroup.CloudResourceID]*securitygroup.SynchronizationContent)
 	cloudAppliedToSGs := make(map[securitygroup.CloudResourceID]*securitygroup.SynchronizationContent)
 	rscWithUnknownSGs := make(map[securitygroup.CloudResource]struct{})
 	for content := range ch {
-		log.V(1).Info("Sync from cloud", "SecurityGroup", content)
+		log.V(1).Info("Sync from cloud", "SecurityGroup", content, "Snapshot", fromSnapshot, "Account", account)
+		contents = append(contents, content)
 		indexer := r.addrSGIndexer
 		sgNew := newAddrSecurityGroup
 		if !content.MembershipOnly {
@@ -234,18 +336,59 @@ func (r *NetworkPolicyReconciler) syncWithCloud() {
 			}
 		}
 	}
-	r.syncedWithCloud = true
+	if account == nil {
+		r.syncedWithCloud = true
+		r.syncedWithSnapshot = fromSnapshot
+		if r.dryRun != nil {
+			r.dryRun.setEnforcedSecurity(contents)
+		}
+		if !fromSnapshot {
+			r.saveCloudSecuritySnapshot(contents)
+		}
+	} else if r.dryRun != nil {
+		r.dryRun.setAccountEnforcedSecurity(contents, isAccountSG)
+	}
+	// isSynced returns true if sg is to be synchronized with cloud content c. Drift is not reported from snapshot, but
+	// SecurityGroups of accounts not remediating drift are not synchronized with it either.
+	drifts := make(map[string]*CloudSecurityDrift)
+	isSynced := func(sg cloudSecurityGroup, c *securitygroup.SynchronizationContent, membershipOnly bool) bool {
+		if !fromSnapshot {
+			return r.checkCloudSecurityDrift(sg, c, membershipOnly, drifts)
+		}
+		id := sg.getID()
+		return len(getSecurityGroupDiscrepancies(sg, c, r)) == 0 || r.isCloudSecurityDriftRemediated(&id)
+	}
+	if account != nil {
+		// Drifts of other accounts are kept.
+		for _, i := range r.cloudSecurityDriftIndexer.List() {
+			if drift := i.(*CloudSecurityDrift); !isAccountSG(&drift.ID) {
+				drifts[drift.String()] = drift
+			}
+		}
+	}
 	for _, i := range r.addrSGIndexer.List() {
 		sg := i.(*addrSecurityGroup)
-		if sg.isIPBlocks() {
+		if sg.isIPBlocks() || !isAccountSG(&sg.id) {
+			continue
+		}
+		if !isSynced(sg, cloudAddrSGs[sg.getID()], true) {
 			continue
 		}
 		sg.sync(cloudAddrSGs[sg.getID()], r)
 	}
 	for _, i := range r.appliedToSGIndexer.List() {
 		sg := i.(*appliedToSecurityGroup)
+		if !isAccountSG(&sg.id) {
+			continue
+		}
+		if !isSynced(sg, cloudAppliedToSGs[sg.getID()], false) {
+			continue
+		}
 		sg.sync(cloudAppliedToSGs[sg.getID()], r)
 	}
+	if !fromSnapshot {
+		r.setCloudSecurityDrifts(drifts)
+	}
 	// For cloud resource with any non-antrea+ SG, tricking plug-in to remove them by explicitly
 	// updating a single instance of associated security group.
 	for rsc := range rscWithUnknownSGs {
@@ -256,6 +399,10 @@ func (r *NetworkPolicyReconciler) syncWithCloud() {
 		}
 		tracker := i.(*cloudResourceNPTracker)
 		for _, sg := range tracker.appliedToSGs {
+			if !r.isCloudSecurityDriftRemediated(&sg.id) {
+				log.Info("Skip removing security groups of resource not managed by controller", "CloudResource", rsc)
+				break
+			}
 			_ = sg.update(nil, nil, r)
 			break
 		}
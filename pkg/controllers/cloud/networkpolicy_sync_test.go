@@ -0,0 +1,113 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"time"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+var _ = Describe("NetworkPolicy account sync", func() {
+	var (
+		reconciler *NetworkPolicyReconciler
+		accounts   []cloudv1alpha1.CloudProviderAccount
+		account01  = types.NamespacedName{Namespace: "default", Name: "account01"}
+		account02  = types.NamespacedName{Namespace: "default", Name: "account02"}
+		vm1        = newTestVM("vm-1", "vpc-1")
+		vm2        = newTestVM("vm-2", "vpc-2")
+		addrID1    = securitygroup.CloudResourceID{Name: "addr-grp", Vpc: "vpc-1"}
+		addrID2    = securitygroup.CloudResourceID{Name: "addr-grp", Vpc: "vpc-2"}
+	)
+
+	BeforeEach(func() {
+		reconciler = newTestNetworkPolicyReconciler(false, nil)
+		reconciler.lastCloudSyncTime = time.Now()
+
+		// SecurityGroups of both accounts are realized, and only SecurityGroups of account01 are in cloud.
+		state := securityGroupStateCreated
+		Expect(reconciler.addrSGIndexer.Add(newAddrSecurityGroup(&addrID1, []*securitygroup.CloudResource{vm1},
+			&state))).Should(Succeed())
+		Expect(reconciler.addrSGIndexer.Add(newAddrSecurityGroup(&addrID2, []*securitygroup.CloudResource{vm2},
+			&state))).Should(Succeed())
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupAccount(&addrID1).Return(&account01).AnyTimes()
+		mockCloudSecurityAPI.EXPECT().GetSecurityGroupAccount(&addrID2).Return(&account02).AnyTimes()
+		accounts = []cloudv1alpha1.CloudProviderAccount{{}, {}}
+		accounts[0].Namespace, accounts[0].Name = account01.Namespace, account01.Name
+		accounts[1].Namespace, accounts[1].Name = account02.Namespace, account02.Name
+		mockClient.EXPECT().List(mock.Any(), mock.Any()).AnyTimes().
+			Do(func(_ context.Context, accountList *cloudv1alpha1.CloudProviderAccountList) {
+				accountList.Items = nil
+				for i := range accounts {
+					accountList.Items = append(accountList.Items, *accounts[i].DeepCopy())
+				}
+			}).Return(nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	// expectAccountSync expects security groups of account01 to be retrieved from cloud once.
+	expectAccountSync := func() {
+		ch := make(chan securitygroup.SynchronizationContent, 1)
+		ch <- securitygroup.SynchronizationContent{Resource: addrID1, MembershipOnly: true,
+			Members: []securitygroup.CloudResource{*vm1}}
+		close(ch)
+		mockCloudSecurityAPI.EXPECT().GetAccountSecurityGroupSyncChan(&account01).Return(ch)
+	}
+
+	It("Should synchronize account with cloud on demand", func() {
+		accounts[0].Annotations = map[string]string{cloudv1alpha1.CloudProviderAccountResyncAnnotation: "1"}
+		expectAccountSync()
+		mockClient.EXPECT().Status().Return(mockStatusWriter)
+		mockStatusWriter.EXPECT().Update(mock.Any(), mock.Any()).Return(nil).
+			Do(func(_ context.Context, account *cloudv1alpha1.CloudProviderAccount) {
+				Expect(account.Name).To(Equal(account01.Name))
+				Expect(account.Status.ResyncRequest).To(Equal("1"))
+				Expect(account.Status.LastResyncTime).ToNot(BeNil())
+			})
+		reconciler.syncAccountsWithCloud()
+
+		// Resync request already handled is not handled again.
+		reconciler.syncAccountsWithCloud()
+		accounts[0].Status.ResyncRequest = "1"
+		reconciler.syncAccountsWithCloud()
+	})
+
+	It("Should synchronize account with cloud on its own interval", func() {
+		interval := uint(60)
+		accounts[0].Spec.CloudSyncIntervalInSeconds = &interval
+		reconciler.syncAccountsWithCloud()
+
+		reconciler.lastCloudSyncTime = time.Now().Add(-2 * time.Minute)
+		expectAccountSync()
+		reconciler.syncAccountsWithCloud()
+		reconciler.syncAccountsWithCloud()
+	})
+
+	It("Should not synchronize account prior to synchronization with cloud", func() {
+		accounts[0].Annotations = map[string]string{cloudv1alpha1.CloudProviderAccountResyncAnnotation: "1"}
+		reconciler.syncedWithCloud = false
+		reconciler.syncAccountsWithCloud()
+	})
+})
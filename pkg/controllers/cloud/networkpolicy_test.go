@@ -36,6 +36,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	antreanetworking "antrea.io/antrea/pkg/apis/controlplane/v1beta2"
+	antreacrd "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	antreatypes "antrea.io/antrea/pkg/apis/crd/v1alpha2"
 	cloud "antrea.io/nephe/apis/crd/v1alpha1"
 	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
@@ -762,7 +763,7 @@ var _ = Describe("NetworkPolicy", func() {
 			if hasPolicy && !hasError {
 				Expect(found).To(BeTrue())
 				npStatus := obj.(*NetworkPolicyStatus)
-				status, ok := npStatus.NPStatus[anp.Name]
+				status, ok := npStatus.NPStatus[fmt.Sprintf("%s:%s", anp.SourceRef.Type, anp.Name)]
 				Expect(ok).To(BeTrue())
 				Expect(status).To(Equal(NetworkPolicyStatusApplied))
 			} else if !hasPolicy && hasError {
@@ -811,6 +812,171 @@ var _ = Describe("NetworkPolicy", func() {
 		createAndVerifyNP(false)
 	})
 
+	It("Create networkPolicy with IPv4 and IPv6 IPBlocks", func() {
+		_, ingressIPv6Block, _ := net.ParseCIDR("2001:db8:5::/64")
+		_, egressIPv6Block, _ := net.ParseCIDR("2001:db8:6::/64")
+		for i, ipBlock := range []*net.IPNet{ingressIPv6Block, egressIPv6Block} {
+			ipv6Block := antreanetworking.IPBlock{}
+			ipv6Block.CIDR.IP = antreanetworking.IPAddress(ipBlock.IP)
+			ipv6Block.CIDR.PrefixLength = 64
+			if anp.Rules[i].Direction == antreanetworking.DirectionIn {
+				anp.Rules[i].From.IPBlocks = append(anp.Rules[i].From.IPBlocks, ipv6Block)
+			} else {
+				anp.Rules[i].To.IPBlocks = append(anp.Rules[i].To.IPBlocks, ipv6Block)
+			}
+		}
+		ingressRule.FromSrcIP = append(ingressRule.FromSrcIP, ingressIPv6Block)
+		egressRule.ToDstIP = append(egressRule.ToDstIP, egressIPv6Block)
+		createAndVerifyNP(false)
+	})
+
+	It("Create networkPolicy with Drop action", func() {
+		action := antreacrd.RuleActionDrop
+		for i := range anp.Rules {
+			anp.Rules[i].Action = &action
+		}
+		mockCloudSecurityAPI.EXPECT().IsRuleActionSupported(mock.Any(), securitygroup.RuleActionDrop).
+			Return(true).AnyTimes()
+		ingressRule.Action = securitygroup.RuleActionDrop
+		egressRule.Action = securitygroup.RuleActionDrop
+		createAndVerifyNP(false)
+	})
+
+	It("Create networkPolicy with port range and ICMP", func() {
+		endPort := int32(32767)
+		anp.Rules[0].Services[0].Port = &intstr.IntOrString{IntVal: 30000}
+		anp.Rules[0].Services[0].EndPort = &endPort
+		icmpProtocol := antreanetworking.ProtocolICMP
+		icmpType := int32(8)
+		anp.Rules[1].Services[0] = antreanetworking.Service{Protocol: &icmpProtocol, ICMPType: &icmpType}
+		startPort, endPortInt, icmp, icmpTypeInt := 30000, 32767, 1, 8
+		ingressRule.FromPort = &startPort
+		ingressRule.FromEndPort = &endPortInt
+		egressRule.ToPort = nil
+		egressRule.Protocol = &icmp
+		egressRule.ICMPType = &icmpTypeInt
+		createAndVerifyNP(false)
+	})
+
+	It("Create networkPolicy with named port", func() {
+		namedPort := []antreanetworking.NamedPort{{Name: "https", Port: 443, Protocol: antreanetworking.ProtocolTCP}}
+		for _, grp := range appliedToGrps {
+			grp.GroupMembers[0].Ports = namedPort
+		}
+		addrGrps[1].GroupMembers[0].Ports = namedPort
+		for i := range anp.Rules {
+			anp.Rules[i].Services[0].Port = &intstr.IntOrString{Type: intstr.String, StrVal: "https"}
+		}
+		createAndVerifyNP(false)
+	})
+
+	It("Resolve named port with rule appliedToGroups and fail closed for deny rules", func() {
+		action := antreacrd.RuleActionDrop
+		np := &networkPolicy{}
+		anp.DeepCopyInto(&np.NetworkPolicy)
+		np.Rules = np.Rules[:1]
+		np.Rules[0].Action = &action
+		np.Rules[0].From.AddressGroups = nil
+		np.Rules[0].AppliedToGroups = []string{appliedToGrpsNames[0]}
+		np.Rules[0].Services[0].Port = &intstr.IntOrString{Type: intstr.String, StrVal: "https"}
+		reconciler.groupNamedPorts[appliedToGrpsNames[0]] = map[string][]antreanetworking.NamedPort{
+			"vm-0": {{Name: "https", Port: 8443, Protocol: antreanetworking.ProtocolTCP}}}
+		reconciler.groupNamedPorts[appliedToGrpsNames[1]] = map[string][]antreanetworking.NamedPort{
+			"vm-1": {{Name: "https", Port: 443, Protocol: antreanetworking.ProtocolTCP}}}
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(HaveLen(1))
+		Expect(*np.ingressRules[0].FromPort).To(Equal(8443))
+
+		By("Unresolved named port denies the whole protocol")
+		delete(reconciler.groupNamedPorts, appliedToGrpsNames[0])
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(HaveLen(1))
+		Expect(np.ingressRules[0].FromPort).To(BeNil())
+		Expect(*np.ingressRules[0].Protocol).To(Equal(6))
+
+		By("Unresolved named port is ignored by allow rules")
+		action = antreacrd.RuleActionAllow
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(BeEmpty())
+	})
+
+	It("Report named port resolved to different ports by members", func() {
+		action := antreacrd.RuleActionDrop
+		np := &networkPolicy{}
+		anp.DeepCopyInto(&np.NetworkPolicy)
+		np.Rules = np.Rules[:1]
+		np.Rules[0].Action = &action
+		np.Rules[0].From.AddressGroups = nil
+		np.Rules[0].AppliedToGroups = []string{appliedToGrpsNames[0]}
+		np.Rules[0].Services[0].Port = &intstr.IntOrString{Type: intstr.String, StrVal: "https"}
+		reconciler.groupNamedPorts[appliedToGrpsNames[0]] = map[string][]antreanetworking.NamedPort{
+			"vm-0": {{Name: "https", Port: 8443, Protocol: antreanetworking.ProtocolTCP}},
+			"vm-1": {{Name: "https", Port: 443, Protocol: antreanetworking.ProtocolTCP}}}
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(HaveLen(2))
+		Expect(*np.ingressRules[0].FromPort).To(Equal(443))
+		Expect(*np.ingressRules[1].FromPort).To(Equal(8443))
+		err := np.getStatus(reconciler)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("https"))
+
+		By("Conflicting named port is ignored by allow rules")
+		action = antreacrd.RuleActionAllow
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(BeEmpty())
+		Expect(np.getStatus(reconciler)).To(HaveOccurred())
+
+		By("Conflict is cleared once members agree")
+		reconciler.groupNamedPorts[appliedToGrpsNames[0]]["vm-1"][0].Port = 8443
+		np.computeRules(reconciler)
+		Expect(np.ingressRules).To(HaveLen(1))
+		Expect(np.namedPortConflicts).To(BeEmpty())
+	})
+
+	It("Create networkPolicy with tier and priority", func() {
+		tierPriority := int32(250)
+		policyPriority := float64(5)
+		anp.TierPriority = &tierPriority
+		anp.Priority = &policyPriority
+		for i := range anp.Rules {
+			anp.Rules[i].Priority = int32(i)
+		}
+		ingressRule.Priority = &securitygroup.RulePriority{TierPriority: tierPriority, PolicyPriority: policyPriority, RuleIndex: 0}
+		egressRule.Priority = &securitygroup.RulePriority{TierPriority: tierPriority, PolicyPriority: policyPriority, RuleIndex: 1}
+		createAndVerifyNP(false)
+	})
+
+	It("Modify networkPolicy priority", func() {
+		createAndVerifyNP(false)
+
+		tierPriority := int32(250)
+		policyPriority := float64(5)
+		anp.TierPriority = &tierPriority
+		anp.Priority = &policyPriority
+		ingressRule.Priority = &securitygroup.RulePriority{TierPriority: tierPriority, PolicyPriority: policyPriority}
+		egressRule.Priority = &securitygroup.RulePriority{TierPriority: tierPriority, PolicyPriority: policyPriority}
+		checkNPPatchChange(appliedToGrps)
+		event := watch.Event{Type: watch.Modified, Object: anp}
+		err := reconciler.processNetworkPolicy(event)
+		Expect(err).ToNot(HaveOccurred())
+
+		wait()
+	})
+
+	It("Reject networkPolicy with action not supported by cloud", func() {
+		action := antreacrd.RuleActionReject
+		np := &networkPolicy{}
+		anp.DeepCopyInto(&np.NetworkPolicy)
+		np.Rules[0].Action = &action
+		id := &securitygroup.CloudResourceID{Name: appliedToGrpsNames[0], Vpc: vpc}
+		mockCloudSecurityAPI.EXPECT().IsRuleActionSupported(id, securitygroup.RuleActionReject).Return(false)
+		Expect(np.getRuleActionStatus(id)).To(HaveOccurred())
+
+		passAction := antreacrd.RuleActionPass
+		np.Rules[0].Action = &passAction
+		Expect(reconciler.isNetworkPolicySupported(&np.NetworkPolicy)).To(HaveOccurred())
+	})
+
 	It("Delete networkPolicy in order", func() {
 		createAndVerifyNP(true)
 		deleteAndVerifyNP(false)
@@ -918,6 +1084,32 @@ var _ = Describe("NetworkPolicy", func() {
 		verifyNPStatus(trackedVMs, false, false)
 	})
 
+	It("Tracking K8s networkPolicy and Antrea clusterNetworkPolicy", func() {
+		for _, t := range []antreanetworking.NetworkPolicyType{antreanetworking.K8sNetworkPolicy,
+			antreanetworking.AntreaClusterNetworkPolicy} {
+			trackedVMs := make(map[string]*cloud.VirtualMachine)
+			npName := anp.Name
+			anp.Name = "uid-" + npName
+			anp.Namespace = ""
+			anp.SourceRef = &antreanetworking.NetworkPolicyReference{Type: t, Name: npName}
+			if t == antreanetworking.K8sNetworkPolicy {
+				anp.SourceRef.Namespace = namespace
+				ingressRule.Priority = &securitygroup.RulePriority{TierPriority: k8sNetworkPolicyTierPriority}
+				egressRule.Priority = ingressRule.Priority
+			}
+			createAndVerifyNP(false)
+			Expect(anp.Name).To(Equal(npName))
+			Expect(anp.Namespace).To(Equal(anp.SourceRef.Namespace))
+			verifyNPTracker(trackedVMs, true, false)
+			verifyNPStatus(trackedVMs, true, false)
+			deleteAndVerifyNP(false)
+			verifyNPTracker(trackedVMs, false, false)
+			verifyNPStatus(trackedVMs, false, false)
+			ingressRule.Priority = nil
+			egressRule.Priority = nil
+		}
+	})
+
 	It("Create NetworkPolicy groups after security group garbage collection", func() {
 		createAndVerifyNP(false)
 		sgConfig.sgDeletePending = true
@@ -0,0 +1,407 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	cloudv1alpha1 "antrea.io/nephe/apis/crd/v1alpha1"
+	cloudcommon "antrea.io/nephe/pkg/cloud-provider/cloudapi/common"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update;delete
+
+const (
+	// snapshotFormatVersion is the version of the snapshot format, snapshots of other versions are ignored.
+	snapshotFormatVersion = "2"
+
+	snapshotVersionKey            = "version"
+	snapshotTimestampKey          = "timestamp"
+	snapshotShardsKey             = "shards"
+	snapshotChecksumKey           = "checksum"
+	snapshotSelectorGenerationKey = "selectorGeneration"
+	snapshotVirtualMachinesKey    = "virtualMachines"
+	snapshotSecurityGroupsKey     = "securityGroups"
+
+	// inventorySnapshotPrefix prefixes the name of the ConfigMap checkpointing inventory of a CloudEntitySelector,
+	// in the selector namespace.
+	inventorySnapshotPrefix = "nephe-inventory-"
+	// securitySnapshotName is the name of the ConfigMap checkpointing the cloud view of enforced security.
+	securitySnapshotName = "nephe-cloud-security"
+)
+
+// snapshotShardMaxBytes is the maximum size of snapshot items stored in one ConfigMap, well below the 1 MiB limit of
+// a ConfigMap. Items beyond it are stored in additional ConfigMaps.
+var snapshotShardMaxBytes = 512 * 1024
+
+var snapshotSaveFailureCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "nephe_snapshot_save_failures_total",
+	Help: "Number of failures to checkpoint an inventory or cloud security snapshot.",
+}, []string{"snapshot"})
+
+func init() {
+	metrics.Registry.MustRegister(snapshotSaveFailureCount)
+}
+
+// snapshotVirtualMachine is a VirtualMachine as checkpointed in an inventory snapshot. It keeps only the fields a
+// restored VirtualMachine is compared and created with, i.e. its name, cloud annotations and cloud discovered status.
+type snapshotVirtualMachine struct {
+	Name        string                             `json:"name"`
+	Annotations map[string]string                  `json:"annotations,omitempty"`
+	Status      cloudv1alpha1.VirtualMachineStatus `json:"status"`
+}
+
+// getSnapshotShardName returns the name of the ConfigMap of shard index of snapshot name, shard 0 being the snapshot
+// ConfigMap itself.
+func getSnapshotShardName(name types.NamespacedName, index int) types.NamespacedName {
+	if index == 0 {
+		return name
+	}
+	return types.NamespacedName{Namespace: name.Namespace, Name: fmt.Sprintf("%s-%d", name.Name, index)}
+}
+
+// getSnapshotChecksum returns the checksum of snapshot shards, which detects shards of different checkpoints.
+func getSnapshotChecksum(shards []string) string {
+	hash := fnv.New64a()
+	for _, shard := range shards {
+		_, _ = hash.Write([]byte(shard))
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// shardSnapshotItems groups JSON encoded items into JSON arrays of at most snapshotShardMaxBytes each, except that an
+// item larger than that is in an array of its own. At least one, possibly empty, array is returned.
+func shardSnapshotItems(items [][]byte) []string {
+	var shards []string
+	var shard strings.Builder
+	for _, item := range items {
+		if shard.Len() > 0 && shard.Len()+len(item)+2 > snapshotShardMaxBytes {
+			shard.WriteByte(']')
+			shards = append(shards, shard.String())
+			shard.Reset()
+		}
+		if shard.Len() == 0 {
+			shard.WriteByte('[')
+		} else {
+			shard.WriteByte(',')
+		}
+		shard.Write(item)
+	}
+	if shard.Len() == 0 {
+		shard.WriteByte('[')
+	}
+	shard.WriteByte(']')
+	return append(shards, shard.String())
+}
+
+// getSnapshot returns data of a snapshot ConfigMap, and JSON encoded items stored under itemsKey in all its shards. nil
+// data is returned if the snapshot does not exist, is of another format version, or if any shard is missing or from
+// another checkpoint.
+func getSnapshot(c client.Client, name types.NamespacedName, itemsKey string) (map[string]string, []json.RawMessage,
+	error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), name, configMap); err != nil {
+		return nil, nil, client.IgnoreNotFound(err)
+	}
+	data := configMap.Data
+	if data[snapshotVersionKey] != snapshotFormatVersion {
+		return nil, nil, nil
+	}
+	shardCount, err := strconv.Atoi(data[snapshotShardsKey])
+	if err != nil || shardCount < 1 {
+		return nil, nil, nil
+	}
+
+	shards := []string{data[itemsKey]}
+	for i := 1; i < shardCount; i++ {
+		shard := &corev1.ConfigMap{}
+		if err = c.Get(context.TODO(), getSnapshotShardName(name, i), shard); err != nil {
+			return nil, nil, client.IgnoreNotFound(err)
+		}
+		shards = append(shards, shard.Data[itemsKey])
+	}
+	if getSnapshotChecksum(shards) != data[snapshotChecksumKey] {
+		return nil, nil, nil
+	}
+
+	var items []json.RawMessage
+	for _, shard := range shards {
+		var shardItems []json.RawMessage
+		if err = json.Unmarshal([]byte(shard), &shardItems); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, shardItems...)
+	}
+	return data, items, nil
+}
+
+// putSnapshot checkpoints JSON encoded items under itemsKey, sharded across the snapshot ConfigMap and additional
+// ConfigMaps as needed, with data stamped with the format version and current time. Additional shards are written
+// first, so that a partially written snapshot fails the checksum of the snapshot ConfigMap. Created ConfigMaps are
+// owned by owner if not nil, so that they are garbage collected with the owner.
+func putSnapshot(c client.Client, scheme *runtime.Scheme, name types.NamespacedName, data map[string]string,
+	itemsKey string, shards []string, owner metav1.Object) error {
+	checksum := getSnapshotChecksum(shards)
+	for i := 1; i < len(shards); i++ {
+		shardData := map[string]string{
+			snapshotVersionKey:  snapshotFormatVersion,
+			snapshotChecksumKey: checksum,
+			itemsKey:            shards[i],
+		}
+		if _, err := putConfigMap(c, scheme, getSnapshotShardName(name, i), shardData, owner); err != nil {
+			return err
+		}
+	}
+
+	data[snapshotVersionKey] = snapshotFormatVersion
+	data[snapshotTimestampKey] = time.Now().UTC().Format(time.RFC3339)
+	data[snapshotShardsKey] = strconv.Itoa(len(shards))
+	data[snapshotChecksumKey] = checksum
+	data[itemsKey] = shards[0]
+	previous, err := putConfigMap(c, scheme, name, data, owner)
+	if err != nil {
+		return err
+	}
+
+	// remove shards of the previous checkpoint no longer used.
+	previousShardCount, _ := strconv.Atoi(previous[snapshotShardsKey])
+	for i := len(shards); i < previousShardCount; i++ {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace,
+			Name: getSnapshotShardName(name, i).Name}}
+		if err = c.Delete(context.TODO(), configMap); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putConfigMap creates or updates ConfigMap name with data, and returns its previous data if any. A created ConfigMap
+// is owned by owner if not nil.
+func putConfigMap(c client.Client, scheme *runtime.Scheme, name types.NamespacedName, data map[string]string,
+	owner metav1.Object) (map[string]string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(context.TODO(), name, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		previous := configMap.Data
+		configMap.Data = data
+		return previous, c.Update(context.TODO(), configMap)
+	}
+
+	configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+		Data:       data,
+	}
+	if owner != nil {
+		if err = controllerutil.SetControllerReference(owner, configMap, scheme); err != nil {
+			return nil, err
+		}
+	}
+	return nil, c.Create(context.TODO(), configMap)
+}
+
+// getInventorySnapshotName returns the name of the inventory snapshot ConfigMap of the poller selector.
+func (p *accountPoller) getInventorySnapshotName() types.NamespacedName {
+	return types.NamespacedName{Namespace: p.selector.Namespace, Name: inventorySnapshotPrefix + p.selector.Name}
+}
+
+// loadInventorySnapshot returns virtual machines of the poller selector checkpointed by saveInventorySnapshot. The
+// snapshot is not found if it does not exist, or if the selector changed since the snapshot was saved.
+func (p *accountPoller) loadInventorySnapshot() ([]*cloudv1alpha1.VirtualMachine, bool) {
+	name := p.getInventorySnapshotName()
+	data, items, err := getSnapshot(p.Client, name, snapshotVirtualMachinesKey)
+	if err != nil {
+		p.log.Info("failed to get inventory snapshot", "snapshot", name, "error", err)
+		return nil, false
+	}
+	if data == nil || data[snapshotSelectorGenerationKey] != strconv.FormatInt(p.selector.Generation, 10) {
+		return nil, false
+	}
+
+	virtualMachines := make([]*cloudv1alpha1.VirtualMachine, 0, len(items))
+	for _, item := range items {
+		snapshotVM := snapshotVirtualMachine{}
+		if err = json.Unmarshal(item, &snapshotVM); err != nil {
+			p.log.Info("failed to parse inventory snapshot", "snapshot", name, "error", err)
+			return nil, false
+		}
+		virtualMachines = append(virtualMachines, &cloudv1alpha1.VirtualMachine{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       cloudcommon.VirtualMachineCRDKind,
+				APIVersion: cloudcommon.APIVersion,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				UID:         uuid.NewUUID(),
+				Name:        snapshotVM.Name,
+				Namespace:   p.selector.Namespace,
+				Annotations: snapshotVM.Annotations,
+			},
+			Status: snapshotVM.Status,
+		})
+	}
+	p.snapshotChecksum = data[snapshotChecksumKey]
+	p.log.Info("restored inventory snapshot", "account", p.namespacedName, "snapshot", name,
+		"virtual-machines", len(virtualMachines), "timestamp", data[snapshotTimestampKey])
+	return virtualMachines, true
+}
+
+// saveInventorySnapshot checkpoints virtual machines of the poller selector, if changed since the last checkpoint.
+func (p *accountPoller) saveInventorySnapshot(virtualMachines []*cloudv1alpha1.VirtualMachine) {
+	sorted := make([]*cloudv1alpha1.VirtualMachine, len(virtualMachines))
+	copy(sorted, virtualMachines)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	name := p.getInventorySnapshotName()
+	items := make([][]byte, 0, len(sorted))
+	for _, vm := range sorted {
+		snapshotVM := snapshotVirtualMachine{Name: vm.Name, Annotations: vm.Annotations, Status: vm.Status}
+		// StateTransitionTime is set by controller, not discovered from cloud.
+		snapshotVM.Status.StateTransitionTime = nil
+		item, err := json.Marshal(snapshotVM)
+		if err != nil {
+			p.log.Info("failed to encode inventory snapshot", "account", p.namespacedName, "error", err)
+			snapshotSaveFailureCount.WithLabelValues(name.String()).Inc()
+			return
+		}
+		items = append(items, item)
+	}
+	shards := shardSnapshotItems(items)
+	checksum := getSnapshotChecksum(shards)
+	if checksum == p.snapshotChecksum {
+		return
+	}
+
+	data := map[string]string{
+		snapshotSelectorGenerationKey: strconv.FormatInt(p.selector.Generation, 10),
+	}
+	if err := putSnapshot(p.Client, p.scheme, name, data, snapshotVirtualMachinesKey, shards, p.selector); err != nil {
+		p.log.Info("failed to save inventory snapshot", "account", p.namespacedName, "snapshot", name, "error", err)
+		snapshotSaveFailureCount.WithLabelValues(name.String()).Inc()
+		return
+	}
+	p.snapshotChecksum = checksum
+}
+
+// getSecuritySnapshotName returns the name of the cloud security snapshot ConfigMap.
+func (r *NetworkPolicyReconciler) getSecuritySnapshotName() types.NamespacedName {
+	return types.NamespacedName{Namespace: r.SnapshotNamespace, Name: securitySnapshotName}
+}
+
+// loadCloudSecuritySnapshot returns the cloud view of enforced security checkpointed by saveCloudSecuritySnapshot.
+func (r *NetworkPolicyReconciler) loadCloudSecuritySnapshot() ([]securitygroup.SynchronizationContent, bool) {
+	if r.SnapshotNamespace == "" {
+		return nil, false
+	}
+	name := r.getSecuritySnapshotName()
+	data, items, err := getSnapshot(r.Client, name, snapshotSecurityGroupsKey)
+	if err != nil {
+		r.Log.Info("Failed to get cloud security snapshot", "snapshot", name, "error", err)
+		return nil, false
+	}
+	if data == nil {
+		return nil, false
+	}
+
+	contents := make([]securitygroup.SynchronizationContent, 0, len(items))
+	for _, item := range items {
+		content := securitygroup.SynchronizationContent{}
+		if err = json.Unmarshal(item, &content); err != nil {
+			r.Log.Info("Failed to parse cloud security snapshot", "snapshot", name, "error", err)
+			return nil, false
+		}
+		contents = append(contents, content)
+	}
+	r.securitySnapshotChecksum = data[snapshotChecksumKey]
+	r.Log.Info("Restored cloud security snapshot", "snapshot", name, "securityGroups", len(contents),
+		"timestamp", data[snapshotTimestampKey])
+	return contents, true
+}
+
+// saveCloudSecuritySnapshot checkpoints the cloud view of enforced security, if changed since the last checkpoint.
+func (r *NetworkPolicyReconciler) saveCloudSecuritySnapshot(contents []securitygroup.SynchronizationContent) {
+	if r.SnapshotNamespace == "" {
+		return
+	}
+	sorted := make([]securitygroup.SynchronizationContent, len(contents))
+	copy(sorted, contents)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].MembershipOnly != sorted[j].MembershipOnly {
+			return sorted[i].MembershipOnly
+		}
+		return sorted[i].Resource.String() < sorted[j].Resource.String()
+	})
+	name := r.getSecuritySnapshotName()
+	items := make([][]byte, 0, len(sorted))
+	for i := range sorted {
+		item, err := json.Marshal(&sorted[i])
+		if err != nil {
+			r.Log.Info("Failed to encode cloud security snapshot", "error", err)
+			snapshotSaveFailureCount.WithLabelValues(name.String()).Inc()
+			return
+		}
+		items = append(items, item)
+	}
+	shards := shardSnapshotItems(items)
+	checksum := getSnapshotChecksum(shards)
+	if checksum == r.securitySnapshotChecksum {
+		return
+	}
+
+	if err := putSnapshot(r.Client, r.Scheme, name, map[string]string{}, snapshotSecurityGroupsKey, shards, nil); err != nil {
+		r.Log.Info("Failed to save cloud security snapshot", "snapshot", name, "error", err)
+		snapshotSaveFailureCount.WithLabelValues(name.String()).Inc()
+		return
+	}
+	r.securitySnapshotChecksum = checksum
+}
+
+// isCloudSecurityEnforcementReady returns true if inventory of every cloud account is available for security
+// enforcement, so that the cloud view of enforced security is complete.
+func (r *NetworkPolicyReconciler) isCloudSecurityEnforcementReady() bool {
+	accountList := &cloudv1alpha1.CloudProviderAccountList{}
+	if err := r.List(context.TODO(), accountList); err != nil {
+		r.Log.V(1).Info("Failed to list accounts", "error", err)
+		return false
+	}
+	for _, account := range accountList.Items {
+		if !meta.IsStatusConditionTrue(account.Status.Conditions, cloudv1alpha1.CloudProviderAccountConditionSecurityEnforcementReady) {
+			return false
+		}
+	}
+	return true
+}
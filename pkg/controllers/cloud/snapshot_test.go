@@ -0,0 +1,329 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"net"
+
+	mock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	cloudtest "antrea.io/nephe/pkg/testing/cloudsecurity"
+	"antrea.io/nephe/pkg/testing/controllerruntimeclient"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		// configMaps are ConfigMaps saved through mockClient, keyed by name.
+		configMaps map[types.NamespacedName]*corev1.ConfigMap
+		// writes is the number of ConfigMaps created or updated through mockClient.
+		writes int
+		// shardMaxBytes is snapshotShardMaxBytes before a test changes it.
+		shardMaxBytes = snapshotShardMaxBytes
+	)
+
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "")
+
+	// saved returns saved ConfigMap name.
+	saved := func(name types.NamespacedName) *corev1.ConfigMap {
+		return configMaps[name]
+	}
+
+	BeforeEach(func() {
+		mockCtrl = mock.NewController(GinkgoT())
+		mockClient = controllerruntimeclient.NewMockClient(mockCtrl)
+		configMaps = make(map[types.NamespacedName]*corev1.ConfigMap)
+		writes = 0
+		snapshotShardMaxBytes = shardMaxBytes
+
+		mockClient.EXPECT().Get(mock.Any(), mock.Any(), mock.Any()).
+			DoAndReturn(func(_ context.Context, key client.ObjectKey, out *corev1.ConfigMap) error {
+				configMap, found := configMaps[key]
+				if !found {
+					return notFound
+				}
+				configMap.DeepCopyInto(out)
+				return nil
+			}).AnyTimes()
+		save := func(_ context.Context, obj *corev1.ConfigMap, _ ...client.CreateOption) error {
+			configMaps[types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name}] = obj.DeepCopy()
+			writes++
+			return nil
+		}
+		mockClient.EXPECT().Create(mock.Any(), mock.Any()).DoAndReturn(save).AnyTimes()
+		mockClient.EXPECT().Update(mock.Any(), mock.Any()).
+			DoAndReturn(func(ctx context.Context, obj *corev1.ConfigMap, _ ...client.UpdateOption) error {
+				return save(ctx, obj)
+			}).AnyTimes()
+		mockClient.EXPECT().Delete(mock.Any(), mock.Any()).
+			DoAndReturn(func(_ context.Context, obj *corev1.ConfigMap, _ ...client.DeleteOption) error {
+				delete(configMaps, types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name})
+				return nil
+			}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		snapshotShardMaxBytes = shardMaxBytes
+		mockCtrl.Finish()
+	})
+
+	Context("Inventory snapshot", func() {
+		var (
+			poller *accountPoller
+			vms    []*cloud.VirtualMachine
+		)
+
+		newPoller := func(generation int64) *accountPoller {
+			selector := &cloud.CloudEntitySelector{
+				ObjectMeta: v1.ObjectMeta{Name: "selector", Namespace: testNamespace, UID: "selector-uid",
+					Generation: generation},
+			}
+			return &accountPoller{
+				Client:         mockClient,
+				log:            logf.Log,
+				scheme:         scheme,
+				namespacedName: &types.NamespacedName{Namespace: testNamespace, Name: "account"},
+				selector:       selector,
+			}
+		}
+
+		BeforeEach(func() {
+			poller = newPoller(1)
+			vms = nil
+			for _, name := range []string{"vm-2", "vm-1"} {
+				vms = append(vms, &cloud.VirtualMachine{
+					ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+					Status: cloud.VirtualMachineStatus{
+						Provider:            cloud.AWSCloudProvider,
+						State:               cloud.Running,
+						VirtualPrivateCloud: "vpc-1",
+						Tags:                map[string]string{"Name": name},
+					},
+				})
+			}
+		})
+
+		name := types.NamespacedName{Namespace: testNamespace, Name: inventorySnapshotPrefix + "selector"}
+
+		// expectRestored expects restored virtual machines to be vms in name order.
+		expectRestored := func(restored []*cloud.VirtualMachine) {
+			Expect(restored).To(HaveLen(len(vms)))
+			sorted := []*cloud.VirtualMachine{vms[1], vms[0]}
+			for i, vm := range restored {
+				Expect(vm.Name).To(Equal(sorted[i].Name))
+				Expect(vm.Namespace).To(Equal(testNamespace))
+				Expect(vm.Annotations).To(Equal(sorted[i].Annotations))
+				Expect(vm.Status).To(Equal(sorted[i].Status))
+			}
+		}
+
+		It("Should restore saved virtual machines", func() {
+			poller.saveInventorySnapshot(vms)
+			Expect(saved(name).Data[snapshotVersionKey]).To(Equal(snapshotFormatVersion))
+			Expect(saved(name).Data[snapshotSelectorGenerationKey]).To(Equal("1"))
+			Expect(saved(name).Data[snapshotShardsKey]).To(Equal("1"))
+			Expect(saved(name).OwnerReferences).To(HaveLen(1))
+			Expect(saved(name).OwnerReferences[0].Name).To(Equal("selector"))
+
+			// unchanged virtual machines are not saved again.
+			poller.saveInventorySnapshot([]*cloud.VirtualMachine{vms[1], vms[0]})
+			Expect(writes).To(Equal(1))
+
+			restarted := newPoller(1)
+			restored, found := restarted.loadInventorySnapshot()
+			Expect(found).To(BeTrue())
+			expectRestored(restored)
+			restarted.saveInventorySnapshot(vms)
+			Expect(writes).To(Equal(1))
+		})
+
+		It("Should save fields needed for restore only", func() {
+			now := v1.Now()
+			vms[0].UID = "vm-uid"
+			vms[0].Status.StateTransitionTime = &now
+			poller.saveInventorySnapshot(vms)
+			Expect(saved(name).Data[snapshotVirtualMachinesKey]).ToNot(ContainSubstring("vm-uid"))
+			Expect(saved(name).Data[snapshotVirtualMachinesKey]).ToNot(ContainSubstring("stateTransitionTime"))
+			Expect(saved(name).Data[snapshotVirtualMachinesKey]).To(ContainSubstring(`"vpc-1"`))
+		})
+
+		It("Should shard virtual machines across ConfigMaps", func() {
+			snapshotShardMaxBytes = 1
+			poller.saveInventorySnapshot(vms)
+			Expect(saved(name).Data[snapshotShardsKey]).To(Equal("2"))
+			Expect(saved(getSnapshotShardName(name, 1))).ToNot(BeNil())
+			Expect(saved(getSnapshotShardName(name, 1)).OwnerReferences).To(HaveLen(1))
+
+			restored, found := newPoller(1).loadInventorySnapshot()
+			Expect(found).To(BeTrue())
+			expectRestored(restored)
+
+			// shards no longer used are removed.
+			poller.saveInventorySnapshot(vms[:1])
+			Expect(saved(name).Data[snapshotShardsKey]).To(Equal("1"))
+			Expect(saved(getSnapshotShardName(name, 1))).To(BeNil())
+		})
+
+		It("Should ignore snapshot with a missing or stale shard", func() {
+			snapshotShardMaxBytes = 1
+			poller.saveInventorySnapshot(vms)
+			shard := saved(getSnapshotShardName(name, 1))
+
+			delete(configMaps, getSnapshotShardName(name, 1))
+			_, found := newPoller(1).loadInventorySnapshot()
+			Expect(found).To(BeFalse())
+
+			configMaps[getSnapshotShardName(name, 1)] = shard
+			shard.Data[snapshotVirtualMachinesKey] = "[]"
+			_, found = newPoller(1).loadInventorySnapshot()
+			Expect(found).To(BeFalse())
+		})
+
+		It("Should ignore snapshot of a changed selector", func() {
+			poller.saveInventorySnapshot(vms)
+
+			restarted := newPoller(2)
+			_, found := restarted.loadInventorySnapshot()
+			Expect(found).To(BeFalse())
+		})
+
+		It("Should ignore snapshot of another format version", func() {
+			poller.saveInventorySnapshot(vms)
+			saved(name).Data[snapshotVersionKey] = "0"
+
+			_, found := newPoller(1).loadInventorySnapshot()
+			Expect(found).To(BeFalse())
+		})
+
+		It("Should count snapshots failed to save", func() {
+			mockClient = controllerruntimeclient.NewMockClient(mockCtrl)
+			mockClient.EXPECT().Get(mock.Any(), name, mock.Any()).Return(errors.NewServiceUnavailable("unavailable"))
+			poller.Client = mockClient
+			failures := testutil.ToFloat64(snapshotSaveFailureCount.WithLabelValues(name.String()))
+			poller.saveInventorySnapshot(vms)
+			Expect(testutil.ToFloat64(snapshotSaveFailureCount.WithLabelValues(name.String()))).To(Equal(failures + 1))
+		})
+	})
+
+	Context("Cloud security snapshot", func() {
+		var (
+			reconciler *NetworkPolicyReconciler
+			contents   []securitygroup.SynchronizationContent
+		)
+
+		name := types.NamespacedName{Namespace: "nephe-system", Name: securitySnapshotName}
+
+		BeforeEach(func() {
+			mockCloudSecurityAPI = cloudtest.NewMockCloudSecurityGroupAPI(mockCtrl)
+			securitygroup.CloudSecurityGroup = mockCloudSecurityAPI
+			reconciler = &NetworkPolicyReconciler{
+				Log:               logf.Log,
+				Client:            mockClient,
+				Scheme:            scheme,
+				SnapshotNamespace: "nephe-system",
+			}
+			ipNet := &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(24, 32)}
+			port := 22
+			contents = []securitygroup.SynchronizationContent{
+				{
+					Resource: securitygroup.CloudResourceID{Name: "at", Vpc: "vpc-1"},
+					Members: []securitygroup.CloudResource{{Type: securitygroup.CloudResourceTypeVM,
+						Name: securitygroup.CloudResourceID{Name: "vm-1", Vpc: "vpc-1"}}},
+					IngressRules: []securitygroup.IngressRule{{FromPort: &port, FromSrcIP: []*net.IPNet{ipNet}}},
+				},
+				{
+					Resource:       securitygroup.CloudResourceID{Name: "ag", Vpc: "vpc-1"},
+					MembershipOnly: true,
+				},
+			}
+		})
+
+		It("Should restore saved cloud view before the first synchronization only", func() {
+			reconciler.saveCloudSecuritySnapshot(contents)
+			Expect(saved(name).OwnerReferences).To(BeEmpty())
+
+			ch, fromSnapshot := reconciler.getSecurityGroupSyncChan()
+			Expect(fromSnapshot).To(BeTrue())
+			var restored []securitygroup.SynchronizationContent
+			for content := range ch {
+				restored = append(restored, content)
+			}
+			Expect(restored).To(Equal([]securitygroup.SynchronizationContent{contents[1], contents[0]}))
+
+			reconciler.syncedWithCloud = true
+			cloudCh := make(chan securitygroup.SynchronizationContent)
+			mockCloudSecurityAPI.EXPECT().GetSecurityGroupSyncChan().Return(cloudCh)
+			_, fromSnapshot = reconciler.getSecurityGroupSyncChan()
+			Expect(fromSnapshot).To(BeFalse())
+		})
+
+		It("Should synchronize with cloud without snapshot", func() {
+			cloudCh := make(chan securitygroup.SynchronizationContent)
+			mockCloudSecurityAPI.EXPECT().GetSecurityGroupSyncChan().Return(cloudCh)
+			_, fromSnapshot := reconciler.getSecurityGroupSyncChan()
+			Expect(fromSnapshot).To(BeFalse())
+		})
+
+		It("Should update changed cloud view only", func() {
+			reconciler.saveCloudSecuritySnapshot(contents)
+			reconciler.saveCloudSecuritySnapshot([]securitygroup.SynchronizationContent{contents[1], contents[0]})
+			Expect(writes).To(Equal(1))
+
+			reconciler.saveCloudSecuritySnapshot(contents[:1])
+			Expect(writes).To(Equal(2))
+			Expect(saved(name).Data[snapshotSecurityGroupsKey]).To(ContainSubstring(`"vm-1"`))
+			Expect(saved(name).Data[snapshotSecurityGroupsKey]).ToNot(ContainSubstring(`"ag"`))
+		})
+
+		It("Should shard cloud view across ConfigMaps", func() {
+			snapshotShardMaxBytes = 1
+			reconciler.saveCloudSecuritySnapshot(contents)
+			Expect(saved(name).Data[snapshotShardsKey]).To(Equal("2"))
+			Expect(saved(getSnapshotShardName(name, 1)).OwnerReferences).To(BeEmpty())
+
+			restored, found := reconciler.loadCloudSecuritySnapshot()
+			Expect(found).To(BeTrue())
+			Expect(restored).To(Equal([]securitygroup.SynchronizationContent{contents[1], contents[0]}))
+		})
+
+		It("Should be ready for cloud synchronization once enforcement is ready for all accounts", func() {
+			conditions := []v1.Condition{{Type: cloud.CloudProviderAccountConditionSecurityEnforcementReady,
+				Status: v1.ConditionTrue}}
+			accounts := []cloud.CloudProviderAccount{
+				{Status: cloud.CloudProviderAccountStatus{Conditions: conditions}},
+				{},
+			}
+			mockClient.EXPECT().List(mock.Any(), mock.Any()).
+				Do(func(_ context.Context, accountList *cloud.CloudProviderAccountList) {
+					accountList.Items = accounts
+				}).Return(nil).Times(2)
+			Expect(reconciler.isCloudSecurityEnforcementReady()).To(BeFalse())
+			accounts[1].Status.Conditions = conditions
+			Expect(reconciler.isCloudSecurityEnforcementReady()).To(BeTrue())
+		})
+	})
+})
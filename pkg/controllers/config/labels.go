@@ -16,10 +16,20 @@ package config
 
 const (
 	// Well known labels on ExternalEntities so that they can be selected by Antrea NetworkPolicies.
-	ExternalEntityLabelKeyPostfix    = "nephe"
-	ExternalEntityLabelKeyNamespace  = "namespace." + ExternalEntityLabelKeyPostfix
-	ExternalEntityLabelKeyKind       = "kind." + ExternalEntityLabelKeyPostfix
-	ExternalEntityLabelKeyName       = "name." + ExternalEntityLabelKeyPostfix
-	ExternalEntityLabelKeyTagPostfix = ".tag." + ExternalEntityLabelKeyPostfix
-	ExternalEntityLabelCloudVPCKey   = "vpc." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelKeyPostfix     = "nephe"
+	ExternalEntityLabelKeyNamespace   = "namespace." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelKeyKind        = "kind." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelKeyName        = "name." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelKeyTagPostfix  = ".tag." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelCloudVPCKey    = "vpc." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelCloudRegionKey = "region." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelCloudZoneKey   = "zone." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelCloudSubnetKey = "subnet." + ExternalEntityLabelKeyPostfix
+	ExternalEntityLabelCloudOSKey     = "os." + ExternalEntityLabelKeyPostfix
+)
+
+const (
+	// VMTagKeyNamedPorts is the well known cloud tag on VMs that declares named ports,
+	// in the format of <name>:<port>[/<protocol>], separated by comma. e.g. http:80/TCP,metrics:9100.
+	VMTagKeyNamedPorts = "nephe-ports"
 )
@@ -15,7 +15,17 @@
 package utils
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	antreatypes "antrea.io/antrea/pkg/apis/crd/v1alpha2"
 	"antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/controllers/config"
 )
 
 // GetVMIPAddresses returns IP addresses of all network interfaces attached to the vm.
@@ -30,3 +40,57 @@ func GetVMIPAddresses(vm *v1alpha1.VirtualMachine) []v1alpha1.IPAddress {
 	}
 	return ips
 }
+
+// GetVMNamedPorts returns named ports declared by the vm in its nephe-ports tag.
+// Malformed entries are skipped and returned as error.
+func GetVMNamedPorts(vm *v1alpha1.VirtualMachine) ([]antreatypes.NamedPort, error) {
+	value, ok := vm.Status.Tags[config.VMTagKeyNamedPorts]
+	if !ok {
+		return nil, nil
+	}
+	var namedPorts []antreatypes.NamedPort
+	var err error
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		namedPort, e := parseNamedPort(entry)
+		if e != nil {
+			err = multierr.Append(err, e)
+			continue
+		}
+		namedPorts = append(namedPorts, *namedPort)
+	}
+	if err != nil {
+		err = fmt.Errorf("malformed tag %s: %w", config.VMTagKeyNamedPorts, err)
+	}
+	return namedPorts, err
+}
+
+// parseNamedPort parses a named port in the format of <name>:<port>[/<protocol>].
+// Protocol defaults to TCP.
+func parseNamedPort(entry string) (*antreatypes.NamedPort, error) {
+	fields := strings.SplitN(entry, ":", 2)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("%q: expect <name>:<port>[/<protocol>]", entry)
+	}
+	name := fields[0]
+	if errs := validation.IsValidPortName(name); len(errs) > 0 {
+		return nil, fmt.Errorf("%q: invalid port name: %s", entry, strings.Join(errs, ", "))
+	}
+	protocol := corev1.ProtocolTCP
+	portStr := fields[1]
+	if i := strings.Index(portStr, "/"); i >= 0 {
+		protocol = corev1.Protocol(strings.ToUpper(portStr[i+1:]))
+		portStr = portStr[:i]
+	}
+	if protocol != corev1.ProtocolTCP && protocol != corev1.ProtocolUDP && protocol != corev1.ProtocolSCTP {
+		return nil, fmt.Errorf("%q: unsupported protocol %s", entry, protocol)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || len(validation.IsValidPortNum(port)) > 0 {
+		return nil, fmt.Errorf("%q: invalid port number %s", entry, portStr)
+	}
+	return &antreatypes.NamedPort{Name: name, Port: int32(port), Protocol: protocol}, nil
+}
@@ -39,9 +39,11 @@ func (v *VirtualMachineSource) GetEndPointAddresses() ([]string, error) {
 	return ip, nil
 }
 
-// GetEndPointPort returns nil as VirtualMachine has no associated port.
+// GetEndPointPort returns named ports declared in VirtualMachine's nephe-ports tag.
+// Malformed entries are ignored, and are reported in VirtualMachine status.
 func (v *VirtualMachineSource) GetEndPointPort(_ client.Client) []antreatypes.NamedPort {
-	return nil
+	ports, _ := utils.GetVMNamedPorts(&v.VirtualMachine)
+	return ports
 }
 
 // GetTags returns tags of VirtualMachine.
@@ -51,7 +53,20 @@ func (v *VirtualMachineSource) GetTags() map[string]string {
 
 // GetLabelsFromClient returns VirtualMachine specific labels.
 func (v *VirtualMachineSource) GetLabelsFromClient(_ client.Client) map[string]string {
-	return map[string]string{config.ExternalEntityLabelCloudVPCKey: v.Status.VirtualPrivateCloud}
+	labels := map[string]string{config.ExternalEntityLabelCloudVPCKey: v.Status.VirtualPrivateCloud}
+	if len(v.Status.Region) > 0 {
+		labels[config.ExternalEntityLabelCloudRegionKey] = v.Status.Region
+	}
+	if len(v.Status.Zone) > 0 {
+		labels[config.ExternalEntityLabelCloudZoneKey] = v.Status.Zone
+	}
+	if len(v.Status.Subnet) > 0 {
+		labels[config.ExternalEntityLabelCloudSubnetKey] = v.Status.Subnet
+	}
+	if len(v.Status.OSType) > 0 {
+		labels[config.ExternalEntityLabelCloudOSKey] = string(v.Status.OSType)
+	}
+	return labels
 }
 
 // GetExternalNode returns external node/controller associated with VirtualMachine.
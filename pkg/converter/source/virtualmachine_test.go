@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -307,3 +308,23 @@ var _ = Describe("VirtualmachineConverter", func() {
 		})
 	})
 })
+
+var _ = Describe("VirtualMachineSource", func() {
+	table.DescribeTable("GetEndPointPort from named ports tag",
+		func(tag string, expected []antreatypes.NamedPort) {
+			vm := &source.VirtualMachineSource{}
+			vm.Status.Tags = map[string]string{config.VMTagKeyNamedPorts: tag}
+			Expect(vm.GetEndPointPort(nil)).To(Equal(expected))
+		},
+		table.Entry("With protocols", "http:80/TCP,dns:53/udp",
+			[]antreatypes.NamedPort{
+				{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP},
+				{Name: "dns", Port: 53, Protocol: corev1.ProtocolUDP},
+			}),
+		table.Entry("With default protocol", "metrics:9100",
+			[]antreatypes.NamedPort{{Name: "metrics", Port: 9100, Protocol: corev1.ProtocolTCP}}),
+		table.Entry("With malformed entries", "http:80,bad,ssh:70000,web:8080/ICMP",
+			[]antreatypes.NamedPort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}}),
+		table.Entry("With all entries malformed", "http", nil),
+	)
+})
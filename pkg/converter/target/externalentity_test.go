@@ -28,6 +28,7 @@ import (
 	antreatypes "antrea.io/antrea/pkg/apis/crd/v1alpha2"
 
 	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/controllers/config"
 	"antrea.io/nephe/pkg/testing"
 	"antrea.io/nephe/pkg/testing/controllerruntimeclient"
 )
@@ -180,4 +181,22 @@ var _ = Describe("Externalentity", func() {
 			table.Entry("VirtualMachine", "VirtualMachine", false))
 	})
 
+	Context("Source has cloud placement information", func() {
+		It("Should generate well known labels of VirtualMachine placement", func() {
+			externalEntitySource := externalEntitySources["VirtualMachine"]
+			labels := externalEntitySource.GetLabelsFromClient(mockclient)
+			Expect(labels).ToNot(HaveKey(config.ExternalEntityLabelCloudZoneKey))
+			Expect(labels).ToNot(HaveKey(config.ExternalEntityLabelCloudSubnetKey))
+			Expect(labels).ToNot(HaveKey(config.ExternalEntityLabelCloudOSKey))
+
+			vm := externalEntitySource.EmbedType().(*cloud.VirtualMachine)
+			vm.Status.Zone = "us-east-1a"
+			vm.Status.Subnet = "subnet-01"
+			vm.Status.OSType = cloud.Windows
+			labels = externalEntitySource.GetLabelsFromClient(mockclient)
+			Expect(labels).To(HaveKeyWithValue(config.ExternalEntityLabelCloudZoneKey, "us-east-1a"))
+			Expect(labels).To(HaveKeyWithValue(config.ExternalEntityLabelCloudSubnetKey, "subnet-01"))
+			Expect(labels).To(HaveKeyWithValue(config.ExternalEntityLabelCloudOSKey, "windows"))
+		})
+	})
 })
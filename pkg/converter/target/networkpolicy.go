@@ -0,0 +1,273 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	antreacrd "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+	cloud "antrea.io/nephe/apis/crd/v1alpha1"
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/controllers/config"
+)
+
+const (
+	// ImportedNetworkPolicyNamePrefix is the name prefix of Antrea NetworkPolicies imported from cloud SecurityGroups.
+	ImportedNetworkPolicyNamePrefix = "import-"
+	// ImportedNetworkPolicyPriority is the priority of Antrea NetworkPolicies imported from cloud SecurityGroups.
+	ImportedNetworkPolicyPriority = 1
+
+	networkPolicyNameSizeLimit  = 253
+	networkPolicyNameExpression = "[^a-z0-9.-]+"
+)
+
+var importedPortProtocols = map[int]corev1.Protocol{
+	securitygroup.ProtocolNameNumMap["tcp"]: corev1.ProtocolTCP,
+	securitygroup.ProtocolNameNumMap["udp"]: corev1.ProtocolUDP,
+	132:                                     corev1.ProtocolSCTP,
+}
+
+// NetworkPoliciesFromSecurityGroups returns Antrea NetworkPolicies in namespace equivalent to cloud SecurityGroups, each
+// applied to ExternalEntities of the VirtualMachine members of a SecurityGroup, and descriptions of settings of the
+// SecurityGroups the NetworkPolicies do not represent. Like cloud SecurityGroups, members of the NetworkPolicies enforced
+// by nephe drop traffic not allowed by any rule. ExternalEntities have no label of SecurityGroup membership, so current
+// members of a SecurityGroup are selected by name, which is reported for every SecurityGroup selected.
+func NetworkPoliciesFromSecurityGroups(namespace string, groups []securitygroup.ImportContent) ([]*antreacrd.NetworkPolicy,
+	[]string) {
+	groupMembers := make(map[securitygroup.CloudResourceID][]string)
+	for _, group := range groups {
+		groupMembers[group.Resource] = getVirtualMachineLabelValues(group.Members)
+	}
+
+	var policies []*antreacrd.NetworkPolicy
+	var unsupported []string
+	policyNames := make(map[string]struct{})
+	selectedGroups := make(map[securitygroup.CloudResourceID]struct{})
+	for _, group := range groups {
+		report := func(format string, args ...interface{}) {
+			unsupported = append(unsupported, fmt.Sprintf("security group %v: %v", group.Resource.Name, fmt.Sprintf(format, args...)))
+		}
+		for _, msg := range group.Unsupported {
+			report("%v", msg)
+		}
+		members := groupMembers[group.Resource]
+		if group.MembershipOnly || len(members) == 0 {
+			continue
+		}
+
+		policy := &antreacrd.NetworkPolicy{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "NetworkPolicy",
+				APIVersion: "crd.antrea.io/v1alpha1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getImportedNetworkPolicyName(group.Resource.Name, policyNames),
+				Namespace: namespace,
+			},
+			Spec: antreacrd.NetworkPolicySpec{
+				Priority:  ImportedNetworkPolicyPriority,
+				AppliedTo: []antreacrd.NetworkPolicyPeer{{ExternalEntitySelector: getVirtualMachineSelector(members)}},
+			},
+		}
+
+		ingressRules := append([]securitygroup.IngressRule{}, group.IngressRules...)
+		sort.SliceStable(ingressRules, func(i, j int) bool {
+			return ingressRules[i].Priority.Less(ingressRules[j].Priority)
+		})
+		for _, rule := range ingressRules {
+			antreaRule, err := convertToImportedRule(rule.Protocol, rule.FromPort, rule.FromEndPort, rule.ICMPType, rule.ICMPCode,
+				rule.Action)
+			if err != nil {
+				report("ingress rule %v", err)
+				continue
+			}
+			peers, peersUnsupported := convertToImportedPeers(rule.FromSrcIP, rule.FromSecurityGroups, groupMembers,
+				selectedGroups)
+			for _, msg := range peersUnsupported {
+				report("ingress rule %v", msg)
+			}
+			if len(peers) == 0 {
+				continue
+			}
+			antreaRule.From = peers
+			policy.Spec.Ingress = append(policy.Spec.Ingress, *antreaRule)
+		}
+
+		egressRules := append([]securitygroup.EgressRule{}, group.EgressRules...)
+		sort.SliceStable(egressRules, func(i, j int) bool {
+			return egressRules[i].Priority.Less(egressRules[j].Priority)
+		})
+		for _, rule := range egressRules {
+			antreaRule, err := convertToImportedRule(rule.Protocol, rule.ToPort, rule.ToEndPort, rule.ICMPType, rule.ICMPCode,
+				rule.Action)
+			if err != nil {
+				report("egress rule %v", err)
+				continue
+			}
+			peers, peersUnsupported := convertToImportedPeers(rule.ToDstIP, rule.ToSecurityGroups, groupMembers, selectedGroups)
+			for _, msg := range peersUnsupported {
+				report("egress rule %v", msg)
+			}
+			if len(peers) == 0 {
+				continue
+			}
+			antreaRule.To = peers
+			policy.Spec.Egress = append(policy.Spec.Egress, *antreaRule)
+		}
+
+		// members of SecurityGroup without rules to import are left without NetworkPolicy, which is reported.
+		if len(policy.Spec.Ingress) == 0 && len(policy.Spec.Egress) == 0 {
+			report("has no rules to import")
+			continue
+		}
+		policies = append(policies, policy)
+		selectedGroups[group.Resource] = struct{}{}
+	}
+	for _, group := range groups {
+		if _, found := selectedGroups[group.Resource]; found {
+			unsupported = append(unsupported, fmt.Sprintf("security group %v: membership is not imported, "+
+				"virtual machine members %v are selected by name", group.Resource.Name, groupMembers[group.Resource]))
+		}
+	}
+	return policies, unsupported
+}
+
+// convertToImportedRule returns Antrea NetworkPolicy rule without peers matching protocol and ports of a cloud rule.
+func convertToImportedRule(protocol, port, endPort, icmpType, icmpCode *int, action securitygroup.RuleAction) (*antreacrd.Rule,
+	error) {
+	ruleAction := antreacrd.RuleActionAllow
+	if action == securitygroup.RuleActionDrop {
+		ruleAction = antreacrd.RuleActionDrop
+	} else if action == securitygroup.RuleActionReject {
+		ruleAction = antreacrd.RuleActionReject
+	}
+	rule := &antreacrd.Rule{Action: &ruleAction}
+	if protocol == nil {
+		return rule, nil
+	}
+
+	if *protocol == securitygroup.ProtocolNameNumMap["icmp"] {
+		icmp := &antreacrd.ICMPProtocol{}
+		if icmpType != nil {
+			icmp.ICMPType = int32Pointer(*icmpType)
+			if icmpCode != nil {
+				icmp.ICMPCode = int32Pointer(*icmpCode)
+			}
+		}
+		rule.Protocols = []antreacrd.NetworkPolicyProtocol{{ICMP: icmp}}
+		return rule, nil
+	}
+	portProtocol, found := importedPortProtocols[*protocol]
+	if !found {
+		return nil, fmt.Errorf("of protocol %v cannot be imported", *protocol)
+	}
+	policyPort := antreacrd.NetworkPolicyPort{Protocol: &portProtocol}
+	if port != nil {
+		portValue := intstr.FromInt(*port)
+		policyPort.Port = &portValue
+		if endPort != nil {
+			policyPort.EndPort = int32Pointer(*endPort)
+		}
+	}
+	rule.Ports = []antreacrd.NetworkPolicyPort{policyPort}
+	return rule, nil
+}
+
+// convertToImportedPeers returns Antrea NetworkPolicy peers of cloud rule IP blocks and SecurityGroups, SecurityGroups
+// are matched by ExternalEntities of their VirtualMachine members in groupMembers, and added to selectedGroups.
+func convertToImportedPeers(ipNets []*net.IPNet, groups []*securitygroup.CloudResourceID,
+	groupMembers map[securitygroup.CloudResourceID][]string,
+	selectedGroups map[securitygroup.CloudResourceID]struct{}) ([]antreacrd.NetworkPolicyPeer, []string) {
+	var peers []antreacrd.NetworkPolicyPeer
+	var unsupported []string
+	for _, ipNet := range ipNets {
+		peers = append(peers, antreacrd.NetworkPolicyPeer{IPBlock: &antreacrd.IPBlock{CIDR: ipNet.String()}})
+	}
+	for _, group := range groups {
+		members := groupMembers[*group]
+		if len(members) == 0 {
+			unsupported = append(unsupported, fmt.Sprintf("refers to security group %v with no virtual machine members",
+				group.Name))
+			continue
+		}
+		peers = append(peers, antreacrd.NetworkPolicyPeer{ExternalEntitySelector: getVirtualMachineSelector(members)})
+		selectedGroups[*group] = struct{}{}
+	}
+	return peers, unsupported
+}
+
+// getVirtualMachineLabelValues returns sorted unique ExternalEntity name label values of VirtualMachine members.
+func getVirtualMachineLabelValues(members []securitygroup.CloudResource) []string {
+	nameSet := make(map[string]struct{})
+	for _, member := range members {
+		if member.Type != securitygroup.CloudResourceTypeVM || len(member.Name.Name) == 0 {
+			continue
+		}
+		nameSet[strings.ToLower(member.Name.Name)] = struct{}{}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getVirtualMachineSelector returns selector of ExternalEntities of VirtualMachines with names.
+func getVirtualMachineSelector(names []string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			config.ExternalEntityLabelKeyKind: GetExternalEntityLabelKind(&cloud.VirtualMachine{}),
+		},
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      config.ExternalEntityLabelKeyName,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   names,
+		}},
+	}
+}
+
+// getImportedNetworkPolicyName returns a valid Antrea NetworkPolicy name of a SecurityGroup not in usedNames, and adds it
+// to usedNames.
+func getImportedNetworkPolicyName(groupName string, usedNames map[string]struct{}) string {
+	reg, _ := regexp.Compile(networkPolicyNameExpression)
+	name := strings.Trim(reg.ReplaceAllString(strings.ToLower(groupName), "-"), "-.")
+	name = ImportedNetworkPolicyNamePrefix + name
+	if len(name) > networkPolicyNameSizeLimit {
+		name = name[:networkPolicyNameSizeLimit]
+	}
+	uniqueName := name
+	for i := 2; ; i++ {
+		if _, found := usedNames[uniqueName]; !found {
+			break
+		}
+		uniqueName = fmt.Sprintf("%v-%d", name, i)
+	}
+	usedNames[uniqueName] = struct{}{}
+	return uniqueName
+}
+
+func int32Pointer(v int) *int32 {
+	ret := int32(v)
+	return &ret
+}
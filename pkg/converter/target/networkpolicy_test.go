@@ -0,0 +1,169 @@
+// Copyright 2022 Antrea Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target_test
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	antreacrd "antrea.io/antrea/pkg/apis/crd/v1alpha1"
+
+	"antrea.io/nephe/pkg/cloud-provider/securitygroup"
+	"antrea.io/nephe/pkg/controllers/config"
+	converter "antrea.io/nephe/pkg/converter/target"
+)
+
+var _ = Describe("NetworkPolicy", func() {
+	var (
+		namespace = "test-networkpolicy-namespace"
+		vpcID     = "vpc-01"
+		webGroup  = securitygroup.CloudResourceID{Name: "Web SG", Vpc: vpcID}
+		dbGroup   = securitygroup.CloudResourceID{Name: "db", Vpc: vpcID}
+		lbGroup   = securitygroup.CloudResourceID{Name: "lb", Vpc: vpcID}
+	)
+
+	vmMembers := func(names ...string) []securitygroup.CloudResource {
+		var members []securitygroup.CloudResource
+		for _, name := range names {
+			members = append(members, securitygroup.CloudResource{
+				Type: securitygroup.CloudResourceTypeVM,
+				Name: securitygroup.CloudResourceID{Name: name, Vpc: vpcID},
+			})
+		}
+		return members
+	}
+	vmSelector := func(names ...string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{
+			MatchLabels: map[string]string{config.ExternalEntityLabelKeyKind: "virtualmachine"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: config.ExternalEntityLabelKeyName, Operator: metav1.LabelSelectorOpIn, Values: names},
+			},
+		}
+	}
+	intPtr := func(v int) *int {
+		return &v
+	}
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/16")
+
+	It("Should convert security groups to NetworkPolicies selecting virtual machines", func() {
+		groups := []securitygroup.ImportContent{
+			{
+				Resource: webGroup,
+				Members:  vmMembers("I-WEB2", "i-web1", "i-web2"),
+				IngressRules: []securitygroup.IngressRule{
+					{Protocol: intPtr(6), FromPort: intPtr(8000), FromEndPort: intPtr(8080), FromSrcIP: []*net.IPNet{cidr}},
+					{Protocol: intPtr(1), ICMPType: intPtr(8), FromSecurityGroups: []*securitygroup.CloudResourceID{&dbGroup}},
+				},
+				EgressRules: []securitygroup.EgressRule{{ToSecurityGroups: []*securitygroup.CloudResourceID{&dbGroup}}},
+			},
+			{Resource: dbGroup, Members: vmMembers("i-db")},
+		}
+		policies, unsupported := converter.NetworkPoliciesFromSecurityGroups(namespace, groups)
+		Expect(policies).To(HaveLen(1))
+		Expect(unsupported).To(ConsistOf(
+			"security group db: has no rules to import",
+			"security group Web SG: membership is not imported, virtual machine members [i-web1 i-web2] are selected by name",
+			"security group db: membership is not imported, virtual machine members [i-db] are selected by name",
+		))
+
+		policy := policies[0]
+		Expect(policy.Name).To(Equal("import-web-sg"))
+		Expect(policy.Namespace).To(Equal(namespace))
+		Expect(policy.Spec.AppliedTo).To(Equal([]antreacrd.NetworkPolicyPeer{{ExternalEntitySelector: vmSelector("i-web1", "i-web2")}}))
+		Expect(policy.Spec.Ingress).To(HaveLen(2))
+		Expect(*policy.Spec.Ingress[0].Action).To(Equal(antreacrd.RuleActionAllow))
+		Expect(policy.Spec.Ingress[0].Ports).To(HaveLen(1))
+		Expect(*policy.Spec.Ingress[0].Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+		Expect(policy.Spec.Ingress[0].Ports[0].Port.IntValue()).To(Equal(8000))
+		Expect(*policy.Spec.Ingress[0].Ports[0].EndPort).To(Equal(int32(8080)))
+		Expect(policy.Spec.Ingress[0].From).To(Equal([]antreacrd.NetworkPolicyPeer{{IPBlock: &antreacrd.IPBlock{CIDR: "10.0.0.0/16"}}}))
+		Expect(policy.Spec.Ingress[1].Protocols).To(HaveLen(1))
+		Expect(*policy.Spec.Ingress[1].Protocols[0].ICMP.ICMPType).To(Equal(int32(8)))
+		Expect(policy.Spec.Ingress[1].Protocols[0].ICMP.ICMPCode).To(BeNil())
+		Expect(policy.Spec.Ingress[1].From).To(Equal([]antreacrd.NetworkPolicyPeer{{ExternalEntitySelector: vmSelector("i-db")}}))
+		Expect(policy.Spec.Egress).To(HaveLen(1))
+		Expect(policy.Spec.Egress[0].Ports).To(BeEmpty())
+		Expect(policy.Spec.Egress[0].To).To(Equal([]antreacrd.NetworkPolicyPeer{{ExternalEntitySelector: vmSelector("i-db")}}))
+	})
+
+	It("Should order rules by priority and keep deny actions", func() {
+		groups := []securitygroup.ImportContent{{
+			Resource: webGroup,
+			Members:  vmMembers("vm"),
+			IngressRules: []securitygroup.IngressRule{
+				{Protocol: intPtr(6), FromPort: intPtr(22), FromSrcIP: []*net.IPNet{cidr},
+					Priority: &securitygroup.RulePriority{RuleIndex: 200}},
+				{FromSrcIP: []*net.IPNet{cidr}, Action: securitygroup.RuleActionDrop,
+					Priority: &securitygroup.RulePriority{RuleIndex: 100}},
+			},
+		}}
+		policies, unsupported := converter.NetworkPoliciesFromSecurityGroups(namespace, groups)
+		Expect(unsupported).To(ConsistOf(
+			"security group Web SG: membership is not imported, virtual machine members [vm] are selected by name"))
+		Expect(policies).To(HaveLen(1))
+		Expect(policies[0].Spec.Ingress).To(HaveLen(2))
+		Expect(*policies[0].Spec.Ingress[0].Action).To(Equal(antreacrd.RuleActionDrop))
+		Expect(*policies[0].Spec.Ingress[1].Action).To(Equal(antreacrd.RuleActionAllow))
+		Expect(policies[0].Spec.Ingress[1].Ports[0].Port.IntValue()).To(Equal(22))
+		Expect(policies[0].Spec.Ingress[1].Ports[0].EndPort).To(BeNil())
+	})
+
+	It("Should report settings that cannot be imported", func() {
+		groups := []securitygroup.ImportContent{
+			{
+				Resource: webGroup,
+				Members:  vmMembers("vm"),
+				IngressRules: []securitygroup.IngressRule{
+					{Protocol: intPtr(58), FromSrcIP: []*net.IPNet{cidr}},
+					{Protocol: intPtr(6), FromSecurityGroups: []*securitygroup.CloudResourceID{&lbGroup, &dbGroup}},
+				},
+				Unsupported: []string{"attached to subnet subnet-01"},
+			},
+			{Resource: dbGroup, MembershipOnly: true, Members: vmMembers("db")},
+			{Resource: lbGroup},
+		}
+		policies, unsupported := converter.NetworkPoliciesFromSecurityGroups(namespace, groups)
+		Expect(unsupported).To(ConsistOf(
+			"security group Web SG: attached to subnet subnet-01",
+			"security group Web SG: ingress rule of protocol 58 cannot be imported",
+			"security group Web SG: ingress rule refers to security group lb with no virtual machine members",
+			"security group Web SG: membership is not imported, virtual machine members [vm] are selected by name",
+			"security group db: membership is not imported, virtual machine members [db] are selected by name",
+		))
+		Expect(policies).To(HaveLen(1))
+		Expect(policies[0].Spec.Ingress).To(HaveLen(1))
+		Expect(policies[0].Spec.Ingress[0].From).To(Equal([]antreacrd.NetworkPolicyPeer{{ExternalEntitySelector: vmSelector("db")}}))
+	})
+
+	It("Should generate unique NetworkPolicy names", func() {
+		rules := []securitygroup.EgressRule{{ToDstIP: []*net.IPNet{cidr}}}
+		groups := []securitygroup.ImportContent{
+			{Resource: securitygroup.CloudResourceID{Name: "web_sg", Vpc: vpcID}, Members: vmMembers("vm1"), EgressRules: rules},
+			{Resource: securitygroup.CloudResourceID{Name: "web.sg", Vpc: vpcID}, Members: vmMembers("vm2"), EgressRules: rules},
+			{Resource: securitygroup.CloudResourceID{Name: "Web-SG", Vpc: vpcID}, Members: vmMembers("vm3"), EgressRules: rules},
+		}
+		policies, unsupported := converter.NetworkPoliciesFromSecurityGroups(namespace, groups)
+		Expect(unsupported).To(HaveLen(3))
+		Expect(unsupported[0]).To(ContainSubstring("membership is not imported"))
+		Expect(policies).To(HaveLen(3))
+		Expect(policies[0].Name).To(Equal("import-web-sg"))
+		Expect(policies[1].Name).To(Equal("import-web.sg"))
+		Expect(policies[2].Name).To(Equal("import-web-sg-2"))
+	})
+})
@@ -24,6 +24,7 @@ import (
 
 	securitygroup "antrea.io/nephe/pkg/cloud-provider/securitygroup"
 	gomock "github.com/golang/mock/gomock"
+	types "k8s.io/apimachinery/pkg/types"
 )
 
 // MockCloudSecurityGroupAPI is a mock of CloudSecurityGroupAPI interface.
@@ -77,6 +78,34 @@ func (mr *MockCloudSecurityGroupAPIMockRecorder) DeleteSecurityGroup(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecurityGroup", reflect.TypeOf((*MockCloudSecurityGroupAPI)(nil).DeleteSecurityGroup), arg0, arg1)
 }
 
+// GetAccountSecurityGroupSyncChan mocks base method.
+func (m *MockCloudSecurityGroupAPI) GetAccountSecurityGroupSyncChan(arg0 *types.NamespacedName) <-chan securitygroup.SynchronizationContent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountSecurityGroupSyncChan", arg0)
+	ret0, _ := ret[0].(<-chan securitygroup.SynchronizationContent)
+	return ret0
+}
+
+// GetAccountSecurityGroupSyncChan indicates an expected call of GetAccountSecurityGroupSyncChan.
+func (mr *MockCloudSecurityGroupAPIMockRecorder) GetAccountSecurityGroupSyncChan(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountSecurityGroupSyncChan", reflect.TypeOf((*MockCloudSecurityGroupAPI)(nil).GetAccountSecurityGroupSyncChan), arg0)
+}
+
+// GetSecurityGroupAccount mocks base method.
+func (m *MockCloudSecurityGroupAPI) GetSecurityGroupAccount(arg0 *securitygroup.CloudResourceID) *types.NamespacedName {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecurityGroupAccount", arg0)
+	ret0, _ := ret[0].(*types.NamespacedName)
+	return ret0
+}
+
+// GetSecurityGroupAccount indicates an expected call of GetSecurityGroupAccount.
+func (mr *MockCloudSecurityGroupAPIMockRecorder) GetSecurityGroupAccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecurityGroupAccount", reflect.TypeOf((*MockCloudSecurityGroupAPI)(nil).GetSecurityGroupAccount), arg0)
+}
+
 // GetSecurityGroupSyncChan mocks base method.
 func (m *MockCloudSecurityGroupAPI) GetSecurityGroupSyncChan() <-chan securitygroup.SynchronizationContent {
 	m.ctrl.T.Helper()
@@ -91,6 +120,20 @@ func (mr *MockCloudSecurityGroupAPIMockRecorder) GetSecurityGroupSyncChan() *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecurityGroupSyncChan", reflect.TypeOf((*MockCloudSecurityGroupAPI)(nil).GetSecurityGroupSyncChan))
 }
 
+// IsRuleActionSupported mocks base method.
+func (m *MockCloudSecurityGroupAPI) IsRuleActionSupported(arg0 *securitygroup.CloudResourceID, arg1 securitygroup.RuleAction) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRuleActionSupported", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRuleActionSupported indicates an expected call of IsRuleActionSupported.
+func (mr *MockCloudSecurityGroupAPIMockRecorder) IsRuleActionSupported(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRuleActionSupported", reflect.TypeOf((*MockCloudSecurityGroupAPI)(nil).IsRuleActionSupported), arg0, arg1)
+}
+
 // UpdateSecurityGroupMembers mocks base method.
 func (m *MockCloudSecurityGroupAPI) UpdateSecurityGroupMembers(arg0 *securitygroup.CloudResourceID, arg1 []*securitygroup.CloudResource, arg2 bool) <-chan error {
 	m.ctrl.T.Helper()
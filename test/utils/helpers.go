@@ -244,6 +244,9 @@ func ConfigureEntitySelectorAndWait(
 	return nil
 }
 
+// anpStatusKeyPrefix is the prefix of Antrea NetworkPolicy names in VirtualMachinePolicy status.
+const anpStatusKeyPrefix = "AntreaNetworkPolicy:"
+
 // CheckCloudResourceNetworkPolicies checks NetworkPolicies has been applied to cloud resources.
 func CheckCloudResourceNetworkPolicies(k8sClient client.Client, kind, namespace string, ids []string, anps []string) error {
 	getVMANPs := func(id string) (map[string]*runtimev1alpha1.NetworkPolicyStatus, error) {
@@ -277,7 +280,7 @@ func CheckCloudResourceNetworkPolicies(k8sClient client.Client, kind, namespace
 				return false, nil
 			}
 			for _, a := range anps {
-				v, ok := npv[a]
+				v, ok := npv[anpStatusKeyPrefix+a]
 				if !ok {
 					return false, nil
 				}